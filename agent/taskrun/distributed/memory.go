@@ -0,0 +1,172 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps records in memory. Services sharing one MemoryStore
+// behave like replicas sharing a database, which is useful for tests.
+//
+// Records are kept JSON-encoded so that they go through the same
+// serialization as in external stores.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+// NewMemoryStore creates an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string][]byte)}
+}
+
+// Create implements Store.
+func (s *MemoryStore) Create(ctx context.Context, rec *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[rec.Run.ID]; ok {
+		return taskrun.ErrRunAlreadyExists
+	}
+	stored := *rec
+	stored.Version = 1
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	s.records[rec.Run.ID] = data
+	rec.Version = stored.Version
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, runID string) (*Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.records[strings.TrimSpace(runID)]
+	if !ok {
+		return nil, taskrun.ErrRunNotFound
+	}
+	return decodeRecord(data)
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(ctx context.Context, rec *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.records[rec.Run.ID]
+	if !ok {
+		return taskrun.ErrRunNotFound
+	}
+	current, err := decodeRecord(data)
+	if err != nil {
+		return err
+	}
+	if current.Version != rec.Version {
+		return ErrConflict
+	}
+	stored := *rec
+	stored.Version++
+	if data, err = json.Marshal(&stored); err != nil {
+		return err
+	}
+	s.records[rec.Run.ID] = data
+	rec.Version = stored.Version
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(
+	ctx context.Context,
+	filter taskrun.ListFilter,
+) ([]*Record, error) {
+	return s.scan(ctx, func(rec *Record) bool {
+		return MatchesFilter(rec.Run, filter)
+	})
+}
+
+// Leasable implements Store.
+func (s *MemoryStore) Leasable(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*Record, error) {
+	records, err := s.scan(ctx, func(rec *Record) bool {
+		return rec.Leasable(now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i int, j int) bool {
+		return records[i].Run.CreatedAt.Before(records[j].Run.CreatedAt)
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// UpdatedSince implements Store.
+func (s *MemoryStore) UpdatedSince(
+	ctx context.Context,
+	since time.Time,
+) ([]*Record, error) {
+	return s.scan(ctx, func(rec *Record) bool {
+		return !rec.Run.UpdatedAt.Before(since)
+	})
+}
+
+func (s *MemoryStore) scan(
+	ctx context.Context,
+	match func(rec *Record) bool,
+) ([]*Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []*Record
+	for _, data := range s.records {
+		rec, err := decodeRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		if match(rec) {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+func decodeRecord(data []byte) (*Record, error) {
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
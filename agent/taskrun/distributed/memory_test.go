@@ -0,0 +1,83 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package distributed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+	newRecord := func(id string, created time.Time) *Record {
+		return &Record{Run: taskrun.Run{
+			ID:          id,
+			OwnerUserID: "user",
+			Status:      taskrun.StatusQueued,
+			CreatedAt:   created,
+			UpdatedAt:   created,
+		}}
+	}
+
+	second := newRecord("second", now)
+	require.NoError(t, store.Create(ctx, second))
+	assert.Equal(t, int64(1), second.Version)
+	first := newRecord("first", now.Add(-time.Minute))
+	require.NoError(t, store.Create(ctx, first))
+	assert.ErrorIs(t, store.Create(ctx, newRecord("first", now)),
+		taskrun.ErrRunAlreadyExists)
+
+	leasable, err := store.Leasable(ctx, now, 0)
+	require.NoError(t, err)
+	require.Len(t, leasable, 2)
+	assert.Equal(t, "first", leasable[0].Run.ID)
+
+	stale, err := store.Get(ctx, "first")
+	require.NoError(t, err)
+	first.LeaseOwner = "worker"
+	first.LeaseExpiresAt = now.Add(time.Minute)
+	first.Run.Status = taskrun.StatusRunning
+	require.NoError(t, store.Update(ctx, first))
+	assert.Equal(t, int64(2), first.Version)
+	assert.ErrorIs(t, store.Update(ctx, stale), ErrConflict)
+
+	leasable, err = store.Leasable(ctx, now, 1)
+	require.NoError(t, err)
+	require.Len(t, leasable, 1)
+	assert.Equal(t, "second", leasable[0].Run.ID)
+	leasable, err = store.Leasable(ctx, now.Add(2*time.Minute), 0)
+	require.NoError(t, err)
+	assert.Len(t, leasable, 2)
+
+	updated, err := store.UpdatedSince(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, "second", updated[0].Run.ID)
+
+	running, err := store.List(ctx, taskrun.ListFilter{
+		Status: taskrun.StatusRunning,
+	})
+	require.NoError(t, err)
+	require.Len(t, running, 1)
+	assert.Equal(t, "worker", running[0].LeaseOwner)
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, taskrun.ErrRunNotFound)
+	assert.ErrorIs(t, store.Update(ctx, newRecord("missing", now)),
+		taskrun.ErrRunNotFound)
+}
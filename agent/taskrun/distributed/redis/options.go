@@ -0,0 +1,62 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package redis
+
+const defaultKeyPrefix = "{taskrun}:"
+
+var defaultOptions = Options{
+	keyPrefix: defaultKeyPrefix,
+}
+
+// Options is the options for the redis taskrun store.
+type Options struct {
+	url          string
+	instanceName string
+	extraOptions []any
+	keyPrefix    string
+}
+
+// Option is the option for the redis taskrun store.
+type Option func(*Options)
+
+// WithRedisClientURL creates a redis client from URL and sets it to the store.
+func WithRedisClientURL(url string) Option {
+	return func(opts *Options) {
+		opts.url = url
+	}
+}
+
+// WithRedisInstance uses a redis instance from storage.
+// Note: WithRedisClientURL has higher priority than WithRedisInstance.
+// If both are specified, WithRedisClientURL will be used.
+func WithRedisInstance(instanceName string) Option {
+	return func(opts *Options) {
+		opts.instanceName = instanceName
+	}
+}
+
+// WithExtraOptions sets the extra options for the redis taskrun store.
+// this option mainly used for the customized redis client builder, it will be passed to the builder.
+func WithExtraOptions(extraOptions ...any) Option {
+	return func(opts *Options) {
+		opts.extraOptions = append(opts.extraOptions, extraOptions...)
+	}
+}
+
+// WithKeyPrefix sets the prefix of every key written by the store, so that
+// several applications can share one redis database. All keys of a store
+// are updated together by scripts, so on a redis cluster the prefix must be
+// a hash tag such as "{myapp-taskrun}:".
+// Default is "{taskrun}:".
+func WithKeyPrefix(prefix string) Option {
+	return func(opts *Options) {
+		opts.keyPrefix = prefix
+	}
+}
@@ -0,0 +1,368 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package redis provides a Redis-backed distributed.Store for task runs.
+//
+// Every replica connected to the same redis shares the runs. Each run is a
+// hash holding the JSON record and its version; sorted sets index the runs
+// for leasing and update polling:
+//
+//	{prefix}run:{id}        hash of data and version
+//	{prefix}leasable        run ids scored by lease expiry, 0 when unleased
+//	{prefix}updated         run ids scored by last lifecycle update
+//	{prefix}owner:{user}    set of run ids of one owner
+//
+// Terminal runs leave the leasable set. Scores are in milliseconds.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun/distributed"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+var luaCreate = redis.NewScript(`
+-- Store a new run and index it.
+--
+-- KEYS[1] = run key
+-- KEYS[2] = leasable index key
+-- KEYS[3] = updated index key
+-- KEYS[4] = owner index key
+--
+-- ARGV[1] = record data
+-- ARGV[2] = run id
+-- ARGV[3] = lease score, or -1 when the run is terminal
+-- ARGV[4] = updated score
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[1], 'version', 1)
+if tonumber(ARGV[3]) >= 0 then
+  redis.call('ZADD', KEYS[2], ARGV[3], ARGV[2])
+end
+redis.call('ZADD', KEYS[3], ARGV[4], ARGV[2])
+redis.call('SADD', KEYS[4], ARGV[2])
+return 1
+`)
+
+var luaUpdate = redis.NewScript(`
+-- Replace a run when its version matches and reindex it.
+--
+-- KEYS[1] = run key
+-- KEYS[2] = leasable index key
+-- KEYS[3] = updated index key
+--
+-- ARGV[1] = record data
+-- ARGV[2] = run id
+-- ARGV[3] = lease score, or -1 when the run is terminal
+-- ARGV[4] = updated score
+-- ARGV[5] = expected version
+--
+-- Returns the new version, 0 on a version conflict and -1 when the run does
+-- not exist.
+local version = redis.call('HGET', KEYS[1], 'version')
+if not version then
+  return -1
+end
+if version ~= ARGV[5] then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[1])
+local next = redis.call('HINCRBY', KEYS[1], 'version', 1)
+if tonumber(ARGV[3]) >= 0 then
+  redis.call('ZADD', KEYS[2], ARGV[3], ARGV[2])
+else
+  redis.call('ZREM', KEYS[2], ARGV[2])
+end
+redis.call('ZADD', KEYS[3], ARGV[4], ARGV[2])
+return next
+`)
+
+var _ distributed.Store = (*Store)(nil)
+
+// Store is a distributed.Store backed by redis.
+type Store struct {
+	opts   Options
+	client redis.UniversalClient
+}
+
+// NewStore creates a new redis taskrun store.
+func NewStore(options ...Option) (*Store, error) {
+	opts := defaultOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	builderOpts := []storage.ClientBuilderOpt{
+		storage.WithClientBuilderURL(opts.url),
+		storage.WithExtraOptions(opts.extraOptions...),
+	}
+
+	// if instance name set, and url not set, use instance name to create redis client
+	if opts.url == "" && opts.instanceName != "" {
+		var ok bool
+		if builderOpts, ok = storage.GetRedisInstance(opts.instanceName); !ok {
+			return nil, fmt.Errorf("redis instance %s not found", opts.instanceName)
+		}
+	}
+
+	redisClient, err := storage.GetClientBuilder()(builderOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create redis client from url failed: %w", err)
+	}
+	return &Store{opts: opts, client: redisClient}, nil
+}
+
+// Create implements distributed.Store.
+func (s *Store) Create(ctx context.Context, rec *distributed.Record) error {
+	stored := *rec
+	stored.Version = 1
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("encode run: %w", err)
+	}
+	keys := []string{
+		s.runKey(rec.Run.ID),
+		s.leasableKey(),
+		s.updatedKey(),
+		s.ownerKey(rec.Run.OwnerUserID),
+	}
+	created, err := luaCreate.Run(ctx, s.client, keys,
+		data, rec.Run.ID, leaseScore(&stored), stored.Run.UpdatedAt.UnixMilli(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("create run: %w", err)
+	}
+	if created == 0 {
+		return taskrun.ErrRunAlreadyExists
+	}
+	rec.Version = stored.Version
+	return nil
+}
+
+// Get implements distributed.Store.
+func (s *Store) Get(ctx context.Context, runID string) (*distributed.Record, error) {
+	values, err := s.client.HMGet(ctx, s.runKey(runID), "data", "version").Result()
+	if err != nil {
+		return nil, fmt.Errorf("get run: %w", err)
+	}
+	rec, err := decodeRecord(values)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, taskrun.ErrRunNotFound
+	}
+	return rec, nil
+}
+
+// Update implements distributed.Store.
+func (s *Store) Update(ctx context.Context, rec *distributed.Record) error {
+	stored := *rec
+	stored.Version++
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("encode run: %w", err)
+	}
+	keys := []string{s.runKey(rec.Run.ID), s.leasableKey(), s.updatedKey()}
+	version, err := luaUpdate.Run(ctx, s.client, keys,
+		data, rec.Run.ID, leaseScore(&stored), stored.Run.UpdatedAt.UnixMilli(),
+		strconv.FormatInt(rec.Version, 10),
+	).Int64()
+	if err != nil {
+		return fmt.Errorf("update run: %w", err)
+	}
+	switch version {
+	case -1:
+		return taskrun.ErrRunNotFound
+	case 0:
+		return distributed.ErrConflict
+	}
+	rec.Version = version
+	return nil
+}
+
+// List implements distributed.Store.
+func (s *Store) List(
+	ctx context.Context,
+	filter taskrun.ListFilter,
+) ([]*distributed.Record, error) {
+	var (
+		ids []string
+		err error
+	)
+	if filter.OwnerUserID != "" {
+		ids, err = s.client.SMembers(ctx, s.ownerKey(filter.OwnerUserID)).Result()
+	} else {
+		ids, err = s.client.ZRange(ctx, s.updatedKey(), 0, -1).Result()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list runs: %w", err)
+	}
+	records, err := s.getAll(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := records[:0]
+	for _, rec := range records {
+		if distributed.MatchesFilter(rec.Run, filter) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// Leasable implements distributed.Store.
+func (s *Store) Leasable(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*distributed.Record, error) {
+	ids, err := s.client.ZRangeByScore(ctx, s.leasableKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list leasable runs: %w", err)
+	}
+	records, err := s.getAll(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := records[:0]
+	for _, rec := range records {
+		if rec.Leasable(now) {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i int, j int) bool {
+		return out[i].Run.CreatedAt.Before(out[j].Run.CreatedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// UpdatedSince implements distributed.Store.
+func (s *Store) UpdatedSince(
+	ctx context.Context,
+	since time.Time,
+) ([]*distributed.Record, error) {
+	ids, err := s.client.ZRangeByScore(ctx, s.updatedKey(), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list updated runs: %w", err)
+	}
+	records, err := s.getAll(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := records[:0]
+	for _, rec := range records {
+		if !rec.Run.UpdatedAt.Before(since) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// Close closes the redis client.
+func (s *Store) Close() error {
+	if s.client != nil {
+		return s.client.Close()
+	}
+	return nil
+}
+
+func (s *Store) getAll(ctx context.Context, ids []string) ([]*distributed.Record, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, 0, len(ids))
+	for _, id := range ids {
+		cmds = append(cmds, pipe.HMGet(ctx, s.runKey(id), "data", "version"))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("get runs: %w", err)
+	}
+	records := make([]*distributed.Record, 0, len(ids))
+	for _, cmd := range cmds {
+		rec, err := decodeRecord(cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// decodeRecord decodes the data and version fields of a run hash. It
+// returns nil when the run does not exist.
+func decodeRecord(values []any) (*distributed.Record, error) {
+	if len(values) != 2 || values[0] == nil || values[1] == nil {
+		return nil, nil
+	}
+	data, _ := values[0].(string)
+	versionText, _ := values[1].(string)
+	version, err := strconv.ParseInt(versionText, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("decode run version: %w", err)
+	}
+	var rec distributed.Record
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return nil, fmt.Errorf("decode run: %w", err)
+	}
+	rec.Version = version
+	return &rec, nil
+}
+
+func (s *Store) runKey(runID string) string {
+	return s.opts.keyPrefix + "run:" + runID
+}
+
+func (s *Store) leasableKey() string {
+	return s.opts.keyPrefix + "leasable"
+}
+
+func (s *Store) updatedKey() string {
+	return s.opts.keyPrefix + "updated"
+}
+
+func (s *Store) ownerKey(userID string) string {
+	return s.opts.keyPrefix + "owner:" + userID
+}
+
+// leaseScore is the leasable index score of a run: -1 for terminal runs,
+// 0 for unleased runs and the lease expiry otherwise.
+func leaseScore(rec *distributed.Record) int64 {
+	switch {
+	case rec.Run.Status.IsTerminal():
+		return -1
+	case rec.LeaseOwner == "" || rec.LeaseExpiresAt.IsZero():
+		return 0
+	default:
+		return rec.LeaseExpiresAt.UnixMilli()
+	}
+}
@@ -0,0 +1,214 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun/distributed"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+func setupTestRedis(t *testing.T) string {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	return "redis://" + mr.Addr()
+}
+
+func newTestStore(t *testing.T, url string, opts ...Option) *Store {
+	t.Helper()
+	s, err := NewStore(append([]Option{WithRedisClientURL(url)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func newRecord(id string, created time.Time) *distributed.Record {
+	return &distributed.Record{
+		Run: taskrun.Run{
+			ID:              id,
+			OwnerUserID:     "user",
+			ParentSessionID: "parent",
+			Task:            "task",
+			Status:          taskrun.StatusQueued,
+			CreatedAt:       created,
+			UpdatedAt:       created,
+		},
+		Request: distributed.Request{
+			Timeout:      time.Minute,
+			RuntimeState: map[string]any{"tenant": "t1"},
+		},
+	}
+}
+
+func TestNewStore_Errors(t *testing.T) {
+	_, err := NewStore(WithRedisClientURL(""))
+	require.Error(t, err)
+	_, err = NewStore(WithRedisInstance("no-instance"))
+	require.Error(t, err)
+}
+
+func TestNewStore_WithRedisInstance(t *testing.T) {
+	url := setupTestRedis(t)
+	storage.RegisterRedisInstance("taskrun-store-test", storage.WithClientBuilderURL(url))
+	s, err := NewStore(WithRedisInstance("taskrun-store-test"))
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.Create(context.Background(), newRecord("run", time.Now())))
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, setupTestRedis(t), WithKeyPrefix("{test}:"))
+	now := time.Now()
+
+	second := newRecord("second", now)
+	require.NoError(t, store.Create(ctx, second))
+	assert.Equal(t, int64(1), second.Version)
+	first := newRecord("first", now.Add(-time.Minute))
+	first.Run.OwnerUserID = "other"
+	require.NoError(t, store.Create(ctx, first))
+	assert.ErrorIs(t, store.Create(ctx, newRecord("first", now)),
+		taskrun.ErrRunAlreadyExists)
+
+	got, err := store.Get(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, first.Run.Task, got.Run.Task)
+	assert.Equal(t, time.Minute, got.Request.Timeout)
+	assert.Equal(t, "t1", got.Request.RuntimeState["tenant"])
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, taskrun.ErrRunNotFound)
+
+	leasable, err := store.Leasable(ctx, now, 0)
+	require.NoError(t, err)
+	require.Len(t, leasable, 2)
+	assert.Equal(t, "first", leasable[0].Run.ID)
+
+	stale := *got
+	got.LeaseOwner = "worker"
+	got.LeaseExpiresAt = now.Add(time.Minute)
+	got.Run.Status = taskrun.StatusRunning
+	require.NoError(t, store.Update(ctx, got))
+	assert.Equal(t, int64(2), got.Version)
+	assert.ErrorIs(t, store.Update(ctx, &stale), distributed.ErrConflict)
+	assert.ErrorIs(t, store.Update(ctx, newRecord("missing", now)),
+		taskrun.ErrRunNotFound)
+
+	leasable, err = store.Leasable(ctx, now, 5)
+	require.NoError(t, err)
+	require.Len(t, leasable, 1)
+	assert.Equal(t, "second", leasable[0].Run.ID)
+	leasable, err = store.Leasable(ctx, now.Add(2*time.Minute), 1)
+	require.NoError(t, err)
+	require.Len(t, leasable, 1)
+	assert.Equal(t, "first", leasable[0].Run.ID)
+
+	second.Run.Status = taskrun.StatusCompleted
+	second.Run.UpdatedAt = now.Add(time.Second)
+	require.NoError(t, store.Update(ctx, second))
+	leasable, err = store.Leasable(ctx, now.Add(2*time.Minute), 0)
+	require.NoError(t, err)
+	require.Len(t, leasable, 1)
+	assert.Equal(t, "first", leasable[0].Run.ID)
+
+	updated, err := store.UpdatedSince(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, "second", updated[0].Run.ID)
+
+	runs, err := store.List(ctx, taskrun.ListFilter{
+		OwnerUserID: "other",
+		Status:      taskrun.StatusRunning,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "worker", runs[0].LeaseOwner)
+	runs, err = store.List(ctx, taskrun.ListFilter{
+		Status: taskrun.StatusCompleted,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "second", runs[0].Run.ID)
+}
+
+type replyRunner struct{}
+
+func (replyRunner) Run(
+	context.Context,
+	string,
+	string,
+	model.Message,
+	...agent.RunOption,
+) (<-chan *event.Event, error) {
+	ch := make(chan *event.Event, 1)
+	ch <- &event.Event{Response: &model.Response{
+		Object:  model.ObjectTypeChatCompletion,
+		Choices: []model.Choice{{Message: model.NewAssistantMessage("ok")}},
+	}}
+	close(ch)
+	return ch, nil
+}
+
+func (replyRunner) Close() error {
+	return nil
+}
+
+func TestStore_WithService(t *testing.T) {
+	ctx := context.Background()
+	url := setupTestRedis(t)
+	now := time.Now()
+
+	// A run left behind by a replica that crashed while running it.
+	orphan := newRecord("orphan", now)
+	orphan.Run.Status = taskrun.StatusRunning
+	orphan.Attempt = 1
+	orphan.LeaseOwner = "crashed"
+	orphan.LeaseExpiresAt = now.Add(-time.Second)
+	require.NoError(t, newTestStore(t, url).Create(ctx, orphan))
+
+	client, err := distributed.NewService(nil, newTestStore(t, url),
+		distributed.WithWorker(false),
+		distributed.WithPollInterval(5*time.Millisecond))
+	require.NoError(t, err)
+	client.Start(ctx)
+	defer client.Close()
+	worker, err := distributed.NewService(replyRunner{}, newTestStore(t, url),
+		distributed.WithPollInterval(5*time.Millisecond))
+	require.NoError(t, err)
+	worker.Start(ctx)
+	defer worker.Close()
+
+	run, err := client.Spawn(ctx, taskrun.SpawnRequest{
+		OwnerUserID:     "user",
+		ParentSessionID: "parent",
+		Task:            "task",
+	})
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for _, id := range []string{run.ID, "orphan"} {
+		final, err := client.Wait(waitCtx, id)
+		require.NoError(t, err)
+		assert.Equal(t, taskrun.StatusCompleted, final.Status)
+		assert.Equal(t, "ok", final.Result)
+	}
+}
@@ -0,0 +1,576 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+)
+
+const (
+	defaultConcurrency  = 4
+	defaultLeaseTTL     = 30 * time.Second
+	defaultPollInterval = time.Second
+	defaultMaxAttempts  = 3
+
+	defaultStoredResultRunes  = 4000
+	defaultStoredSummaryRunes = 240
+
+	statusCancelingSummary = "canceling"
+	statusCanceledSummary  = "canceled"
+)
+
+// Option configures a Service.
+type Option func(*Options)
+
+// Options contains Service configuration.
+type Options struct {
+	// WorkerID identifies this replica in leases. It must be unique among
+	// replicas. Default is a random id.
+	WorkerID string
+	// DisableWorker makes the replica a pure client that spawns, lists,
+	// cancels, and waits for runs executed by other replicas.
+	DisableWorker bool
+	// Concurrency is the maximum number of runs this replica executes at
+	// once.
+	Concurrency int
+	// LeaseTTL is how long a lease lasts without a heartbeat. Heartbeats
+	// are sent every third of it.
+	LeaseTTL time.Duration
+	// PollInterval is how often the replica looks for leasable runs and
+	// for updates written by other replicas.
+	PollInterval time.Duration
+	// MaxAttempts is the maximum number of leases taken on one run. A run
+	// whose last lease expired fails instead of being leased again.
+	MaxAttempts int
+	// Observer receives lifecycle updates written by any replica. Updates
+	// of other replicas are polled, so a status that lasted shorter than
+	// PollInterval may be skipped; the terminal status is always observed.
+	Observer taskrun.Observer
+	// CheckpointSaver is the saver of graph agents run by this replica.
+	// Leased-again runs resume from their latest checkpoint in it.
+	CheckpointSaver graph.CheckpointSaver
+	// RunOptions are applied to every child run started by this replica.
+	RunOptions []agent.RunOption
+	// RunContext adds local context values to every child run started by
+	// this replica.
+	RunContext func(context.Context) context.Context
+	// Clock is the clock used for run timestamps and leases.
+	Clock func() time.Time
+}
+
+// WithWorkerID sets the lease owner id of this replica.
+func WithWorkerID(id string) Option {
+	return func(opts *Options) {
+		opts.WorkerID = id
+	}
+}
+
+// WithWorker enables or disables executing runs on this replica.
+// Default is enabled.
+func WithWorker(enabled bool) Option {
+	return func(opts *Options) {
+		opts.DisableWorker = !enabled
+	}
+}
+
+// WithConcurrency sets the maximum number of runs executed at once.
+// Default is 4.
+func WithConcurrency(n int) Option {
+	return func(opts *Options) {
+		opts.Concurrency = n
+	}
+}
+
+// WithLeaseTTL sets how long a lease lasts without a heartbeat.
+// Default is 30s.
+func WithLeaseTTL(ttl time.Duration) Option {
+	return func(opts *Options) {
+		opts.LeaseTTL = ttl
+	}
+}
+
+// WithPollInterval sets how often the store is polled. Default is 1s.
+func WithPollInterval(interval time.Duration) Option {
+	return func(opts *Options) {
+		opts.PollInterval = interval
+	}
+}
+
+// WithMaxAttempts sets the maximum number of leases taken on one run.
+// Default is 3.
+func WithMaxAttempts(n int) Option {
+	return func(opts *Options) {
+		opts.MaxAttempts = n
+	}
+}
+
+// WithObserver configures lifecycle update observation.
+func WithObserver(observer taskrun.Observer) Option {
+	return func(opts *Options) {
+		opts.Observer = observer
+	}
+}
+
+// WithCheckpointSaver sets the checkpoint saver used by graph agents, so
+// that runs leased again after a crash resume from their latest checkpoint.
+func WithCheckpointSaver(saver graph.CheckpointSaver) Option {
+	return func(opts *Options) {
+		opts.CheckpointSaver = saver
+	}
+}
+
+// WithRunOptions sets runner options applied to every child run executed by
+// this replica.
+func WithRunOptions(runOpts ...agent.RunOption) Option {
+	return func(opts *Options) {
+		opts.RunOptions = append(opts.RunOptions, runOpts...)
+	}
+}
+
+// WithRunContext sets a function adding local context values to every child
+// run executed by this replica.
+func WithRunContext(fn func(context.Context) context.Context) Option {
+	return func(opts *Options) {
+		opts.RunContext = fn
+	}
+}
+
+// WithClock configures the clock used by the service.
+func WithClock(clock func() time.Time) Option {
+	return func(opts *Options) {
+		opts.Clock = clock
+	}
+}
+
+// Service is one replica of a distributed taskrun controller.
+type Service struct {
+	runner runner.Runner
+	store  Store
+	opts   Options
+
+	mu      sync.Mutex
+	active  map[string]*activeRun
+	waiters map[string][]chan struct{}
+	seen    map[string]seenRun
+
+	wakeup    chan struct{}
+	startOnce sync.Once
+	baseCtx   context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+type activeRun struct {
+	cancel          context.CancelFunc
+	cancelRequested bool
+	lost            bool
+	progress        *taskrun.Progress
+}
+
+type seenRun struct {
+	status    taskrun.Status
+	updatedAt time.Time
+}
+
+var _ taskrun.Controller = (*Service)(nil)
+
+// NewService creates a replica on store. r executes leased runs and may be
+// nil when the worker is disabled.
+func NewService(
+	r runner.Runner,
+	store Store,
+	opts ...Option,
+) (*Service, error) {
+	if store == nil {
+		return nil, fmt.Errorf("taskrun: nil store")
+	}
+	options := Options{
+		Concurrency:  defaultConcurrency,
+		LeaseTTL:     defaultLeaseTTL,
+		PollInterval: defaultPollInterval,
+		MaxAttempts:  defaultMaxAttempts,
+		Clock:        time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	if r == nil && !options.DisableWorker {
+		return nil, fmt.Errorf("taskrun: nil runner")
+	}
+	if strings.TrimSpace(options.WorkerID) == "" {
+		options.WorkerID = uuid.NewString()
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultConcurrency
+	}
+	if options.LeaseTTL <= 0 {
+		options.LeaseTTL = defaultLeaseTTL
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultPollInterval
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaultMaxAttempts
+	}
+	if options.Clock == nil {
+		options.Clock = time.Now
+	}
+	return &Service{
+		runner:  r,
+		store:   store,
+		opts:    options,
+		active:  make(map[string]*activeRun),
+		waiters: make(map[string][]chan struct{}),
+		seen:    make(map[string]seenRun),
+		wakeup:  make(chan struct{}, 1),
+	}, nil
+}
+
+// Start starts leasing runs and watching updates of other replicas.
+func (s *Service) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		s.baseCtx, s.cancel = context.WithCancel(ctx)
+		if !s.opts.DisableWorker {
+			s.wg.Add(1)
+			go s.leaseLoop(s.baseCtx)
+		}
+		if s.opts.Observer != nil {
+			s.wg.Add(1)
+			go s.watchLoop(s.baseCtx, s.opts.Clock())
+		}
+	})
+}
+
+// Close stops the replica. Runs executing on it are interrupted and their
+// leases released, so that other replicas resume them.
+func (s *Service) Close() error {
+	if s == nil {
+		return nil
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// Spawn implements Controller. It enqueues the run; a worker replica starts
+// it.
+func (s *Service) Spawn(
+	ctx context.Context,
+	req taskrun.SpawnRequest,
+) (taskrun.Run, error) {
+	if s == nil {
+		return taskrun.Run{}, fmt.Errorf("taskrun: nil service")
+	}
+	if err := validateSpawnRequest(req); err != nil {
+		return taskrun.Run{}, err
+	}
+	request, err := newRequest(req)
+	if err != nil {
+		return taskrun.Run{}, err
+	}
+
+	now := s.opts.Clock()
+	runID := strings.TrimSpace(req.ID)
+	if runID == "" {
+		runID = uuid.NewString()
+	}
+	rec := &Record{
+		Run: taskrun.Run{
+			ID:              runID,
+			OwnerUserID:     strings.TrimSpace(req.OwnerUserID),
+			ParentSessionID: strings.TrimSpace(req.ParentSessionID),
+			ParentAppName:   strings.TrimSpace(req.ParentAppName),
+			AppName:         strings.TrimSpace(req.AppName),
+			ChildSessionID:  strings.TrimSpace(req.ChildSessionID),
+			RequestID:       strings.TrimSpace(req.RequestID),
+			AgentName:       strings.TrimSpace(req.AgentName),
+			Task:            strings.TrimSpace(req.Task),
+			Status:          taskrun.StatusQueued,
+			Metadata:        cloneMetadata(req.Metadata),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		},
+		Request: request,
+	}
+	if err := s.store.Create(ctx, rec); err != nil {
+		return taskrun.Run{}, err
+	}
+	s.notify(ctx, rec.Run)
+	s.poke()
+	return cloneRun(rec.Run), nil
+}
+
+// List implements Controller.
+func (s *Service) List(
+	ctx context.Context,
+	filter taskrun.ListFilter,
+) ([]taskrun.Run, error) {
+	if s == nil {
+		return nil, nil
+	}
+	filter.OwnerUserID = strings.TrimSpace(filter.OwnerUserID)
+	filter.ParentSessionID = strings.TrimSpace(filter.ParentSessionID)
+	filter.ParentAppName = strings.TrimSpace(filter.ParentAppName)
+	records, err := s.store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]taskrun.Run, 0, len(records))
+	for _, rec := range records {
+		runs = append(runs, rec.Run)
+	}
+	sort.Slice(runs, func(i int, j int) bool {
+		return runs[i].UpdatedAt.After(runs[j].UpdatedAt)
+	})
+	return runs, nil
+}
+
+// Get implements Controller.
+func (s *Service) Get(ctx context.Context, runID string) (*taskrun.Run, error) {
+	if s == nil {
+		return nil, taskrun.ErrRunNotFound
+	}
+	rec, err := s.store.Get(ctx, strings.TrimSpace(runID))
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Run, nil
+}
+
+// Cancel implements Controller. Queued runs are canceled at once; running
+// runs move to canceling until the worker holding them stops the child run.
+func (s *Service) Cancel(
+	ctx context.Context,
+	runID string,
+) (*taskrun.Run, bool, error) {
+	if s == nil {
+		return nil, false, taskrun.ErrRunNotFound
+	}
+	runID = strings.TrimSpace(runID)
+	for {
+		rec, err := s.store.Get(ctx, runID)
+		if err != nil {
+			return nil, false, err
+		}
+		if rec.Run.Status.IsTerminal() ||
+			rec.Run.Status == taskrun.StatusCanceling {
+			return &rec.Run, false, nil
+		}
+		now := s.opts.Clock()
+		if rec.Run.Status == taskrun.StatusQueued {
+			rec.Run = canceledRunView(rec.Run, now)
+		} else {
+			rec.Run.Status = taskrun.StatusCanceling
+			rec.Run.Error = ""
+			rec.Run.Summary = statusCancelingSummary
+			rec.Run.UpdatedAt = now
+		}
+		if err := s.store.Update(ctx, rec); err != nil {
+			if errors.Is(err, ErrConflict) {
+				continue
+			}
+			return nil, false, err
+		}
+		s.interrupt(runID)
+		s.notify(ctx, rec.Run)
+		if rec.Run.Status.IsTerminal() {
+			s.wake(runID)
+		}
+		return &rec.Run, true, nil
+	}
+}
+
+// Wait implements Controller. It returns once any replica finished the run.
+func (s *Service) Wait(ctx context.Context, runID string) (*taskrun.Run, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s == nil {
+		return nil, taskrun.ErrRunNotFound
+	}
+	runID = strings.TrimSpace(runID)
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		ch := s.addWaiter(runID)
+		rec, err := s.store.Get(ctx, runID)
+		if err != nil {
+			s.removeWaiter(runID, ch)
+			return nil, err
+		}
+		if rec.Run.Status.IsTerminal() {
+			s.removeWaiter(runID, ch)
+			return &rec.Run, nil
+		}
+		select {
+		case <-ch:
+		case <-ticker.C:
+			s.removeWaiter(runID, ch)
+		case <-ctx.Done():
+			s.removeWaiter(runID, ch)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func validateSpawnRequest(req taskrun.SpawnRequest) error {
+	if strings.TrimSpace(req.OwnerUserID) == "" {
+		return fmt.Errorf("taskrun: empty owner")
+	}
+	if strings.TrimSpace(req.ParentSessionID) == "" {
+		return fmt.Errorf("taskrun: empty parent session id")
+	}
+	if strings.TrimSpace(req.Task) == "" {
+		return fmt.Errorf("taskrun: empty task")
+	}
+	return nil
+}
+
+// newRequest keeps the serializable part of req. Runtime state goes through
+// a JSON round trip so that every replica sees the same values.
+func newRequest(req taskrun.SpawnRequest) (Request, error) {
+	if len(req.RunOptions) > 0 || req.RunContext != nil {
+		return Request{}, ErrLocalOnly
+	}
+	out := Request{
+		Timeout:          req.Timeout,
+		RuntimeStateKeys: req.RuntimeStateKeys,
+	}
+	if len(req.RuntimeState) > 0 {
+		data, err := json.Marshal(req.RuntimeState)
+		if err != nil {
+			return Request{}, fmt.Errorf("taskrun: encode runtime state: %w", err)
+		}
+		if err := json.Unmarshal(data, &out.RuntimeState); err != nil {
+			return Request{}, fmt.Errorf("taskrun: decode runtime state: %w", err)
+		}
+	}
+	if len(req.InjectedContextMessages) > 0 {
+		out.InjectedContextMessages = append(
+			[]model.Message(nil),
+			req.InjectedContextMessages...,
+		)
+	}
+	return out, nil
+}
+
+func (s *Service) notify(ctx context.Context, run taskrun.Run) {
+	if s == nil || s.opts.Observer == nil {
+		return
+	}
+	s.mu.Lock()
+	if seen, ok := s.seen[run.ID]; ok && seen.status == run.Status {
+		s.mu.Unlock()
+		return
+	}
+	s.seen[run.ID] = seenRun{status: run.Status, updatedAt: run.UpdatedAt}
+	s.mu.Unlock()
+	s.opts.Observer.OnRunUpdate(ctx, cloneRun(run))
+}
+
+func (s *Service) poke() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) addWaiter(runID string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan struct{})
+	s.waiters[runID] = append(s.waiters[runID], ch)
+	return ch
+}
+
+func (s *Service) wake(runID string) {
+	s.mu.Lock()
+	waiters := s.waiters[runID]
+	delete(s.waiters, runID)
+	s.mu.Unlock()
+	for _, waiter := range waiters {
+		close(waiter)
+	}
+}
+
+func (s *Service) removeWaiter(runID string, ch chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiters := s.waiters[runID]
+	for i, waiter := range waiters {
+		if waiter != ch {
+			continue
+		}
+		waiters = append(waiters[:i], waiters[i+1:]...)
+		break
+	}
+	if len(waiters) == 0 {
+		delete(s.waiters, runID)
+		return
+	}
+	s.waiters[runID] = waiters
+}
+
+func canceledRunView(run taskrun.Run, now time.Time) taskrun.Run {
+	run = cloneRun(run)
+	run.Status = taskrun.StatusCanceled
+	run.Error = ""
+	run.Summary = statusCanceledSummary
+	run.UpdatedAt = now
+	run.FinishedAt = cloneTime(now)
+	return run
+}
+
+func failedRunView(run taskrun.Run, errText string, now time.Time) taskrun.Run {
+	run = cloneRun(run)
+	run.Status = taskrun.StatusFailed
+	run.Error = errText
+	run.Summary = summarizeText(run.Error, defaultStoredSummaryRunes)
+	run.UpdatedAt = now
+	run.FinishedAt = cloneTime(now)
+	return run
+}
+
+func cloneMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		out[key] = value
+	}
+	return out
+}
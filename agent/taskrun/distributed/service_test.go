@@ -0,0 +1,416 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package distributed
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/graph/checkpoint/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const (
+	testPollInterval = 5 * time.Millisecond
+	testLeaseTTL     = 60 * time.Millisecond
+	testWaitTimeout  = 5 * time.Second
+)
+
+type runCall struct {
+	userID    string
+	sessionID string
+	message   model.Message
+	runOpts   agent.RunOptions
+}
+
+// fakeRunner replies with reply, or blocks until its context is done when
+// block is set.
+type fakeRunner struct {
+	reply   string
+	block   bool
+	started chan string
+
+	mu    sync.Mutex
+	calls []runCall
+}
+
+func (r *fakeRunner) Run(
+	ctx context.Context,
+	userID string,
+	sessionID string,
+	message model.Message,
+	opts ...agent.RunOption,
+) (<-chan *event.Event, error) {
+	var runOpts agent.RunOptions
+	for _, opt := range opts {
+		opt(&runOpts)
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, runCall{
+		userID:    userID,
+		sessionID: sessionID,
+		message:   message,
+		runOpts:   runOpts,
+	})
+	r.mu.Unlock()
+	if r.started != nil {
+		r.started <- sessionID
+	}
+
+	ch := make(chan *event.Event, 1)
+	if r.block {
+		go func() {
+			defer close(ch)
+			<-ctx.Done()
+		}()
+		return ch, nil
+	}
+	ch <- &event.Event{Response: &model.Response{
+		Object:  model.ObjectTypeChatCompletion,
+		Choices: []model.Choice{{Message: model.NewAssistantMessage(r.reply)}},
+		Usage:   &model.Usage{TotalTokens: 7},
+	}}
+	close(ch)
+	return ch, nil
+}
+
+func (r *fakeRunner) Close() error {
+	return nil
+}
+
+func (r *fakeRunner) lastCall() runCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[len(r.calls)-1]
+}
+
+type recordingObserver struct {
+	mu       sync.Mutex
+	statuses map[string][]taskrun.Status
+}
+
+func (o *recordingObserver) OnRunUpdate(_ context.Context, run taskrun.Run) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.statuses == nil {
+		o.statuses = make(map[string][]taskrun.Status)
+	}
+	o.statuses[run.ID] = append(o.statuses[run.ID], run.Status)
+}
+
+func (o *recordingObserver) get(runID string) []taskrun.Status {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]taskrun.Status(nil), o.statuses[runID]...)
+}
+
+func newTestService(
+	t *testing.T,
+	r *fakeRunner,
+	store Store,
+	opts ...Option,
+) *Service {
+	t.Helper()
+	opts = append([]Option{
+		WithPollInterval(testPollInterval),
+		WithLeaseTTL(testLeaseTTL),
+	}, opts...)
+	var svc *Service
+	var err error
+	if r == nil {
+		svc, err = NewService(nil, store, opts...)
+	} else {
+		svc, err = NewService(r, store, opts...)
+	}
+	require.NoError(t, err)
+	svc.Start(context.Background())
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+func testSpawnRequest() taskrun.SpawnRequest {
+	return taskrun.SpawnRequest{
+		OwnerUserID:     "user",
+		ParentSessionID: "parent",
+		AppName:         "app",
+		AgentName:       "worker",
+		Task:            "summarize",
+	}
+}
+
+func waitRun(t *testing.T, svc *Service, runID string) *taskrun.Run {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testWaitTimeout)
+	defer cancel()
+	run, err := svc.Wait(ctx, runID)
+	require.NoError(t, err)
+	return run
+}
+
+func TestNewService_Errors(t *testing.T) {
+	_, err := NewService(&fakeRunner{}, nil)
+	assert.Error(t, err)
+	_, err = NewService(nil, NewMemoryStore())
+	assert.Error(t, err)
+	_, err = NewService(nil, NewMemoryStore(), WithWorker(false))
+	assert.NoError(t, err)
+}
+
+func TestService_RunsOnAnotherReplica(t *testing.T) {
+	store := NewMemoryStore()
+	observer := &recordingObserver{}
+	client := newTestService(t, nil, store,
+		WithWorker(false), WithObserver(observer))
+	r := &fakeRunner{reply: "done"}
+	newTestService(t, r, store, WithWorkerID("worker-1"))
+
+	req := testSpawnRequest()
+	req.RuntimeState = map[string]any{"tenant": "t1", "limit": 3}
+	req.InjectedContextMessages = []model.Message{
+		model.NewSystemMessage("context"),
+	}
+	req.Timeout = time.Minute
+	run, err := client.Spawn(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, taskrun.StatusQueued, run.Status)
+
+	final := waitRun(t, client, run.ID)
+	assert.Equal(t, taskrun.StatusCompleted, final.Status)
+	assert.Equal(t, "done", final.Result)
+	assert.Equal(t, "done", final.Summary)
+	require.NotNil(t, final.Progress)
+	assert.Equal(t, 7, final.Progress.TotalTokens)
+	require.NotNil(t, final.StartedAt)
+	require.NotNil(t, final.FinishedAt)
+
+	call := r.lastCall()
+	assert.Equal(t, "user", call.userID)
+	assert.Equal(t, final.ChildSessionID, call.sessionID)
+	assert.Equal(t, "summarize", call.message.Content)
+	assert.Equal(t, "app", call.runOpts.AppName)
+	assert.Equal(t, "worker", call.runOpts.AgentByName)
+	assert.Equal(t, final.RequestID, call.runOpts.RequestID)
+	assert.Len(t, call.runOpts.InjectedContextMessages, 1)
+	state := call.runOpts.RuntimeState
+	assert.Equal(t, "t1", state["tenant"])
+	assert.Equal(t, float64(3), state["limit"])
+	assert.Equal(t, true, state[taskrun.RuntimeStateKeyRun])
+	assert.Equal(t, run.ID, state[taskrun.RuntimeStateKeyRunID])
+	assert.Equal(t, "parent", state[taskrun.RuntimeStateKeyParentSessionID])
+	assert.Equal(t, lineagePrefix+run.ID, state[graph.CfgKeyLineageID])
+	assert.NotContains(t, state, graph.CfgKeyCheckpointID)
+
+	// The client only polls the store, so it may miss running.
+	require.Eventually(t, func() bool {
+		statuses := observer.get(run.ID)
+		return statuses[len(statuses)-1] == taskrun.StatusCompleted
+	}, testWaitTimeout, testPollInterval)
+	statuses := observer.get(run.ID)
+	assert.Equal(t, taskrun.StatusQueued, statuses[0])
+	assert.LessOrEqual(t, len(statuses), 3)
+
+	runs, err := client.List(context.Background(), taskrun.ListFilter{
+		OwnerUserID: "user",
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.ID, runs[0].ID)
+	runs, err = client.List(context.Background(), taskrun.ListFilter{
+		OwnerUserID: "other",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestService_CancelFromAnotherReplica(t *testing.T) {
+	store := NewMemoryStore()
+	client := newTestService(t, nil, store, WithWorker(false))
+	r := &fakeRunner{block: true, started: make(chan string, 1)}
+	newTestService(t, r, store)
+
+	run, err := client.Spawn(context.Background(), testSpawnRequest())
+	require.NoError(t, err)
+	<-r.started
+
+	canceling, changed, err := client.Cancel(context.Background(), run.ID)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, taskrun.StatusCanceling, canceling.Status)
+	_, changed, err = client.Cancel(context.Background(), run.ID)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	final := waitRun(t, client, run.ID)
+	assert.Equal(t, taskrun.StatusCanceled, final.Status)
+
+	_, _, err = client.Cancel(context.Background(), "missing")
+	assert.ErrorIs(t, err, taskrun.ErrRunNotFound)
+}
+
+func TestService_CancelQueuedRun(t *testing.T) {
+	client := newTestService(t, nil, NewMemoryStore(), WithWorker(false))
+	run, err := client.Spawn(context.Background(), testSpawnRequest())
+	require.NoError(t, err)
+
+	canceled, changed, err := client.Cancel(context.Background(), run.ID)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, taskrun.StatusCanceled, canceled.Status)
+	assert.Equal(t, taskrun.StatusCanceled, waitRun(t, client, run.ID).Status)
+}
+
+func TestService_ReleasesRunsOnClose(t *testing.T) {
+	store := NewMemoryStore()
+	blocking := &fakeRunner{block: true, started: make(chan string, 1)}
+	first, err := NewService(blocking, store,
+		WithPollInterval(testPollInterval), WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+	first.Start(context.Background())
+
+	run, err := first.Spawn(context.Background(), testSpawnRequest())
+	require.NoError(t, err)
+	<-blocking.started
+	require.NoError(t, first.Close())
+
+	rec, err := store.Get(context.Background(), run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, taskrun.StatusRunning, rec.Run.Status)
+	assert.Empty(t, rec.LeaseOwner)
+
+	r := &fakeRunner{reply: "finished"}
+	second := newTestService(t, r, store)
+	final := waitRun(t, second, run.ID)
+	assert.Equal(t, taskrun.StatusCompleted, final.Status)
+	assert.Equal(t, rec.Run.ChildSessionID, r.lastCall().sessionID)
+}
+
+func TestService_ReleasesOrphanedRuns(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+	orphan := func(id string, attempt int, status taskrun.Status) {
+		require.NoError(t, store.Create(ctx, &Record{
+			Run: taskrun.Run{
+				ID:              id,
+				OwnerUserID:     "user",
+				ParentSessionID: "parent",
+				ChildSessionID:  "child-" + id,
+				Task:            "summarize",
+				Status:          status,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			},
+			Attempt:        attempt,
+			LeaseOwner:     "crashed",
+			LeaseExpiresAt: now.Add(-time.Second),
+		}))
+	}
+	orphan("resumed", 1, taskrun.StatusRunning)
+	orphan("exhausted", 3, taskrun.StatusRunning)
+	orphan("canceling", 1, taskrun.StatusCanceling)
+
+	saver := inmemory.NewSaver()
+	_, err := saver.Put(ctx, graph.PutRequest{
+		Config: graph.CreateCheckpointConfig(
+			lineagePrefix+"resumed", "", "worker",
+		),
+		Checkpoint: graph.NewCheckpoint(nil, nil, nil),
+		Metadata:   graph.NewCheckpointMetadata(graph.CheckpointSourceLoop, 1),
+	})
+	require.NoError(t, err)
+
+	r := &fakeRunner{reply: "resumed"}
+	svc := newTestService(t, r, store, WithCheckpointSaver(saver))
+
+	final := waitRun(t, svc, "resumed")
+	assert.Equal(t, taskrun.StatusCompleted, final.Status)
+	call := r.lastCall()
+	assert.Equal(t, "child-resumed", call.sessionID)
+	assert.Equal(t, resumeMessage, call.message.Content)
+	assert.Equal(t, "", call.runOpts.RuntimeState[graph.CfgKeyCheckpointID])
+	assert.Equal(t, "worker", call.runOpts.RuntimeState[graph.CfgKeyCheckpointNS])
+	rec, err := store.Get(ctx, "resumed")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rec.Attempt)
+
+	exhausted := waitRun(t, svc, "exhausted")
+	assert.Equal(t, taskrun.StatusFailed, exhausted.Status)
+	assert.Contains(t, exhausted.Error, "3 attempts")
+	assert.Equal(t, taskrun.StatusCanceled, waitRun(t, svc, "canceling").Status)
+}
+
+func TestService_TakesOverExpiredLease(t *testing.T) {
+	store := NewMemoryStore()
+	stalled := &fakeRunner{block: true, started: make(chan string, 1)}
+	first := newTestService(t, stalled, store, WithWorkerID("first"))
+	run, err := first.Spawn(context.Background(), testSpawnRequest())
+	require.NoError(t, err)
+	<-stalled.started
+
+	// Another worker takes the lease over as if the first one stalled.
+	rec, err := store.Get(context.Background(), run.ID)
+	require.NoError(t, err)
+	rec.LeaseOwner = "second"
+	rec.LeaseExpiresAt = time.Now().Add(time.Minute)
+	require.NoError(t, store.Update(context.Background(), rec))
+
+	require.Eventually(t, func() bool {
+		return !first.isActive(run.ID)
+	}, testWaitTimeout, testPollInterval)
+	rec, err = store.Get(context.Background(), run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "second", rec.LeaseOwner)
+	assert.Equal(t, taskrun.StatusRunning, rec.Run.Status)
+}
+
+func TestService_SpawnErrors(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, nil, NewMemoryStore(), WithWorker(false))
+
+	req := testSpawnRequest()
+	req.OwnerUserID = ""
+	_, err := svc.Spawn(ctx, req)
+	assert.Error(t, err)
+
+	req = testSpawnRequest()
+	req.RunOptions = []agent.RunOption{agent.WithAppName("app")}
+	_, err = svc.Spawn(ctx, req)
+	assert.ErrorIs(t, err, ErrLocalOnly)
+
+	req = testSpawnRequest()
+	req.RuntimeState = map[string]any{"ch": make(chan int)}
+	_, err = svc.Spawn(ctx, req)
+	assert.Error(t, err)
+
+	req = testSpawnRequest()
+	req.ID = "run-1"
+	_, err = svc.Spawn(ctx, req)
+	require.NoError(t, err)
+	_, err = svc.Spawn(ctx, req)
+	assert.ErrorIs(t, err, taskrun.ErrRunAlreadyExists)
+
+	_, err = svc.Get(ctx, "missing")
+	assert.ErrorIs(t, err, taskrun.ErrRunNotFound)
+	_, err = svc.Wait(ctx, "missing")
+	assert.ErrorIs(t, err, taskrun.ErrRunNotFound)
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = svc.Wait(waitCtx, "run-1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,64 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package sqldb
+
+const defaultTableName = "taskrun_runs"
+
+// Dialect selects the SQL flavor used for DDL, placeholders and inserts.
+type Dialect int
+
+const (
+	// DialectSQLite targets SQLite 3.24 or later.
+	DialectSQLite Dialect = iota
+	// DialectPostgres targets PostgreSQL 9.5 or later.
+	DialectPostgres
+	// DialectMySQL targets MySQL 5.7 or later.
+	DialectMySQL
+)
+
+// Option configures the SQL store.
+type Option func(*options)
+
+type options struct {
+	dialect    Dialect
+	tableName  string
+	skipDBInit bool
+}
+
+var defaultOptions = options{
+	dialect:   DialectSQLite,
+	tableName: defaultTableName,
+}
+
+// WithDialect sets the SQL dialect of the database.
+// Default is DialectSQLite.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithTableName sets the table storing runs.
+// Default is "taskrun_runs".
+func WithTableName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.tableName = name
+		}
+	}
+}
+
+// WithSkipDBInit skips creating the table. Use it when the schema is managed
+// externally.
+func WithSkipDBInit(skip bool) Option {
+	return func(o *options) {
+		o.skipDBInit = skip
+	}
+}
@@ -0,0 +1,382 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package sqldb provides a SQL-backed distributed.Store for task runs.
+//
+// Every replica using the same table shares the runs. It works on any
+// database/sql handle; select the SQL flavor with WithDialect:
+//
+//	db, _ := sql.Open("pgx", dsn)
+//	store, err := sqldb.NewStore(db, sqldb.WithDialect(sqldb.DialectPostgres))
+//	svc, err := distributed.NewService(r, store)
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun/distributed"
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+const selectColumns = "SELECT data, version FROM "
+
+var _ distributed.Store = (*Store)(nil)
+
+// Store is a distributed.Store keeping one row per run. The record is
+// stored as JSON next to the columns used for filtering and leasing.
+type Store struct {
+	db   *sql.DB
+	opts options
+
+	sqlCreate       string
+	sqlGet          string
+	sqlExists       string
+	sqlUpdate       string
+	sqlLeasable     string
+	sqlLeasableAll  string
+	sqlUpdatedSince string
+}
+
+// NewStore creates a SQL store on db and creates its table unless
+// WithSkipDBInit is set. The caller keeps ownership of db.
+func NewStore(db *sql.DB, opts ...Option) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !tableNamePattern.MatchString(o.tableName) {
+		return nil, fmt.Errorf("invalid table name %q", o.tableName)
+	}
+	switch o.dialect {
+	case DialectSQLite, DialectPostgres, DialectMySQL:
+	default:
+		return nil, fmt.Errorf("unsupported dialect %d", o.dialect)
+	}
+
+	s := &Store{db: db, opts: o}
+	s.buildQueries()
+	if !o.skipDBInit {
+		for _, stmt := range s.ddl() {
+			if _, err := db.ExecContext(context.Background(), stmt); err != nil {
+				return nil, fmt.Errorf("init database failed: %w", err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Create implements distributed.Store.
+func (s *Store) Create(ctx context.Context, rec *distributed.Record) error {
+	stored := *rec
+	stored.Version = 1
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("encode run: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, s.sqlCreate,
+		stored.Run.ID,
+		stored.Run.OwnerUserID,
+		stored.Run.ParentSessionID,
+		stored.Run.ParentAppName,
+		string(stored.Run.Status),
+		stored.Version,
+		leaseDeadline(&stored),
+		stored.Run.CreatedAt.UnixNano(),
+		stored.Run.UpdatedAt.UnixNano(),
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("create run: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return taskrun.ErrRunAlreadyExists
+	}
+	rec.Version = stored.Version
+	return nil
+}
+
+// Get implements distributed.Store.
+func (s *Store) Get(ctx context.Context, runID string) (*distributed.Record, error) {
+	rec, err := scanRecord(s.db.QueryRowContext(ctx, s.sqlGet, runID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, taskrun.ErrRunNotFound
+	}
+	return rec, err
+}
+
+// Update implements distributed.Store.
+func (s *Store) Update(ctx context.Context, rec *distributed.Record) error {
+	stored := *rec
+	stored.Version++
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("encode run: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, s.sqlUpdate,
+		stored.Run.OwnerUserID,
+		stored.Run.ParentSessionID,
+		stored.Run.ParentAppName,
+		string(stored.Run.Status),
+		stored.Version,
+		leaseDeadline(&stored),
+		stored.Run.UpdatedAt.UnixNano(),
+		data,
+		stored.Run.ID,
+		rec.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("update run: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update run: %w", err)
+	}
+	if n == 0 {
+		var one int
+		err := s.db.QueryRowContext(ctx, s.sqlExists, stored.Run.ID).Scan(&one)
+		if errors.Is(err, sql.ErrNoRows) {
+			return taskrun.ErrRunNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("update run: %w", err)
+		}
+		return distributed.ErrConflict
+	}
+	rec.Version = stored.Version
+	return nil
+}
+
+// List implements distributed.Store.
+func (s *Store) List(
+	ctx context.Context,
+	filter taskrun.ListFilter,
+) ([]*distributed.Record, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"owner_user_id", filter.OwnerUserID},
+		{"parent_session_id", filter.ParentSessionID},
+		{"parent_app_name", filter.ParentAppName},
+		{"status", string(filter.Status)},
+	} {
+		if f.value == "" {
+			continue
+		}
+		conds = append(conds, f.column+" = ?")
+		args = append(args, f.value)
+	}
+	query := selectColumns + s.opts.tableName
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return s.query(ctx, s.rebind(query), args...)
+}
+
+// Leasable implements distributed.Store.
+func (s *Store) Leasable(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*distributed.Record, error) {
+	args := []any{
+		string(taskrun.StatusCompleted),
+		string(taskrun.StatusFailed),
+		string(taskrun.StatusCanceled),
+		now.UnixNano(),
+	}
+	if limit <= 0 {
+		return s.query(ctx, s.sqlLeasableAll, args...)
+	}
+	return s.query(ctx, s.sqlLeasable, append(args, limit)...)
+}
+
+// UpdatedSince implements distributed.Store.
+func (s *Store) UpdatedSince(
+	ctx context.Context,
+	since time.Time,
+) ([]*distributed.Record, error) {
+	return s.query(ctx, s.sqlUpdatedSince, since.UnixNano())
+}
+
+func (s *Store) query(
+	ctx context.Context,
+	query string,
+	args ...any,
+) ([]*distributed.Record, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query runs: %w", err)
+	}
+	defer rows.Close()
+	var records []*distributed.Record
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query runs: %w", err)
+	}
+	return records, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecord(row scanner) (*distributed.Record, error) {
+	var (
+		data    []byte
+		version int64
+	)
+	if err := row.Scan(&data, &version); err != nil {
+		return nil, err
+	}
+	var rec distributed.Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("decode run: %w", err)
+	}
+	rec.Version = version
+	return &rec, nil
+}
+
+// leaseDeadline is the time from which the run can be leased in
+// nanoseconds. Runs without a lease owner can be leased at once.
+func leaseDeadline(rec *distributed.Record) int64 {
+	if rec.LeaseOwner == "" || rec.LeaseExpiresAt.IsZero() {
+		return 0
+	}
+	return rec.LeaseExpiresAt.UnixNano()
+}
+
+func (s *Store) ddl() []string {
+	t := s.opts.tableName
+	switch s.opts.dialect {
+	case DialectPostgres:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + t + " (" +
+				"id TEXT NOT NULL PRIMARY KEY, " +
+				"owner_user_id TEXT NOT NULL, " +
+				"parent_session_id TEXT NOT NULL, " +
+				"parent_app_name TEXT NOT NULL, " +
+				"status TEXT NOT NULL, " +
+				"version BIGINT NOT NULL, " +
+				"lease_expires_at BIGINT NOT NULL DEFAULT 0, " +
+				"created_at BIGINT NOT NULL, " +
+				"updated_at BIGINT NOT NULL, " +
+				"data BYTEA NOT NULL)",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t, "owner") + " ON " + t + " (owner_user_id)",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t, "lease") + " ON " + t + " (status, lease_expires_at)",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t, "updated") + " ON " + t + " (updated_at)",
+		}
+	case DialectMySQL:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + t + " (" +
+				"id VARCHAR(191) NOT NULL, " +
+				"owner_user_id VARCHAR(191) NOT NULL, " +
+				"parent_session_id VARCHAR(191) NOT NULL, " +
+				"parent_app_name VARCHAR(191) NOT NULL, " +
+				"status VARCHAR(32) NOT NULL, " +
+				"version BIGINT NOT NULL, " +
+				"lease_expires_at BIGINT NOT NULL DEFAULT 0, " +
+				"created_at BIGINT NOT NULL, " +
+				"updated_at BIGINT NOT NULL, " +
+				"data LONGBLOB NOT NULL, " +
+				"PRIMARY KEY (id), " +
+				"KEY " + indexName(t, "owner") + " (owner_user_id), " +
+				"KEY " + indexName(t, "lease") + " (status, lease_expires_at), " +
+				"KEY " + indexName(t, "updated") + " (updated_at)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+		}
+	default:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + t + " (" +
+				"id TEXT NOT NULL PRIMARY KEY, " +
+				"owner_user_id TEXT NOT NULL, " +
+				"parent_session_id TEXT NOT NULL, " +
+				"parent_app_name TEXT NOT NULL, " +
+				"status TEXT NOT NULL, " +
+				"version INTEGER NOT NULL, " +
+				"lease_expires_at INTEGER NOT NULL DEFAULT 0, " +
+				"created_at INTEGER NOT NULL, " +
+				"updated_at INTEGER NOT NULL, " +
+				"data BLOB NOT NULL)",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t, "owner") + " ON " + t + " (owner_user_id)",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t, "lease") + " ON " + t + " (status, lease_expires_at)",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t, "updated") + " ON " + t + " (updated_at)",
+		}
+	}
+}
+
+func (s *Store) buildQueries() {
+	t := s.opts.tableName
+	insert := "INSERT INTO " + t + " (id, owner_user_id, parent_session_id, parent_app_name, " +
+		"status, version, lease_expires_at, created_at, updated_at, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	if s.opts.dialect == DialectMySQL {
+		s.sqlCreate = strings.Replace(insert, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	} else {
+		s.sqlCreate = s.rebind(insert + " ON CONFLICT (id) DO NOTHING")
+	}
+	s.sqlGet = s.rebind(selectColumns + t + " WHERE id = ?")
+	s.sqlExists = s.rebind("SELECT 1 FROM " + t + " WHERE id = ?")
+	s.sqlUpdate = s.rebind("UPDATE " + t + " SET owner_user_id = ?, parent_session_id = ?, " +
+		"parent_app_name = ?, status = ?, version = ?, lease_expires_at = ?, updated_at = ?, data = ? " +
+		"WHERE id = ? AND version = ?")
+	leasable := selectColumns + t +
+		" WHERE status NOT IN (?, ?, ?) AND lease_expires_at <= ? ORDER BY created_at"
+	s.sqlLeasableAll = s.rebind(leasable)
+	s.sqlLeasable = s.rebind(leasable + " LIMIT ?")
+	s.sqlUpdatedSince = s.rebind(selectColumns + t + " WHERE updated_at >= ?")
+}
+
+// rebind converts "?" placeholders to "$n" for PostgreSQL.
+func (s *Store) rebind(query string) string {
+	if s.opts.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// indexName derives an index name, dropping any schema qualifier.
+func indexName(table string, suffix string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	return "idx_" + table + "_" + suffix
+}
@@ -0,0 +1,156 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package distributed provides a taskrun controller shared by several
+// replicas through an external Store.
+//
+// Spawn only enqueues a run. Every replica started with a worker polls the
+// store, leases queued runs and runs whose lease expired, renews the lease
+// while the child agent runs, and writes progress and the terminal result
+// back to the store. When a replica crashes, its leases expire and another
+// replica leases the runs again. Runs of a graph agent resume from their
+// latest checkpoint when WithCheckpointSaver is configured.
+//
+// List, Get, Cancel, and Wait read and write the store, so they work from
+// any replica. Observers receive lifecycle updates written by every replica.
+//
+// The sqldb subpackage stores runs in a database/sql database. A Redis store
+// lives in the separate agent/taskrun/distributed/redis module.
+package distributed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// ErrConflict indicates that a record was updated by another writer since it
+// was read.
+var ErrConflict = errors.New("taskrun: run was updated concurrently")
+
+// ErrLocalOnly indicates that a spawn request carries values which cannot be
+// sent to other replicas.
+var ErrLocalOnly = errors.New(
+	"taskrun: run options and run context cannot be sent to other replicas",
+)
+
+// Record is the stored state of one run.
+type Record struct {
+	Run     taskrun.Run `json:"run"`
+	Request Request     `json:"request"`
+	// Version increases with every write and guards concurrent writers.
+	Version int64 `json:"version"`
+	// Attempt counts the leases taken on the run.
+	Attempt int `json:"attempt,omitempty"`
+	// LeaseOwner is the worker holding the run.
+	LeaseOwner string `json:"lease_owner,omitempty"`
+	// LeaseExpiresAt is when another worker may take over the run.
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+// Request is the serializable part of a SpawnRequest that workers need to
+// start the child run. Run identity and task fields live in Record.Run.
+type Request struct {
+	Timeout                 time.Duration            `json:"timeout,omitempty"`
+	RuntimeState            map[string]any           `json:"runtime_state,omitempty"`
+	RuntimeStateKeys        taskrun.RuntimeStateKeys `json:"runtime_state_keys"`
+	InjectedContextMessages []model.Message          `json:"injected_context_messages,omitempty"`
+}
+
+// Leasable reports whether a worker may lease the run at now.
+func (r *Record) Leasable(now time.Time) bool {
+	if r == nil || r.Run.Status.IsTerminal() {
+		return false
+	}
+	return r.LeaseOwner == "" || !r.LeaseExpiresAt.After(now)
+}
+
+// Store persists run records shared by all replicas.
+//
+// Implementations must make Create and Update atomic: Update writes the
+// record only when the stored version equals rec.Version.
+type Store interface {
+	// Create stores a new record with version 1. It returns
+	// taskrun.ErrRunAlreadyExists when the run id is taken.
+	Create(ctx context.Context, rec *Record) error
+	// Get returns taskrun.ErrRunNotFound when the run does not exist.
+	Get(ctx context.Context, runID string) (*Record, error)
+	// Update replaces the record when the stored version equals rec.Version
+	// and increments rec.Version. It returns ErrConflict when the version
+	// changed and taskrun.ErrRunNotFound when the run does not exist.
+	Update(ctx context.Context, rec *Record) error
+	// List returns the records matching filter in any order.
+	List(ctx context.Context, filter taskrun.ListFilter) ([]*Record, error)
+	// Leasable returns up to limit records for which Record.Leasable(now)
+	// holds, oldest first.
+	Leasable(ctx context.Context, now time.Time, limit int) ([]*Record, error)
+	// UpdatedSince returns the records whose Run.UpdatedAt is not before
+	// since.
+	UpdatedSince(ctx context.Context, since time.Time) ([]*Record, error)
+}
+
+// MatchesFilter reports whether run matches filter. Stores use it to apply
+// filters that they do not index.
+func MatchesFilter(run taskrun.Run, filter taskrun.ListFilter) bool {
+	if filter.OwnerUserID != "" && run.OwnerUserID != filter.OwnerUserID {
+		return false
+	}
+	if filter.ParentSessionID != "" &&
+		run.ParentSessionID != filter.ParentSessionID {
+		return false
+	}
+	if filter.ParentAppName != "" &&
+		run.ParentAppName != filter.ParentAppName {
+		return false
+	}
+	if filter.Status != "" && run.Status != filter.Status {
+		return false
+	}
+	return true
+}
+
+func cloneRun(r taskrun.Run) taskrun.Run {
+	out := r
+	if r.StartedAt != nil {
+		startedAt := *r.StartedAt
+		out.StartedAt = &startedAt
+	}
+	if r.FinishedAt != nil {
+		finishedAt := *r.FinishedAt
+		out.FinishedAt = &finishedAt
+	}
+	out.Progress = cloneProgress(r.Progress)
+	if r.Metadata != nil {
+		out.Metadata = make(map[string]string, len(r.Metadata))
+		for key, value := range r.Metadata {
+			out.Metadata[key] = value
+		}
+	}
+	return out
+}
+
+func cloneProgress(progress *taskrun.Progress) *taskrun.Progress {
+	if progress == nil {
+		return nil
+	}
+	out := *progress
+	if progress.LastEventAt != nil {
+		lastEventAt := *progress.LastEventAt
+		out.LastEventAt = &lastEventAt
+	}
+	return &out
+}
+
+func cloneTime(value time.Time) *time.Time {
+	copied := value
+	return &copied
+}
@@ -0,0 +1,521 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/taskrun"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const (
+	childSessionPrefix = "taskrun:"
+	requestIDPrefix    = "taskrun:"
+	lineagePrefix      = "taskrun:"
+
+	// resumeMessage asks a graph agent to continue from its checkpoint
+	// without adding new user input.
+	resumeMessage = "resume"
+
+	maxWriteRetries = 5
+	// observerLookback widens every update poll to tolerate clock skew
+	// between replicas.
+	observerLookback = 10 * time.Second
+)
+
+func (s *Service) leaseLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.leaseRuns(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wakeup:
+		}
+	}
+}
+
+func (s *Service) leaseRuns(ctx context.Context) {
+	s.mu.Lock()
+	free := s.opts.Concurrency - len(s.active)
+	s.mu.Unlock()
+	if free <= 0 {
+		return
+	}
+	records, err := s.store.Leasable(ctx, s.opts.Clock(), free)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warnf("taskrun: list leasable runs failed: %v", err)
+		}
+		return
+	}
+	for _, rec := range records {
+		if free == 0 {
+			return
+		}
+		if !s.lease(ctx, rec) {
+			continue
+		}
+		free--
+		s.wg.Add(1)
+		go func(rec *Record) {
+			defer s.wg.Done()
+			s.execute(ctx, rec)
+		}(rec)
+	}
+}
+
+// lease takes the lease of rec. Runs that must not run again are finished
+// instead and false is returned.
+func (s *Service) lease(ctx context.Context, rec *Record) bool {
+	now := s.opts.Clock()
+	if !rec.Leasable(now) || s.isActive(rec.Run.ID) {
+		return false
+	}
+	switch {
+	case rec.Run.Status == taskrun.StatusCanceling:
+		s.finishOrphan(ctx, rec, canceledRunView(rec.Run, now))
+		return false
+	case rec.Attempt >= s.opts.MaxAttempts:
+		s.finishOrphan(ctx, rec, failedRunView(rec.Run, fmt.Sprintf(
+			"taskrun: lease expired after %d attempts", rec.Attempt,
+		), now))
+		return false
+	}
+
+	started := rec.Run.Status == taskrun.StatusQueued
+	rec.Attempt++
+	rec.LeaseOwner = s.opts.WorkerID
+	rec.LeaseExpiresAt = now.Add(s.opts.LeaseTTL)
+	if started {
+		rec.Run.Status = taskrun.StatusRunning
+		if rec.Run.ChildSessionID == "" {
+			rec.Run.ChildSessionID = newChildSessionID(rec.Run.ID, now)
+		}
+		if rec.Run.RequestID == "" {
+			rec.Run.RequestID = newRequestID(rec.Run.ID, now)
+		}
+		rec.Run.StartedAt = cloneTime(now)
+		rec.Run.UpdatedAt = now
+	}
+	if err := s.store.Update(ctx, rec); err != nil {
+		if !errors.Is(err, ErrConflict) && ctx.Err() == nil {
+			log.Warnf("taskrun: lease run %s failed: %v", rec.Run.ID, err)
+		}
+		return false
+	}
+	if started {
+		s.notify(ctx, rec.Run)
+	}
+	return true
+}
+
+// finishOrphan writes the terminal state of a run that lost its worker.
+func (s *Service) finishOrphan(ctx context.Context, rec *Record, run taskrun.Run) {
+	rec.Run = run
+	rec.LeaseOwner = ""
+	rec.LeaseExpiresAt = time.Time{}
+	if err := s.store.Update(ctx, rec); err != nil {
+		if !errors.Is(err, ErrConflict) && ctx.Err() == nil {
+			log.Warnf("taskrun: finish run %s failed: %v", rec.Run.ID, err)
+		}
+		return
+	}
+	s.notify(ctx, rec.Run)
+	s.wake(rec.Run.ID)
+}
+
+func (s *Service) execute(parent context.Context, rec *Record) {
+	runCtx := parent
+	if s.opts.RunContext != nil {
+		if enriched := s.opts.RunContext(runCtx); enriched != nil {
+			runCtx = enriched
+		}
+	}
+	var cancel context.CancelFunc
+	if rec.Request.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(runCtx, rec.Request.Timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(runCtx)
+	}
+	defer cancel()
+
+	runID := rec.Run.ID
+	s.mu.Lock()
+	s.active[runID] = &activeRun{cancel: cancel}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, runID)
+		s.mu.Unlock()
+		s.poke()
+	}()
+
+	stop := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		s.heartbeat(runID, stop)
+	}()
+
+	result := replyAccumulator{}
+	progress := progressAccumulator{}
+	runErr := s.runChild(runCtx, rec, &result, &progress)
+	close(stop)
+	<-heartbeatDone
+	s.finish(runID, trimResult(result.text), runErr, progress.snapshot())
+}
+
+func (s *Service) runChild(
+	ctx context.Context,
+	rec *Record,
+	result *replyAccumulator,
+	progress *progressAccumulator,
+) error {
+	run := rec.Run
+	state, resumed := s.checkpointState(ctx, rec)
+	runOpts := append([]agent.RunOption(nil), s.opts.RunOptions...)
+	if run.AppName != "" {
+		runOpts = append(runOpts, agent.WithAppName(run.AppName))
+	}
+	runOpts = append(runOpts,
+		agent.WithRequestID(run.RequestID),
+		agent.MergeRuntimeState(state),
+		agent.MergeRuntimeState(runtimeStateForRun(
+			&run,
+			rec.Request.RuntimeState,
+			rec.Request.RuntimeStateKeys,
+		)),
+	)
+	if len(rec.Request.InjectedContextMessages) > 0 {
+		runOpts = append(runOpts, agent.WithInjectedContextMessages(
+			rec.Request.InjectedContextMessages,
+		))
+	}
+	if run.AgentName != "" {
+		runOpts = append(runOpts, agent.WithAgentByName(run.AgentName))
+	}
+	message := model.NewUserMessage(run.Task)
+	if resumed {
+		message = model.NewUserMessage(resumeMessage)
+	}
+
+	events, err := s.runner.Run(
+		ctx,
+		run.OwnerUserID,
+		run.ChildSessionID,
+		message,
+		runOpts...,
+	)
+	if err != nil {
+		return err
+	}
+	for evt := range events {
+		result.consume(evt)
+		if progress.consume(evt, s.opts.Clock()) {
+			s.setProgress(run.ID, progress.snapshot())
+		}
+	}
+	if result.err != nil {
+		return result.err
+	}
+	return ctx.Err()
+}
+
+// checkpointState returns the graph checkpoint runtime state of a run. Every
+// attempt uses the same lineage, and an attempt after a lost lease resumes
+// from the latest checkpoint of that lineage when one exists.
+func (s *Service) checkpointState(
+	ctx context.Context,
+	rec *Record,
+) (map[string]any, bool) {
+	lineageID := lineagePrefix + rec.Run.ID
+	state := map[string]any{graph.CfgKeyLineageID: lineageID}
+	if rec.Attempt <= 1 || s.opts.CheckpointSaver == nil {
+		return state, false
+	}
+	latest, err := graph.NewCheckpointManager(s.opts.CheckpointSaver).
+		Latest(ctx, lineageID, "")
+	if err != nil {
+		log.Warnf("taskrun: load checkpoint of run %s failed: %v", rec.Run.ID, err)
+		return state, false
+	}
+	if latest == nil || latest.Checkpoint == nil {
+		return state, false
+	}
+	ref := graph.CheckpointRef{
+		LineageID: lineageID,
+		Namespace: graph.GetNamespace(latest.Config),
+	}
+	for key, value := range ref.ToRuntimeState() {
+		state[key] = value
+	}
+	return state, true
+}
+
+func (s *Service) heartbeat(runID string, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.opts.LeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.renew(runID)
+		}
+	}
+}
+
+// renew extends the lease and stores the latest progress. It interrupts the
+// child run when the run was canceled or the lease was taken over.
+func (s *Service) renew(runID string) {
+	ctx := context.Background()
+	for i := 0; i < maxWriteRetries; i++ {
+		rec, err := s.store.Get(ctx, runID)
+		if err != nil {
+			log.Warnf("taskrun: heartbeat of run %s failed: %v", runID, err)
+			return
+		}
+		if rec.LeaseOwner != s.opts.WorkerID || rec.Run.Status.IsTerminal() {
+			s.loseLease(runID)
+			return
+		}
+		if rec.Run.Status == taskrun.StatusCanceling {
+			s.interrupt(runID)
+		}
+		rec.LeaseExpiresAt = s.opts.Clock().Add(s.opts.LeaseTTL)
+		if progress := s.progress(runID); progress != nil {
+			rec.Run.Progress = progress
+		}
+		err = s.store.Update(ctx, rec)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			log.Warnf("taskrun: heartbeat of run %s failed: %v", runID, err)
+		}
+		return
+	}
+}
+
+// finish writes the outcome of a child run. Runs interrupted by Close are
+// released for other replicas instead.
+func (s *Service) finish(
+	runID string,
+	output string,
+	runErr error,
+	progress *taskrun.Progress,
+) {
+	ctx := context.Background()
+	for i := 0; i < maxWriteRetries; i++ {
+		rec, err := s.store.Get(ctx, runID)
+		if err != nil {
+			log.Warnf("taskrun: finish run %s failed: %v", runID, err)
+			return
+		}
+		if rec.LeaseOwner != s.opts.WorkerID || rec.Run.Status.IsTerminal() {
+			return
+		}
+		s.mu.Lock()
+		active := s.active[runID]
+		lost := active != nil && active.lost
+		cancelRequested := active != nil && active.cancelRequested
+		s.mu.Unlock()
+		if lost {
+			return
+		}
+		now := s.opts.Clock()
+		released := errors.Is(runErr, context.Canceled) &&
+			s.baseCtx.Err() != nil && !cancelRequested &&
+			rec.Run.Status != taskrun.StatusCanceling
+		if released {
+			rec.Run.Progress = cloneProgress(progress)
+		} else {
+			rec.Run = finishedRunView(rec.Run, output, runErr, progress, now)
+		}
+		rec.LeaseOwner = ""
+		rec.LeaseExpiresAt = time.Time{}
+		err = s.store.Update(ctx, rec)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			log.Warnf("taskrun: finish run %s failed: %v", runID, err)
+			return
+		}
+		if !released {
+			s.notify(ctx, rec.Run)
+			s.wake(runID)
+		}
+		return
+	}
+	log.Warnf("taskrun: finish run %s failed: %v", runID, ErrConflict)
+}
+
+func finishedRunView(
+	run taskrun.Run,
+	output string,
+	runErr error,
+	progress *taskrun.Progress,
+	now time.Time,
+) taskrun.Run {
+	run = cloneRun(run)
+	run.Result = output
+	run.Progress = cloneProgress(progress)
+	run.UpdatedAt = now
+	run.FinishedAt = cloneTime(now)
+	switch {
+	case errors.Is(runErr, context.Canceled),
+		run.Status == taskrun.StatusCanceling && runErr == nil:
+		run.Status = taskrun.StatusCanceled
+		run.Error = ""
+		run.Summary = statusCanceledSummary
+	case runErr != nil:
+		run.Status = taskrun.StatusFailed
+		run.Error = runErr.Error()
+		run.Summary = summarizeText(run.Error, defaultStoredSummaryRunes)
+	default:
+		run.Status = taskrun.StatusCompleted
+		run.Error = ""
+		run.Summary = summarizeText(output, defaultStoredSummaryRunes)
+	}
+	return run
+}
+
+// watchLoop notifies the observer of lifecycle updates written by any
+// replica and wakes local waiters of runs finished elsewhere.
+func (s *Service) watchLoop(ctx context.Context, since time.Time) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		polledAt := s.opts.Clock()
+		cutoff := since.Add(-observerLookback)
+		records, err := s.store.UpdatedSince(ctx, cutoff)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warnf("taskrun: poll run updates failed: %v", err)
+			}
+			continue
+		}
+		for _, rec := range records {
+			s.notify(ctx, rec.Run)
+			if rec.Run.Status.IsTerminal() {
+				s.wake(rec.Run.ID)
+			}
+		}
+		s.pruneSeen(cutoff)
+		since = polledAt
+	}
+}
+
+func (s *Service) pruneSeen(cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, seen := range s.seen {
+		if seen.updatedAt.Before(cutoff) {
+			delete(s.seen, id)
+		}
+	}
+}
+
+func (s *Service) isActive(runID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active[runID] != nil
+}
+
+func (s *Service) setProgress(runID string, progress *taskrun.Progress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active := s.active[runID]; active != nil {
+		active.progress = progress
+	}
+}
+
+func (s *Service) progress(runID string) *taskrun.Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active := s.active[runID]; active != nil {
+		return cloneProgress(active.progress)
+	}
+	return nil
+}
+
+// interrupt cancels a child run executed by this replica after Cancel.
+func (s *Service) interrupt(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active := s.active[runID]; active != nil {
+		active.cancelRequested = true
+		active.cancel()
+	}
+}
+
+// loseLease stops a child run whose lease was taken over by another worker.
+func (s *Service) loseLease(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active := s.active[runID]; active != nil {
+		active.lost = true
+		active.cancel()
+	}
+}
+
+func runtimeStateForRun(
+	run *taskrun.Run,
+	extra map[string]any,
+	keys taskrun.RuntimeStateKeys,
+) map[string]any {
+	state := make(map[string]any, len(extra)+3)
+	for key, value := range extra {
+		state[key] = value
+	}
+	if keys.Run == "" && keys.RunID == "" && keys.ParentSessionID == "" {
+		keys = taskrun.RuntimeStateKeys{
+			Run:             taskrun.RuntimeStateKeyRun,
+			RunID:           taskrun.RuntimeStateKeyRunID,
+			ParentSessionID: taskrun.RuntimeStateKeyParentSessionID,
+		}
+	}
+	if keys.Run != "" {
+		state[keys.Run] = true
+	}
+	if keys.RunID != "" {
+		state[keys.RunID] = run.ID
+	}
+	if keys.ParentSessionID != "" {
+		state[keys.ParentSessionID] = run.ParentSessionID
+	}
+	return state
+}
+
+func newChildSessionID(runID string, now time.Time) string {
+	return fmt.Sprintf("%s%s:%d", childSessionPrefix, runID, now.UnixNano())
+}
+
+func newRequestID(runID string, now time.Time) string {
+	return fmt.Sprintf("%s%s:%d", requestIDPrefix, runID, now.UnixNano())
+}
@@ -0,0 +1,24 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package local
+
+import "errors"
+
+// Sentinel errors for input validation and quotas.
+var (
+	ErrEmptyRootDir      = errors.New("local artifact: root directory cannot be empty")
+	ErrEmptyFilename     = errors.New("local artifact: filename cannot be empty")
+	ErrInvalidFilename   = errors.New("local artifact: filename contains invalid characters")
+	ErrNilArtifact       = errors.New("local artifact: artifact cannot be nil")
+	ErrEmptySessionInfo  = errors.New("local artifact: session info fields cannot be empty")
+	ErrInvalidSession    = errors.New("local artifact: session info contains invalid path elements")
+	ErrArtifactTooLarge  = errors.New("local artifact: artifact exceeds the maximum size")
+	ErrUserQuotaExceeded = errors.New("local artifact: user storage quota exceeded")
+)
@@ -0,0 +1,67 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package local
+
+import "os"
+
+const (
+	defaultDirMode  os.FileMode = 0o755
+	defaultFileMode os.FileMode = 0o644
+)
+
+// Option defines a function type for configuring the local artifact service.
+type Option func(*options)
+
+// options holds the configuration options for the local artifact service.
+type options struct {
+	// maxArtifactSize limits the size in bytes of a single artifact version.
+	// Zero means unlimited.
+	maxArtifactSize int64
+	// userQuota limits the total size in bytes of all artifact versions
+	// stored for one app/user pair, across sessions. Zero means unlimited.
+	userQuota int64
+	dirMode   os.FileMode
+	fileMode  os.FileMode
+}
+
+// WithMaxArtifactSize limits the size in bytes of a single artifact version.
+// Saving a larger artifact fails with ErrArtifactTooLarge.
+// Zero or negative means unlimited, which is the default.
+func WithMaxArtifactSize(size int64) Option {
+	return func(o *options) {
+		o.maxArtifactSize = size
+	}
+}
+
+// WithUserQuota limits the total size in bytes of all artifact versions
+// stored for one app/user pair, including every session and the "user:"
+// namespace. Saving past the quota fails with ErrUserQuotaExceeded.
+// Zero or negative means unlimited, which is the default.
+func WithUserQuota(size int64) Option {
+	return func(o *options) {
+		o.userQuota = size
+	}
+}
+
+// WithDirMode sets the permission bits of created directories.
+// Default is 0755.
+func WithDirMode(mode os.FileMode) Option {
+	return func(o *options) {
+		o.dirMode = mode
+	}
+}
+
+// WithFileMode sets the permission bits of created files.
+// Default is 0644.
+func WithFileMode(mode os.FileMode) Option {
+	return func(o *options) {
+		o.fileMode = mode
+	}
+}
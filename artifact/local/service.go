@@ -0,0 +1,531 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package local provides a local filesystem implementation of the artifact service.
+//
+// Artifacts are stored under a root directory:
+//   - For files with user namespace (starting with "user:"):
+//     {root}/{app_name}/{user_id}/user/{filename}/{version}
+//   - For regular session-scoped files:
+//     {root}/{app_name}/{user_id}/sessions/{session_id}/{filename}/{version}
+//
+// Session directories live under their own "sessions" directory, so no
+// session ID can collide with the user namespace directory.
+//
+// Every version has a "{version}.meta.json" sidecar holding its MIME type
+// and display name. Path elements are percent-encoded, so filenames may
+// contain "/" without escaping the root directory.
+//
+// Versions are written to a temporary file and then hard linked into place,
+// so readers never observe partial data and concurrent writers, including
+// other processes sharing the directory, never overwrite each other.
+//
+// Example:
+//
+//	service, err := local.NewService("/var/lib/myapp/artifacts",
+//	    local.WithMaxArtifactSize(10<<20),
+//	    local.WithUserQuota(1<<30),
+//	)
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/artifact"
+	iartifact "trpc.group/trpc-go/trpc-agent-go/internal/artifact"
+)
+
+const (
+	defaultContentType = "application/octet-stream"
+	userNamespaceDir   = "user"
+	sessionsDir        = "sessions"
+	metaSuffix         = ".meta.json"
+	tempPattern        = ".tmp-*"
+)
+
+// metadata is the sidecar stored next to every artifact version.
+type metadata struct {
+	MimeType  string    `json:"mime_type,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Service is a local filesystem implementation of the artifact service.
+// It is suitable for single-node deployments and offline tests.
+type Service struct {
+	root string
+	opts options
+	// mu serializes writers of this service so that quota checks and
+	// version allocation see a consistent view.
+	mu sync.Mutex
+}
+
+// NewService creates a new local artifact service rooted at dir.
+// The directory is created if it does not exist.
+func NewService(dir string, opts ...Option) (*Service, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, ErrEmptyRootDir
+	}
+	o := options{
+		dirMode:  defaultDirMode,
+		fileMode: defaultFileMode,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("local artifact: resolve root directory: %w", err)
+	}
+	if err := os.MkdirAll(root, o.dirMode); err != nil {
+		return nil, fmt.Errorf("local artifact: create root directory: %w", err)
+	}
+	return &Service{root: root, opts: o}, nil
+}
+
+// Root returns the absolute root directory of the service.
+func (s *Service) Root() string {
+	return s.root
+}
+
+// SaveArtifact saves an artifact to the local filesystem.
+func (s *Service) SaveArtifact(
+	ctx context.Context,
+	sessionInfo artifact.SessionInfo,
+	filename string,
+	art *artifact.Artifact,
+) (int, error) {
+	if art == nil {
+		return 0, ErrNilArtifact
+	}
+	dir, err := s.artifactDir(sessionInfo, filename)
+	if err != nil {
+		return 0, err
+	}
+	size := int64(len(art.Data))
+	if s.opts.maxArtifactSize > 0 && size > s.opts.maxArtifactSize {
+		return 0, fmt.Errorf("%w: %d > %d bytes", ErrArtifactTooLarge, size, s.opts.maxArtifactSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opts.userQuota > 0 {
+		used, err := s.userUsage(sessionInfo)
+		if err != nil {
+			return 0, err
+		}
+		if used+size > s.opts.userQuota {
+			return 0, fmt.Errorf("%w: %d + %d > %d bytes", ErrUserQuotaExceeded, used, size, s.opts.userQuota)
+		}
+	}
+
+	if err := os.MkdirAll(dir, s.opts.dirMode); err != nil {
+		return 0, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	tmp, err := s.writeTemp(dir, art.Data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	versions, err := listVersions(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list versions: %w", err)
+	}
+	version := 0
+	if len(versions) > 0 {
+		version = versions[len(versions)-1] + 1
+	}
+	// Linking fails if the version already exists, so writers in other
+	// processes can never replace each other's data.
+	for {
+		err := os.Link(tmp, versionPath(dir, version))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return 0, fmt.Errorf("failed to commit artifact version %d: %w", version, err)
+		}
+		version++
+	}
+
+	meta := metadata{
+		MimeType:  art.MimeType,
+		Name:      art.Name,
+		URL:       art.URL,
+		Size:      size,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.writeMetadata(dir, version, meta); err != nil {
+		return 0, fmt.Errorf("failed to write artifact metadata: %w", err)
+	}
+	return version, nil
+}
+
+// LoadArtifact gets an artifact from the local filesystem.
+func (s *Service) LoadArtifact(
+	ctx context.Context,
+	sessionInfo artifact.SessionInfo,
+	filename string,
+	version *int,
+) (*artifact.Artifact, error) {
+	dir, err := s.artifactDir(sessionInfo, filename)
+	if err != nil {
+		return nil, err
+	}
+	var target int
+	if version == nil {
+		versions, err := listVersions(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions: %w", err)
+		}
+		if len(versions) == 0 {
+			return nil, nil // Artifact not found
+		}
+		target = versions[len(versions)-1]
+	} else {
+		target = *version
+		if target < 0 {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(versionPath(dir, target))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil // Artifact not found
+		}
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	meta, err := readMetadata(dir, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+	art := &artifact.Artifact{
+		Data:     data,
+		MimeType: meta.MimeType,
+		URL:      meta.URL,
+		Name:     meta.Name,
+	}
+	if art.MimeType == "" {
+		art.MimeType = defaultContentType
+	}
+	if art.Name == "" {
+		art.Name = filename
+	}
+	return art, nil
+}
+
+// ListArtifactKeys lists all the artifact filenames within a session,
+// including the user-namespaced artifacts of the session's user.
+func (s *Service) ListArtifactKeys(
+	ctx context.Context,
+	sessionInfo artifact.SessionInfo,
+) ([]string, error) {
+	userDir, err := s.userDir(sessionInfo)
+	if err != nil {
+		return nil, err
+	}
+	sessionDir := filepath.Join(userDir, sessionsDir, encodeElem(sessionInfo.SessionID))
+	namespaceDir := filepath.Join(userDir, userNamespaceDir)
+
+	filenameSet := make(map[string]struct{})
+	for _, dir := range []string{sessionDir, namespaceDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list artifacts: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			name, err := url.PathUnescape(entry.Name())
+			if err != nil {
+				continue
+			}
+			// The user namespace directory only holds "user:" files, and a
+			// session directory never does.
+			if (dir == namespaceDir) != iartifact.FileHasUserNamespace(name) {
+				continue
+			}
+			filenameSet[name] = struct{}{}
+		}
+	}
+
+	filenames := make([]string, 0, len(filenameSet))
+	for name := range filenameSet {
+		filenames = append(filenames, name)
+	}
+	sort.Strings(filenames)
+	return filenames, nil
+}
+
+// DeleteArtifact deletes all versions of an artifact.
+// Deleting an artifact that does not exist is not an error.
+func (s *Service) DeleteArtifact(
+	ctx context.Context,
+	sessionInfo artifact.SessionInfo,
+	filename string,
+) error {
+	dir, err := s.artifactDir(sessionInfo, filename)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete artifact: %w", err)
+	}
+	return nil
+}
+
+// DeleteVersion deletes a single version of an artifact. The artifact is
+// removed entirely when its last version is deleted. Deleting a version
+// that does not exist is not an error.
+func (s *Service) DeleteVersion(
+	ctx context.Context,
+	sessionInfo artifact.SessionInfo,
+	filename string,
+	version int,
+) error {
+	dir, err := s.artifactDir(sessionInfo, filename)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range []string{versionPath(dir, version), metadataPath(dir, version)} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete artifact version %d: %w", version, err)
+		}
+	}
+	versions, err := listVersions(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list versions: %w", err)
+	}
+	if len(versions) == 0 {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to delete artifact: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListVersions lists all versions of an artifact in ascending order.
+func (s *Service) ListVersions(
+	ctx context.Context,
+	sessionInfo artifact.SessionInfo,
+	filename string,
+) ([]int, error) {
+	dir, err := s.artifactDir(sessionInfo, filename)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := listVersions(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	return versions, nil
+}
+
+// Usage returns the total size in bytes of all artifact versions stored for
+// the app/user pair of sessionInfo. This is the value checked against
+// WithUserQuota.
+func (s *Service) Usage(ctx context.Context, sessionInfo artifact.SessionInfo) (int64, error) {
+	return s.userUsage(sessionInfo)
+}
+
+func (s *Service) userDir(info artifact.SessionInfo) (string, error) {
+	if info.AppName == "" || info.UserID == "" || info.SessionID == "" {
+		return "", ErrEmptySessionInfo
+	}
+	for _, elem := range []string{info.AppName, info.UserID, info.SessionID} {
+		if !validElem(elem) {
+			return "", ErrInvalidSession
+		}
+	}
+	return filepath.Join(s.root, encodeElem(info.AppName), encodeElem(info.UserID)), nil
+}
+
+func (s *Service) artifactDir(info artifact.SessionInfo, filename string) (string, error) {
+	userDir, err := s.userDir(info)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(filename) == "" {
+		return "", ErrEmptyFilename
+	}
+	if !validElem(filename) {
+		return "", ErrInvalidFilename
+	}
+	if iartifact.FileHasUserNamespace(filename) {
+		return filepath.Join(userDir, userNamespaceDir, encodeElem(filename)), nil
+	}
+	return filepath.Join(userDir, sessionsDir, encodeElem(info.SessionID), encodeElem(filename)), nil
+}
+
+func (s *Service) userUsage(info artifact.SessionInfo) (int64, error) {
+	userDir, err := s.userDir(info)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	err = filepath.WalkDir(userDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := parseVersion(d.Name()); !ok {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		total += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute storage usage: %w", err)
+	}
+	return total, nil
+}
+
+// writeTemp writes data to a synced temporary file in dir and returns its
+// path.
+func (s *Service) writeTemp(dir string, data []byte) (string, error) {
+	f, err := os.CreateTemp(dir, tempPattern)
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(name, s.opts.fileMode)
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// writeMetadata atomically replaces the metadata sidecar of a version.
+func (s *Service) writeMetadata(dir string, version int, meta metadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	tmp, err := s.writeTemp(dir, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, metadataPath(dir, version)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// readMetadata reads the sidecar of a version. A missing sidecar yields
+// empty metadata, e.g. while a concurrent save is still writing it.
+func readMetadata(dir string, version int) (metadata, error) {
+	var meta metadata
+	data, err := os.ReadFile(metadataPath(dir, version))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return meta, nil
+		}
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, err
+	}
+	return meta, nil
+}
+
+// listVersions returns the committed versions in dir in ascending order.
+func listVersions(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []int{}, nil
+		}
+		return nil, err
+	}
+	versions := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if v, ok := parseVersion(entry.Name()); ok {
+			versions = append(versions, v)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+func parseVersion(name string) (int, bool) {
+	v, err := strconv.Atoi(name)
+	if err != nil || v < 0 || strconv.Itoa(v) != name {
+		return 0, false
+	}
+	return v, true
+}
+
+func versionPath(dir string, version int) string {
+	return filepath.Join(dir, strconv.Itoa(version))
+}
+
+func metadataPath(dir string, version int) string {
+	return filepath.Join(dir, strconv.Itoa(version)+metaSuffix)
+}
+
+// encodeElem percent-encodes a path element so that it maps to exactly one
+// directory name on every platform.
+func encodeElem(elem string) string {
+	return strings.ReplaceAll(url.PathEscape(elem), ":", "%3A")
+}
+
+func validElem(elem string) bool {
+	return elem != "." && elem != ".." && !strings.Contains(elem, "\x00")
+}
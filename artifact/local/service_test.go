@@ -0,0 +1,317 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/artifact"
+)
+
+var testSession = artifact.SessionInfo{
+	AppName:   "app",
+	UserID:    "user1",
+	SessionID: "sess1",
+}
+
+func newTestService(t *testing.T, opts ...Option) *Service {
+	t.Helper()
+	s, err := NewService(t.TempDir(), opts...)
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewService_EmptyRoot(t *testing.T) {
+	_, err := NewService(" ")
+	assert.ErrorIs(t, err, ErrEmptyRootDir)
+}
+
+func TestService_SaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	v, err := s.SaveArtifact(ctx, testSession, "report.txt", &artifact.Artifact{
+		Data:     []byte("v0"),
+		MimeType: "text/plain",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, v)
+
+	v, err = s.SaveArtifact(ctx, testSession, "report.txt", &artifact.Artifact{
+		Data:     []byte("v1"),
+		MimeType: "text/markdown",
+		Name:     "Report",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	latest, err := s.LoadArtifact(ctx, testSession, "report.txt", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), latest.Data)
+	assert.Equal(t, "text/markdown", latest.MimeType)
+	assert.Equal(t, "Report", latest.Name)
+
+	first := 0
+	old, err := s.LoadArtifact(ctx, testSession, "report.txt", &first)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v0"), old.Data)
+	assert.Equal(t, "text/plain", old.MimeType)
+	assert.Equal(t, "report.txt", old.Name)
+
+	missing := 7
+	art, err := s.LoadArtifact(ctx, testSession, "report.txt", &missing)
+	require.NoError(t, err)
+	assert.Nil(t, art)
+	art, err = s.LoadArtifact(ctx, testSession, "nope.txt", nil)
+	require.NoError(t, err)
+	assert.Nil(t, art)
+
+	versions, err := s.ListVersions(ctx, testSession, "report.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, versions)
+}
+
+func TestService_PersistsAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s1, err := NewService(dir)
+	require.NoError(t, err)
+	_, err = s1.SaveArtifact(ctx, testSession, "a.bin", &artifact.Artifact{Data: []byte{1, 2, 3}})
+	require.NoError(t, err)
+
+	s2, err := NewService(dir)
+	require.NoError(t, err)
+	art, err := s2.LoadArtifact(ctx, testSession, "a.bin", nil)
+	require.NoError(t, err)
+	require.NotNil(t, art)
+	assert.Equal(t, []byte{1, 2, 3}, art.Data)
+	assert.Equal(t, defaultContentType, art.MimeType)
+
+	v, err := s2.SaveArtifact(ctx, testSession, "a.bin", &artifact.Artifact{Data: []byte{4}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestService_Layout(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.SaveArtifact(ctx, testSession, "notes.txt", &artifact.Artifact{Data: []byte("x"), MimeType: "text/plain"})
+	require.NoError(t, err)
+	_, err = s.SaveArtifact(ctx, testSession, "user:profile.json", &artifact.Artifact{Data: []byte("{}")})
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(s.Root(), "app", "user1", "sessions", "sess1", "notes.txt", "0"))
+	assert.FileExists(t, filepath.Join(s.Root(), "app", "user1", "sessions", "sess1", "notes.txt", "0"+metaSuffix))
+	assert.FileExists(t, filepath.Join(s.Root(), "app", "user1", "user", "user%3Aprofile.json", "0"))
+
+	entries, err := os.ReadDir(filepath.Join(s.Root(), "app", "user1", "sessions", "sess1", "notes.txt"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "temporary files must be cleaned up")
+}
+
+func TestService_FilenameCannotEscapeRoot(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.SaveArtifact(ctx, testSession, "../../../etc/passwd", &artifact.Artifact{Data: []byte("x")})
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(s.Root(), "app", "user1", "sessions", "sess1", "..%2F..%2F..%2Fetc%2Fpasswd"))
+
+	keys, err := s.ListArtifactKeys(ctx, testSession)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"../../../etc/passwd"}, keys)
+
+	_, err = s.SaveArtifact(ctx, testSession, "..", &artifact.Artifact{Data: []byte("x")})
+	assert.ErrorIs(t, err, ErrInvalidFilename)
+	_, err = s.SaveArtifact(ctx, artifact.SessionInfo{AppName: "app", UserID: "..", SessionID: "s"},
+		"a.txt", &artifact.Artifact{Data: []byte("x")})
+	assert.ErrorIs(t, err, ErrInvalidSession)
+}
+
+func TestService_ListArtifactKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	other := testSession
+	other.SessionID = "sess2"
+
+	for _, name := range []string{"b.txt", "a.txt", "user:shared.txt"} {
+		_, err := s.SaveArtifact(ctx, testSession, name, &artifact.Artifact{Data: []byte(name)})
+		require.NoError(t, err)
+	}
+	_, err := s.SaveArtifact(ctx, other, "c.txt", &artifact.Artifact{Data: []byte("c")})
+	require.NoError(t, err)
+
+	keys, err := s.ListArtifactKeys(ctx, testSession)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "b.txt", "user:shared.txt"}, keys)
+
+	keys, err = s.ListArtifactKeys(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c.txt", "user:shared.txt"}, keys)
+
+	// User-namespaced artifacts are visible from every session of the user.
+	art, err := s.LoadArtifact(ctx, other, "user:shared.txt", nil)
+	require.NoError(t, err)
+	require.NotNil(t, art)
+	assert.Equal(t, []byte("user:shared.txt"), art.Data)
+}
+
+func TestService_SessionNamedUser(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	reserved := testSession
+	reserved.SessionID = "user"
+
+	_, err := s.SaveArtifact(ctx, reserved, "private.txt", &artifact.Artifact{Data: []byte("p")})
+	require.NoError(t, err)
+	_, err = s.SaveArtifact(ctx, testSession, "user:shared.txt", &artifact.Artifact{Data: []byte("s")})
+	require.NoError(t, err)
+
+	keys, err := s.ListArtifactKeys(ctx, testSession)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user:shared.txt"}, keys)
+
+	keys, err = s.ListArtifactKeys(ctx, reserved)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"private.txt", "user:shared.txt"}, keys)
+
+	art, err := s.LoadArtifact(ctx, testSession, "private.txt", nil)
+	require.NoError(t, err)
+	assert.Nil(t, art)
+}
+
+func TestService_Delete(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	for i := 0; i < 3; i++ {
+		_, err := s.SaveArtifact(ctx, testSession, "a.txt", &artifact.Artifact{Data: []byte{byte(i)}})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.DeleteVersion(ctx, testSession, "a.txt", 1))
+	versions, err := s.ListVersions(ctx, testSession, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, versions)
+
+	// New versions never reuse a number below the latest one.
+	v, err := s.SaveArtifact(ctx, testSession, "a.txt", &artifact.Artifact{Data: []byte{9}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	require.NoError(t, s.DeleteArtifact(ctx, testSession, "a.txt"))
+	versions, err = s.ListVersions(ctx, testSession, "a.txt")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+	keys, err := s.ListArtifactKeys(ctx, testSession)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, s.DeleteArtifact(ctx, testSession, "missing.txt"))
+
+	_, err = s.SaveArtifact(ctx, testSession, "b.txt", &artifact.Artifact{Data: []byte("b")})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteVersion(ctx, testSession, "b.txt", 0))
+	assert.NoDirExists(t, filepath.Join(s.Root(), "app", "user1", "sessions", "sess1", "b.txt"))
+}
+
+func TestService_Quotas(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithMaxArtifactSize(4), WithUserQuota(6))
+
+	_, err := s.SaveArtifact(ctx, testSession, "big.bin", &artifact.Artifact{Data: make([]byte, 5)})
+	assert.ErrorIs(t, err, ErrArtifactTooLarge)
+
+	_, err = s.SaveArtifact(ctx, testSession, "a.bin", &artifact.Artifact{Data: make([]byte, 4)})
+	require.NoError(t, err)
+	other := testSession
+	other.SessionID = "sess2"
+	_, err = s.SaveArtifact(ctx, other, "user:b.bin", &artifact.Artifact{Data: make([]byte, 2)})
+	require.NoError(t, err)
+
+	used, err := s.Usage(ctx, testSession)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), used)
+
+	_, err = s.SaveArtifact(ctx, testSession, "c.bin", &artifact.Artifact{Data: make([]byte, 1)})
+	assert.ErrorIs(t, err, ErrUserQuotaExceeded)
+
+	// Quotas are per user.
+	otherUser := testSession
+	otherUser.UserID = "user2"
+	_, err = s.SaveArtifact(ctx, otherUser, "c.bin", &artifact.Artifact{Data: make([]byte, 1)})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteArtifact(ctx, testSession, "a.bin"))
+	_, err = s.SaveArtifact(ctx, testSession, "c.bin", &artifact.Artifact{Data: make([]byte, 1)})
+	require.NoError(t, err)
+}
+
+func TestService_ConcurrentSaves(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	// Two services on the same directory behave like two processes.
+	s1, err := NewService(dir)
+	require.NoError(t, err)
+	s2, err := NewService(dir)
+	require.NoError(t, err)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := s1
+			if i%2 == 1 {
+				s = s2
+			}
+			_, err := s.SaveArtifact(ctx, testSession, "log.txt", &artifact.Artifact{Data: []byte(fmt.Sprint(i))})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	versions, err := s1.ListVersions(ctx, testSession, "log.txt")
+	require.NoError(t, err)
+	require.Len(t, versions, n)
+	seen := make(map[string]bool)
+	for _, v := range versions {
+		v := v
+		art, err := s1.LoadArtifact(ctx, testSession, "log.txt", &v)
+		require.NoError(t, err)
+		seen[string(art.Data)] = true
+	}
+	assert.Len(t, seen, n, "no save may overwrite another")
+}
+
+func TestService_Validation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.SaveArtifact(ctx, testSession, "a.txt", nil)
+	assert.ErrorIs(t, err, ErrNilArtifact)
+	_, err = s.SaveArtifact(ctx, testSession, " ", &artifact.Artifact{})
+	assert.ErrorIs(t, err, ErrEmptyFilename)
+	_, err = s.LoadArtifact(ctx, artifact.SessionInfo{AppName: "app"}, "a.txt", nil)
+	assert.ErrorIs(t, err, ErrEmptySessionInfo)
+	_, err = s.ListArtifactKeys(ctx, artifact.SessionInfo{})
+	assert.ErrorIs(t, err, ErrEmptySessionInfo)
+	_, err = s.ListVersions(ctx, testSession, "bad\x00name")
+	assert.ErrorIs(t, err, ErrInvalidFilename)
+}
@@ -0,0 +1,220 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package redis provides a Redis-backed graph.Cache for node-result caching.
+//
+// Entries survive restarts and are shared by every replica connected to the
+// same redis. Each entry is a string key with its own TTL; a set per
+// namespace indexes the entries so that Clear removes exactly one namespace.
+// Keys of one namespace share a hash tag and live in the same cluster slot:
+//
+//	{prefix}{ns}          set of cache keys in the namespace
+//	{prefix}{ns}:{key}    serialized node result
+//
+// Namespaces already include the graph version set via
+// StateGraph.WithGraphVersion, so bumping the version stops reusing entries
+// written by older graphs; they expire through their TTL.
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/internal/graphcache"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+// backendName is reported as the backend of the cache metrics.
+const backendName = "redis"
+
+var luaSet = redis.NewScript(`
+-- Store an entry and index it in its namespace.
+--
+-- KEYS[1] = entry key
+-- KEYS[2] = namespace index key
+--
+-- ARGV[1] = value
+-- ARGV[2] = ttl_ms (0 means no expiration)
+-- ARGV[3] = cache key (index member)
+local ttl = tonumber(ARGV[2])
+local existed = redis.call('EXISTS', KEYS[2])
+
+if ttl > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+redis.call('SADD', KEYS[2], ARGV[3])
+
+-- The index must live at least as long as its longest-lived entry.
+if ttl > 0 then
+  local cur = redis.call('PTTL', KEYS[2])
+  if existed == 0 or (cur > 0 and cur < ttl) then
+    redis.call('PEXPIRE', KEYS[2], ttl)
+  end
+else
+  redis.call('PERSIST', KEYS[2])
+end
+return 1
+`)
+
+var luaClear = redis.NewScript(`
+-- Delete every entry of a namespace and its index.
+--
+-- KEYS[1] = namespace index key
+local members = redis.call('SMEMBERS', KEYS[1])
+for _, m in ipairs(members) do
+  redis.call('DEL', KEYS[1] .. ':' .. m)
+end
+redis.call('DEL', KEYS[1])
+return #members
+`)
+
+var _ graph.Cache = (*Cache)(nil)
+
+// Cache is a graph.Cache backed by redis.
+type Cache struct {
+	opts    Options
+	client  redis.UniversalClient
+	metrics *graphcache.Metrics
+}
+
+// NewCache creates a new redis graph cache.
+func NewCache(options ...Option) (*Cache, error) {
+	opts := defaultOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.serializer == nil {
+		opts.serializer = graph.NewJSONCacheSerializer()
+	}
+
+	builderOpts := []storage.ClientBuilderOpt{
+		storage.WithClientBuilderURL(opts.url),
+		storage.WithExtraOptions(opts.extraOptions...),
+	}
+
+	// if instance name set, and url not set, use instance name to create redis client
+	if opts.url == "" && opts.instanceName != "" {
+		var ok bool
+		if builderOpts, ok = storage.GetRedisInstance(opts.instanceName); !ok {
+			return nil, fmt.Errorf("redis instance %s not found", opts.instanceName)
+		}
+	}
+
+	redisClient, err := storage.GetClientBuilder()(builderOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create redis client from url failed: %w", err)
+	}
+	return &Cache{
+		opts:    opts,
+		client:  redisClient,
+		metrics: graphcache.NewMetrics(backendName, opts.meterProvider),
+	}, nil
+}
+
+// Get implements graph.Cache.
+func (c *Cache) Get(ns, key string) (any, bool) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	data, err := c.client.Get(ctx, c.entryKey(ns, key)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.metrics.Error(ns, graphcache.OperationGet)
+			log.Warnf("graph redis cache: get %s/%s failed: %v", ns, key, err)
+		}
+		c.metrics.Miss(ns)
+		return nil, false
+	}
+	val, err := c.opts.serializer.Unmarshal(data)
+	if err != nil {
+		c.metrics.Error(ns, graphcache.OperationDecode)
+		c.metrics.Miss(ns)
+		log.Warnf("graph redis cache: decode %s/%s failed: %v", ns, key, err)
+		return nil, false
+	}
+	c.metrics.Hit(ns)
+	return val, true
+}
+
+// Set implements graph.Cache. Values that cannot be serialized are skipped.
+func (c *Cache) Set(ns, key string, val any, ttl time.Duration) {
+	data, err := c.opts.serializer.Marshal(val)
+	if err != nil {
+		c.metrics.Error(ns, graphcache.OperationEncode)
+		log.Warnf("graph redis cache: encode %s/%s failed: %v", ns, key, err)
+		return
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	keys := []string{c.entryKey(ns, key), c.indexKey(ns)}
+	if err := luaSet.Run(ctx, c.client, keys, data, ttlMilliseconds(ttl), key).Err(); err != nil {
+		c.metrics.Error(ns, graphcache.OperationSet)
+		log.Warnf("graph redis cache: set %s/%s failed: %v", ns, key, err)
+		return
+	}
+	c.metrics.Set(ns)
+}
+
+// Clear implements graph.Cache.
+func (c *Cache) Clear(ns string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	if err := luaClear.Run(ctx, c.client, []string{c.indexKey(ns)}).Err(); err != nil {
+		c.metrics.Error(ns, graphcache.OperationClear)
+		log.Warnf("graph redis cache: clear %s failed: %v", ns, err)
+	}
+}
+
+// Stats returns the hit, miss, set and error counters of this cache
+// instance. The same accesses are exported as OpenTelemetry counters, see
+// WithMeterProvider.
+func (c *Cache) Stats() graph.CacheStats {
+	return c.metrics.Stats()
+}
+
+// Close closes the redis client.
+func (c *Cache) Close() error {
+	if c.client != nil {
+		return c.client.Close()
+	}
+	return nil
+}
+
+func (c *Cache) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.opts.timeout)
+}
+
+func (c *Cache) indexKey(ns string) string {
+	return c.opts.keyPrefix + "{" + ns + "}"
+}
+
+func (c *Cache) entryKey(ns, key string) string {
+	return c.indexKey(ns) + ":" + key
+}
+
+// ttlMilliseconds converts ttl to whole milliseconds, rounding sub
+// millisecond TTLs up so they do not become "no expiration".
+func ttlMilliseconds(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	ms := ttl.Milliseconds()
+	if ms == 0 {
+		return 1
+	}
+	return ms
+}
@@ -0,0 +1,213 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redis
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+)
+
+func setupTestRedis(t *testing.T) (*miniredis.Miniredis, string) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	return mr, "redis://" + mr.Addr()
+}
+
+func newTestCache(t *testing.T, url string, opts ...Option) *Cache {
+	t.Helper()
+	c, err := NewCache(append([]Option{WithRedisClientURL(url)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestNewCache_Errors(t *testing.T) {
+	_, err := NewCache(WithRedisClientURL(""))
+	require.Error(t, err)
+	_, err = NewCache(WithRedisInstance("no-instance"))
+	require.Error(t, err)
+}
+
+func TestNewCache_WithRedisInstance(t *testing.T) {
+	_, url := setupTestRedis(t)
+	storage.RegisterRedisInstance("graph-cache-test", storage.WithClientBuilderURL(url))
+	c, err := NewCache(WithRedisInstance("graph-cache-test"))
+	require.NoError(t, err)
+	defer c.Close()
+	c.Set("ns", "k", "v", 0)
+	v, ok := c.Get("ns", "k")
+	require.True(t, ok)
+	assert.Equal(t, "v", v)
+}
+
+func TestCache_GetSetClear(t *testing.T) {
+	mr, url := setupTestRedis(t)
+	c := newTestCache(t, url, WithKeyPrefix("app:"))
+
+	_, ok := c.Get("ns", "k")
+	assert.False(t, ok)
+
+	c.Set("ns", "k", graph.State{"a": "x"}, 0)
+	c.Set("ns", "k2", &graph.Command{GoTo: "next"}, 0)
+	c.Set("other", "k", "kept", 0)
+	assert.True(t, mr.Exists("app:{ns}:k"))
+	members, err := mr.SMembers("app:{ns}")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k", "k2"}, members)
+
+	v, ok := c.Get("ns", "k")
+	require.True(t, ok)
+	assert.Equal(t, graph.State{"a": "x"}, v)
+	v, ok = c.Get("ns", "k2")
+	require.True(t, ok)
+	assert.Equal(t, &graph.Command{GoTo: "next"}, v)
+
+	c.Clear("ns")
+	_, ok = c.Get("ns", "k")
+	assert.False(t, ok)
+	assert.False(t, mr.Exists("app:{ns}"))
+	v, ok = c.Get("other", "k")
+	require.True(t, ok)
+	assert.Equal(t, "kept", v)
+
+	assert.Equal(t, graph.CacheStats{Hits: 3, Misses: 2, Sets: 3}, c.Stats())
+}
+
+func TestCache_TTL(t *testing.T) {
+	mr, url := setupTestRedis(t)
+	c := newTestCache(t, url)
+
+	c.Set("ns", "short", "v", time.Second)
+	c.Set("ns", "long", "v", time.Minute)
+	assert.Equal(t, time.Minute, mr.TTL("graph_cache:{ns}"), "index outlives its entries")
+
+	mr.FastForward(2 * time.Second)
+	_, ok := c.Get("ns", "short")
+	assert.False(t, ok)
+	_, ok = c.Get("ns", "long")
+	assert.True(t, ok)
+
+	c.Set("ns", "forever", "v", 0)
+	assert.Equal(t, time.Duration(0), mr.TTL("graph_cache:{ns}"))
+	mr.FastForward(time.Hour)
+	_, ok = c.Get("ns", "forever")
+	assert.True(t, ok)
+}
+
+func TestCache_Errors(t *testing.T) {
+	mr, url := setupTestRedis(t)
+	reader := sdkmetric.NewManualReader()
+	c := newTestCache(t, url, WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+
+	c.Set("ns", "k", graph.State{"fn": func() {}}, 0)
+	assert.Equal(t, int64(1), c.Stats().Errors)
+
+	require.NoError(t, mr.Set("graph_cache:{ns}:bad", "not json"))
+	_, ok := c.Get("ns", "bad")
+	assert.False(t, ok)
+	assert.Equal(t, int64(2), c.Stats().Errors)
+
+	mr.Close()
+	c.Set("ns", "k", "v", 0)
+	_, ok = c.Get("ns", "k")
+	assert.False(t, ok)
+	c.Clear("ns")
+	assert.Equal(t, int64(5), c.Stats().Errors)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	errs := make(map[string]int64)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name != metrics.MetricTRPCAgentGoGraphCacheErrorCnt {
+			continue
+		}
+		for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+			op, _ := dp.Attributes.Value(attribute.Key(metrics.KeyTRPCAgentGoGraphCacheOperation))
+			backend, _ := dp.Attributes.Value(attribute.Key(metrics.KeyTRPCAgentGoGraphCacheBackend))
+			assert.Equal(t, "redis", backend.AsString())
+			errs[op.AsString()] += dp.Value
+		}
+	}
+	assert.Equal(t, map[string]int64{"encode": 1, "decode": 1, "set": 1, "get": 1, "clear": 1}, errs)
+}
+
+func TestTTLMilliseconds(t *testing.T) {
+	assert.Equal(t, int64(0), ttlMilliseconds(0))
+	assert.Equal(t, int64(1), ttlMilliseconds(time.Microsecond))
+	assert.Equal(t, int64(1500), ttlMilliseconds(1500*time.Millisecond))
+}
+
+// TestCache_SharedAcrossReplicas runs the same graph on two executors with
+// separate cache clients connected to one redis.
+func TestCache_SharedAcrossReplicas(t *testing.T) {
+	_, url := setupTestRedis(t)
+	schema := graph.NewStateSchema().
+		AddField("n", graph.StateField{Type: reflect.TypeOf(0), Reducer: graph.DefaultReducer}).
+		AddField("out", graph.StateField{Type: reflect.TypeOf(0), Reducer: graph.DefaultReducer})
+
+	var calls atomic.Int32
+	var got any
+	newExecutor := func(version string) (*graph.Executor, *Cache) {
+		c := newTestCache(t, url)
+		sg := graph.NewStateGraph(schema).
+			WithCache(c).
+			WithGraphVersion(version)
+		sg.AddNode("work", func(ctx context.Context, st graph.State) (any, error) {
+			calls.Add(1)
+			return graph.State{"out": st["n"].(int) * 2}, nil
+		}, graph.WithNodeCachePolicy(graph.DefaultCachePolicy()), graph.WithCacheKeyFields("n"))
+		sg.AddNode("read", func(ctx context.Context, st graph.State) (any, error) {
+			got = st["out"]
+			return nil, nil
+		})
+		sg.SetEntryPoint("work").AddEdge("work", "read").SetFinishPoint("read")
+		g, err := sg.Compile()
+		require.NoError(t, err)
+		ex, err := graph.NewExecutor(g)
+		require.NoError(t, err)
+		return ex, c
+	}
+	run := func(ex *graph.Executor) {
+		ch, err := ex.Execute(context.Background(), graph.State{"n": 21}, &agent.Invocation{InvocationID: "inv"})
+		require.NoError(t, err)
+		for range ch {
+		}
+	}
+
+	ex1, c1 := newExecutor("v1")
+	ex2, c2 := newExecutor("v1")
+	run(ex1)
+	run(ex2)
+	assert.Equal(t, int32(1), calls.Load(), "second replica must reuse the cached result")
+	assert.Equal(t, 42, got)
+	assert.Equal(t, int64(1), c1.Stats().Sets)
+	assert.Equal(t, int64(1), c2.Stats().Hits)
+
+	ex3, _ := newExecutor("v2")
+	run(ex3)
+	assert.Equal(t, int32(2), calls.Load())
+}
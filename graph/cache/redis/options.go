@@ -0,0 +1,102 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redis
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+)
+
+const (
+	defaultKeyPrefix = "graph_cache:"
+	defaultTimeout   = 3 * time.Second
+)
+
+var defaultOptions = Options{
+	keyPrefix: defaultKeyPrefix,
+	timeout:   defaultTimeout,
+}
+
+// Options is the options for the redis graph cache.
+type Options struct {
+	url           string
+	instanceName  string
+	extraOptions  []any
+	keyPrefix     string
+	serializer    graph.CacheSerializer
+	timeout       time.Duration
+	meterProvider metric.MeterProvider
+}
+
+// Option is the option for the redis graph cache.
+type Option func(*Options)
+
+// WithRedisClientURL creates a redis client from URL and sets it to the cache.
+func WithRedisClientURL(url string) Option {
+	return func(opts *Options) {
+		opts.url = url
+	}
+}
+
+// WithRedisInstance uses a redis instance from storage.
+// Note: WithRedisClientURL has higher priority than WithRedisInstance.
+// If both are specified, WithRedisClientURL will be used.
+func WithRedisInstance(instanceName string) Option {
+	return func(opts *Options) {
+		opts.instanceName = instanceName
+	}
+}
+
+// WithExtraOptions sets the extra options for the redis graph cache.
+// this option mainly used for the customized redis client builder, it will be passed to the builder.
+func WithExtraOptions(extraOptions ...any) Option {
+	return func(opts *Options) {
+		opts.extraOptions = append(opts.extraOptions, extraOptions...)
+	}
+}
+
+// WithKeyPrefix sets the prefix of every key written by the cache, so that
+// several applications can share one redis database.
+// Default is "graph_cache:".
+func WithKeyPrefix(prefix string) Option {
+	return func(opts *Options) {
+		opts.keyPrefix = prefix
+	}
+}
+
+// WithSerializer sets how node results are encoded.
+// Default is graph.NewJSONCacheSerializer().
+func WithSerializer(s graph.CacheSerializer) Option {
+	return func(opts *Options) {
+		opts.serializer = s
+	}
+}
+
+// WithTimeout bounds every redis command, since graph.Cache methods do not
+// take a context. Default is 3s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		if timeout > 0 {
+			opts.timeout = timeout
+		}
+	}
+}
+
+// WithMeterProvider sets the meter provider that hit, miss, set and error
+// counters are exported to. Default is the global provider from
+// telemetry/metric.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(opts *Options) {
+		opts.meterProvider = mp
+	}
+}
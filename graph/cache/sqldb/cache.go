@@ -0,0 +1,307 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package sqldb provides a SQL-backed graph.Cache for node-result caching.
+//
+// Entries survive restarts and are shared by every replica using the same
+// database. It works on any database/sql handle; select the SQL flavor with
+// WithDialect:
+//
+//	db, _ := sql.Open("pgx", dsn)
+//	c, err := sqldb.NewCache(db, sqldb.WithDialect(sqldb.DialectPostgres))
+//	sg := graph.NewStateGraph(schema).WithCache(c).WithGraphVersion("v2")
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/internal/graphcache"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+)
+
+// backendName is reported as the backend of the cache metrics.
+const backendName = "sql"
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+var _ graph.Cache = (*Cache)(nil)
+
+// Cache is a graph.Cache storing entries in a SQL table.
+type Cache struct {
+	db   *sql.DB
+	opts options
+
+	sqlGet          string
+	sqlSet          string
+	sqlClear        string
+	sqlDeleteExpKey string
+	sqlDeleteExp    string
+
+	metrics *graphcache.Metrics
+
+	cleanupDone chan struct{}
+	cleanupWG   sync.WaitGroup
+	closeOnce   sync.Once
+}
+
+// NewCache creates a SQL cache on db and creates its table unless
+// WithSkipDBInit is set. The caller keeps ownership of db.
+func NewCache(db *sql.DB, opts ...Option) (*Cache, error) {
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.serializer == nil {
+		o.serializer = graph.NewJSONCacheSerializer()
+	}
+	if !tableNamePattern.MatchString(o.tableName) {
+		return nil, fmt.Errorf("invalid table name %q", o.tableName)
+	}
+	switch o.dialect {
+	case DialectSQLite, DialectPostgres, DialectMySQL:
+	default:
+		return nil, fmt.Errorf("unsupported dialect %d", o.dialect)
+	}
+
+	c := &Cache{
+		db:          db,
+		opts:        o,
+		metrics:     graphcache.NewMetrics(backendName, o.meterProvider),
+		cleanupDone: make(chan struct{}),
+	}
+	c.buildQueries()
+	if !o.skipDBInit {
+		ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+		defer cancel()
+		for _, stmt := range c.ddl() {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("init database failed: %w", err)
+			}
+		}
+	}
+	if o.cleanupInterval > 0 {
+		c.cleanupWG.Add(1)
+		go c.cleanupLoop()
+	}
+	return c, nil
+}
+
+// Get implements graph.Cache.
+func (c *Cache) Get(ns, key string) (any, bool) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	var (
+		data      []byte
+		expiresAt int64
+	)
+	err := c.db.QueryRowContext(ctx, c.sqlGet, ns, key).Scan(&data, &expiresAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.metrics.Error(ns, graphcache.OperationGet)
+			log.Warnf("graph sql cache: get %s/%s failed: %v", ns, key, err)
+		}
+		c.metrics.Miss(ns)
+		return nil, false
+	}
+	now := time.Now().UnixNano()
+	if expiresAt > 0 && expiresAt <= now {
+		if _, err := c.db.ExecContext(ctx, c.sqlDeleteExpKey, ns, key, now); err != nil {
+			c.metrics.Error(ns, graphcache.OperationDeleteExpired)
+			log.Warnf("graph sql cache: delete expired %s/%s failed: %v", ns, key, err)
+		}
+		c.metrics.Miss(ns)
+		return nil, false
+	}
+	val, err := c.opts.serializer.Unmarshal(data)
+	if err != nil {
+		c.metrics.Error(ns, graphcache.OperationDecode)
+		c.metrics.Miss(ns)
+		log.Warnf("graph sql cache: decode %s/%s failed: %v", ns, key, err)
+		return nil, false
+	}
+	c.metrics.Hit(ns)
+	return val, true
+}
+
+// Set implements graph.Cache. Values that cannot be serialized are skipped.
+func (c *Cache) Set(ns, key string, val any, ttl time.Duration) {
+	data, err := c.opts.serializer.Marshal(val)
+	if err != nil {
+		c.metrics.Error(ns, graphcache.OperationEncode)
+		log.Warnf("graph sql cache: encode %s/%s failed: %v", ns, key, err)
+		return
+	}
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	if _, err := c.db.ExecContext(ctx, c.sqlSet, ns, key, data, expiresAt); err != nil {
+		c.metrics.Error(ns, graphcache.OperationSet)
+		log.Warnf("graph sql cache: set %s/%s failed: %v", ns, key, err)
+		return
+	}
+	c.metrics.Set(ns)
+}
+
+// Clear implements graph.Cache.
+func (c *Cache) Clear(ns string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	if _, err := c.db.ExecContext(ctx, c.sqlClear, ns); err != nil {
+		c.metrics.Error(ns, graphcache.OperationClear)
+		log.Warnf("graph sql cache: clear %s failed: %v", ns, err)
+	}
+}
+
+// DeleteExpired deletes all expired entries and returns how many were
+// removed.
+func (c *Cache) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := c.db.ExecContext(ctx, c.sqlDeleteExp, time.Now().UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired entries: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// Stats returns the hit, miss, set and error counters of this cache
+// instance. The same accesses are exported as OpenTelemetry counters, see
+// WithMeterProvider.
+func (c *Cache) Stats() graph.CacheStats {
+	return c.metrics.Stats()
+}
+
+// Close stops the background cleanup job. It does not close the database.
+// It's safe to call Close multiple times.
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.cleanupDone)
+		c.cleanupWG.Wait()
+	})
+	return nil
+}
+
+func (c *Cache) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.opts.timeout)
+}
+
+func (c *Cache) cleanupLoop() {
+	defer c.cleanupWG.Done()
+	ticker := time.NewTicker(c.opts.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.cleanupDone:
+			return
+		case <-ticker.C:
+			ctx, cancel := c.context()
+			if _, err := c.DeleteExpired(ctx); err != nil {
+				log.Warnf("graph sql cache: cleanup failed: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (c *Cache) ddl() []string {
+	t := c.opts.tableName
+	switch c.opts.dialect {
+	case DialectPostgres:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + t + " (" +
+				"ns TEXT NOT NULL, " +
+				"cache_key TEXT NOT NULL, " +
+				"value BYTEA NOT NULL, " +
+				"expires_at BIGINT NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (ns, cache_key))",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t) + " ON " + t + " (expires_at)",
+		}
+	case DialectMySQL:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + t + " (" +
+				"ns VARCHAR(191) NOT NULL, " +
+				"cache_key VARCHAR(191) NOT NULL, " +
+				"value LONGBLOB NOT NULL, " +
+				"expires_at BIGINT NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (ns, cache_key), " +
+				"KEY " + indexName(t) + " (expires_at)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+		}
+	default:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + t + " (" +
+				"ns TEXT NOT NULL, " +
+				"cache_key TEXT NOT NULL, " +
+				"value BLOB NOT NULL, " +
+				"expires_at INTEGER NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (ns, cache_key))",
+			"CREATE INDEX IF NOT EXISTS " + indexName(t) + " ON " + t + " (expires_at)",
+		}
+	}
+}
+
+func (c *Cache) buildQueries() {
+	t := c.opts.tableName
+	c.sqlGet = c.rebind("SELECT value, expires_at FROM " + t + " WHERE ns = ? AND cache_key = ?")
+	c.sqlClear = c.rebind("DELETE FROM " + t + " WHERE ns = ?")
+	c.sqlDeleteExpKey = c.rebind("DELETE FROM " + t +
+		" WHERE ns = ? AND cache_key = ? AND expires_at > 0 AND expires_at <= ?")
+	c.sqlDeleteExp = c.rebind("DELETE FROM " + t + " WHERE expires_at > 0 AND expires_at <= ?")
+	insert := "INSERT INTO " + t + " (ns, cache_key, value, expires_at) VALUES (?, ?, ?, ?)"
+	if c.opts.dialect == DialectMySQL {
+		c.sqlSet = insert + " ON DUPLICATE KEY UPDATE value = VALUES(value), expires_at = VALUES(expires_at)"
+		return
+	}
+	c.sqlSet = c.rebind(insert +
+		" ON CONFLICT (ns, cache_key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at")
+}
+
+// rebind converts "?" placeholders to "$n" for PostgreSQL.
+func (c *Cache) rebind(query string) string {
+	if c.opts.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// indexName derives the expiry index name, dropping any schema qualifier.
+func indexName(table string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	return "idx_" + table + "_expires_at"
+}
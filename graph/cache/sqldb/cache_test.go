@@ -0,0 +1,219 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // Import SQLite driver.
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNewCache_Validation(t *testing.T) {
+	_, err := NewCache(nil)
+	assert.Error(t, err)
+
+	db := openTestDB(t)
+	_, err = NewCache(db, WithTableName("bad;name"))
+	assert.Error(t, err)
+	_, err = NewCache(db, WithDialect(Dialect(42)))
+	assert.Error(t, err)
+}
+
+func TestCache_GetSetClear(t *testing.T) {
+	c, err := NewCache(openTestDB(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, ok := c.Get("ns", "k")
+	assert.False(t, ok)
+
+	c.Set("ns", "k", graph.State{"a": "x"}, 0)
+	v, ok := c.Get("ns", "k")
+	require.True(t, ok)
+	assert.Equal(t, graph.State{"a": "x"}, v)
+
+	c.Set("ns", "k", graph.State{"a": "y"}, 0)
+	v, ok = c.Get("ns", "k")
+	require.True(t, ok)
+	assert.Equal(t, graph.State{"a": "y"}, v)
+
+	c.Set("other", "k", "kept", 0)
+	c.Clear("ns")
+	_, ok = c.Get("ns", "k")
+	assert.False(t, ok)
+	v, ok = c.Get("other", "k")
+	require.True(t, ok)
+	assert.Equal(t, "kept", v)
+
+	assert.Equal(t, graph.CacheStats{Hits: 3, Misses: 2, Sets: 3}, c.Stats())
+}
+
+func TestCache_TTL(t *testing.T) {
+	c, err := NewCache(openTestDB(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.Set("ns", "short", "v", 20*time.Millisecond)
+	c.Set("ns", "forever", "v", 0)
+	_, ok := c.Get("ns", "short")
+	assert.True(t, ok)
+	time.Sleep(40 * time.Millisecond)
+	_, ok = c.Get("ns", "short")
+	assert.False(t, ok)
+
+	c.Set("ns", "short2", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	n, err := c.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, ok = c.Get("ns", "forever")
+	assert.True(t, ok)
+}
+
+func TestCache_SerializationError(t *testing.T) {
+	c, err := NewCache(openTestDB(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.Set("ns", "k", graph.State{"fn": func() {}}, 0)
+	_, ok := c.Get("ns", "k")
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), c.Stats().Errors)
+}
+
+func TestCache_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	c, err := NewCache(openTestDB(t),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.Set("ns", "k", "v", 0)
+	c.Get("ns", "k")
+	c.Get("ns", "missing")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	got := make(map[string]int64)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+			got[m.Name] += dp.Value
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		metrics.MetricTRPCAgentGoGraphCacheHitCnt:  1,
+		metrics.MetricTRPCAgentGoGraphCacheMissCnt: 1,
+		metrics.MetricTRPCAgentGoGraphCacheSetCnt:  1,
+	}, got)
+}
+
+func TestCache_CleanupLoop(t *testing.T) {
+	c, err := NewCache(openTestDB(t), WithCleanupInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	c.Set("ns", "k", "v", time.Millisecond)
+	require.Eventually(t, func() bool {
+		var n int
+		require.NoError(t, c.db.QueryRow("SELECT COUNT(*) FROM graph_cache").Scan(&n))
+		return n == 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+}
+
+func TestCache_Queries(t *testing.T) {
+	pg := &Cache{opts: options{dialect: DialectPostgres, tableName: "public.cache"}}
+	pg.buildQueries()
+	assert.Equal(t, "SELECT value, expires_at FROM public.cache WHERE ns = $1 AND cache_key = $2", pg.sqlGet)
+	assert.Contains(t, pg.sqlSet, "VALUES ($1, $2, $3, $4) ON CONFLICT (ns, cache_key) DO UPDATE")
+	assert.Contains(t, pg.ddl()[1], "idx_cache_expires_at ON public.cache")
+
+	my := &Cache{opts: options{dialect: DialectMySQL, tableName: "cache"}}
+	my.buildQueries()
+	assert.Contains(t, my.sqlSet, "VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE")
+	require.Len(t, my.ddl(), 1)
+	assert.Contains(t, my.ddl()[0], "KEY idx_cache_expires_at (expires_at)")
+}
+
+// TestCache_SharedAcrossReplicas runs the same graph on two executors with
+// separate cache instances backed by one database.
+func TestCache_SharedAcrossReplicas(t *testing.T) {
+	db := openTestDB(t)
+	schema := graph.NewStateSchema().
+		AddField("n", graph.StateField{Type: reflect.TypeOf(0), Reducer: graph.DefaultReducer}).
+		AddField("out", graph.StateField{Type: reflect.TypeOf(0), Reducer: graph.DefaultReducer})
+
+	var calls atomic.Int32
+	var got any
+	newExecutor := func(version string) (*graph.Executor, *Cache) {
+		c, err := NewCache(db)
+		require.NoError(t, err)
+		sg := graph.NewStateGraph(schema).
+			WithCache(c).
+			WithGraphVersion(version)
+		sg.AddNode("work", func(ctx context.Context, st graph.State) (any, error) {
+			calls.Add(1)
+			return graph.State{"out": st["n"].(int) * 2}, nil
+		}, graph.WithNodeCachePolicy(graph.DefaultCachePolicy()), graph.WithCacheKeyFields("n"))
+		sg.AddNode("read", func(ctx context.Context, st graph.State) (any, error) {
+			got = st["out"]
+			return nil, nil
+		})
+		sg.SetEntryPoint("work").AddEdge("work", "read").SetFinishPoint("read")
+		g, err := sg.Compile()
+		require.NoError(t, err)
+		ex, err := graph.NewExecutor(g)
+		require.NoError(t, err)
+		return ex, c
+	}
+	run := func(ex *graph.Executor) {
+		ch, err := ex.Execute(context.Background(), graph.State{"n": 21}, &agent.Invocation{InvocationID: "inv"})
+		require.NoError(t, err)
+		for range ch {
+		}
+	}
+
+	ex1, c1 := newExecutor("v1")
+	ex2, c2 := newExecutor("v1")
+	run(ex1)
+	run(ex2)
+	assert.Equal(t, int32(1), calls.Load(), "second replica must reuse the cached result")
+	assert.Equal(t, 42, got)
+	assert.Equal(t, int64(1), c2.Stats().Hits)
+	assert.Equal(t, int64(1), c1.Stats().Sets)
+
+	// A new graph version does not see entries of the old one.
+	ex3, _ := newExecutor("v2")
+	run(ex3)
+	assert.Equal(t, int32(2), calls.Load())
+}
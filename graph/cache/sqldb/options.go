@@ -0,0 +1,116 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package sqldb
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+)
+
+const (
+	defaultTableName = "graph_cache"
+	defaultTimeout   = 5 * time.Second
+)
+
+// Dialect selects the SQL flavor used for DDL, placeholders and upserts.
+type Dialect int
+
+const (
+	// DialectSQLite targets SQLite 3.24 or later.
+	DialectSQLite Dialect = iota
+	// DialectPostgres targets PostgreSQL 9.5 or later.
+	DialectPostgres
+	// DialectMySQL targets MySQL 5.7 or later.
+	DialectMySQL
+)
+
+// Option configures the SQL cache.
+type Option func(*options)
+
+type options struct {
+	dialect         Dialect
+	tableName       string
+	serializer      graph.CacheSerializer
+	timeout         time.Duration
+	cleanupInterval time.Duration
+	skipDBInit      bool
+	meterProvider   metric.MeterProvider
+}
+
+var defaultOptions = options{
+	dialect:   DialectSQLite,
+	tableName: defaultTableName,
+	timeout:   defaultTimeout,
+}
+
+// WithDialect sets the SQL dialect of the database.
+// Default is DialectSQLite.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithTableName sets the table storing cache entries.
+// Default is "graph_cache".
+func WithTableName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.tableName = name
+		}
+	}
+}
+
+// WithSerializer sets how node results are encoded.
+// Default is graph.NewJSONCacheSerializer().
+func WithSerializer(s graph.CacheSerializer) Option {
+	return func(o *options) {
+		o.serializer = s
+	}
+}
+
+// WithTimeout bounds every database operation, since graph.Cache methods do
+// not take a context. Default is 5s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.timeout = timeout
+		}
+	}
+}
+
+// WithCleanupInterval enables a background job deleting expired entries at
+// the given interval. Expired entries are never returned either way; the job
+// only reclaims space. Disabled by default.
+func WithCleanupInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.cleanupInterval = interval
+	}
+}
+
+// WithSkipDBInit skips creating the table. Use it when the schema is managed
+// externally.
+func WithSkipDBInit(skip bool) Option {
+	return func(o *options) {
+		o.skipDBInit = skip
+	}
+}
+
+// WithMeterProvider sets the meter provider that hit, miss, set and error
+// counters are exported to. Default is the global provider from
+// telemetry/metric.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) {
+		o.meterProvider = mp
+	}
+}
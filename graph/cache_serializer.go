@@ -0,0 +1,124 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CacheSerializer converts node results to bytes and back. It is used by
+// Cache implementations that store entries outside the process, such as
+// Redis or SQL backends.
+type CacheSerializer interface {
+	// Marshal encodes a node result.
+	Marshal(val any) ([]byte, error)
+	// Unmarshal decodes bytes produced by Marshal.
+	Unmarshal(data []byte) (any, error)
+}
+
+// CacheStats holds cache access counters.
+type CacheStats struct {
+	// Hits is the number of Get calls that returned a value.
+	Hits int64
+	// Misses is the number of Get calls that found no live entry.
+	Misses int64
+	// Sets is the number of entries stored successfully.
+	Sets int64
+	// Errors is the number of failed backend or serialization operations.
+	Errors int64
+}
+
+// HitRatio returns Hits / (Hits + Misses), or 0 when nothing was looked up.
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Kinds of node results recorded by the JSON cache serializer.
+const (
+	cacheValueKindState    = "state"
+	cacheValueKindCommand  = "command"
+	cacheValueKindCommands = "commands"
+	cacheValueKindValue    = "value"
+)
+
+type cacheEnvelope struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+type jsonCacheSerializer struct{}
+
+// NewJSONCacheSerializer returns the default CacheSerializer. It records
+// whether a result is a State, *Command or []*Command so the executor sees
+// the same result type on a cache hit. State values come back as their JSON
+// form and are converted to the schema field types by the executor, so
+// results must be JSON encodable.
+func NewJSONCacheSerializer() CacheSerializer {
+	return jsonCacheSerializer{}
+}
+
+// Marshal implements CacheSerializer.
+func (jsonCacheSerializer) Marshal(val any) ([]byte, error) {
+	kind := cacheValueKindValue
+	switch val.(type) {
+	case State:
+		kind = cacheValueKindState
+	case *Command:
+		kind = cacheValueKindCommand
+	case []*Command:
+		kind = cacheValueKindCommands
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("marshal cache value: %w", err)
+	}
+	return json.Marshal(cacheEnvelope{Kind: kind, Value: raw})
+}
+
+// Unmarshal implements CacheSerializer.
+func (jsonCacheSerializer) Unmarshal(data []byte) (any, error) {
+	var env cacheEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("unmarshal cache envelope: %w", err)
+	}
+	var (
+		out any
+		err error
+	)
+	switch env.Kind {
+	case cacheValueKindState:
+		var s State
+		err = json.Unmarshal(env.Value, &s)
+		out = s
+	case cacheValueKindCommand:
+		var c *Command
+		err = json.Unmarshal(env.Value, &c)
+		out = c
+	case cacheValueKindCommands:
+		var cs []*Command
+		err = json.Unmarshal(env.Value, &cs)
+		out = cs
+	case cacheValueKindValue:
+		var v any
+		err = json.Unmarshal(env.Value, &v)
+		out = v
+	default:
+		return nil, fmt.Errorf("unknown cache value kind %q", env.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal cache value: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+)
+
+func TestJSONCacheSerializer_RoundTrip(t *testing.T) {
+	s := NewJSONCacheSerializer()
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"state", State{"a": "x", "n": 1}, State{"a": "x", "n": float64(1)}},
+		{"command", &Command{Update: State{"a": "x"}, GoTo: "next"}, &Command{Update: State{"a": "x"}, GoTo: "next"}},
+		{"commands", []*Command{{GoTo: "a"}, {GoTo: "b"}}, []*Command{{GoTo: "a"}, {GoTo: "b"}}},
+		{"value", "plain", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := s.Marshal(tt.in)
+			require.NoError(t, err)
+			out, err := s.Unmarshal(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out)
+		})
+	}
+
+	_, err := s.Marshal(State{"fn": func() {}})
+	assert.Error(t, err)
+	_, err = s.Unmarshal([]byte(`{"kind":"bogus","value":null}`))
+	assert.Error(t, err)
+	_, err = s.Unmarshal([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestCacheStats_HitRatio(t *testing.T) {
+	assert.Equal(t, float64(0), CacheStats{}.HitRatio())
+	assert.Equal(t, 0.75, CacheStats{Hits: 3, Misses: 1}.HitRatio())
+}
+
+// serializingCache stores entries as bytes, like an out-of-process cache.
+type serializingCache struct {
+	inner *InMemoryCache
+	ser   CacheSerializer
+}
+
+func (c *serializingCache) Get(ns, key string) (any, bool) {
+	v, ok := c.inner.Get(ns, key)
+	if !ok {
+		return nil, false
+	}
+	out, err := c.ser.Unmarshal(v.([]byte))
+	return out, err == nil
+}
+
+func (c *serializingCache) Set(ns, key string, val any, ttl time.Duration) {
+	if data, err := c.ser.Marshal(val); err == nil {
+		c.inner.Set(ns, key, data, ttl)
+	}
+}
+
+func (c *serializingCache) Clear(ns string) { c.inner.Clear(ns) }
+
+func TestNodeCache_SerializedHitRestoresSchemaTypes(t *testing.T) {
+	type item struct {
+		ID string `json:"id"`
+	}
+	schema := NewStateSchema().
+		AddField("n", StateField{Type: reflect.TypeOf(0), Reducer: DefaultReducer}).
+		AddField("items", StateField{Type: reflect.TypeOf([]item{}), Reducer: DefaultReducer}).
+		AddField("count", StateField{Type: reflect.TypeOf(0), Reducer: DefaultReducer})
+
+	var calls int
+	var gotItems, gotCount any
+	sg := NewStateGraph(schema).
+		WithCache(&serializingCache{inner: NewInMemoryCache(), ser: NewJSONCacheSerializer()}).
+		WithCachePolicy(DefaultCachePolicy())
+	sg.AddNode("work", func(ctx context.Context, st State) (any, error) {
+		calls++
+		return State{"items": []item{{ID: "a"}}, "count": 2}, nil
+	}, WithCacheKeyFields("n"))
+	sg.AddNode("check", func(ctx context.Context, st State) (any, error) {
+		gotItems, gotCount = st["items"], st["count"]
+		return nil, nil
+	})
+	sg.SetEntryPoint("work").AddEdge("work", "check").SetFinishPoint("check")
+	g, err := sg.Compile()
+	require.NoError(t, err)
+	ex, err := NewExecutor(g)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		ch, err := ex.Execute(context.Background(), State{"n": 1}, &agent.Invocation{InvocationID: "inv"})
+		require.NoError(t, err)
+		drain(ch)
+		assert.Equal(t, []item{{ID: "a"}}, gotItems)
+		assert.Equal(t, 2, gotCount)
+	}
+	assert.Equal(t, 1, calls, "second run must be served from the cache")
+}
@@ -0,0 +1,81 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mysql
+
+import (
+	"trpc.group/trpc-go/trpc-agent-go/internal/session/sqldb"
+)
+
+// Options is the options for the mysql checkpoint saver.
+type Options struct {
+	// MySQL connection settings (using DSN or instance name)
+	dsn          string
+	instanceName string
+	extraOptions []any
+
+	// skipDBInit skips table and index creation.
+	skipDBInit bool
+	// tablePrefix is the prefix for all table names.
+	tablePrefix string
+}
+
+// Option is the option for the mysql checkpoint saver.
+type Option func(*Options)
+
+// WithMySQLClientDSN sets the MySQL DSN connection string directly (recommended).
+// Example: "user:password@tcp(localhost:3306)/checkpoints?parseTime=true&charset=utf8mb4"
+func WithMySQLClientDSN(dsn string) Option {
+	return func(opts *Options) {
+		opts.dsn = dsn
+	}
+}
+
+// WithMySQLInstance uses a MySQL instance from storage.
+// The instance must be registered via storage.RegisterMySQLInstance() before use.
+//
+// Note: WithMySQLClientDSN has higher priority than WithMySQLInstance.
+// If both are specified, DSN will be used.
+func WithMySQLInstance(instanceName string) Option {
+	return func(opts *Options) {
+		opts.instanceName = instanceName
+	}
+}
+
+// WithExtraOptions sets the extra options for the mysql checkpoint saver.
+// These options will be passed to the MySQL client builder.
+func WithExtraOptions(extraOptions ...any) Option {
+	return func(opts *Options) {
+		opts.extraOptions = append(opts.extraOptions, extraOptions...)
+	}
+}
+
+// WithSkipDBInit skips database initialization (table and index creation).
+// Useful when the runtime account has no DDL permissions or when tables are managed externally.
+func WithSkipDBInit(skip bool) Option {
+	return func(opts *Options) {
+		opts.skipDBInit = skip
+	}
+}
+
+// WithTablePrefix sets a prefix for all table names, consistent with the
+// mysql session service. For example, with prefix "trpc" the tables are
+// trpc_checkpoints and trpc_checkpoint_writes.
+//
+// Note: An underscore will be automatically added if not present.
+func WithTablePrefix(prefix string) Option {
+	return func(opts *Options) {
+		if prefix == "" {
+			opts.tablePrefix = ""
+			return
+		}
+		sqldb.MustValidateTablePrefix(prefix)
+		opts.tablePrefix = prefix
+	}
+}
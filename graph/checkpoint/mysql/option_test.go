@@ -0,0 +1,39 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	opts := Options{}
+	for _, opt := range []Option{
+		WithMySQLClientDSN("user:pass@tcp(localhost:3306)/db"),
+		WithMySQLInstance("inst"),
+		WithExtraOptions("a"),
+		WithExtraOptions("b"),
+		WithSkipDBInit(true),
+		WithTablePrefix("app"),
+	} {
+		opt(&opts)
+	}
+	assert.Equal(t, "user:pass@tcp(localhost:3306)/db", opts.dsn)
+	assert.Equal(t, "inst", opts.instanceName)
+	assert.Equal(t, []any{"a", "b"}, opts.extraOptions)
+	assert.True(t, opts.skipDBInit)
+	assert.Equal(t, "app", opts.tablePrefix)
+
+	WithTablePrefix("")(&opts)
+	assert.Empty(t, opts.tablePrefix)
+	assert.Panics(t, func() { WithTablePrefix("bad-prefix")(&Options{}) })
+}
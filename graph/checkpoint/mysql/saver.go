@@ -0,0 +1,576 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package mysql provides MySQL-based checkpoint storage implementation
+// for graph execution state persistence and recovery.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/internal/session/sqldb"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/mysql"
+)
+
+// Base table names. The effective names include the table prefix.
+const (
+	tableNameCheckpoints      = "checkpoints"
+	tableNameCheckpointWrites = "checkpoint_writes"
+)
+
+// SQL templates for table creation. Key columns use VARCHAR(191) so composite
+// primary keys stay within the InnoDB index size limit under utf8mb4. The
+// lookup index is declared inline because MySQL has no CREATE INDEX IF NOT EXISTS.
+const (
+	sqlCreateCheckpointsTable = `
+		CREATE TABLE IF NOT EXISTS {{TABLE_NAME}} (
+			lineage_id VARCHAR(191) NOT NULL,
+			checkpoint_ns VARCHAR(191) NOT NULL DEFAULT '',
+			checkpoint_id VARCHAR(191) NOT NULL,
+			parent_checkpoint_id VARCHAR(191) NOT NULL DEFAULT '',
+			ts BIGINT NOT NULL,
+			checkpoint_json LONGBLOB NOT NULL,
+			metadata_json LONGBLOB NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			PRIMARY KEY (lineage_id, checkpoint_ns, checkpoint_id),
+			KEY {{INDEX_NAME}} (lineage_id, checkpoint_ns, ts)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+	sqlCreateCheckpointWritesTable = `
+		CREATE TABLE IF NOT EXISTS {{TABLE_NAME}} (
+			lineage_id VARCHAR(191) NOT NULL,
+			checkpoint_ns VARCHAR(191) NOT NULL DEFAULT '',
+			checkpoint_id VARCHAR(191) NOT NULL,
+			task_id VARCHAR(191) NOT NULL,
+			idx INT NOT NULL,
+			channel VARCHAR(255) NOT NULL,
+			value_json LONGBLOB NOT NULL,
+			task_path TEXT NOT NULL,
+			seq BIGINT NOT NULL,
+			PRIMARY KEY (lineage_id, checkpoint_ns, checkpoint_id, task_id, idx)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+)
+
+type tableDef struct {
+	name        string
+	indexSuffix string
+	template    string
+}
+
+var tableDefs = []tableDef{
+	{tableNameCheckpoints, sqldb.IndexSuffixLookup, sqlCreateCheckpointsTable},
+	{tableNameCheckpointWrites, "", sqlCreateCheckpointWritesTable},
+}
+
+const checkpointColumns = "checkpoint_json, metadata_json, parent_checkpoint_id, checkpoint_ns, checkpoint_id"
+
+// Saver is a MySQL-backed implementation of graph.CheckpointSaver.
+// Checkpoints and metadata are stored as JSON blobs, pending writes are
+// stored per task in a separate table.
+type Saver struct {
+	opts   Options
+	client storage.Client
+	once   sync.Once // ensure Close is called only once
+
+	tableCheckpoints string
+	tableWrites      string
+}
+
+// NewSaver creates a new mysql checkpoint saver. Unless WithSkipDBInit is
+// set, the required tables are created if they do not exist.
+func NewSaver(options ...Option) (*Saver, error) {
+	opts := Options{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	builderOpts := []storage.ClientBuilderOpt{
+		storage.WithClientBuilderDSN(opts.dsn),
+		storage.WithExtraOptions(opts.extraOptions...),
+	}
+	if opts.dsn == "" && opts.instanceName != "" {
+		var ok bool
+		if builderOpts, ok = storage.GetMySQLInstance(opts.instanceName); !ok {
+			return nil, fmt.Errorf("mysql instance %s not found", opts.instanceName)
+		}
+	}
+	client, err := storage.GetClientBuilder()(builderOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create mysql client failed: %w", err)
+	}
+
+	s := newSaver(client, opts)
+	if !opts.skipDBInit {
+		if err := s.initDB(context.Background()); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("init database failed: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func newSaver(client storage.Client, opts Options) *Saver {
+	return &Saver{
+		opts:             opts,
+		client:           client,
+		tableCheckpoints: sqldb.BuildTableName(opts.tablePrefix, tableNameCheckpoints),
+		tableWrites:      sqldb.BuildTableName(opts.tablePrefix, tableNameCheckpointWrites),
+	}
+}
+
+// initDB creates tables together with their indexes.
+func (s *Saver) initDB(ctx context.Context) error {
+	for _, table := range tableDefs {
+		fullTableName := sqldb.BuildTableName(s.opts.tablePrefix, table.name)
+		tableSQL := strings.ReplaceAll(table.template, "{{TABLE_NAME}}", fullTableName)
+		if table.indexSuffix != "" {
+			indexName := sqldb.BuildIndexName(s.opts.tablePrefix, table.name, table.indexSuffix)
+			tableSQL = strings.ReplaceAll(tableSQL, "{{INDEX_NAME}}", indexName)
+		}
+		if _, err := s.client.Exec(ctx, tableSQL); err != nil {
+			return fmt.Errorf("create table %s failed: %w", fullTableName, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the checkpoint for the given config.
+func (s *Saver) Get(ctx context.Context, config map[string]any) (*graph.Checkpoint, error) {
+	t, err := s.GetTuple(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, nil
+	}
+	return t.Checkpoint, nil
+}
+
+// checkpointRow is a raw row of the checkpoints table.
+type checkpointRow struct {
+	checkpointJSON []byte
+	metadataJSON   []byte
+	parentID       string
+	namespace      string
+	checkpointID   string
+}
+
+func scanCheckpointRow(rows *sql.Rows) (*checkpointRow, error) {
+	var r checkpointRow
+	if err := rows.Scan(&r.checkpointJSON, &r.metadataJSON, &r.parentID, &r.namespace, &r.checkpointID); err != nil {
+		return nil, fmt.Errorf("scan checkpoint: %w", err)
+	}
+	return &r, nil
+}
+
+// GetTuple returns the checkpoint tuple for the given config. When the
+// namespace is empty the lookup spans all namespaces of the lineage.
+func (s *Saver) GetTuple(ctx context.Context, config map[string]any) (*graph.CheckpointTuple, error) {
+	lineageID := graph.GetLineageID(config)
+	checkpointNS := graph.GetNamespace(config)
+	checkpointID := graph.GetCheckpointID(config)
+	if lineageID == "" {
+		return nil, errors.New("lineage_id is required")
+	}
+
+	q := "SELECT " + checkpointColumns + " FROM " + s.tableCheckpoints + " WHERE lineage_id = ?"
+	args := []any{lineageID}
+	if checkpointNS != "" {
+		q += " AND checkpoint_ns = ?"
+		args = append(args, checkpointNS)
+	}
+	if checkpointID != "" {
+		q += " AND checkpoint_id = ?"
+		args = append(args, checkpointID)
+	}
+	q += " ORDER BY ts DESC LIMIT 1"
+
+	var row *checkpointRow
+	err := s.client.Query(ctx, func(rows *sql.Rows) error {
+		var err error
+		if row, err = scanCheckpointRow(rows); err != nil {
+			return err
+		}
+		return storage.ErrBreak
+	}, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select checkpoint failed: %w", err)
+	}
+	if row == nil {
+		return nil, nil
+	}
+	return s.buildTuple(ctx, lineageID, row)
+}
+
+// buildTuple constructs a CheckpointTuple from a raw row.
+func (s *Saver) buildTuple(ctx context.Context, lineageID string, row *checkpointRow) (*graph.CheckpointTuple, error) {
+	var ckpt graph.Checkpoint
+	if err := json.Unmarshal(row.checkpointJSON, &ckpt); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	var meta graph.CheckpointMetadata
+	if err := json.Unmarshal(row.metadataJSON, &meta); err != nil {
+		return nil, fmt.Errorf("unmarshal metadata: %w", err)
+	}
+	writes, err := s.loadWrites(ctx, lineageID, row.namespace, row.checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	var parentCfg map[string]any
+	if row.parentID != "" {
+		// Look up the parent's actual namespace. If not found, use empty namespace
+		// to allow cross-namespace lookup by ID.
+		parentNS, err := s.findCheckpointNamespace(ctx, lineageID, row.parentID)
+		if err != nil {
+			return nil, err
+		}
+		parentCfg = graph.CreateCheckpointConfig(lineageID, row.parentID, parentNS)
+	}
+	return &graph.CheckpointTuple{
+		Config:        graph.CreateCheckpointConfig(lineageID, row.checkpointID, row.namespace),
+		Checkpoint:    &ckpt,
+		Metadata:      &meta,
+		ParentConfig:  parentCfg,
+		PendingWrites: writes,
+	}, nil
+}
+
+// findCheckpointNamespace returns the namespace of the given checkpoint ID
+// within a lineage, or an empty string when it does not exist.
+func (s *Saver) findCheckpointNamespace(ctx context.Context, lineageID, checkpointID string) (string, error) {
+	var ns string
+	err := s.client.QueryRow(ctx, []any{&ns},
+		"SELECT checkpoint_ns FROM "+s.tableCheckpoints+
+			" WHERE lineage_id = ? AND checkpoint_id = ? ORDER BY ts DESC LIMIT 1",
+		lineageID, checkpointID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("lookup parent namespace: %w", err)
+	}
+	return ns, nil
+}
+
+// List returns checkpoints for the lineage/namespace ordered from newest to
+// oldest, with optional Before, Limit and Metadata filters. Metadata filters
+// match against CheckpointMetadata.Extra.
+func (s *Saver) List(
+	ctx context.Context,
+	config map[string]any,
+	filter *graph.CheckpointFilter,
+) ([]*graph.CheckpointTuple, error) {
+	lineageID := graph.GetLineageID(config)
+	checkpointNS := graph.GetNamespace(config)
+	if lineageID == "" {
+		return nil, errors.New("lineage_id is required")
+	}
+
+	q := "SELECT " + checkpointColumns + " FROM " + s.tableCheckpoints + " WHERE lineage_id = ?"
+	args := []any{lineageID}
+	if checkpointNS != "" {
+		q += " AND checkpoint_ns = ?"
+		args = append(args, checkpointNS)
+	}
+	if filter != nil && filter.Before != nil {
+		if beforeID := graph.GetCheckpointID(filter.Before); beforeID != "" {
+			beforeTs, ok, err := s.getTimestamp(ctx, lineageID, checkpointNS, beforeID)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				q += " AND ts < ?"
+				args = append(args, beforeTs)
+			}
+		}
+	}
+	q += " ORDER BY ts DESC"
+	// Metadata filtering happens after decoding, so only push the limit down
+	// when there is nothing left to filter.
+	if filter != nil && filter.Limit > 0 && len(filter.Metadata) == 0 {
+		q += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	var rowsData []*checkpointRow
+	err := s.client.Query(ctx, func(rows *sql.Rows) error {
+		row, err := scanCheckpointRow(rows)
+		if err != nil {
+			return err
+		}
+		rowsData = append(rowsData, row)
+		return nil
+	}, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select checkpoints: %w", err)
+	}
+
+	var tuples []*graph.CheckpointTuple
+	for _, row := range rowsData {
+		tuple, err := s.buildTuple(ctx, lineageID, row)
+		if err != nil {
+			return nil, err
+		}
+		if !matchesMetadataFilter(tuple, filter) {
+			continue
+		}
+		tuples = append(tuples, tuple)
+		if filter != nil && filter.Limit > 0 && len(tuples) >= filter.Limit {
+			break
+		}
+	}
+	return tuples, nil
+}
+
+// getTimestamp returns the timestamp of a checkpoint used by the Before filter.
+func (s *Saver) getTimestamp(ctx context.Context, lineageID, checkpointNS, checkpointID string) (int64, bool, error) {
+	q := "SELECT ts FROM " + s.tableCheckpoints + " WHERE lineage_id = ? AND checkpoint_id = ?"
+	args := []any{lineageID, checkpointID}
+	if checkpointNS != "" {
+		q += " AND checkpoint_ns = ?"
+		args = append(args, checkpointNS)
+	}
+	q += " ORDER BY ts DESC LIMIT 1"
+	var ts int64
+	err := s.client.QueryRow(ctx, []any{&ts}, q, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("get before timestamp: %w", err)
+	}
+	return ts, true, nil
+}
+
+// matchesMetadataFilter checks if a tuple matches the metadata filter.
+func matchesMetadataFilter(tuple *graph.CheckpointTuple, filter *graph.CheckpointFilter) bool {
+	if filter == nil || len(filter.Metadata) == 0 {
+		return true
+	}
+	if tuple.Metadata == nil || tuple.Metadata.Extra == nil {
+		return false
+	}
+	for key, value := range filter.Metadata {
+		if tuple.Metadata.Extra[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Saver) insertCheckpointSQL() string {
+	return "INSERT INTO " + s.tableCheckpoints + " (lineage_id, checkpoint_ns, checkpoint_id, " +
+		"parent_checkpoint_id, ts, checkpoint_json, metadata_json) VALUES (?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE parent_checkpoint_id = VALUES(parent_checkpoint_id), ts = VALUES(ts), " +
+		"checkpoint_json = VALUES(checkpoint_json), metadata_json = VALUES(metadata_json)"
+}
+
+func (s *Saver) insertWriteSQL() string {
+	return "INSERT INTO " + s.tableWrites + " (lineage_id, checkpoint_ns, checkpoint_id, task_id, idx, " +
+		"channel, value_json, task_path, seq) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE channel = VALUES(channel), value_json = VALUES(value_json), " +
+		"task_path = VALUES(task_path), seq = VALUES(seq)"
+}
+
+// checkpointArgs marshals a checkpoint into insert arguments.
+func checkpointArgs(
+	lineageID, checkpointNS string,
+	ckpt *graph.Checkpoint,
+	meta *graph.CheckpointMetadata,
+) ([]any, error) {
+	checkpointJSON, err := json.Marshal(ckpt)
+	if err != nil {
+		return nil, fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	if meta == nil {
+		meta = &graph.CheckpointMetadata{Source: graph.CheckpointSourceUpdate, Step: 0}
+	}
+	metadataJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("marshal metadata: %w", err)
+	}
+	// Use UnixNano for better precision in ordering.
+	ts := ckpt.Timestamp.UnixNano()
+	if ckpt.Timestamp.IsZero() {
+		ts = time.Now().UTC().UnixNano()
+	}
+	// Use the ParentCheckpointID from the checkpoint itself, not from config.
+	return []any{
+		lineageID, checkpointNS, ckpt.ID, ckpt.ParentCheckpointID, ts,
+		checkpointJSON, metadataJSON,
+	}, nil
+}
+
+// Put stores the checkpoint and returns the updated config with checkpoint ID.
+func (s *Saver) Put(ctx context.Context, req graph.PutRequest) (map[string]any, error) {
+	if req.Checkpoint == nil {
+		return nil, errors.New("checkpoint cannot be nil")
+	}
+	lineageID := graph.GetLineageID(req.Config)
+	checkpointNS := graph.GetNamespace(req.Config)
+	if lineageID == "" {
+		return nil, errors.New("lineage_id is required")
+	}
+	args, err := checkpointArgs(lineageID, checkpointNS, req.Checkpoint, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.client.Exec(ctx, s.insertCheckpointSQL(), args...); err != nil {
+		return nil, fmt.Errorf("insert checkpoint: %w", err)
+	}
+	return graph.CreateCheckpointConfig(lineageID, req.Checkpoint.ID, checkpointNS), nil
+}
+
+// PutWrites stores write entries for a checkpoint.
+func (s *Saver) PutWrites(ctx context.Context, req graph.PutWritesRequest) error {
+	lineageID := graph.GetLineageID(req.Config)
+	checkpointNS := graph.GetNamespace(req.Config)
+	checkpointID := graph.GetCheckpointID(req.Config)
+	if lineageID == "" || checkpointID == "" {
+		return errors.New("lineage_id and checkpoint_id are required")
+	}
+	if len(req.Writes) == 0 {
+		return nil
+	}
+	return s.client.Transaction(ctx, func(tx *sql.Tx) error {
+		for idx, w := range req.Writes {
+			valueJSON, err := json.Marshal(w.Value)
+			if err != nil {
+				return fmt.Errorf("marshal write: %w", err)
+			}
+			// Use Sequence if available in the write, otherwise use index.
+			seq := w.Sequence
+			if seq == 0 {
+				seq = int64(idx)
+			}
+			if _, err := tx.ExecContext(ctx, s.insertWriteSQL(),
+				lineageID, checkpointNS, checkpointID, req.TaskID, idx,
+				w.Channel, valueJSON, req.TaskPath, seq,
+			); err != nil {
+				return fmt.Errorf("insert write: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// PutFull atomically stores a checkpoint with its pending writes in a single transaction.
+func (s *Saver) PutFull(ctx context.Context, req graph.PutFullRequest) (map[string]any, error) {
+	lineageID := graph.GetLineageID(req.Config)
+	checkpointNS := graph.GetNamespace(req.Config)
+	if lineageID == "" {
+		return nil, errors.New("lineage_id is required")
+	}
+	if req.Checkpoint == nil {
+		return nil, errors.New("checkpoint cannot be nil")
+	}
+	args, err := checkpointArgs(lineageID, checkpointNS, req.Checkpoint, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.Transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.insertCheckpointSQL(), args...); err != nil {
+			return fmt.Errorf("insert checkpoint: %w", err)
+		}
+		for idx, w := range req.PendingWrites {
+			valueJSON, err := json.Marshal(w.Value)
+			if err != nil {
+				return fmt.Errorf("marshal write value: %w", err)
+			}
+			// Use Sequence if available, otherwise fallback to timestamp.
+			seq := w.Sequence
+			if seq == 0 {
+				seq = time.Now().UnixNano()
+			}
+			if _, err := tx.ExecContext(ctx, s.insertWriteSQL(),
+				lineageID, checkpointNS, req.Checkpoint.ID, w.TaskID, idx,
+				w.Channel, valueJSON, "", seq,
+			); err != nil {
+				return fmt.Errorf("insert write: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return graph.CreateCheckpointConfig(lineageID, req.Checkpoint.ID, checkpointNS), nil
+}
+
+// DeleteLineage deletes all checkpoints and writes for the lineage across
+// all namespaces.
+func (s *Saver) DeleteLineage(ctx context.Context, lineageID string) error {
+	if lineageID == "" {
+		return errors.New("lineage_id is required")
+	}
+	return s.client.Transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM "+s.tableWrites+" WHERE lineage_id = ?", lineageID); err != nil {
+			return fmt.Errorf("delete writes: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM "+s.tableCheckpoints+" WHERE lineage_id = ?", lineageID); err != nil {
+			return fmt.Errorf("delete checkpoints: %w", err)
+		}
+		return nil
+	})
+}
+
+// Close releases the underlying client.
+func (s *Saver) Close() error {
+	var err error
+	s.once.Do(func() {
+		if s.client != nil {
+			err = s.client.Close()
+		}
+	})
+	return err
+}
+
+func (s *Saver) loadWrites(
+	ctx context.Context,
+	lineageID, checkpointNS, checkpointID string,
+) ([]graph.PendingWrite, error) {
+	var writes []graph.PendingWrite
+	err := s.client.Query(ctx, func(rows *sql.Rows) error {
+		var (
+			taskID, channel string
+			valueJSON       []byte
+			seq             int64
+		)
+		if err := rows.Scan(&taskID, &channel, &valueJSON, &seq); err != nil {
+			return fmt.Errorf("scan write: %w", err)
+		}
+		var value any
+		if err := json.Unmarshal(valueJSON, &value); err != nil {
+			return fmt.Errorf("unmarshal write: %w", err)
+		}
+		writes = append(writes, graph.PendingWrite{
+			TaskID:   taskID,
+			Channel:  channel,
+			Value:    value,
+			Sequence: seq,
+		})
+		return nil
+	}, "SELECT task_id, channel, value_json, seq FROM "+s.tableWrites+
+		" WHERE lineage_id = ? AND checkpoint_ns = ? AND checkpoint_id = ? ORDER BY seq, idx",
+		lineageID, checkpointNS, checkpointID)
+	if err != nil {
+		return nil, fmt.Errorf("select writes: %w", err)
+	}
+	return writes, nil
+}
@@ -0,0 +1,435 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/mysql"
+)
+
+// mockMySQLClient implements storage.Client for testing with sqlmock.
+type mockMySQLClient struct {
+	db *sql.DB
+}
+
+func (m *mockMySQLClient) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *mockMySQLClient) Query(ctx context.Context, next storage.NextFunc, query string, args ...any) error {
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := next(rows); err != nil {
+			if errors.Is(err, storage.ErrBreak) {
+				break
+			}
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (m *mockMySQLClient) QueryRow(ctx context.Context, dest []any, query string, args ...any) error {
+	return m.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+}
+
+func (m *mockMySQLClient) Transaction(ctx context.Context, fn storage.TxFunc, opts ...storage.TxOption) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *mockMySQLClient) Close() error {
+	return m.db.Close()
+}
+
+func setupMockSaver(t *testing.T, opts ...Option) (*Saver, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := newSaver(&mockMySQLClient{db: db}, o)
+	t.Cleanup(func() { _ = s.Close() })
+	return s, mock
+}
+
+func checkpointRows(t *testing.T, ckpt *graph.Checkpoint, meta *graph.CheckpointMetadata, ns string) *sqlmock.Rows {
+	ckptJSON, err := json.Marshal(ckpt)
+	require.NoError(t, err)
+	metaJSON, err := json.Marshal(meta)
+	require.NoError(t, err)
+	return sqlmock.NewRows([]string{"checkpoint_json", "metadata_json", "parent_checkpoint_id", "checkpoint_ns", "checkpoint_id"}).
+		AddRow(ckptJSON, metaJSON, ckpt.ParentCheckpointID, ns, ckpt.ID)
+}
+
+func emptyWriteRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"task_id", "channel", "value_json", "seq"})
+}
+
+func TestNewSaver_InitDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	oldBuilder := storage.GetClientBuilder()
+	defer storage.SetClientBuilder(oldBuilder)
+
+	var dsn string
+	storage.SetClientBuilder(func(opts ...storage.ClientBuilderOpt) (storage.Client, error) {
+		o := &storage.ClientBuilderOpts{}
+		for _, opt := range opts {
+			opt(o)
+		}
+		dsn = o.DSN
+		return &mockMySQLClient{db: db}, nil
+	})
+
+	mock.ExpectExec("(?s)CREATE TABLE IF NOT EXISTS app_checkpoints .* KEY idx_app_checkpoints_lookup").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS app_checkpoint_writes").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+
+	s, err := NewSaver(WithMySQLClientDSN("user:pass@tcp(db:3306)/ckpt"), WithTablePrefix("app"))
+	require.NoError(t, err)
+	assert.Equal(t, "user:pass@tcp(db:3306)/ckpt", dsn)
+	assert.Equal(t, "app_checkpoints", s.tableCheckpoints)
+	assert.Equal(t, "app_checkpoint_writes", s.tableWrites)
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewSaver_InitDBError(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	oldBuilder := storage.GetClientBuilder()
+	defer storage.SetClientBuilder(oldBuilder)
+	storage.SetClientBuilder(func(opts ...storage.ClientBuilderOpt) (storage.Client, error) {
+		return &mockMySQLClient{db: db}, nil
+	})
+	mock.ExpectExec("CREATE TABLE").WillReturnError(errors.New("denied"))
+	mock.ExpectClose()
+
+	_, err = NewSaver(WithMySQLClientDSN("user:pass@tcp(localhost:3306)/db"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "init database failed")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewSaver_SkipDBInit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	oldBuilder := storage.GetClientBuilder()
+	defer storage.SetClientBuilder(oldBuilder)
+	storage.SetClientBuilder(func(opts ...storage.ClientBuilderOpt) (storage.Client, error) {
+		return &mockMySQLClient{db: db}, nil
+	})
+
+	s, err := NewSaver(WithSkipDBInit(true), WithTablePrefix("t_"))
+	require.NoError(t, err)
+	assert.Equal(t, "t_checkpoints", s.tableCheckpoints)
+	assert.Equal(t, "t_checkpoint_writes", s.tableWrites)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewSaver_InstanceNotFound(t *testing.T) {
+	_, err := NewSaver(WithMySQLInstance("missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mysql instance missing not found")
+}
+
+func TestNewSaver_BuilderError(t *testing.T) {
+	oldBuilder := storage.GetClientBuilder()
+	defer storage.SetClientBuilder(oldBuilder)
+	storage.SetClientBuilder(func(opts ...storage.ClientBuilderOpt) (storage.Client, error) {
+		return nil, errors.New("boom")
+	})
+	_, err := NewSaver()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create mysql client failed")
+}
+
+func TestSaver_Put(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+	ckpt := graph.NewCheckpoint(map[string]any{"counter": 1}, map[string]int64{"counter": 1}, nil)
+
+	mock.ExpectExec("INSERT INTO checkpoints .* ON DUPLICATE KEY UPDATE").
+		WithArgs("ln", "ns", ckpt.ID, "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	cfg, err := s.Put(ctx, graph.PutRequest{
+		Config:     graph.CreateCheckpointConfig("ln", "", "ns"),
+		Checkpoint: ckpt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ckpt.ID, graph.GetCheckpointID(cfg))
+	assert.Equal(t, "ns", graph.GetNamespace(cfg))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.Put(ctx, graph.PutRequest{Config: graph.CreateCheckpointConfig("ln", "", "")})
+	require.EqualError(t, err, "checkpoint cannot be nil")
+	_, err = s.Put(ctx, graph.PutRequest{Config: map[string]any{}, Checkpoint: ckpt})
+	require.EqualError(t, err, "lineage_id is required")
+}
+
+func TestSaver_GetTuple(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+
+	ckpt := graph.NewCheckpoint(map[string]any{"k": "v"}, nil, nil)
+	ckpt.ParentCheckpointID = "parent"
+	meta := graph.NewCheckpointMetadata(graph.CheckpointSourceLoop, 2)
+
+	mock.ExpectQuery("SELECT .* FROM checkpoints WHERE lineage_id = \\? AND checkpoint_id = \\? ORDER BY ts DESC LIMIT 1").
+		WithArgs("ln", ckpt.ID).
+		WillReturnRows(checkpointRows(t, ckpt, meta, "ns"))
+	valueJSON, _ := json.Marshal("hello")
+	mock.ExpectQuery("SELECT task_id, channel, value_json, seq FROM checkpoint_writes").
+		WithArgs("ln", "ns", ckpt.ID).
+		WillReturnRows(emptyWriteRows().AddRow("task", "ch", valueJSON, 3))
+	mock.ExpectQuery("SELECT checkpoint_ns FROM checkpoints").
+		WithArgs("ln", "parent").
+		WillReturnRows(sqlmock.NewRows([]string{"checkpoint_ns"}).AddRow("parent-ns"))
+
+	tuple, err := s.GetTuple(ctx, graph.CreateCheckpointConfig("ln", ckpt.ID, ""))
+	require.NoError(t, err)
+	require.NotNil(t, tuple)
+	assert.Equal(t, ckpt.ID, tuple.Checkpoint.ID)
+	assert.Equal(t, "v", tuple.Checkpoint.ChannelValues["k"])
+	assert.Equal(t, 2, tuple.Metadata.Step)
+	assert.Equal(t, "ns", graph.GetNamespace(tuple.Config))
+	assert.Equal(t, "parent-ns", graph.GetNamespace(tuple.ParentConfig))
+	require.Len(t, tuple.PendingWrites, 1)
+	assert.Equal(t, graph.PendingWrite{TaskID: "task", Channel: "ch", Value: "hello", Sequence: 3}, tuple.PendingWrites[0])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaver_GetTuple_NotFound(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT .* FROM checkpoints WHERE lineage_id = \\? AND checkpoint_ns = \\? ORDER BY").
+		WithArgs("ln", "ns").
+		WillReturnRows(sqlmock.NewRows([]string{"checkpoint_json", "metadata_json", "parent_checkpoint_id", "checkpoint_ns", "checkpoint_id"}))
+	ckpt, err := s.Get(ctx, graph.CreateCheckpointConfig("ln", "", "ns"))
+	require.NoError(t, err)
+	assert.Nil(t, ckpt)
+
+	_, err = s.Get(ctx, map[string]any{})
+	require.EqualError(t, err, "lineage_id is required")
+
+	mock.ExpectQuery("SELECT").WillReturnError(errors.New("db down"))
+	_, err = s.GetTuple(ctx, graph.CreateCheckpointConfig("ln", "", ""))
+	require.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaver_List_BeforeAndLimit(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+
+	ckpt := graph.NewCheckpoint(nil, nil, nil)
+	meta := graph.NewCheckpointMetadata(graph.CheckpointSourceInput, -1)
+
+	mock.ExpectQuery("SELECT ts FROM checkpoints WHERE lineage_id = \\? AND checkpoint_id = \\? AND checkpoint_ns = \\?").
+		WithArgs("ln", "before", "ns").
+		WillReturnRows(sqlmock.NewRows([]string{"ts"}).AddRow(int64(100)))
+	mock.ExpectQuery("SELECT .* FROM checkpoints WHERE lineage_id = \\? AND checkpoint_ns = \\? AND ts < \\? ORDER BY ts DESC LIMIT \\?").
+		WithArgs("ln", "ns", int64(100), 1).
+		WillReturnRows(checkpointRows(t, ckpt, meta, "ns"))
+	mock.ExpectQuery("SELECT task_id").WillReturnRows(emptyWriteRows())
+
+	tuples, err := s.List(ctx, graph.CreateCheckpointConfig("ln", "", "ns"), &graph.CheckpointFilter{
+		Before: graph.CreateCheckpointConfig("ln", "before", "ns"),
+		Limit:  1,
+	})
+	require.NoError(t, err)
+	require.Len(t, tuples, 1)
+	assert.Equal(t, ckpt.ID, tuples[0].Checkpoint.ID)
+	assert.Nil(t, tuples[0].ParentConfig)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaver_List_MetadataFilter(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+
+	first := graph.NewCheckpoint(nil, nil, nil)
+	firstMeta := graph.NewCheckpointMetadata(graph.CheckpointSourceInput, 0)
+	firstMeta.Extra = map[string]any{"user": "a"}
+	second := graph.NewCheckpoint(nil, nil, nil)
+	secondMeta := graph.NewCheckpointMetadata(graph.CheckpointSourceInput, 1)
+	secondMeta.Extra = map[string]any{"user": "b"}
+
+	rows := checkpointRows(t, first, firstMeta, "")
+	secondCkpt, _ := json.Marshal(second)
+	secondMetaJSON, _ := json.Marshal(secondMeta)
+	rows.AddRow(secondCkpt, secondMetaJSON, "", "", second.ID)
+
+	// Limit is applied after filtering, so the SQL has no LIMIT clause.
+	mock.ExpectQuery("SELECT .* FROM checkpoints WHERE lineage_id = \\? ORDER BY ts DESC$").
+		WithArgs("ln").
+		WillReturnRows(rows)
+	mock.ExpectQuery("SELECT task_id").WillReturnRows(emptyWriteRows())
+	mock.ExpectQuery("SELECT task_id").WillReturnRows(emptyWriteRows())
+
+	tuples, err := s.List(ctx, graph.CreateCheckpointConfig("ln", "", ""), &graph.CheckpointFilter{
+		Metadata: map[string]any{"user": "b"},
+		Limit:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, tuples, 1)
+	assert.Equal(t, second.ID, tuples[0].Checkpoint.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.List(ctx, map[string]any{}, nil)
+	require.EqualError(t, err, "lineage_id is required")
+}
+
+func TestSaver_PutWrites(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+	cfg := graph.CreateCheckpointConfig("ln", "ck", "ns")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO checkpoint_writes .* ON DUPLICATE KEY UPDATE").
+		WithArgs("ln", "ns", "ck", "task", 0, "a", sqlmock.AnyArg(), "path", int64(0)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO checkpoint_writes").
+		WithArgs("ln", "ns", "ck", "task", 1, "b", sqlmock.AnyArg(), "path", int64(42)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := s.PutWrites(ctx, graph.PutWritesRequest{
+		Config:   cfg,
+		TaskID:   "task",
+		TaskPath: "path",
+		Writes: []graph.PendingWrite{
+			{Channel: "a", Value: 1},
+			{Channel: "b", Value: 2, Sequence: 42},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.PutWrites(ctx, graph.PutWritesRequest{Config: cfg}))
+	require.EqualError(t,
+		s.PutWrites(ctx, graph.PutWritesRequest{Config: graph.CreateCheckpointConfig("ln", "", "")}),
+		"lineage_id and checkpoint_id are required")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaver_PutFull(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+	ckpt := graph.NewCheckpoint(nil, nil, nil)
+	ckpt.Timestamp = time.Unix(0, 123)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO checkpoints").
+		WithArgs("ln", "", ckpt.ID, "", int64(123), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO checkpoint_writes").
+		WithArgs("ln", "", ckpt.ID, "t1", 0, "ch", sqlmock.AnyArg(), "", int64(7)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	cfg, err := s.PutFull(ctx, graph.PutFullRequest{
+		Config:        graph.CreateCheckpointConfig("ln", "", ""),
+		Checkpoint:    ckpt,
+		Metadata:      graph.NewCheckpointMetadata(graph.CheckpointSourceLoop, 1),
+		PendingWrites: []graph.PendingWrite{{TaskID: "t1", Channel: "ch", Value: "v", Sequence: 7}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ckpt.ID, graph.GetCheckpointID(cfg))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaver_PutFull_RollbackOnError(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+	ckpt := graph.NewCheckpoint(nil, nil, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO checkpoints").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO checkpoint_writes").WillReturnError(errors.New("write failed"))
+	mock.ExpectRollback()
+
+	_, err := s.PutFull(ctx, graph.PutFullRequest{
+		Config:        graph.CreateCheckpointConfig("ln", "", ""),
+		Checkpoint:    ckpt,
+		PendingWrites: []graph.PendingWrite{{TaskID: "t1", Channel: "ch", Value: "v"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "insert write")
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.PutFull(ctx, graph.PutFullRequest{Config: graph.CreateCheckpointConfig("ln", "", "")})
+	require.EqualError(t, err, "checkpoint cannot be nil")
+	_, err = s.PutFull(ctx, graph.PutFullRequest{Config: map[string]any{}, Checkpoint: ckpt})
+	require.EqualError(t, err, "lineage_id is required")
+}
+
+func TestSaver_DeleteLineage(t *testing.T) {
+	s, mock := setupMockSaver(t, WithTablePrefix("p"))
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM p_checkpoint_writes WHERE lineage_id = \\?").
+		WithArgs("ln").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM p_checkpoints WHERE lineage_id = \\?").
+		WithArgs("ln").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, s.DeleteLineage(ctx, "ln"))
+	require.EqualError(t, s.DeleteLineage(ctx, ""), "lineage_id is required")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaver_GetTuple_ParentNamespaceUnknown(t *testing.T) {
+	s, mock := setupMockSaver(t)
+	ctx := context.Background()
+
+	ckpt := graph.NewCheckpoint(nil, nil, nil)
+	ckpt.ParentCheckpointID = "gone"
+	meta := graph.NewCheckpointMetadata(graph.CheckpointSourceLoop, 1)
+
+	mock.ExpectQuery("SELECT .* FROM checkpoints").WillReturnRows(checkpointRows(t, ckpt, meta, "ns"))
+	mock.ExpectQuery("SELECT task_id").WillReturnRows(emptyWriteRows())
+	mock.ExpectQuery("SELECT checkpoint_ns FROM checkpoints").
+		WithArgs("ln", "gone").
+		WillReturnRows(sqlmock.NewRows([]string{"checkpoint_ns"}))
+
+	tuple, err := s.GetTuple(ctx, graph.CreateCheckpointConfig("ln", "", "ns"))
+	require.NoError(t, err)
+	require.NotNil(t, tuple.ParentConfig)
+	assert.Equal(t, "gone", graph.GetCheckpointID(tuple.ParentConfig))
+	assert.Equal(t, "", graph.GetNamespace(tuple.ParentConfig))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,77 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	opts := Options{}
+	for _, opt := range []Option{
+		WithPostgresClientDSN("postgres://localhost/db"),
+		WithHost("h"),
+		WithPort(15432),
+		WithUser("u"),
+		WithPassword("p"),
+		WithDatabase("d"),
+		WithSSLMode("require"),
+		WithPostgresInstance("inst"),
+		WithExtraOptions("a"),
+		WithExtraOptions("b"),
+		WithSkipDBInit(true),
+		WithSchema("s"),
+	} {
+		opt(&opts)
+	}
+	assert.Equal(t, "postgres://localhost/db", opts.dsn)
+	assert.Equal(t, "h", opts.host)
+	assert.Equal(t, 15432, opts.port)
+	assert.Equal(t, "u", opts.user)
+	assert.Equal(t, "p", opts.password)
+	assert.Equal(t, "d", opts.database)
+	assert.Equal(t, "require", opts.sslMode)
+	assert.Equal(t, "inst", opts.instanceName)
+	assert.Equal(t, []any{"a", "b"}, opts.extraOptions)
+	assert.True(t, opts.skipDBInit)
+	assert.Equal(t, "s", opts.schema)
+}
+
+func TestWithTablePrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "empty", input: "", expected: ""},
+		{name: "without underscore", input: "app", expected: "app_"},
+		{name: "with underscore", input: "app_", expected: "app_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{}
+			WithTablePrefix(tt.input)(&opts)
+			assert.Equal(t, tt.expected, opts.tablePrefix)
+		})
+	}
+	assert.Panics(t, func() { WithTablePrefix("bad-prefix")(&Options{}) })
+	assert.Panics(t, func() { WithSchema("bad;schema")(&Options{}) })
+}
+
+func TestBuildConnString(t *testing.T) {
+	assert.Equal(t,
+		"host=localhost port=5432 dbname=trpc-agent-go-pgcheckpoint sslmode=disable",
+		buildConnString(Options{}))
+	assert.Equal(t,
+		"host=h port=1 dbname=d sslmode=require user=u password=p",
+		buildConnString(Options{host: "h", port: 1, database: "d", sslMode: "require", user: "u", password: "p"}))
+}
@@ -2947,11 +2947,65 @@ func (e *Executor) attemptCacheLookup(t *Task) (bool, any) {
 
 	ns := e.graph.cacheNamespace(t.NodeID)
 	if cached, ok := c.Get(ns, string(keyBytes)); ok {
-		return true, cached
+		return true, e.restoreCachedResult(cached)
 	}
 	return false, nil
 }
 
+// restoreCachedResult converts state values decoded by an out-of-process
+// cache (for example through NewJSONCacheSerializer) back to their schema
+// field types. Values that already have a concrete type, such as the deep
+// copies returned by InMemoryCache, are left unchanged.
+func (e *Executor) restoreCachedResult(result any) any {
+	switch v := result.(type) {
+	case State:
+		return e.restoreCachedState(v)
+	case *Command:
+		if v != nil && v.Update != nil {
+			v.Update = e.restoreCachedState(v.Update)
+		}
+	case []*Command:
+		for _, c := range v {
+			if c != nil && c.Update != nil {
+				c.Update = e.restoreCachedState(c.Update)
+			}
+		}
+	}
+	return result
+}
+
+func (e *Executor) restoreCachedState(s State) State {
+	if raw, ok := s[StateKeyOneShotMessages]; ok && isJSONGeneric(raw) {
+		if msgs, err := decodeMessages(raw); err == nil {
+			s[StateKeyOneShotMessages] = msgs
+		}
+	}
+	schema := e.graph.Schema()
+	if schema == nil {
+		return s
+	}
+	for key, value := range s {
+		field, exists := schema.Fields[key]
+		if !exists || field.Type == nil || field.Type.Kind() == reflect.Interface {
+			continue
+		}
+		if isJSONGeneric(value) {
+			s[key] = e.restoreCheckpointValueWithSchema(value, field)
+		}
+	}
+	return s
+}
+
+// isJSONGeneric reports whether v has one of the types produced by decoding
+// JSON into an untyped value.
+func isJSONGeneric(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any, float64:
+		return true
+	}
+	return false
+}
+
 func applyCacheKeySelector(
 	selector func(map[string]any) any,
 	input any,
@@ -14,6 +14,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -132,6 +133,9 @@ type Node struct {
 	callbacks *NodeCallbacks
 	// Optional per-node cache policy. If nil, graph-level policy applies.
 	cachePolicy *CachePolicy
+	// cacheKeyFields records the field projection configured by
+	// WithCacheKeyFields so it can be introspected after construction.
+	cacheKeyFields []string
 	// Optional per-node cache key selector. When set, the executor applies this
 	// selector to the sanitized node input before invoking the CachePolicy.KeyFunc.
 	// The selector receives a sanitized map[string]any view and should return a
@@ -201,6 +205,17 @@ type Node struct {
 	// sub-agent. This provides a concise way to implement "pass only the
 	// result" pipelines between agent nodes without extra glue nodes.
 	agentInputFromLastResponse bool
+
+	// annotations holds free-form strings attached via WithNodeAnnotations.
+	// The executor never reads them.
+	annotations map[string]string
+}
+
+// JoinEdge describes a join edge added via StateGraph.AddJoinEdge. The target
+// node runs only after every node in From has completed.
+type JoinEdge struct {
+	From []string
+	To   string
 }
 
 // Edge represents an edge in the graph.
@@ -377,6 +392,138 @@ func (n *Node) Tools(ctx context.Context) []tool.Tool {
 	return tools
 }
 
+// Annotations returns a copy of the annotations attached via
+// WithNodeAnnotations.
+func (n *Node) Annotations() map[string]string {
+	return copyStringMap(n.annotations)
+}
+
+// RetryPolicies returns the retry policies configured on the node.
+func (n *Node) RetryPolicies() []RetryPolicy {
+	return append([]RetryPolicy(nil), n.retryPolicies...)
+}
+
+// CachePolicy returns the per-node cache policy (may be nil).
+func (n *Node) CachePolicy() *CachePolicy {
+	return n.cachePolicy
+}
+
+// CacheKeyFields returns the fields configured via WithCacheKeyFields.
+func (n *Node) CacheKeyFields() []string {
+	return append([]string(nil), n.cacheKeyFields...)
+}
+
+// InterruptBefore reports whether a static interrupt pauses before the node.
+func (n *Node) InterruptBefore() bool {
+	return n.interruptBefore
+}
+
+// InterruptAfter reports whether a static interrupt pauses after the node.
+func (n *Node) InterruptAfter() bool {
+	return n.interruptAfter
+}
+
+// Destinations returns a copy of the declared dynamic destinations.
+func (n *Node) Destinations() map[string]string {
+	return copyStringMap(n.destinations)
+}
+
+// Ends returns a copy of the per-node named ends mapping.
+func (n *Node) Ends() map[string]string {
+	return copyStringMap(n.ends)
+}
+
+// ToolSets returns the tool sets configured on the node.
+func (n *Node) ToolSets() []tool.ToolSet {
+	return append([]tool.ToolSet(nil), n.toolSets...)
+}
+
+// RefreshToolSetsOnRun reports whether tool sets are re-resolved per run.
+func (n *Node) RefreshToolSetsOnRun() bool {
+	return n.refreshToolSetsOnRun
+}
+
+// ParallelTools reports whether a Tools node executes tool calls concurrently.
+func (n *Node) ParallelTools() bool {
+	return n.enableParallelTools
+}
+
+// AgentIsolatedMessages reports whether an agent node isolates the child
+// invocation from the parent message history.
+func (n *Node) AgentIsolatedMessages() bool {
+	return n.agentIsolatedMessages
+}
+
+// AgentInputFromLastResponse reports whether an agent node maps the parent's
+// last response to the child user input.
+func (n *Node) AgentInputFromLastResponse() bool {
+	return n.agentInputFromLastResponse
+}
+
+// UserInputKey returns the state key configured via WithUserInputKey.
+func (n *Node) UserInputKey() string {
+	return n.userInputKey
+}
+
+// StreamOutput returns the stream name configured via WithStreamOutput.
+func (n *Node) StreamOutput() string {
+	return n.streamOutputName
+}
+
+// GenerationConfig returns the per-node generation config (may be nil).
+func (n *Node) GenerationConfig() *model.GenerationConfig {
+	if n.llmGenerationConfig == nil {
+		return nil
+	}
+	cfg := *n.llmGenerationConfig
+	return &cfg
+}
+
+func copyStringMap(src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return nil
+	}
+	dst := make(map[string]string, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// JoinEdges returns the join edges of the graph sorted by target node ID.
+func (g *Graph) JoinEdges() []JoinEdge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var joins []JoinEdge
+	for name, ch := range g.channelManager.GetAllChannels() {
+		if !strings.HasPrefix(name, ChannelJoinPrefix) || ch == nil {
+			continue
+		}
+		targets := g.triggerToNodes[name]
+		if len(targets) == 0 {
+			continue
+		}
+		joins = append(joins, JoinEdge{
+			From: append([]string(nil), ch.BarrierExpected...),
+			To:   targets[0],
+		})
+	}
+	sort.Slice(joins, func(i, j int) bool {
+		if joins[i].To != joins[j].To {
+			return joins[i].To < joins[j].To
+		}
+		return strings.Join(joins[i].From, ",") < strings.Join(joins[j].From, ",")
+	})
+	return joins
+}
+
+// GraphVersion returns the version set via StateGraph.WithGraphVersion.
+func (g *Graph) GraphVersion() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.graphVersion
+}
+
 // Schema returns the state schema.
 func (g *Graph) Schema() *StateSchema {
 	return g.schema
@@ -712,3 +712,42 @@ func TestWrapperCondFunc_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestGraph_IntrospectionAccessors(t *testing.T) {
+	noop := func(ctx context.Context, s State) (any, error) { return nil, nil }
+	sg := NewStateGraph(NewStateSchema())
+	sg.AddNode("a", noop, WithNodeAnnotations(map[string]string{"k": "v"}),
+		WithCacheKeyFields("x"), WithInterruptBefore())
+	sg.AddNode("b", noop, WithNodeAnnotations(map[string]string{"k2": "v2"}))
+	sg.AddNode("c", noop, WithEndsMap(map[string]string{"done": End}))
+	sg.SetEntryPoint("a")
+	sg.AddEdge("a", "b")
+	sg.AddJoinEdge([]string{"b", "a"}, "c")
+	sg.SetFinishPoint("c")
+	sg.WithGraphVersion("v3")
+	g, err := sg.Compile()
+	require.NoError(t, err)
+
+	a, _ := g.Node("a")
+	annotations := a.Annotations()
+	assert.Equal(t, map[string]string{"k": "v"}, annotations)
+	annotations["k"] = "mutated"
+	assert.Equal(t, "v", a.Annotations()["k"])
+	assert.Equal(t, []string{"x"}, a.CacheKeyFields())
+	assert.True(t, a.InterruptBefore())
+	assert.False(t, a.InterruptAfter())
+
+	c, _ := g.Node("c")
+	assert.Equal(t, map[string]string{"done": End}, c.Ends())
+	assert.Nil(t, c.Annotations())
+
+	assert.Equal(t, []JoinEdge{{From: []string{"a", "b"}, To: "c"}}, g.JoinEdges())
+	assert.Equal(t, "v3", g.GraphVersion())
+
+	// A custom selector replaces the recorded key fields.
+	sg2 := NewStateGraph(NewStateSchema())
+	sg2.AddNode("a", noop, WithCacheKeyFields("x"),
+		WithCacheKeySelector(func(m map[string]any) any { return m }))
+	n, _ := sg2.SetEntryPoint("a").MustCompile().Node("a")
+	assert.Empty(t, n.CacheKeyFields())
+}
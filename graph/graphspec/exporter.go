@@ -0,0 +1,399 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graphspec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	itool "trpc.group/trpc-go/trpc-agent-go/internal/tool"
+)
+
+// ExportStateGraph compiles sg and exports it. See Export.
+func ExportStateGraph(sg *graph.StateGraph, reg *Registry) (*Spec, error) {
+	if sg == nil {
+		return nil, errors.New("graphspec: state graph is nil")
+	}
+	g, err := sg.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return Export(g, reg)
+}
+
+// Export serializes a compiled graph into a Spec.
+//
+// Models, tool sets, reducers, retry conditions and caches are named by
+// looking them up in reg. Node functions and conditions cannot be recovered
+// from compiled closures; they are named from the annotations Load attaches
+// and otherwise default to the node ID, so registering them under the node
+// ID makes a hand-built graph round-trip. Custom cache key functions,
+// callbacks and other Go-only configuration are not exported.
+func Export(g *graph.Graph, reg *Registry) (*Spec, error) {
+	if g == nil {
+		return nil, errors.New("graphspec: graph is nil")
+	}
+	if reg == nil {
+		reg = NewRegistry()
+	}
+	e := &exporter{reg: reg}
+	spec := &Spec{
+		Version:      SpecVersionV1,
+		GraphVersion: g.GraphVersion(),
+		EntryPoint:   g.EntryPoint(),
+	}
+	spec.State = e.exportState(g.Schema())
+	spec.Cache = e.exportCache(g)
+
+	nodes := g.Nodes()
+	for _, n := range nodes {
+		spec.Nodes = append(spec.Nodes, e.exportNode(n))
+	}
+
+	joins := g.JoinEdges()
+	joined := make(map[[2]string]bool)
+	for _, j := range joins {
+		spec.JoinEdges = append(spec.JoinEdges, JoinEdgeSpec{From: j.From, To: j.To})
+		for _, from := range j.From {
+			joined[[2]string{from, j.To}] = true
+		}
+	}
+
+	sources := append([]string{graph.Start}, nodeIDs(nodes)...)
+	for _, from := range sources {
+		for _, edge := range g.Edges(from) {
+			switch {
+			case from == graph.Start && edge.To == spec.EntryPoint:
+			case edge.To == graph.End:
+				spec.FinishPoints = appendUnique(spec.FinishPoints, from)
+			case joined[[2]string{from, edge.To}]:
+			default:
+				spec.Edges = append(spec.Edges, EdgeSpec{From: from, To: edge.To})
+			}
+		}
+		ce, ok := g.ConditionalEdge(from)
+		if !ok || ce == nil {
+			continue
+		}
+		spec.ConditionalEdges = append(spec.ConditionalEdges, e.exportConditional(g, ce))
+	}
+	if len(e.errs) > 0 {
+		return nil, fmt.Errorf("graphspec: export: %w", errors.Join(e.errs...))
+	}
+	return spec, nil
+}
+
+type exporter struct {
+	reg  *Registry
+	errs []error
+}
+
+func (e *exporter) fail(format string, args ...any) {
+	e.errs = append(e.errs, fmt.Errorf(format, args...))
+}
+
+func nodeIDs(nodes []*graph.Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, existing := range list {
+		if existing == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+var nodeTypeNames = map[graph.NodeType]string{
+	graph.NodeTypeFunction: NodeTypeFunction,
+	graph.NodeTypeLLM:      NodeTypeLLM,
+	graph.NodeTypeTool:     NodeTypeTools,
+	graph.NodeTypeAgent:    NodeTypeAgent,
+	graph.NodeTypeRouter:   NodeTypeRouter,
+	graph.NodeTypeJoin:     NodeTypeJoin,
+}
+
+func (e *exporter) exportNode(n *graph.Node) NodeSpec {
+	ns := NodeSpec{
+		ID:              n.ID,
+		Type:            nodeTypeNames[n.Type],
+		Description:     n.Description,
+		UserInputKey:    n.UserInputKey(),
+		StreamOutput:    n.StreamOutput(),
+		InterruptBefore: n.InterruptBefore(),
+		InterruptAfter:  n.InterruptAfter(),
+		Destinations:    n.Destinations(),
+		Ends:            n.Ends(),
+	}
+	if ns.Type == "" {
+		ns.Type = NodeTypeFunction
+	}
+	if n.Name != n.ID {
+		ns.Name = n.Name
+	}
+	annotations := n.Annotations()
+	switch ns.Type {
+	case NodeTypeFunction, NodeTypeRouter, NodeTypeJoin:
+		if ref := annotations[AnnotationKeyFunction]; ref != "" && ref != n.ID {
+			ns.Function = ref
+		}
+	case NodeTypeLLM:
+		ns.Instruction = n.Instruction()
+		ns.GenerationConfig = n.GenerationConfig()
+		if m := n.Model(); m != nil {
+			ns.Model = e.reg.modelName(m)
+			if ns.Model == "" {
+				ns.Model = m.Info().Name
+			}
+		}
+	case NodeTypeAgent:
+		agentSpec := &AgentSpec{
+			IsolatedMessages:      n.AgentIsolatedMessages(),
+			EventScope:            n.AgentEventScope(),
+			InputFromLastResponse: n.AgentInputFromLastResponse(),
+		}
+		if *agentSpec != (AgentSpec{}) {
+			ns.Agent = agentSpec
+		}
+	}
+	if ns.Type == NodeTypeLLM || ns.Type == NodeTypeTools {
+		e.exportTools(n, &ns)
+	}
+	for _, policy := range n.RetryPolicies() {
+		ns.Retry = append(ns.Retry, e.exportRetry(n.ID, policy))
+	}
+	if policy := n.CachePolicy(); policy != nil {
+		ns.Cache = &NodeCacheSpec{
+			TTL:       Duration(policy.TTL),
+			KeyFields: n.CacheKeyFields(),
+		}
+	}
+	for k, v := range annotations {
+		if strings.HasPrefix(k, "graphspec.") {
+			continue
+		}
+		if ns.Annotations == nil {
+			ns.Annotations = make(map[string]string)
+		}
+		ns.Annotations[k] = v
+	}
+	return ns
+}
+
+func (e *exporter) exportTools(n *graph.Node, ns *NodeSpec) {
+	ctx := context.Background()
+	fromSets := make(map[string]bool)
+	for _, ts := range n.ToolSets() {
+		name := e.reg.toolSetName(ts)
+		if name == "" {
+			name = ts.Name()
+		}
+		ns.ToolSets = append(ns.ToolSets, name)
+		for _, t := range itool.NewNamedToolSet(ts).Tools(ctx) {
+			fromSets[t.Declaration().Name] = true
+		}
+	}
+	for _, t := range n.Tools(ctx) {
+		if name := t.Declaration().Name; !fromSets[name] {
+			ns.Tools = append(ns.Tools, name)
+		}
+	}
+	ns.RefreshToolSetsOnRun = n.RefreshToolSetsOnRun()
+	ns.ParallelTools = n.ParallelTools()
+}
+
+func (e *exporter) exportRetry(nodeID string, policy graph.RetryPolicy) RetrySpec {
+	rs := RetrySpec{
+		MaxAttempts:       policy.MaxAttempts,
+		InitialInterval:   Duration(policy.InitialInterval),
+		BackoffFactor:     policy.BackoffFactor,
+		MaxInterval:       Duration(policy.MaxInterval),
+		Jitter:            policy.Jitter,
+		MaxElapsedTime:    Duration(policy.MaxElapsedTime),
+		PerAttemptTimeout: Duration(policy.PerAttemptTimeout),
+	}
+	for i, cond := range policy.RetryOn {
+		name := e.reg.retryConditionName(cond)
+		if name == "" {
+			e.fail("node %q: retry condition %d is not registered", nodeID, i)
+			continue
+		}
+		rs.RetryOn = append(rs.RetryOn, name)
+	}
+	return rs
+}
+
+func (e *exporter) exportConditional(g *graph.Graph, ce *graph.ConditionalEdge) ConditionalEdgeSpec {
+	var annotations map[string]string
+	if n, ok := g.Node(ce.From); ok && n != nil {
+		annotations = n.Annotations()
+	}
+	if toolsNode := annotations[AnnotationKeyToolsNode]; toolsNode != "" {
+		return ConditionalEdgeSpec{
+			From:      ce.From,
+			ToolsNode: toolsNode,
+			Fallback:  annotations[AnnotationKeyFallback],
+		}
+	}
+	out := ConditionalEdgeSpec{
+		From:      ce.From,
+		Condition: annotations[AnnotationKeyCondition],
+	}
+	if out.Condition == "" {
+		out.Condition = ce.From
+	}
+	if len(ce.PathMap) > 0 {
+		out.Routes = make(map[string]string, len(ce.PathMap))
+		for k, v := range ce.PathMap {
+			out.Routes[k] = v
+		}
+	}
+	return out
+}
+
+func (e *exporter) exportState(schema *graph.StateSchema) *StateSpec {
+	if schema == nil || len(schema.Fields) == 0 {
+		return nil
+	}
+	state := &StateSpec{}
+	fields := schema.Fields
+	if hasMessagesPreset(fields) {
+		state.Preset = StatePresetMessages
+	}
+	preset := graph.MessagesStateSchema().Fields
+	for _, name := range sortedKeys(fields) {
+		field := fields[name]
+		if state.Preset != "" {
+			if p, ok := preset[name]; ok && sameField(p, field) {
+				continue
+			}
+		}
+		fs := StateFieldSpec{
+			Name:     name,
+			Type:     fieldTypeName(field.Type),
+			Required: field.Required,
+		}
+		if fs.Type == FieldTypeAny {
+			fs.Type = ""
+		}
+		reducer := e.reg.reducerName(field.Reducer)
+		if reducer == "" {
+			e.fail("state field %q: reducer is not registered", name)
+		}
+		if reducer != ReducerDefault {
+			fs.Reducer = reducer
+		}
+		if field.Default != nil {
+			if v := field.Default(); v != nil {
+				if _, err := json.Marshal(v); err == nil {
+					fs.Default = v
+				}
+			}
+		}
+		state.Fields = append(state.Fields, fs)
+	}
+	return state
+}
+
+func hasMessagesPreset(fields map[string]graph.StateField) bool {
+	for name, p := range graph.MessagesStateSchema().Fields {
+		f, ok := fields[name]
+		if !ok || !sameField(p, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameField(a, b graph.StateField) bool {
+	return a.Type == b.Type &&
+		a.Required == b.Required &&
+		reflect.ValueOf(a.Reducer).Pointer() == reflect.ValueOf(b.Reducer).Pointer()
+}
+
+func fieldTypeName(t reflect.Type) string {
+	for _, name := range sortedKeys(fieldTypes) {
+		if fieldTypes[name] == t {
+			return name
+		}
+	}
+	return FieldTypeAny
+}
+
+func (e *exporter) exportCache(g *graph.Graph) *CacheSpec {
+	c := g.Cache()
+	if c == nil {
+		return nil
+	}
+	cs := &CacheSpec{Backend: e.reg.cacheName(c)}
+	if _, inMemory := c.(*graph.InMemoryCache); cs.Backend == "" && !inMemory {
+		e.fail("cache backend %T is not registered", c)
+	}
+	if policy := g.CachePolicy(); policy != nil {
+		cs.DefaultPolicy = true
+		cs.TTL = Duration(policy.TTL)
+	}
+	return cs
+}
+
+// EncodeJSON encodes spec as indented JSON.
+func EncodeJSON(spec *Spec) ([]byte, error) {
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// EncodeYAML encodes spec as YAML. Field order follows the JSON encoding.
+func EncodeYAML(spec *Spec) ([]byte, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is a subset of YAML, so decoding it into a yaml.Node keeps the
+	// key order and the json tag names.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearStyle(&node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearStyle resets the flow style inherited from the JSON input so the
+// output uses block style.
+func clearStyle(n *yaml.Node) {
+	n.Style &^= yaml.FlowStyle
+	if n.Kind == yaml.ScalarNode && n.Style&yaml.DoubleQuotedStyle != 0 && n.Tag == "!!str" {
+		n.Style &^= yaml.DoubleQuotedStyle
+	}
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
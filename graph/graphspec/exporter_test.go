@@ -0,0 +1,210 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graphspec
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+type stubModel struct{ name string }
+
+func (m *stubModel) GenerateContent(ctx context.Context, req *model.Request) (<-chan *model.Response, error) {
+	ch := make(chan *model.Response)
+	close(ch)
+	return ch, nil
+}
+
+func (m *stubModel) Info() model.Info { return model.Info{Name: m.name} }
+
+type stubToolSet struct {
+	name  string
+	tools []tool.Tool
+}
+
+func (s *stubToolSet) Tools(context.Context) []tool.Tool { return s.tools }
+func (s *stubToolSet) Close() error                      { return nil }
+func (s *stubToolSet) Name() string                      { return s.name }
+
+func newEchoTool(name string) tool.Tool {
+	return function.NewFunctionTool(
+		func(ctx context.Context, in map[string]any) (map[string]any, error) { return in, nil },
+		function.WithName(name),
+	)
+}
+
+func TestExport_RoundTrip(t *testing.T) {
+	rec := &visitRecorder{}
+	reg := newTestRegistry(t, rec)
+	spec, err := Parse([]byte(testSpecYAML))
+	require.NoError(t, err)
+	g, err := Load(spec, reg)
+	require.NoError(t, err)
+
+	exported, err := Export(g, reg)
+	require.NoError(t, err)
+	assert.Equal(t, SpecVersionV1, exported.Version)
+	assert.Equal(t, "prepare", exported.EntryPoint)
+	assert.ElementsMatch(t, []string{"approve", "reject"}, exported.FinishPoints)
+	assert.ElementsMatch(t, []EdgeSpec{{From: "prepare", To: "left"}, {From: "prepare", To: "right"}}, exported.Edges)
+	assert.Equal(t, []JoinEdgeSpec{{From: []string{"left", "right"}, To: "decide"}}, exported.JoinEdges)
+	require.Len(t, exported.ConditionalEdges, 1)
+	assert.Equal(t, "by_score", exported.ConditionalEdges[0].Condition)
+	assert.Equal(t, map[string]string{"high": "approve", "low": "reject"}, exported.ConditionalEdges[0].Routes)
+	require.NotNil(t, exported.State)
+	assert.Equal(t, []StateFieldSpec{
+		{Name: "score", Type: FieldTypeInt, Default: 0},
+		{Name: "tags", Type: FieldTypeStringList, Reducer: ReducerStringSlice},
+	}, exported.State.Fields)
+
+	byID := make(map[string]NodeSpec)
+	for _, n := range exported.Nodes {
+		byID[n.ID] = n
+	}
+	assert.Equal(t, "branch", byID["left"].Function)
+	assert.Empty(t, byID["prepare"].Function)
+	assert.Empty(t, byID["prepare"].Annotations)
+	assert.Equal(t, []string{RetryConditionTransient}, byID["prepare"].Retry[0].RetryOn)
+	assert.Equal(t, NodeTypeRouter, byID["decide"].Type)
+	assert.Equal(t, &NodeCacheSpec{TTL: Duration(30 * time.Second), KeyFields: []string{"score"}}, byID["right"].Cache)
+
+	// Encode, decode and load again; the second export must be identical.
+	data, err := EncodeYAML(exported)
+	require.NoError(t, err)
+	reparsed, err := Parse(data)
+	require.NoError(t, err)
+	g2, err := Load(reparsed, reg)
+	require.NoError(t, err)
+	again, err := Export(g2, reg)
+	require.NoError(t, err)
+	assert.Equal(t, exported, again)
+	runGraph(t, g2)
+	assert.Contains(t, rec.visits, "approve")
+}
+
+func TestExportStateGraph_HandBuilt(t *testing.T) {
+	llm := &stubModel{name: "gpt"}
+	search := newEchoTool("search")
+	ts := &stubToolSet{name: "fs", tools: []tool.Tool{newEchoTool("read")}}
+	reg := NewRegistry()
+	require.NoError(t, reg.RegisterModel("main", llm))
+	require.NoError(t, reg.RegisterTool(search))
+	require.NoError(t, reg.RegisterToolSet("files", ts))
+
+	noop := func(ctx context.Context, s graph.State) (any, error) { return nil, nil }
+	sg := graph.NewStateGraph(graph.MessagesStateSchema())
+	sg.AddLLMNode("ask", llm, "be brief", map[string]tool.Tool{"search": search},
+		graph.WithToolSets([]tool.ToolSet{ts}),
+		graph.WithGenerationConfig(model.GenerationConfig{Stream: true}),
+	)
+	sg.AddToolsNode("tools", map[string]tool.Tool{"search": search}, graph.WithEnableParallelTools(true))
+	sg.AddAgentNode("helper", graph.WithSubgraphIsolatedMessages(true))
+	sg.AddNode("done", noop, graph.WithNodeAnnotations(map[string]string{"owner": "ops"}))
+	sg.SetEntryPoint("ask")
+	sg.AddToolsConditionalEdges("ask", "tools", "helper")
+	sg.AddEdge("tools", "ask")
+	sg.AddEdge("helper", "done")
+	sg.SetFinishPoint("done")
+
+	spec, err := ExportStateGraph(sg, reg)
+	require.NoError(t, err)
+	assert.Equal(t, StatePresetMessages, spec.State.Preset)
+	assert.Empty(t, spec.State.Fields)
+
+	byID := make(map[string]NodeSpec)
+	for _, n := range spec.Nodes {
+		byID[n.ID] = n
+	}
+	ask := byID["ask"]
+	assert.Equal(t, NodeTypeLLM, ask.Type)
+	assert.Equal(t, "main", ask.Model)
+	assert.Equal(t, "be brief", ask.Instruction)
+	assert.Equal(t, []string{"search"}, ask.Tools)
+	assert.Equal(t, []string{"files"}, ask.ToolSets)
+	require.NotNil(t, ask.GenerationConfig)
+	assert.True(t, ask.GenerationConfig.Stream)
+	assert.Equal(t, NodeTypeTools, byID["tools"].Type)
+	assert.True(t, byID["tools"].ParallelTools)
+	assert.Equal(t, &AgentSpec{IsolatedMessages: true}, byID["helper"].Agent)
+	assert.Equal(t, map[string]string{"owner": "ops"}, byID["done"].Annotations)
+
+	// The tools condition is recognised only when built from a spec; a
+	// hand-built conditional edge exports under its source node ID.
+	require.Len(t, spec.ConditionalEdges, 1)
+	assert.Equal(t, "ask", spec.ConditionalEdges[0].Condition)
+
+	// Rebuilding from a spec records the tools shortcut and round-trips.
+	spec.ConditionalEdges[0] = ConditionalEdgeSpec{From: "ask", ToolsNode: "tools", Fallback: "helper"}
+	require.NoError(t, reg.RegisterFunction("done", noop))
+	g, err := Load(spec, reg)
+	require.NoError(t, err)
+	again, err := Export(g, reg)
+	require.NoError(t, err)
+	assert.Equal(t, spec.ConditionalEdges, again.ConditionalEdges)
+	assert.True(t, reflect.DeepEqual(spec.Nodes, again.Nodes))
+}
+
+func TestExport_Errors(t *testing.T) {
+	_, err := Export(nil, nil)
+	require.Error(t, err)
+	_, err = ExportStateGraph(nil, nil)
+	require.Error(t, err)
+
+	custom := func(existing, update any) any { return update }
+	schema := graph.NewStateSchema().AddField("x", graph.StateField{
+		Type:    reflect.TypeOf(""),
+		Reducer: custom,
+	})
+	sg := graph.NewStateGraph(schema)
+	sg.AddNode("a", func(ctx context.Context, s graph.State) (any, error) { return nil, nil },
+		graph.WithRetryPolicy(graph.RetryPolicy{RetryOn: []graph.RetryCondition{
+			graph.RetryOnPredicate(func(error) bool { return true }),
+		}}),
+	)
+	sg.SetEntryPoint("a").SetFinishPoint("a")
+	_, err = ExportStateGraph(sg, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `state field "x": reducer is not registered`)
+	assert.Contains(t, err.Error(), `retry condition 0 is not registered`)
+
+	reg := NewRegistry()
+	require.NoError(t, reg.RegisterReducer("custom", custom))
+	sg2 := graph.NewStateGraph(schema)
+	sg2.AddNode("a", func(ctx context.Context, s graph.State) (any, error) { return nil, nil })
+	sg2.SetEntryPoint("a").SetFinishPoint("a")
+	spec, err := ExportStateGraph(sg2, reg)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", spec.State.Fields[0].Reducer)
+}
+
+func TestDuration_JSON(t *testing.T) {
+	var d Duration
+	require.NoError(t, d.UnmarshalJSON([]byte(`"250ms"`)))
+	assert.Equal(t, Duration(250*time.Millisecond), d)
+	require.NoError(t, d.UnmarshalJSON([]byte(`1.5`)))
+	assert.Equal(t, Duration(1500*time.Millisecond), d)
+	require.NoError(t, d.UnmarshalJSON([]byte(`null`)))
+	assert.Equal(t, Duration(0), d)
+	require.Error(t, d.UnmarshalJSON([]byte(`"soon"`)))
+	require.Error(t, d.UnmarshalJSON([]byte(`true`)))
+	out, err := Duration(time.Second).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `"1s"`, string(out))
+}
@@ -0,0 +1,585 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graphspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Parse decodes a Spec from JSON or YAML. JSON is detected by a leading '{';
+// everything else is treated as YAML. Unknown fields are rejected so that
+// typos in hand-written specs surface early.
+func Parse(data []byte) (*Spec, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, errors.New("graphspec: empty spec")
+	}
+	jsonData := trimmed
+	if trimmed[0] != '{' {
+		var doc any
+		if err := yaml.Unmarshal(trimmed, &doc); err != nil {
+			return nil, fmt.Errorf("graphspec: decode yaml: %w", err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("graphspec: convert yaml: %w", err)
+		}
+		jsonData = converted
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.DisallowUnknownFields()
+	var spec Spec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("graphspec: decode spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// ParseFile reads and decodes a Spec from path.
+func ParseFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("graphspec: read %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Load validates spec, resolves its references against reg and compiles the
+// resulting graph.
+func Load(spec *Spec, reg *Registry) (*graph.Graph, error) {
+	sg, err := Build(spec, reg)
+	if err != nil {
+		return nil, err
+	}
+	return sg.Compile()
+}
+
+// LoadFile is a convenience wrapper around ParseFile and Load.
+func LoadFile(path string, reg *Registry) (*graph.Graph, error) {
+	spec, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(spec, reg)
+}
+
+// Build validates spec, resolves its references against reg and returns the
+// uncompiled StateGraph. Callers can attach Go-only configuration such as
+// callbacks before calling Compile.
+func Build(spec *Spec, reg *Registry) (*graph.StateGraph, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		reg = NewRegistry()
+	}
+	b := &builder{spec: spec, reg: reg}
+	schema := b.buildSchema()
+	nodes := make([]resolvedNode, 0, len(spec.Nodes))
+	for i := range spec.Nodes {
+		nodes = append(nodes, b.resolveNode(&spec.Nodes[i]))
+	}
+	conds := b.resolveConditions()
+	cache := b.resolveCache()
+	if len(b.errs) > 0 {
+		return nil, fmt.Errorf("graphspec: %w", errors.Join(b.errs...))
+	}
+
+	sg := graph.NewStateGraph(schema)
+	for _, n := range nodes {
+		n.add(sg)
+	}
+	sg.SetEntryPoint(spec.EntryPoint)
+	for _, e := range spec.Edges {
+		sg.AddEdge(e.From, e.To)
+	}
+	for _, j := range spec.JoinEdges {
+		sg.AddJoinEdge(j.From, j.To)
+	}
+	for _, c := range conds {
+		c(sg)
+	}
+	for _, id := range spec.FinishPoints {
+		sg.SetFinishPoint(id)
+	}
+	if spec.GraphVersion != "" {
+		sg.WithGraphVersion(spec.GraphVersion)
+	}
+	if spec.Cache != nil {
+		sg.WithCache(cache)
+		if spec.Cache.DefaultPolicy {
+			policy := graph.DefaultCachePolicy()
+			policy.TTL = time.Duration(spec.Cache.TTL)
+			sg.WithCachePolicy(policy)
+		}
+	}
+	return sg, nil
+}
+
+type builder struct {
+	spec *Spec
+	reg  *Registry
+	errs []error
+}
+
+func (b *builder) fail(format string, args ...any) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+func (b *builder) buildSchema() *graph.StateSchema {
+	if b.spec.State == nil {
+		return graph.NewStateSchema()
+	}
+	var schema *graph.StateSchema
+	if b.spec.State.Preset == StatePresetMessages {
+		schema = graph.MessagesStateSchema()
+	} else {
+		schema = graph.NewStateSchema()
+	}
+	for _, f := range b.spec.State.Fields {
+		reducer, ok := b.reg.Reducer(f.Reducer)
+		if !ok {
+			b.fail("state field %q: unknown reducer %q", f.Name, f.Reducer)
+			continue
+		}
+		typ := fieldTypes[fieldTypeOrAny(f.Type)]
+		field := graph.StateField{
+			Type:     typ,
+			Reducer:  reducer,
+			Required: f.Required,
+		}
+		if f.Default != nil {
+			defaultFn, err := defaultFunc(typ, f.Default)
+			if err != nil {
+				b.fail("state field %q: %w", f.Name, err)
+				continue
+			}
+			field.Default = defaultFn
+		}
+		schema.AddField(f.Name, field)
+	}
+	return schema
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+var fieldTypes = map[string]reflect.Type{
+	FieldTypeAny:        anyType,
+	FieldTypeString:     reflect.TypeOf(""),
+	FieldTypeInt:        reflect.TypeOf(0),
+	FieldTypeFloat:      reflect.TypeOf(float64(0)),
+	FieldTypeBool:       reflect.TypeOf(false),
+	FieldTypeMap:        reflect.TypeOf(map[string]any{}),
+	FieldTypeList:       reflect.TypeOf([]any{}),
+	FieldTypeStringList: reflect.TypeOf([]string{}),
+	FieldTypeMessages:   reflect.TypeOf([]model.Message{}),
+}
+
+func fieldTypeOrAny(t string) string {
+	if t == "" {
+		return FieldTypeAny
+	}
+	return t
+}
+
+// defaultFunc converts v to typ once to validate it, then returns a function
+// producing a fresh copy on every call.
+func defaultFunc(typ reflect.Type, v any) (func() any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode default: %w", err)
+	}
+	decode := func() (any, error) {
+		ptr := reflect.New(typ)
+		if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	}
+	if _, err := decode(); err != nil {
+		return nil, fmt.Errorf("default is not a valid %v: %w", typ, err)
+	}
+	return func() any {
+		out, _ := decode()
+		return out
+	}, nil
+}
+
+type resolvedNode struct {
+	spec     *NodeSpec
+	fn       graph.NodeFunc
+	model    model.Model
+	tools    map[string]tool.Tool
+	toolSets []tool.ToolSet
+	opts     []graph.Option
+}
+
+func (n resolvedNode) add(sg *graph.StateGraph) {
+	switch n.spec.Type {
+	case NodeTypeLLM:
+		sg.AddLLMNode(n.spec.ID, n.model, n.spec.Instruction, n.tools, n.opts...)
+	case NodeTypeTools:
+		sg.AddToolsNode(n.spec.ID, n.tools, n.opts...)
+	case NodeTypeAgent:
+		sg.AddAgentNode(n.spec.ID, n.opts...)
+	default:
+		sg.AddNode(n.spec.ID, n.fn, n.opts...)
+	}
+}
+
+func (b *builder) resolveNode(ns *NodeSpec) resolvedNode {
+	n := resolvedNode{spec: ns}
+	annotations := make(map[string]string, len(ns.Annotations)+1)
+	for k, v := range ns.Annotations {
+		annotations[k] = v
+	}
+	if ns.Name != "" {
+		n.opts = append(n.opts, graph.WithName(ns.Name))
+	}
+	if ns.Description != "" {
+		n.opts = append(n.opts, graph.WithDescription(ns.Description))
+	}
+
+	switch ns.Type {
+	case "", NodeTypeFunction, NodeTypeRouter, NodeTypeJoin:
+		ref := ns.Function
+		if ref == "" {
+			ref = ns.ID
+		}
+		fn, ok := b.reg.Function(ref)
+		if !ok {
+			b.fail("node %q: unknown function %q", ns.ID, ref)
+		}
+		n.fn = fn
+		annotations[AnnotationKeyFunction] = ref
+		switch ns.Type {
+		case NodeTypeRouter:
+			n.opts = append(n.opts, graph.WithNodeType(graph.NodeTypeRouter))
+		case NodeTypeJoin:
+			n.opts = append(n.opts, graph.WithNodeType(graph.NodeTypeJoin))
+		}
+	case NodeTypeLLM:
+		m, ok := b.reg.Model(ns.Model)
+		if !ok {
+			b.fail("node %q: unknown model %q", ns.ID, ns.Model)
+		}
+		n.model = m
+		if ns.GenerationConfig != nil {
+			n.opts = append(n.opts, graph.WithGenerationConfig(*ns.GenerationConfig))
+		}
+	case NodeTypeAgent:
+		if ns.Agent != nil {
+			if ns.Agent.IsolatedMessages {
+				n.opts = append(n.opts, graph.WithSubgraphIsolatedMessages(true))
+			}
+			if ns.Agent.EventScope != "" {
+				n.opts = append(n.opts, graph.WithSubgraphEventScope(ns.Agent.EventScope))
+			}
+			if ns.Agent.InputFromLastResponse {
+				n.opts = append(n.opts, graph.WithSubgraphInputFromLastResponse())
+			}
+		}
+	}
+
+	if ns.Type == NodeTypeLLM || ns.Type == NodeTypeTools {
+		n.tools = make(map[string]tool.Tool, len(ns.Tools))
+		for _, name := range ns.Tools {
+			t, ok := b.reg.Tool(name)
+			if !ok {
+				b.fail("node %q: unknown tool %q", ns.ID, name)
+				continue
+			}
+			n.tools[name] = t
+		}
+		for _, name := range ns.ToolSets {
+			ts, ok := b.reg.ToolSet(name)
+			if !ok {
+				b.fail("node %q: unknown tool set %q", ns.ID, name)
+				continue
+			}
+			n.toolSets = append(n.toolSets, ts)
+		}
+		if len(n.toolSets) > 0 {
+			n.opts = append(n.opts, graph.WithToolSets(n.toolSets))
+		}
+		if ns.RefreshToolSetsOnRun {
+			n.opts = append(n.opts, graph.WithRefreshToolSetsOnRun(true))
+		}
+		if ns.ParallelTools {
+			n.opts = append(n.opts, graph.WithEnableParallelTools(true))
+		}
+	}
+
+	if ns.UserInputKey != "" {
+		n.opts = append(n.opts, graph.WithUserInputKey(ns.UserInputKey))
+	}
+	if ns.StreamOutput != "" {
+		n.opts = append(n.opts, graph.WithStreamOutput(ns.StreamOutput))
+	}
+	for i, rs := range ns.Retry {
+		policy, err := b.retryPolicy(rs)
+		if err != nil {
+			b.fail("node %q: retry[%d]: %w", ns.ID, i, err)
+			continue
+		}
+		n.opts = append(n.opts, graph.WithRetryPolicy(policy))
+	}
+	if ns.Cache != nil {
+		policy := graph.DefaultCachePolicy()
+		policy.TTL = time.Duration(ns.Cache.TTL)
+		n.opts = append(n.opts, graph.WithNodeCachePolicy(policy))
+		if len(ns.Cache.KeyFields) > 0 {
+			n.opts = append(n.opts, graph.WithCacheKeyFields(ns.Cache.KeyFields...))
+		}
+	}
+	if ns.InterruptBefore {
+		n.opts = append(n.opts, graph.WithInterruptBefore())
+	}
+	if ns.InterruptAfter {
+		n.opts = append(n.opts, graph.WithInterruptAfter())
+	}
+	if len(ns.Destinations) > 0 {
+		n.opts = append(n.opts, graph.WithDestinations(ns.Destinations))
+	}
+	if len(ns.Ends) > 0 {
+		n.opts = append(n.opts, graph.WithEndsMap(ns.Ends))
+	}
+	for _, ce := range b.spec.ConditionalEdges {
+		if ce.From != ns.ID {
+			continue
+		}
+		if ce.ToolsNode != "" {
+			annotations[AnnotationKeyToolsNode] = ce.ToolsNode
+			annotations[AnnotationKeyFallback] = ce.Fallback
+		} else {
+			annotations[AnnotationKeyCondition] = ce.Condition
+		}
+	}
+	if len(annotations) > 0 {
+		n.opts = append(n.opts, graph.WithNodeAnnotations(annotations))
+	}
+	return n
+}
+
+func (b *builder) retryPolicy(rs RetrySpec) (graph.RetryPolicy, error) {
+	policy := graph.RetryPolicy{
+		MaxAttempts:       rs.MaxAttempts,
+		InitialInterval:   time.Duration(rs.InitialInterval),
+		BackoffFactor:     rs.BackoffFactor,
+		MaxInterval:       time.Duration(rs.MaxInterval),
+		Jitter:            rs.Jitter,
+		MaxElapsedTime:    time.Duration(rs.MaxElapsedTime),
+		PerAttemptTimeout: time.Duration(rs.PerAttemptTimeout),
+	}
+	for _, name := range rs.RetryOn {
+		cond, ok := b.reg.RetryCondition(name)
+		if !ok {
+			return graph.RetryPolicy{}, fmt.Errorf("unknown retry condition %q", name)
+		}
+		policy.RetryOn = append(policy.RetryOn, cond)
+	}
+	return policy, nil
+}
+
+func (b *builder) resolveConditions() []func(*graph.StateGraph) {
+	var out []func(*graph.StateGraph)
+	for _, ce := range b.spec.ConditionalEdges {
+		ce := ce
+		if ce.ToolsNode != "" {
+			out = append(out, func(sg *graph.StateGraph) {
+				sg.AddToolsConditionalEdges(ce.From, ce.ToolsNode, ce.Fallback)
+			})
+			continue
+		}
+		cond, ok := b.reg.Condition(ce.Condition)
+		if !ok {
+			b.fail("conditional edge from %q: unknown condition %q", ce.From, ce.Condition)
+			continue
+		}
+		out = append(out, func(sg *graph.StateGraph) {
+			sg.AddConditionalEdges(ce.From, cond, ce.Routes)
+		})
+	}
+	return out
+}
+
+func (b *builder) resolveCache() graph.Cache {
+	if b.spec.Cache == nil {
+		return nil
+	}
+	if b.spec.Cache.Backend == "" {
+		return graph.NewInMemoryCache()
+	}
+	c, ok := b.reg.Cache(b.spec.Cache.Backend)
+	if !ok {
+		b.fail("unknown cache backend %q", b.spec.Cache.Backend)
+	}
+	return c
+}
+
+// Validate checks the structure of the spec without resolving references.
+// It reports every problem it finds.
+func (s *Spec) Validate() error {
+	if s == nil {
+		return errors.New("graphspec: spec is nil")
+	}
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if s.Version != "" && s.Version != SpecVersionV1 {
+		fail("unsupported spec version %q", s.Version)
+	}
+	if s.State != nil {
+		if s.State.Preset != "" && s.State.Preset != StatePresetMessages {
+			fail("unknown state preset %q", s.State.Preset)
+		}
+		seen := make(map[string]bool, len(s.State.Fields))
+		for _, f := range s.State.Fields {
+			if f.Name == "" {
+				fail("state field with empty name")
+				continue
+			}
+			if seen[f.Name] {
+				fail("duplicate state field %q", f.Name)
+			}
+			seen[f.Name] = true
+			if _, ok := fieldTypes[fieldTypeOrAny(f.Type)]; !ok {
+				fail("state field %q: unknown type %q", f.Name, f.Type)
+			}
+		}
+	}
+
+	nodes := make(map[string]*NodeSpec, len(s.Nodes))
+	for i := range s.Nodes {
+		n := &s.Nodes[i]
+		switch {
+		case n.ID == "":
+			fail("node[%d]: empty id", i)
+			continue
+		case n.ID == graph.Start || n.ID == graph.End:
+			fail("node id %q is reserved", n.ID)
+			continue
+		case nodes[n.ID] != nil:
+			fail("duplicate node id %q", n.ID)
+			continue
+		}
+		nodes[n.ID] = n
+		switch n.Type {
+		case "", NodeTypeFunction, NodeTypeRouter, NodeTypeJoin, NodeTypeAgent, NodeTypeTools:
+		case NodeTypeLLM:
+			if n.Model == "" {
+				fail("node %q: llm node requires a model", n.ID)
+			}
+		default:
+			fail("node %q: unknown type %q", n.ID, n.Type)
+		}
+	}
+	exists := func(id string, allowStart, allowEnd bool) bool {
+		if id == graph.Start {
+			return allowStart
+		}
+		if id == graph.End {
+			return allowEnd
+		}
+		return nodes[id] != nil
+	}
+	for _, n := range s.Nodes {
+		for _, to := range sortedKeys(n.Destinations) {
+			if !exists(to, false, true) {
+				fail("node %q: destination %q does not exist", n.ID, to)
+			}
+		}
+		for _, name := range sortedKeys(n.Ends) {
+			if to := n.Ends[name]; !exists(to, false, true) {
+				fail("node %q: end %q targets unknown node %q", n.ID, name, to)
+			}
+		}
+	}
+
+	if s.EntryPoint == "" {
+		fail("entry_point is required")
+	} else if !exists(s.EntryPoint, false, false) {
+		fail("entry_point %q does not exist", s.EntryPoint)
+	}
+	for _, id := range s.FinishPoints {
+		if !exists(id, false, false) {
+			fail("finish point %q does not exist", id)
+		}
+	}
+	for _, e := range s.Edges {
+		if !exists(e.From, true, false) {
+			fail("edge %q -> %q: unknown source", e.From, e.To)
+		}
+		if !exists(e.To, false, true) {
+			fail("edge %q -> %q: unknown target", e.From, e.To)
+		}
+	}
+	for _, j := range s.JoinEdges {
+		if len(j.From) == 0 {
+			fail("join edge to %q: no sources", j.To)
+		}
+		for _, from := range j.From {
+			if !exists(from, false, false) {
+				fail("join edge to %q: unknown source %q", j.To, from)
+			}
+		}
+		if !exists(j.To, false, true) {
+			fail("join edge: unknown target %q", j.To)
+		}
+	}
+	condFrom := make(map[string]bool, len(s.ConditionalEdges))
+	for _, ce := range s.ConditionalEdges {
+		if !exists(ce.From, false, false) {
+			fail("conditional edge: unknown source %q", ce.From)
+			continue
+		}
+		if condFrom[ce.From] {
+			fail("conditional edge: node %q has more than one conditional edge", ce.From)
+		}
+		condFrom[ce.From] = true
+		switch {
+		case ce.ToolsNode != "":
+			if ce.Condition != "" || len(ce.Routes) > 0 {
+				fail("conditional edge from %q: tools_node cannot be combined with condition or routes", ce.From)
+			}
+			if !exists(ce.ToolsNode, false, false) {
+				fail("conditional edge from %q: unknown tools node %q", ce.From, ce.ToolsNode)
+			}
+			if ce.Fallback == "" || !exists(ce.Fallback, false, true) {
+				fail("conditional edge from %q: unknown fallback %q", ce.From, ce.Fallback)
+			}
+		case ce.Condition == "":
+			fail("conditional edge from %q: condition or tools_node is required", ce.From)
+		default:
+			for _, key := range sortedKeys(ce.Routes) {
+				if to := ce.Routes[key]; !exists(to, false, true) {
+					fail("conditional edge from %q: route %q targets unknown node %q", ce.From, key, to)
+				}
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("graphspec: invalid spec: %w", errors.Join(errs...))
+}
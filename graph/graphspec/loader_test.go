@@ -0,0 +1,302 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graphspec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+)
+
+const testSpecYAML = `
+version: v1
+name: review
+graph_version: "2"
+state:
+  fields:
+    - name: score
+      type: int
+      default: 0
+    - name: tags
+      type: string_list
+      reducer: string_slice
+cache:
+  ttl: 1m
+entry_point: prepare
+finish_points: [approve, reject]
+nodes:
+  - id: prepare
+    description: normalizes input
+    retry:
+      - max_attempts: 3
+        initial_interval: 10ms
+        backoff_factor: 2
+        retry_on: [transient]
+  - id: left
+    function: branch
+  - id: right
+    function: branch
+    cache:
+      ttl: 30s
+      key_fields: [score]
+  - id: decide
+    type: router
+    interrupt_before: false
+    ends:
+      ok: approve
+  - id: approve
+  - id: reject
+edges:
+  - from: prepare
+    to: left
+  - from: prepare
+    to: right
+join_edges:
+  - from: [left, right]
+    to: decide
+conditional_edges:
+  - from: decide
+    condition: by_score
+    routes:
+      high: approve
+      low: reject
+`
+
+type visitRecorder struct {
+	mu     sync.Mutex
+	visits []string
+}
+
+func (r *visitRecorder) fn(name string, update graph.State) graph.NodeFunc {
+	return func(ctx context.Context, state graph.State) (any, error) {
+		r.mu.Lock()
+		r.visits = append(r.visits, name)
+		r.mu.Unlock()
+		return update, nil
+	}
+}
+
+func newTestRegistry(t *testing.T, rec *visitRecorder) *Registry {
+	t.Helper()
+	reg := NewRegistry()
+	require.NoError(t, reg.RegisterFunction("prepare", rec.fn("prepare", graph.State{"score": 7})))
+	require.NoError(t, reg.RegisterFunction("branch", rec.fn("branch", nil)))
+	require.NoError(t, reg.RegisterFunction("decide", rec.fn("decide", nil)))
+	require.NoError(t, reg.RegisterFunction("approve", rec.fn("approve", nil)))
+	require.NoError(t, reg.RegisterFunction("reject", rec.fn("reject", nil)))
+	require.NoError(t, reg.RegisterCondition("by_score", graph.ConditionalFunc(
+		func(ctx context.Context, state graph.State) (string, error) {
+			if score, _ := state["score"].(int); score > 5 {
+				return "high", nil
+			}
+			return "low", nil
+		},
+	)))
+	return reg
+}
+
+func runGraph(t *testing.T, g *graph.Graph) {
+	t.Helper()
+	exec, err := graph.NewExecutor(g)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := exec.Execute(ctx, graph.State{}, agent.NewInvocation())
+	require.NoError(t, err)
+	for range ch {
+	}
+}
+
+func TestParse_YAMLAndJSON(t *testing.T) {
+	spec, err := Parse([]byte(testSpecYAML))
+	require.NoError(t, err)
+	assert.Equal(t, "prepare", spec.EntryPoint)
+	require.Len(t, spec.Nodes, 6)
+	assert.Equal(t, Duration(10*time.Millisecond), spec.Nodes[0].Retry[0].InitialInterval)
+	assert.Equal(t, Duration(time.Minute), spec.Cache.TTL)
+
+	data, err := EncodeJSON(spec)
+	require.NoError(t, err)
+	fromJSON, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, spec, fromJSON)
+}
+
+func TestParse_RejectsUnknownFields(t *testing.T) {
+	_, err := Parse([]byte("entry_point: a\nnodez: []\n"))
+	require.Error(t, err)
+	_, err = Parse(nil)
+	require.Error(t, err)
+}
+
+func TestLoad_ExecutesGraph(t *testing.T) {
+	rec := &visitRecorder{}
+	spec, err := Parse([]byte(testSpecYAML))
+	require.NoError(t, err)
+	g, err := Load(spec, newTestRegistry(t, rec))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2", g.GraphVersion())
+	require.NotNil(t, g.Cache())
+	decide, ok := g.Node("decide")
+	require.True(t, ok)
+	assert.Equal(t, graph.NodeTypeRouter, decide.Type)
+	right, _ := g.Node("right")
+	require.NotNil(t, right.CachePolicy())
+	assert.Equal(t, 30*time.Second, right.CachePolicy().TTL)
+	assert.Equal(t, []string{"score"}, right.CacheKeyFields())
+	prepare, _ := g.Node("prepare")
+	require.Len(t, prepare.RetryPolicies(), 1)
+	assert.Equal(t, 3, prepare.RetryPolicies()[0].MaxAttempts)
+	assert.Equal(t, []graph.JoinEdge{{From: []string{"left", "right"}, To: "decide"}}, g.JoinEdges())
+
+	field := g.Schema().Fields["score"]
+	require.NotNil(t, field.Default)
+	assert.Equal(t, 0, field.Default())
+
+	runGraph(t, g)
+	assert.Equal(t, "prepare", rec.visits[0])
+	assert.Contains(t, rec.visits, "decide")
+	assert.Contains(t, rec.visits, "approve")
+	assert.NotContains(t, rec.visits, "reject")
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testSpecYAML), 0o600))
+	_, err := LoadFile(path, newTestRegistry(t, &visitRecorder{}))
+	require.NoError(t, err)
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	require.Error(t, err)
+}
+
+func TestBuild_UnresolvedReferences(t *testing.T) {
+	spec := &Spec{
+		EntryPoint: "a",
+		State: &StateSpec{Fields: []StateFieldSpec{
+			{Name: "x", Reducer: "nope"},
+			{Name: "y", Type: FieldTypeInt, Default: "not-a-number"},
+		}},
+		Nodes: []NodeSpec{
+			{ID: "a"},
+			{ID: "b", Type: NodeTypeLLM, Model: "missing", Tools: []string{"t"}},
+			{ID: "c", Retry: []RetrySpec{{RetryOn: []string{"unknown"}}}},
+		},
+		ConditionalEdges: []ConditionalEdgeSpec{{From: "a", Condition: "cond"}},
+		Cache:            &CacheSpec{Backend: "redis"},
+	}
+	_, err := Build(spec, nil)
+	require.Error(t, err)
+	for _, want := range []string{
+		`unknown reducer "nope"`,
+		`state field "y"`,
+		`unknown function "a"`,
+		`unknown model "missing"`,
+		`unknown tool "t"`,
+		`unknown retry condition "unknown"`,
+		`unknown condition "cond"`,
+		`unknown cache backend "redis"`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	spec := &Spec{
+		Version:    "v9",
+		EntryPoint: "missing",
+		State: &StateSpec{
+			Preset: "chat",
+			Fields: []StateFieldSpec{{Name: "a", Type: "decimal"}, {Name: "a"}, {}},
+		},
+		Nodes: []NodeSpec{
+			{ID: "a", Destinations: map[string]string{"zz": ""}, Ends: map[string]string{"x": "yy"}},
+			{ID: "a"},
+			{ID: graph.End},
+			{},
+			{ID: "b", Type: "widget"},
+			{ID: "c", Type: NodeTypeLLM},
+		},
+		FinishPoints: []string{"nope"},
+		Edges:        []EdgeSpec{{From: graph.End, To: "a"}, {From: "a", To: graph.Start}},
+		JoinEdges:    []JoinEdgeSpec{{To: "q"}},
+		ConditionalEdges: []ConditionalEdgeSpec{
+			{From: "a"},
+			{From: "a", Condition: "c", Routes: map[string]string{"k": "missing"}},
+			{From: "b", ToolsNode: "x", Condition: "y"},
+			{From: "ghost", Condition: "c"},
+		},
+	}
+	err := spec.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`unsupported spec version "v9"`,
+		`unknown state preset "chat"`,
+		`unknown type "decimal"`,
+		`duplicate state field "a"`,
+		`state field with empty name`,
+		`duplicate node id "a"`,
+		`node id "__end__" is reserved`,
+		`node[3]: empty id`,
+		`unknown type "widget"`,
+		`llm node requires a model`,
+		`destination "zz" does not exist`,
+		`end "x" targets unknown node "yy"`,
+		`entry_point "missing" does not exist`,
+		`finish point "nope" does not exist`,
+		`unknown source`,
+		`unknown target`,
+		`join edge to "q": no sources`,
+		`condition or tools_node is required`,
+		`more than one conditional edge`,
+		`route "k" targets unknown node "missing"`,
+		`tools_node cannot be combined`,
+		`unknown source "ghost"`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+	var nilSpec *Spec
+	require.Error(t, nilSpec.Validate())
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	fn := func(ctx context.Context, s graph.State) (any, error) { return nil, nil }
+	require.NoError(t, reg.RegisterFunction("f", fn))
+	require.Error(t, reg.RegisterFunction("f", fn))
+	require.Error(t, reg.RegisterFunction("", fn))
+	require.Error(t, reg.RegisterFunction("g", nil))
+	require.Error(t, reg.RegisterCondition("c", "not a func"))
+	require.Error(t, reg.RegisterCondition("c", graph.ConditionalFunc(nil)))
+	require.NoError(t, reg.RegisterCondition("m", graph.MultiConditionalFunc(
+		func(ctx context.Context, s graph.State) ([]string, error) { return nil, nil },
+	)))
+	require.Error(t, reg.RegisterReducer(ReducerMerge, graph.MergeReducer))
+	require.Error(t, reg.RegisterRetryCondition(RetryConditionTransient, graph.DefaultTransientCondition()))
+	require.Error(t, reg.RegisterModel("m", nil))
+	require.Error(t, reg.RegisterTool(nil))
+	require.Error(t, reg.RegisterToolSet("ts", nil))
+	require.Error(t, reg.RegisterCache("c", nil))
+
+	reducer, ok := reg.Reducer("")
+	require.True(t, ok)
+	require.NotNil(t, reducer)
+	_, ok = reg.RetryCondition(RetryConditionTransient)
+	require.True(t, ok)
+}
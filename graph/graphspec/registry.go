@@ -0,0 +1,310 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graphspec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Built-in reducer names. They are always resolvable and cannot be
+// overridden.
+const (
+	ReducerDefault     = "default"
+	ReducerCover       = "cover"
+	ReducerAppend      = "append"
+	ReducerStringSlice = "string_slice"
+	ReducerMerge       = "merge"
+	ReducerMessage     = "message"
+)
+
+// RetryConditionTransient resolves to graph.DefaultTransientCondition.
+const RetryConditionTransient = "transient"
+
+var builtinReducers = map[string]graph.StateReducer{
+	ReducerDefault:     graph.DefaultReducer,
+	ReducerCover:       graph.CoverReducer,
+	ReducerAppend:      graph.AppendReducer,
+	ReducerStringSlice: graph.StringSliceReducer,
+	ReducerMerge:       graph.MergeReducer,
+	ReducerMessage:     graph.MessageReducer,
+}
+
+// Registry resolves names used in a Spec to Go values. A Registry is safe
+// for concurrent use and can be shared by many Load calls.
+type Registry struct {
+	mu              sync.RWMutex
+	functions       map[string]graph.NodeFunc
+	conditions      map[string]any
+	models          map[string]model.Model
+	tools           map[string]tool.Tool
+	toolSets        map[string]tool.ToolSet
+	reducers        map[string]graph.StateReducer
+	retryConditions map[string]graph.RetryCondition
+	caches          map[string]graph.Cache
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		functions:       make(map[string]graph.NodeFunc),
+		conditions:      make(map[string]any),
+		models:          make(map[string]model.Model),
+		tools:           make(map[string]tool.Tool),
+		toolSets:        make(map[string]tool.ToolSet),
+		reducers:        make(map[string]graph.StateReducer),
+		retryConditions: make(map[string]graph.RetryCondition),
+		caches:          make(map[string]graph.Cache),
+	}
+}
+
+// RegisterFunction registers a node function.
+func (r *Registry) RegisterFunction(name string, fn graph.NodeFunc) error {
+	if fn == nil {
+		return fmt.Errorf("graphspec: function %q is nil", name)
+	}
+	return register(r, r.functions, "function", name, fn)
+}
+
+// RegisterCondition registers a condition function. fn must be a
+// graph.ConditionalFunc, graph.MultiConditionalFunc or
+// graph.UniversalCondFunc.
+func (r *Registry) RegisterCondition(name string, fn any) error {
+	switch fn.(type) {
+	case graph.ConditionalFunc, graph.MultiConditionalFunc, graph.UniversalCondFunc:
+	default:
+		return fmt.Errorf("graphspec: condition %q has unsupported type %T", name, fn)
+	}
+	if reflect.ValueOf(fn).IsNil() {
+		return fmt.Errorf("graphspec: condition %q is nil", name)
+	}
+	return register(r, r.conditions, "condition", name, fn)
+}
+
+// RegisterModel registers a model for llm nodes.
+func (r *Registry) RegisterModel(name string, m model.Model) error {
+	if m == nil {
+		return fmt.Errorf("graphspec: model %q is nil", name)
+	}
+	return register(r, r.models, "model", name, m)
+}
+
+// RegisterTool registers a tool under its declaration name.
+func (r *Registry) RegisterTool(t tool.Tool) error {
+	if t == nil || t.Declaration() == nil {
+		return errors.New("graphspec: tool or its declaration is nil")
+	}
+	return register(r, r.tools, "tool", t.Declaration().Name, t)
+}
+
+// RegisterToolSet registers a tool set.
+func (r *Registry) RegisterToolSet(name string, ts tool.ToolSet) error {
+	if ts == nil {
+		return fmt.Errorf("graphspec: tool set %q is nil", name)
+	}
+	return register(r, r.toolSets, "tool set", name, ts)
+}
+
+// RegisterReducer registers a state reducer. Built-in reducer names are
+// reserved.
+func (r *Registry) RegisterReducer(name string, reducer graph.StateReducer) error {
+	if reducer == nil {
+		return fmt.Errorf("graphspec: reducer %q is nil", name)
+	}
+	if _, ok := builtinReducers[name]; ok {
+		return fmt.Errorf("graphspec: reducer %q is built in", name)
+	}
+	return register(r, r.reducers, "reducer", name, reducer)
+}
+
+// RegisterRetryCondition registers a retry condition. The name
+// RetryConditionTransient is reserved.
+func (r *Registry) RegisterRetryCondition(name string, cond graph.RetryCondition) error {
+	if cond == nil {
+		return fmt.Errorf("graphspec: retry condition %q is nil", name)
+	}
+	if name == RetryConditionTransient {
+		return fmt.Errorf("graphspec: retry condition %q is built in", name)
+	}
+	return register(r, r.retryConditions, "retry condition", name, cond)
+}
+
+// RegisterCache registers a graph.Cache backend.
+func (r *Registry) RegisterCache(name string, c graph.Cache) error {
+	if c == nil {
+		return fmt.Errorf("graphspec: cache %q is nil", name)
+	}
+	return register(r, r.caches, "cache", name, c)
+}
+
+func register[T any](r *Registry, m map[string]T, kind, name string, v T) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("graphspec: %s name is empty", kind)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := m[name]; ok {
+		return fmt.Errorf("graphspec: %s already registered: %s", kind, name)
+	}
+	m[name] = v
+	return nil
+}
+
+func lookup[T any](r *Registry, m map[string]T, name string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := m[name]
+	return v, ok
+}
+
+// Function returns a registered node function.
+func (r *Registry) Function(name string) (graph.NodeFunc, bool) {
+	return lookup(r, r.functions, name)
+}
+
+// Condition returns a registered condition function.
+func (r *Registry) Condition(name string) (any, bool) {
+	return lookup(r, r.conditions, name)
+}
+
+// Model returns a registered model.
+func (r *Registry) Model(name string) (model.Model, bool) {
+	return lookup(r, r.models, name)
+}
+
+// Tool returns a registered tool.
+func (r *Registry) Tool(name string) (tool.Tool, bool) {
+	return lookup(r, r.tools, name)
+}
+
+// ToolSet returns a registered tool set.
+func (r *Registry) ToolSet(name string) (tool.ToolSet, bool) {
+	return lookup(r, r.toolSets, name)
+}
+
+// Reducer returns a built-in or registered reducer.
+func (r *Registry) Reducer(name string) (graph.StateReducer, bool) {
+	if name == "" {
+		name = ReducerDefault
+	}
+	if reducer, ok := builtinReducers[name]; ok {
+		return reducer, true
+	}
+	return lookup(r, r.reducers, name)
+}
+
+// RetryCondition returns a built-in or registered retry condition.
+func (r *Registry) RetryCondition(name string) (graph.RetryCondition, bool) {
+	if name == RetryConditionTransient {
+		return graph.DefaultTransientCondition(), true
+	}
+	return lookup(r, r.retryConditions, name)
+}
+
+// Cache returns a registered cache backend.
+func (r *Registry) Cache(name string) (graph.Cache, bool) {
+	return lookup(r, r.caches, name)
+}
+
+// reverse lookups used by Export.
+
+func (r *Registry) modelName(m model.Model) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return findByIdentity(r.models, m)
+}
+
+func (r *Registry) toolSetName(ts tool.ToolSet) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return findByIdentity(r.toolSets, ts)
+}
+
+func (r *Registry) cacheName(c graph.Cache) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return findByIdentity(r.caches, c)
+}
+
+func (r *Registry) reducerName(reducer graph.StateReducer) string {
+	if reducer == nil {
+		return ""
+	}
+	ptr := reflect.ValueOf(reducer).Pointer()
+	for _, name := range sortedKeys(builtinReducers) {
+		if reflect.ValueOf(builtinReducers[name]).Pointer() == ptr {
+			return name
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, name := range sortedKeys(r.reducers) {
+		if reflect.ValueOf(r.reducers[name]).Pointer() == ptr {
+			return name
+		}
+	}
+	return ""
+}
+
+func (r *Registry) retryConditionName(cond graph.RetryCondition) string {
+	if sameValue(cond, graph.DefaultTransientCondition()) {
+		return RetryConditionTransient
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return findByIdentity(r.retryConditions, cond)
+}
+
+// findByIdentity returns the first name, in sorted order, whose value is
+// identical to v. The caller must hold r.mu.
+func findByIdentity[T any](m map[string]T, v T) string {
+	for _, name := range sortedKeys(m) {
+		if sameValue(m[name], v) {
+			return name
+		}
+	}
+	return ""
+}
+
+// sameValue compares two values by identity. Functions are compared by code
+// pointer and uncomparable values are never equal.
+func sameValue(a, b any) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	if va.Kind() == reflect.Func {
+		return va.Pointer() == vb.Pointer()
+	}
+	if !va.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,278 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package graphspec provides a declarative YAML/JSON format for graph
+// topologies. A Spec describes nodes, edges, join edges, conditional routes,
+// per-node policies and the state schema. Load validates a Spec and compiles
+// it into a *graph.Graph by resolving named references (node functions,
+// conditions, models, tools, reducers) against a Registry. Export performs
+// the reverse and serializes an existing graph back into a Spec.
+package graphspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// SpecVersionV1 is the current spec format version.
+const SpecVersionV1 = "v1"
+
+// Node type names used in NodeSpec.Type.
+const (
+	// NodeTypeFunction is a node backed by a registered graph.NodeFunc.
+	NodeTypeFunction = "function"
+	// NodeTypeLLM is a node added via graph.StateGraph.AddLLMNode.
+	NodeTypeLLM = "llm"
+	// NodeTypeTools is a node added via graph.StateGraph.AddToolsNode.
+	NodeTypeTools = "tools"
+	// NodeTypeAgent is a node added via graph.StateGraph.AddAgentNode.
+	NodeTypeAgent = "agent"
+	// NodeTypeRouter is a function node styled as a router.
+	NodeTypeRouter = "router"
+	// NodeTypeJoin is a function node styled as a join.
+	NodeTypeJoin = "join"
+)
+
+// State field type names used in StateFieldSpec.Type.
+const (
+	FieldTypeAny        = "any"
+	FieldTypeString     = "string"
+	FieldTypeInt        = "int"
+	FieldTypeFloat      = "float"
+	FieldTypeBool       = "bool"
+	FieldTypeMap        = "map"
+	FieldTypeList       = "list"
+	FieldTypeStringList = "string_list"
+	FieldTypeMessages   = "messages"
+)
+
+// StatePresetMessages starts the schema from graph.MessagesStateSchema.
+const StatePresetMessages = "messages"
+
+// Annotation keys written onto graph nodes by Load so that Export can recover
+// references that cannot be derived from compiled closures.
+const (
+	// AnnotationKeyFunction records the registry name of a node function.
+	AnnotationKeyFunction = "graphspec.function"
+	// AnnotationKeyCondition records the registry name of the condition of the
+	// conditional edge leaving the node.
+	AnnotationKeyCondition = "graphspec.condition"
+	// AnnotationKeyToolsNode records the tools node of a tools condition.
+	AnnotationKeyToolsNode = "graphspec.tools_node"
+	// AnnotationKeyFallback records the fallback node of a tools condition.
+	AnnotationKeyFallback = "graphspec.fallback"
+)
+
+// Spec is the declarative description of a graph.
+type Spec struct {
+	// Version is the spec format version. Empty means SpecVersionV1.
+	Version string `json:"version,omitempty"`
+	// Name is an optional human-readable graph name.
+	Name string `json:"name,omitempty"`
+	// Description is an optional free-form description.
+	Description string `json:"description,omitempty"`
+	// GraphVersion scopes node cache namespaces (graph.StateGraph.WithGraphVersion).
+	GraphVersion string `json:"graph_version,omitempty"`
+	// State describes the state schema. Nil means an empty schema.
+	State *StateSpec `json:"state,omitempty"`
+	// Cache configures graph-level node result caching.
+	Cache *CacheSpec `json:"cache,omitempty"`
+	// EntryPoint is the first node to run.
+	EntryPoint string `json:"entry_point"`
+	// FinishPoints are nodes that route to graph.End.
+	FinishPoints []string `json:"finish_points,omitempty"`
+	// Nodes lists the graph nodes.
+	Nodes []NodeSpec `json:"nodes"`
+	// Edges lists static edges.
+	Edges []EdgeSpec `json:"edges,omitempty"`
+	// JoinEdges lists fan-in edges that wait for all sources.
+	JoinEdges []JoinEdgeSpec `json:"join_edges,omitempty"`
+	// ConditionalEdges lists conditional routes.
+	ConditionalEdges []ConditionalEdgeSpec `json:"conditional_edges,omitempty"`
+}
+
+// StateSpec describes the graph state schema.
+type StateSpec struct {
+	// Preset optionally seeds the schema. Supported: StatePresetMessages.
+	Preset string `json:"preset,omitempty"`
+	// Fields adds or overrides schema fields.
+	Fields []StateFieldSpec `json:"fields,omitempty"`
+}
+
+// StateFieldSpec describes one state schema field.
+type StateFieldSpec struct {
+	Name string `json:"name"`
+	// Type is one of the FieldType* names. Empty means FieldTypeAny.
+	Type string `json:"type,omitempty"`
+	// Reducer names a built-in or registered reducer. Empty means "default".
+	Reducer string `json:"reducer,omitempty"`
+	// Default is the initial value, converted to Type.
+	Default any `json:"default,omitempty"`
+	// Required marks the field as required during state validation.
+	Required bool `json:"required,omitempty"`
+}
+
+// CacheSpec configures graph-level caching.
+type CacheSpec struct {
+	// Backend names a registered graph.Cache. Empty means a new
+	// graph.InMemoryCache.
+	Backend string `json:"backend,omitempty"`
+	// TTL is the default entry lifetime. Zero means no expiration.
+	TTL Duration `json:"ttl,omitempty"`
+	// DefaultPolicy applies graph.DefaultCachePolicy to every node that has
+	// no per-node policy.
+	DefaultPolicy bool `json:"default_policy,omitempty"`
+}
+
+// NodeSpec describes one node.
+type NodeSpec struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Function names the registered node function for function, router and
+	// join nodes. Empty means the node ID.
+	Function string `json:"function,omitempty"`
+
+	// Model names the registered model for llm nodes.
+	Model string `json:"model,omitempty"`
+	// Instruction is the static instruction for llm nodes.
+	Instruction string `json:"instruction,omitempty"`
+	// GenerationConfig overrides generation parameters for llm nodes.
+	GenerationConfig *model.GenerationConfig `json:"generation_config,omitempty"`
+
+	// Tools names registered tools for llm and tools nodes.
+	Tools []string `json:"tools,omitempty"`
+	// ToolSets names registered tool sets for llm and tools nodes.
+	ToolSets []string `json:"tool_sets,omitempty"`
+	// RefreshToolSetsOnRun re-resolves tool sets on every node run.
+	RefreshToolSetsOnRun bool `json:"refresh_tool_sets_on_run,omitempty"`
+	// ParallelTools runs tool calls concurrently in tools nodes.
+	ParallelTools bool `json:"parallel_tools,omitempty"`
+
+	// Agent configures agent nodes. The node ID is the sub-agent name.
+	Agent *AgentSpec `json:"agent,omitempty"`
+
+	UserInputKey string `json:"user_input_key,omitempty"`
+	StreamOutput string `json:"stream_output,omitempty"`
+
+	Retry           []RetrySpec    `json:"retry,omitempty"`
+	Cache           *NodeCacheSpec `json:"cache,omitempty"`
+	InterruptBefore bool           `json:"interrupt_before,omitempty"`
+	InterruptAfter  bool           `json:"interrupt_after,omitempty"`
+
+	// Destinations declares dynamic routing targets for validation and
+	// visualization.
+	Destinations map[string]string `json:"destinations,omitempty"`
+	// Ends declares per-node symbolic branch names.
+	Ends map[string]string `json:"ends,omitempty"`
+	// Annotations are attached to the node via graph.WithNodeAnnotations.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AgentSpec configures an agent node.
+type AgentSpec struct {
+	IsolatedMessages      bool   `json:"isolated_messages,omitempty"`
+	EventScope            string `json:"event_scope,omitempty"`
+	InputFromLastResponse bool   `json:"input_from_last_response,omitempty"`
+}
+
+// RetrySpec mirrors graph.RetryPolicy.
+type RetrySpec struct {
+	MaxAttempts       int      `json:"max_attempts,omitempty"`
+	InitialInterval   Duration `json:"initial_interval,omitempty"`
+	BackoffFactor     float64  `json:"backoff_factor,omitempty"`
+	MaxInterval       Duration `json:"max_interval,omitempty"`
+	Jitter            bool     `json:"jitter,omitempty"`
+	MaxElapsedTime    Duration `json:"max_elapsed_time,omitempty"`
+	PerAttemptTimeout Duration `json:"per_attempt_timeout,omitempty"`
+	// RetryOn names registered retry conditions. The built-in
+	// RetryConditionTransient is always available.
+	RetryOn []string `json:"retry_on,omitempty"`
+}
+
+// NodeCacheSpec enables result caching for a node using
+// graph.DefaultCachePolicy.
+type NodeCacheSpec struct {
+	TTL Duration `json:"ttl,omitempty"`
+	// KeyFields restricts the cache key to a subset of input fields.
+	KeyFields []string `json:"key_fields,omitempty"`
+}
+
+// EdgeSpec is a static edge. Use graph.Start and graph.End for the virtual
+// start and end nodes.
+type EdgeSpec struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// JoinEdgeSpec waits for all From nodes before triggering To.
+type JoinEdgeSpec struct {
+	From []string `json:"from"`
+	To   string   `json:"to"`
+}
+
+// ConditionalEdgeSpec is a conditional route leaving From.
+//
+// Either Condition (with Routes) or ToolsNode (with Fallback) must be set.
+// The latter is the declarative form of AddToolsConditionalEdges.
+type ConditionalEdgeSpec struct {
+	From string `json:"from"`
+	// Condition names a registered condition function.
+	Condition string `json:"condition,omitempty"`
+	// Routes maps condition results to target nodes. It may be empty when
+	// the source node declares Ends.
+	Routes map[string]string `json:"routes,omitempty"`
+	// ToolsNode routes to this node when the last message has tool calls.
+	ToolsNode string `json:"tools_node,omitempty"`
+	// Fallback is used together with ToolsNode.
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// Duration is a time.Duration that encodes as a Go duration string such as
+// "1.5s". It also decodes plain numbers as seconds.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch val := v.(type) {
+	case nil:
+		*d = 0
+	case float64:
+		*d = Duration(val * float64(time.Second))
+	case string:
+		val = strings.TrimSpace(val)
+		if val == "" {
+			*d = 0
+			return nil
+		}
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", val, err)
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+	return nil
+}
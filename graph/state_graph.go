@@ -220,6 +220,7 @@ func WithCacheKeyFields(fields ...string) Option {
 	// copy fields to avoid external mutation
 	fcopy := append([]string(nil), fields...)
 	return func(node *Node) {
+		node.cacheKeyFields = append([]string(nil), fcopy...)
 		node.cacheKeySelector = func(m map[string]any) any {
 			if m == nil {
 				return nil
@@ -240,6 +241,7 @@ func WithCacheKeyFields(fields ...string) Option {
 // CachePolicy.KeyFunc.
 func WithCacheKeySelector(selector func(map[string]any) any) Option {
 	return func(node *Node) {
+		node.cacheKeyFields = nil
 		node.cacheKeySelector = selector
 	}
 }
@@ -420,6 +422,23 @@ func WithAgentNodeEventCallback(callback AgentEventCallback) Option {
 	}
 }
 
+// WithNodeAnnotations attaches free-form string annotations to the node. The
+// executor ignores them; tooling such as exporters and UIs can read them back
+// via Node.Annotations. Repeated calls merge keys.
+func WithNodeAnnotations(annotations map[string]string) Option {
+	return func(node *Node) {
+		if len(annotations) == 0 {
+			return
+		}
+		if node.annotations == nil {
+			node.annotations = make(map[string]string, len(annotations))
+		}
+		for k, v := range annotations {
+			node.annotations[k] = v
+		}
+	}
+}
+
 // Subgraph I/O mapping and scope utilities
 
 // SubgraphResult captures a subgraph's outputs exposed to the parent mapper.
@@ -15,8 +15,9 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
-	"sort"
 	"strings"
+
+	atrace "trpc.group/trpc-go/trpc-agent-go/agent/trace"
 )
 
 // Public constants for common string literals to avoid magic strings.
@@ -59,9 +60,11 @@ const (
 
 	colorConditionalEdge = "#999999"
 	colorDestinationEdge = "#aaaaaa"
+
+	colorTrace = "#d32f2f"
 )
 
-// VizOptions configures DOT export and rendering.
+// VizOptions configures DOT, Mermaid and JSON topology export.
 // Use helpers like WithRankDir to construct options.
 type VizOptions struct {
 	// RankDir sets DOT graph direction: "LR" (left-to-right) or "TB" (top-to-bottom).
@@ -73,6 +76,14 @@ type VizOptions struct {
 	IncludeStartEnd bool
 	// GraphLabel optionally labels the whole graph (shows in DOT as label=...).
 	GraphLabel string
+	// Subgraphs nests graphs under nodes, keyed by node path. A node path is
+	// the node ID for top-level nodes and "parent/child" for nodes inside a
+	// nested subgraph. Set via WithSubgraph.
+	Subgraphs map[string]*Graph
+	// ExecutionTrace overlays the steps of a recorded run.
+	ExecutionTrace *atrace.Trace
+	// ExecutedPath overlays an ordered list of visited node paths.
+	ExecutedPath []string
 }
 
 // VizOption mutates VizOptions.
@@ -102,6 +113,44 @@ func WithGraphLabel(label string) VizOption {
 	return func(o *VizOptions) { o.GraphLabel = label }
 }
 
+// WithSubgraph renders sub nested under the node at nodePath, typically the
+// graph behind an agent node. Use "parent/child" paths for deeper nesting.
+func WithSubgraph(nodePath string, sub *Graph) VizOption {
+	return func(o *VizOptions) {
+		if nodePath == "" || sub == nil {
+			return
+		}
+		if o.Subgraphs == nil {
+			o.Subgraphs = make(map[string]*Graph)
+		}
+		o.Subgraphs[nodePath] = sub
+	}
+}
+
+// WithExecutionTrace highlights the nodes and edges taken by a recorded run.
+// Steps are matched to nodes by their trace node IDs and descend into graphs
+// registered with WithSubgraph.
+func WithExecutionTrace(tr *atrace.Trace) VizOption {
+	return func(o *VizOptions) { o.ExecutionTrace = tr }
+}
+
+// WithExecutedPath highlights an ordered list of visited node paths, for
+// example node IDs collected from node start events.
+func WithExecutedPath(nodePaths ...string) VizOption {
+	return func(o *VizOptions) {
+		o.ExecutedPath = append([]string(nil), nodePaths...)
+	}
+}
+
+// newVizOptions applies opts on top of the defaults.
+func newVizOptions(opts []VizOption) *VizOptions {
+	o := defaultVizOptions()
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
 // defaultVizOptions returns sensible defaults for visualization.
 func defaultVizOptions() *VizOptions {
 	return &VizOptions{
@@ -117,33 +166,16 @@ func defaultVizOptions() *VizOptions {
 //   - Runtime edges (solid)
 //   - Conditional edges (dashed, labeled by branch)
 //   - Declared destinations from WithDestinations (dotted, gray)
+//   - Subgraphs attached via WithSubgraph (clusters)
+//   - The overlaid execution trace (bold red nodes and edges)
 func (g *Graph) DOT(opts ...VizOption) string {
-	o := defaultVizOptions()
-	for _, fn := range opts {
-		fn(o)
-	}
-
-	// Snapshot data under read lock.
-	g.mu.RLock()
-	nodeIDs := make([]string, 0, len(g.nodes))
-	for id := range g.nodes {
-		nodeIDs = append(nodeIDs, id)
-	}
-	sort.Strings(nodeIDs)
-	edgesCopy := copyEdges(g.edges)
-	condCopy := copyConditionalEdges(g.conditionalEdges)
-	entry := g.entryPoint
-	g.mu.RUnlock()
-
+	t := g.Topology(opts...)
 	var b strings.Builder
 	b.WriteString("digraph G {\n")
-	writeGraphHeader(&b, o)
-	writeVirtualStartEnd(&b, o)
-	writeNodes(&b, g, nodeIDs)
-	writeRuntimeEdges(&b, edgesCopy, o)
-	writeConditionalEdges(&b, g, condCopy, o)
-	writeDestinations(&b, g, nodeIDs, o)
-	highlightEntry(&b, entry, o)
+	writeGraphHeader(&b, t)
+	writeDOTNodes(&b, t, "", "  ")
+	writeDOTEdges(&b, t, "")
+	highlightEntry(&b, t, "")
 	b.WriteString("}\n")
 	return b.String()
 }
@@ -169,138 +201,103 @@ func copyConditionalEdges(src map[string]*ConditionalEdge) map[string]*Condition
 }
 
 // writeGraphHeader writes DOT-level attributes.
-func writeGraphHeader(b *strings.Builder, o *VizOptions) {
-	b.WriteString(fmt.Sprintf("  rankdir=%s;\n", escapeIdentifier(o.RankDir)))
+func writeGraphHeader(b *strings.Builder, t *Topology) {
+	b.WriteString(fmt.Sprintf("  rankdir=%s;\n", escapeIdentifier(t.Direction)))
 	b.WriteString("  node [fontname=\"Helvetica\"];\n")
 	b.WriteString("  edge [fontname=\"Helvetica\"];\n")
-	if o.GraphLabel != "" {
-		b.WriteString(fmt.Sprintf("  label=\"%s\";\n  labelloc=t;\n", escapeLabel(o.GraphLabel)))
+	if t.Label != "" {
+		b.WriteString(fmt.Sprintf("  label=\"%s\";\n  labelloc=t;\n", escapeLabel(t.Label)))
 	}
 }
 
-// writeVirtualStartEnd optionally emits Start/End visuals.
-func writeVirtualStartEnd(b *strings.Builder, o *VizOptions) {
-	if !o.IncludeStartEnd {
-		return
+// writeDOTNodes emits node declarations with simple styling per NodeType.
+// Nodes with a subgraph are followed by a cluster holding the nested nodes.
+func writeDOTNodes(b *strings.Builder, t *Topology, scope, indent string) {
+	for _, n := range t.Nodes {
+		border, extra := n.Style.Border, ""
+		if n.Visits > 0 {
+			border, extra = colorTrace, ", penwidth=3"
+		}
+		fmt.Fprintf(b, "%s\"%s\" [label=\"%s\", shape=%s, style=filled, fillcolor=\"%s\", color=\"%s\"%s];\n",
+			indent, escapeIdentifier(joinNodePath(scope, n.ID)), escapeLabel(n.Label),
+			n.Style.Shape, n.Style.Fill, border, extra)
 	}
-	b.WriteString(fmt.Sprintf("  \"%s\" [label=\"start\", shape=%s, style=filled, fillcolor=\"%s\", color=\"%s\"];\n",
-		escapeIdentifier(Start), shapeOval, colorStartFill, colorStartBorder))
-	b.WriteString(fmt.Sprintf("  \"%s\" [label=\"finish\", shape=%s, style=filled, fillcolor=\"%s\", color=\"%s\"];\n",
-		escapeIdentifier(End), shapeOval, colorEndFill, colorEndBorder))
-}
-
-// writeNodes emits node declarations with simple styling per NodeType.
-func writeNodes(b *strings.Builder, g *Graph, nodeIDs []string) {
-	for _, id := range nodeIDs {
-		n := g.nodes[id]
-		label := n.Name
-		if label == "" {
-			label = n.ID
+	for _, n := range t.Nodes {
+		if n.Subgraph == nil {
+			continue
 		}
-		shape, fill, color := styleForNodeType(n.Type)
-		b.WriteString(fmt.Sprintf("  \"%s\" [label=\"%s\", shape=%s, style=filled, fillcolor=\"%s\", color=\"%s\"];\n",
-			escapeIdentifier(n.ID), escapeLabel(label), shape, fill, color))
+		path := joinNodePath(scope, n.ID)
+		fmt.Fprintf(b, "%ssubgraph \"cluster_%s\" {\n", indent, escapeIdentifier(path))
+		fmt.Fprintf(b, "%s  label=\"%s\";\n%s  style=rounded;\n%s  color=\"%s\";\n",
+			indent, escapeLabel(n.Label), indent, indent, n.Style.Border)
+		writeDOTNodes(b, n.Subgraph, path, indent+"  ")
+		fmt.Fprintf(b, "%s}\n", indent)
 	}
 }
 
-// writeRuntimeEdges emits solid edges (optionally skipping Start/End).
-func writeRuntimeEdges(b *strings.Builder, edges map[string][]*Edge, o *VizOptions) {
-	var fromIDs []string
-	for from := range edges {
-		fromIDs = append(fromIDs, from)
-	}
-	sort.Strings(fromIDs)
-	for _, from := range fromIDs {
-		es := edges[from]
-		for _, e := range es {
-			if !o.IncludeStartEnd && (e.From == Start || e.To == End) {
-				continue
+// writeDOTEdges emits runtime edges (solid), conditional edges (dashed with
+// branch labels), declared destinations (dotted gray) and subgraph links,
+// then recurses into subgraphs.
+func writeDOTEdges(b *strings.Builder, t *Topology, scope string) {
+	for _, e := range t.Edges {
+		from := escapeIdentifier(joinNodePath(scope, e.From))
+		to := escapeIdentifier(joinNodePath(scope, e.To))
+		var attrs []string
+		switch e.Kind {
+		case TopologyEdgeConditional:
+			attrs = append(attrs, "style=dashed", fmt.Sprintf("color=\"%s\"", edgeColor(e, colorConditionalEdge)))
+		case TopologyEdgeDestination:
+			attrs = append(attrs, "style=dotted", fmt.Sprintf("color=\"%s\"", edgeColor(e, colorDestinationEdge)))
+		case TopologyEdgeSubgraph:
+			attrs = append(attrs, "style=dotted", fmt.Sprintf("color=\"%s\"", edgeColor(e, colorDestinationEdge)),
+				"arrowhead=none")
+		default:
+			if e.Traversed {
+				attrs = append(attrs, fmt.Sprintf("color=\"%s\"", colorTrace))
 			}
-			b.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\";\n", escapeIdentifier(e.From), escapeIdentifier(e.To)))
 		}
-	}
-}
-
-// writeConditionalEdges emits dashed edges with branch labels.
-func writeConditionalEdges(b *strings.Builder, g *Graph, cond map[string]*ConditionalEdge, o *VizOptions) {
-	var fromIDs []string
-	for from := range cond {
-		fromIDs = append(fromIDs, from)
-	}
-	sort.Strings(fromIDs)
-	for _, from := range fromIDs {
-		ce := cond[from]
-		keys := make([]string, 0, len(ce.PathMap))
-		for k := range ce.PathMap {
-			keys = append(keys, k)
+		if e.Label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=\"%s\"", escapeLabel(e.Label)))
 		}
-		// For MultiCondition with empty PathMap, fallback to node-level ends
-		// to render potential branches for visualization.
-		if len(keys) == 0 {
-			if n, ok := g.nodes[from]; ok && n != nil && n.ends != nil {
-				for k := range n.ends {
-					keys = append(keys, k)
-				}
-			}
+		if e.Kind == TopologyEdgeDestination {
+			attrs = append(attrs, "constraint=false")
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			to := ce.PathMap[k]
-			if to == "" {
-				// Resolve through ends map for display purposes.
-				if n, ok := g.nodes[from]; ok && n != nil && n.ends != nil {
-					if v, ok2 := n.ends[k]; ok2 {
-						to = v
-					}
-				}
-			}
-			if !o.IncludeStartEnd && (from == Start || to == End) {
-				continue
-			}
-			b.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\" [style=dashed, color=\"%s\", label=\"%s\"];\n",
-				escapeIdentifier(from), escapeIdentifier(to), colorConditionalEdge, escapeLabel(k)))
+		if e.Traversed {
+			attrs = append(attrs, "penwidth=3")
 		}
-	}
-}
-
-// writeDestinations emits dotted gray edges for declared destinations.
-func writeDestinations(b *strings.Builder, g *Graph, nodeIDs []string, o *VizOptions) {
-	if !o.IncludeDestinations {
-		return
-	}
-	for _, id := range nodeIDs {
-		n := g.nodes[id]
-		if n.destinations == nil {
+		if len(attrs) == 0 {
+			fmt.Fprintf(b, "  \"%s\" -> \"%s\";\n", from, to)
 			continue
 		}
-		var dstIDs []string
-		for to := range n.destinations {
-			dstIDs = append(dstIDs, to)
-		}
-		sort.Strings(dstIDs)
-		for _, to := range dstIDs {
-			if !o.IncludeStartEnd && to == End {
-				continue
-			}
-			lbl := n.destinations[to]
-			if lbl != "" {
-				fmt.Fprintf(b, "  \"%s\" -> \"%s\" [style=dotted, color=\"%s\", label=\"%s\", constraint=false];\n",
-					escapeIdentifier(n.ID), escapeIdentifier(to), colorDestinationEdge, escapeLabel(lbl))
-			} else {
-				fmt.Fprintf(b, "  \"%s\" -> \"%s\" [style=dotted, color=\"%s\", constraint=false];\n",
-					escapeIdentifier(n.ID), escapeIdentifier(to), colorDestinationEdge)
-			}
+		fmt.Fprintf(b, "  \"%s\" -> \"%s\" [%s];\n", from, to, strings.Join(attrs, ", "))
+	}
+	for _, n := range t.Nodes {
+		if n.Subgraph != nil {
+			writeDOTEdges(b, n.Subgraph, joinNodePath(scope, n.ID))
 		}
 	}
 }
 
+// edgeColor returns the trace color for traversed edges and def otherwise.
+func edgeColor(e TopologyEdge, def string) string {
+	if e.Traversed {
+		return colorTrace
+	}
+	return def
+}
+
 // highlightEntry emphasizes entry when Start node is hidden.
-func highlightEntry(b *strings.Builder, entry string, o *VizOptions) {
-	if o.IncludeStartEnd || entry == "" {
-		return
+func highlightEntry(b *strings.Builder, t *Topology, scope string) {
+	hasStart := len(t.Nodes) > 0 && t.Nodes[0].ID == Start && t.Nodes[0].Virtual
+	if !hasStart && t.EntryPoint != "" {
+		// When Start is hidden, emphasize entry with a double border.
+		b.WriteString(fmt.Sprintf("  \"%s\" [peripheries=2];\n", escapeIdentifier(joinNodePath(scope, t.EntryPoint))))
+	}
+	for _, n := range t.Nodes {
+		if n.Subgraph != nil {
+			highlightEntry(b, n.Subgraph, joinNodePath(scope, n.ID))
+		}
 	}
-	// When Start is hidden, emphasize entry with a double border.
-	b.WriteString(fmt.Sprintf("  \"%s\" [peripheries=2];\n", escapeIdentifier(entry)))
 }
 
 // WriteDOT writes the DOT representation to the provided writer.
@@ -0,0 +1,205 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mermaid class names used for node-type styling.
+const (
+	mermaidClassStart   = "startNode"
+	mermaidClassEnd     = "endNode"
+	mermaidClassEntry   = "entryNode"
+	mermaidClassVisited = "visitedNode"
+)
+
+// Mermaid returns a Mermaid flowchart representation of the graph. It honors
+// the same VizOptions as DOT: rank direction, Start/End, destinations,
+// conditional edge labels, nested subgraphs and the execution trace overlay
+// (visited nodes and traversed edges are drawn bold red).
+func (g *Graph) Mermaid(opts ...VizOption) string {
+	t := g.Topology(opts...)
+	m := &mermaidWriter{ids: make(map[string]string)}
+	if t.Label != "" {
+		fmt.Fprintf(&m.b, "---\ntitle: %s\n---\n", strconv.Quote(t.Label))
+	}
+	fmt.Fprintf(&m.b, "flowchart %s\n", t.Direction)
+	m.writeNodes(t, "", "  ")
+	m.writeEdges(t, "")
+	m.writeStyles()
+	return m.b.String()
+}
+
+// WriteMermaid writes the Mermaid representation to the provided writer.
+func (g *Graph) WriteMermaid(w io.Writer, opts ...VizOption) error {
+	_, err := io.WriteString(w, g.Mermaid(opts...))
+	return err
+}
+
+// mermaidWriter renders a Topology. Mermaid identifiers are generated
+// because node IDs may contain characters Mermaid does not accept.
+type mermaidWriter struct {
+	b       strings.Builder
+	ids     map[string]string
+	classes map[string]TopologyStyle
+	visited []string
+	entries []string
+	links   int
+	// linkStyles maps a linkStyle declaration to the link indexes using it.
+	linkStyles map[string][]int
+}
+
+// id returns the Mermaid identifier for a node path.
+func (m *mermaidWriter) id(path string) string {
+	if id, ok := m.ids[path]; ok {
+		return id
+	}
+	id := fmt.Sprintf("n%d", len(m.ids))
+	m.ids[path] = id
+	return id
+}
+
+func (m *mermaidWriter) writeNodes(t *Topology, scope, indent string) {
+	hasStart := false
+	for _, n := range t.Nodes {
+		path := joinNodePath(scope, n.ID)
+		id := m.id(path)
+		if n.Subgraph != nil {
+			// Keep the node itself and nest its graph right after it.
+			m.writeNode(indent, id, n)
+			fmt.Fprintf(&m.b, "%ssubgraph %s_sub [%s]\n", indent, id, mermaidQuote(n.Label))
+			fmt.Fprintf(&m.b, "%s  direction %s\n", indent, n.Subgraph.Direction)
+			m.writeNodes(n.Subgraph, path, indent+"  ")
+			fmt.Fprintf(&m.b, "%send\n", indent)
+		} else {
+			m.writeNode(indent, id, n)
+		}
+		if n.Virtual && n.ID == Start {
+			hasStart = true
+		}
+		if n.Visits > 0 {
+			m.visited = append(m.visited, id)
+		}
+	}
+	if !hasStart && t.EntryPoint != "" {
+		m.entries = append(m.entries, m.id(joinNodePath(scope, t.EntryPoint)))
+	}
+}
+
+func (m *mermaidWriter) writeNode(indent, id string, n TopologyNode) {
+	class := mermaidNodeClass(n)
+	if m.classes == nil {
+		m.classes = make(map[string]TopologyStyle)
+	}
+	m.classes[class] = n.Style
+	label := mermaidQuote(n.Label)
+	var shape string
+	switch n.Style.Shape {
+	case shapeDiamond:
+		shape = "{" + label + "}"
+	case shapeOval:
+		shape = "([" + label + "])"
+	default:
+		shape = "[" + label + "]"
+	}
+	fmt.Fprintf(&m.b, "%s%s%s:::%s\n", indent, id, shape, class)
+}
+
+func (m *mermaidWriter) writeEdges(t *Topology, scope string) {
+	for _, e := range t.Edges {
+		from := m.id(joinNodePath(scope, e.From))
+		to := m.id(joinNodePath(scope, e.To))
+		arrow, style := "-->", ""
+		switch e.Kind {
+		case TopologyEdgeConditional:
+			arrow, style = "-.->", "stroke:"+colorConditionalEdge
+		case TopologyEdgeDestination:
+			arrow, style = "-.->", "stroke:"+colorDestinationEdge
+		case TopologyEdgeSubgraph:
+			arrow, style = "-.-", "stroke:"+colorDestinationEdge
+		}
+		if e.Traversed {
+			if arrow == "-->" {
+				arrow = "==>"
+			}
+			style = "stroke:" + colorTrace + ",stroke-width:3px"
+		}
+		if e.Label != "" {
+			fmt.Fprintf(&m.b, "  %s %s|%s| %s\n", from, arrow, mermaidQuote(e.Label), to)
+		} else {
+			fmt.Fprintf(&m.b, "  %s %s %s\n", from, arrow, to)
+		}
+		if style != "" {
+			if m.linkStyles == nil {
+				m.linkStyles = make(map[string][]int)
+			}
+			m.linkStyles[style] = append(m.linkStyles[style], m.links)
+		}
+		m.links++
+	}
+	for _, n := range t.Nodes {
+		if n.Subgraph != nil {
+			m.writeEdges(n.Subgraph, joinNodePath(scope, n.ID))
+		}
+	}
+}
+
+func (m *mermaidWriter) writeStyles() {
+	for _, class := range sortedMapKeys(m.classes) {
+		st := m.classes[class]
+		fmt.Fprintf(&m.b, "  classDef %s fill:%s,stroke:%s\n", class, st.Fill, st.Border)
+	}
+	if len(m.entries) > 0 {
+		fmt.Fprintf(&m.b, "  classDef %s stroke-width:3px\n", mermaidClassEntry)
+		fmt.Fprintf(&m.b, "  class %s %s\n", strings.Join(m.entries, ","), mermaidClassEntry)
+	}
+	if len(m.visited) > 0 {
+		fmt.Fprintf(&m.b, "  classDef %s stroke:%s,stroke-width:3px\n", mermaidClassVisited, colorTrace)
+		fmt.Fprintf(&m.b, "  class %s %s\n", strings.Join(m.visited, ","), mermaidClassVisited)
+	}
+	styles := sortedMapKeys(m.linkStyles)
+	sort.SliceStable(styles, func(i, j int) bool {
+		return m.linkStyles[styles[i]][0] < m.linkStyles[styles[j]][0]
+	})
+	for _, style := range styles {
+		idx := make([]string, 0, len(m.linkStyles[style]))
+		for _, i := range m.linkStyles[style] {
+			idx = append(idx, strconv.Itoa(i))
+		}
+		fmt.Fprintf(&m.b, "  linkStyle %s %s\n", strings.Join(idx, ","), style)
+	}
+}
+
+// mermaidNodeClass returns the class used to style a node by its type.
+func mermaidNodeClass(n TopologyNode) string {
+	if n.Virtual {
+		if n.ID == Start {
+			return mermaidClassStart
+		}
+		return mermaidClassEnd
+	}
+	if n.Type == "" {
+		return string(NodeTypeFunction) + "Node"
+	}
+	return n.Type + "Node"
+}
+
+// mermaidQuote quotes a label for Mermaid, escaping characters that would
+// terminate the string.
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, "\"", "#quot;")
+	s = strings.ReplaceAll(s, "\n", "<br/>")
+	return "\"" + s + "\""
+}
@@ -0,0 +1,449 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	atrace "trpc.group/trpc-go/trpc-agent-go/agent/trace"
+)
+
+// TopologyEdgeKind classifies edges in a Topology.
+type TopologyEdgeKind string
+
+// Edge kinds used in Topology.
+const (
+	// TopologyEdgeRuntime is a static edge added via AddEdge.
+	TopologyEdgeRuntime TopologyEdgeKind = "runtime"
+	// TopologyEdgeJoin is a static edge that feeds a join (AddJoinEdge).
+	TopologyEdgeJoin TopologyEdgeKind = "join"
+	// TopologyEdgeConditional is a branch of a conditional edge.
+	TopologyEdgeConditional TopologyEdgeKind = "conditional"
+	// TopologyEdgeDestination is a declared dynamic destination.
+	TopologyEdgeDestination TopologyEdgeKind = "destination"
+	// TopologyEdgeSubgraph links a node to the entry of its nested subgraph.
+	TopologyEdgeSubgraph TopologyEdgeKind = "subgraph"
+)
+
+// Topology is a structured, renderer-independent view of a graph. It is the
+// model behind DOT, Mermaid and JSON export and is stable for JSON encoding.
+type Topology struct {
+	// Direction is the layout direction ("LR" or "TB").
+	Direction string `json:"direction"`
+	// Label is the optional graph label.
+	Label string `json:"label,omitempty"`
+	// EntryPoint is the first node to run.
+	EntryPoint string `json:"entry_point,omitempty"`
+	// Traced reports whether an execution trace was overlaid.
+	Traced bool `json:"traced,omitempty"`
+	// Nodes lists Start/End (when enabled) followed by nodes sorted by ID.
+	Nodes []TopologyNode `json:"nodes"`
+	// Edges lists runtime, conditional, destination and subgraph edges in
+	// that order.
+	Edges []TopologyEdge `json:"edges"`
+}
+
+// TopologyNode is a node in a Topology.
+type TopologyNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	// Type is the NodeType. It is empty for the virtual Start/End nodes.
+	Type string `json:"type,omitempty"`
+	// Virtual marks the Start/End nodes.
+	Virtual bool          `json:"virtual,omitempty"`
+	Entry   bool          `json:"entry,omitempty"`
+	Style   TopologyStyle `json:"style"`
+	// Visits counts how often the node ran in the overlaid trace.
+	Visits int `json:"visits,omitempty"`
+	// Subgraph is the nested graph attached via WithSubgraph.
+	Subgraph *Topology `json:"subgraph,omitempty"`
+}
+
+// TopologyStyle carries the node-type styling used by the renderers.
+type TopologyStyle struct {
+	Shape  string `json:"shape"`
+	Fill   string `json:"fill"`
+	Border string `json:"border"`
+}
+
+// TopologyEdge is an edge in a Topology.
+type TopologyEdge struct {
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Kind  TopologyEdgeKind `json:"kind"`
+	Label string           `json:"label,omitempty"`
+	// Traversed reports whether the overlaid trace took this edge.
+	Traversed bool `json:"traversed,omitempty"`
+}
+
+// Topology returns a structured view of the graph honoring the VizOptions.
+func (g *Graph) Topology(opts ...VizOption) *Topology {
+	o := newVizOptions(opts)
+	return buildTopology(g, o, "", newTraceOverlay(g, o))
+}
+
+// TopologyJSON returns the indented JSON encoding of Topology.
+func (g *Graph) TopologyJSON(opts ...VizOption) ([]byte, error) {
+	return json.MarshalIndent(g.Topology(opts...), "", "  ")
+}
+
+// WriteTopologyJSON writes the JSON topology to the provided writer.
+func (g *Graph) WriteTopologyJSON(w io.Writer, opts ...VizOption) error {
+	data, err := g.TopologyJSON(opts...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// buildTopology converts g into a Topology. scope is the node path of the
+// parent node for nested subgraphs and empty for the root graph.
+func buildTopology(g *Graph, o *VizOptions, scope string, overlay *traceOverlay) *Topology {
+	joins := g.JoinEdges()
+
+	// Snapshot data under read lock.
+	g.mu.RLock()
+	nodeIDs := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+	nodes := make(map[string]*Node, len(g.nodes))
+	for id, n := range g.nodes {
+		nodes[id] = n
+	}
+	edgesCopy := copyEdges(g.edges)
+	condCopy := copyConditionalEdges(g.conditionalEdges)
+	entry := g.entryPoint
+	g.mu.RUnlock()
+
+	t := &Topology{
+		Direction:  o.RankDir,
+		EntryPoint: entry,
+		Traced:     overlay != nil,
+		Nodes:      []TopologyNode{},
+		Edges:      []TopologyEdge{},
+	}
+	if scope == "" {
+		t.Label = o.GraphLabel
+	}
+	if o.IncludeStartEnd {
+		t.Nodes = append(t.Nodes,
+			TopologyNode{ID: Start, Label: "start", Virtual: true,
+				Style: TopologyStyle{Shape: shapeOval, Fill: colorStartFill, Border: colorStartBorder}},
+			TopologyNode{ID: End, Label: "finish", Virtual: true,
+				Style: TopologyStyle{Shape: shapeOval, Fill: colorEndFill, Border: colorEndBorder}},
+		)
+	}
+	for _, id := range nodeIDs {
+		n := nodes[id]
+		label := n.Name
+		if label == "" {
+			label = n.ID
+		}
+		shape, fill, color := styleForNodeType(n.Type)
+		tn := TopologyNode{
+			ID:     n.ID,
+			Label:  label,
+			Type:   string(n.Type),
+			Entry:  n.ID == entry,
+			Style:  TopologyStyle{Shape: shape, Fill: fill, Border: color},
+			Visits: overlay.visitCount(joinNodePath(scope, n.ID)),
+		}
+		if sub := o.Subgraphs[joinNodePath(scope, n.ID)]; sub != nil && sub != g {
+			tn.Subgraph = buildTopology(sub, o, joinNodePath(scope, n.ID), overlay)
+		}
+		t.Nodes = append(t.Nodes, tn)
+	}
+
+	joinSources := make(map[[2]string]bool)
+	for _, j := range joins {
+		for _, from := range j.From {
+			joinSources[[2]string{from, j.To}] = true
+		}
+	}
+	addEdge := func(from, to string, kind TopologyEdgeKind, label string) {
+		t.Edges = append(t.Edges, TopologyEdge{
+			From:      from,
+			To:        to,
+			Kind:      kind,
+			Label:     label,
+			Traversed: overlay.traversed(joinNodePath(scope, from), joinNodePath(scope, to)),
+		})
+	}
+
+	// Runtime edges (optionally skipping Start/End).
+	for _, from := range sortedMapKeys(edgesCopy) {
+		for _, e := range edgesCopy[from] {
+			if !o.IncludeStartEnd && (e.From == Start || e.To == End) {
+				continue
+			}
+			kind := TopologyEdgeRuntime
+			if joinSources[[2]string{e.From, e.To}] {
+				kind = TopologyEdgeJoin
+			}
+			addEdge(e.From, e.To, kind, "")
+		}
+	}
+
+	// Conditional edges labeled by branch.
+	for _, from := range sortedMapKeys(condCopy) {
+		ce := condCopy[from]
+		keys := make([]string, 0, len(ce.PathMap))
+		for k := range ce.PathMap {
+			keys = append(keys, k)
+		}
+		// For MultiCondition with empty PathMap, fallback to node-level ends
+		// to render potential branches for visualization.
+		n := nodes[from]
+		if len(keys) == 0 && n != nil {
+			for k := range n.ends {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			to := ce.PathMap[k]
+			if to == "" && n != nil {
+				// Resolve through ends map for display purposes.
+				to = n.ends[k]
+			}
+			if to == "" {
+				continue
+			}
+			if !o.IncludeStartEnd && (from == Start || to == End) {
+				continue
+			}
+			addEdge(from, to, TopologyEdgeConditional, k)
+		}
+	}
+
+	// Declared destinations.
+	if o.IncludeDestinations {
+		for _, id := range nodeIDs {
+			n := nodes[id]
+			for _, to := range sortedMapKeys(n.destinations) {
+				if !o.IncludeStartEnd && to == End {
+					continue
+				}
+				addEdge(n.ID, to, TopologyEdgeDestination, n.destinations[to])
+			}
+		}
+	}
+
+	// Links from nodes to their nested subgraph entry.
+	for _, tn := range t.Nodes {
+		if tn.Subgraph == nil {
+			continue
+		}
+		target := tn.Subgraph.EntryPoint
+		if o.IncludeStartEnd {
+			target = Start
+		}
+		if target == "" {
+			continue
+		}
+		path := joinNodePath(scope, tn.ID)
+		t.Edges = append(t.Edges, TopologyEdge{
+			From:      tn.ID,
+			To:        joinNodePath(tn.ID, target),
+			Kind:      TopologyEdgeSubgraph,
+			Traversed: overlay.scopeVisited(path),
+		})
+	}
+	return t
+}
+
+// sortedMapKeys returns the keys of m in ascending order.
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinNodePath joins a subgraph scope and a local node ID.
+func joinNodePath(scope, id string) string {
+	if scope == "" {
+		return id
+	}
+	return scope + "/" + id
+}
+
+// splitNodePath splits a node path into its scope and local node ID.
+func splitNodePath(path string) (scope, id string) {
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
+
+// traceOverlay records visited node paths and traversed edges.
+type traceOverlay struct {
+	visits map[string]int
+	edges  map[[2]string]bool
+}
+
+// newTraceOverlay builds the overlay from the trace options. It returns nil
+// when no trace was supplied.
+func newTraceOverlay(g *Graph, o *VizOptions) *traceOverlay {
+	if o.ExecutionTrace == nil && len(o.ExecutedPath) == 0 {
+		return nil
+	}
+	ov := &traceOverlay{
+		visits: make(map[string]int),
+		edges:  make(map[[2]string]bool),
+	}
+	if o.ExecutionTrace != nil {
+		ov.addTrace(g, o.Subgraphs, o.ExecutionTrace)
+	}
+	ov.addPath(o.ExecutedPath)
+	return ov
+}
+
+// addPath overlays an ordered list of node paths. Consecutive entries within
+// the same scope become traversed edges; the first and last entries of each
+// scope connect to that scope's Start and End.
+func (ov *traceOverlay) addPath(paths []string) {
+	last := make(map[string]string)
+	var scopes []string
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		scope, _ := splitNodePath(path)
+		prev, ok := last[scope]
+		if !ok {
+			scopes = append(scopes, scope)
+			prev = joinNodePath(scope, Start)
+		}
+		ov.edges[[2]string{prev, path}] = true
+		ov.visits[path]++
+		last[scope] = path
+	}
+	for _, scope := range scopes {
+		ov.edges[[2]string{last[scope], joinNodePath(scope, End)}] = true
+	}
+}
+
+// addTrace overlays the steps of an execution trace. Step node IDs are
+// slash-separated structure paths; they are matched against graph node IDs,
+// descending into registered subgraphs.
+func (ov *traceOverlay) addTrace(g *Graph, subgraphs map[string]*Graph, tr *atrace.Trace) {
+	resolved := make(map[string]string, len(tr.Steps))
+	var order []string
+	for _, step := range tr.Steps {
+		path, ok := resolveTracePath(g, subgraphs, step.NodeID)
+		if !ok {
+			continue
+		}
+		ov.visits[path]++
+		if _, seen := resolved[step.StepID]; !seen {
+			order = append(order, step.StepID)
+		}
+		resolved[step.StepID] = path
+	}
+	hasSuccessor := make(map[string]bool)
+	for _, step := range tr.Steps {
+		path, ok := resolved[step.StepID]
+		if !ok {
+			continue
+		}
+		scope, _ := splitNodePath(path)
+		linked := false
+		for _, pred := range step.PredecessorStepIDs {
+			predPath, ok := resolved[pred]
+			if !ok {
+				continue
+			}
+			if predScope, _ := splitNodePath(predPath); predScope != scope {
+				continue
+			}
+			ov.edges[[2]string{predPath, path}] = true
+			hasSuccessor[predPath] = true
+			linked = true
+		}
+		if !linked {
+			ov.edges[[2]string{joinNodePath(scope, Start), path}] = true
+		}
+	}
+	for _, stepID := range order {
+		path := resolved[stepID]
+		if hasSuccessor[path] {
+			continue
+		}
+		scope, _ := splitNodePath(path)
+		ov.edges[[2]string{path, joinNodePath(scope, End)}] = true
+	}
+}
+
+// resolveTracePath maps a trace step node ID to a graph node path. Segments
+// that do not name a node (agent names) are skipped.
+func resolveTracePath(g *Graph, subgraphs map[string]*Graph, nodeID string) (string, bool) {
+	if nodeID == "" {
+		return "", false
+	}
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	segs := strings.Split(nodeID, "/")
+	cur, scope := g, ""
+	for i, seg := range segs {
+		seg = unescape.Replace(seg)
+		if _, ok := cur.Node(seg); !ok {
+			continue
+		}
+		path := joinNodePath(scope, seg)
+		if i == len(segs)-1 {
+			return path, true
+		}
+		sub := subgraphs[path]
+		if sub == nil {
+			// Steps below a node without a registered subgraph belong to
+			// that node's internals and are not graph nodes.
+			return "", false
+		}
+		cur, scope = sub, path
+	}
+	return "", false
+}
+
+func (ov *traceOverlay) visitCount(path string) int {
+	if ov == nil {
+		return 0
+	}
+	return ov.visits[path]
+}
+
+func (ov *traceOverlay) traversed(from, to string) bool {
+	if ov == nil {
+		return false
+	}
+	return ov.edges[[2]string{from, to}]
+}
+
+// scopeVisited reports whether any node below scope was visited.
+func (ov *traceOverlay) scopeVisited(scope string) bool {
+	if ov == nil {
+		return false
+	}
+	prefix := scope + "/"
+	for path := range ov.visits {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
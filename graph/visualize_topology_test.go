@@ -0,0 +1,295 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	atrace "trpc.group/trpc-go/trpc-agent-go/agent/trace"
+)
+
+// buildSampleSubgraph constructs a one-node graph used as a nested subgraph.
+func buildSampleSubgraph(t *testing.T) *Graph {
+	t.Helper()
+	sg := NewStateGraph(NewStateSchema())
+	sg.AddNode("inner", func(ctx context.Context, s State) (any, error) { return s, nil }, WithNodeType(NodeTypeLLM))
+	sg.SetEntryPoint("inner").SetFinishPoint("inner")
+	g, err := sg.Compile()
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	return g
+}
+
+func findTopologyNode(t *testing.T, topo *Topology, id string) TopologyNode {
+	t.Helper()
+	for _, n := range topo.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found in topology: %+v", id, topo.Nodes)
+	return TopologyNode{}
+}
+
+func findTopologyEdge(topo *Topology, from, to string) (TopologyEdge, bool) {
+	for _, e := range topo.Edges {
+		if e.From == from && e.To == to {
+			return e, true
+		}
+	}
+	return TopologyEdge{}, false
+}
+
+func TestTopology_NodesEdgesAndKinds(t *testing.T) {
+	g := buildSampleGraph(t)
+	topo := g.Topology(WithRankDir(RankDirTB), WithGraphLabel("Sample"))
+
+	if topo.Direction != RankDirTB || topo.Label != "Sample" || topo.EntryPoint != testNodePrepare {
+		t.Fatalf("unexpected header: %+v", topo)
+	}
+	if topo.Traced {
+		t.Fatalf("expected no trace overlay")
+	}
+	if !topo.Nodes[0].Virtual || topo.Nodes[0].ID != Start || topo.Nodes[1].ID != End {
+		t.Fatalf("expected Start/End first, got: %+v", topo.Nodes[:2])
+	}
+	ask := findTopologyNode(t, topo, testNodeAsk)
+	if ask.Type != string(NodeTypeLLM) || ask.Style.Fill != colorLLMFill {
+		t.Fatalf("unexpected llm node styling: %+v", ask)
+	}
+	if !findTopologyNode(t, topo, testNodePrepare).Entry {
+		t.Fatalf("expected prepare to be marked as entry")
+	}
+
+	if e, ok := findTopologyEdge(topo, testNodePrepare, testNodeAsk); !ok || e.Kind != TopologyEdgeRuntime {
+		t.Fatalf("expected runtime edge prepare->ask, got: %+v", e)
+	}
+	if e, ok := findTopologyEdge(topo, testNodeAsk, testNodeTools); !ok || e.Kind != TopologyEdgeConditional || e.Label != testNodeTools {
+		t.Fatalf("expected labeled conditional edge ask->tools, got: %+v", e)
+	}
+	if e, ok := findTopologyEdge(topo, "noop", End); !ok || e.Kind != TopologyEdgeDestination || e.Label != "finish early" {
+		t.Fatalf("expected destination edge noop->end, got: %+v", e)
+	}
+
+	hidden := g.Topology(WithIncludeStartEnd(false), WithIncludeDestinations(false))
+	for _, n := range hidden.Nodes {
+		if n.Virtual {
+			t.Fatalf("expected Start/End hidden, got: %+v", n)
+		}
+	}
+	for _, e := range hidden.Edges {
+		if e.Kind == TopologyEdgeDestination || e.To == End || e.From == Start {
+			t.Fatalf("unexpected edge with Start/End and destinations hidden: %+v", e)
+		}
+	}
+}
+
+func TestTopology_JoinEdges(t *testing.T) {
+	sg := NewStateGraph(NewStateSchema())
+	noop := func(ctx context.Context, s State) (any, error) { return s, nil }
+	sg.AddNode("a", noop).AddNode("b", noop).AddNode("c", noop)
+	sg.SetEntryPoint("a")
+	sg.AddEdge("a", "b")
+	sg.AddJoinEdge([]string{"a", "b"}, "c")
+	sg.SetFinishPoint("c")
+	g, err := sg.Compile()
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	topo := g.Topology()
+	if e, ok := findTopologyEdge(topo, "b", "c"); !ok || e.Kind != TopologyEdgeJoin {
+		t.Fatalf("expected join edge b->c, got: %+v", e)
+	}
+	if e, ok := findTopologyEdge(topo, "a", "b"); !ok || e.Kind != TopologyEdgeRuntime {
+		t.Fatalf("expected runtime edge a->b, got: %+v", e)
+	}
+}
+
+func TestTopology_SubgraphAndExecutedPath(t *testing.T) {
+	g := buildSampleGraph(t)
+	sub := buildSampleSubgraph(t)
+	topo := g.Topology(
+		WithSubgraph(testNodeTools, sub),
+		WithExecutedPath(testNodePrepare, testNodeAsk, testNodeTools, testNodeTools+"/inner"),
+	)
+	if !topo.Traced {
+		t.Fatalf("expected trace overlay")
+	}
+	tools := findTopologyNode(t, topo, testNodeTools)
+	if tools.Subgraph == nil || tools.Visits != 1 {
+		t.Fatalf("expected visited tools node with subgraph, got: %+v", tools)
+	}
+	if n := findTopologyNode(t, tools.Subgraph, "inner"); n.Visits != 1 {
+		t.Fatalf("expected inner node visited, got: %+v", n)
+	}
+	if findTopologyNode(t, topo, testNodeFallback).Visits != 0 {
+		t.Fatalf("expected fallback not visited")
+	}
+	for _, pair := range [][2]string{{Start, testNodePrepare}, {testNodePrepare, testNodeAsk}, {testNodeAsk, testNodeTools}} {
+		if e, ok := findTopologyEdge(topo, pair[0], pair[1]); !ok || !e.Traversed {
+			t.Fatalf("expected %s->%s traversed, got: %+v", pair[0], pair[1], e)
+		}
+	}
+	if e, _ := findTopologyEdge(topo, testNodeAsk, testNodeFallback); e.Traversed {
+		t.Fatalf("expected ask->fallback not traversed")
+	}
+	if e, ok := findTopologyEdge(topo, testNodeTools, testNodeTools+"/"+Start); !ok || e.Kind != TopologyEdgeSubgraph || !e.Traversed {
+		t.Fatalf("expected traversed subgraph link, got: %+v", e)
+	}
+	if e, ok := findTopologyEdge(tools.Subgraph, "inner", End); !ok || !e.Traversed {
+		t.Fatalf("expected inner->end traversed, got: %+v", e)
+	}
+}
+
+func TestTopology_ExecutionTrace(t *testing.T) {
+	g := buildSampleGraph(t)
+	sub := buildSampleSubgraph(t)
+	tr := &atrace.Trace{Steps: []atrace.Step{
+		{StepID: "s1", NodeID: "root/" + testNodePrepare},
+		{StepID: "s2", NodeID: "root/" + testNodeAsk, PredecessorStepIDs: []string{"s1"}},
+		{StepID: "s3", NodeID: "root/" + testNodeTools, PredecessorStepIDs: []string{"s2"}},
+		{StepID: "s4", NodeID: "root/" + testNodeTools + "/child/inner", PredecessorStepIDs: []string{"s3"}},
+		{StepID: "s5", NodeID: "root/" + testNodeAsk + "/model", PredecessorStepIDs: []string{"s2"}},
+		{StepID: "s6", NodeID: "root/" + testNodeFallback, PredecessorStepIDs: []string{"s3"}},
+	}}
+	topo := g.Topology(WithSubgraph(testNodeTools, sub), WithExecutionTrace(tr))
+
+	if n := findTopologyNode(t, topo, testNodeAsk); n.Visits != 1 {
+		t.Fatalf("expected ask visited once (internal steps ignored), got: %d", n.Visits)
+	}
+	if e, _ := findTopologyEdge(topo, testNodePrepare, testNodeAsk); !e.Traversed {
+		t.Fatalf("expected prepare->ask traversed")
+	}
+	if e, _ := findTopologyEdge(topo, testNodeFallback, End); !e.Traversed {
+		t.Fatalf("expected fallback->end traversed")
+	}
+	tools := findTopologyNode(t, topo, testNodeTools)
+	if n := findTopologyNode(t, tools.Subgraph, "inner"); n.Visits != 1 {
+		t.Fatalf("expected nested trace step resolved, got: %+v", n)
+	}
+	if e, _ := findTopologyEdge(tools.Subgraph, Start, "inner"); !e.Traversed {
+		t.Fatalf("expected nested start->inner traversed")
+	}
+}
+
+func TestTopologyJSON_RoundTrip(t *testing.T) {
+	g := buildSampleGraph(t)
+	var buf bytes.Buffer
+	if err := g.WriteTopologyJSON(&buf, WithExecutedPath(testNodePrepare)); err != nil {
+		t.Fatalf("WriteTopologyJSON error: %v", err)
+	}
+	var decoded Topology
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !decoded.Traced || decoded.EntryPoint != testNodePrepare || len(decoded.Nodes) != len(g.Topology().Nodes) {
+		t.Fatalf("unexpected decoded topology: %+v", decoded)
+	}
+	if !strings.Contains(buf.String(), `"kind": "conditional"`) {
+		t.Fatalf("expected edge kinds in JSON, got: %s", buf.String())
+	}
+}
+
+func TestMermaid_IncludesNodesEdgesAndStyles(t *testing.T) {
+	g := buildSampleGraph(t)
+	out := g.Mermaid(WithRankDir(RankDirTB), WithGraphLabel("Test"))
+
+	if !strings.Contains(out, "title: \"Test\"") || !strings.Contains(out, "flowchart TB\n") {
+		t.Fatalf("expected title and direction, got: %s", out)
+	}
+	for _, want := range []string{
+		`(["start"]):::startNode`,
+		`(["finish"]):::endNode`,
+		`["ask"]:::llmNode`,
+		`["tools"]:::toolNode`,
+		`-.->|"tools"|`,
+		`-.->|"finish early"|`,
+		"classDef llmNode fill:" + colorLLMFill + ",stroke:" + colorLLMBorder,
+		"stroke:" + colorConditionalEdge,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in Mermaid, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "==>") || strings.Contains(out, "visitedNode") {
+		t.Fatalf("unexpected trace styling without overlay: %s", out)
+	}
+}
+
+func TestMermaid_HideStartEndHighlightsEntry(t *testing.T) {
+	g := buildSampleGraph(t)
+	out := g.Mermaid(WithIncludeStartEnd(false), WithIncludeDestinations(false))
+	if strings.Contains(out, "startNode") || strings.Contains(out, "finish early") {
+		t.Fatalf("expected Start/End and destinations hidden, got: %s", out)
+	}
+	if !strings.Contains(out, "classDef entryNode") {
+		t.Fatalf("expected entry highlight, got: %s", out)
+	}
+}
+
+func TestMermaid_SubgraphAndTrace(t *testing.T) {
+	g := buildSampleGraph(t)
+	sub := buildSampleSubgraph(t)
+	var buf bytes.Buffer
+	err := g.WriteMermaid(&buf,
+		WithSubgraph(testNodeTools, sub),
+		WithExecutedPath(testNodePrepare, testNodeAsk, testNodeTools, testNodeTools+"/inner"),
+	)
+	if err != nil {
+		t.Fatalf("WriteMermaid error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`_sub ["tools"]`,
+		`["inner"]:::llmNode`,
+		"==>",
+		"classDef visitedNode stroke:" + colorTrace,
+		"stroke:" + colorTrace + ",stroke-width:3px",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in Mermaid, got: %s", want, out)
+		}
+	}
+}
+
+func TestMermaid_EscapesLabels(t *testing.T) {
+	sg := NewStateGraph(NewStateSchema())
+	sg.AddNode("q", func(ctx context.Context, s State) (any, error) { return s, nil }, WithName("say \"hi\"\nnow"))
+	sg.SetEntryPoint("q").SetFinishPoint("q")
+	g, err := sg.Compile()
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	out := g.Mermaid()
+	if !strings.Contains(out, `["say #quot;hi#quot;<br/>now"]`) {
+		t.Fatalf("expected escaped label, got: %s", out)
+	}
+}
+
+func TestDOT_SubgraphClusterAndTrace(t *testing.T) {
+	g := buildSampleGraph(t)
+	sub := buildSampleSubgraph(t)
+	dot := g.DOT(WithSubgraph(testNodeTools, sub), WithExecutedPath(testNodePrepare, testNodeAsk))
+	for _, want := range []string{
+		"subgraph \"cluster_tools\" {",
+		"\"tools/inner\" [label=\"inner\"",
+		"\"prepare\" -> \"ask\" [color=\"" + colorTrace + "\", penwidth=3];",
+		"\"tools\" -> \"tools/__start__\" [style=dotted",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("expected %q in DOT, got: %s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package graphcache holds helpers shared by the graph.Cache backends.
+package graphcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	tmetric "trpc.group/trpc-go/trpc-agent-go/telemetry/metric"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+)
+
+// Operations reported with cache errors.
+const (
+	OperationGet           = "get"
+	OperationSet           = "set"
+	OperationClear         = "clear"
+	OperationEncode        = "encode"
+	OperationDecode        = "decode"
+	OperationDeleteExpired = "delete_expired"
+)
+
+// Metrics records the accesses of one cache instance, both as OpenTelemetry
+// counters, so that every replica of a deployment can be aggregated, and as
+// local totals returned by Stats.
+type Metrics struct {
+	backend       string
+	meterProvider metric.MeterProvider
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	sets   atomic.Int64
+	errs   atomic.Int64
+
+	once     sync.Once
+	hitCnt   metric.Int64Counter
+	missCnt  metric.Int64Counter
+	setCnt   metric.Int64Counter
+	errorCnt metric.Int64Counter
+}
+
+// NewMetrics creates the metrics of a cache backend. A nil meter provider
+// uses the global provider from telemetry/metric.
+func NewMetrics(backend string, mp metric.MeterProvider) *Metrics {
+	return &Metrics{backend: backend, meterProvider: mp}
+}
+
+// Hit records a Get that returned a value.
+func (m *Metrics) Hit(ns string) {
+	m.hits.Add(1)
+	m.add(&m.hitCnt, ns)
+}
+
+// Miss records a Get that found no live entry.
+func (m *Metrics) Miss(ns string) {
+	m.misses.Add(1)
+	m.add(&m.missCnt, ns)
+}
+
+// Set records an entry stored successfully.
+func (m *Metrics) Set(ns string) {
+	m.sets.Add(1)
+	m.add(&m.setCnt, ns)
+}
+
+// Error records a failed backend or serialization operation.
+func (m *Metrics) Error(ns, operation string) {
+	m.errs.Add(1)
+	m.add(&m.errorCnt, ns,
+		attribute.String(metrics.KeyTRPCAgentGoGraphCacheOperation, operation))
+}
+
+// Stats returns the totals recorded by this instance.
+func (m *Metrics) Stats() graph.CacheStats {
+	return graph.CacheStats{
+		Hits:   m.hits.Load(),
+		Misses: m.misses.Load(),
+		Sets:   m.sets.Load(),
+		Errors: m.errs.Load(),
+	}
+}
+
+// add increments a counter once the counters are created.
+func (m *Metrics) add(counter *metric.Int64Counter, ns string, attrs ...attribute.KeyValue) {
+	m.once.Do(m.init)
+	c := *counter
+	if c == nil {
+		return
+	}
+	attrs = append(attrs,
+		attribute.String(metrics.KeyTRPCAgentGoGraphCacheBackend, m.backend),
+		attribute.String(metrics.KeyTRPCAgentGoGraphCacheNamespace, ns),
+	)
+	c.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+}
+
+func (m *Metrics) init() {
+	mp := m.meterProvider
+	if mp == nil {
+		mp = tmetric.GetMeterProvider()
+	}
+	if mp == nil {
+		return
+	}
+	meter := mp.Meter(metrics.MeterNameGraphCache)
+	for _, c := range []struct {
+		counter     *metric.Int64Counter
+		name        string
+		description string
+	}{
+		{&m.hitCnt, metrics.MetricTRPCAgentGoGraphCacheHitCnt, "Number of graph cache hits"},
+		{&m.missCnt, metrics.MetricTRPCAgentGoGraphCacheMissCnt, "Number of graph cache misses"},
+		{&m.setCnt, metrics.MetricTRPCAgentGoGraphCacheSetCnt, "Number of graph cache entries stored"},
+		{&m.errorCnt, metrics.MetricTRPCAgentGoGraphCacheErrorCnt, "Number of failed graph cache operations"},
+	} {
+		var err error
+		if *c.counter, err = meter.Int64Counter(
+			c.name,
+			metric.WithDescription(c.description),
+			metric.WithUnit("1"),
+		); err != nil {
+			log.Warnf("graph cache: create metric %s: %v", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package graphcache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := NewMetrics("test", sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	m.Hit("a")
+	m.Hit("a")
+	m.Miss("b")
+	m.Set("a")
+	m.Error("b", OperationDecode)
+	assert.Equal(t, graph.CacheStats{Hits: 2, Misses: 1, Sets: 1, Errors: 1}, m.Stats())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, metrics.MeterNameGraphCache, rm.ScopeMetrics[0].Scope.Name)
+	sums := make(map[string]metricdata.DataPoint[int64])
+	for _, mt := range rm.ScopeMetrics[0].Metrics {
+		data := mt.Data.(metricdata.Sum[int64])
+		require.Len(t, data.DataPoints, 1, mt.Name)
+		sums[mt.Name] = data.DataPoints[0]
+	}
+	require.Len(t, sums, 4)
+
+	hit := sums[metrics.MetricTRPCAgentGoGraphCacheHitCnt]
+	assert.Equal(t, int64(2), hit.Value)
+	ns, _ := hit.Attributes.Value(attribute.Key(metrics.KeyTRPCAgentGoGraphCacheNamespace))
+	assert.Equal(t, "a", ns.AsString())
+	backend, _ := hit.Attributes.Value(attribute.Key(metrics.KeyTRPCAgentGoGraphCacheBackend))
+	assert.Equal(t, "test", backend.AsString())
+
+	errs := sums[metrics.MetricTRPCAgentGoGraphCacheErrorCnt]
+	assert.Equal(t, int64(1), errs.Value)
+	op, _ := errs.Attributes.Value(attribute.Key(metrics.KeyTRPCAgentGoGraphCacheOperation))
+	assert.Equal(t, OperationDecode, op.AsString())
+	assert.Equal(t, int64(1), sums[metrics.MetricTRPCAgentGoGraphCacheMissCnt].Value)
+	assert.Equal(t, int64(1), sums[metrics.MetricTRPCAgentGoGraphCacheSetCnt].Value)
+}
+
+func TestMetrics_GlobalProvider(t *testing.T) {
+	m := NewMetrics("test", nil)
+	m.Hit("a")
+	assert.Equal(t, int64(1), m.Stats().Hits)
+}
@@ -0,0 +1,180 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package bm25
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultK1 is the term frequency saturation, as in Elasticsearch.
+	DefaultK1 = 1.2
+	// DefaultB is the document length normalization, as in Elasticsearch.
+	DefaultB = 0.75
+	// normConstant maps raw BM25 scores into [0, 1) with x / (x + c).
+	normConstant = 1.0
+)
+
+// Params are the BM25 parameters.
+type Params struct {
+	K1 float64
+	B  float64
+}
+
+// DefaultParams returns the Elasticsearch defaults.
+func DefaultParams() Params {
+	return Params{K1: DefaultK1, B: DefaultB}
+}
+
+// IDF returns the Lucene BM25 inverse document frequency of a term found in
+// df of n documents.
+func IDF(n, df int) float64 {
+	return math.Log(1 + (float64(n)-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// TermScore returns the BM25 contribution of one query term.
+func (p Params) TermScore(idf float64, tf, docLen int, avgDocLen float64) float64 {
+	if tf <= 0 {
+		return 0
+	}
+	norm := 1.0
+	if avgDocLen > 0 {
+		norm = 1 - p.B + p.B*float64(docLen)/avgDocLen
+	}
+	f := float64(tf)
+	return idf * f * (p.K1 + 1) / (f + p.K1*norm)
+}
+
+// Normalize maps a raw BM25 score into [0, 1), so it can be compared with
+// MinScore and combined with cosine similarity.
+func Normalize(score float64) float64 {
+	if score <= 0 {
+		return 0
+	}
+	return score / (score + normConstant)
+}
+
+// Hit is a scored document.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// SortHits sorts hits by descending score, then by ID.
+func SortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+// Index is an in-memory inverted index. It is not safe for concurrent use.
+type Index struct {
+	params   Params
+	tokenize Tokenizer
+	// postings maps a term to the frequency of the term in each document.
+	postings map[string]map[string]int
+	// docTerms maps a document to its distinct terms, for removal.
+	docTerms map[string][]string
+	docLen   map[string]int
+	totalLen int
+}
+
+// NewIndex creates an empty index. A nil tokenize uses Tokenize.
+func NewIndex(params Params, tokenize Tokenizer) *Index {
+	if tokenize == nil {
+		tokenize = Tokenize
+	}
+	x := &Index{params: params, tokenize: tokenize}
+	x.Reset()
+	return x
+}
+
+// Reset removes every document.
+func (x *Index) Reset() {
+	x.postings = make(map[string]map[string]int)
+	x.docTerms = make(map[string][]string)
+	x.docLen = make(map[string]int)
+	x.totalLen = 0
+}
+
+// Add indexes text under id, replacing any previous text.
+func (x *Index) Add(id, text string) {
+	x.Remove(id)
+	tf, length := TermFrequencies(x.tokenize, text)
+	terms := make([]string, 0, len(tf))
+	for term, n := range tf {
+		docs, ok := x.postings[term]
+		if !ok {
+			docs = make(map[string]int)
+			x.postings[term] = docs
+		}
+		docs[id] = n
+		terms = append(terms, term)
+	}
+	x.docTerms[id] = terms
+	x.docLen[id] = length
+	x.totalLen += length
+}
+
+// Remove drops id from the index.
+func (x *Index) Remove(id string) {
+	terms, ok := x.docTerms[id]
+	if !ok {
+		return
+	}
+	for _, term := range terms {
+		docs := x.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	x.totalLen -= x.docLen[id]
+	delete(x.docTerms, id)
+	delete(x.docLen, id)
+}
+
+// Search returns the raw BM25 scores of documents matching at least one
+// query term, best first. Document frequencies count every indexed
+// document; accept, when set, only restricts which documents are returned.
+// A limit <= 0 returns every match.
+func (x *Index) Search(query string, limit int, accept func(id string) bool) []Hit {
+	n := len(x.docLen)
+	if n == 0 {
+		return nil
+	}
+	avgDocLen := float64(x.totalLen) / float64(n)
+	scores := make(map[string]float64)
+	for _, term := range QueryTerms(x.tokenize, query) {
+		docs := x.postings[term]
+		if len(docs) == 0 {
+			continue
+		}
+		idf := IDF(n, len(docs))
+		for id, tf := range docs {
+			if accept != nil && !accept(id) {
+				continue
+			}
+			scores[id] += x.params.TermScore(idf, tf, x.docLen[id], avgDocLen)
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	SortHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
@@ -0,0 +1,113 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package bm25
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"latin", "Hello, World! go-1.21", []string{"hello", "world", "go", "1", "21"}},
+		{"chinese", "向量检索", []string{"向", "向量", "量", "量检", "检", "检索", "索"}},
+		{"mixed", "用Go写Agent", []string{"用", "go", "写", "agent"}},
+		{"japanese", "ひらがな", []string{"ひ", "ひら", "ら", "らが", "が", "がな", "な"}},
+		{"accents", "Café naïve", []string{"café", "naïve"}},
+		{"empty", " ,. ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Tokenize(tt.text))
+		})
+	}
+	assert.Equal(t, []string{"a", "b"}, QueryTerms(Tokenize, "a b A"))
+}
+
+func TestIndex_Search(t *testing.T) {
+	x := NewIndex(DefaultParams(), nil)
+	x.Add("1", "the quick brown fox")
+	x.Add("2", "the lazy dog sleeps all day long in the sun")
+	x.Add("3", "quick quick fox")
+	x.Add("4", "向量数据库支持混合检索")
+	x.Add("5", "数据库")
+
+	hits := x.Search("quick fox", 0, nil)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "3", hits[0].ID, "higher term frequency in a shorter document ranks first")
+	assert.Equal(t, "1", hits[1].ID)
+
+	hits = x.Search("混合检索", 0, nil)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "4", hits[0].ID)
+	hits = x.Search("数据库", 0, nil)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "5", hits[0].ID)
+
+	assert.Empty(t, x.Search("missing", 0, nil))
+	hits = x.Search("the", 0, func(id string) bool { return id == "2" })
+	require.Len(t, hits, 1)
+	assert.Equal(t, "2", hits[0].ID)
+	assert.Len(t, x.Search("quick fox the", 1, nil), 1)
+
+	// Replacing and removing keep the statistics consistent.
+	x.Add("3", "slow turtle")
+	assert.Len(t, x.Search("quick", 0, nil), 1)
+	x.Remove("1")
+	x.Remove("missing")
+	assert.Empty(t, x.Search("quick", 0, nil))
+	_, ok := x.postings["quick"]
+	assert.False(t, ok)
+	assert.Equal(t, 4, len(x.docLen))
+	x.Reset()
+	assert.Nil(t, x.Search("turtle", 0, nil))
+}
+
+func TestScoring(t *testing.T) {
+	p := DefaultParams()
+	assert.Greater(t, IDF(100, 1), IDF(100, 50))
+	assert.Greater(t, p.TermScore(1, 2, 10, 10), p.TermScore(1, 1, 10, 10))
+	assert.Greater(t, p.TermScore(1, 1, 5, 10), p.TermScore(1, 1, 20, 10))
+	assert.Zero(t, p.TermScore(1, 0, 10, 10))
+	assert.Zero(t, Normalize(0))
+	assert.InDelta(t, 0.5, Normalize(1), 1e-9)
+	assert.Less(t, Normalize(100), 1.0)
+}
+
+func TestFusion(t *testing.T) {
+	dense := []Hit{{"a", 0.9}, {"b", 0.8}}
+	sparse := []Hit{{"b", 0.6}, {"c", 0.5}}
+
+	weighted := FuseWeighted(dense, sparse, 0.5, 0.5)
+	require.Len(t, weighted, 3)
+	assert.Equal(t, "b", weighted[0].ID)
+	assert.InDelta(t, 0.7, weighted[0].Score, 1e-9)
+	assert.InDelta(t, 0.4, weighted[0].Dense, 1e-9)
+	assert.InDelta(t, 0.3, weighted[0].Sparse, 1e-9)
+	assert.Equal(t, []string{"b", "a", "c"}, ids(weighted))
+
+	rrf := FuseRRF(dense, sparse, 60)
+	assert.Equal(t, []string{"b", "a", "c"}, ids(rrf))
+	assert.InDelta(t, 1.0/62+1.0/61, rrf[0].Score, 1e-12)
+	assert.InDelta(t, 1.0/61, rrf[1].Score, 1e-12)
+}
+
+func ids(fused []Fused) []string {
+	out := make([]string, 0, len(fused))
+	for _, f := range fused {
+		out = append(out, f.ID)
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package bm25
+
+import "sort"
+
+// Fused is a document ranked by hybrid search.
+type Fused struct {
+	ID string
+	// Score is the fused score.
+	Score float64
+	// Dense and Sparse are the parts of Score contributed by the vector and
+	// the keyword ranking.
+	Dense  float64
+	Sparse float64
+}
+
+// FuseWeighted ranks the union of both lists by
+// denseWeight*dense + sparseWeight*sparse. Scores must already be in
+// [0, 1]; a document missing from a list scores 0 there.
+func FuseWeighted(dense, sparse []Hit, denseWeight, sparseWeight float64) []Fused {
+	byID := make(map[string]*Fused)
+	get := func(id string) *Fused {
+		f, ok := byID[id]
+		if !ok {
+			f = &Fused{ID: id}
+			byID[id] = f
+		}
+		return f
+	}
+	for _, h := range dense {
+		get(h.ID).Dense = denseWeight * h.Score
+	}
+	for _, h := range sparse {
+		get(h.ID).Sparse = sparseWeight * h.Score
+	}
+	return sortFused(byID)
+}
+
+// FuseRRF ranks the union of both lists by Reciprocal Rank Fusion: each list
+// contributes 1/(k+rank), with ranks starting at 1. Lists must be sorted
+// best first.
+func FuseRRF(dense, sparse []Hit, k int) []Fused {
+	byID := make(map[string]*Fused)
+	get := func(id string) *Fused {
+		f, ok := byID[id]
+		if !ok {
+			f = &Fused{ID: id}
+			byID[id] = f
+		}
+		return f
+	}
+	for i, h := range dense {
+		get(h.ID).Dense = 1 / float64(k+i+1)
+	}
+	for i, h := range sparse {
+		get(h.ID).Sparse = 1 / float64(k+i+1)
+	}
+	return sortFused(byID)
+}
+
+func sortFused(byID map[string]*Fused) []Fused {
+	out := make([]Fused, 0, len(byID))
+	for _, f := range byID {
+		f.Score = f.Dense + f.Sparse
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
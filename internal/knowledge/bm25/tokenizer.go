@@ -0,0 +1,99 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package bm25 provides keyword search primitives shared by the embedded
+// vector stores: a tokenizer, BM25 scoring, an in-memory inverted index and
+// the fusion of vector and keyword rankings for hybrid search.
+package bm25
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenizer splits text into index terms.
+type Tokenizer func(text string) []string
+
+// Tokenize is the default Tokenizer. It lower-cases text and splits it on
+// anything that is not a letter or digit. Chinese, Japanese and Korean text
+// has no spaces between words, so a run of those characters yields each
+// character and each pair of adjacent characters, as Elasticsearch's
+// cjk_bigram filter does with output_unigrams enabled. Unigrams let single
+// character queries match; bigrams rank documents containing the query's
+// words above those that only share characters with it.
+func Tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+		cjk    []rune
+	)
+	flushWord := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	flushCJK := func() {
+		for i, r := range cjk {
+			tokens = append(tokens, string(r))
+			if i+1 < len(cjk) {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r):
+			flushCJK()
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// TermFrequencies tokenizes text and counts each term. It returns the
+// counts and the number of tokens.
+func TermFrequencies(tokenize Tokenizer, text string) (map[string]int, int) {
+	tokens := tokenize(text)
+	tf := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		if t != "" {
+			tf[t]++
+		}
+	}
+	return tf, len(tokens)
+}
+
+// QueryTerms tokenizes a query into distinct terms, in first-seen order.
+func QueryTerms(tokenize Tokenizer, query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range tokenize(query) {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
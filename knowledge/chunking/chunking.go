@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/internal/encoding"
@@ -267,6 +268,140 @@ func createChunk(originalDoc *document.Document, content string, chunkNumber int
 	}
 }
 
+// setSourceOffsets records where each chunk starts and ends in original, the
+// content of the document, in runes. Chunks are located in order in content,
+// the cleaned text they were split from, and may overlap; a chunk whose
+// content does not appear verbatim after the previous one gets no offsets.
+// The positions are then mapped back to original, and no offsets are recorded
+// when content cannot be aligned with it.
+func setSourceOffsets(original, content string, chunks []*document.Document) []*document.Document {
+	var (
+		starts, ends []int
+		aligned      = true
+	)
+	if original != content {
+		starts, ends, aligned = alignCleanedText(original, content)
+	}
+	if !aligned {
+		return chunks
+	}
+	from, lastByte, lastRune := 0, 0, 0
+	for _, chunk := range chunks {
+		if chunk.Content == "" || from > len(content) {
+			continue
+		}
+		idx := strings.Index(content[from:], chunk.Content)
+		if idx < 0 {
+			continue
+		}
+		start := from + idx
+		startRune := lastRune + utf8.RuneCountInString(content[lastByte:start])
+		endRune := startRune + utf8.RuneCountInString(chunk.Content)
+		lastByte, lastRune = start, startRune
+		from = start + 1
+		if starts != nil {
+			startRune, endRune = starts[startRune], ends[endRune-1]
+		}
+		chunk.Metadata[source.MetaChunkStartOffset] = startRune
+		chunk.Metadata[source.MetaChunkEndOffset] = endRune
+	}
+	return chunks
+}
+
+// alignCleanedText maps every rune of content, the result of cleaning
+// original, to the span of runes of original it comes from. Cleaning only
+// drops whitespace and turns "\r\n" and "\r" into "\n", so the texts are
+// aligned in one pass. It reports false when content is not such a cleaned
+// form of original, e.g. after an encoding conversion.
+func alignCleanedText(original, content string) (starts, ends []int, ok bool) {
+	orig := []rune(original)
+	starts = make([]int, 0, len(orig))
+	ends = make([]int, 0, len(orig))
+	i := 0
+	for _, r := range content {
+		matched := false
+		for i < len(orig) && !matched {
+			switch o := orig[i]; {
+			case o == r:
+				starts, ends = append(starts, i), append(ends, i+1)
+				matched = true
+				i++
+			case o == '\r' && r == '\n':
+				end := i + 1
+				if end < len(orig) && orig[end] == '\n' {
+					end++
+				}
+				starts, ends = append(starts, i), append(ends, end)
+				matched = true
+				i = end
+			case unicode.IsSpace(o):
+				i++
+			default:
+				return nil, nil, false
+			}
+		}
+		if !matched {
+			return nil, nil, false
+		}
+	}
+	return starts, ends, true
+}
+
+// applySourceOverlap prefixes every chunk after the first with the tail of
+// the previous chunk, joined by the separator found between them in content.
+func applySourceOverlap(
+	content string,
+	chunks []*document.Document,
+	overlap int,
+	chunkSize int,
+	trimWhitespace bool,
+) []*document.Document {
+	if len(chunks) <= 1 {
+		return chunks
+	}
+	rawContents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		rawContents[i] = chunk.Content
+	}
+	separators := sourceChunkSeparators(
+		content,
+		rawContents,
+		" ",
+		trimWhitespace,
+	)
+	overlappedChunks := []*document.Document{chunks[0]}
+	for i := 1; i < len(chunks); i++ {
+		// Create new metadata for overlapped chunk.
+		metadata := make(map[string]any)
+		for k, v := range chunks[i].Metadata {
+			metadata[k] = v
+		}
+
+		overlappedContent, actualOverlap := joinWithOverlapMode(
+			overlappedChunks[len(overlappedChunks)-1].Content,
+			chunks[i].Content,
+			overlap,
+			chunkSize,
+			separators[i],
+			trimWhitespace,
+		)
+		if actualOverlap > 0 {
+			metadata[source.MetaOverlappedContentSize] =
+				encoding.RuneCount(overlappedContent)
+		}
+		overlappedChunk := &document.Document{
+			ID:        chunks[i].ID,
+			Name:      chunks[i].Name,
+			Content:   overlappedContent,
+			Metadata:  metadata,
+			CreatedAt: chunks[i].CreatedAt,
+			UpdatedAt: chunks[i].UpdatedAt,
+		}
+		overlappedChunks = append(overlappedChunks, overlappedChunk)
+	}
+	return overlappedChunks
+}
+
 func joinWithOverlapMode(
 	previous string,
 	current string,
@@ -184,6 +184,66 @@ func TestCreateChunk(t *testing.T) {
 	}
 }
 
+func TestSetSourceOffsets(t *testing.T) {
+	content := "héllo wörld. héllo again."
+	doc := &document.Document{ID: "d", Content: content}
+	chunks := []*document.Document{
+		createChunk(doc, "héllo wörld.", 1),
+		createChunk(doc, "wörld. héllo", 2),
+		createChunk(doc, "not in the document", 3),
+		createChunk(doc, "héllo again.", 4),
+	}
+	setSourceOffsets(content, content, chunks)
+
+	runes := []rune(content)
+	for _, i := range []int{0, 1, 3} {
+		start := chunks[i].Metadata[source.MetaChunkStartOffset].(int)
+		end := chunks[i].Metadata[source.MetaChunkEndOffset].(int)
+		assert.Equal(t, chunks[i].Content, string(runes[start:end]), "chunk %d", i)
+	}
+	assert.Equal(t, 13, chunks[3].Metadata[source.MetaChunkStartOffset], "repeated text is located after the previous chunk")
+	assert.NotContains(t, chunks[2].Metadata, source.MetaChunkStartOffset)
+
+	fixed, err := NewFixedSizeChunking(WithChunkSize(10)).Chunk(&document.Document{ID: "f", Content: "0123456789abcdefghij"})
+	require.NoError(t, err)
+	require.Len(t, fixed, 2)
+	assert.Equal(t, 10, fixed[1].Metadata[source.MetaChunkStartOffset])
+	assert.Equal(t, 20, fixed[1].Metadata[source.MetaChunkEndOffset])
+}
+
+func TestSetSourceOffsets_CRLF(t *testing.T) {
+	original := "\r\n  first line\r\nsecond line\rthird líne  \r\n"
+	runes := []rune(original)
+	normalize := func(s string) string {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		return strings.ReplaceAll(s, "\r", "\n")
+	}
+	for _, strategy := range []Strategy{
+		NewFixedSizeChunking(WithChunkSize(12), WithOverlap(0)),
+		NewFixedSizeChunking(WithChunkSize(12), WithOverlap(0), WithWhitespaceTrimming()),
+		NewRecursiveChunking(WithRecursiveChunkSize(12), WithRecursiveOverlap(0), WithRecursiveWhitespaceTrimming()),
+	} {
+		chunks, err := strategy.Chunk(&document.Document{ID: "crlf", Content: original})
+		require.NoError(t, err)
+		require.NotEmpty(t, chunks)
+		for _, chunk := range chunks {
+			start, ok := chunk.Metadata[source.MetaChunkStartOffset].(int)
+			require.True(t, ok, "chunk %q has no offsets", chunk.Content)
+			end := chunk.Metadata[source.MetaChunkEndOffset].(int)
+			cited := strings.TrimSpace(normalize(string(runes[start:end])))
+			assert.Equal(t, strings.TrimSpace(chunk.Content), cited)
+		}
+	}
+
+	chunks := []*document.Document{createChunk(&document.Document{ID: "d"}, "b", 1)}
+	setSourceOffsets("a\r\nb", "a\nb", chunks)
+	assert.Equal(t, 3, chunks[0].Metadata[source.MetaChunkStartOffset])
+	assert.Equal(t, 4, chunks[0].Metadata[source.MetaChunkEndOffset])
+	chunks = []*document.Document{createChunk(&document.Document{ID: "d"}, "b", 1)}
+	setSourceOffsets("converted", "a\nb", chunks)
+	assert.NotContains(t, chunks[0].Metadata, source.MetaChunkStartOffset)
+}
+
 func TestJoinWithOverlap(t *testing.T) {
 	tests := []struct {
 		name            string
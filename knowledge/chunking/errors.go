@@ -26,4 +26,11 @@ var (
 
 	// ErrNilDocument indicates that a nil document was provided.
 	ErrNilDocument = errors.New("document cannot be nil")
+
+	// ErrInvalidMinChunkSize indicates that the minimum chunk size is negative
+	// or larger than the chunk size.
+	ErrInvalidMinChunkSize = errors.New("min chunk size must be between 0 and chunk size")
+
+	// ErrNilEmbedder indicates that a strategy requiring an embedder has none.
+	ErrNilEmbedder = errors.New("embedder cannot be nil")
 )
@@ -96,7 +96,7 @@ func (f *FixedSizeChunking) Chunk(doc *document.Document) ([]*document.Document,
 	// If content is smaller than chunk size, return as single chunk.
 	if contentLength <= f.chunkSize {
 		chunk := createChunk(doc, content, 1)
-		return []*document.Document{chunk}, nil
+		return setSourceOffsets(doc.Content, content, []*document.Document{chunk}), nil
 	}
 
 	coreSize := f.chunkSize
@@ -196,7 +196,7 @@ func (f *FixedSizeChunking) Chunk(doc *document.Document) ([]*document.Document,
 		}
 		chunks = append(chunks, chunk)
 	}
-	return chunks, nil
+	return setSourceOffsets(doc.Content, content, chunks), nil
 }
 
 type fixedTextChunk struct {
@@ -107,7 +107,7 @@ func (m *MarkdownChunking) Chunk(doc *document.Document) ([]*document.Document,
 	// If content is small enough, return as single chunk.
 	if encoding.RuneCount(content) <= m.chunkSize {
 		chunk := m.createMarkdownChunk(doc, content, 1)
-		return []*document.Document{chunk}, nil
+		return setSourceOffsets(doc.Content, content, []*document.Document{chunk}), nil
 	}
 
 	// Parse Markdown structure, then pack adjacent semantic units.
@@ -134,7 +134,7 @@ func (m *MarkdownChunking) Chunk(doc *document.Document) ([]*document.Document,
 		chunks = m.applyOverlap(content, chunks)
 	}
 
-	return chunks, nil
+	return setSourceOffsets(doc.Content, content, chunks), nil
 }
 
 // headerSection represents a section split by a specific header level.
@@ -15,7 +15,6 @@ import (
 
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/internal/encoding"
-	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
 )
 
 // RecursiveChunking implements a recursive chunking strategy that uses a hierarchy of separators.
@@ -127,7 +126,7 @@ func (r *RecursiveChunking) Chunk(doc *document.Document) ([]*document.Document,
 	if r.overlap > 0 {
 		chunks = r.applyOverlap(content, chunks)
 	}
-	return chunks, nil
+	return setSourceOffsets(doc.Content, content, chunks), nil
 }
 
 // recursiveSplit is the core recursive function that splits text using separator hierarchy.
@@ -288,48 +287,5 @@ func (r *RecursiveChunking) applyOverlap(
 	content string,
 	chunks []*document.Document,
 ) []*document.Document {
-	if len(chunks) <= 1 {
-		return chunks
-	}
-	rawContents := make([]string, len(chunks))
-	for i, chunk := range chunks {
-		rawContents[i] = chunk.Content
-	}
-	separators := sourceChunkSeparators(
-		content,
-		rawContents,
-		" ",
-		r.trimWhitespace,
-	)
-	overlappedChunks := []*document.Document{chunks[0]}
-	for i := 1; i < len(chunks); i++ {
-		// Create new metadata for overlapped chunk.
-		metadata := make(map[string]any)
-		for k, v := range chunks[i].Metadata {
-			metadata[k] = v
-		}
-
-		overlappedContent, actualOverlap := joinWithOverlapMode(
-			overlappedChunks[len(overlappedChunks)-1].Content,
-			chunks[i].Content,
-			r.overlap,
-			r.chunkSize,
-			separators[i],
-			r.trimWhitespace,
-		)
-		if actualOverlap > 0 {
-			metadata[source.MetaOverlappedContentSize] =
-				encoding.RuneCount(overlappedContent)
-		}
-		overlappedChunk := &document.Document{
-			ID:        chunks[i].ID,
-			Name:      chunks[i].Name,
-			Content:   overlappedContent,
-			Metadata:  metadata,
-			CreatedAt: chunks[i].CreatedAt,
-			UpdatedAt: chunks[i].UpdatedAt,
-		}
-		overlappedChunks = append(overlappedChunks, overlappedChunk)
-	}
-	return overlappedChunks
+	return applySourceOverlap(content, chunks, r.overlap, r.chunkSize, r.trimWhitespace)
 }
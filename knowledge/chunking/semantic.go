@@ -0,0 +1,422 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package chunking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/embedder"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/internal/encoding"
+)
+
+var (
+	defaultSemanticBufferSize           = 1
+	defaultSemanticBreakpointPercentile = 95.0
+	defaultSemanticBatchSize            = 32
+)
+
+// SemanticChunking implements a chunking strategy that places chunk
+// boundaries where the topic changes. Sentences are embedded together with
+// their neighbours, and a breakpoint is placed between two sentences whose
+// embeddings are less similar than the rest of the document, or than a
+// fixed threshold. Chunk size limits still apply: small topics are merged
+// and long topics are split at sentence boundaries.
+type SemanticChunking struct {
+	embedder             embedder.Embedder
+	chunkSize            int
+	minChunkSize         int
+	overlap              int
+	bufferSize           int
+	breakpointPercentile float64
+	breakpointThreshold  float64
+	useThreshold         bool
+	batchSize            int
+	trimWhitespace       bool
+}
+
+// SemanticOption represents a functional option for configuring SemanticChunking.
+type SemanticOption func(*SemanticChunking)
+
+// WithSemanticChunkSize sets the maximum size of each chunk in Unicode runes.
+func WithSemanticChunkSize(size int) SemanticOption {
+	return func(sc *SemanticChunking) {
+		sc.chunkSize = size
+	}
+}
+
+// WithSemanticMinChunkSize sets the minimum size of a chunk in Unicode runes.
+// A topic smaller than this is merged with the following one.
+func WithSemanticMinChunkSize(size int) SemanticOption {
+	return func(sc *SemanticChunking) {
+		sc.minChunkSize = size
+	}
+}
+
+// WithSemanticOverlap sets the maximum number of Unicode runes to overlap between chunks.
+func WithSemanticOverlap(overlap int) SemanticOption {
+	return func(sc *SemanticChunking) {
+		sc.overlap = overlap
+	}
+}
+
+// WithSemanticBufferSize sets how many neighbouring sentences on each side
+// are embedded together with a sentence. Larger windows smooth out noise
+// from short sentences. Negative values are ignored.
+func WithSemanticBufferSize(size int) SemanticOption {
+	return func(sc *SemanticChunking) {
+		if size >= 0 {
+			sc.bufferSize = size
+		}
+	}
+}
+
+// WithSemanticBreakpointPercentile places breakpoints where the distance
+// between adjacent windows exceeds the given percentile (0-100] of all
+// distances in the document. This is the default, with the 95th percentile.
+func WithSemanticBreakpointPercentile(percentile float64) SemanticOption {
+	return func(sc *SemanticChunking) {
+		if percentile > 0 && percentile <= 100 {
+			sc.breakpointPercentile = percentile
+			sc.useThreshold = false
+		}
+	}
+}
+
+// WithSemanticBreakpointThreshold places breakpoints where the cosine
+// similarity between adjacent windows drops below threshold, instead of
+// using a percentile.
+func WithSemanticBreakpointThreshold(threshold float64) SemanticOption {
+	return func(sc *SemanticChunking) {
+		sc.breakpointThreshold = threshold
+		sc.useThreshold = true
+	}
+}
+
+// WithSemanticBatchSize sets how many windows are embedded per request when
+// the embedder implements embedder.BatchEmbedder. Values <= 0 are ignored.
+func WithSemanticBatchSize(size int) SemanticOption {
+	return func(sc *SemanticChunking) {
+		if size > 0 {
+			sc.batchSize = size
+		}
+	}
+}
+
+// WithSemanticWhitespaceTrimming enables the legacy behavior that trims
+// leading and trailing whitespace from the document, every line, and chunk
+// boundaries.
+func WithSemanticWhitespaceTrimming() SemanticOption {
+	return func(sc *SemanticChunking) {
+		sc.trimWhitespace = true
+	}
+}
+
+// NewSemanticChunking creates a new semantic chunking strategy that embeds
+// sentences with emb.
+func NewSemanticChunking(emb embedder.Embedder, opts ...SemanticOption) *SemanticChunking {
+	sc := &SemanticChunking{
+		embedder:             emb,
+		chunkSize:            defaultChunkSize,
+		overlap:              defaultOverlap,
+		bufferSize:           defaultSemanticBufferSize,
+		breakpointPercentile: defaultSemanticBreakpointPercentile,
+		batchSize:            defaultSemanticBatchSize,
+	}
+	// Apply options.
+	for _, opt := range opts {
+		opt(sc)
+	}
+	return sc
+}
+
+// Chunk splits the document at topic boundaries.
+func (s *SemanticChunking) Chunk(doc *document.Document) ([]*document.Document, error) {
+	return s.ChunkWithContext(context.Background(), doc)
+}
+
+// ChunkWithContext splits the document at topic boundaries, using ctx for
+// the embedding requests.
+func (s *SemanticChunking) ChunkWithContext(
+	ctx context.Context,
+	doc *document.Document,
+) ([]*document.Document, error) {
+	if err := validateChunkConfig(s.chunkSize, s.overlap); err != nil {
+		return nil, err
+	}
+	if s.minChunkSize < 0 || s.minChunkSize > s.chunkSize {
+		return nil, ErrInvalidMinChunkSize
+	}
+	if s.embedder == nil {
+		return nil, ErrNilEmbedder
+	}
+	if doc == nil {
+		return nil, ErrNilDocument
+	}
+
+	if doc.IsEmpty() {
+		return nil, ErrEmptyDocument
+	}
+
+	content := cleanTextWithWhitespaceTrimming(
+		doc.Content,
+		s.trimWhitespace,
+	)
+	if isBlankText(content) {
+		return nil, ErrEmptyDocument
+	}
+	coreSize := s.chunkSize
+	if s.overlap > 0 {
+		coreSize = s.chunkSize - s.overlap
+	}
+
+	sentences := splitSentences(content, coreSize)
+	breakpoints, err := s.findBreakpoints(ctx, sentences)
+	if err != nil {
+		return nil, err
+	}
+	textChunks := s.groupSentences(sentences, breakpoints, s.chunkSize, coreSize)
+	if !s.trimWhitespace {
+		textChunks = coalesceWhitespaceChunks(
+			textChunks,
+			s.chunkSize,
+			coreSize,
+		)
+	}
+	chunks := make([]*document.Document, 0, len(textChunks))
+	for _, chunkText := range textChunks {
+		if s.trimWhitespace {
+			chunkText = strings.TrimSpace(chunkText)
+		}
+		if isBlankText(chunkText) {
+			continue
+		}
+		chunks = append(chunks, createChunk(doc, chunkText, len(chunks)+1))
+	}
+
+	// Apply overlap if specified.
+	if s.overlap > 0 {
+		chunks = applySourceOverlap(content, chunks, s.overlap, s.chunkSize, s.trimWhitespace)
+	}
+	return setSourceOffsets(doc.Content, content, chunks), nil
+}
+
+// findBreakpoints reports, for each sentence but the last, whether a topic
+// boundary follows it.
+func (s *SemanticChunking) findBreakpoints(
+	ctx context.Context,
+	sentences []string,
+) ([]bool, error) {
+	if len(sentences) < 2 {
+		return nil, nil
+	}
+
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		from := max(0, i-s.bufferSize)
+		to := min(len(sentences), i+s.bufferSize+1)
+		windows[i] = strings.TrimSpace(strings.Join(sentences[from:to], ""))
+	}
+	embeddings, err := s.embed(ctx, windows)
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity(embeddings[i], embeddings[i+1])
+	}
+
+	cutoff := 1 - s.breakpointThreshold
+	if !s.useThreshold {
+		cutoff = percentile(distances, s.breakpointPercentile)
+	}
+	breakpoints := make([]bool, len(distances))
+	for i, distance := range distances {
+		breakpoints[i] = distance > cutoff
+	}
+	return breakpoints, nil
+}
+
+// embed embeds texts in batches when the embedder supports it, and one by
+// one otherwise.
+func (s *SemanticChunking) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	if batchEmbedder, ok := s.embedder.(embedder.BatchEmbedder); ok {
+		for start := 0; start < len(texts); start += s.batchSize {
+			batch := texts[start:min(len(texts), start+s.batchSize)]
+			vectors, err := batchEmbedder.GetEmbeddings(ctx, batch)
+			if err != nil {
+				return nil, fmt.Errorf("semantic chunking: embed sentences: %w", err)
+			}
+			if len(vectors) != len(batch) {
+				return nil, fmt.Errorf(
+					"semantic chunking: embedder returned %d embeddings for %d sentences",
+					len(vectors), len(batch),
+				)
+			}
+			embeddings = append(embeddings, vectors...)
+		}
+	} else {
+		for _, text := range texts {
+			vector, err := s.embedder.GetEmbedding(ctx, text)
+			if err != nil {
+				return nil, fmt.Errorf("semantic chunking: embed sentence: %w", err)
+			}
+			embeddings = append(embeddings, vector)
+		}
+	}
+	for _, vector := range embeddings {
+		if len(vector) == 0 {
+			return nil, errors.New("semantic chunking: received empty embedding")
+		}
+	}
+	return embeddings, nil
+}
+
+// groupSentences joins sentences into chunks. A chunk ends at a breakpoint
+// once it reaches the minimum size, or before a sentence that would not fit.
+func (s *SemanticChunking) groupSentences(
+	sentences []string,
+	breakpoints []bool,
+	firstChunkSize int,
+	nextChunkSize int,
+) []string {
+	var chunks []string
+	var current strings.Builder
+	currentSize := 0
+
+	flush := func() {
+		if currentSize > 0 {
+			chunks = append(chunks, current.String())
+		}
+		current.Reset()
+		currentSize = 0
+	}
+
+	for i, sentence := range sentences {
+		chunkSize := nextChunkSize
+		if len(chunks) == 0 {
+			chunkSize = firstChunkSize
+		}
+		sentenceSize := encoding.RuneCount(sentence)
+		if currentSize > 0 && currentSize+sentenceSize > chunkSize {
+			flush()
+		}
+		current.WriteString(sentence)
+		currentSize += sentenceSize
+		if i < len(breakpoints) && breakpoints[i] && currentSize >= s.minChunkSize {
+			flush()
+		}
+	}
+
+	// Merge a trailing chunk below the minimum size into the previous one
+	// when both fit.
+	if currentSize > 0 && currentSize < s.minChunkSize && len(chunks) > 0 {
+		last := len(chunks) - 1
+		lastSize := nextChunkSize
+		if last == 0 {
+			lastSize = firstChunkSize
+		}
+		if encoding.RuneCount(chunks[last])+currentSize <= lastSize {
+			chunks[last] += current.String()
+			current.Reset()
+			currentSize = 0
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitSentences splits content into sentences that concatenate back to
+// content. Trailing whitespace stays with its sentence, and sentences longer
+// than maxSize are split at natural text boundaries.
+func splitSentences(content string, maxSize int) []string {
+	runes := []rune(content)
+	var sentences []string
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\n' && !isSentenceBoundary(runes, i) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+		sentences = append(sentences, string(runes[start:end]))
+		start = end
+		i = end - 1
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+
+	var result []string
+	for _, sentence := range sentences {
+		if isBlankText(sentence) && len(result) > 0 {
+			result[len(result)-1] += sentence
+			continue
+		}
+		for encoding.RuneCount(sentence) > maxSize {
+			prefix, remaining := splitTextAtNaturalBoundaryWithWhitespaceTrimming(
+				sentence,
+				maxSize,
+				false,
+			)
+			result = append(result, prefix)
+			sentence = remaining
+		}
+		if sentence != "" {
+			result = append(result, sentence)
+		}
+	}
+	return result
+}
+
+// percentile returns the p-th percentile of values with linear
+// interpolation between the closest ranks.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 when
+// their lengths differ or either is a zero vector.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,236 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package chunking
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/embedder"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/internal/encoding"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+// topicEmbedder embeds text as counts of topic keywords, so sentences about
+// the same topic are similar and sentences about different topics are not.
+type topicEmbedder struct {
+	calls int
+	err   error
+}
+
+var topicKeywords = []string{"cat", "car", "rain", "猫"}
+
+func (e *topicEmbedder) GetEmbedding(_ context.Context, text string) ([]float64, error) {
+	e.calls++
+	if e.err != nil {
+		return nil, e.err
+	}
+	vector := make([]float64, len(topicKeywords)+1)
+	for i, keyword := range topicKeywords {
+		vector[i] = float64(strings.Count(strings.ToLower(text), keyword))
+	}
+	// A small constant keeps keyword-free text from being a zero vector.
+	vector[len(topicKeywords)] = 0.01
+	return vector, nil
+}
+
+func (e *topicEmbedder) GetEmbeddingWithUsage(ctx context.Context, text string) ([]float64, map[string]any, error) {
+	vector, err := e.GetEmbedding(ctx, text)
+	return vector, nil, err
+}
+
+func (e *topicEmbedder) GetDimensions() int { return len(topicKeywords) + 1 }
+
+type batchTopicEmbedder struct {
+	topicEmbedder
+	batches []int
+}
+
+func (e *batchTopicEmbedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	e.batches = append(e.batches, len(texts))
+	vectors := make([][]float64, 0, len(texts))
+	for _, text := range texts {
+		vector, err := e.topicEmbedder.GetEmbedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+var _ embedder.BatchEmbedder = (*batchTopicEmbedder)(nil)
+
+const threeTopics = "The cat sleeps. The cat purrs. A cat hunts mice. " +
+	"The car is fast. The car needs fuel. " +
+	"Rain falls today. Rain will stop soon."
+
+func chunkContents(chunks []*document.Document) []string {
+	contents := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		contents = append(contents, chunk.Content)
+	}
+	return contents
+}
+
+func TestSemanticChunking_Errors(t *testing.T) {
+	emb := &topicEmbedder{}
+	_, err := NewSemanticChunking(emb).Chunk(nil)
+	assert.ErrorIs(t, err, ErrNilDocument)
+	_, err = NewSemanticChunking(emb).Chunk(&document.Document{})
+	assert.ErrorIs(t, err, ErrEmptyDocument)
+	_, err = NewSemanticChunking(nil).Chunk(&document.Document{Content: "text"})
+	assert.ErrorIs(t, err, ErrNilEmbedder)
+	_, err = NewSemanticChunking(emb, WithSemanticChunkSize(0)).Chunk(&document.Document{Content: "text"})
+	assert.ErrorIs(t, err, ErrInvalidChunkSize)
+	_, err = NewSemanticChunking(emb, WithSemanticChunkSize(10), WithSemanticMinChunkSize(20)).
+		Chunk(&document.Document{Content: "text"})
+	assert.ErrorIs(t, err, ErrInvalidMinChunkSize)
+
+	failing := &topicEmbedder{err: errors.New("boom")}
+	_, err = NewSemanticChunking(failing).Chunk(&document.Document{Content: threeTopics})
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestSemanticChunking_ThresholdBreakpoints(t *testing.T) {
+	emb := &topicEmbedder{}
+	sc := NewSemanticChunking(emb,
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointThreshold(0.5),
+	)
+	doc := &document.Document{ID: "notes", Content: threeTopics, Metadata: map[string]any{"k": "v"}}
+	chunks, err := sc.Chunk(doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"The cat sleeps. The cat purrs. A cat hunts mice. ",
+		"The car is fast. The car needs fuel. ",
+		"Rain falls today. Rain will stop soon.",
+	}, chunkContents(chunks))
+	assert.Equal(t, threeTopics, strings.Join(chunkContents(chunks), ""), "chunks preserve the source text")
+	assert.Equal(t, 7, emb.calls)
+	for i, chunk := range chunks {
+		assert.Equal(t, "notes_"+string(rune('1'+i)), chunk.ID)
+		assert.Equal(t, i+1, chunk.Metadata[source.MetaChunkIndex])
+		assert.Equal(t, encoding.RuneCount(chunk.Content), chunk.Metadata[source.MetaChunkSize])
+		assert.Equal(t, "v", chunk.Metadata["k"])
+	}
+}
+
+func TestSemanticChunking_PercentileBreakpoints(t *testing.T) {
+	// The default window embeds each sentence with its neighbours, and the
+	// default 95th percentile only breaks at the sharpest topic change.
+	emb := &batchTopicEmbedder{}
+	chunks, err := NewSemanticChunking(emb, WithSemanticBatchSize(3)).
+		Chunk(&document.Document{Content: "The cat sleeps. The cat purrs. The car is fast. The car needs fuel."})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"The cat sleeps. The cat purrs. ",
+		"The car is fast. The car needs fuel.",
+	}, chunkContents(chunks))
+	assert.Equal(t, []int{3, 1}, emb.batches, "the batch embedder is used in batches")
+	assert.Equal(t, 4, emb.calls)
+
+	// Lower percentiles break more often.
+	chunks, err = NewSemanticChunking(&topicEmbedder{},
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointPercentile(50),
+	).Chunk(&document.Document{Content: threeTopics})
+	require.NoError(t, err)
+	assert.Len(t, chunks, 3)
+
+	// Uniform text has no breakpoints.
+	chunks, err = NewSemanticChunking(&topicEmbedder{}).
+		Chunk(&document.Document{Content: "猫很可爱。猫喜欢睡觉。猫会抓老鼠。"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"猫很可爱。猫喜欢睡觉。猫会抓老鼠。"}, chunkContents(chunks))
+}
+
+func TestSemanticChunking_SizeLimits(t *testing.T) {
+	// Topics below the minimum size are merged with the next one.
+	chunks, err := NewSemanticChunking(&topicEmbedder{},
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointThreshold(0.5),
+		WithSemanticMinChunkSize(40),
+	).Chunk(&document.Document{Content: threeTopics})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"The cat sleeps. The cat purrs. A cat hunts mice. ",
+		"The car is fast. The car needs fuel. Rain falls today. Rain will stop soon.",
+	}, chunkContents(chunks))
+
+	// A small trailing topic is merged into the previous chunk.
+	chunks, err = NewSemanticChunking(&topicEmbedder{},
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointThreshold(0.5),
+		WithSemanticMinChunkSize(20),
+	).Chunk(&document.Document{Content: "The cat sleeps. The cat purrs. Rain falls."})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"The cat sleeps. The cat purrs. Rain falls."}, chunkContents(chunks))
+
+	// Long topics are split at sentence boundaries, and unbroken sentences
+	// at natural text boundaries.
+	chunks, err = NewSemanticChunking(&topicEmbedder{},
+		WithSemanticChunkSize(35),
+		WithSemanticBreakpointThreshold(0.5),
+	).Chunk(&document.Document{Content: threeTopics + " " + strings.Repeat("x", 50)})
+	require.NoError(t, err)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, encoding.RuneCount(chunk.Content), 35)
+	}
+	assert.Equal(t, "The cat sleeps. The cat purrs. ", chunks[0].Content)
+	assert.Equal(t, threeTopics+" "+strings.Repeat("x", 50), strings.Join(chunkContents(chunks), ""))
+}
+
+func TestSemanticChunking_OverlapAndWhitespace(t *testing.T) {
+	chunks, err := NewSemanticChunking(&topicEmbedder{},
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointThreshold(0.5),
+		WithSemanticChunkSize(80),
+		WithSemanticOverlap(10),
+	).Chunk(&document.Document{Content: threeTopics})
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "mice. The car is fast. The car needs fuel. ", chunks[1].Content)
+	assert.Equal(t, encoding.RuneCount(chunks[1].Content), chunks[1].Metadata[source.MetaOverlappedContentSize])
+	assert.NotContains(t, chunks[0].Metadata, source.MetaOverlappedContentSize)
+
+	content := "  The cat sleeps.\n\n  The car is fast.  "
+	chunks, err = NewSemanticChunking(&topicEmbedder{},
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointThreshold(0.5),
+	).Chunk(&document.Document{Content: content})
+	require.NoError(t, err)
+	assert.Equal(t, content, strings.Join(chunkContents(chunks), ""), "whitespace is preserved by default")
+
+	chunks, err = NewSemanticChunking(&topicEmbedder{},
+		WithSemanticBufferSize(0),
+		WithSemanticBreakpointThreshold(0.5),
+		WithSemanticWhitespaceTrimming(),
+	).Chunk(&document.Document{Content: content})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"The cat sleeps.", "The car is fast."}, chunkContents(chunks))
+}
+
+func TestSemanticHelpers(t *testing.T) {
+	assert.Equal(t, []string{"A b. ", "C?\n\n", "D"}, splitSentences("A b. C?\n\nD", 100))
+	assert.Equal(t, []string{"一。", "二！"}, splitSentences("一。二！", 100))
+	assert.InDelta(t, 2.5, percentile([]float64{4, 1, 3, 2}, 50), 1e-9)
+	assert.InDelta(t, 4, percentile([]float64{4, 1, 3, 2}, 100), 1e-9)
+	assert.Zero(t, percentile(nil, 50))
+	assert.InDelta(t, 1, cosineSimilarity([]float64{1, 1}, []float64{2, 2}), 1e-9)
+	assert.Zero(t, cosineSimilarity([]float64{1}, []float64{1, 0}))
+	assert.Zero(t, cosineSimilarity([]float64{0, 0}, []float64{1, 0}))
+}
@@ -0,0 +1,240 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package citation ties final agent answers back to the knowledge chunks
+// they are based on.
+//
+// A Tracker assigns citation IDs to the chunks returned by knowledge search
+// tools. The tools show them to the model as markers such as "[3]", and the
+// Plugin parses the markers out of the final response into an Info attached
+// to the event under ExtensionKey. An optional Verifier flags sentences that
+// no cited chunk supports:
+//
+//	tracker := citation.NewTracker()
+//	searchTool := tool.NewKnowledgeSearchTool(kb, tool.WithCitationTracker(tracker))
+//	p := citation.NewPlugin(tracker, citation.WithVerifier(citation.NewLexicalVerifier()))
+//	r := runner.NewRunner("app", agent, runner.WithPlugins(p))
+package citation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+// ExtensionKey is the event extension holding the Info of a final response.
+const ExtensionKey = "trpc_agent.citations"
+
+// markerPattern matches citation markers such as "[3]" or "[1, 4]".
+var markerPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// trailingMarker matches markers at the start of the rest of a sentence.
+var trailingMarker = regexp.MustCompile(`^[ \t]*\[\d+(?:\s*,\s*\d+)*\]`)
+
+// Span is a half-open range of rune offsets.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Chunk is a retrieved knowledge chunk that can be cited.
+type Chunk struct {
+	// ID is the citation ID shown to the model, e.g. "3" for marker "[3]".
+	ID string `json:"id"`
+	// DocumentID is the ID of the chunk in the vector store.
+	DocumentID string `json:"document_id"`
+	// Source is the URI of the source document, e.g. a file path or URL.
+	Source string `json:"source,omitempty"`
+	// SourceName is the name of the knowledge source.
+	SourceName string `json:"source_name,omitempty"`
+	// ChunkIndex is the 1-based index of the chunk in the source document.
+	ChunkIndex int `json:"chunk_index,omitempty"`
+	// Offsets is the position of the chunk in the source document, when
+	// the chunking strategy records it.
+	Offsets *Span `json:"offsets,omitempty"`
+	// Content is the chunk text, kept for verification.
+	Content string `json:"-"`
+}
+
+// Citation is a chunk cited by a response.
+type Citation struct {
+	Chunk
+	// Markers are the positions of the markers citing the chunk in the
+	// response.
+	Markers []Span `json:"markers"`
+}
+
+// Sentence is a sentence of a response.
+type Sentence struct {
+	// Text is the sentence without citation markers.
+	Text string `json:"text"`
+	// Span is the position of the sentence in the response.
+	Span Span `json:"span"`
+	// CitationIDs are the IDs cited by the sentence.
+	CitationIDs []string `json:"citation_ids,omitempty"`
+}
+
+// Verification is the result of verifying a response.
+type Verification struct {
+	// Verifier is the name of the verifier.
+	Verifier string `json:"verifier"`
+	// Sentences is the number of sentences checked.
+	Sentences int `json:"sentences"`
+	// Unsupported are the sentences no cited chunk supports.
+	Unsupported []Sentence `json:"unsupported,omitempty"`
+}
+
+// Info is attached to a final response event under ExtensionKey.
+type Info struct {
+	// Citations are the cited chunks in order of first citation.
+	Citations []Citation `json:"citations,omitempty"`
+	// Unresolved are cited IDs that match no retrieved chunk.
+	Unresolved []string `json:"unresolved,omitempty"`
+	// Verification is set when the plugin has a Verifier.
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+// Marker returns the marker citing id, e.g. "[3]".
+func Marker(id string) string {
+	return "[" + id + "]"
+}
+
+// chunkFromDocument builds the Chunk of a retrieved document.
+func chunkFromDocument(id string, doc *document.Document) Chunk {
+	c := Chunk{ID: id, DocumentID: doc.ID, Content: doc.Content}
+	meta := doc.Metadata
+	c.Source = firstString(meta, source.MetaURI, source.MetaFilePath, source.MetaURL, source.MetaSource)
+	c.SourceName, _ = meta[source.MetaSourceName].(string)
+	c.ChunkIndex, _ = toInt(meta[source.MetaChunkIndex])
+	start, okStart := toInt(meta[source.MetaChunkStartOffset])
+	end, okEnd := toInt(meta[source.MetaChunkEndOffset])
+	if okStart && okEnd {
+		c.Offsets = &Span{Start: start, End: end}
+	}
+	return c
+}
+
+func firstString(meta map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := meta[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// toInt converts integer metadata, which may have been decoded from JSON as
+// float64 by the vector store.
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// marker is a citation marker found in a response.
+type marker struct {
+	ids  []string
+	span Span
+}
+
+// parseMarkers returns the citation markers of response in order.
+func parseMarkers(response string) []marker {
+	var markers []marker
+	for _, loc := range markerPattern.FindAllStringSubmatchIndex(response, -1) {
+		var ids []string
+		for _, id := range strings.Split(response[loc[2]:loc[3]], ",") {
+			ids = append(ids, strings.TrimSpace(id))
+		}
+		markers = append(markers, marker{ids: ids, span: runeSpan(response, loc[0], loc[1])})
+	}
+	return markers
+}
+
+// SplitSentences splits response into sentences at sentence punctuation
+// followed by a space, and at line breaks. Citation markers stay with the
+// sentence they follow and are removed from the sentence text.
+func SplitSentences(response string) []Sentence {
+	var sentences []Sentence
+	start := 0
+	add := func(end int) {
+		raw := response[start:end]
+		text := strings.TrimSpace(markerPattern.ReplaceAllString(raw, ""))
+		if text != "" {
+			lead := len(raw) - len(strings.TrimLeft(raw, " \t\n"))
+			trail := len(strings.TrimRight(raw, " \t\n"))
+			s := Sentence{Text: strings.Join(strings.Fields(text), " "), Span: runeSpan(response, start+lead, start+trail)}
+			for _, m := range parseMarkers(raw) {
+				s.CitationIDs = appendUnique(s.CitationIDs, m.ids...)
+			}
+			sentences = append(sentences, s)
+		}
+		start = end
+	}
+	skip := 0
+	for i, r := range response {
+		if i < skip {
+			continue
+		}
+		switch {
+		case r == '\n':
+			add(i + 1)
+		case strings.ContainsRune(".!?。！？", r):
+			end := i + utf8.RuneLen(r)
+			// Keep markers right after the punctuation, e.g. "done. [2]",
+			// with the sentence they follow.
+			for loc := trailingMarker.FindStringIndex(response[end:]); loc != nil; loc = trailingMarker.FindStringIndex(response[end:]) {
+				end += loc[1]
+			}
+			// Western punctuation only ends a sentence before a space, so
+			// that "3.5" or "e.g.x" are kept together.
+			if r > utf8.RuneSelf || end == len(response) || response[end] == ' ' || response[end] == '\n' {
+				add(end)
+				skip = end
+			}
+		}
+	}
+	add(len(response))
+	return sentences
+}
+
+// runeSpan converts the byte range [start, end) of s to rune offsets.
+func runeSpan(s string, start, end int) Span {
+	runeStart := utf8.RuneCountInString(s[:start])
+	return Span{Start: runeStart, End: runeStart + utf8.RuneCountInString(s[start:end])}
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,243 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package citation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+func sessionContext(id string) (context.Context, *agent.Invocation) {
+	inv := agent.NewInvocation(agent.WithInvocationSession(session.NewSession("app", "user", id)))
+	return agent.NewInvocationContext(context.Background(), inv), inv
+}
+
+func TestTracker_StableIDs(t *testing.T) {
+	tracker := NewTracker()
+	ctx, _ := sessionContext("s1")
+	doc := &document.Document{ID: "a", Content: "alpha", Metadata: map[string]any{
+		source.MetaURI:              "file:///docs/a.md",
+		source.MetaSourceName:       "docs",
+		source.MetaChunkIndex:       2,
+		source.MetaChunkStartOffset: float64(10),
+		source.MetaChunkEndOffset:   float64(15),
+	}}
+
+	c := tracker.Cite(ctx, doc)
+	assert.Equal(t, Chunk{
+		ID: "1", DocumentID: "a", Source: "file:///docs/a.md", SourceName: "docs",
+		ChunkIndex: 2, Offsets: &Span{Start: 10, End: 15}, Content: "alpha",
+	}, c)
+	assert.Equal(t, "2", tracker.Cite(ctx, &document.Document{ID: "b"}).ID)
+	assert.Equal(t, "1", tracker.Cite(ctx, doc).ID)
+	assert.Equal(t, "3", tracker.Cite(ctx, &document.Document{Content: "no id"}).ID)
+
+	// Another session numbers from 1.
+	other, _ := sessionContext("s2")
+	assert.Equal(t, "1", tracker.Cite(other, &document.Document{ID: "b"}).ID)
+	_, ok := tracker.Lookup(other, "2")
+	assert.False(t, ok)
+	got, ok := tracker.Lookup(ctx, "2")
+	require.True(t, ok)
+	assert.Equal(t, "b", got.DocumentID)
+}
+
+func TestTracker_Limits(t *testing.T) {
+	tracker := NewTracker(WithMaxSessions(1), WithMaxChunksPerSession(2))
+	ctx, _ := sessionContext("s1")
+	tracker.Cite(ctx, &document.Document{ID: "a"})
+	tracker.Cite(ctx, &document.Document{ID: "b"})
+	tracker.Cite(ctx, &document.Document{ID: "c"})
+	_, ok := tracker.Lookup(ctx, "1")
+	assert.False(t, ok)
+	// An evicted chunk gets a new ID, IDs are never reused.
+	assert.Equal(t, "4", tracker.Cite(ctx, &document.Document{ID: "a"}).ID)
+
+	other, _ := sessionContext("s2")
+	tracker.Cite(other, &document.Document{ID: "a"})
+	_, ok = tracker.Lookup(ctx, "4")
+	assert.False(t, ok, "least recently used session should be dropped")
+}
+
+func TestSplitSentences(t *testing.T) {
+	response := "Go 1.21 added min. [1] Channels are typed [2, 3]! 你好。[1]\n- item one\nDone"
+	sentences := SplitSentences(response)
+	require.Len(t, sentences, 5)
+	assert.Equal(t, Sentence{Text: "Go 1.21 added min.", Span: Span{Start: 0, End: 22}, CitationIDs: []string{"1"}}, sentences[0])
+	assert.Equal(t, "Channels are typed !", sentences[1].Text)
+	assert.Equal(t, []string{"2", "3"}, sentences[1].CitationIDs)
+	assert.Equal(t, Sentence{Text: "你好。", Span: Span{Start: 50, End: 56}, CitationIDs: []string{"1"}}, sentences[2])
+	assert.Equal(t, "- item one", sentences[3].Text)
+	assert.Equal(t, Sentence{Text: "Done", Span: Span{Start: 68, End: 72}}, sentences[4])
+}
+
+func TestPlugin_AttachesCitations(t *testing.T) {
+	tracker := NewTracker()
+	ctx, inv := sessionContext("s1")
+	tracker.Cite(ctx, &document.Document{ID: "a", Content: "Channels are typed conduits between goroutines."})
+	tracker.Cite(ctx, &document.Document{ID: "b", Content: "The select statement waits on channel operations."})
+
+	p := NewPlugin(tracker, WithVerifier(NewLexicalVerifier()))
+	assert.Equal(t, "citation", p.Name())
+
+	e := finalEvent("Channels are typed conduits between goroutines [1]. " +
+		"Select waits on channel operations [2][9]. Mutexes are faster than channels.")
+	got, err := p.onEvent(ctx, inv, e)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	info, ok, err := event.GetExtension[Info](got, ExtensionKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, info.Citations, 2)
+	assert.Equal(t, "a", info.Citations[0].DocumentID)
+	assert.Equal(t, []Span{{Start: 47, End: 50}}, info.Citations[0].Markers)
+	assert.Equal(t, "b", info.Citations[1].DocumentID)
+	assert.Equal(t, []string{"9"}, info.Unresolved)
+	require.NotNil(t, info.Verification)
+	assert.Equal(t, "lexical", info.Verification.Verifier)
+	assert.Equal(t, 3, info.Verification.Sentences)
+	require.Len(t, info.Verification.Unsupported, 1)
+	assert.Equal(t, "Mutexes are faster than channels.", info.Verification.Unsupported[0].Text)
+}
+
+func TestPlugin_SkipsEvents(t *testing.T) {
+	tracker := NewTracker()
+	ctx, inv := sessionContext("s1")
+	p := NewPlugin(tracker)
+
+	// Nothing was retrieved in the session.
+	got, err := p.onEvent(ctx, inv, finalEvent("Hello [1]."))
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	tracker.Cite(ctx, &document.Document{ID: "a"})
+	partial := finalEvent("Hello [1].")
+	partial.Response.IsPartial = true
+	got, err = p.onEvent(ctx, inv, partial)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// No markers and no verifier.
+	got, err = p.onEvent(ctx, inv, finalEvent("Hello."))
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestPlugin_VerifierErrorFailsOpen(t *testing.T) {
+	tracker := NewTracker()
+	ctx, inv := sessionContext("s1")
+	tracker.Cite(ctx, &document.Document{ID: "a", Content: "alpha"})
+	p := NewPlugin(tracker, WithVerifier(NewLLMVerifier(&stubModel{err: errors.New("boom")})))
+
+	got, err := p.onEvent(ctx, inv, finalEvent("Alpha [1]."))
+	require.NoError(t, err)
+	info, ok, err := event.GetExtension[Info](got, ExtensionKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, info.Citations, 1)
+	assert.Nil(t, info.Verification)
+}
+
+func TestLexicalVerifier(t *testing.T) {
+	chunks := map[string]Chunk{
+		"1": {ID: "1", Content: "Goroutines are lightweight threads managed by the Go runtime."},
+		"2": {ID: "2", Content: "知识库支持向量检索。"},
+	}
+	sentences := []Sentence{
+		{Text: "Goroutines are lightweight threads.", CitationIDs: []string{"1"}},
+		{Text: "Goroutines are lightweight threads.", CitationIDs: []string{"2"}},
+		{Text: "知识库支持向量检索。"},
+		{Text: "Sure!"},
+		{Text: "Rust has no garbage collector at all."},
+	}
+	unsupported, err := NewLexicalVerifier().Verify(context.Background(), sentences, chunks)
+	require.NoError(t, err)
+	assert.Equal(t, []Sentence{sentences[1], sentences[4]}, unsupported)
+
+	unsupported, err = NewLexicalVerifier(WithMinOverlap(1)).Verify(context.Background(),
+		[]Sentence{{Text: "Goroutines are cheap threads."}}, chunks)
+	require.NoError(t, err)
+	assert.Len(t, unsupported, 1)
+}
+
+func TestLLMVerifier(t *testing.T) {
+	chunks := map[string]Chunk{"1": {ID: "1", Content: "alpha"}, "10": {ID: "10", Content: "beta"}}
+	sentences := []Sentence{
+		{Text: "first", Span: Span{Start: 0, End: 5}, CitationIDs: []string{"1"}},
+		{Text: "second", Span: Span{Start: 6, End: 12}},
+		{Text: "third", Span: Span{Start: 13, End: 18}},
+	}
+	m := &stubModel{content: "3, 2, 3, 7"}
+	unsupported, err := NewLLMVerifier(m).Verify(context.Background(), sentences, chunks)
+	require.NoError(t, err)
+	assert.Equal(t, []Sentence{sentences[1], sentences[2]}, unsupported)
+	require.Len(t, m.capture.Messages, 2)
+	assert.Contains(t, m.capture.Messages[1].Content, "[1] alpha\n\n[10] beta")
+	assert.Contains(t, m.capture.Messages[1].Content, "1. first (cites [1])")
+
+	m = &stubModel{content: "NONE"}
+	unsupported, err = NewLLMVerifier(m).Verify(context.Background(), sentences, chunks)
+	require.NoError(t, err)
+	assert.Empty(t, unsupported)
+
+	// Without cited chunks nothing is supported.
+	unsupported, err = NewLLMVerifier(m).Verify(context.Background(), sentences, nil)
+	require.NoError(t, err)
+	assert.Equal(t, sentences, unsupported)
+
+	_, err = NewLLMVerifier(&stubModel{apiErr: &model.ResponseError{Message: "rate limited"}}).
+		Verify(context.Background(), sentences, chunks)
+	assert.ErrorContains(t, err, "rate limited")
+}
+
+func finalEvent(content string) *event.Event {
+	return event.NewResponseEvent("inv", "assistant", &model.Response{
+		Done:    true,
+		Choices: []model.Choice{{Message: model.NewAssistantMessage(content)}},
+	})
+}
+
+type stubModel struct {
+	content string
+	err     error
+	apiErr  *model.ResponseError
+	capture *model.Request
+}
+
+func (m *stubModel) GenerateContent(_ context.Context, req *model.Request) (<-chan *model.Response, error) {
+	m.capture = req
+	if m.err != nil {
+		return nil, m.err
+	}
+	ch := make(chan *model.Response, 1)
+	if m.apiErr != nil {
+		ch <- &model.Response{Error: m.apiErr}
+	} else {
+		ch <- &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage(m.content)}}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (m *stubModel) Info() model.Info {
+	return model.Info{Name: "stub"}
+}
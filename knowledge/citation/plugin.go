@@ -0,0 +1,144 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package citation
+
+import (
+	"context"
+	"fmt"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/plugin"
+)
+
+const defaultPluginName = "citation"
+
+// Plugin attaches the citations of final responses to their events.
+type Plugin struct {
+	tracker  *Tracker
+	name     string
+	verifier Verifier
+}
+
+var _ plugin.Plugin = (*Plugin)(nil)
+
+// PluginOption configures a Plugin.
+type PluginOption func(*Plugin)
+
+// WithName sets the plugin name. Defaults to "citation".
+func WithName(name string) PluginOption {
+	return func(p *Plugin) {
+		if name != "" {
+			p.name = name
+		}
+	}
+}
+
+// WithVerifier checks every final response that follows a knowledge search
+// with v, and reports the unsupported sentences in Info.Verification.
+func WithVerifier(v Verifier) PluginOption {
+	return func(p *Plugin) {
+		p.verifier = v
+	}
+}
+
+// NewPlugin creates a plugin resolving citations against tracker, which must
+// be the tracker given to the knowledge search tools.
+func NewPlugin(tracker *Tracker, opts ...PluginOption) *Plugin {
+	p := &Plugin{tracker: tracker, name: defaultPluginName}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name implements plugin.Plugin.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Register implements plugin.Plugin.
+func (p *Plugin) Register(r *plugin.Registry) {
+	if p == nil || p.tracker == nil || r == nil {
+		return
+	}
+	r.OnEvent(p.onEvent)
+}
+
+func (p *Plugin) onEvent(
+	ctx context.Context,
+	inv *agent.Invocation,
+	e *event.Event,
+) (*event.Event, error) {
+	if e == nil || e.Response == nil || e.Response.Error != nil || !e.Response.IsFinalResponse() ||
+		e.Response.IsToolResultResponse() || e.Response.Object == model.ObjectTypeRunnerCompletion {
+		return nil, nil
+	}
+	msg := e.Response.Choices[0].Message
+	if msg.Role != model.RoleAssistant || msg.Content == "" {
+		return nil, nil
+	}
+	retrieved := p.tracker.chunks(scopeKey(inv))
+	if len(retrieved) == 0 {
+		return nil, nil
+	}
+
+	info, cited := resolve(msg.Content, retrieved)
+	if p.verifier != nil {
+		sentences := SplitSentences(msg.Content)
+		unsupported, err := p.verifier.Verify(ctx, sentences, cited)
+		if err != nil {
+			// Fail open: verification is advisory and must not drop answers.
+			log.WarnfContext(ctx, "%s: verify response: %v", p.name, err)
+		} else {
+			info.Verification = &Verification{
+				Verifier:    p.verifier.Name(),
+				Sentences:   len(sentences),
+				Unsupported: unsupported,
+			}
+		}
+	}
+	if len(info.Citations) == 0 && len(info.Unresolved) == 0 && info.Verification == nil {
+		return nil, nil
+	}
+	if err := event.SetExtension(e, ExtensionKey, info); err != nil {
+		return nil, fmt.Errorf("%s: attach citations: %w", p.name, err)
+	}
+	return e, nil
+}
+
+// resolve matches the markers of response with the retrieved chunks. It
+// returns the citations in order of first citation and the cited chunks by
+// ID.
+func resolve(response string, retrieved map[string]Chunk) (Info, map[string]Chunk) {
+	var info Info
+	cited := make(map[string]Chunk)
+	index := make(map[string]int)
+	for _, m := range parseMarkers(response) {
+		for _, id := range m.ids {
+			c, ok := retrieved[id]
+			if !ok {
+				info.Unresolved = appendUnique(info.Unresolved, id)
+				continue
+			}
+			i, seen := index[id]
+			if !seen {
+				i = len(info.Citations)
+				index[id] = i
+				cited[id] = c
+				info.Citations = append(info.Citations, Citation{Chunk: c})
+			}
+			info.Citations[i].Markers = append(info.Citations[i].Markers, m.span)
+		}
+	}
+	return info, cited
+}
@@ -0,0 +1,196 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package citation
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+)
+
+const (
+	defaultMaxSessions         = 1024
+	defaultMaxChunksPerSession = 1000
+)
+
+// Tracker assigns citation IDs to retrieved chunks. IDs are numbered per
+// session in order of first retrieval and are stable: a chunk returned
+// again by a later search, in the same or a later turn, keeps its ID. Runs
+// without a session are tracked per invocation. Tracker is safe for
+// concurrent use.
+type Tracker struct {
+	maxSessions int
+	maxChunks   int
+
+	mu       sync.Mutex
+	sessions map[string]*list.Element
+	// recent orders the sessions by last use, most recent first.
+	recent *list.List
+}
+
+type sessionChunks struct {
+	key    string
+	nextID int
+	// byDoc maps a document key to its citation ID.
+	byDoc  map[string]string
+	chunks map[string]trackedChunk
+	// order holds the citation IDs by assignment, oldest first.
+	order []string
+}
+
+type trackedChunk struct {
+	Chunk
+	docKey string
+}
+
+// TrackerOption configures a Tracker.
+type TrackerOption func(*Tracker)
+
+// WithMaxSessions sets how many sessions are tracked. The least recently
+// used session is dropped beyond it. Defaults to 1024.
+func WithMaxSessions(n int) TrackerOption {
+	return func(t *Tracker) {
+		if n > 0 {
+			t.maxSessions = n
+		}
+	}
+}
+
+// WithMaxChunksPerSession sets how many chunks are kept per session. The
+// oldest chunks are dropped beyond it and their IDs are not reused.
+// Defaults to 1000.
+func WithMaxChunksPerSession(n int) TrackerOption {
+	return func(t *Tracker) {
+		if n > 0 {
+			t.maxChunks = n
+		}
+	}
+}
+
+// NewTracker creates a Tracker.
+func NewTracker(opts ...TrackerOption) *Tracker {
+	t := &Tracker{
+		maxSessions: defaultMaxSessions,
+		maxChunks:   defaultMaxChunksPerSession,
+		sessions:    make(map[string]*list.Element),
+		recent:      list.New(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Cite returns the citation chunk of doc in the session of ctx, assigning
+// the next ID when doc has not been cited before.
+func (t *Tracker) Cite(ctx context.Context, doc *document.Document) Chunk {
+	inv, _ := agent.InvocationFromContext(ctx)
+	return t.cite(scopeKey(inv), doc)
+}
+
+// Lookup returns the chunk with the given citation ID in the session of ctx.
+func (t *Tracker) Lookup(ctx context.Context, id string) (Chunk, bool) {
+	inv, _ := agent.InvocationFromContext(ctx)
+	return t.lookup(scopeKey(inv), id)
+}
+
+func (t *Tracker) cite(key string, doc *document.Document) Chunk {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.session(key, true)
+
+	docKey := doc.ID
+	if docKey == "" {
+		docKey = "content:" + doc.Content
+	}
+	id, ok := s.byDoc[docKey]
+	if !ok {
+		s.nextID++
+		id = strconv.Itoa(s.nextID)
+		s.byDoc[docKey] = id
+		s.order = append(s.order, id)
+		if len(s.order) > t.maxChunks {
+			oldest := s.order[0]
+			s.order = s.order[1:]
+			delete(s.byDoc, s.chunks[oldest].docKey)
+			delete(s.chunks, oldest)
+		}
+	}
+	// Refresh the chunk in case the document was re-indexed.
+	c := chunkFromDocument(id, doc)
+	s.chunks[id] = trackedChunk{Chunk: c, docKey: docKey}
+	return c
+}
+
+func (t *Tracker) lookup(key, id string) (Chunk, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.session(key, false)
+	if s == nil {
+		return Chunk{}, false
+	}
+	c, ok := s.chunks[id]
+	return c.Chunk, ok
+}
+
+// chunks returns a copy of the chunks of the session key by citation ID.
+func (t *Tracker) chunks(key string) map[string]Chunk {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.session(key, false)
+	if s == nil {
+		return nil
+	}
+	chunks := make(map[string]Chunk, len(s.chunks))
+	for id, c := range s.chunks {
+		chunks[id] = c.Chunk
+	}
+	return chunks
+}
+
+// session returns the chunks of the session key and marks it as recently
+// used. t.mu must be held.
+func (t *Tracker) session(key string, create bool) *sessionChunks {
+	if elem, ok := t.sessions[key]; ok {
+		t.recent.MoveToFront(elem)
+		return elem.Value.(*sessionChunks)
+	}
+	if !create {
+		return nil
+	}
+	s := &sessionChunks{
+		key:    key,
+		byDoc:  make(map[string]string),
+		chunks: make(map[string]trackedChunk),
+	}
+	t.sessions[key] = t.recent.PushFront(s)
+	if t.recent.Len() > t.maxSessions {
+		oldest := t.recent.Back()
+		t.recent.Remove(oldest)
+		delete(t.sessions, oldest.Value.(*sessionChunks).key)
+	}
+	return s
+}
+
+// scopeKey identifies the session of inv, or inv itself when it has no
+// session.
+func scopeKey(inv *agent.Invocation) string {
+	if inv == nil {
+		return ""
+	}
+	if inv.Session != nil && inv.Session.ID != "" {
+		return "session:" + inv.Session.AppName + "/" + inv.Session.UserID + "/" + inv.Session.ID
+	}
+	return "invocation:" + inv.InvocationID
+}
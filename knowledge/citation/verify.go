@@ -0,0 +1,297 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package citation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Verifier checks whether the sentences of a response are supported by the
+// chunks the response cites. A sentence with citations is checked against
+// the chunks it cites, and a sentence without citations against all cited
+// chunks.
+type Verifier interface {
+	// Name returns the name reported in Verification.
+	Name() string
+	// Verify returns the unsupported sentences. chunks holds the cited
+	// chunks by citation ID and may be empty.
+	Verify(ctx context.Context, sentences []Sentence, chunks map[string]Chunk) ([]Sentence, error)
+}
+
+const (
+	defaultMinOverlap = 0.5
+	defaultMinTokens  = 3
+)
+
+// stopWords are ignored by the lexical verifier.
+var stopWords = map[string]bool{
+	"the": true, "an": true, "and": true, "or": true, "of": true, "to": true, "in": true,
+	"on": true, "at": true, "by": true, "for": true, "with": true, "is": true, "are": true,
+	"was": true, "were": true, "be": true, "it": true, "its": true, "this": true, "that": true,
+	"as": true, "from": true, "can": true, "has": true, "have": true, "not": true,
+}
+
+// LexicalVerifier is a Verifier based on token overlap. A sentence is
+// supported when a large enough share of its tokens occurs in one of its
+// chunks. It needs no model call, so it is cheap enough to run on every
+// response, but it flags paraphrases that share few words with the source.
+type LexicalVerifier struct {
+	minOverlap float64
+	minTokens  int
+}
+
+// LexicalOption configures a LexicalVerifier.
+type LexicalOption func(*LexicalVerifier)
+
+// WithMinOverlap sets the share of sentence tokens, in (0, 1], that must
+// occur in a chunk. Defaults to 0.5.
+func WithMinOverlap(f float64) LexicalOption {
+	return func(v *LexicalVerifier) {
+		if f > 0 && f <= 1 {
+			v.minOverlap = f
+		}
+	}
+}
+
+// WithMinTokens skips sentences with fewer tokens, such as "Sure!" or list
+// headings. Defaults to 3.
+func WithMinTokens(n int) LexicalOption {
+	return func(v *LexicalVerifier) {
+		if n >= 0 {
+			v.minTokens = n
+		}
+	}
+}
+
+// NewLexicalVerifier creates a LexicalVerifier.
+func NewLexicalVerifier(opts ...LexicalOption) *LexicalVerifier {
+	v := &LexicalVerifier{minOverlap: defaultMinOverlap, minTokens: defaultMinTokens}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Name implements Verifier.
+func (v *LexicalVerifier) Name() string {
+	return "lexical"
+}
+
+// Verify implements Verifier.
+func (v *LexicalVerifier) Verify(_ context.Context, sentences []Sentence, chunks map[string]Chunk) ([]Sentence, error) {
+	chunkTokens := make(map[string]map[string]bool, len(chunks))
+	for id, c := range chunks {
+		chunkTokens[id] = tokenSet(c.Content)
+	}
+	var unsupported []Sentence
+	for _, s := range sentences {
+		tokens := tokenSet(s.Text)
+		if len(tokens) < v.minTokens || len(tokens) == 0 {
+			continue
+		}
+		supported := false
+		for _, id := range candidates(s, chunks) {
+			matched := 0
+			for tok := range tokens {
+				if chunkTokens[id][tok] {
+					matched++
+				}
+			}
+			if float64(matched)/float64(len(tokens)) >= v.minOverlap {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			unsupported = append(unsupported, s)
+		}
+	}
+	return unsupported, nil
+}
+
+// candidates returns the IDs of the chunks s is checked against, sorted for
+// a deterministic order.
+func candidates(s Sentence, chunks map[string]Chunk) []string {
+	var ids []string
+	for _, id := range s.CitationIDs {
+		if _, ok := chunks[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		return ids
+	}
+	for id := range chunks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// tokenSet returns the lower-cased words of text without stop words and
+// one-letter words. Han characters are tokens on their own, as Chinese text
+// has no word separators.
+func tokenSet(text string) map[string]bool {
+	tokens := make(map[string]bool)
+	var word []rune
+	flush := func() {
+		if len(word) > 1 && !stopWords[string(word)] {
+			tokens[string(word)] = true
+		}
+		word = word[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			tokens[string(r)] = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+const defaultLLMVerifierPrompt = `You check whether an answer is grounded in its sources.
+You are given numbered sources and the numbered sentences of an answer. A sentence is supported when the sources it cites, or any source if it cites none, state or directly imply it. Greetings, transitions and questions to the user count as supported.
+Reply with the numbers of the unsupported sentences separated by commas, e.g. "2, 5", or "NONE" when every sentence is supported. Do not explain.`
+
+const defaultMaxChunkLength = 2000
+
+// sentenceNumber matches the sentence numbers in the answer of the model.
+var sentenceNumber = regexp.MustCompile(`\d+`)
+
+// LLMVerifier is a Verifier that asks a model which sentences the cited
+// chunks do not support. It catches paraphrases a LexicalVerifier flags, at
+// the price of one model call per response.
+type LLMVerifier struct {
+	model        model.Model
+	systemPrompt string
+}
+
+// LLMOption configures an LLMVerifier.
+type LLMOption func(*LLMVerifier)
+
+// WithLLMSystemPrompt overrides the prompt. The model must still answer with
+// the numbers of the unsupported sentences.
+func WithLLMSystemPrompt(prompt string) LLMOption {
+	return func(v *LLMVerifier) {
+		v.systemPrompt = prompt
+	}
+}
+
+// NewLLMVerifier creates an LLMVerifier using m.
+func NewLLMVerifier(m model.Model, opts ...LLMOption) *LLMVerifier {
+	v := &LLMVerifier{model: m, systemPrompt: defaultLLMVerifierPrompt}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Name implements Verifier.
+func (v *LLMVerifier) Name() string {
+	return "llm"
+}
+
+// Verify implements Verifier.
+func (v *LLMVerifier) Verify(ctx context.Context, sentences []Sentence, chunks map[string]Chunk) ([]Sentence, error) {
+	if len(sentences) == 0 {
+		return nil, nil
+	}
+	if v.model == nil {
+		return nil, fmt.Errorf("citation verifier model is nil")
+	}
+	if len(chunks) == 0 {
+		return sentences, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Sources:\n")
+	ids := make([]string, 0, len(chunks))
+	for id := range chunks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		c, _ := strconv.Atoi(ids[j])
+		return a < c
+	})
+	for _, id := range ids {
+		content := chunks[id].Content
+		if runes := []rune(content); len(runes) > defaultMaxChunkLength {
+			content = string(runes[:defaultMaxChunkLength])
+		}
+		fmt.Fprintf(&b, "\n%s %s\n", Marker(id), strings.TrimSpace(content))
+	}
+	b.WriteString("\nAnswer sentences:\n")
+	for i, s := range sentences {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, s.Text)
+		if len(s.CitationIDs) > 0 {
+			fmt.Fprintf(&b, " (cites %s)", Marker(strings.Join(s.CitationIDs, ", ")))
+		}
+	}
+
+	answer, err := v.complete(ctx, b.String())
+	if err != nil {
+		return nil, err
+	}
+	var unsupported []Sentence
+	seen := make(map[int]bool)
+	for _, num := range sentenceNumber.FindAllString(answer, -1) {
+		n, err := strconv.Atoi(num)
+		if err != nil || n < 1 || n > len(sentences) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		unsupported = append(unsupported, sentences[n-1])
+	}
+	sort.Slice(unsupported, func(i, j int) bool { return unsupported[i].Span.Start < unsupported[j].Span.Start })
+	return unsupported, nil
+}
+
+func (v *LLMVerifier) complete(ctx context.Context, user string) (string, error) {
+	ch, err := v.model.GenerateContent(ctx, &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage(v.systemPrompt),
+			model.NewUserMessage(user),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("citation verify call failed: %w", err)
+	}
+
+	var result strings.Builder
+	for resp := range ch {
+		if resp.Error != nil {
+			return "", fmt.Errorf("citation verify error: %s", resp.Error.Message)
+		}
+		for _, choice := range resp.Choices {
+			if choice.Message.Content != "" {
+				result.WriteString(choice.Message.Content)
+			}
+			if choice.Delta.Content != "" {
+				result.WriteString(choice.Delta.Content)
+			}
+		}
+	}
+	return result.String(), nil
+}
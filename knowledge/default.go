@@ -16,6 +16,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net/url"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"slices"
@@ -328,6 +330,149 @@ func (dk *BuiltinKnowledge) reloadSource(
 	return nil
 }
 
+// ReloadSourceFiles re-indexes some files of a registered source. Documents of
+// changed files are replaced, documents of removed files are deleted, and the
+// rest of the source is left untouched. The source must implement
+// source.FileSource, and the paths are the ones returned by its ListFiles.
+// All changed files are read before any document is deleted, so when one of
+// them cannot be read the index is left unchanged and an error is returned.
+func (dk *BuiltinKnowledge) ReloadSourceFiles(
+	ctx context.Context,
+	sourceName string,
+	changed []string,
+	removed []string,
+	opts ...LoadOption,
+) error {
+	dk.dataOperationMu.Lock()
+	defer dk.dataOperationMu.Unlock()
+
+	if dk.vectorStore == nil {
+		return fmt.Errorf("vector store not configured")
+	}
+	var src source.Source
+	for _, existingSource := range dk.sources {
+		if existingSource.Name() == sourceName {
+			src = existingSource
+			break
+		}
+	}
+	if src == nil {
+		return fmt.Errorf("source with name %s not found", sourceName)
+	}
+	fileSrc, ok := src.(source.FileSource)
+	if !ok {
+		return fmt.Errorf("source %s does not support file reload", sourceName)
+	}
+
+	uris := make([]string, 0, len(changed)+len(removed))
+	for _, filePath := range append(append([]string(nil), changed...), removed...) {
+		uri, err := fileURI(filePath)
+		if err != nil {
+			return err
+		}
+		uris = append(uris, uri)
+	}
+
+	config := dk.buildLoadConfig(1, opts...)
+	docs, err := fileSrc.ReadFiles(ctx, changed)
+	if err != nil {
+		err = fmt.Errorf("failed to read files from source %s: %w", sourceName, err)
+		reporter := newLoadReporter(config, []string{sourceName}, time.Now(), defaultSizeBuckets, func() int { return 0 })
+		reporter.Error(ctx, LoadProgressEvent{SourceName: sourceName}, err)
+		reporter.Close()
+		return err
+	}
+	log.InfofContext(ctx, "Reloading %d changed and %d removed file(s) of source %s",
+		len(changed), len(removed), sourceName)
+
+	changedSource := &fileDocumentsSource{Source: src, docs: docs}
+	if dk.enableSourceSync {
+		return dk.syncReloadFiles(ctx, changedSource, uris, config)
+	}
+	for _, uri := range uris {
+		filter := map[string]any{
+			source.MetaSourceName: sourceName,
+			source.MetaURI:        uri,
+		}
+		if err := dk.vectorStore.DeleteByFilter(ctx, vectorstore.WithDeleteFilter(filter)); err != nil {
+			return fmt.Errorf("failed to delete documents of %s: %w", uri, err)
+		}
+	}
+	if err := dk.loadSourceInternal(ctx, []source.Source{changedSource}, config); err != nil {
+		return fmt.Errorf("failed to reload files of source %s: %w", sourceName, err)
+	}
+	return nil
+}
+
+// syncReloadFiles reloads the documents of some files using the incremental
+// sync strategy: unchanged chunks are kept, and chunks of the files that are
+// no longer produced are deleted.
+func (dk *BuiltinKnowledge) syncReloadFiles(
+	ctx context.Context,
+	changedSource *fileDocumentsSource,
+	uris []string,
+	config *loadConfig,
+) error {
+	sourceName := changedSource.Name()
+	if err := dk.refreshSourceDocInfo(ctx, sourceName); err != nil {
+		return fmt.Errorf("failed to update vector store metadata: %w", err)
+	}
+
+	// mark the documents of the files unprocessed, so that the ones that are
+	// not produced again can be told apart after loading
+	var previousDocIDs []string
+	for _, uri := range uris {
+		for _, info := range dk.cacheURIInfo[uri] {
+			if info.SourceName == sourceName {
+				dk.processedDocIDs.Delete(info.DocumentID)
+				previousDocIDs = append(previousDocIDs, info.DocumentID)
+			}
+		}
+	}
+
+	if err := dk.loadSourceInternal(ctx, []source.Source{changedSource}, config); err != nil {
+		return fmt.Errorf("failed to reload files of source %s: %w", sourceName, err)
+	}
+
+	var staleDocIDs []string
+	for _, docID := range previousDocIDs {
+		if _, exists := dk.processedDocIDs.Load(docID); !exists {
+			staleDocIDs = append(staleDocIDs, docID)
+		}
+	}
+	if len(staleDocIDs) > 0 {
+		if err := dk.vectorStore.DeleteByFilter(ctx, vectorstore.WithDeleteDocumentIDs(staleDocIDs)); err != nil {
+			return fmt.Errorf("failed to delete outdated documents: %w", err)
+		}
+	}
+
+	if err := dk.refreshSourceDocInfo(ctx, sourceName); err != nil {
+		return fmt.Errorf("failed to update vector store metadata: %w", err)
+	}
+	return nil
+}
+
+// fileDocumentsSource wraps a source so that the documents read from some of
+// its files can be loaded with the same name and metadata as the source.
+type fileDocumentsSource struct {
+	source.Source
+	docs []*document.Document
+}
+
+// ReadDocuments returns the documents read from the files.
+func (s *fileDocumentsSource) ReadDocuments(context.Context) ([]*document.Document, error) {
+	return s.docs, nil
+}
+
+// fileURI returns the URI that file-based sources set on their documents.
+func fileURI(filePath string) (string, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path of %s: %w", filePath, err)
+	}
+	return (&url.URL{Scheme: "file", Path: absPath}).String(), nil
+}
+
 // loadSourceInternal loads sources with proper concurrency handling
 func (dk *BuiltinKnowledge) loadSourceInternal(ctx context.Context, sources []source.Source, config *loadConfig) error {
 	dk.processingDocIDs = sync.Map{}
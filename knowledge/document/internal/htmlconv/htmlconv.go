@@ -0,0 +1,443 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package htmlconv converts the main content of HTML pages into Markdown.
+package htmlconv
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Result is a converted HTML page.
+type Result struct {
+	// Title is the text of the <title> element.
+	Title string
+	// Heading is the text of the first heading of the main content.
+	Heading string
+	// Markdown is the main content rendered as Markdown.
+	Markdown string
+}
+
+// boilerplateTokens are class and id tokens of page chrome rather than content.
+var boilerplateTokens = map[string]bool{
+	"nav": true, "navbar": true, "navigation": true, "menu": true,
+	"sidebar": true, "breadcrumb": true, "breadcrumbs": true,
+	"cookie": true, "cookies": true, "advert": true, "advertisement": true, "ads": true,
+	"share": true, "social": true, "related": true, "comments": true,
+	"popup": true, "modal": true, "banner": true, "footer": true,
+}
+
+var (
+	tokenSeparator = regexp.MustCompile(`[\s_-]+`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// Convert parses an HTML page and renders its main content as Markdown.
+// Headings, lists, tables, code blocks and block quotes keep their structure;
+// scripts, hidden elements and page chrome such as navigation, sidebars and
+// footers are dropped.
+func Convert(r io.Reader) (*Result, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTML: %w", err)
+	}
+	return ConvertNode(doc), nil
+}
+
+// ConvertNode renders the main content of a parsed HTML document.
+func ConvertNode(doc *html.Node) *Result {
+	result := &Result{}
+	if title := findNode(doc, func(n *html.Node) bool { return isElement(n, "title") }); title != nil {
+		result.Title = collapseSpaces(textContent(title))
+	}
+
+	root := preferredContentNode(doc)
+	c := &converter{}
+	c.walk(root)
+	c.flush()
+	result.Heading = c.heading
+	result.Markdown = strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(c.blocks, "\n\n"), "\n\n"))
+	return result
+}
+
+// preferredContentNode returns the element holding the main content: an
+// explicit main element, else the largest article, else the body.
+func preferredContentNode(doc *html.Node) *html.Node {
+	if node := findNode(doc, func(n *html.Node) bool {
+		return isElement(n, "main") || (n.Type == html.ElementNode && attr(n, "role") == "main")
+	}); node != nil && !isInvisible(node) {
+		return node
+	}
+	var best *html.Node
+	bestLen := 0
+	walk(doc, func(n *html.Node) {
+		if isElement(n, "article") && !isInvisible(n) {
+			if l := len(strings.TrimSpace(textContent(n))); l > bestLen {
+				best, bestLen = n, l
+			}
+		}
+	})
+	if best != nil {
+		return best
+	}
+	if body := findNode(doc, func(n *html.Node) bool { return isElement(n, "body") }); body != nil {
+		return body
+	}
+	return doc
+}
+
+type converter struct {
+	blocks  []string
+	inline  strings.Builder
+	heading string
+	// inContent is set below main and article elements, where header and
+	// footer elements belong to the content.
+	inContent bool
+}
+
+// render converts the children of n into blocks with a nested converter.
+func (c *converter) render(n *html.Node) []string {
+	sub := &converter{inContent: c.inContent, heading: c.heading}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sub.walk(child)
+	}
+	sub.flush()
+	if c.heading == "" {
+		c.heading = sub.heading
+	}
+	return sub.blocks
+}
+
+// flush ends the current paragraph.
+func (c *converter) flush() {
+	text := collapseInline(c.inline.String())
+	c.inline.Reset()
+	if text != "" {
+		c.blocks = append(c.blocks, text)
+	}
+}
+
+func (c *converter) block(text string) {
+	c.flush()
+	if strings.TrimSpace(text) != "" {
+		c.blocks = append(c.blocks, text)
+	}
+}
+
+func (c *converter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		// Source line breaks are plain whitespace; only <br> breaks a line.
+		c.inline.WriteString(strings.ReplaceAll(n.Data, "\n", " "))
+		return
+	case html.ElementNode:
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			c.walk(child)
+		}
+		return
+	}
+	if c.isBoilerplate(n) {
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := collapseSpaces(strings.Join(c.render(n), " "))
+		if text == "" {
+			return
+		}
+		if c.heading == "" {
+			c.heading = text
+		}
+		c.block(strings.Repeat("#", int(tag[1]-'0')) + " " + text)
+	case "p", "div", "section", "figure", "figcaption", "dl", "dt", "dd",
+		"address", "details", "summary", "caption", "center":
+		c.flush()
+		c.walkChildren(n)
+		c.flush()
+	case "main", "article", "header", "footer":
+		c.flush()
+		inContent := c.inContent
+		c.inContent = true
+		c.walkChildren(n)
+		c.flush()
+		c.inContent = inContent
+	case "br":
+		c.inline.WriteString("\n")
+	case "hr":
+		c.block("---")
+	case "ul", "ol":
+		c.block(c.renderList(n, tag == "ol"))
+	case "pre":
+		c.block(renderPre(n))
+	case "code", "kbd", "samp":
+		if text := collapseSpaces(textContent(n)); text != "" {
+			c.inline.WriteString("`" + text + "`")
+		}
+	case "blockquote":
+		blocks := c.render(n)
+		if len(blocks) == 0 {
+			return
+		}
+		lines := strings.Split(strings.Join(blocks, "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		c.block(strings.Join(lines, "\n"))
+	case "table":
+		c.block(renderTable(n))
+	case "img":
+		if alt := collapseSpaces(attr(n, "alt")); alt != "" {
+			c.inline.WriteString(alt)
+		}
+	default:
+		c.walkChildren(n)
+	}
+}
+
+func (c *converter) walkChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
+
+func (c *converter) isBoilerplate(n *html.Node) bool {
+	if isInvisible(n) {
+		return true
+	}
+	switch strings.ToLower(n.Data) {
+	case "nav", "aside", "form", "button", "input", "select", "textarea",
+		"iframe", "canvas", "object", "embed", "dialog", "menu":
+		return true
+	case "header", "footer":
+		if !c.inContent {
+			return true
+		}
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "complementary", "search", "dialog":
+		return true
+	}
+	for _, name := range []string{"class", "id"} {
+		for _, token := range tokenSeparator.Split(strings.ToLower(attr(n, name)), -1) {
+			if boilerplateTokens[token] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderList renders a list with nested lists indented under their items.
+func (c *converter) renderList(n *html.Node, ordered bool) string {
+	var items []string
+	number := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if !isElement(li, "li") || c.isBoilerplate(li) {
+			continue
+		}
+		text := strings.Join(c.render(li), "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		lines := strings.Split(text, "\n")
+		for i := range lines {
+			if i == 0 {
+				lines[i] = marker + lines[i]
+			} else if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderTable renders a table as a Markdown table whose first row is the
+// header.
+func renderTable(n *html.Node) string {
+	var rows [][]string
+	width := 0
+	walk(n, func(tr *html.Node) {
+		if !isElement(tr, "tr") || closestTable(tr) != n {
+			return
+		}
+		var cells []string
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if !isElement(cell, "td") && !isElement(cell, "th") {
+				continue
+			}
+			text := collapseSpaces(textContent(cell))
+			cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+			width = max(width, len(cells))
+		}
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for i := 0; i < width; i++ {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(rows[0])
+	b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderPre renders preformatted text as a fenced code block.
+func renderPre(n *html.Node) string {
+	language := ""
+	for _, node := range []*html.Node{n, findNode(n, func(m *html.Node) bool { return isElement(m, "code") })} {
+		if node == nil {
+			continue
+		}
+		for _, class := range strings.Fields(attr(node, "class")) {
+			if strings.HasPrefix(class, "language-") {
+				language = strings.TrimPrefix(class, "language-")
+			}
+		}
+	}
+	code := strings.Trim(textContent(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	return "```" + language + "\n" + code + "\n```"
+}
+
+func closestTable(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if isElement(p, "table") {
+			return p
+		}
+	}
+	return nil
+}
+
+// collapseInline collapses whitespace within each line and drops blank lines.
+func collapseInline(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(m *html.Node) {
+		if m.Type == html.TextNode && !hasInvisibleAncestor(m.Parent, n) {
+			b.WriteString(m.Data)
+		}
+	})
+	return b.String()
+}
+
+func hasInvisibleAncestor(n, stop *html.Node) bool {
+	for ; n != nil && n != stop.Parent; n = n.Parent {
+		if isInvisible(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInvisible(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	switch strings.ToLower(n.Data) {
+	case "script", "style", "noscript", "template", "svg", "head":
+		return true
+	}
+	if hasAttr(n, "hidden") || strings.EqualFold(attr(n, "aria-hidden"), "true") {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+func isElement(n *html.Node, tag string) bool {
+	return n != nil && n.Type == html.ElementNode && strings.EqualFold(n.Data, tag)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func walk(n *html.Node, visit func(*html.Node)) {
+	if n == nil {
+		return
+	}
+	visit(n)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, visit)
+	}
+}
+
+func findNode(root *html.Node, matches func(*html.Node) bool) *html.Node {
+	if root == nil {
+		return nil
+	}
+	if matches(root) {
+		return root
+	}
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		if found := findNode(child, matches); found != nil {
+			return found
+		}
+	}
+	return nil
+}
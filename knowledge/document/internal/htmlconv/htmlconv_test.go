@@ -0,0 +1,96 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package htmlconv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func convert(t *testing.T, page string) *Result {
+	t.Helper()
+	result, err := Convert(strings.NewReader(page))
+	require.NoError(t, err)
+	return result
+}
+
+func TestConvert_MainContent(t *testing.T) {
+	result := convert(t, `<html><head><title> Guide  </title><style>p{}</style></head>
+<body>
+  <header><a href="/">Home</a></header>
+  <nav><ul><li>Menu item</li></ul></nav>
+  <div class="cookie-banner">We use cookies</div>
+  <main>
+    <header><h1>Install</h1></header>
+    <p>Run the   installer
+       now.</p>
+    <div hidden>secret</div>
+    <p style="display: none">also secret</p>
+    <script>alert(1)</script>
+    <footer>Last updated today</footer>
+  </main>
+  <aside>Related posts</aside>
+  <footer>Copyright</footer>
+</body></html>`)
+
+	assert.Equal(t, "Guide", result.Title)
+	assert.Equal(t, "Install", result.Heading)
+	assert.Equal(t, "# Install\n\nRun the installer now.\n\nLast updated today", result.Markdown)
+}
+
+func TestConvert_ArticleAndBodyFallback(t *testing.T) {
+	result := convert(t, `<body><article>short</article><article><h2>Long</h2><p>The longest article wins.</p></article></body>`)
+	assert.Equal(t, "## Long\n\nThe longest article wins.", result.Markdown)
+
+	result = convert(t, `<body><div id="sidebar">links</div><p>Body <b>text</b><br>next line</p></body>`)
+	assert.Equal(t, "Body text\nnext line", result.Markdown)
+	assert.Empty(t, result.Title)
+}
+
+func TestConvert_Structure(t *testing.T) {
+	result := convert(t, `<body>
+<h2>Lists</h2>
+<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>
+<ol><li>first</li><li>second</li></ol>
+<blockquote><p>quoted</p><p>twice</p></blockquote>
+<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+<p>Use <code>go   test</code> and <a href="https://example.com">the docs</a>. <img alt="diagram"></p>
+<hr>
+</body>`)
+
+	assert.Equal(t, "## Lists\n\n"+
+		"- one\n- two\n  - nested\n\n"+
+		"1. first\n2. second\n\n"+
+		"> quoted\n>\n> twice\n\n"+
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n"+
+		"Use `go test` and the docs. diagram\n\n"+
+		"---", result.Markdown)
+}
+
+func TestConvert_Table(t *testing.T) {
+	result := convert(t, `<body><table>
+<thead><tr><th>Name</th><th>Notes</th></tr></thead>
+<tbody>
+  <tr><td>Alice</td><td>a | b</td></tr>
+  <tr><td>Bob</td></tr>
+  <tr><td>Carol</td><td><table><tr><td>inner</td></tr></table></td></tr>
+</tbody></table></body>`)
+
+	assert.Equal(t, "| Name | Notes |\n"+
+		"| --- | --- |\n"+
+		"| Alice | a \\| b |\n"+
+		"| Bob |  |\n"+
+		"| Carol | inner |", result.Markdown)
+}
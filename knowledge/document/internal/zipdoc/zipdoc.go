@@ -0,0 +1,124 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package zipdoc reads the parts of zip based document formats such as
+// Office Open XML and EPUB.
+package zipdoc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxPartSize bounds the decompressed size of a single part.
+const maxPartSize = 256 << 20
+
+// ErrPartNotFound is returned when an archive has no part with the given name.
+var ErrPartNotFound = errors.New("part not found")
+
+// Archive is an opened zip document.
+type Archive struct {
+	files map[string]*zip.File
+}
+
+// Open opens a zip document held in memory.
+func Open(data []byte) (*Archive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open zip archive: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	return &Archive{files: files}, nil
+}
+
+// ReadFile returns the decompressed content of the named part.
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	f, ok := a.files[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPartNotFound, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open part %s: %w", name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read part %s: %w", name, err)
+	}
+	if len(data) > maxPartSize {
+		return nil, fmt.Errorf("part %s exceeds %d bytes", name, maxPartSize)
+	}
+	return data, nil
+}
+
+// DecodeXML decodes the named XML part into v.
+func (a *Archive) DecodeXML(name string, v any) error {
+	data, err := a.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse part %s: %w", name, err)
+	}
+	return nil
+}
+
+// Relationship is an Open Packaging Conventions relationship.
+type Relationship struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+	Mode   string `xml:"TargetMode,attr"`
+}
+
+// Relationships returns the relationships of the named part keyed by ID,
+// with internal targets resolved to part names. A part without a
+// relationships part has no relationships.
+func (a *Archive) Relationships(part string) (map[string]Relationship, error) {
+	dir, file := path.Split(part)
+	relsName := dir + "_rels/" + file + ".rels"
+	var rels struct {
+		Items []Relationship `xml:"Relationship"`
+	}
+	if err := a.DecodeXML(relsName, &rels); err != nil {
+		if errors.Is(err, ErrPartNotFound) {
+			return map[string]Relationship{}, nil
+		}
+		return nil, err
+	}
+	result := make(map[string]Relationship, len(rels.Items))
+	for _, rel := range rels.Items {
+		if !strings.EqualFold(rel.Mode, "External") {
+			rel.Target = Resolve(part, rel.Target)
+		}
+		result[rel.ID] = rel
+	}
+	return result, nil
+}
+
+// Resolve resolves a target referenced from the part base to a part name.
+func Resolve(base, target string) string {
+	if i := strings.IndexAny(target, "#?"); i >= 0 {
+		target = target[:i]
+	}
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return strings.TrimPrefix(path.Join(path.Dir(base), target), "/")
+}
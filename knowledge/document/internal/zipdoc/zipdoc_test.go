@@ -0,0 +1,70 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package zipdoc
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	assert.Equal(t, "xl/worksheets/sheet1.xml", Resolve("xl/workbook.xml", "worksheets/sheet1.xml"))
+	assert.Equal(t, "ppt/notesSlides/n1.xml", Resolve("ppt/slides/slide1.xml", "../notesSlides/n1.xml"))
+	assert.Equal(t, "xl/styles.xml", Resolve("xl/workbook.xml", "/xl/styles.xml"))
+	assert.Equal(t, "ch1.xhtml", Resolve("content.opf", "ch1.xhtml#start"))
+	assert.Equal(t, "xl/workbook.xml", Resolve("", "xl/workbook.xml"))
+}
+
+func TestArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"/doc/part.xml": `<root><v>1</v></root>`,
+		"doc/_rels/part.xml.rels": `<Relationships>
+<Relationship Id="rId1" Type="t/image" Target="../media/a.png"/>
+<Relationship Id="rId2" Type="t/link" Target="https://example.com" TargetMode="External"/>
+</Relationships>`,
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	archive, err := Open(buf.Bytes())
+	require.NoError(t, err)
+
+	var root struct {
+		V int `xml:"v"`
+	}
+	require.NoError(t, archive.DecodeXML("doc/part.xml", &root))
+	assert.Equal(t, 1, root.V)
+
+	rels, err := archive.Relationships("doc/part.xml")
+	require.NoError(t, err)
+	assert.Equal(t, "media/a.png", rels["rId1"].Target)
+	assert.Equal(t, "https://example.com", rels["rId2"].Target)
+
+	rels, err = archive.Relationships("doc/other.xml")
+	require.NoError(t, err)
+	assert.Empty(t, rels)
+
+	_, err = archive.ReadFile("missing.xml")
+	assert.ErrorIs(t, err, ErrPartNotFound)
+	assert.ErrorContains(t, archive.DecodeXML("doc/_rels/part.xml.rels", new(int)), "parse part")
+
+	_, err = Open([]byte("not a zip"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,324 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package epub provides EPUB document reader implementation.
+package epub
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/chunking"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	idocument "trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/htmlconv"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/zipdoc"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	itransform "trpc.group/trpc-go/trpc-agent-go/knowledge/internal/transform"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/transform"
+)
+
+var (
+	// supportedExtensions defines the file extensions supported by this reader.
+	supportedExtensions = []string{".epub"}
+)
+
+const containerPart = "META-INF/container.xml"
+
+// init registers the EPUB reader with the global registry.
+func init() {
+	reader.RegisterReader(supportedExtensions, New)
+}
+
+// Reader reads EPUB books and applies chunking strategies.
+// Each chapter of the reading order becomes its own document, converted to
+// Markdown so that headings, lists and tables keep their structure.
+type Reader struct {
+	chunk            bool
+	chunkingStrategy chunking.Strategy
+	transformers     []transform.Transformer
+}
+
+// New creates a new EPUB reader with the given options.
+// EPUB reader uses MarkdownChunking on the converted chapters by default.
+func New(opts ...reader.Option) reader.Reader {
+	// Build config from options
+	config := &reader.Config{
+		Chunk: true,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Build chunking strategy using the default builder for EPUB
+	strategy := reader.BuildChunkingStrategy(config, buildDefaultChunkingStrategy)
+
+	// Create reader from config
+	return &Reader{
+		chunk:            config.Chunk,
+		chunkingStrategy: strategy,
+		transformers:     config.Transformers,
+	}
+}
+
+// buildDefaultChunkingStrategy builds the default chunking strategy for EPUB reader.
+// Chapters are converted to Markdown, so MarkdownChunking splits them by headings.
+func buildDefaultChunkingStrategy(chunkSize, overlap int) chunking.Strategy {
+	var opts []chunking.MarkdownOption
+	if chunkSize != 0 {
+		opts = append(opts, chunking.WithMarkdownChunkSize(chunkSize))
+	}
+	if overlap != 0 {
+		opts = append(opts, chunking.WithMarkdownOverlap(overlap))
+	}
+	return chunking.NewMarkdownChunking(opts...)
+}
+
+// ReadFromReader reads EPUB content from an io.Reader and returns a list of documents.
+func (r *Reader) ReadFromReader(name string, rd io.Reader) ([]*document.Document, error) {
+	content, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	return r.readDocuments(name, content)
+}
+
+// ReadFromFile reads EPUB content from a file path and returns a list of documents.
+func (r *Reader) ReadFromFile(filePath string) ([]*document.Document, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Get file name without extension.
+	fileName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return r.readDocuments(fileName, content)
+}
+
+// ReadFromURL reads EPUB content from a URL and returns a list of documents.
+func (r *Reader) ReadFromURL(urlStr string) ([]*document.Document, error) {
+	// Validate URL before making HTTP request.
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
+	}
+
+	// Download EPUB from URL.
+	resp, err := http.Get(parsedURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Get file name from URL.
+	fileName := r.extractFileNameFromURL(urlStr)
+	return r.ReadFromReader(fileName, resp.Body)
+}
+
+// chapter is a converted content document of the reading order.
+type chapter struct {
+	title    string
+	markdown string
+}
+
+// readDocuments parses a book and turns each chapter into documents.
+func (r *Reader) readDocuments(name string, content []byte) ([]*document.Document, error) {
+	bookTitle, chapters, err := parseBook(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse EPUB: %w", err)
+	}
+
+	docs := make([]*document.Document, 0, len(chapters))
+	for i, ch := range chapters {
+		doc := idocument.CreateDocument(ch.markdown, name)
+		doc.Metadata[source.MetaChapterIndex] = i + 1
+		if ch.title != "" {
+			doc.Metadata[source.MetaChapterTitle] = ch.title
+		}
+		if bookTitle != "" {
+			doc.Metadata[source.MetaTitle] = bookTitle
+		}
+		docs = append(docs, doc)
+	}
+
+	// Apply preprocess.
+	docs, err = itransform.ApplyPreprocess(docs, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply preprocess: %w", err)
+	}
+
+	// Apply chunking if enabled.
+	if r.chunk {
+		docs, err = r.chunkDocuments(docs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply postprocess.
+	docs, err = itransform.ApplyPostprocess(docs, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply postprocess: %w", err)
+	}
+
+	return docs, nil
+}
+
+// chunkDocuments applies chunking to documents.
+func (r *Reader) chunkDocuments(docs []*document.Document) ([]*document.Document, error) {
+	if r.chunkingStrategy == nil {
+		r.chunkingStrategy = buildDefaultChunkingStrategy(0, 0)
+	}
+
+	var result []*document.Document
+	for _, doc := range docs {
+		chunks, err := r.chunkingStrategy.Chunk(doc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, chunks...)
+	}
+	return result, nil
+}
+
+// extractFileNameFromURL extracts a file name from a URL.
+func (r *Reader) extractFileNameFromURL(url string) string {
+	// Extract the last part of the URL as the file name.
+	parts := strings.Split(url, "/")
+	if len(parts) > 0 {
+		fileName := parts[len(parts)-1]
+		// Remove query parameters and fragments.
+		if idx := strings.Index(fileName, "?"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		if idx := strings.Index(fileName, "#"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		// Remove file extension.
+		fileName = strings.TrimSuffix(fileName, ".epub")
+		if fileName != "" {
+			return fileName
+		}
+	}
+	return "epub_document"
+}
+
+// Name returns the name of this reader.
+func (r *Reader) Name() string {
+	return "EPUBReader"
+}
+
+// SupportedExtensions returns the file extensions this reader supports.
+func (r *Reader) SupportedExtensions() []string {
+	return supportedExtensions
+}
+
+type xmlContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type xmlPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// parseBook reads the title and the non-empty chapters of a book in reading
+// order.
+func parseBook(content []byte) (string, []chapter, error) {
+	archive, err := zipdoc.Open(content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var container xmlContainer
+	if err := archive.DecodeXML(containerPart, &container); err != nil {
+		return "", nil, err
+	}
+	packagePart := ""
+	for _, rootfile := range container.Rootfiles {
+		if rootfile.MediaType == "" || rootfile.MediaType == "application/oebps-package+xml" {
+			packagePart = rootfile.FullPath
+			break
+		}
+	}
+	if packagePart == "" {
+		return "", nil, fmt.Errorf("no package document in %s", containerPart)
+	}
+
+	var pkg xmlPackage
+	if err := archive.DecodeXML(packagePart, &pkg); err != nil {
+		return "", nil, err
+	}
+	bookTitle := ""
+	if len(pkg.Title) > 0 {
+		bookTitle = strings.Join(strings.Fields(pkg.Title[0]), " ")
+	}
+
+	type item struct{ href, mediaType string }
+	manifest := make(map[string]item, len(pkg.Manifest))
+	for _, it := range pkg.Manifest {
+		manifest[it.ID] = item{href: it.Href, mediaType: it.MediaType}
+	}
+
+	var chapters []chapter
+	for _, ref := range pkg.Spine {
+		it, ok := manifest[ref.IDRef]
+		if !ok || !isContentDocument(it.mediaType) {
+			continue
+		}
+		href, err := url.PathUnescape(it.href)
+		if err != nil {
+			href = it.href
+		}
+		data, err := archive.ReadFile(zipdoc.Resolve(packagePart, href))
+		if err != nil {
+			return "", nil, err
+		}
+		page, err := htmlconv.Convert(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, fmt.Errorf("convert %s: %w", href, err)
+		}
+		if page.Markdown == "" {
+			continue
+		}
+		title := page.Heading
+		if title == "" {
+			title = page.Title
+		}
+		chapters = append(chapters, chapter{title: title, markdown: page.Markdown})
+	}
+	return bookTitle, chapters, nil
+}
+
+func isContentDocument(mediaType string) bool {
+	switch mediaType {
+	case "application/xhtml+xml", "text/html":
+		return true
+	default:
+		return false
+	}
+}
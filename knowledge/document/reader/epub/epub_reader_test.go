@@ -0,0 +1,152 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func xhtml(title, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>` + title + `</title></head><body>` + body + `</body></html>`
+}
+
+func buildBook(t *testing.T) []byte {
+	t.Helper()
+	return buildZip(t, map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title> The Go
+  Handbook </dc:title></metadata>
+<manifest>
+  <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+  <item id="ch1" href="text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+  <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  <item id="css" href="style.css" media-type="text/css"/>
+</manifest>
+<spine><itemref idref="nav"/><itemref idref="ch2"/><itemref idref="css"/><itemref idref="missing"/><itemref idref="ch1"/></spine>
+</package>`,
+		"OEBPS/nav.xhtml": xhtml("Contents", `<nav><ol><li><a href="text/ch2.xhtml">Types</a></li></ol></nav>`),
+		"OEBPS/text/ch2.xhtml": xhtml("Types", `<section><h1>Types</h1><p>Go has structs.</p>`+
+			`<table><tr><th>Kind</th></tr><tr><td>int</td></tr></table></section>`),
+		"OEBPS/text/chapter 1.xhtml": xhtml("Untitled", `<p>Preface without heading.</p>`),
+		"OEBPS/style.css":            "body{}",
+	})
+}
+
+func TestEPUBReader_Chapters(t *testing.T) {
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromReader("handbook", bytes.NewReader(buildBook(t)))
+	require.NoError(t, err)
+	require.Len(t, docs, 2, "the navigation document has no content")
+
+	first := docs[0]
+	assert.Equal(t, "handbook", first.Name)
+	assert.Equal(t, "# Types\n\nGo has structs.\n\n| Kind |\n| --- |\n| int |", first.Content)
+	assert.Equal(t, 1, first.Metadata[source.MetaChapterIndex])
+	assert.Equal(t, "Types", first.Metadata[source.MetaChapterTitle])
+	assert.Equal(t, "The Go Handbook", first.Metadata[source.MetaTitle])
+
+	second := docs[1]
+	assert.Equal(t, "Preface without heading.", second.Content)
+	assert.Equal(t, 2, second.Metadata[source.MetaChapterIndex])
+	assert.Equal(t, "Untitled", second.Metadata[source.MetaChapterTitle], "the document title names chapters without headings")
+}
+
+func TestEPUBReader_Chunking(t *testing.T) {
+	rdr := New()
+	docs, err := rdr.ReadFromReader("handbook", bytes.NewReader(buildBook(t)))
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	for _, doc := range docs {
+		assert.Equal(t, "The Go Handbook", doc.Metadata[source.MetaTitle])
+		assert.NotNil(t, doc.Metadata[source.MetaChapterIndex])
+		assert.NotNil(t, doc.Metadata[source.MetaChunkIndex])
+	}
+}
+
+func TestEPUBReader_Errors(t *testing.T) {
+	rdr := New()
+	_, err := rdr.ReadFromReader("bad", strings.NewReader("not a zip"))
+	assert.ErrorContains(t, err, "failed to parse EPUB")
+
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, map[string]string{"mimetype": "application/epub+zip"})))
+	assert.ErrorContains(t, err, "part not found")
+
+	files := map[string]string{
+		"META-INF/container.xml": `<container><rootfiles/></container>`,
+	}
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, files)))
+	assert.ErrorContains(t, err, "no package document")
+
+	files["META-INF/container.xml"] = `<container><rootfiles><rootfile full-path="book.opf"/></rootfiles></container>`
+	files["book.opf"] = `<package><manifest><item id="a" href="a.xhtml" media-type="application/xhtml+xml"/></manifest>` +
+		`<spine><itemref idref="a"/></spine></package>`
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, files)))
+	assert.ErrorContains(t, err, "part not found: a.xhtml")
+}
+
+func TestEPUBReader_ReadFromFileAndURL(t *testing.T) {
+	data := buildBook(t)
+	path := filepath.Join(t.TempDir(), "go-handbook.epub")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "go-handbook", docs[0].Name)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+	docs, err = rdr.ReadFromURL(server.URL + "/books/go-handbook.epub")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "go-handbook", docs[0].Name)
+
+	_, err = rdr.ReadFromURL("ftp://example.com/book.epub")
+	assert.ErrorContains(t, err, "unsupported URL scheme")
+
+	assert.Equal(t, "EPUBReader", rdr.Name())
+	assert.Equal(t, []string{".epub"}, rdr.SupportedExtensions())
+	_, ok := reader.GetReader(".epub")
+	assert.True(t, ok)
+}
@@ -0,0 +1,221 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package html provides HTML document reader implementation.
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/chunking"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	idocument "trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/htmlconv"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	itransform "trpc.group/trpc-go/trpc-agent-go/knowledge/internal/transform"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/transform"
+)
+
+var (
+	// supportedExtensions defines the file extensions supported by this reader.
+	supportedExtensions = []string{".html", ".htm"}
+)
+
+// init registers the HTML reader with the global registry.
+func init() {
+	reader.RegisterReader(supportedExtensions, New)
+}
+
+// Reader reads HTML documents and applies chunking strategies.
+// Only the main content of a page is kept: navigation, sidebars, footers,
+// scripts and hidden elements are dropped, and the rest is converted to
+// Markdown so that headings, lists and tables keep their structure.
+type Reader struct {
+	chunk            bool
+	chunkingStrategy chunking.Strategy
+	transformers     []transform.Transformer
+}
+
+// New creates a new HTML reader with the given options.
+// HTML reader uses MarkdownChunking on the converted content by default.
+func New(opts ...reader.Option) reader.Reader {
+	// Build config from options
+	config := &reader.Config{
+		Chunk: true,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Build chunking strategy using the default builder for HTML
+	strategy := reader.BuildChunkingStrategy(config, buildDefaultChunkingStrategy)
+
+	// Create reader from config
+	return &Reader{
+		chunk:            config.Chunk,
+		chunkingStrategy: strategy,
+		transformers:     config.Transformers,
+	}
+}
+
+// buildDefaultChunkingStrategy builds the default chunking strategy for HTML reader.
+// The page is converted to Markdown, so MarkdownChunking splits it by headings.
+func buildDefaultChunkingStrategy(chunkSize, overlap int) chunking.Strategy {
+	var opts []chunking.MarkdownOption
+	if chunkSize != 0 {
+		opts = append(opts, chunking.WithMarkdownChunkSize(chunkSize))
+	}
+	if overlap != 0 {
+		opts = append(opts, chunking.WithMarkdownOverlap(overlap))
+	}
+	return chunking.NewMarkdownChunking(opts...)
+}
+
+// ReadFromReader reads HTML content from an io.Reader and returns a list of documents.
+func (r *Reader) ReadFromReader(name string, rd io.Reader) ([]*document.Document, error) {
+	content, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	return r.readDocuments(name, content)
+}
+
+// ReadFromFile reads HTML content from a file path and returns a list of documents.
+func (r *Reader) ReadFromFile(filePath string) ([]*document.Document, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Get file name without extension.
+	fileName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return r.readDocuments(fileName, content)
+}
+
+// ReadFromURL reads HTML content from a URL and returns a list of documents.
+func (r *Reader) ReadFromURL(urlStr string) ([]*document.Document, error) {
+	// Validate URL before making HTTP request.
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
+	}
+
+	// Download HTML from URL.
+	resp, err := http.Get(parsedURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Get file name from URL.
+	fileName := r.extractFileNameFromURL(urlStr)
+	return r.ReadFromReader(fileName, resp.Body)
+}
+
+// readDocuments converts an HTML page and turns it into documents.
+func (r *Reader) readDocuments(name string, content []byte) ([]*document.Document, error) {
+	page, err := htmlconv.Convert(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create document.
+	doc := idocument.CreateDocument(page.Markdown, name)
+	if title := pageTitle(page); title != "" {
+		doc.Metadata[source.MetaTitle] = title
+	}
+
+	// Apply preprocess.
+	docs, err := itransform.ApplyPreprocess([]*document.Document{doc}, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply preprocess: %w", err)
+	}
+
+	// Apply chunking if enabled.
+	if r.chunk {
+		docs, err = r.chunkDocuments(docs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply postprocess.
+	docs, err = itransform.ApplyPostprocess(docs, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply postprocess: %w", err)
+	}
+
+	return docs, nil
+}
+
+// pageTitle prefers the <title> of a page over its first heading.
+func pageTitle(page *htmlconv.Result) string {
+	if page.Title != "" {
+		return page.Title
+	}
+	return page.Heading
+}
+
+// chunkDocuments applies chunking to documents.
+func (r *Reader) chunkDocuments(docs []*document.Document) ([]*document.Document, error) {
+	if r.chunkingStrategy == nil {
+		r.chunkingStrategy = buildDefaultChunkingStrategy(0, 0)
+	}
+
+	var result []*document.Document
+	for _, doc := range docs {
+		chunks, err := r.chunkingStrategy.Chunk(doc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, chunks...)
+	}
+	return result, nil
+}
+
+// extractFileNameFromURL extracts a file name from a URL.
+func (r *Reader) extractFileNameFromURL(url string) string {
+	// Extract the last part of the URL as the file name.
+	parts := strings.Split(url, "/")
+	if len(parts) > 0 {
+		fileName := parts[len(parts)-1]
+		// Remove query parameters and fragments.
+		if idx := strings.Index(fileName, "?"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		if idx := strings.Index(fileName, "#"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		// Remove file extension.
+		fileName = strings.TrimSuffix(strings.TrimSuffix(fileName, ".html"), ".htm")
+		if fileName != "" {
+			return fileName
+		}
+	}
+	return "html_document"
+}
+
+// Name returns the name of this reader.
+func (r *Reader) Name() string {
+	return "HTMLReader"
+}
+
+// SupportedExtensions returns the file extensions this reader supports.
+func (r *Reader) SupportedExtensions() []string {
+	return supportedExtensions
+}
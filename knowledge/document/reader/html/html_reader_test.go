@@ -0,0 +1,113 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package html
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+const testPage = `<!DOCTYPE html>
+<html><head><title>Release Notes</title></head>
+<body>
+<nav><a href="/">Home</a> <a href="/docs">Docs</a></nav>
+<main>
+<h1>Version 2</h1>
+<p>Version 2 adds streaming.</p>
+<h2>Limits</h2>
+<table>
+<tr><th>Plan</th><th>Requests</th></tr>
+<tr><td>Free</td><td>100</td></tr>
+</table>
+</main>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestHTMLReader_ReadFromReader(t *testing.T) {
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromReader("notes", strings.NewReader(testPage))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+
+	doc := docs[0]
+	assert.Equal(t, "notes", doc.Name)
+	assert.Equal(t, "Release Notes", doc.Metadata[source.MetaTitle])
+	assert.Equal(t, "# Version 2\n\nVersion 2 adds streaming.\n\n## Limits\n\n"+
+		"| Plan | Requests |\n| --- | --- |\n| Free | 100 |", doc.Content)
+	assert.NotContains(t, doc.Content, "Docs")
+	assert.NotContains(t, doc.Content, "Copyright")
+}
+
+func TestHTMLReader_Chunking(t *testing.T) {
+	rdr := New(reader.WithChunkSize(60))
+	docs, err := rdr.ReadFromReader("notes", strings.NewReader(testPage))
+	require.NoError(t, err)
+	require.Greater(t, len(docs), 1, "headings split the page")
+	for _, doc := range docs {
+		assert.Equal(t, "Release Notes", doc.Metadata[source.MetaTitle])
+		assert.NotEmpty(t, doc.Metadata[source.MetaChunkIndex])
+	}
+	assert.Contains(t, docs[len(docs)-1].Content, "| Free | 100 |")
+
+	// Without a <title>, the first heading names the page.
+	docs, err = rdr.ReadFromReader("page", strings.NewReader("<h1>Heading</h1><p>text</p>"))
+	require.NoError(t, err)
+	require.NotEmpty(t, docs)
+	assert.Equal(t, "Heading", docs[0].Metadata[source.MetaTitle])
+}
+
+func TestHTMLReader_ReadFromFileAndURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.html")
+	require.NoError(t, os.WriteFile(path, []byte(testPage), 0o600))
+
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "release", docs[0].Name)
+
+	_, err = rdr.ReadFromFile(filepath.Join(t.TempDir(), "missing.html"))
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(testPage))
+	}))
+	defer server.Close()
+	docs, err = rdr.ReadFromURL(server.URL + "/docs/release.htm?v=2")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "release", docs[0].Name)
+
+	_, err = rdr.ReadFromURL("ftp://example.com/page.html")
+	assert.ErrorContains(t, err, "unsupported URL scheme")
+}
+
+func TestHTMLReader_Registration(t *testing.T) {
+	rdr := New()
+	assert.Equal(t, "HTMLReader", rdr.Name())
+	assert.Equal(t, []string{".html", ".htm"}, rdr.SupportedExtensions())
+
+	for _, ext := range []string{".html", ".HTM"} {
+		r, ok := reader.GetReader(ext)
+		require.True(t, ok, ext)
+		assert.Equal(t, "HTMLReader", r.Name())
+	}
+	assert.Contains(t, reader.GetAllReaders(), "html")
+}
@@ -0,0 +1,454 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package pptx provides PPTX document reader implementation.
+package pptx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/chunking"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	idocument "trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/zipdoc"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	itransform "trpc.group/trpc-go/trpc-agent-go/knowledge/internal/transform"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/transform"
+)
+
+var (
+	// supportedExtensions defines the file extensions supported by this reader.
+	supportedExtensions = []string{".pptx"}
+)
+
+const (
+	nsDrawingML           = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	relTypeOfficeDocument = "/officeDocument"
+	relTypeNotesSlide     = "/notesSlide"
+	defaultPresentation   = "ppt/presentation.xml"
+)
+
+// init registers the PPTX reader with the global registry.
+func init() {
+	reader.RegisterReader(supportedExtensions, New)
+}
+
+// Reader reads PPTX presentations and applies chunking strategies.
+// Each slide becomes its own document holding the slide title, the slide
+// text and the speaker notes.
+type Reader struct {
+	chunk            bool
+	chunkingStrategy chunking.Strategy
+	transformers     []transform.Transformer
+}
+
+// New creates a new PPTX reader with the given options.
+// PPTX reader uses FixedSizeChunking by default.
+func New(opts ...reader.Option) reader.Reader {
+	// Build config from options
+	config := &reader.Config{
+		Chunk: true,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Build chunking strategy using the default builder for PPTX
+	strategy := reader.BuildChunkingStrategy(config, buildDefaultChunkingStrategy)
+
+	// Create reader from config
+	return &Reader{
+		chunk:            config.Chunk,
+		chunkingStrategy: strategy,
+		transformers:     config.Transformers,
+	}
+}
+
+// buildDefaultChunkingStrategy builds the default chunking strategy for PPTX reader.
+// Slides are usually short, so most slides fit into a single chunk.
+func buildDefaultChunkingStrategy(chunkSize, overlap int) chunking.Strategy {
+	var opts []chunking.Option
+	if chunkSize != 0 {
+		opts = append(opts, chunking.WithChunkSize(chunkSize))
+	}
+	if overlap != 0 {
+		opts = append(opts, chunking.WithOverlap(overlap))
+	}
+	return chunking.NewFixedSizeChunking(opts...)
+}
+
+// ReadFromReader reads PPTX content from an io.Reader and returns a list of documents.
+func (r *Reader) ReadFromReader(name string, rd io.Reader) ([]*document.Document, error) {
+	content, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	return r.readDocuments(name, content)
+}
+
+// ReadFromFile reads PPTX content from a file path and returns a list of documents.
+func (r *Reader) ReadFromFile(filePath string) ([]*document.Document, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Get file name without extension.
+	fileName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return r.readDocuments(fileName, content)
+}
+
+// ReadFromURL reads PPTX content from a URL and returns a list of documents.
+func (r *Reader) ReadFromURL(urlStr string) ([]*document.Document, error) {
+	// Validate URL before making HTTP request.
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
+	}
+
+	// Download PPTX from URL.
+	resp, err := http.Get(parsedURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Get file name from URL.
+	fileName := r.extractFileNameFromURL(urlStr)
+	return r.ReadFromReader(fileName, resp.Body)
+}
+
+// slide is the extracted text of one slide.
+type slide struct {
+	number int
+	title  string
+	body   []string
+	notes  string
+}
+
+// content renders the slide title, text and speaker notes.
+func (s *slide) content() string {
+	var parts []string
+	if s.title != "" {
+		parts = append(parts, s.title)
+	}
+	if len(s.body) > 0 {
+		parts = append(parts, strings.Join(s.body, "\n"))
+	}
+	if s.notes != "" {
+		parts = append(parts, "Notes: "+s.notes)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// readDocuments parses a presentation and turns each slide into documents.
+func (r *Reader) readDocuments(name string, content []byte) ([]*document.Document, error) {
+	slides, err := parsePresentation(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PPTX: %w", err)
+	}
+
+	var docs []*document.Document
+	for _, s := range slides {
+		text := s.content()
+		if text == "" {
+			continue
+		}
+		doc := idocument.CreateDocument(text, name)
+		doc.Metadata[source.MetaSlideNumber] = s.number
+		if s.title != "" {
+			doc.Metadata[source.MetaSlideTitle] = s.title
+		}
+		if s.notes != "" {
+			doc.Metadata[source.MetaSpeakerNotes] = s.notes
+		}
+		docs = append(docs, doc)
+	}
+
+	// Apply preprocess.
+	docs, err = itransform.ApplyPreprocess(docs, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply preprocess: %w", err)
+	}
+
+	// Apply chunking if enabled.
+	if r.chunk {
+		docs, err = r.chunkDocuments(docs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply postprocess.
+	docs, err = itransform.ApplyPostprocess(docs, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply postprocess: %w", err)
+	}
+
+	return docs, nil
+}
+
+// chunkDocuments applies chunking to documents.
+func (r *Reader) chunkDocuments(docs []*document.Document) ([]*document.Document, error) {
+	if r.chunkingStrategy == nil {
+		r.chunkingStrategy = buildDefaultChunkingStrategy(0, 0)
+	}
+
+	var result []*document.Document
+	for _, doc := range docs {
+		chunks, err := r.chunkingStrategy.Chunk(doc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, chunks...)
+	}
+	return result, nil
+}
+
+// extractFileNameFromURL extracts a file name from a URL.
+func (r *Reader) extractFileNameFromURL(url string) string {
+	// Extract the last part of the URL as the file name.
+	parts := strings.Split(url, "/")
+	if len(parts) > 0 {
+		fileName := parts[len(parts)-1]
+		// Remove query parameters and fragments.
+		if idx := strings.Index(fileName, "?"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		if idx := strings.Index(fileName, "#"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		// Remove file extension.
+		fileName = strings.TrimSuffix(fileName, ".pptx")
+		if fileName != "" {
+			return fileName
+		}
+	}
+	return "pptx_document"
+}
+
+// Name returns the name of this reader.
+func (r *Reader) Name() string {
+	return "PPTXReader"
+}
+
+// SupportedExtensions returns the file extensions this reader supports.
+func (r *Reader) SupportedExtensions() []string {
+	return supportedExtensions
+}
+
+type xmlPresentation struct {
+	SlideIDs []struct {
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sldIdLst>sldId"`
+}
+
+// parsePresentation reads the slides of a presentation in presentation order.
+func parsePresentation(content []byte) ([]*slide, error) {
+	archive, err := zipdoc.Open(content)
+	if err != nil {
+		return nil, err
+	}
+
+	presentationPart := defaultPresentation
+	rootRels, err := archive.Relationships("")
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range rootRels {
+		if strings.HasSuffix(rel.Type, relTypeOfficeDocument) {
+			presentationPart = rel.Target
+		}
+	}
+
+	var presentation xmlPresentation
+	if err := archive.DecodeXML(presentationPart, &presentation); err != nil {
+		return nil, err
+	}
+	rels, err := archive.Relationships(presentationPart)
+	if err != nil {
+		return nil, err
+	}
+
+	slides := make([]*slide, 0, len(presentation.SlideIDs))
+	for i, id := range presentation.SlideIDs {
+		relID := ""
+		for _, attr := range id.Attrs {
+			if attr.Name.Local == "id" && attr.Name.Space != "" {
+				relID = attr.Value
+			}
+		}
+		rel, ok := rels[relID]
+		if !ok {
+			return nil, fmt.Errorf("slide %d has no part", i+1)
+		}
+		s, err := parseSlide(archive, rel.Target)
+		if err != nil {
+			return nil, err
+		}
+		s.number = i + 1
+		slides = append(slides, s)
+	}
+	return slides, nil
+}
+
+// parseSlide extracts the title, text and speaker notes of a slide.
+func parseSlide(archive *zipdoc.Archive, part string) (*slide, error) {
+	data, err := archive.ReadFile(part)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := parseShapes(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse part %s: %w", part, err)
+	}
+
+	s := &slide{}
+	for _, sh := range shapes {
+		if s.title == "" && (sh.placeholder == "title" || sh.placeholder == "ctrTitle") {
+			s.title = strings.Join(sh.paragraphs, " ")
+			continue
+		}
+		switch sh.placeholder {
+		case "sldNum", "dt", "ftr", "hdr":
+			continue
+		}
+		s.body = append(s.body, sh.paragraphs...)
+	}
+
+	rels, err := archive.Relationships(part)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range rels {
+		if !strings.HasSuffix(rel.Type, relTypeNotesSlide) {
+			continue
+		}
+		notes, err := parseNotes(archive, rel.Target)
+		if err != nil {
+			return nil, err
+		}
+		s.notes = notes
+	}
+	return s, nil
+}
+
+// parseNotes extracts the speaker notes from the body placeholder of a notes
+// slide, leaving out the slide image, number and header placeholders.
+func parseNotes(archive *zipdoc.Archive, part string) (string, error) {
+	data, err := archive.ReadFile(part)
+	if err != nil {
+		if errors.Is(err, zipdoc.ErrPartNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	shapes, err := parseShapes(data)
+	if err != nil {
+		return "", fmt.Errorf("parse part %s: %w", part, err)
+	}
+	var paragraphs []string
+	for _, sh := range shapes {
+		if sh.placeholder == "body" {
+			paragraphs = append(paragraphs, sh.paragraphs...)
+		}
+	}
+	return strings.Join(paragraphs, "\n"), nil
+}
+
+// shape is the text of a shape or table in document order.
+type shape struct {
+	placeholder string
+	paragraphs  []string
+}
+
+// parseShapes collects the non-empty DrawingML paragraphs of a slide part,
+// grouped by the shape that holds them.
+func parseShapes(data []byte) ([]shape, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		shapes    []shape
+		stack     []*shape
+		paragraph *strings.Builder
+		inText    bool
+	)
+	current := func() *shape {
+		if len(stack) == 0 {
+			return nil
+		}
+		return stack[len(stack)-1]
+	}
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "sp" || t.Name.Local == "graphicFrame":
+				stack = append(stack, &shape{})
+			case t.Name.Local == "ph" && current() != nil:
+				current().placeholder = "body"
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "type" {
+						current().placeholder = attr.Value
+					}
+				}
+			case t.Name.Space == nsDrawingML && t.Name.Local == "p":
+				paragraph = &strings.Builder{}
+			case t.Name.Space == nsDrawingML && t.Name.Local == "t":
+				inText = true
+			case t.Name.Space == nsDrawingML && t.Name.Local == "br" && paragraph != nil:
+				paragraph.WriteString(" ")
+			}
+		case xml.CharData:
+			if inText && paragraph != nil {
+				paragraph.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == nsDrawingML && t.Name.Local == "t":
+				inText = false
+			case t.Name.Space == nsDrawingML && t.Name.Local == "p" && paragraph != nil:
+				text := strings.Join(strings.Fields(paragraph.String()), " ")
+				paragraph = nil
+				if text == "" {
+					continue
+				}
+				if sh := current(); sh != nil {
+					sh.paragraphs = append(sh.paragraphs, text)
+				} else {
+					shapes = append(shapes, shape{paragraphs: []string{text}})
+				}
+			case (t.Name.Local == "sp" || t.Name.Local == "graphicFrame") && len(stack) > 0:
+				sh := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if len(sh.paragraphs) > 0 {
+					shapes = append(shapes, *sh)
+				}
+			}
+		}
+	}
+	return shapes, nil
+}
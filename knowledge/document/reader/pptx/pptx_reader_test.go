@@ -0,0 +1,186 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+const (
+	nsPresentation = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	nsRel          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	relNotes       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+	relSlide       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// textShape renders a shape with an optional placeholder and paragraphs.
+func textShape(placeholder string, paragraphs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<p:sp><p:nvSpPr><p:nvPr>`)
+	if placeholder != "" {
+		b.WriteString(placeholder)
+	}
+	b.WriteString(`</p:nvPr></p:nvSpPr><p:txBody>`)
+	for _, p := range paragraphs {
+		b.WriteString(`<a:p><a:r><a:t>` + p + `</a:t></a:r></a:p>`)
+	}
+	b.WriteString(`</p:txBody></p:sp>`)
+	return b.String()
+}
+
+func slidePart(shapes ...string) string {
+	return `<p:sld xmlns:p="` + nsPresentation + `" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><p:cSld><p:spTree>` +
+		strings.Join(shapes, "") + `</p:spTree></p:cSld></p:sld>`
+}
+
+func buildPresentation(t *testing.T) []byte {
+	t.Helper()
+	table := `<p:graphicFrame><a:graphic><a:graphicData><a:tbl><a:tr>` +
+		`<a:tc><a:txBody><a:p><a:r><a:t>Q1</a:t></a:r></a:p></a:txBody></a:tc>` +
+		`<a:tc><a:txBody><a:p><a:r><a:t>Q2</a:t></a:r></a:p></a:txBody></a:tc>` +
+		`</a:tr></a:tbl></a:graphicData></a:graphic></p:graphicFrame>`
+	return buildZip(t, map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/>
+</Relationships>`,
+		// Slides are listed in presentation order, not part name order.
+		"ppt/presentation.xml": `<p:presentation xmlns:p="` + nsPresentation + `" xmlns:r="` + nsRel + `"><p:sldIdLst>
+<p:sldId id="256" r:id="rId3"/><p:sldId id="257" r:id="rId2"/><p:sldId id="258" r:id="rId4"/>
+</p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<Relationships>
+<Relationship Id="rId2" Type="` + relSlide + `" Target="slides/slide1.xml"/>
+<Relationship Id="rId3" Type="` + relSlide + `" Target="slides/slide2.xml"/>
+<Relationship Id="rId4" Type="` + relSlide + `" Target="slides/slide3.xml"/>
+</Relationships>`,
+		"ppt/slides/slide2.xml": slidePart(
+			textShape(`<p:ph type="ctrTitle"/>`, "Quarterly   Review"),
+			textShape(`<p:ph type="subTitle" idx="1"/>`, "Finance team"),
+			textShape(`<p:ph type="sldNum" idx="12"/>`, "1"),
+		),
+		"ppt/slides/_rels/slide2.xml.rels": `<Relationships>
+<Relationship Id="rId1" Type="` + relNotes + `" Target="../notesSlides/notesSlide1.xml"/>
+</Relationships>`,
+		"ppt/notesSlides/notesSlide1.xml": slidePart(
+			textShape(`<p:ph type="sldImg"/>`),
+			textShape(`<p:ph type="body" idx="1"/>`, "Welcome everyone.", "Keep it short."),
+			textShape(`<p:ph type="sldNum" idx="5"/>`, "1"),
+		),
+		"ppt/slides/slide1.xml": slidePart(
+			textShape(`<p:ph type="title"/>`, "Revenue"),
+			textShape(`<p:ph idx="1"/>`, "Revenue grew", ""),
+			table,
+		),
+		"ppt/slides/slide3.xml": slidePart(textShape("")),
+	})
+}
+
+func TestPPTXReader_Slides(t *testing.T) {
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromReader("deck", bytes.NewReader(buildPresentation(t)))
+	require.NoError(t, err)
+	require.Len(t, docs, 2, "slides without text are skipped")
+
+	first := docs[0]
+	assert.Equal(t, "deck", first.Name)
+	assert.Equal(t, "Quarterly Review\n\nFinance team\n\nNotes: Welcome everyone.\nKeep it short.", first.Content)
+	assert.Equal(t, 1, first.Metadata[source.MetaSlideNumber])
+	assert.Equal(t, "Quarterly Review", first.Metadata[source.MetaSlideTitle])
+	assert.Equal(t, "Welcome everyone.\nKeep it short.", first.Metadata[source.MetaSpeakerNotes])
+
+	second := docs[1]
+	assert.Equal(t, "Revenue\n\nRevenue grew\nQ1\nQ2", second.Content)
+	assert.Equal(t, 2, second.Metadata[source.MetaSlideNumber])
+	assert.Equal(t, "Revenue", second.Metadata[source.MetaSlideTitle])
+	assert.NotContains(t, second.Metadata, source.MetaSpeakerNotes)
+}
+
+func TestPPTXReader_Chunking(t *testing.T) {
+	rdr := New(reader.WithChunkSize(30))
+	docs, err := rdr.ReadFromReader("deck", bytes.NewReader(buildPresentation(t)))
+	require.NoError(t, err)
+	require.Greater(t, len(docs), 2)
+	for _, doc := range docs {
+		assert.NotNil(t, doc.Metadata[source.MetaSlideNumber])
+		assert.LessOrEqual(t, len([]rune(doc.Content)), 30)
+	}
+}
+
+func TestPPTXReader_Errors(t *testing.T) {
+	rdr := New()
+	_, err := rdr.ReadFromReader("bad", strings.NewReader("not a zip"))
+	assert.ErrorContains(t, err, "failed to parse PPTX")
+
+	files := map[string]string{
+		"ppt/presentation.xml": `<p:presentation xmlns:p="` + nsPresentation + `" xmlns:r="` + nsRel + `"><p:sldIdLst>
+<p:sldId id="256" r:id="rId9"/></p:sldIdLst></p:presentation>`,
+	}
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, files)))
+	assert.ErrorContains(t, err, "slide 1 has no part")
+
+	files["ppt/_rels/presentation.xml.rels"] = `<Relationships><Relationship Id="rId9" Target="slides/slide1.xml"/></Relationships>`
+	files["ppt/slides/slide1.xml"] = `<p:sld>`
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, files)))
+	assert.ErrorContains(t, err, "parse part ppt/slides/slide1.xml")
+}
+
+func TestPPTXReader_ReadFromFileAndURL(t *testing.T) {
+	data := buildPresentation(t)
+	path := filepath.Join(t.TempDir(), "review.pptx")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "review", docs[0].Name)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+	docs, err = rdr.ReadFromURL(server.URL + "/review.pptx?download=1")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "review", docs[0].Name)
+
+	_, err = rdr.ReadFromURL("ftp://example.com/review.pptx")
+	assert.ErrorContains(t, err, "unsupported URL scheme")
+
+	assert.Equal(t, "PPTXReader", rdr.Name())
+	assert.Equal(t, []string{".pptx"}, rdr.SupportedExtensions())
+	_, ok := reader.GetReader(".pptx")
+	assert.True(t, ok)
+}
@@ -103,6 +103,8 @@ func extensionToType(ext string) string {
 		return "pdf"
 	case "docx", "doc":
 		return "docx"
+	case "html", "htm":
+		return "html"
 	case "py":
 		return "python"
 	default:
@@ -107,6 +107,8 @@ func TestExtensionToType(t *testing.T) {
 		{".csv", "csv"},
 		{".pdf", "pdf"},
 		{".docx", "docx"},
+		{".html", "html"},
+		{".htm", "html"},
 		{".py", "python"},
 		{".xlsx", "xlsx"}, // unknown -> passthrough without dot
 	}
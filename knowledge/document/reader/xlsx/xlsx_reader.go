@@ -0,0 +1,522 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package xlsx provides XLSX document reader implementation.
+package xlsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/chunking"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	idocument "trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/internal/zipdoc"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	itransform "trpc.group/trpc-go/trpc-agent-go/knowledge/internal/transform"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/transform"
+)
+
+var (
+	// supportedExtensions defines the file extensions supported by this reader.
+	supportedExtensions = []string{".xlsx"}
+)
+
+const (
+	relTypeOfficeDocument = "/officeDocument"
+	relTypeSharedStrings  = "/sharedStrings"
+	defaultWorkbookPart   = "xl/workbook.xml"
+)
+
+// init registers the XLSX reader with the global registry.
+func init() {
+	reader.RegisterReader(supportedExtensions, New)
+}
+
+// Reader reads XLSX workbooks and applies chunking strategies.
+// Each sheet becomes its own document with one line per row. The first
+// non-empty row is treated as the header, and the cells of the following rows
+// are labelled with their column headers so that every chunk is
+// self-describing.
+type Reader struct {
+	chunk            bool
+	chunkingStrategy chunking.Strategy
+	transformers     []transform.Transformer
+}
+
+// New creates a new XLSX reader with the given options.
+// XLSX reader uses line-preserving FixedSizeChunking by default.
+func New(opts ...reader.Option) reader.Reader {
+	// Build config from options
+	config := &reader.Config{
+		Chunk: true,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Build chunking strategy using the default builder for XLSX
+	strategy := reader.BuildChunkingStrategy(config, buildDefaultChunkingStrategy)
+
+	// Create reader from config
+	return &Reader{
+		chunk:            config.Chunk,
+		chunkingStrategy: strategy,
+		transformers:     config.Transformers,
+	}
+}
+
+// buildDefaultChunkingStrategy builds the default chunking strategy for XLSX reader.
+// Newlines keep complete rows together whenever one row fits the budget.
+func buildDefaultChunkingStrategy(chunkSize, overlap int) chunking.Strategy {
+	opts := []chunking.Option{chunking.WithPreserveLines()}
+	if chunkSize != 0 {
+		opts = append(opts, chunking.WithChunkSize(chunkSize))
+	}
+	if overlap != 0 {
+		opts = append(opts, chunking.WithOverlap(overlap))
+	}
+	return chunking.NewFixedSizeChunking(opts...)
+}
+
+// ReadFromReader reads XLSX content from an io.Reader and returns a list of documents.
+func (r *Reader) ReadFromReader(name string, rd io.Reader) ([]*document.Document, error) {
+	content, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	return r.readDocuments(name, content)
+}
+
+// ReadFromFile reads XLSX content from a file path and returns a list of documents.
+func (r *Reader) ReadFromFile(filePath string) ([]*document.Document, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Get file name without extension.
+	fileName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return r.readDocuments(fileName, content)
+}
+
+// ReadFromURL reads XLSX content from a URL and returns a list of documents.
+func (r *Reader) ReadFromURL(urlStr string) ([]*document.Document, error) {
+	// Validate URL before making HTTP request.
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
+	}
+
+	// Download XLSX from URL.
+	resp, err := http.Get(parsedURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Get file name from URL.
+	fileName := r.extractFileNameFromURL(urlStr)
+	return r.ReadFromReader(fileName, resp.Body)
+}
+
+// sheetText is the text of one sheet, one line per non-empty row.
+type sheetText struct {
+	name  string
+	lines []string
+	rows  []int
+}
+
+// readDocuments parses a workbook and turns each sheet into documents.
+func (r *Reader) readDocuments(name string, content []byte) ([]*document.Document, error) {
+	sheets, err := parseWorkbook(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+
+	var docs []*document.Document
+	for i, sheet := range sheets {
+		if len(sheet.lines) == 0 {
+			continue
+		}
+		doc := idocument.CreateDocument(strings.Join(sheet.lines, "\n"), name)
+		doc.Metadata[source.MetaSheetName] = sheet.name
+		doc.Metadata[source.MetaSheetIndex] = i + 1
+		doc.Metadata[source.MetaRowStart] = sheet.rows[0]
+		doc.Metadata[source.MetaRowEnd] = sheet.rows[len(sheet.rows)-1]
+
+		// Apply preprocess.
+		sheetDocs, err := itransform.ApplyPreprocess([]*document.Document{doc}, r.transformers...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply preprocess: %w", err)
+		}
+
+		// Apply chunking if enabled.
+		if r.chunk {
+			sheetDocs, err = r.chunkDocuments(sheetDocs, sheet)
+			if err != nil {
+				return nil, err
+			}
+		}
+		docs = append(docs, sheetDocs...)
+	}
+
+	// Apply postprocess.
+	docs, err = itransform.ApplyPostprocess(docs, r.transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply postprocess: %w", err)
+	}
+
+	return docs, nil
+}
+
+// chunkDocuments applies chunking to the documents of a sheet and records
+// the rows each chunk covers.
+func (r *Reader) chunkDocuments(docs []*document.Document, sheet sheetText) ([]*document.Document, error) {
+	if r.chunkingStrategy == nil {
+		r.chunkingStrategy = buildDefaultChunkingStrategy(0, 0)
+	}
+
+	var result []*document.Document
+	for _, doc := range docs {
+		chunks, err := r.chunkingStrategy.Chunk(doc)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Content == strings.Join(sheet.lines, "\n") {
+			setChunkRows(doc.Content, chunks, sheet)
+		}
+		result = append(result, chunks...)
+	}
+	return result, nil
+}
+
+// setChunkRows locates each chunk in the sheet text and sets the first and
+// last row it covers. Chunks that cannot be located, for example because a
+// transformer or custom strategy rewrote them, keep the rows of the sheet.
+func setChunkRows(content string, chunks []*document.Document, sheet sheetText) {
+	lineEnds := make([]int, len(sheet.lines))
+	offset := 0
+	for i, line := range sheet.lines {
+		offset += len(line)
+		lineEnds[i] = offset
+		offset++ // newline
+	}
+
+	from := 0
+	for _, chunk := range chunks {
+		if from > len(content) {
+			return
+		}
+		pos := strings.Index(content[from:], chunk.Content)
+		if pos < 0 || chunk.Content == "" {
+			continue
+		}
+		start := from + pos
+		end := start + len(chunk.Content)
+		first, last := -1, -1
+		lineStart := 0
+		for i, lineEnd := range lineEnds {
+			if lineStart < end && lineEnd > start {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+			lineStart = lineEnd + 1
+		}
+		if first >= 0 && first < len(sheet.rows) && last < len(sheet.rows) {
+			chunk.Metadata[source.MetaRowStart] = sheet.rows[first]
+			chunk.Metadata[source.MetaRowEnd] = sheet.rows[last]
+		}
+		from = start + 1
+	}
+}
+
+// extractFileNameFromURL extracts a file name from a URL.
+func (r *Reader) extractFileNameFromURL(url string) string {
+	// Extract the last part of the URL as the file name.
+	parts := strings.Split(url, "/")
+	if len(parts) > 0 {
+		fileName := parts[len(parts)-1]
+		// Remove query parameters and fragments.
+		if idx := strings.Index(fileName, "?"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		if idx := strings.Index(fileName, "#"); idx != -1 {
+			fileName = fileName[:idx]
+		}
+		// Remove file extension.
+		fileName = strings.TrimSuffix(fileName, ".xlsx")
+		if fileName != "" {
+			return fileName
+		}
+	}
+	return "xlsx_document"
+}
+
+// Name returns the name of this reader.
+func (r *Reader) Name() string {
+	return "XLSXReader"
+}
+
+// SupportedExtensions returns the file extensions this reader supports.
+func (r *Reader) SupportedExtensions() []string {
+	return supportedExtensions
+}
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name  string     `xml:"name,attr"`
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xmlSharedStrings struct {
+	Items []xmlText `xml:"si"`
+}
+
+type xmlWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string  `xml:"r,attr"`
+			T      string  `xml:"t,attr"`
+			V      string  `xml:"v"`
+			Inline xmlText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// parseWorkbook reads the sheets of a workbook in workbook order.
+func parseWorkbook(content []byte) ([]sheetText, error) {
+	archive, err := zipdoc.Open(content)
+	if err != nil {
+		return nil, err
+	}
+
+	workbookPart := defaultWorkbookPart
+	rootRels, err := archive.Relationships("")
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range rootRels {
+		if strings.HasSuffix(rel.Type, relTypeOfficeDocument) {
+			workbookPart = rel.Target
+		}
+	}
+
+	var workbook xmlWorkbook
+	if err := archive.DecodeXML(workbookPart, &workbook); err != nil {
+		return nil, err
+	}
+	rels, err := archive.Relationships(workbookPart)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings []string
+	for _, rel := range rels {
+		if !strings.HasSuffix(rel.Type, relTypeSharedStrings) {
+			continue
+		}
+		var sst xmlSharedStrings
+		if err := archive.DecodeXML(rel.Target, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			sharedStrings = append(sharedStrings, item.String())
+		}
+	}
+
+	sheets := make([]sheetText, 0, len(workbook.Sheets))
+	for _, s := range workbook.Sheets {
+		relID := ""
+		for _, attr := range s.Attrs {
+			if attr.Name.Local == "id" && attr.Name.Space != "" {
+				relID = attr.Value
+			}
+		}
+		rel, ok := rels[relID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %q has no part", s.Name)
+		}
+		var worksheet xmlWorksheet
+		if err := archive.DecodeXML(rel.Target, &worksheet); err != nil {
+			return nil, err
+		}
+		sheet, err := sheetToText(s.Name, &worksheet, sharedStrings)
+		if err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// sheetToText renders the rows of a worksheet. Cells of data rows are
+// labelled with the header of their column, falling back to the column
+// letter when the header cell is empty.
+func sheetToText(name string, worksheet *xmlWorksheet, sharedStrings []string) (sheetText, error) {
+	sheet := sheetText{name: name}
+	var header []string
+	nextRow := 1
+	for _, row := range worksheet.Rows {
+		rowNumber := row.R
+		if rowNumber == 0 {
+			rowNumber = nextRow
+		}
+		nextRow = rowNumber + 1
+
+		var values []string
+		nextCol := 0
+		for _, cell := range row.Cells {
+			col := nextCol
+			if cell.R != "" {
+				c, err := columnIndex(cell.R)
+				if err != nil {
+					return sheet, fmt.Errorf("sheet %q: %w", name, err)
+				}
+				col = c
+			}
+			nextCol = col + 1
+
+			value, err := cellValue(cell.T, cell.V, cell.Inline, sharedStrings)
+			if err != nil {
+				return sheet, fmt.Errorf("sheet %q cell %s: %w", name, cell.R, err)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = normalizeCell(value)
+		}
+		if isEmptyRow(values) {
+			continue
+		}
+
+		if header == nil {
+			header = values
+			sheet.lines = append(sheet.lines, strings.Join(trimTrailingEmpty(values), " | "))
+			sheet.rows = append(sheet.rows, rowNumber)
+			continue
+		}
+		var fields []string
+		for col, value := range values {
+			if value == "" {
+				continue
+			}
+			label := columnName(col)
+			if col < len(header) && header[col] != "" {
+				label = header[col]
+			}
+			fields = append(fields, label+": "+value)
+		}
+		sheet.lines = append(sheet.lines, strings.Join(fields, " | "))
+		sheet.rows = append(sheet.rows, rowNumber)
+	}
+	return sheet, nil
+}
+
+// cellValue resolves the display value of a cell.
+func cellValue(cellType, value string, inline xmlText, sharedStrings []string) (string, error) {
+	switch cellType {
+	case "s":
+		idx, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || idx < 0 || idx >= len(sharedStrings) {
+			return "", fmt.Errorf("invalid shared string index %q", value)
+		}
+		return sharedStrings[idx], nil
+	case "inlineStr":
+		return inline.String(), nil
+	case "b":
+		if strings.TrimSpace(value) == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return value, nil
+	}
+}
+
+// columnIndex returns the zero-based column of a cell reference such as "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, ch := range strings.ToUpper(ref) {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A') + 1
+		n++
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// columnName returns the letters of a zero-based column.
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+func normalizeCell(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+	value = strings.ReplaceAll(value, "\n", " ")
+	return strings.TrimSpace(value)
+}
+
+func isEmptyRow(values []string) bool {
+	for _, value := range values {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func trimTrailingEmpty(values []string) []string {
+	for len(values) > 0 && values[len(values)-1] == "" {
+		values = values[:len(values)-1]
+	}
+	return values
+}
@@ -0,0 +1,192 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+const (
+	nsMain = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRel  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func worksheet(rows string) string {
+	return `<worksheet xmlns="` + nsMain + `"><sheetData>` + rows + `</sheetData></worksheet>`
+}
+
+func buildWorkbook(t *testing.T, extraRows int) []byte {
+	t.Helper()
+	var people strings.Builder
+	people.WriteString(`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>Active</t></is></c></row>`)
+	people.WriteString(`<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3"><v>30</v></c><c r="C3"><v>x</v></c><c r="D3" t="b"><v>1</v></c></row>`)
+	people.WriteString(`<row r="4"><c r="A4" t="inlineStr"><is><r><t>Bo</t></r><r><t>b</t></r></is></c><c r="D4" t="b"><v>0</v></c></row>`)
+	for i := 0; i < extraRows; i++ {
+		r := 5 + i
+		fmt.Fprintf(&people, `<row r="%d"><c r="A%d" t="inlineStr"><is><t>Person %d</t></is></c><c r="B%d"><v>%d</v></c></row>`, r, r, i, r, 20+i)
+	}
+
+	return buildZip(t, map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+		"xl/workbook.xml": `<workbook xmlns="` + nsMain + `" xmlns:r="` + nsRel + `"><sheets>
+<sheet name="People" sheetId="1" r:id="rId1"/>
+<sheet name="Empty" sheetId="2" r:id="rId2"/>
+<sheet name="Notes" sheetId="3" r:id="rId3"/>
+</sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet3.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="` + nsMain + `">
+<si><t>Name</t></si><si><t>Age</t></si><si><r><t>Ali</t></r><r><t>ce</t></r></si>
+</sst>`,
+		"xl/worksheets/sheet1.xml": worksheet(people.String()),
+		"xl/worksheets/sheet2.xml": worksheet(`<row r="1"><c r="A1"><v></v></c></row>`),
+		"xl/worksheets/sheet3.xml": worksheet(`<row><c t="inlineStr"><is><t>Remember
+the milk</t></is></c></row>`),
+	})
+}
+
+func TestXLSXReader_Sheets(t *testing.T) {
+	rdr := New(reader.WithChunk(false))
+	docs, err := rdr.ReadFromReader("book", bytes.NewReader(buildWorkbook(t, 0)))
+	require.NoError(t, err)
+	require.Len(t, docs, 2, "empty sheets are skipped")
+
+	people := docs[0]
+	assert.Equal(t, "book", people.Name)
+	assert.Equal(t, "Name | Age |  | Active\n"+
+		"Name: Alice | Age: 30 | C: x | Active: TRUE\n"+
+		"Name: Bob | Active: FALSE", people.Content)
+	assert.Equal(t, "People", people.Metadata[source.MetaSheetName])
+	assert.Equal(t, 1, people.Metadata[source.MetaSheetIndex])
+	assert.Equal(t, 1, people.Metadata[source.MetaRowStart])
+	assert.Equal(t, 4, people.Metadata[source.MetaRowEnd])
+
+	notes := docs[1]
+	assert.Equal(t, "Remember the milk", notes.Content)
+	assert.Equal(t, "Notes", notes.Metadata[source.MetaSheetName])
+	assert.Equal(t, 3, notes.Metadata[source.MetaSheetIndex])
+}
+
+func TestXLSXReader_ChunkRows(t *testing.T) {
+	rdr := New(reader.WithChunkSize(120))
+	docs, err := rdr.ReadFromReader("book", bytes.NewReader(buildWorkbook(t, 20)))
+	require.NoError(t, err)
+	require.Greater(t, len(docs), 3)
+
+	lastEnd := 0
+	for _, doc := range docs {
+		if doc.Metadata[source.MetaSheetName] != "People" {
+			continue
+		}
+		start := doc.Metadata[source.MetaRowStart].(int)
+		end := doc.Metadata[source.MetaRowEnd].(int)
+		assert.LessOrEqual(t, start, end)
+		assert.Greater(t, start, lastEnd, "chunks cover consecutive rows")
+		lastEnd = end
+		for _, line := range strings.Split(doc.Content, "\n") {
+			assert.True(t, line == "Name | Age |  | Active" || strings.HasPrefix(line, "Name: "), line)
+		}
+	}
+	assert.Equal(t, 24, lastEnd)
+}
+
+func TestXLSXReader_Errors(t *testing.T) {
+	rdr := New()
+	_, err := rdr.ReadFromReader("bad", strings.NewReader("not a zip"))
+	assert.ErrorContains(t, err, "failed to parse XLSX")
+
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, map[string]string{"a.txt": "x"})))
+	assert.ErrorContains(t, err, "part not found")
+
+	files := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns:r="` + nsRel + `"><sheets><sheet name="S" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   worksheet(`<row><c r="A1" t="s"><v>7</v></c></row>`),
+	}
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, files)))
+	assert.ErrorContains(t, err, "invalid shared string index")
+
+	files["xl/_rels/workbook.xml.rels"] = `<Relationships/>`
+	_, err = rdr.ReadFromReader("bad", bytes.NewReader(buildZip(t, files)))
+	assert.ErrorContains(t, err, `sheet "S" has no part`)
+}
+
+func TestXLSXReader_ReadFromFileAndURL(t *testing.T) {
+	data := buildWorkbook(t, 0)
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	rdr := New()
+	docs, err := rdr.ReadFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "report", docs[0].Name)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+	docs, err = rdr.ReadFromURL(server.URL + "/files/report.xlsx#sheet")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "report", docs[0].Name)
+
+	_, err = rdr.ReadFromURL("file:///tmp/report.xlsx")
+	assert.ErrorContains(t, err, "unsupported URL scheme")
+
+	assert.Equal(t, "XLSXReader", rdr.Name())
+	assert.Equal(t, []string{".xlsx"}, rdr.SupportedExtensions())
+	_, ok := reader.GetReader(".xlsx")
+	assert.True(t, ok)
+}
+
+func TestColumnHelpers(t *testing.T) {
+	for ref, want := range map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "ab3": 27} {
+		got, err := columnIndex(ref)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, ref)
+		assert.Equal(t, strings.ToUpper(strings.TrimRight(ref, "0123456789")), columnName(got))
+	}
+	_, err := columnIndex("12")
+	assert.Error(t, err)
+}
@@ -35,3 +35,38 @@ type Data struct {
 	Nodes []*Node `json:"nodes"`
 	Edges []*Edge `json:"edges"`
 }
+
+// Metadata keys set on graphs extracted from prose documents.
+const (
+	// MetaNodeKind is the kind of an extracted node: NodeKindEntity,
+	// NodeKindChunk or NodeKindCommunity.
+	MetaNodeKind = "trpc_agent_go_graph_node_kind"
+	// MetaEntityType is the type of an entity node, such as "person".
+	MetaEntityType = "trpc_agent_go_graph_entity_type"
+	// MetaAliases lists the surface forms merged into an entity node.
+	MetaAliases = "trpc_agent_go_graph_aliases"
+	// MetaChunkIDs lists the IDs of the chunks an entity or relation was
+	// extracted from.
+	MetaChunkIDs = "trpc_agent_go_graph_chunk_ids"
+	// MetaDescription holds the description of a relation edge.
+	MetaDescription = "trpc_agent_go_graph_description"
+	// MetaWeight counts the chunks that state a relation edge.
+	MetaWeight = "trpc_agent_go_graph_weight"
+	// MetaMemberIDs lists the entity IDs of a community node.
+	MetaMemberIDs = "trpc_agent_go_graph_member_ids"
+)
+
+// Node kinds of graphs extracted from prose documents.
+const (
+	NodeKindEntity    = "entity"
+	NodeKindChunk     = "chunk"
+	NodeKindCommunity = "community"
+)
+
+// Structural edge types of graphs extracted from prose documents.
+const (
+	// EdgeTypeMentions links a chunk node to the entities it mentions.
+	EdgeTypeMentions = "MENTIONS"
+	// EdgeTypeInCommunity links an entity node to its community node.
+	EdgeTypeInCommunity = "IN_COMMUNITY"
+)
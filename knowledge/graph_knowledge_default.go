@@ -62,9 +62,12 @@ type graphLoadConfig struct {
 // BuiltinGraphKnowledge is the default graph-plus-vector implementation of
 // GraphKnowledge.
 type BuiltinGraphKnowledge struct {
-	store       graphstore.Store
-	vectorStore vectorstore.VectorStore
-	embedder    embedder.Embedder
+	store           graphstore.Store
+	vectorStore     vectorstore.VectorStore
+	embedder        embedder.Embedder
+	retrievalMode   GraphRetrievalMode
+	traversalDepth  int
+	maxContextNodes int
 }
 
 // NewGraphKnowledge creates a new BuiltinGraphKnowledge.
@@ -137,6 +140,13 @@ func (gk *BuiltinGraphKnowledge) Search(
 		return nil, errors.New("search query cannot be empty")
 	}
 
+	if gk.retrievalMode == GraphRetrievalGlobal {
+		result, err := gk.searchGlobal(ctx, req)
+		if err != nil || result != nil {
+			return result, err
+		}
+	}
+
 	seeds, err := gk.retrieveSeedNodes(ctx, req)
 	if err != nil {
 		return nil, err
@@ -144,6 +154,9 @@ func (gk *BuiltinGraphKnowledge) Search(
 	if len(seeds) == 0 {
 		return nil, errors.New("no relevant information found")
 	}
+	if gk.retrievalMode != GraphRetrievalSeeds {
+		return gk.searchLocal(ctx, seeds)
+	}
 
 	docResults := make([]*Result, 0, len(seeds))
 	for _, seed := range seeds {
@@ -634,6 +647,9 @@ func graphSeedEmbeddingText(doc *document.Document) string {
 	if doc.Metadata == nil {
 		return truncateGraphSeedContent(doc.Content)
 	}
+	if kind := graphSeedMetadataString(doc.Metadata, graph.MetaNodeKind); kind != "" {
+		return extractedGraphSeedEmbeddingText(doc, kind)
+	}
 
 	var builder strings.Builder
 	appendGraphSeedField(&builder, "id", doc.ID)
@@ -663,6 +679,29 @@ func graphSeedEmbeddingText(doc *document.Document) string {
 	return builder.String()
 }
 
+// extractedGraphSeedEmbeddingText embeds nodes of graphs extracted from prose
+// documents by name, type and description instead of code fields.
+func extractedGraphSeedEmbeddingText(doc *document.Document, kind string) string {
+	content := truncateGraphSeedContent(doc.Content)
+	if kind == graph.NodeKindChunk {
+		return content
+	}
+	var builder strings.Builder
+	appendGraphSeedField(&builder, "name", doc.Name)
+	nodeType := graphSeedMetadataString(doc.Metadata, graph.MetaEntityType)
+	if nodeType == "" {
+		nodeType = kind
+	}
+	appendGraphSeedField(&builder, "type", nodeType)
+	if content != "" {
+		if builder.Len() > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString(content)
+	}
+	return builder.String()
+}
+
 func appendGraphSeedField(builder *strings.Builder, key, value string) {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -0,0 +1,309 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+)
+
+const (
+	defaultGraphTraversalDepth  = 1
+	defaultGraphMaxContextNodes = 20
+	// graphNeighbourScoreDecay scales the seed score for nodes reached by
+	// traversal so that they rank below the seeds they were found from.
+	graphNeighbourScoreDecay = 0.5
+	// graphPathSeeds is the number of top seeds connected by path search.
+	graphPathSeeds = 3
+)
+
+// GraphRetrievalMode selects how BuiltinGraphKnowledge.Search builds results.
+type GraphRetrievalMode int
+
+const (
+	// GraphRetrievalSeeds returns the nodes found by vector search. This is
+	// the default.
+	GraphRetrievalSeeds GraphRetrievalMode = iota
+	// GraphRetrievalLocal expands the vector hits with their graph
+	// neighbourhood and the paths between the best hits, and renders
+	// entities, relationships, communities and source chunks as text.
+	// It suits questions about specific entities.
+	GraphRetrievalLocal
+	// GraphRetrievalGlobal answers from community summaries, which suits
+	// questions about the corpus as a whole. It falls back to
+	// GraphRetrievalLocal when no community is indexed.
+	GraphRetrievalGlobal
+)
+
+// WithGraphRetrievalMode sets the retrieval mode used by Search.
+func WithGraphRetrievalMode(mode GraphRetrievalMode) GraphKnowledgeOption {
+	return func(gk *BuiltinGraphKnowledge) {
+		gk.retrievalMode = mode
+	}
+}
+
+// WithGraphTraversalDepth sets how many hops local retrieval traverses from
+// every vector hit. The default is 1.
+func WithGraphTraversalDepth(depth int) GraphKnowledgeOption {
+	return func(gk *BuiltinGraphKnowledge) {
+		gk.traversalDepth = depth
+	}
+}
+
+// WithGraphMaxContextNodes caps the number of nodes local retrieval returns.
+// The default is 20.
+func WithGraphMaxContextNodes(n int) GraphKnowledgeOption {
+	return func(gk *BuiltinGraphKnowledge) {
+		gk.maxContextNodes = n
+	}
+}
+
+// searchGlobal searches community nodes only. It returns a nil result when no
+// community matches so that the caller can fall back to local retrieval.
+func (gk *BuiltinGraphKnowledge) searchGlobal(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
+	filter := &SearchFilter{Metadata: map[string]any{graph.MetaNodeKind: graph.NodeKindCommunity}}
+	if req.SearchFilter != nil {
+		filter.DocumentIDs = req.SearchFilter.DocumentIDs
+		filter.FilterCondition = req.SearchFilter.FilterCondition
+		for k, v := range req.SearchFilter.Metadata {
+			if k != graph.MetaNodeKind {
+				filter.Metadata[k] = v
+			}
+		}
+	}
+	communityReq := *req
+	communityReq.SearchFilter = filter
+	seeds, err := gk.retrieveSeedNodes(ctx, &communityReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(seeds) == 0 {
+		return nil, nil
+	}
+	gc := newGraphContext(len(seeds))
+	for _, seed := range seeds {
+		gc.addNode(seed.node, seed.score)
+	}
+	return gc.searchResult(), nil
+}
+
+// searchLocal expands the seeds with their neighbourhood and the paths between
+// the best seeds.
+func (gk *BuiltinGraphKnowledge) searchLocal(ctx context.Context, seeds []*graphSeed) (*SearchResult, error) {
+	if gk.store == nil {
+		return nil, errors.New("graph store is not configured")
+	}
+	depth := resolvePositiveInt(gk.traversalDepth, defaultGraphTraversalDepth)
+	maxNodes := resolvePositiveInt(gk.maxContextNodes, defaultGraphMaxContextNodes)
+	gc := newGraphContext(maxNodes)
+	for _, seed := range seeds {
+		gc.addNode(seed.node, seed.score)
+	}
+
+	for _, seed := range seeds {
+		result, err := gk.store.Traverse(ctx, &graph.TraverseQuery{
+			StartIDs:  []string{seed.node.ID},
+			Direction: graph.DirectionBoth,
+			MaxDepth:  depth,
+			MaxNodes:  maxNodes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("traverse graph from %s: %w", seed.node.ID, err)
+		}
+		if result == nil {
+			continue
+		}
+		for _, node := range result.Nodes {
+			gc.addNode(node, seed.score*graphNeighbourScoreDecay)
+		}
+		gc.addEdges(result.Edges)
+	}
+
+	pathSeeds := seeds
+	if len(pathSeeds) > graphPathSeeds {
+		pathSeeds = pathSeeds[:graphPathSeeds]
+	}
+	for i := 0; i < len(pathSeeds); i++ {
+		for j := i + 1; j < len(pathSeeds); j++ {
+			result, err := gk.store.FindPaths(ctx, &graph.PathQuery{
+				FromID:    pathSeeds[i].node.ID,
+				ToID:      pathSeeds[j].node.ID,
+				Direction: graph.DirectionBoth,
+				MaxDepth:  2 * depth,
+				MaxPaths:  1,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("find graph paths between %s and %s: %w",
+					pathSeeds[i].node.ID, pathSeeds[j].node.ID, err)
+			}
+			if result == nil {
+				continue
+			}
+			score := pathSeeds[j].score * graphNeighbourScoreDecay
+			for _, path := range result.Paths {
+				if path == nil {
+					continue
+				}
+				for _, node := range path.Nodes {
+					gc.addNode(node, score)
+				}
+				gc.addEdges(path.Edges)
+			}
+		}
+	}
+	return gc.searchResult(), nil
+}
+
+// graphContext collects scored nodes and the edges between them.
+type graphContext struct {
+	maxNodes int
+	nodes    map[string]*Result
+	order    []string
+	edges    []*graph.Edge
+	edgeIDs  map[string]struct{}
+}
+
+func newGraphContext(maxNodes int) *graphContext {
+	return &graphContext{
+		maxNodes: maxNodes,
+		nodes:    make(map[string]*Result),
+		edgeIDs:  make(map[string]struct{}),
+	}
+}
+
+func (gc *graphContext) addNode(node *graph.Node, score float64) {
+	if node == nil || node.ID == "" {
+		return
+	}
+	if existing, ok := gc.nodes[node.ID]; ok {
+		if score > existing.Score {
+			existing.Score = score
+		}
+		return
+	}
+	if len(gc.order) >= gc.maxNodes {
+		return
+	}
+	name := node.Name
+	if name == "" {
+		name = node.ID
+	}
+	gc.nodes[node.ID] = &Result{
+		Document: &document.Document{
+			ID:       node.ID,
+			Name:     name,
+			Content:  node.Content,
+			Metadata: cloneMetadata(node.Metadata),
+		},
+		Score: score,
+	}
+	gc.order = append(gc.order, node.ID)
+}
+
+func (gc *graphContext) addEdges(edges []*graph.Edge) {
+	for _, edge := range edges {
+		if edge == nil {
+			continue
+		}
+		key := edge.ID
+		if key == "" {
+			key = edge.FromID + "\x00" + edge.Type + "\x00" + edge.ToID
+		}
+		if _, ok := gc.edgeIDs[key]; ok {
+			continue
+		}
+		gc.edgeIDs[key] = struct{}{}
+		gc.edges = append(gc.edges, edge)
+	}
+}
+
+func (gc *graphContext) searchResult() *SearchResult {
+	results := make([]*Result, 0, len(gc.order))
+	for _, id := range gc.order {
+		results = append(results, gc.nodes[id])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	top := results[0]
+	return &SearchResult{
+		Document:  top.Document,
+		Score:     top.Score,
+		Text:      gc.text(results),
+		Documents: results,
+	}
+}
+
+// text renders the context as sections of entities, relationships,
+// communities and source chunks.
+func (gc *graphContext) text(results []*Result) string {
+	var entities, communities, sources, relationships []string
+	for _, result := range results {
+		doc := result.Document
+		kind, _ := doc.Metadata[graph.MetaNodeKind].(string)
+		switch kind {
+		case graph.NodeKindChunk:
+			sources = append(sources, "- "+doc.Content)
+		case graph.NodeKindCommunity:
+			communities = append(communities, "- "+doc.Name+": "+singleLine(doc.Content))
+		case graph.NodeKindEntity:
+			line := "- " + doc.Name
+			if entityType, _ := doc.Metadata[graph.MetaEntityType].(string); entityType != "" {
+				line += " (" + entityType + ")"
+			}
+			if content := singleLine(doc.Content); content != "" {
+				line += ": " + content
+			}
+			entities = append(entities, line)
+		default:
+			entities = append(entities, "- "+doc.Name+": "+doc.Content)
+		}
+	}
+	for _, edge := range gc.edges {
+		if edge.Type == graph.EdgeTypeMentions || edge.Type == graph.EdgeTypeInCommunity {
+			continue
+		}
+		from, to := gc.nodes[edge.FromID], gc.nodes[edge.ToID]
+		if from == nil || to == nil {
+			continue
+		}
+		line := "- " + from.Document.Name + " " + edge.Type + " " + to.Document.Name
+		if description, _ := edge.Metadata[graph.MetaDescription].(string); description != "" {
+			line += ": " + singleLine(description)
+		}
+		relationships = append(relationships, line)
+	}
+
+	var sections []string
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"Entities", entities},
+		{"Relationships", relationships},
+		{"Communities", communities},
+		{"Sources", sources},
+	} {
+		if len(section.lines) > 0 {
+			sections = append(sections, section.title+":\n"+strings.Join(section.lines, "\n"))
+		}
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
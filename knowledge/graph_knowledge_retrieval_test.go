@@ -0,0 +1,293 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+	graphinmemory "trpc.group/trpc-go/trpc-agent-go/knowledge/graphstore/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore/inmemory"
+)
+
+var (
+	entityAda = &graph.Node{
+		ID:      "entity:ada",
+		Name:    "Ada Lovelace",
+		Content: "A mathematician.\nWrote the first program.",
+		Metadata: map[string]any{
+			graph.MetaNodeKind:   graph.NodeKindEntity,
+			graph.MetaEntityType: "person",
+		},
+	}
+	entityBabbage = &graph.Node{
+		ID:       "entity:babbage",
+		Name:     "Charles Babbage",
+		Content:  "An inventor.",
+		Metadata: map[string]any{graph.MetaNodeKind: graph.NodeKindEntity, graph.MetaEntityType: "person"},
+	}
+	entityEngine = &graph.Node{
+		ID:       "entity:engine",
+		Name:     "Analytical Engine",
+		Metadata: map[string]any{graph.MetaNodeKind: graph.NodeKindEntity},
+	}
+	chunkAda = &graph.Node{
+		ID:       "chunk:1",
+		Name:     "ada.txt",
+		Content:  "Ada Lovelace worked with Charles Babbage.",
+		Metadata: map[string]any{graph.MetaNodeKind: graph.NodeKindChunk},
+	}
+	communityComputing = &graph.Node{
+		ID:      "community:1",
+		Name:    "Community 1: Ada Lovelace, Charles Babbage",
+		Content: "Early computing pioneers.",
+		Metadata: map[string]any{
+			graph.MetaNodeKind:  graph.NodeKindCommunity,
+			graph.MetaMemberIDs: []string{"entity:ada", "entity:babbage"},
+		},
+	}
+	edgeWorkedWith = &graph.Edge{
+		ID:       "relation:ada|WORKED_WITH|babbage",
+		FromID:   "entity:ada",
+		ToID:     "entity:babbage",
+		Type:     "WORKED_WITH",
+		Metadata: map[string]any{graph.MetaDescription: "Collaborated on the engine."},
+	}
+	edgeMentions = &graph.Edge{ID: "mentions:1", FromID: "chunk:1", ToID: "entity:ada", Type: graph.EdgeTypeMentions}
+)
+
+func newEntityGraphKnowledge(t *testing.T, store *stubGraphStore, nodes []*graph.Node, opts ...GraphKnowledgeOption) *BuiltinGraphKnowledge {
+	t.Helper()
+	opts = append([]GraphKnowledgeOption{
+		WithGraphStore(store),
+		WithGraphVectorStore(inmemory.New()),
+		WithGraphEmbedder(stubGraphEmbedder{}),
+	}, opts...)
+	gk := NewGraphKnowledge(opts...)
+	if err := gk.LoadGraphSource(context.Background(), &stubGraphSource{data: &graph.Data{Nodes: nodes}}); err != nil {
+		t.Fatalf("LoadGraphSource() error = %v", err)
+	}
+	store.nodes = nil
+	return gk
+}
+
+func TestBuiltinGraphKnowledge_SearchLocal(t *testing.T) {
+	store := &stubGraphStore{traverseResp: &graph.TraverseResult{
+		Nodes: []*graph.Node{entityAda, entityBabbage, chunkAda, communityComputing},
+		Edges: []*graph.Edge{edgeWorkedWith, edgeMentions, edgeWorkedWith},
+	}}
+	gk := newEntityGraphKnowledge(t, store,
+		[]*graph.Node{entityAda, entityBabbage, chunkAda, communityComputing},
+		WithGraphRetrievalMode(GraphRetrievalLocal),
+		WithGraphTraversalDepth(2),
+	)
+
+	result, err := gk.Search(context.Background(), &SearchRequest{
+		Query:        "Who is Ada?",
+		SearchFilter: &SearchFilter{DocumentIDs: []string{"entity:ada"}},
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if store.traverseReq == nil || store.traverseReq.Direction != graph.DirectionBoth ||
+		store.traverseReq.MaxDepth != 2 || store.traverseReq.MaxNodes != defaultGraphMaxContextNodes {
+		t.Fatalf("traverse request = %+v", store.traverseReq)
+	}
+	if store.pathReq != nil {
+		t.Fatalf("path search needs two seeds, got %+v", store.pathReq)
+	}
+	if len(result.Documents) != 4 || result.Document.ID != "entity:ada" {
+		t.Fatalf("Documents = %+v", result.Documents)
+	}
+	if got, want := result.Documents[1].Score, result.Score*graphNeighbourScoreDecay; got != want {
+		t.Fatalf("neighbour score = %v, want %v", got, want)
+	}
+
+	want := "Entities:\n" +
+		"- Ada Lovelace (person): A mathematician. Wrote the first program.\n" +
+		"- Charles Babbage (person): An inventor.\n\n" +
+		"Relationships:\n" +
+		"- Ada Lovelace WORKED_WITH Charles Babbage: Collaborated on the engine.\n\n" +
+		"Communities:\n" +
+		"- Community 1: Ada Lovelace, Charles Babbage: Early computing pioneers.\n\n" +
+		"Sources:\n" +
+		"- Ada Lovelace worked with Charles Babbage."
+	if result.Text != want {
+		t.Fatalf("Text = %q, want %q", result.Text, want)
+	}
+}
+
+func TestBuiltinGraphKnowledge_SearchLocalPathsAndCap(t *testing.T) {
+	store := &stubGraphStore{
+		traverseResp: &graph.TraverseResult{Nodes: []*graph.Node{chunkAda, communityComputing}},
+		pathResp: &graph.PathResult{Paths: []*graph.Path{nil, {
+			Nodes: []*graph.Node{entityAda, entityEngine, entityBabbage},
+			Edges: []*graph.Edge{
+				{FromID: "entity:ada", ToID: "entity:engine", Type: "WORKED_ON"},
+				{FromID: "entity:babbage", ToID: "entity:engine", Type: "DESIGNED"},
+			},
+		}}},
+	}
+	gk := newEntityGraphKnowledge(t, store,
+		[]*graph.Node{entityAda, entityBabbage, chunkAda, communityComputing},
+		WithGraphRetrievalMode(GraphRetrievalLocal),
+		WithGraphMaxContextNodes(3),
+	)
+
+	result, err := gk.Search(context.Background(), &SearchRequest{
+		Query:        "Ada and Babbage",
+		SearchFilter: &SearchFilter{DocumentIDs: []string{"entity:ada", "entity:babbage"}},
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if store.pathReq == nil || store.pathReq.MaxDepth != 2 || store.pathReq.MaxPaths != 1 {
+		t.Fatalf("path request = %+v", store.pathReq)
+	}
+	if len(result.Documents) != 3 {
+		t.Fatalf("Documents = %d, want capped at 3", len(result.Documents))
+	}
+	if strings.Contains(result.Text, "Analytical Engine") {
+		t.Fatalf("nodes over the cap must not be rendered: %q", result.Text)
+	}
+}
+
+func TestBuiltinGraphKnowledge_SearchGlobal(t *testing.T) {
+	store := &stubGraphStore{}
+	gk := newEntityGraphKnowledge(t, store,
+		[]*graph.Node{entityAda, entityBabbage, chunkAda, communityComputing},
+		WithGraphRetrievalMode(GraphRetrievalGlobal),
+	)
+
+	result, err := gk.Search(context.Background(), &SearchRequest{Query: "What is this corpus about?"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(result.Documents) != 1 || result.Document.ID != "community:1" {
+		t.Fatalf("Documents = %+v, want the community only", result.Documents)
+	}
+	if result.Text != "Communities:\n- Community 1: Ada Lovelace, Charles Babbage: Early computing pioneers." {
+		t.Fatalf("Text = %q", result.Text)
+	}
+	if store.traverseReq != nil {
+		t.Fatal("global retrieval must not traverse the graph")
+	}
+
+	// Without communities, global retrieval falls back to local retrieval.
+	store = &stubGraphStore{}
+	gk = newEntityGraphKnowledge(t, store, []*graph.Node{entityAda}, WithGraphRetrievalMode(GraphRetrievalGlobal))
+	result, err = gk.Search(context.Background(), &SearchRequest{Query: "Ada"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if result.Document.ID != "entity:ada" || store.traverseReq == nil {
+		t.Fatalf("expected local fallback, got %+v", result.Document)
+	}
+}
+
+func TestBuiltinGraphKnowledge_SearchLocalWithInMemoryGraphStore(t *testing.T) {
+	store, err := graphinmemory.New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	gk := NewGraphKnowledge(
+		WithGraphStore(store),
+		WithGraphVectorStore(inmemory.New()),
+		WithGraphEmbedder(stubGraphEmbedder{}),
+		WithGraphRetrievalMode(GraphRetrievalLocal),
+	)
+	src := &stubGraphSource{data: &graph.Data{
+		Nodes: []*graph.Node{entityAda, entityBabbage, chunkAda},
+		Edges: []*graph.Edge{edgeWorkedWith, edgeMentions},
+	}}
+	if err := gk.LoadGraphSource(context.Background(), src); err != nil {
+		t.Fatalf("LoadGraphSource() error = %v", err)
+	}
+
+	result, err := gk.Search(context.Background(), &SearchRequest{
+		Query:        "Charles Babbage",
+		SearchFilter: &SearchFilter{DocumentIDs: []string{"entity:babbage"}},
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(result.Documents) != 2 || result.Documents[1].Document.ID != "entity:ada" {
+		t.Fatalf("Documents = %+v, want the seed and its neighbour", result.Documents)
+	}
+	if !strings.Contains(result.Text, "- Ada Lovelace WORKED_WITH Charles Babbage") {
+		t.Fatalf("Text = %q", result.Text)
+	}
+}
+
+type failingQueryGraphStore struct {
+	stubGraphStore
+	traverseErr error
+	pathErr     error
+}
+
+func (s *failingQueryGraphStore) Traverse(ctx context.Context, query *graph.TraverseQuery) (*graph.TraverseResult, error) {
+	if s.traverseErr != nil {
+		return nil, s.traverseErr
+	}
+	return s.stubGraphStore.Traverse(ctx, query)
+}
+
+func (s *failingQueryGraphStore) FindPaths(ctx context.Context, query *graph.PathQuery) (*graph.PathResult, error) {
+	return nil, s.pathErr
+}
+
+func TestBuiltinGraphKnowledge_SearchLocalErrors(t *testing.T) {
+	vs := inmemory.New()
+	for _, node := range []*graph.Node{entityAda, entityBabbage} {
+		if err := vs.Add(context.Background(), &document.Document{ID: node.ID, Name: node.Name}, []float64{1}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	req := &SearchRequest{Query: "Ada"}
+
+	store := &failingQueryGraphStore{traverseErr: errors.New("down")}
+	gk := NewGraphKnowledge(WithGraphStore(store), WithGraphVectorStore(vs), WithGraphEmbedder(stubGraphEmbedder{}),
+		WithGraphRetrievalMode(GraphRetrievalLocal))
+	if _, err := gk.Search(context.Background(), req); err == nil || !strings.Contains(err.Error(), "traverse graph from") {
+		t.Fatalf("Search() error = %v, want traverse error", err)
+	}
+
+	store = &failingQueryGraphStore{pathErr: errors.New("down")}
+	gk = NewGraphKnowledge(WithGraphStore(store), WithGraphVectorStore(vs), WithGraphEmbedder(stubGraphEmbedder{}),
+		WithGraphRetrievalMode(GraphRetrievalLocal))
+	if _, err := gk.Search(context.Background(), req); err == nil || !strings.Contains(err.Error(), "find graph paths") {
+		t.Fatalf("Search() error = %v, want path error", err)
+	}
+
+	gk = NewGraphKnowledge(WithGraphVectorStore(vs), WithGraphEmbedder(stubGraphEmbedder{}),
+		WithGraphRetrievalMode(GraphRetrievalLocal))
+	if _, err := gk.Search(context.Background(), req); err == nil || !strings.Contains(err.Error(), "graph store is not configured") {
+		t.Fatalf("Search() error = %v, want store error", err)
+	}
+}
+
+func TestGraphSeedEmbeddingText_ExtractedNodes(t *testing.T) {
+	doc := &document.Document{ID: entityAda.ID, Name: entityAda.Name, Content: entityAda.Content, Metadata: entityAda.Metadata}
+	if got, want := graphSeedEmbeddingText(doc), "name: Ada Lovelace\ntype: person\n"+entityAda.Content; got != want {
+		t.Fatalf("entity embedding text = %q, want %q", got, want)
+	}
+	doc = &document.Document{ID: chunkAda.ID, Name: chunkAda.Name, Content: chunkAda.Content, Metadata: chunkAda.Metadata}
+	if got := graphSeedEmbeddingText(doc); got != chunkAda.Content {
+		t.Fatalf("chunk embedding text = %q", got)
+	}
+	doc = &document.Document{ID: communityComputing.ID, Name: "Community 1", Content: "Summary.", Metadata: communityComputing.Metadata}
+	if got, want := graphSeedEmbeddingText(doc), "name: Community 1\ntype: community\nSummary."; got != want {
+		t.Fatalf("community embedding text = %q, want %q", got, want)
+	}
+}
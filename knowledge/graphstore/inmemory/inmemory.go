@@ -0,0 +1,505 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package inmemory provides an in-process graph store implementation.
+//
+// The store keeps nodes and edges in memory and needs no external service,
+// which makes GraphKnowledge usable in tests, CI and single-node
+// deployments. WithSnapshotPath persists the graph to a JSON file so it
+// survives restarts.
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graphstore"
+)
+
+const (
+	defaultTraverseDepth    = 1
+	defaultTraverseMaxNodes = 100
+	defaultPathDepth        = 5
+	defaultMaxPaths         = 10
+)
+
+var (
+	_ graphstore.Store = (*Store)(nil)
+
+	errStoreClosed = errors.New("inmemory: graph store is closed")
+)
+
+// Store is an in-memory graph backend. It is safe for concurrent use.
+type Store struct {
+	mu    sync.RWMutex
+	nodes map[string]*graph.Node
+	// edges is keyed by edgeKey. Like the AGE backend, there is at most one
+	// edge of a type between two nodes.
+	edges map[string]*graph.Edge
+	out   map[string]map[string]struct{}
+	in    map[string]map[string]struct{}
+
+	// snapshotPath is loaded by New and written by Close when set.
+	snapshotPath string
+}
+
+// Option configures an in-memory graph store.
+type Option func(*Store)
+
+// WithSnapshotPath sets a snapshot file. New loads it when it exists and
+// Close writes it, so the graph survives restarts. Call SaveFile to write a
+// snapshot at other times, e.g. after loading a graph source.
+func WithSnapshotPath(path string) Option {
+	return func(s *Store) {
+		s.snapshotPath = path
+	}
+}
+
+// New creates an in-memory graph store.
+func New(opts ...Option) (*Store, error) {
+	s := &Store{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.reset()
+	if s.snapshotPath != "" {
+		if err := s.LoadFile(s.snapshotPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("inmemory: load snapshot %s: %w", s.snapshotPath, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) reset() {
+	s.nodes = make(map[string]*graph.Node)
+	s.edges = make(map[string]*graph.Edge)
+	s.out = make(map[string]map[string]struct{})
+	s.in = make(map[string]map[string]struct{})
+}
+
+// AddNodes inserts or updates graph nodes.
+func (s *Store) AddNodes(ctx context.Context, nodes []*graph.Node) error {
+	for i, node := range nodes {
+		if node == nil {
+			return fmt.Errorf("inmemory: node at index %d is nil", i)
+		}
+		if node.ID == "" {
+			return fmt.Errorf("inmemory: node at index %d has empty id", i)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nodes == nil {
+		return errStoreClosed
+	}
+	for _, node := range nodes {
+		s.nodes[node.ID] = cloneNode(node)
+	}
+	return nil
+}
+
+// AddEdges inserts or updates graph edges. An edge is identified by its
+// endpoints and type. As with the AGE backend, edges whose endpoints have not
+// been added as nodes are skipped.
+func (s *Store) AddEdges(ctx context.Context, edges []*graph.Edge) error {
+	for i, edge := range edges {
+		if edge == nil {
+			return fmt.Errorf("inmemory: edge at index %d is nil", i)
+		}
+		if edge.FromID == "" || edge.ToID == "" {
+			return fmt.Errorf("inmemory: edge at index %d has empty endpoint", i)
+		}
+		if edge.Type == "" {
+			return fmt.Errorf("inmemory: edge at index %d has empty type", i)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nodes == nil {
+		return errStoreClosed
+	}
+	for _, edge := range edges {
+		s.addEdgeLocked(edge)
+	}
+	return nil
+}
+
+func (s *Store) addEdgeLocked(edge *graph.Edge) {
+	if _, ok := s.nodes[edge.FromID]; !ok {
+		return
+	}
+	if _, ok := s.nodes[edge.ToID]; !ok {
+		return
+	}
+	key := edgeKey(edge)
+	stored := cloneEdge(edge)
+	if stored.ID == "" {
+		// Keep the ID of the edge being updated, as AGE only sets it when
+		// given.
+		if existing, ok := s.edges[key]; ok {
+			stored.ID = existing.ID
+		} else {
+			stored.ID = edge.FromID + ":" + edge.Type + ":" + edge.ToID
+		}
+	}
+	s.edges[key] = stored
+	link(s.out, edge.FromID, key)
+	link(s.in, edge.ToID, key)
+}
+
+// Traverse runs a breadth-first traversal from one or more start nodes.
+// Start nodes come first in the result, followed by the reached nodes
+// ordered by distance and ID.
+func (s *Store) Traverse(ctx context.Context, query *graph.TraverseQuery) (*graph.TraverseResult, error) {
+	if query == nil {
+		return nil, errors.New("inmemory: traverse query is required")
+	}
+	if len(query.StartIDs) == 0 {
+		return nil, errors.New("inmemory: start_ids cannot be empty")
+	}
+	if err := validateDirection(query.Direction); err != nil {
+		return nil, err
+	}
+	depth := query.MaxDepth
+	if depth <= 0 {
+		depth = defaultTraverseDepth
+	}
+	maxNodes := query.MaxNodes
+	if maxNodes <= 0 {
+		maxNodes = defaultTraverseMaxNodes
+	}
+	types := edgeTypeSet(query.EdgeTypes)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.nodes == nil {
+		return nil, errStoreClosed
+	}
+
+	visited := make(map[string]struct{})
+	var order []string
+	var frontier []string
+	for _, id := range query.StartIDs {
+		if _, ok := s.nodes[id]; !ok {
+			continue
+		}
+		if _, ok := visited[id]; ok {
+			continue
+		}
+		visited[id] = struct{}{}
+		order = append(order, id)
+		frontier = append(frontier, id)
+	}
+
+	var edges []*graph.Edge
+	seenEdges := make(map[string]struct{})
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		var next []string
+		for _, id := range frontier {
+			for _, step := range s.stepsLocked(id, query.Direction, types) {
+				if _, ok := seenEdges[step.key]; !ok {
+					seenEdges[step.key] = struct{}{}
+					edges = append(edges, step.edge)
+				}
+				if _, ok := visited[step.to]; ok {
+					continue
+				}
+				visited[step.to] = struct{}{}
+				next = append(next, step.to)
+			}
+		}
+		sort.Strings(next)
+		order = append(order, next...)
+		frontier = next
+	}
+
+	truncated := false
+	if len(order) > maxNodes {
+		order = order[:maxNodes]
+		truncated = true
+	}
+	kept := make(map[string]struct{}, len(order))
+	result := &graph.TraverseResult{
+		Nodes: make([]*graph.Node, 0, len(order)),
+		Edges: make([]*graph.Edge, 0, len(edges)),
+	}
+	for _, id := range order {
+		kept[id] = struct{}{}
+		result.Nodes = append(result.Nodes, cloneNode(s.nodes[id]))
+	}
+	for _, edge := range edges {
+		_, fromKept := kept[edge.FromID]
+		_, toKept := kept[edge.ToID]
+		if fromKept && toKept {
+			result.Edges = append(result.Edges, cloneEdge(edge))
+		}
+	}
+	result.Truncated = truncated
+	return result, nil
+}
+
+// FindPaths finds simple paths between two nodes, shortest first.
+func (s *Store) FindPaths(ctx context.Context, query *graph.PathQuery) (*graph.PathResult, error) {
+	if query == nil {
+		return nil, errors.New("inmemory: path query is required")
+	}
+	if query.FromID == "" || query.ToID == "" {
+		return nil, errors.New("inmemory: from_id and to_id are required")
+	}
+	if err := validateDirection(query.Direction); err != nil {
+		return nil, err
+	}
+	depth := query.MaxDepth
+	if depth <= 0 {
+		depth = defaultPathDepth
+	}
+	maxPaths := query.MaxPaths
+	if maxPaths <= 0 {
+		maxPaths = defaultMaxPaths
+	}
+	types := edgeTypeSet(query.EdgeTypes)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.nodes == nil {
+		return nil, errStoreClosed
+	}
+	result := &graph.PathResult{}
+	if _, ok := s.nodes[query.FromID]; !ok {
+		return result, nil
+	}
+	if _, ok := s.nodes[query.ToID]; !ok {
+		return result, nil
+	}
+
+	// Distances to the target bound the search: a partial path is only
+	// extended through nodes that can still reach the target in time.
+	toTarget := s.distancesLocked(query.ToID, reverse(query.Direction), types, depth)
+	if _, ok := toTarget[query.FromID]; !ok {
+		return result, nil
+	}
+
+	f := &pathFinder{
+		store:     s,
+		direction: query.Direction,
+		types:     types,
+		toID:      query.ToID,
+		toTarget:  toTarget,
+		limit:     maxPaths + 1,
+	}
+	for length := toTarget[query.FromID]; length <= depth && len(f.paths) < f.limit; length++ {
+		f.search(query.FromID, length, []string{query.FromID}, nil, map[string]struct{}{query.FromID: {}})
+	}
+	if len(f.paths) > maxPaths {
+		f.paths = f.paths[:maxPaths]
+		result.Truncated = true
+	}
+	result.Paths = f.paths
+	return result, nil
+}
+
+type pathFinder struct {
+	store     *Store
+	direction graph.Direction
+	types     map[string]struct{}
+	toID      string
+	toTarget  map[string]int
+	limit     int
+	paths     []*graph.Path
+}
+
+// search collects paths of exactly remaining more hops from the last node.
+func (f *pathFinder) search(id string, remaining int, nodeIDs []string, edges []*graph.Edge, onPath map[string]struct{}) {
+	if len(f.paths) >= f.limit {
+		return
+	}
+	if remaining == 0 {
+		if id != f.toID {
+			return
+		}
+		path := &graph.Path{
+			Nodes: make([]*graph.Node, 0, len(nodeIDs)),
+			Edges: make([]*graph.Edge, 0, len(edges)),
+		}
+		for _, nodeID := range nodeIDs {
+			path.Nodes = append(path.Nodes, cloneNode(f.store.nodes[nodeID]))
+		}
+		for _, edge := range edges {
+			path.Edges = append(path.Edges, cloneEdge(edge))
+		}
+		f.paths = append(f.paths, path)
+		return
+	}
+	if id == f.toID {
+		return
+	}
+	for _, step := range f.store.stepsLocked(id, f.direction, f.types) {
+		if _, ok := onPath[step.to]; ok {
+			continue
+		}
+		if dist, ok := f.toTarget[step.to]; !ok || dist > remaining-1 {
+			continue
+		}
+		onPath[step.to] = struct{}{}
+		f.search(step.to, remaining-1, append(nodeIDs, step.to), append(edges, step.edge), onPath)
+		delete(onPath, step.to)
+	}
+}
+
+// distancesLocked returns the hop distance from start to every node reachable
+// within depth hops.
+func (s *Store) distancesLocked(start string, direction graph.Direction, types map[string]struct{}, depth int) map[string]int {
+	dist := map[string]int{start: 0}
+	frontier := []string{start}
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		var next []string
+		for _, id := range frontier {
+			for _, step := range s.stepsLocked(id, direction, types) {
+				if _, ok := dist[step.to]; ok {
+					continue
+				}
+				dist[step.to] = level
+				next = append(next, step.to)
+			}
+		}
+		frontier = next
+	}
+	return dist
+}
+
+type step struct {
+	key  string
+	edge *graph.Edge
+	to   string
+}
+
+// stepsLocked returns the edges that can be followed from id, ordered by
+// edge key for deterministic results.
+func (s *Store) stepsLocked(id string, direction graph.Direction, types map[string]struct{}) []step {
+	var steps []step
+	if direction != graph.DirectionIn {
+		for key := range s.out[id] {
+			edge := s.edges[key]
+			if matchesType(edge, types) {
+				steps = append(steps, step{key: key, edge: edge, to: edge.ToID})
+			}
+		}
+	}
+	if direction == graph.DirectionIn || direction == graph.DirectionBoth {
+		for key := range s.in[id] {
+			edge := s.edges[key]
+			if matchesType(edge, types) {
+				steps = append(steps, step{key: key, edge: edge, to: edge.FromID})
+			}
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].key != steps[j].key {
+			return steps[i].key < steps[j].key
+		}
+		return steps[i].to < steps[j].to
+	})
+	return steps
+}
+
+// Close releases the graph. With WithSnapshotPath it writes the snapshot
+// first.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if s.snapshotPath != "" && s.nodes != nil {
+		err = s.saveFileLocked(s.snapshotPath)
+	}
+	s.nodes = nil
+	s.edges = nil
+	s.out = nil
+	s.in = nil
+	return err
+}
+
+func validateDirection(direction graph.Direction) error {
+	switch direction {
+	case "", graph.DirectionOut, graph.DirectionIn, graph.DirectionBoth:
+		return nil
+	default:
+		return fmt.Errorf("inmemory: unsupported direction %q", direction)
+	}
+}
+
+func reverse(direction graph.Direction) graph.Direction {
+	switch direction {
+	case graph.DirectionIn:
+		return graph.DirectionOut
+	case graph.DirectionBoth:
+		return graph.DirectionBoth
+	default:
+		return graph.DirectionIn
+	}
+}
+
+func edgeTypeSet(edgeTypes []string) map[string]struct{} {
+	if len(edgeTypes) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(edgeTypes))
+	for _, edgeType := range edgeTypes {
+		set[edgeType] = struct{}{}
+	}
+	return set
+}
+
+func matchesType(edge *graph.Edge, types map[string]struct{}) bool {
+	if types == nil {
+		return true
+	}
+	_, ok := types[edge.Type]
+	return ok
+}
+
+func edgeKey(edge *graph.Edge) string {
+	return edge.FromID + "\x00" + edge.Type + "\x00" + edge.ToID
+}
+
+func link(index map[string]map[string]struct{}, id, key string) {
+	keys, ok := index[id]
+	if !ok {
+		keys = make(map[string]struct{})
+		index[id] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func cloneNode(node *graph.Node) *graph.Node {
+	cloned := *node
+	cloned.Metadata = cloneMetadata(node.Metadata)
+	return &cloned
+}
+
+func cloneEdge(edge *graph.Edge) *graph.Edge {
+	cloned := *edge
+	cloned.Metadata = cloneMetadata(edge.Metadata)
+	return &cloned
+}
+
+func cloneMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return nil
+	}
+	cloned := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		cloned[k] = v
+	}
+	return cloned
+}
@@ -0,0 +1,179 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+)
+
+// newTestStore builds the graph
+//
+//	a -CALLS-> b -CALLS-> c -CALLS-> d
+//	a -USES-> e -CALLS-> d
+//	x (isolated)
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New()
+	require.NoError(t, err)
+	ctx := context.Background()
+	var nodes []*graph.Node
+	for _, id := range []string{"a", "b", "c", "d", "e", "x"} {
+		nodes = append(nodes, &graph.Node{ID: id, Name: "node " + id})
+	}
+	require.NoError(t, s.AddNodes(ctx, nodes))
+	require.NoError(t, s.AddEdges(ctx, []*graph.Edge{
+		{ID: "ab", FromID: "a", ToID: "b", Type: "CALLS"},
+		{ID: "bc", FromID: "b", ToID: "c", Type: "CALLS"},
+		{ID: "cd", FromID: "c", ToID: "d", Type: "CALLS"},
+		{ID: "ae", FromID: "a", ToID: "e", Type: "USES"},
+		{FromID: "e", ToID: "d", Type: "CALLS"},
+		{FromID: "a", ToID: "missing", Type: "CALLS"},
+	}))
+	return s
+}
+
+func nodeIDs(nodes []*graph.Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func edgeIDs(edges []*graph.Edge) []string {
+	ids := make([]string, 0, len(edges))
+	for _, edge := range edges {
+		ids = append(ids, edge.ID)
+	}
+	return ids
+}
+
+func TestStore_Traverse(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	result, err := s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "e"}, nodeIDs(result.Nodes))
+	assert.ElementsMatch(t, []string{"ab", "ae"}, edgeIDs(result.Edges))
+	assert.False(t, result.Truncated)
+
+	result, err = s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a"}, MaxDepth: 3, EdgeTypes: []string{"CALLS"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, nodeIDs(result.Nodes))
+
+	result, err = s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"d"}, Direction: graph.DirectionIn, MaxDepth: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "c", "e", "a", "b"}, nodeIDs(result.Nodes))
+	assert.Equal(t, "e:CALLS:d", result.Edges[1].ID, "edges without id get a derived one")
+
+	result, err = s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"c"}, Direction: graph.DirectionBoth})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b", "d"}, nodeIDs(result.Nodes))
+
+	result, err = s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a", "x", "unknown"}, MaxDepth: 5, MaxNodes: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "x", "b"}, nodeIDs(result.Nodes))
+	assert.Equal(t, []string{"ab"}, edgeIDs(result.Edges), "edges to dropped nodes are filtered")
+	assert.True(t, result.Truncated)
+
+	_, err = s.Traverse(ctx, nil)
+	assert.Error(t, err)
+	_, err = s.Traverse(ctx, &graph.TraverseQuery{})
+	assert.ErrorContains(t, err, "start_ids cannot be empty")
+	_, err = s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a"}, Direction: "sideways"})
+	assert.ErrorContains(t, err, "unsupported direction")
+}
+
+func TestStore_FindPaths(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	result, err := s.FindPaths(ctx, &graph.PathQuery{FromID: "a", ToID: "d"})
+	require.NoError(t, err)
+	require.Len(t, result.Paths, 2)
+	assert.Equal(t, []string{"a", "e", "d"}, nodeIDs(result.Paths[0].Nodes), "shortest path first")
+	assert.Equal(t, []string{"ae", "e:CALLS:d"}, edgeIDs(result.Paths[0].Edges))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, nodeIDs(result.Paths[1].Nodes))
+	assert.False(t, result.Truncated)
+
+	result, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "a", ToID: "d", MaxPaths: 1})
+	require.NoError(t, err)
+	require.Len(t, result.Paths, 1)
+	assert.True(t, result.Truncated)
+
+	result, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "a", ToID: "d", MaxDepth: 2, EdgeTypes: []string{"CALLS"}})
+	require.NoError(t, err)
+	assert.Empty(t, result.Paths)
+
+	result, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "d", ToID: "a"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Paths, "edges are directed by default")
+
+	result, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "d", ToID: "a", Direction: graph.DirectionIn})
+	require.NoError(t, err)
+	assert.Len(t, result.Paths, 2)
+
+	result, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "b", ToID: "e", Direction: graph.DirectionBoth})
+	require.NoError(t, err)
+	require.Len(t, result.Paths, 2)
+	assert.Equal(t, []string{"b", "a", "e"}, nodeIDs(result.Paths[0].Nodes))
+	assert.Equal(t, []string{"b", "c", "d", "e"}, nodeIDs(result.Paths[1].Nodes))
+
+	result, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "a", ToID: "x"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Paths)
+
+	_, err = s.FindPaths(ctx, nil)
+	assert.Error(t, err)
+	_, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "a"})
+	assert.ErrorContains(t, err, "from_id and to_id are required")
+	_, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "a", ToID: "d", Direction: "up"})
+	assert.ErrorContains(t, err, "unsupported direction")
+}
+
+func TestStore_Upserts(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	node := &graph.Node{ID: "a", Name: "renamed", Metadata: map[string]any{"k": "v"}}
+	require.NoError(t, s.AddNodes(ctx, []*graph.Node{node}))
+	node.Metadata["k"] = "mutated"
+	require.NoError(t, s.AddEdges(ctx, []*graph.Edge{
+		{FromID: "a", ToID: "b", Type: "CALLS", Metadata: map[string]any{"weight": 2}},
+	}))
+
+	result, err := s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a"}, EdgeTypes: []string{"CALLS"}})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", result.Nodes[0].Name)
+	assert.Equal(t, "v", result.Nodes[0].Metadata["k"], "the store keeps its own copy")
+	require.Len(t, result.Edges, 1)
+	assert.Equal(t, "ab", result.Edges[0].ID, "updates without id keep the stored id")
+	assert.Equal(t, 2, result.Edges[0].Metadata["weight"])
+
+	assert.ErrorContains(t, s.AddNodes(ctx, []*graph.Node{nil}), "node at index 0 is nil")
+	assert.ErrorContains(t, s.AddNodes(ctx, []*graph.Node{{}}), "empty id")
+	assert.ErrorContains(t, s.AddEdges(ctx, []*graph.Edge{nil}), "edge at index 0 is nil")
+	assert.ErrorContains(t, s.AddEdges(ctx, []*graph.Edge{{FromID: "a"}}), "empty endpoint")
+	assert.ErrorContains(t, s.AddEdges(ctx, []*graph.Edge{{FromID: "a", ToID: "b"}}), "empty type")
+
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.AddNodes(ctx, []*graph.Node{{ID: "a"}}), errStoreClosed)
+	assert.ErrorIs(t, s.AddEdges(ctx, nil), errStoreClosed)
+	_, err = s.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a"}})
+	assert.ErrorIs(t, err, errStoreClosed)
+	_, err = s.FindPaths(ctx, &graph.PathQuery{FromID: "a", ToID: "b"})
+	assert.ErrorIs(t, err, errStoreClosed)
+}
@@ -0,0 +1,153 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+)
+
+// snapshotVersion is the version of the snapshot format.
+const snapshotVersion = 1
+
+// snapshot is the JSON file format. Metadata is stored as JSON, so numbers
+// read back as float64, the same as with the AGE backend.
+type snapshot struct {
+	Version int `json:"version"`
+	graph.Data
+}
+
+// Save writes a snapshot of all nodes and edges to w.
+func (s *Store) Save(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.saveLocked(w)
+}
+
+// SaveFile writes a snapshot to path. The file is replaced atomically, so
+// a crash while saving leaves the previous snapshot intact.
+func (s *Store) SaveFile(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.saveFileLocked(path)
+}
+
+// Load replaces the contents of the store with the snapshot read from r.
+func (s *Store) Load(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(bufio.NewReader(r)).Decode(&snap); err != nil {
+		return fmt.Errorf("inmemory: decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("inmemory: unsupported snapshot version %d", snap.Version)
+	}
+	for i, node := range snap.Nodes {
+		if node == nil || node.ID == "" {
+			return fmt.Errorf("inmemory: snapshot node %d is invalid", i)
+		}
+	}
+	for i, edge := range snap.Edges {
+		if edge == nil || edge.FromID == "" || edge.ToID == "" || edge.Type == "" {
+			return fmt.Errorf("inmemory: snapshot edge %d is invalid", i)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+	for _, node := range snap.Nodes {
+		s.nodes[node.ID] = node
+	}
+	for _, edge := range snap.Edges {
+		s.addEdgeLocked(edge)
+	}
+	return nil
+}
+
+// LoadFile replaces the contents of the store with the snapshot at path.
+// The returned error wraps os.ErrNotExist when there is no snapshot.
+func (s *Store) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Load(f)
+}
+
+func (s *Store) saveLocked(w io.Writer) error {
+	if s.nodes == nil {
+		return errStoreClosed
+	}
+	snap := snapshot{
+		Version: snapshotVersion,
+		Data: graph.Data{
+			Nodes: make([]*graph.Node, 0, len(s.nodes)),
+			Edges: make([]*graph.Edge, 0, len(s.edges)),
+		},
+	}
+	for _, id := range sortedKeys(s.nodes) {
+		snap.Nodes = append(snap.Nodes, s.nodes[id])
+	}
+	for _, key := range sortedKeys(s.edges) {
+		snap.Edges = append(snap.Edges, s.edges[key])
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := json.NewEncoder(bw).Encode(&snap); err != nil {
+		return fmt.Errorf("inmemory: encode snapshot: %w", err)
+	}
+	return bw.Flush()
+}
+
+func (s *Store) saveFileLocked(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("inmemory: create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("inmemory: create snapshot file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := s.saveLocked(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("inmemory: sync snapshot file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("inmemory: close snapshot file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("inmemory: replace snapshot file: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,71 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+)
+
+func TestStore_SaveLoad(t *testing.T) {
+	s := newTestStore(t)
+	var buf bytes.Buffer
+	require.NoError(t, s.Save(&buf))
+
+	loaded, err := New()
+	require.NoError(t, err)
+	require.NoError(t, loaded.Load(&buf))
+	result, err := loaded.FindPaths(context.Background(), &graph.PathQuery{FromID: "a", ToID: "d"})
+	require.NoError(t, err)
+	require.Len(t, result.Paths, 2)
+	assert.Equal(t, []string{"ae", "e:CALLS:d"}, edgeIDs(result.Paths[0].Edges))
+
+	assert.ErrorContains(t, loaded.Load(strings.NewReader("{")), "decode snapshot")
+	assert.ErrorContains(t, loaded.Load(strings.NewReader(`{"version":2}`)), "unsupported snapshot version")
+	assert.ErrorContains(t, loaded.Load(strings.NewReader(`{"version":1,"nodes":[{"id":""}]}`)), "snapshot node 0")
+	assert.ErrorContains(t, loaded.Load(strings.NewReader(`{"version":1,"edges":[{"from_id":"a"}]}`)), "snapshot edge 0")
+}
+
+func TestStore_SnapshotPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph", "snapshot.json")
+	ctx := context.Background()
+
+	s, err := New(WithSnapshotPath(path))
+	require.NoError(t, err)
+	require.NoError(t, s.AddNodes(ctx, []*graph.Node{
+		{ID: "a", Metadata: map[string]any{"line": 3}},
+		{ID: "b"},
+	}))
+	require.NoError(t, s.AddEdges(ctx, []*graph.Edge{{FromID: "a", ToID: "b", Type: "CALLS"}}))
+	require.NoError(t, s.Close())
+
+	reopened, err := New(WithSnapshotPath(path))
+	require.NoError(t, err)
+	result, err := reopened.Traverse(ctx, &graph.TraverseQuery{StartIDs: []string{"a"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, nodeIDs(result.Nodes))
+	assert.Equal(t, float64(3), result.Nodes[0].Metadata["line"], "metadata round-trips through JSON")
+	assert.Len(t, result.Edges, 1)
+
+	require.NoError(t, reopened.Close())
+	assert.ErrorIs(t, reopened.SaveFile(path), errStoreClosed)
+
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+	_, err = New(WithSnapshotPath(path))
+	assert.ErrorContains(t, err, "load snapshot")
+}
@@ -60,8 +60,24 @@ func (e *LLMEnhancer) EnhanceQuery(ctx context.Context, req *Request) (*Enhanced
 		return &Enhanced{}, nil
 	}
 
+	enhanced, err := generate(ctx, e.model, e.systemPrompt, req)
+	if err != nil {
+		return nil, err
+	}
+	if enhanced == "" {
+		enhanced = req.Query
+	}
+
+	return &Enhanced{
+		Enhanced: enhanced,
+	}, nil
+}
+
+// generate asks m to answer req.Query under systemPrompt, with the
+// conversation history as context, and returns the trimmed output.
+func generate(ctx context.Context, m model.Model, systemPrompt string, req *Request) (string, error) {
 	messages := []model.Message{
-		model.NewSystemMessage(e.systemPrompt),
+		model.NewSystemMessage(systemPrompt),
 	}
 
 	for _, h := range req.History {
@@ -75,17 +91,17 @@ func (e *LLMEnhancer) EnhanceQuery(ctx context.Context, req *Request) (*Enhanced
 
 	messages = append(messages, model.NewUserMessage(req.Query))
 
-	ch, err := e.model.GenerateContent(ctx, &model.Request{
+	ch, err := m.GenerateContent(ctx, &model.Request{
 		Messages: messages,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("query enhance LLM call failed: %w", err)
+		return "", fmt.Errorf("query enhance LLM call failed: %w", err)
 	}
 
 	var result strings.Builder
 	for resp := range ch {
 		if resp.Error != nil {
-			return nil, fmt.Errorf("query enhance LLM error: %s", resp.Error.Message)
+			return "", fmt.Errorf("query enhance LLM error: %s", resp.Error.Message)
 		}
 		for _, choice := range resp.Choices {
 			if choice.Message.Content != "" {
@@ -96,13 +112,5 @@ func (e *LLMEnhancer) EnhanceQuery(ctx context.Context, req *Request) (*Enhanced
 			}
 		}
 	}
-
-	enhanced := strings.TrimSpace(result.String())
-	if enhanced == "" {
-		enhanced = req.Query
-	}
-
-	return &Enhanced{
-		Enhanced: enhanced,
-	}, nil
+	return strings.TrimSpace(result.String()), nil
 }
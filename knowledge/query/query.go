@@ -54,4 +54,34 @@ type Enhanced struct {
 
 	// Keywords contains extracted key terms.
 	Keywords []string
+
+	// Variants are the queries to search when the enhancer produces more
+	// than one. The retriever searches every variant and fuses the results;
+	// Enhanced is still used to rerank them. Empty means only Enhanced is
+	// searched.
+	Variants []Variant
+}
+
+// VariantKind describes how a query variant was produced.
+type VariantKind string
+
+// Supported variant kinds.
+const (
+	// VariantOriginal is the query as issued by the user.
+	VariantOriginal VariantKind = "original"
+	// VariantMultiQuery is an alternative phrasing of the query.
+	VariantMultiQuery VariantKind = "multi_query"
+	// VariantHyDE is a hypothetical document answering the query.
+	VariantHyDE VariantKind = "hyde"
+	// VariantStepBack is a more general question behind the query.
+	VariantStepBack VariantKind = "step_back"
+)
+
+// Variant is one of several queries searched for a single request.
+type Variant struct {
+	// Kind describes how the variant was produced.
+	Kind VariantKind
+
+	// Text is the query text to search.
+	Text string
 }
@@ -0,0 +1,189 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const defaultVariantCount = 3
+
+// listMarker matches bullets and numbering such as "-", "*", "1." or "2)".
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+
+const multiQueryPromptTemplate = `Given a chat history and the latest user question, write %d different search queries that could retrieve documents answering the question from a vector database.
+
+Rules:
+- Output ONLY the queries, one per line, without numbering or any other text.
+- Resolve any references (e.g. "it", "that", "the above") using conversation context.
+- Vary the wording and perspective of each query, e.g. use synonyms or focus on different aspects.
+- Keep each query concise and focused on key concepts.`
+
+const hydePrompt = `Given a chat history and the latest user question, write a short passage that answers the question as it might appear in a reference document.
+
+Rules:
+- Output ONLY the passage, nothing else.
+- Resolve any references (e.g. "it", "that", "the above") using conversation context.
+- Write in a factual, documentation-like style in at most one paragraph.
+- If you are unsure about the facts, write a plausible passage anyway; it is only used for search.`
+
+const stepBackPrompt = `Given a chat history and the latest user question, write a more general "step-back" question about the concepts or principles needed to answer it.
+
+Rules:
+- Output ONLY the step-back question, nothing else.
+- Resolve any references (e.g. "it", "that", "the above") using conversation context.
+- Abstract away specific details such as names, numbers and versions.
+- Keep the question concise.`
+
+// VariantEnhancer generates additional query variants with an LLM, so that a
+// single search covers several formulations of the user's question.
+type VariantEnhancer struct {
+	model           model.Model
+	kind            VariantKind
+	systemPrompt    string
+	count           int
+	includeOriginal bool
+}
+
+// VariantEnhancerOption configures a VariantEnhancer.
+type VariantEnhancerOption func(*VariantEnhancer)
+
+// WithVariantSystemPrompt overrides the prompt used to generate variants.
+// For multi-query enhancers the model must output one query per line.
+func WithVariantSystemPrompt(prompt string) VariantEnhancerOption {
+	return func(e *VariantEnhancer) {
+		e.systemPrompt = prompt
+	}
+}
+
+// WithVariantCount sets how many queries a multi-query enhancer generates.
+// Defaults to 3. It has no effect on other enhancers.
+func WithVariantCount(n int) VariantEnhancerOption {
+	return func(e *VariantEnhancer) {
+		if n > 0 {
+			e.count = n
+		}
+	}
+}
+
+// WithOriginalQuery controls whether the original query is searched along
+// with the generated variants. Enabled by default.
+func WithOriginalQuery(include bool) VariantEnhancerOption {
+	return func(e *VariantEnhancer) {
+		e.includeOriginal = include
+	}
+}
+
+// NewMultiQueryEnhancer creates an enhancer that rephrases the query into
+// several alternative search queries.
+func NewMultiQueryEnhancer(m model.Model, opts ...VariantEnhancerOption) *VariantEnhancer {
+	e := newVariantEnhancer(m, VariantMultiQuery, "", opts)
+	if e.systemPrompt == "" {
+		e.systemPrompt = fmt.Sprintf(multiQueryPromptTemplate, e.count)
+	}
+	return e
+}
+
+// NewHyDEEnhancer creates an enhancer that writes a hypothetical document
+// answering the query (HyDE) and searches with it. Documents are often closer
+// to such a passage in embedding space than to the question itself.
+func NewHyDEEnhancer(m model.Model, opts ...VariantEnhancerOption) *VariantEnhancer {
+	return newVariantEnhancer(m, VariantHyDE, hydePrompt, opts)
+}
+
+// NewStepBackEnhancer creates an enhancer that generalizes the query into a
+// step-back question, retrieving background knowledge that specific
+// questions miss.
+func NewStepBackEnhancer(m model.Model, opts ...VariantEnhancerOption) *VariantEnhancer {
+	return newVariantEnhancer(m, VariantStepBack, stepBackPrompt, opts)
+}
+
+func newVariantEnhancer(
+	m model.Model,
+	kind VariantKind,
+	systemPrompt string,
+	opts []VariantEnhancerOption,
+) *VariantEnhancer {
+	e := &VariantEnhancer{
+		model:           m,
+		kind:            kind,
+		systemPrompt:    systemPrompt,
+		count:           defaultVariantCount,
+		includeOriginal: true,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// EnhanceQuery implements the Enhancer interface. Enhanced is the original
+// query, or the first variant when the original is not searched.
+func (e *VariantEnhancer) EnhanceQuery(ctx context.Context, req *Request) (*Enhanced, error) {
+	if req == nil || req.Query == "" {
+		return &Enhanced{}, nil
+	}
+
+	output, err := generate(ctx, e.model, e.systemPrompt, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	if e.kind == VariantMultiQuery {
+		texts = parseQueryLines(output, e.count)
+	} else if output != "" {
+		texts = []string{output}
+	}
+
+	var variants []Variant
+	seen := make(map[string]bool)
+	if e.includeOriginal {
+		variants = append(variants, Variant{Kind: VariantOriginal, Text: req.Query})
+		seen[strings.ToLower(req.Query)] = true
+	}
+	for _, text := range texts {
+		if key := strings.ToLower(text); !seen[key] {
+			seen[key] = true
+			variants = append(variants, Variant{Kind: e.kind, Text: text})
+		}
+	}
+	if len(variants) == 0 {
+		// Nothing usable was generated, search the original query only.
+		return &Enhanced{Enhanced: req.Query}, nil
+	}
+	return &Enhanced{
+		Enhanced: variants[0].Text,
+		Variants: variants,
+	}, nil
+}
+
+// parseQueryLines splits the model output into at most limit queries,
+// dropping list markers the model may add despite the instructions.
+func parseQueryLines(output string, limit int) []string {
+	var queries []string
+	for _, line := range strings.Split(output, "\n") {
+		line = listMarker.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.TrimSpace(strings.Trim(line, `"'`))
+		if line == "" {
+			continue
+		}
+		queries = append(queries, line)
+		if len(queries) == limit {
+			break
+		}
+	}
+	return queries
+}
@@ -0,0 +1,119 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestMultiQueryEnhancer(t *testing.T) {
+	m := &stubModel{content: "1. go channels tutorial\n- How do Go channels work?\n\n* \"buffered channel semantics\"\nWhat is a goroutine?\n"}
+	e := NewMultiQueryEnhancer(m, WithVariantCount(3))
+
+	res, err := e.EnhanceQuery(context.Background(), &Request{
+		Query:   "how do they work?",
+		History: []ConversationMessage{{Role: "user", Content: "tell me about go channels"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Variant{
+		{Kind: VariantOriginal, Text: "how do they work?"},
+		{Kind: VariantMultiQuery, Text: "go channels tutorial"},
+		{Kind: VariantMultiQuery, Text: "How do Go channels work?"},
+		{Kind: VariantMultiQuery, Text: "buffered channel semantics"},
+	}
+	if len(res.Variants) != len(want) {
+		t.Fatalf("expected %d variants, got %+v", len(want), res.Variants)
+	}
+	for i := range want {
+		if res.Variants[i] != want[i] {
+			t.Fatalf("variant %d: expected %+v, got %+v", i, want[i], res.Variants[i])
+		}
+	}
+	if res.Enhanced != "how do they work?" {
+		t.Fatalf("expected the original query as Enhanced, got %q", res.Enhanced)
+	}
+	// system + 1 history + 1 user = 3 messages
+	if len(m.capture.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(m.capture.Messages))
+	}
+	if !strings.Contains(m.capture.Messages[0].Content, "write 3 different search queries") {
+		t.Fatalf("unexpected system prompt: %q", m.capture.Messages[0].Content)
+	}
+}
+
+func TestMultiQueryEnhancer_DeduplicatesAndFallsBack(t *testing.T) {
+	m := &stubModel{content: "Original Query\noriginal query\n2025 release notes"}
+	res, err := NewMultiQueryEnhancer(m).EnhanceQuery(context.Background(), &Request{Query: "original query"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Variants) != 2 || res.Variants[1].Text != "2025 release notes" {
+		t.Fatalf("unexpected variants: %+v", res.Variants)
+	}
+
+	m = &stubModel{content: "  "}
+	res, err = NewMultiQueryEnhancer(m, WithOriginalQuery(false)).EnhanceQuery(context.Background(), &Request{Query: "q"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Enhanced != "q" || len(res.Variants) != 0 {
+		t.Fatalf("expected fallback to the original query, got %+v", res)
+	}
+}
+
+func TestHyDEAndStepBackEnhancers(t *testing.T) {
+	m := &stubModel{content: "Channels are typed conduits between goroutines."}
+	res, err := NewHyDEEnhancer(m, WithOriginalQuery(false)).EnhanceQuery(context.Background(), &Request{Query: "what is a channel"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Variants) != 1 || res.Variants[0].Kind != VariantHyDE || res.Enhanced != m.content {
+		t.Fatalf("unexpected HyDE result: %+v", res)
+	}
+
+	m = &stubModel{content: "How does Go scheduling work?"}
+	res, err = NewStepBackEnhancer(m, WithVariantSystemPrompt("custom")).EnhanceQuery(
+		context.Background(), &Request{Query: "why does my goroutine block on Go 1.21?"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Variants) != 2 || res.Variants[1] != (Variant{Kind: VariantStepBack, Text: m.content}) {
+		t.Fatalf("unexpected step-back result: %+v", res)
+	}
+	if m.capture.Messages[0].Content != "custom" {
+		t.Fatalf("expected custom prompt, got %q", m.capture.Messages[0].Content)
+	}
+}
+
+func TestVariantEnhancer_Errors(t *testing.T) {
+	e := NewHyDEEnhancer(&stubModel{err: errors.New("boom")})
+	if _, err := e.EnhanceQuery(context.Background(), &Request{Query: "q"}); err == nil ||
+		!strings.Contains(err.Error(), "query enhance LLM call failed") {
+		t.Fatalf("expected call error, got %v", err)
+	}
+
+	e = NewStepBackEnhancer(&stubModel{apiErr: &model.ResponseError{Message: "rate limited"}})
+	if _, err := e.EnhanceQuery(context.Background(), &Request{Query: "q"}); err == nil ||
+		!strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("expected response error, got %v", err)
+	}
+
+	res, err := e.EnhanceQuery(context.Background(), &Request{})
+	if err != nil || res.Enhanced != "" || len(res.Variants) != 0 {
+		t.Fatalf("expected empty result for empty query, got %+v, %v", res, err)
+	}
+}
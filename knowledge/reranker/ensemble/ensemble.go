@@ -0,0 +1,228 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package ensemble provides a Reranker that combines the outputs of several
+// rerankers, e.g. a cheap HTTP cross-encoder with an LLM judge.
+package ensemble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+)
+
+// Fusion is the way the member rankings are combined.
+type Fusion string
+
+const (
+	// FusionRRF combines the rankings with weighted reciprocal rank fusion,
+	// sum(weight / (k + rank)). It ignores the score scales of the members.
+	FusionRRF Fusion = "rrf"
+	// FusionWeighted min-max normalizes the scores of each member to 0-1 and
+	// sums them by weight. Results a member dropped contribute 0.
+	FusionWeighted Fusion = "weighted"
+)
+
+// defaultRRFK is the reciprocal rank fusion constant from the original paper.
+const defaultRRFK = 60
+
+var (
+	// errNoRerankers is returned when no member reranker is configured.
+	errNoRerankers = errors.New("ensemble reranker requires at least one reranker")
+	// errUnsupportedFusion is returned for an unknown fusion.
+	errUnsupportedFusion = errors.New("ensemble fusion must be rrf or weighted")
+)
+
+type member struct {
+	reranker reranker.Reranker
+	weight   float64
+}
+
+// Reranker implements Reranker by running several rerankers concurrently and
+// fusing their outputs.
+type Reranker struct {
+	members []member
+	fusion  Fusion
+	rrfK    int
+	topN    int
+}
+
+// Option configures Reranker.
+type Option func(*Reranker)
+
+// WithReranker adds a member reranker with the given weight. Members with a
+// weight <= 0 get weight 1.
+func WithReranker(r reranker.Reranker, weight float64) Option {
+	return func(e *Reranker) {
+		if weight <= 0 {
+			weight = 1
+		}
+		e.members = append(e.members, member{reranker: r, weight: weight})
+	}
+}
+
+// WithFusion sets how the member outputs are combined. Defaults to FusionRRF.
+func WithFusion(f Fusion) Option {
+	return func(e *Reranker) {
+		e.fusion = f
+	}
+}
+
+// WithRRFK sets the reciprocal rank fusion constant. Defaults to 60.
+func WithRRFK(k int) Option {
+	return func(e *Reranker) {
+		if k > 0 {
+			e.rrfK = k
+		}
+	}
+}
+
+// WithTopN sets the number of results to return. Defaults to all results.
+func WithTopN(n int) Option {
+	return func(e *Reranker) {
+		e.topN = n
+	}
+}
+
+// New creates a new ensemble reranker.
+func New(opts ...Option) (*Reranker, error) {
+	e := &Reranker{
+		fusion: FusionRRF,
+		rrfK:   defaultRRFK,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if len(e.members) == 0 {
+		return nil, errNoRerankers
+	}
+	for i, m := range e.members {
+		if m.reranker == nil {
+			return nil, fmt.Errorf("ensemble reranker %d is nil", i)
+		}
+	}
+	if e.fusion != FusionRRF && e.fusion != FusionWeighted {
+		return nil, errUnsupportedFusion
+	}
+	return e, nil
+}
+
+// Rerank implements the Reranker interface. All members must succeed.
+func (e *Reranker) Rerank(
+	ctx context.Context,
+	query *reranker.Query,
+	results []*reranker.Result,
+) ([]*reranker.Result, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	outputs := make([][]*reranker.Result, len(e.members))
+	errs := make([]error, len(e.members))
+	var wg sync.WaitGroup
+	for i, m := range e.members {
+		wg.Add(1)
+		go func(i int, r reranker.Reranker) {
+			defer wg.Done()
+			// Members may reorder or rescore their input, give each a copy.
+			input := make([]*reranker.Result, len(results))
+			for j, res := range results {
+				input[j] = &reranker.Result{Document: res.Document, Score: res.Score}
+			}
+			outputs[i], errs[i] = r.Rerank(ctx, query, input)
+		}(i, m.reranker)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("ensemble reranker %d: %w", i, err)
+		}
+	}
+
+	fused := e.fuse(results, outputs)
+	if e.topN > 0 && len(fused) > e.topN {
+		fused = fused[:e.topN]
+	}
+	return fused, nil
+}
+
+// fuse combines the member outputs. Results are matched by document ID, or
+// by document pointer for documents without an ID. Only results returned by
+// at least one member are kept, in descending fused score; ties keep the
+// input order.
+func (e *Reranker) fuse(results []*reranker.Result, outputs [][]*reranker.Result) []*reranker.Result {
+	position := make(map[any]int, len(results))
+	for i, res := range results {
+		if k := key(res); k != nil {
+			if _, ok := position[k]; !ok {
+				position[k] = i
+			}
+		}
+	}
+
+	scores := make(map[int]float64)
+	for i, output := range outputs {
+		weight := e.members[i].weight
+		var lo, hi float64
+		if e.fusion == FusionWeighted && len(output) > 0 {
+			lo, hi = output[0].Score, output[0].Score
+			for _, res := range output {
+				lo, hi = min(lo, res.Score), max(hi, res.Score)
+			}
+		}
+		for rank, res := range output {
+			pos, ok := position[key(res)]
+			if !ok {
+				continue
+			}
+			if e.fusion == FusionRRF {
+				scores[pos] += weight / float64(e.rrfK+rank+1)
+				continue
+			}
+			normalized := 1.0
+			if hi > lo {
+				normalized = (res.Score - lo) / (hi - lo)
+			}
+			scores[pos] += weight * normalized
+		}
+	}
+
+	positions := make([]int, 0, len(scores))
+	for pos := range scores {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		si, sj := scores[positions[i]], scores[positions[j]]
+		if si != sj {
+			return si > sj
+		}
+		return positions[i] < positions[j]
+	})
+
+	fused := make([]*reranker.Result, len(positions))
+	for i, pos := range positions {
+		fused[i] = &reranker.Result{Document: results[pos].Document, Score: scores[pos]}
+	}
+	return fused
+}
+
+// key identifies the document of a result across member outputs.
+func key(res *reranker.Result) any {
+	if res == nil || res.Document == nil {
+		return nil
+	}
+	if res.Document.ID != "" {
+		return res.Document.ID
+	}
+	return res.Document
+}
@@ -0,0 +1,125 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ensemble
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker/topk"
+)
+
+// fixedReranker returns the input results with the given scores by document
+// ID, in descending score order; IDs without a score are dropped.
+type fixedReranker struct {
+	scores map[string]float64
+	err    error
+}
+
+func (f *fixedReranker) Rerank(_ context.Context, _ *reranker.Query, results []*reranker.Result) ([]*reranker.Result, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out []*reranker.Result
+	for _, res := range results {
+		if score, ok := f.scores[res.Document.ID]; ok {
+			res.Score = score
+			out = append(out, res)
+		}
+	}
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].Score > out[j-1].Score; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out, nil
+}
+
+func newResults(ids ...string) []*reranker.Result {
+	results := make([]*reranker.Result, len(ids))
+	for i, id := range ids {
+		results[i] = &reranker.Result{Document: &document.Document{ID: id}, Score: 0.5}
+	}
+	return results
+}
+
+func ids(results []*reranker.Result) []string {
+	var out []string
+	for _, res := range results {
+		out = append(out, res.Document.ID)
+	}
+	return out
+}
+
+func TestReranker_RRF(t *testing.T) {
+	first := &fixedReranker{scores: map[string]float64{"a": 0.9, "b": 0.8, "c": 0.1}}
+	second := &fixedReranker{scores: map[string]float64{"c": 9, "b": 8}}
+	r, err := New(WithReranker(first, 1), WithReranker(second, 0))
+	require.NoError(t, err)
+
+	input := newResults("a", "b", "c", "d")
+	out, err := r.Rerank(context.Background(), &reranker.Query{Text: "q"}, input)
+	require.NoError(t, err)
+	// c: 1/63 + 1/61, b: 1/62 + 1/62, a: 1/61. d was dropped by both.
+	assert.Equal(t, []string{"c", "b", "a"}, ids(out))
+	assert.InDelta(t, 1.0/63+1.0/61, out[0].Score, 1e-12)
+	for _, res := range input {
+		assert.Equal(t, 0.5, res.Score, "members must not modify the input")
+	}
+
+	r, err = New(WithReranker(first, 3), WithReranker(second, 1), WithRRFK(1), WithTopN(1))
+	require.NoError(t, err)
+	out, err = r.Rerank(context.Background(), nil, input)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids(out), "weights favor the first member")
+}
+
+func TestReranker_Weighted(t *testing.T) {
+	first := &fixedReranker{scores: map[string]float64{"a": 0.9, "b": 0.5, "c": 0.1}}
+	second := &fixedReranker{scores: map[string]float64{"c": 100, "b": 90, "a": 0}}
+	r, err := New(WithFusion(FusionWeighted), WithReranker(first, 1), WithReranker(second, 2))
+	require.NoError(t, err)
+
+	out, err := r.Rerank(context.Background(), nil, newResults("a", "b", "c"))
+	require.NoError(t, err)
+	// a: 1 + 0, b: 0.5 + 1.8, c: 0 + 2.
+	assert.Equal(t, []string{"b", "c", "a"}, ids(out))
+	assert.InDelta(t, 2.3, out[0].Score, 1e-9)
+
+	// Members that do not reorder, e.g. topk, keep the input ranking.
+	r, err = New(WithReranker(topk.New(), 1), WithReranker(topk.New(topk.WithK(1)), 1))
+	require.NoError(t, err)
+	out, err = r.Rerank(context.Background(), nil, newResults("x", "y", "z"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "y", "z"}, ids(out))
+}
+
+func TestReranker_Errors(t *testing.T) {
+	_, err := New()
+	assert.ErrorIs(t, err, errNoRerankers)
+	_, err = New(WithReranker(nil, 1))
+	assert.ErrorContains(t, err, "ensemble reranker 0 is nil")
+	_, err = New(WithReranker(topk.New(), 1), WithFusion("max"))
+	assert.ErrorIs(t, err, errUnsupportedFusion)
+
+	r, err := New(WithReranker(topk.New(), 1), WithReranker(&fixedReranker{err: errors.New("boom")}, 1))
+	require.NoError(t, err)
+	_, err = r.Rerank(context.Background(), nil, newResults("a"))
+	assert.ErrorContains(t, err, "ensemble reranker 1: boom")
+
+	out, err := r.Rerank(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+}
@@ -0,0 +1,96 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llm
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+)
+
+const listwisePrompt = `You are a search relevance judge. Given a query and numbered passages, rank the passages by how relevant they are to the query.
+
+Rules:
+- Put the most relevant passage first.
+- Include every passage exactly once.
+- Output ONLY the ranking in the format "[2] > [1] > [3]", nothing else.`
+
+var rankID = regexp.MustCompile(`\[(\d+)\]`)
+
+// rankListwise ranks the results with a sliding window of batchSize
+// passages that moves from the bottom of the list to the top by half a
+// window, so that relevant passages can bubble up across windows. The
+// windows depend on each other and run sequentially. The returned raw score
+// of a result is n minus its final position.
+func (r *Reranker) rankListwise(
+	ctx context.Context,
+	query *reranker.Query,
+	results []*reranker.Result,
+) ([]float64, error) {
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	step := max(r.batchSize/2, 1)
+	text := queryText(query)
+	for end := len(order); ; end -= step {
+		start := max(end-r.batchSize, 0)
+		window := order[start:end]
+		output, err := r.complete(ctx, r.formatPassages(text, results, window))
+		if err != nil {
+			return nil, err
+		}
+		copy(window, applyRanking(window, parseRanking(output, len(window))))
+		if start == 0 {
+			break
+		}
+	}
+
+	scores := make([]float64, len(results))
+	for pos, idx := range order {
+		scores[idx] = float64(len(order) - pos)
+	}
+	return scores, nil
+}
+
+// parseRanking returns the 0-based batch positions in the order given by
+// the model, skipping duplicates and ids out of range.
+func parseRanking(output string, n int) []int {
+	seen := make(map[int]bool, n)
+	var ranking []int
+	for _, m := range rankID.FindAllStringSubmatch(output, -1) {
+		id, err := strconv.Atoi(m[1])
+		if err != nil || id < 1 || id > n || seen[id-1] {
+			continue
+		}
+		seen[id-1] = true
+		ranking = append(ranking, id-1)
+	}
+	return ranking
+}
+
+// applyRanking reorders window by ranking. Passages the model left out keep
+// their relative order after the ranked ones.
+func applyRanking(window []int, ranking []int) []int {
+	ranked := make([]int, 0, len(window))
+	seen := make(map[int]bool, len(window))
+	for _, pos := range ranking {
+		ranked = append(ranked, window[pos])
+		seen[pos] = true
+	}
+	for pos, idx := range window {
+		if !seen[pos] {
+			ranked = append(ranked, idx)
+		}
+	}
+	return ranked
+}
@@ -0,0 +1,306 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package llm provides a Reranker implementation that uses a language model
+// to judge query/passage relevance. Any model.Model works, including locally
+// served models, so no data has to leave the deployment.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Mode is the way the model is asked to judge relevance.
+type Mode string
+
+const (
+	// ModePointwise asks the model for an absolute 0-10 relevance score per
+	// passage. Batches are scored concurrently.
+	ModePointwise Mode = "pointwise"
+	// ModeListwise asks the model to order the passages by relevance. Result
+	// sets larger than the batch size are ranked with an overlapping sliding
+	// window, from the bottom of the list to the top.
+	ModeListwise Mode = "listwise"
+)
+
+// Normalization is the way raw relevance judgments are turned into scores.
+type Normalization string
+
+const (
+	// NormalizationLinear maps pointwise scores from 0-10 to 0-1 and
+	// listwise positions to (n-rank)/n.
+	NormalizationLinear Normalization = "linear"
+	// NormalizationMinMax rescales the scores of a single Rerank call to
+	// 0-1, with the best result at 1 and the worst at 0.
+	NormalizationMinMax Normalization = "minmax"
+	// NormalizationNone keeps the raw pointwise scores (0-10) and uses the
+	// reversed position (n-rank) for listwise ranking.
+	NormalizationNone Normalization = "none"
+)
+
+const (
+	defaultBatchSize        = 10
+	defaultMaxConcurrency   = 4
+	defaultMaxContentLength = 2000
+	maxPointwiseScore       = 10.0
+)
+
+var (
+	// errModelNil is returned when no model is given.
+	errModelNil = errors.New("llm reranker model cannot be nil")
+	// errUnsupportedMode is returned for an unknown mode.
+	errUnsupportedMode = errors.New("llm reranker mode must be pointwise or listwise")
+	// errUnsupportedNormalization is returned for an unknown normalization.
+	errUnsupportedNormalization = errors.New("llm reranker normalization must be linear, minmax or none")
+)
+
+// Reranker implements Reranker by prompting a model.Model.
+type Reranker struct {
+	model            model.Model
+	mode             Mode
+	normalization    Normalization
+	systemPrompt     string
+	batchSize        int
+	maxConcurrency   int
+	maxContentLength int
+	topN             int
+}
+
+// Option configures Reranker.
+type Option func(*Reranker)
+
+// WithMode sets the ranking mode. Defaults to ModePointwise.
+func WithMode(mode Mode) Option {
+	return func(r *Reranker) {
+		r.mode = mode
+	}
+}
+
+// WithNormalization sets how scores are normalized. Defaults to
+// NormalizationLinear.
+func WithNormalization(n Normalization) Option {
+	return func(r *Reranker) {
+		r.normalization = n
+	}
+}
+
+// WithSystemPrompt overrides the prompt of the selected mode. The model must
+// still answer in the format the mode expects: "[id] score" lines for
+// pointwise and "[id] > [id] > ..." for listwise ranking.
+func WithSystemPrompt(prompt string) Option {
+	return func(r *Reranker) {
+		r.systemPrompt = prompt
+	}
+}
+
+// WithBatchSize sets how many passages are sent to the model per call.
+// Defaults to 10.
+func WithBatchSize(n int) Option {
+	return func(r *Reranker) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithMaxConcurrency limits the number of concurrent model calls in
+// pointwise mode. Defaults to 4.
+func WithMaxConcurrency(n int) Option {
+	return func(r *Reranker) {
+		if n > 0 {
+			r.maxConcurrency = n
+		}
+	}
+}
+
+// WithMaxContentLength truncates each passage to n runes before sending it
+// to the model. Defaults to 2000, and n <= 0 disables truncation.
+func WithMaxContentLength(n int) Option {
+	return func(r *Reranker) {
+		r.maxContentLength = n
+	}
+}
+
+// WithTopN sets the number of results to return. Defaults to all results.
+func WithTopN(n int) Option {
+	return func(r *Reranker) {
+		r.topN = n
+	}
+}
+
+// New creates a new LLM reranker.
+func New(m model.Model, opts ...Option) (*Reranker, error) {
+	r := &Reranker{
+		model:            m,
+		mode:             ModePointwise,
+		normalization:    NormalizationLinear,
+		batchSize:        defaultBatchSize,
+		maxConcurrency:   defaultMaxConcurrency,
+		maxContentLength: defaultMaxContentLength,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.model == nil {
+		return nil, errModelNil
+	}
+	switch r.mode {
+	case ModePointwise:
+		if r.systemPrompt == "" {
+			r.systemPrompt = pointwisePrompt
+		}
+	case ModeListwise:
+		if r.systemPrompt == "" {
+			r.systemPrompt = listwisePrompt
+		}
+	default:
+		return nil, errUnsupportedMode
+	}
+	switch r.normalization {
+	case NormalizationLinear, NormalizationMinMax, NormalizationNone:
+	default:
+		return nil, errUnsupportedNormalization
+	}
+	return r, nil
+}
+
+// Rerank implements the Reranker interface.
+func (r *Reranker) Rerank(
+	ctx context.Context,
+	query *reranker.Query,
+	results []*reranker.Result,
+) ([]*reranker.Result, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	var (
+		scores []float64
+		err    error
+	)
+	if r.mode == ModeListwise {
+		scores, err = r.rankListwise(ctx, query, results)
+	} else {
+		scores, err = r.scorePointwise(ctx, query, results)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.normalize(scores)
+
+	reranked := make([]*reranker.Result, len(results))
+	for i, res := range results {
+		reranked[i] = &reranker.Result{Document: res.Document, Score: scores[i]}
+	}
+	sortByScore(reranked)
+	if r.topN > 0 && len(reranked) > r.topN {
+		reranked = reranked[:r.topN]
+	}
+	return reranked, nil
+}
+
+// normalize rescales the raw scores in place.
+func (r *Reranker) normalize(scores []float64) {
+	switch r.normalization {
+	case NormalizationLinear:
+		if r.mode == ModeListwise {
+			for i := range scores {
+				scores[i] /= float64(len(scores))
+			}
+			return
+		}
+		for i := range scores {
+			scores[i] /= maxPointwiseScore
+		}
+	case NormalizationMinMax:
+		lo, hi := scores[0], scores[0]
+		for _, s := range scores {
+			lo, hi = min(lo, s), max(hi, s)
+		}
+		for i := range scores {
+			if hi == lo {
+				scores[i] = 1
+			} else {
+				scores[i] = (scores[i] - lo) / (hi - lo)
+			}
+		}
+	}
+}
+
+// complete sends the prompt and the user message to the model and returns
+// the text of the answer.
+func (r *Reranker) complete(ctx context.Context, user string) (string, error) {
+	ch, err := r.model.GenerateContent(ctx, &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage(r.systemPrompt),
+			model.NewUserMessage(user),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("llm rerank call failed: %w", err)
+	}
+
+	var result strings.Builder
+	for resp := range ch {
+		if resp.Error != nil {
+			return "", fmt.Errorf("llm rerank error: %s", resp.Error.Message)
+		}
+		for _, choice := range resp.Choices {
+			if choice.Message.Content != "" {
+				result.WriteString(choice.Message.Content)
+			}
+			if choice.Delta.Content != "" {
+				result.WriteString(choice.Delta.Content)
+			}
+		}
+	}
+	return result.String(), nil
+}
+
+// queryText returns the text the passages are judged against.
+func queryText(query *reranker.Query) string {
+	if query == nil {
+		return ""
+	}
+	if query.FinalQuery != "" {
+		return query.FinalQuery
+	}
+	return query.Text
+}
+
+// formatPassages renders the query and the passages at indices, numbered
+// from 1 within the batch.
+func (r *Reranker) formatPassages(query string, results []*reranker.Result, indices []int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n\nPassages:\n", query)
+	for n, idx := range indices {
+		content := ""
+		if doc := results[idx].Document; doc != nil {
+			content = doc.Content
+		}
+		if runes := []rune(content); r.maxContentLength > 0 && len(runes) > r.maxContentLength {
+			content = string(runes[:r.maxContentLength])
+		}
+		fmt.Fprintf(&b, "\n[%d] %s\n", n+1, strings.TrimSpace(content))
+	}
+	return b.String()
+}
+
+// sortByScore sorts results by descending score, keeping the input order
+// for equal scores.
+func sortByScore(results []*reranker.Result) {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+}
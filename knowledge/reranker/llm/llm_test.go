@@ -0,0 +1,203 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+var passageLine = regexp.MustCompile(`(?m)^\[(\d+)\] (.*)$`)
+
+// judgeModel answers rerank prompts from a fixed relevance per passage
+// content, in the format of the prompt's mode.
+type judgeModel struct {
+	relevance map[string]float64
+	err       error
+	apiErr    *model.ResponseError
+	delay     time.Duration
+
+	mu       sync.Mutex
+	calls    int
+	inFlight int
+	peak     int
+	prompts  []string
+}
+
+func (m *judgeModel) GenerateContent(_ context.Context, req *model.Request) (<-chan *model.Response, error) {
+	m.mu.Lock()
+	m.calls++
+	m.inFlight++
+	m.peak = max(m.peak, m.inFlight)
+	m.prompts = append(m.prompts, req.Messages[1].Content)
+	m.mu.Unlock()
+	time.Sleep(m.delay)
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	type passage struct {
+		id    string
+		score float64
+	}
+	var passages []passage
+	for _, match := range passageLine.FindAllStringSubmatch(req.Messages[1].Content, -1) {
+		score, ok := m.relevance[match[2]]
+		if !ok {
+			continue // Leave unknown passages unjudged.
+		}
+		passages = append(passages, passage{id: match[1], score: score})
+	}
+	var answer []string
+	if strings.Contains(req.Messages[0].Content, "rank the passages") {
+		sort.SliceStable(passages, func(i, j int) bool { return passages[i].score > passages[j].score })
+		for _, p := range passages {
+			answer = append(answer, "["+p.id+"]")
+		}
+		answer = []string{strings.Join(answer, " > ")}
+	} else {
+		for _, p := range passages {
+			answer = append(answer, fmt.Sprintf("[%s] %g", p.id, p.score))
+		}
+	}
+
+	ch := make(chan *model.Response, 1)
+	ch <- &model.Response{
+		Choices: []model.Choice{{Message: model.Message{Content: strings.Join(answer, "\n")}}},
+		Error:   m.apiErr,
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (m *judgeModel) Info() model.Info {
+	return model.Info{Name: "judge"}
+}
+
+func newResults(contents ...string) []*reranker.Result {
+	results := make([]*reranker.Result, len(contents))
+	for i, content := range contents {
+		results[i] = &reranker.Result{Document: &document.Document{ID: content, Content: content}, Score: 1}
+	}
+	return results
+}
+
+func contents(results []*reranker.Result) []string {
+	var out []string
+	for _, res := range results {
+		out = append(out, res.Document.Content)
+	}
+	return out
+}
+
+func TestReranker_Pointwise(t *testing.T) {
+	m := &judgeModel{
+		relevance: map[string]float64{"a": 2, "b": 9, "c": 5, "d": 7, "e": 11},
+		delay:     20 * time.Millisecond,
+	}
+	r, err := New(m, WithBatchSize(2), WithMaxConcurrency(2), WithTopN(4))
+	require.NoError(t, err)
+
+	out, err := r.Rerank(context.Background(), &reranker.Query{Text: "q", FinalQuery: "final q"},
+		newResults("a", "b", "c", "d", "e", "unjudged"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e", "b", "d", "c"}, contents(out))
+	assert.Equal(t, 1.0, out[0].Score, "scores above 10 are clamped")
+	assert.InDelta(t, 0.9, out[1].Score, 1e-9)
+	assert.Equal(t, 3, m.calls, "6 passages in batches of 2")
+	assert.Equal(t, 2, m.peak, "concurrency is limited")
+	assert.True(t, strings.HasPrefix(m.prompts[0], "Query: final q\n"))
+}
+
+func TestReranker_PointwiseMinMaxAndTruncation(t *testing.T) {
+	long := strings.Repeat("x", 50)
+	m := &judgeModel{relevance: map[string]float64{"a": 4, "b": 6, long[:10]: 8}}
+	r, err := New(m, WithNormalization(NormalizationMinMax), WithMaxContentLength(10))
+	require.NoError(t, err)
+
+	out, err := r.Rerank(context.Background(), &reranker.Query{Text: "q"}, newResults("a", "b", long))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 0.5, 0}, []float64{out[0].Score, out[1].Score, out[2].Score})
+	assert.Equal(t, long, out[0].Document.Content, "only the prompt is truncated")
+	assert.True(t, strings.HasPrefix(m.prompts[0], "Query: q\n"), "falls back to the original query")
+}
+
+func TestReranker_ListwiseSlidingWindow(t *testing.T) {
+	relevance := map[string]float64{}
+	var input []string
+	for i := 0; i < 7; i++ {
+		c := fmt.Sprintf("p%d", i)
+		relevance[c] = float64(i)
+		input = append(input, c)
+	}
+	m := &judgeModel{relevance: relevance}
+	r, err := New(m, WithMode(ModeListwise), WithBatchSize(4), WithNormalization(NormalizationNone))
+	require.NoError(t, err)
+
+	out, err := r.Rerank(context.Background(), nil, newResults(input...))
+	require.NoError(t, err)
+	// Windows [3,7), [1,5), [0,3) move by half a window, which bubbles the
+	// two best passages to the top.
+	assert.Equal(t, 3, m.calls)
+	assert.Equal(t, []string{"p6", "p5", "p0", "p2", "p1", "p4", "p3"}, contents(out))
+	assert.Equal(t, 7.0, out[0].Score)
+
+	r, err = New(m, WithMode(ModeListwise))
+	require.NoError(t, err)
+	out, err = r.Rerank(context.Background(), nil, newResults("p1", "unranked", "p2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p2", "p1", "unranked"}, contents(out))
+	assert.InDelta(t, 1.0/3, out[2].Score, 1e-9)
+}
+
+func TestReranker_Errors(t *testing.T) {
+	_, err := New(nil)
+	assert.ErrorIs(t, err, errModelNil)
+	_, err = New(&judgeModel{}, WithMode("pairwise"))
+	assert.ErrorIs(t, err, errUnsupportedMode)
+	_, err = New(&judgeModel{}, WithNormalization("sigmoid"))
+	assert.ErrorIs(t, err, errUnsupportedNormalization)
+
+	r, err := New(&judgeModel{err: errors.New("boom")}, WithBatchSize(1))
+	require.NoError(t, err)
+	_, err = r.Rerank(context.Background(), nil, newResults("a", "b"))
+	assert.ErrorContains(t, err, "llm rerank call failed: boom")
+
+	r, err = New(&judgeModel{apiErr: &model.ResponseError{Message: "overloaded"}}, WithMode(ModeListwise))
+	require.NoError(t, err)
+	_, err = r.Rerank(context.Background(), nil, newResults("a"))
+	assert.ErrorContains(t, err, "overloaded")
+
+	out, err := r.Rerank(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestParseScores(t *testing.T) {
+	scores := parseScores("[1] 7\n2: 3.5\n[3]=-2\n[9] 5\nnoise\n[2] 4", 3)
+	assert.Equal(t, map[int]float64{0: 7, 1: 4, 2: 0}, scores)
+	assert.Equal(t, []int{2, 0}, parseRanking("[3] > [1] > [3] > [7]", 3))
+}
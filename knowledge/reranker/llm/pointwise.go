@@ -0,0 +1,108 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llm
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+)
+
+const pointwisePrompt = `You are a search relevance judge. Given a query and numbered passages, rate how relevant each passage is to the query.
+
+Rules:
+- Use a score from 0 (unrelated) to 10 (directly and fully answers the query).
+- Judge each passage on its own; several passages may get the same score.
+- Output ONLY one line per passage in the format "[id] score", e.g. "[1] 7", nothing else.`
+
+// scoreLine matches "[id] score" lines, tolerating a missing bracket or a
+// separator such as ":" between id and score.
+var scoreLine = regexp.MustCompile(`(?m)^\s*\[?(\d+)\]?\s*[:=]?\s*(-?\d+(?:\.\d+)?)`)
+
+// scorePointwise scores the results in batches, running at most
+// maxConcurrency model calls at a time. Passages the model does not score
+// get 0.
+func (r *Reranker) scorePointwise(
+	ctx context.Context,
+	query *reranker.Query,
+	results []*reranker.Result,
+) ([]float64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scores := make([]float64, len(results))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, r.maxConcurrency)
+	text := queryText(query)
+	for start := 0; start < len(results); start += r.batchSize {
+		indices := make([]int, 0, r.batchSize)
+		for i := start; i < len(results) && i < start+r.batchSize; i++ {
+			indices = append(indices, i)
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(indices []int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			output, err := r.complete(ctx, r.formatPassages(text, results, indices))
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			batchScores := parseScores(output, len(indices))
+			for n, idx := range indices {
+				score, ok := batchScores[n]
+				if !ok {
+					log.WarnfContext(ctx, "llm reranker: no score for passage %d, using 0", idx)
+				}
+				scores[idx] = score
+			}
+		}(indices)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return scores, nil
+}
+
+// parseScores parses "[id] score" lines into scores keyed by the 0-based
+// batch position. Ids out of range are ignored and scores are clamped to
+// 0-10.
+func parseScores(output string, n int) map[int]float64 {
+	scores := make(map[int]float64, n)
+	for _, m := range scoreLine.FindAllStringSubmatch(output, -1) {
+		id, err := strconv.Atoi(m[1])
+		if err != nil || id < 1 || id > n {
+			continue
+		}
+		score, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		scores[id-1] = min(max(score, 0), maxPointwiseScore)
+	}
+	return scores
+}
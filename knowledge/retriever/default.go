@@ -11,6 +11,8 @@ package retriever
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/embedder"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/query"
@@ -73,6 +75,7 @@ func New(opts ...Option) *DefaultRetriever {
 func (dr *DefaultRetriever) Retrieve(ctx context.Context, q *Query) (*Result, error) {
 	// Step 1: Enhance query (if enhancer is available).
 	finalQuery := q.Text
+	var variants []query.Variant
 	if dr.queryEnhancer != nil && shouldEnhanceQuery(q) {
 		// Create query request with full context.
 		// No conversion needed as both use the same type from query package
@@ -90,13 +93,60 @@ func (dr *DefaultRetriever) Retrieve(ctx context.Context, q *Query) (*Result, er
 		if finalQuery != q.Text {
 			log.DebugfContext(ctx, "query enhanced: %q -> %q", q.Text, finalQuery)
 		}
+		if len(enhanced.Variants) > 1 {
+			variants = enhanced.Variants
+			log.DebugfContext(ctx, "query %q expanded into %d variants: %+v", q.Text, len(variants), variants)
+		}
+	}
+
+	// Steps 2-4: Embed the query, search the vector store and convert to
+	// reranker format. Multiple variants are searched concurrently and fused.
+	var rerankerResults []*reranker.Result
+	var err error
+	if len(variants) > 0 {
+		rerankerResults, err = dr.searchVariants(ctx, q, variants)
+	} else {
+		rerankerResults, err = dr.search(ctx, q, finalQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 5: Rerank results (if reranker is available).
+	if dr.reranker != nil {
+		rerankerResults, err = dr.reranker.Rerank(ctx, &reranker.Query{
+			Text:       q.Text,
+			FinalQuery: finalQuery,
+			History:    q.History,
+			UserID:     q.UserID,
+			SessionID:  q.SessionID,
+		}, rerankerResults)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	// Step 6: Convert back to retriever format.
+	finalResults := make([]*RelevantDocument, len(rerankerResults))
+	for i, result := range rerankerResults {
+		finalResults[i] = &RelevantDocument{
+			Document: result.Document,
+			Score:    result.Score,
+		}
+	}
+
+	return &Result{
+		Documents: finalResults,
+	}, nil
+}
+
+// search embeds text and searches the vector store with it.
+func (dr *DefaultRetriever) search(ctx context.Context, q *Query, text string) ([]*reranker.Result, error) {
 	// Step 2: Generate embedding.
 	var embedding []float64
-	if dr.embedder != nil && finalQuery != "" {
+	if dr.embedder != nil && text != "" {
 		var err error
-		embedding, err = dr.embedder.GetEmbedding(ctx, finalQuery)
+		embedding, err = dr.embedder.GetEmbedding(ctx, text)
 		if err != nil {
 			return nil, err
 		}
@@ -104,7 +154,7 @@ func (dr *DefaultRetriever) Retrieve(ctx context.Context, q *Query) (*Result, er
 
 	// Step 3: Search vector store.
 	searchResults, err := dr.vectorStore.Search(ctx, &vectorstore.SearchQuery{
-		Query:      finalQuery,
+		Query:      text,
 		Vector:     embedding,
 		Limit:      q.Limit,
 		MinScore:   q.MinScore,
@@ -123,33 +173,33 @@ func (dr *DefaultRetriever) Retrieve(ctx context.Context, q *Query) (*Result, er
 			Score:    doc.Score,
 		}
 	}
+	return rerankerResults, nil
+}
 
-	// Step 5: Rerank results (if reranker is available).
-	if dr.reranker != nil {
-		rerankerResults, err = dr.reranker.Rerank(ctx, &reranker.Query{
-			Text:       q.Text,
-			FinalQuery: finalQuery,
-			History:    q.History,
-			UserID:     q.UserID,
-			SessionID:  q.SessionID,
-		}, rerankerResults)
-		if err != nil {
-			return nil, err
-		}
+// searchVariants searches all query variants concurrently and fuses the
+// results with reciprocal rank fusion.
+func (dr *DefaultRetriever) searchVariants(
+	ctx context.Context,
+	q *Query,
+	variants []query.Variant,
+) ([]*reranker.Result, error) {
+	results := make([][]*reranker.Result, len(variants))
+	errs := make([]error, len(variants))
+	var wg sync.WaitGroup
+	for i, variant := range variants {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			results[i], errs[i] = dr.search(ctx, q, text)
+		}(i, variant.Text)
 	}
-
-	// Step 6: Convert back to retriever format.
-	finalResults := make([]*RelevantDocument, len(rerankerResults))
-	for i, result := range rerankerResults {
-		finalResults[i] = &RelevantDocument{
-			Document: result.Document,
-			Score:    result.Score,
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("search query variant %q: %w", variants[i].Text, err)
 		}
 	}
-
-	return &Result{
-		Documents: finalResults,
-	}, nil
+	return fuseVariants(variants, results, q.Limit), nil
 }
 
 // Close implements the Retriever interface.
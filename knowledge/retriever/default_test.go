@@ -11,12 +11,16 @@ package retriever
 
 import (
 	"context"
+	"errors"
+	"math"
+	"strings"
 	"testing"
 
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
 	q "trpc.group/trpc-go/trpc-agent-go/knowledge/query"
 	r "trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker/topk"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore/inmemory"
 )
@@ -206,6 +210,7 @@ func TestDefaultRetriever_WithNilEmbedder(t *testing.T) {
 // mockQueryEnhancer is a mock query enhancer for testing.
 type mockQueryEnhancer struct {
 	enhanced string
+	variants []q.Variant
 	err      error
 	calls    int
 }
@@ -215,7 +220,7 @@ func (m *mockQueryEnhancer) EnhanceQuery(ctx context.Context, req *q.Request) (*
 	if m.err != nil {
 		return nil, m.err
 	}
-	return &q.Enhanced{Enhanced: m.enhanced}, nil
+	return &q.Enhanced{Enhanced: m.enhanced, Variants: m.variants}, nil
 }
 
 // TestDefaultRetriever_WithQueryEnhancer tests retrieving with query enhancer.
@@ -249,6 +254,82 @@ func TestDefaultRetriever_WithQueryEnhancer(t *testing.T) {
 	}
 }
 
+// vectorEmbedder returns a fixed vector per text.
+type vectorEmbedder map[string][]float64
+
+func (e vectorEmbedder) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	v, ok := e[text]
+	if !ok {
+		return nil, errors.New("unknown text")
+	}
+	return v, nil
+}
+func (e vectorEmbedder) GetEmbeddingWithUsage(ctx context.Context, text string) ([]float64, map[string]any, error) {
+	v, err := e.GetEmbedding(ctx, text)
+	return v, nil, err
+}
+func (vectorEmbedder) GetDimensions() int { return 3 }
+
+// TestDefaultRetriever_WithQueryVariants tests that query variants are
+// searched separately and fused with RRF.
+func TestDefaultRetriever_WithQueryVariants(t *testing.T) {
+	vs := inmemory.New()
+	for id, vec := range map[string][]float64{"a": {1, 0, 0}, "b": {0, 1, 0}, "c": {0, 0, 1}} {
+		if err := vs.Add(context.Background(), &document.Document{ID: id, Content: id}, vec); err != nil {
+			t.Fatalf("add doc: %v", err)
+		}
+	}
+	enhancer := &mockQueryEnhancer{
+		enhanced: "near a",
+		variants: []q.Variant{
+			{Kind: q.VariantOriginal, Text: "near a"},
+			{Kind: q.VariantMultiQuery, Text: "near b"},
+			{Kind: q.VariantStepBack, Text: "near c"},
+		},
+	}
+	d := New(
+		WithEmbedder(vectorEmbedder{
+			"near a": {1, 0.5, 0},
+			"near b": {0.5, 1, 0},
+			"near c": {0, 0.2, 1},
+		}),
+		WithVectorStore(vs),
+		WithQueryEnhancer(enhancer),
+		WithReranker(topk.New()),
+	)
+
+	res, err := d.Retrieve(context.Background(), &Query{Text: "near a", Limit: 2})
+	if err != nil {
+		t.Fatalf("retrieve err: %v", err)
+	}
+	// b is retrieved by all three variants, a by two and c by one.
+	if len(res.Documents) != 2 || res.Documents[0].Document.ID != "b" || res.Documents[1].Document.ID != "a" {
+		t.Fatalf("unexpected fused results: %+v", res.Documents)
+	}
+	wantScore := 1.0/62 + 1.0/61 + 1.0/62
+	if math.Abs(res.Documents[0].Score-wantScore) > 1e-9 {
+		t.Fatalf("expected RRF score %v, got %v", wantScore, res.Documents[0].Score)
+	}
+
+	hits, ok := res.Documents[0].Document.Metadata[source.MetaQueryVariants].([]map[string]any)
+	if !ok || len(hits) != 3 {
+		t.Fatalf("expected 3 variant hits in metadata, got %#v", res.Documents[0].Document.Metadata)
+	}
+	if hits[2]["kind"] != "step_back" || hits[2]["query"] != "near c" || hits[2]["rank"] != 2 {
+		t.Fatalf("unexpected variant hit: %v", hits[2])
+	}
+	if hits, _ := res.Documents[1].Document.Metadata[source.MetaQueryVariants].([]map[string]any); len(hits) != 2 {
+		t.Fatalf("expected 2 variant hits for a, got %v", hits)
+	}
+
+	// A failing variant fails the whole search.
+	enhancer.variants = append(enhancer.variants, q.Variant{Kind: q.VariantHyDE, Text: "unknown"})
+	if _, err := d.Retrieve(context.Background(), &Query{Text: "near a", Limit: 2}); err == nil ||
+		!strings.Contains(err.Error(), `search query variant "unknown"`) {
+		t.Fatalf("expected variant error, got %v", err)
+	}
+}
+
 // TestDefaultRetriever_QueryEnhancerError tests error handling from query enhancer.
 func TestDefaultRetriever_QueryEnhancerError(t *testing.T) {
 	vs := inmemory.New()
@@ -0,0 +1,76 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package retriever
+
+import (
+	"sort"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/query"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/reranker"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+)
+
+// rrfK is the reciprocal rank fusion constant, 60 as in the original paper.
+const rrfK = 60
+
+// fusedResult accumulates the hits of one document across query variants.
+type fusedResult struct {
+	doc   *document.Document
+	score float64
+	hits  []map[string]any
+}
+
+// fuseVariants merges the ranked results of each query variant with
+// reciprocal rank fusion: a document scores sum(1 / (rrfK + rank)) over the
+// variants that retrieved it. The fused score replaces the similarity score,
+// and the variants that retrieved each document are recorded in its
+// metadata under source.MetaQueryVariants. limit <= 0 keeps all documents.
+func fuseVariants(variants []query.Variant, results [][]*reranker.Result, limit int) []*reranker.Result {
+	byID := make(map[string]*fusedResult)
+	var order []*fusedResult
+	for i, variantResults := range results {
+		for rank, r := range variantResults {
+			if r == nil || r.Document == nil {
+				continue
+			}
+			f, ok := byID[r.Document.ID]
+			if !ok {
+				f = &fusedResult{doc: r.Document}
+				byID[r.Document.ID] = f
+				order = append(order, f)
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+			f.hits = append(f.hits, map[string]any{
+				"kind":  string(variants[i].Kind),
+				"query": variants[i].Text,
+				"rank":  rank + 1,
+				"score": r.Score,
+			})
+		}
+	}
+
+	// Stable sort keeps the first-seen order for equal scores.
+	sort.SliceStable(order, func(i, j int) bool { return order[i].score > order[j].score })
+	if limit > 0 && len(order) > limit {
+		order = order[:limit]
+	}
+
+	fused := make([]*reranker.Result, len(order))
+	for i, f := range order {
+		doc := f.doc.Clone()
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]any)
+		}
+		doc.Metadata[source.MetaQueryVariants] = f.hits
+		fused[i] = &reranker.Result{Document: doc, Score: f.score}
+	}
+	return fused
+}
@@ -12,6 +12,7 @@ package dir
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -147,6 +148,59 @@ func (s *Source) ReadDocuments(ctx context.Context) ([]*document.Document, error
 	return allDocuments, nil
 }
 
+// WatchPaths returns the directories of this source.
+func (s *Source) WatchPaths(ctx context.Context) ([]string, error) {
+	var paths []string
+	for _, dirPath := range s.dirPaths {
+		if dirPath != "" {
+			paths = append(paths, dirPath)
+		}
+	}
+	return paths, nil
+}
+
+// ListFiles returns the files currently found in the directories. Directories
+// that do not exist are treated as empty.
+func (s *Source) ListFiles(ctx context.Context) ([]string, error) {
+	var allPaths []string
+	for _, dirPath := range s.dirPaths {
+		if dirPath == "" {
+			continue
+		}
+		if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+			continue
+		}
+		filePaths, err := s.getFilePaths(dirPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file paths from directory %s: %w", dirPath, err)
+		}
+		allPaths = append(allPaths, filePaths...)
+	}
+	return allPaths, nil
+}
+
+// ReadFiles reads the documents of the given files. Unlike ReadDocuments, it
+// does not skip files that fail to process: the files were asked for
+// explicitly, so it returns the errors of all such files.
+func (s *Source) ReadFiles(ctx context.Context, filePaths []string) ([]*document.Document, error) {
+	var (
+		allDocuments []*document.Document
+		errs         []error
+	)
+	for _, filePath := range filePaths {
+		documents, err := s.processFile(ctx, filePath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to process file %s: %w", filePath, err))
+			continue
+		}
+		allDocuments = append(allDocuments, documents...)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return allDocuments, nil
+}
+
 // Name returns the name of this source.
 func (s *Source) Name() string {
 	return s.name
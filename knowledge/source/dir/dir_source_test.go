@@ -228,6 +228,28 @@ func TestReadDocuments_Basic(t *testing.T) {
 	}
 }
 
+func TestReadDocuments_HTMLByExtension(t *testing.T) {
+	tmpDir := t.TempDir()
+	page := "<html><head><title>Guide</title></head><body><nav>Menu</nav><main><h1>Setup</h1><p>Install it.</p></main></body></html>"
+	if err := os.WriteFile(filepath.Join(tmpDir, "guide.html"), []byte(page), 0600); err != nil {
+		t.Fatalf("failed to write html file: %v", err)
+	}
+
+	docs, err := New([]string{tmpDir}).ReadDocuments(context.Background())
+	if err != nil {
+		t.Fatalf("ReadDocuments returned error: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 document, got %d", len(docs))
+	}
+	if docs[0].Content != "# Setup\n\nInstall it." {
+		t.Errorf("unexpected content %q", docs[0].Content)
+	}
+	if docs[0].Metadata[source.MetaTitle] != "Guide" {
+		t.Errorf("unexpected title %v", docs[0].Metadata[source.MetaTitle])
+	}
+}
+
 // TestNameAndMetadata verifies functional options related to name and metadata.
 func TestNameAndMetadata(t *testing.T) {
 	const customName = "my-dir-src"
@@ -316,6 +338,45 @@ func TestSource_Recursive(t *testing.T) {
 	}
 }
 
+func TestListAndReadFiles(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("alpha"), 0o600)
+	os.WriteFile(filepath.Join(root, "b.md"), []byte("# bravo"), 0o600)
+	os.WriteFile(filepath.Join(root, "c.log"), []byte("skipped"), 0o600)
+	missing := filepath.Join(root, "missing")
+
+	src := New([]string{root, "", missing}, WithFileExtensions([]string{".txt", ".md"}))
+	roots, err := src.WatchPaths(ctx)
+	if err != nil || len(roots) != 2 {
+		t.Fatalf("WatchPaths() = %v, %v", roots, err)
+	}
+	files, err := src.ListFiles(ctx)
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("ListFiles() = %v, want the txt and md files", files)
+	}
+
+	docs, err := src.ReadFiles(ctx, []string{filepath.Join(root, "a.txt")})
+	if err != nil {
+		t.Fatalf("ReadFiles() error = %v", err)
+	}
+	if len(docs) != 1 || docs[0].Metadata[source.MetaFileName] != "a.txt" {
+		t.Fatalf("ReadFiles() should read a.txt, got %d docs", len(docs))
+	}
+
+	gone := filepath.Join(root, "gone.txt")
+	docs, err = src.ReadFiles(ctx, []string{filepath.Join(root, "a.txt"), gone, root})
+	if err == nil || !strings.Contains(err.Error(), gone) || !strings.Contains(err.Error(), "not a regular file") {
+		t.Fatalf("ReadFiles() should report every unreadable file, got %v", err)
+	}
+	if docs != nil {
+		t.Fatalf("ReadFiles() should not return documents on error, got %d", len(docs))
+	}
+}
+
 // TestWithMetadataValue verifies the WithMetadataValue option.
 func TestWithMetadataValue(t *testing.T) {
 	const metaKey = "test_key"
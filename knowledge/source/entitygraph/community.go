@@ -0,0 +1,222 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package entitygraph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+)
+
+const (
+	maxLabelPropagationRounds = 20
+	communityNameEntities     = 3
+)
+
+type community struct {
+	members []*entity
+	degree  map[string]int
+}
+
+// buildCommunities groups entities connected by relations and asks the model
+// for a summary of every group large enough.
+func (s *Source) buildCommunities(ctx context.Context, b *builder, concurrency int) (*graph.Data, error) {
+	communities := b.detectCommunities(s.minCommunitySize)
+	summaries := make([]string, len(communities))
+	err := runParallel(ctx, len(communities), concurrency, func(i int) error {
+		summary, err := s.summarizeCommunity(ctx, b, communities[i])
+		if err != nil {
+			return fmt.Errorf("summarize community %d: %w", i+1, err)
+		}
+		summaries[i] = summary
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &graph.Data{}
+	for i, c := range communities {
+		id := fmt.Sprintf("community:%d", i+1)
+		memberIDs := make([]string, 0, len(c.members))
+		for _, member := range c.members {
+			memberIDs = append(memberIDs, member.id)
+		}
+		data.Nodes = append(data.Nodes, &graph.Node{
+			ID:      id,
+			Name:    fmt.Sprintf("Community %d: %s", i+1, strings.Join(c.topNames(communityNameEntities), ", ")),
+			Content: summaries[i],
+			Metadata: map[string]any{
+				graph.MetaNodeKind:  graph.NodeKindCommunity,
+				graph.MetaMemberIDs: memberIDs,
+			},
+		})
+		for _, memberID := range memberIDs {
+			data.Edges = append(data.Edges, &graph.Edge{
+				ID:     "in_community:" + memberID + "|" + id,
+				FromID: memberID,
+				ToID:   id,
+				Type:   graph.EdgeTypeInCommunity,
+			})
+		}
+	}
+	return data, nil
+}
+
+// detectCommunities runs weighted label propagation over the undirected
+// relation graph. Nodes are visited in ID order and ties go to the smallest
+// label, so the result is deterministic.
+func (b *builder) detectCommunities(minSize int) []*community {
+	byID := make(map[string]*entity, len(b.entities))
+	ids := make([]string, 0, len(b.entities))
+	for _, e := range b.entities {
+		byID[e.id] = e
+		ids = append(ids, e.id)
+	}
+	sort.Strings(ids)
+
+	neighbours := make(map[string]map[string]int, len(ids))
+	for _, key := range b.relOrder {
+		r := b.relations[key]
+		for _, pair := range [][2]string{{r.fromID, r.toID}, {r.toID, r.fromID}} {
+			if neighbours[pair[0]] == nil {
+				neighbours[pair[0]] = make(map[string]int)
+			}
+			neighbours[pair[0]][pair[1]] += r.weight
+		}
+	}
+
+	labels := make(map[string]string, len(ids))
+	for _, id := range ids {
+		labels[id] = id
+	}
+	for round := 0; round < maxLabelPropagationRounds; round++ {
+		changed := false
+		for _, id := range ids {
+			if len(neighbours[id]) == 0 {
+				continue
+			}
+			scores := make(map[string]int)
+			for neighbour, weight := range neighbours[id] {
+				scores[labels[neighbour]] += weight
+			}
+			best, bestScore := labels[id], scores[labels[id]]
+			for label, score := range scores {
+				if score > bestScore || (score == bestScore && label < best) {
+					best, bestScore = label, score
+				}
+			}
+			if best != labels[id] {
+				labels[id] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, id := range ids {
+		groups[labels[id]] = append(groups[labels[id]], id)
+	}
+	var communities []*community
+	for _, memberIDs := range groups {
+		if len(memberIDs) < minSize || len(memberIDs) < 2 {
+			continue
+		}
+		c := &community{degree: make(map[string]int, len(memberIDs))}
+		for _, id := range memberIDs {
+			c.members = append(c.members, byID[id])
+			for _, weight := range neighbours[id] {
+				c.degree[id] += weight
+			}
+		}
+		communities = append(communities, c)
+	}
+	sort.Slice(communities, func(i, j int) bool {
+		if len(communities[i].members) != len(communities[j].members) {
+			return len(communities[i].members) > len(communities[j].members)
+		}
+		return communities[i].members[0].id < communities[j].members[0].id
+	})
+	return communities
+}
+
+// topNames returns the names of the n most connected members.
+func (c *community) topNames(n int) []string {
+	members := append([]*entity(nil), c.members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		return c.degree[members[i].id] > c.degree[members[j].id]
+	})
+	if len(members) > n {
+		members = members[:n]
+	}
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		names = append(names, member.name())
+	}
+	return names
+}
+
+func (s *Source) summarizeCommunity(ctx context.Context, b *builder, c *community) (string, error) {
+	prompt := s.communityPrompt
+	if prompt == "" {
+		prompt = DefaultCommunityPrompt
+	}
+	summary, err := generate(ctx, s.model, prompt, communityReport(b, c))
+	if err != nil {
+		return "", err
+	}
+	if summary == "" {
+		return strings.Join(c.topNames(len(c.members)), ", "), nil
+	}
+	return summary, nil
+}
+
+// communityReport lists the entities and relations of a community for the
+// summarization prompt, truncated to maxCommunityPromptRunes.
+func communityReport(b *builder, c *community) string {
+	members := make(map[string]*entity, len(c.members))
+	var lines []string
+	lines = append(lines, "Entities:")
+	for _, member := range c.members {
+		members[member.id] = member
+		line := "- " + member.name()
+		if t := member.entityType(); t != "" {
+			line += " (" + t + ")"
+		}
+		if descriptions := member.descriptions.values(); len(descriptions) > 0 {
+			line += ": " + strings.Join(descriptions, " ")
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", "Relations:")
+	for _, key := range b.relOrder {
+		r := b.relations[key]
+		from, to := members[r.fromID], members[r.toID]
+		if from == nil || to == nil {
+			continue
+		}
+		line := fmt.Sprintf("- %s %s %s", from.name(), r.relType, to.name())
+		if descriptions := r.descriptions.values(); len(descriptions) > 0 {
+			line += ": " + strings.Join(descriptions, " ")
+		}
+		lines = append(lines, line)
+	}
+	report := strings.Join(lines, "\n")
+	if runes := []rune(report); len(runes) > maxCommunityPromptRunes {
+		report = string(runes[:maxCommunityPromptRunes])
+	}
+	return report
+}
@@ -0,0 +1,241 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package entitygraph provides a graph source that uses a language model to
+// extract entities and relations from prose documents.
+//
+// The source reads documents from ordinary knowledge sources, asks the model
+// to extract typed entities and relations from every chunk, merges entities
+// that refer to the same thing across chunks, and groups related entities into
+// communities summarized by the model. The result is loaded with
+// knowledge.BuiltinGraphKnowledge.LoadGraphSource.
+package entitygraph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const (
+	defaultConcurrency      = 4
+	defaultMinCommunitySize = 2
+)
+
+var defaultEntityTypes = []string{"person", "organization", "location", "event", "concept", "product"}
+
+// Source builds an entity graph from the documents of other sources.
+type Source struct {
+	sources          []source.Source
+	model            model.Model
+	name             string
+	entityTypes      []string
+	extractionPrompt string
+	communityPrompt  string
+	concurrency      int
+	chunkNodes       bool
+	communities      bool
+	minCommunitySize int
+}
+
+// New creates an entity graph source that extracts entities and relations
+// from the documents of sources using m.
+func New(m model.Model, sources []source.Source, opts ...Option) *Source {
+	s := &Source{
+		sources:          sources,
+		model:            m,
+		name:             "Entity Graph",
+		entityTypes:      defaultEntityTypes,
+		concurrency:      defaultConcurrency,
+		chunkNodes:       true,
+		communities:      true,
+		minCommunitySize: defaultMinCommunitySize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name returns the name of this source.
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Type returns the type of this source.
+func (s *Source) Type() string {
+	return source.TypeEntityGraph
+}
+
+// ReadGraph reads the documents of all wrapped sources and extracts an entity
+// graph from them.
+func (s *Source) ReadGraph(ctx context.Context, opts ...source.ReadGraphOption) (*graph.Data, error) {
+	var docs []*document.Document
+	for _, src := range s.sources {
+		if src == nil {
+			continue
+		}
+		srcDocs, err := src.ReadDocuments(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("read documents from source %s: %w", src.Name(), err)
+		}
+		docs = append(docs, srcDocs...)
+	}
+	concurrency := source.ReadGraphParseConcurrency(opts)
+	if concurrency <= 0 {
+		concurrency = s.concurrency
+	}
+	return s.extract(ctx, docs, concurrency)
+}
+
+// Extract builds an entity graph from already chunked documents. The document
+// ID is used as the chunk ID; documents without an ID get a stable ID derived
+// from their source, URI, chunk index and content.
+func (s *Source) Extract(ctx context.Context, docs []*document.Document) (*graph.Data, error) {
+	return s.extract(ctx, docs, s.concurrency)
+}
+
+func (s *Source) extract(ctx context.Context, docs []*document.Document, concurrency int) (*graph.Data, error) {
+	if s.model == nil {
+		return nil, errors.New("entity graph model is not configured")
+	}
+	chunks := make([]*document.Document, 0, len(docs))
+	seen := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		if doc == nil || strings.TrimSpace(doc.Content) == "" {
+			continue
+		}
+		id := doc.ID
+		if id == "" {
+			id = chunkID(doc)
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		chunk := *doc
+		chunk.ID = id
+		chunks = append(chunks, &chunk)
+	}
+
+	results := make([]*extraction, len(chunks))
+	err := runParallel(ctx, len(chunks), concurrency, func(i int) error {
+		result, err := s.extractChunk(ctx, chunks[i])
+		if err != nil {
+			return err
+		}
+		results[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBuilder()
+	for i, chunk := range chunks {
+		b.addExtraction(chunk.ID, results[i])
+	}
+	data := b.graphData()
+	if s.chunkNodes {
+		b.addChunkNodes(data, chunks)
+	}
+	if s.communities {
+		communityData, err := s.buildCommunities(ctx, b, concurrency)
+		if err != nil {
+			return nil, err
+		}
+		data.Nodes = append(data.Nodes, communityData.Nodes...)
+		data.Edges = append(data.Edges, communityData.Edges...)
+	}
+	return data, nil
+}
+
+// extractChunk asks the model for the entities and relations of one chunk.
+// Output that cannot be parsed is logged and yields no entities, so one bad
+// answer does not fail the whole graph.
+func (s *Source) extractChunk(ctx context.Context, chunk *document.Document) (*extraction, error) {
+	output, err := generate(ctx, s.model, s.systemPrompt(), chunk.Content)
+	if err != nil {
+		return nil, fmt.Errorf("extract entities from chunk %s: %w", chunk.ID, err)
+	}
+	result, err := parseExtraction(output)
+	if err != nil {
+		log.WarnfContext(ctx, "entitygraph: skip chunk %s: %v", chunk.ID, err)
+		return nil, nil
+	}
+	return result, nil
+}
+
+func chunkID(doc *document.Document) string {
+	var sourceName, uri string
+	var chunkIndex any
+	if doc.Metadata != nil {
+		sourceName, _ = doc.Metadata[source.MetaSourceName].(string)
+		uri, _ = doc.Metadata[source.MetaURI].(string)
+		chunkIndex = doc.Metadata[source.MetaChunkIndex]
+	}
+	if uri == "" {
+		uri = doc.Name
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%v\x00%s", sourceName, uri, chunkIndex, doc.Content)
+	return "chunk:" + hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// runParallel calls fn for every index in [0, n) with at most concurrency
+// calls in flight and returns the first error.
+func runParallel(ctx context.Context, n, concurrency int, fn func(i int) error) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,255 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package entitygraph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// stubModel answers extraction requests by chunk content and community
+// requests with a fixed summary.
+type stubModel struct {
+	mu        sync.Mutex
+	responses map[string]string
+	err       error
+	apiErr    *model.ResponseError
+	calls     []*model.Request
+}
+
+func (s *stubModel) GenerateContent(_ context.Context, req *model.Request) (<-chan *model.Response, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, req)
+	s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	user := req.Messages[len(req.Messages)-1].Content
+	content := "Summary: " + strings.SplitN(strings.TrimPrefix(user, "Entities:\n- "), "\n", 2)[0]
+	if strings.Contains(req.Messages[0].Content, "knowledge graph from a text passage") {
+		content = s.responses[user]
+	}
+	ch := make(chan *model.Response, 1)
+	ch <- &model.Response{
+		Error:   s.apiErr,
+		Choices: []model.Choice{{Message: model.NewAssistantMessage(content)}},
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (s *stubModel) Info() model.Info {
+	return model.Info{Name: "stub"}
+}
+
+type stubSource struct {
+	docs []*document.Document
+	err  error
+}
+
+func (s *stubSource) ReadDocuments(context.Context) ([]*document.Document, error) {
+	return s.docs, s.err
+}
+func (s *stubSource) Name() string                { return "stub" }
+func (s *stubSource) Type() string                { return source.TypeFile }
+func (s *stubSource) GetMetadata() map[string]any { return nil }
+
+const (
+	chunkAda     = "Ada Lovelace worked with Charles Babbage on the Analytical Engine."
+	chunkEngine  = "The Analytical Engine was designed by Babbage in London."
+	chunkUnknown = "Nothing to see here."
+)
+
+func newStubModel() *stubModel {
+	return &stubModel{responses: map[string]string{
+		chunkAda: "```json\n" + `{"entities":[
+{"name":"Ada Lovelace","type":"Person","description":"A mathematician."},
+{"name":"Charles Babbage","type":"person","description":"An inventor."},
+{"name":"Analytical Engine","type":"product","description":"A mechanical computer."}],
+"relations":[
+{"source":"Ada Lovelace","target":"Charles Babbage","type":"worked with","description":"Collaborated."},
+{"source":"Ada Lovelace","target":"Analytical Engine","type":"WORKED_ON"},
+{"source":"Ada Lovelace","target":"ada lovelace","type":"IS"}]}` + "\n```",
+		chunkEngine: `Here you go: {"entities":[
+{"name":"the Analytical Engine","type":"product","description":"Designed in London."},
+{"name":"Charles Babbage","type":"person"},
+{"name":"London","type":"location"}],
+"relations":[
+{"source":"Charles Babbage","target":"The Analytical Engine.","type":"designed","description":"Designed it."},
+{"source":"Analytical Engine","target":"London","type":""}]}`,
+		chunkUnknown: "I could not find any entities.",
+	}}
+}
+
+func nodeByID(data *graph.Data, id string) *graph.Node {
+	for _, node := range data.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+func edgesOfType(data *graph.Data, edgeType string) []*graph.Edge {
+	var edges []*graph.Edge
+	for _, edge := range data.Edges {
+		if edge.Type == edgeType {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func TestExtract_ResolvesEntitiesAcrossChunks(t *testing.T) {
+	m := newStubModel()
+	s := New(m, nil, WithCommunitySummaries(false))
+	data, err := s.Extract(context.Background(), []*document.Document{
+		{ID: "c1", Name: "ada", Content: chunkAda},
+		{ID: "c2", Name: "engine", Content: chunkEngine, Metadata: map[string]any{"topic": "history"}},
+		{ID: "c3", Content: chunkUnknown},
+		{ID: "c4", Content: "   "},
+	})
+	require.NoError(t, err)
+
+	engine := nodeByID(data, "entity:analytical_engine")
+	require.NotNil(t, engine)
+	assert.Equal(t, "Analytical Engine", engine.Name)
+	assert.Equal(t, "A mechanical computer.\nDesigned in London.", engine.Content)
+	assert.Equal(t, graph.NodeKindEntity, engine.Metadata[graph.MetaNodeKind])
+	assert.Equal(t, "product", engine.Metadata[graph.MetaEntityType])
+	assert.Equal(t, []string{"Analytical Engine", "the Analytical Engine", "The Analytical Engine."}, engine.Metadata[graph.MetaAliases])
+	assert.Equal(t, []string{"c1", "c2"}, engine.Metadata[graph.MetaChunkIDs])
+
+	ada := nodeByID(data, "entity:ada_lovelace")
+	require.NotNil(t, ada)
+	assert.Equal(t, "person", ada.Metadata[graph.MetaEntityType])
+	assert.Equal(t, []string{"c1"}, ada.Metadata[graph.MetaChunkIDs])
+
+	relations := map[string]*graph.Edge{}
+	for _, edge := range data.Edges {
+		if edge.Type != graph.EdgeTypeMentions {
+			relations[edge.FromID+" "+edge.Type+" "+edge.ToID] = edge
+		}
+	}
+	assert.Len(t, relations, 4, "self relations are dropped")
+	worked := relations["entity:ada_lovelace WORKED_WITH entity:charles_babbage"]
+	require.NotNil(t, worked)
+	assert.Equal(t, 1, worked.Metadata[graph.MetaWeight])
+	assert.Equal(t, "Collaborated.", worked.Metadata[graph.MetaDescription])
+	designed := relations["entity:charles_babbage DESIGNED entity:analytical_engine"]
+	require.NotNil(t, designed)
+	assert.Equal(t, []string{"c2"}, designed.Metadata[graph.MetaChunkIDs])
+	assert.NotNil(t, relations["entity:analytical_engine RELATED_TO entity:london"])
+
+	chunk := nodeByID(data, "c2")
+	require.NotNil(t, chunk)
+	assert.Equal(t, graph.NodeKindChunk, chunk.Metadata[graph.MetaNodeKind])
+	assert.Equal(t, "history", chunk.Metadata["topic"])
+	assert.Nil(t, nodeByID(data, "c3"), "chunks without entities have no node")
+	assert.Len(t, edgesOfType(data, graph.EdgeTypeMentions), 6)
+	assert.Len(t, m.calls, 3)
+	assert.Contains(t, m.calls[0].Messages[0].Content, "person, organization, location")
+}
+
+func TestReadGraph_Communities(t *testing.T) {
+	m := newStubModel()
+	src := &stubSource{docs: []*document.Document{
+		{Name: "ada", Content: chunkAda, Metadata: map[string]any{source.MetaURI: "ada.txt", source.MetaChunkIndex: 0}},
+		{Name: "engine", Content: chunkEngine, Metadata: map[string]any{source.MetaURI: "engine.txt", source.MetaChunkIndex: 0}},
+	}}
+	s := New(m, []source.Source{src}, WithName("history"), WithChunkNodes(false))
+	assert.Equal(t, "history", s.Name())
+	assert.Equal(t, source.TypeEntityGraph, s.Type())
+
+	data, err := s.ReadGraph(context.Background(), source.WithReadGraphParseConcurrency(1))
+	require.NoError(t, err)
+	assert.Empty(t, edgesOfType(data, graph.EdgeTypeMentions))
+
+	community := nodeByID(data, "community:1")
+	require.NotNil(t, community)
+	assert.Nil(t, nodeByID(data, "community:2"), "all entities are connected")
+	assert.Equal(t, graph.NodeKindCommunity, community.Metadata[graph.MetaNodeKind])
+	assert.Equal(t, []string{"entity:ada_lovelace", "entity:analytical_engine", "entity:charles_babbage", "entity:london"},
+		community.Metadata[graph.MetaMemberIDs])
+	assert.True(t, strings.HasPrefix(community.Name, "Community 1: Analytical Engine, "), community.Name)
+	assert.Equal(t, "Summary: Ada Lovelace (person): A mathematician.", community.Content)
+	assert.Len(t, edgesOfType(data, graph.EdgeTypeInCommunity), 4)
+
+	for _, node := range data.Nodes {
+		for _, id := range toStrings(node.Metadata[graph.MetaChunkIDs]) {
+			assert.True(t, strings.HasPrefix(id, "chunk:"), id)
+		}
+	}
+
+	again, err := s.ReadGraph(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, data, again, "extraction is deterministic")
+}
+
+func toStrings(v any) []string {
+	s, _ := v.([]string)
+	return s
+}
+
+func TestDetectCommunities_MinSize(t *testing.T) {
+	b := newBuilder()
+	b.addExtraction("c1", &extraction{Relations: []extractedRelation{
+		{Source: "A", Target: "B"}, {Source: "B", Target: "C"}, {Source: "C", Target: "A"},
+		{Source: "X", Target: "Y"},
+	}})
+	b.resolve("Loner", "c1")
+
+	communities := b.detectCommunities(2)
+	require.Len(t, communities, 2)
+	assert.Len(t, communities[0].members, 3)
+	assert.Len(t, communities[1].members, 2)
+	assert.Len(t, b.detectCommunities(3), 1)
+}
+
+func TestExtract_Errors(t *testing.T) {
+	docs := []*document.Document{{ID: "c1", Content: chunkAda}}
+
+	_, err := New(nil, nil).Extract(context.Background(), docs)
+	assert.ErrorContains(t, err, "model is not configured")
+
+	_, err = New(&stubModel{err: errors.New("boom")}, nil).Extract(context.Background(), docs)
+	assert.ErrorContains(t, err, "extract entities from chunk c1: boom")
+
+	_, err = New(&stubModel{apiErr: &model.ResponseError{Message: "quota"}}, nil).Extract(context.Background(), docs)
+	assert.ErrorContains(t, err, "model error: quota")
+
+	_, err = New(newStubModel(), []source.Source{&stubSource{err: errors.New("offline")}}).ReadGraph(context.Background())
+	assert.ErrorContains(t, err, "read documents from source stub: offline")
+}
+
+func TestNormalization(t *testing.T) {
+	assert.Equal(t, "openai", entityKey("  OpenAI, "))
+	assert.Equal(t, "united nations", entityKey("The  United Nations"))
+	assert.Equal(t, "the", entityKey("The"))
+	assert.Equal(t, "c++", entityKey("C++"))
+	assert.Equal(t, "WORKS_FOR", normalizeRelationType("works for"))
+	assert.Equal(t, "PART_OF", normalizeRelationType(" part-of "))
+	assert.Equal(t, "RELATED_TO", normalizeRelationType("--"))
+
+	s := New(nil, nil, WithEntityTypes(), WithExtractionPrompt("custom"))
+	assert.Equal(t, "custom", s.systemPrompt())
+	s = New(nil, nil, WithEntityTypes())
+	assert.NotContains(t, s.systemPrompt(), "Entity types must be")
+}
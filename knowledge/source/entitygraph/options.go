@@ -0,0 +1,83 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package entitygraph
+
+// Option represents a functional option for configuring Source.
+type Option func(*Source)
+
+// WithName sets a custom name for the entity graph source.
+func WithName(name string) Option {
+	return func(s *Source) {
+		s.name = name
+	}
+}
+
+// WithEntityTypes sets the entity types the model is asked to extract.
+// An empty list lets the model choose types freely.
+func WithEntityTypes(types ...string) Option {
+	return func(s *Source) {
+		s.entityTypes = types
+	}
+}
+
+// WithExtractionPrompt overrides the system prompt used to extract entities
+// and relations from a chunk. The prompt must ask for the JSON format
+// described in DefaultExtractionPrompt.
+func WithExtractionPrompt(prompt string) Option {
+	return func(s *Source) {
+		s.extractionPrompt = prompt
+	}
+}
+
+// WithCommunityPrompt overrides the system prompt used to summarize a
+// community of related entities.
+func WithCommunityPrompt(prompt string) Option {
+	return func(s *Source) {
+		s.communityPrompt = prompt
+	}
+}
+
+// WithConcurrency sets how many chunks are extracted in parallel.
+// source.WithReadGraphParseConcurrency takes precedence when set.
+func WithConcurrency(n int) Option {
+	return func(s *Source) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
+
+// WithChunkNodes controls whether chunks are kept as graph nodes linked to the
+// entities they mention. Chunk nodes provide provenance for retrieval and are
+// enabled by default.
+func WithChunkNodes(enabled bool) Option {
+	return func(s *Source) {
+		s.chunkNodes = enabled
+	}
+}
+
+// WithCommunitySummaries controls whether entities are grouped into
+// communities summarized by the model. Community summaries answer global
+// questions and are enabled by default.
+func WithCommunitySummaries(enabled bool) Option {
+	return func(s *Source) {
+		s.communities = enabled
+	}
+}
+
+// WithMinCommunitySize sets the minimum number of entities a community needs
+// to be summarized. The default is 2.
+func WithMinCommunitySize(n int) Option {
+	return func(s *Source) {
+		if n > 0 {
+			s.minCommunitySize = n
+		}
+	}
+}
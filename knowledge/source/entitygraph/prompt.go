@@ -0,0 +1,121 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package entitygraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// DefaultExtractionPrompt is the system prompt used to extract entities and
+// relations from a chunk. The %s placeholder receives the allowed entity types.
+const DefaultExtractionPrompt = `You extract a knowledge graph from a text passage.
+
+Identify the named entities in the passage and the relations between them.
+%s
+Rules:
+- Use the entity name exactly as it appears in the passage.
+- Describe each entity and relation in one short sentence based only on the passage.
+- Relation types are short verbs or verb phrases in UPPER_SNAKE_CASE, e.g. WORKS_FOR.
+- Only report relations between entities you listed.
+
+Respond with JSON only, in this format:
+{"entities":[{"name":"...","type":"...","description":"..."}],"relations":[{"source":"...","target":"...","type":"...","description":"..."}]}`
+
+// DefaultCommunityPrompt is the system prompt used to summarize a community.
+const DefaultCommunityPrompt = `You summarize a group of related entities from a knowledge graph.
+
+Write a short report (at most one paragraph) describing what the group is about,
+its most important entities and how they relate. Use only the information given.
+Output the report only.`
+
+// maxCommunityPromptRunes bounds the entity and relation listing sent to the
+// model when summarizing a community.
+const maxCommunityPromptRunes = 8 * 1024
+
+type extraction struct {
+	Entities  []extractedEntity   `json:"entities"`
+	Relations []extractedRelation `json:"relations"`
+}
+
+type extractedEntity struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type extractedRelation struct {
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+func (s *Source) systemPrompt() string {
+	prompt := s.extractionPrompt
+	if prompt == "" {
+		prompt = DefaultExtractionPrompt
+	}
+	if !strings.Contains(prompt, "%s") {
+		return prompt
+	}
+	var types string
+	if len(s.entityTypes) > 0 {
+		types = "Entity types must be one of: " + strings.Join(s.entityTypes, ", ") + ".\n"
+	}
+	return fmt.Sprintf(prompt, types)
+}
+
+// parseExtraction decodes the model output, tolerating code fences and text
+// around the JSON object.
+func parseExtraction(output string) (*extraction, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in model output")
+	}
+	var result extraction
+	if err := json.Unmarshal([]byte(output[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("decode model output: %w", err)
+	}
+	return &result, nil
+}
+
+// generate runs a single-turn completion and returns the concatenated text.
+func generate(ctx context.Context, m model.Model, system, user string) (string, error) {
+	ch, err := m.GenerateContent(ctx, &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage(system),
+			model.NewUserMessage(user),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	for resp := range ch {
+		if resp.Error != nil {
+			return "", fmt.Errorf("model error: %s", resp.Error.Message)
+		}
+		for _, choice := range resp.Choices {
+			if choice.Message.Content != "" {
+				result.WriteString(choice.Message.Content)
+			}
+			if choice.Delta.Content != "" {
+				result.WriteString(choice.Delta.Content)
+			}
+		}
+	}
+	return strings.TrimSpace(result.String()), nil
+}
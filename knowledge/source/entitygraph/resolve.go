@@ -0,0 +1,282 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package entitygraph
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/graph"
+)
+
+const defaultRelationType = "RELATED_TO"
+
+// entity accumulates every mention of one resolved entity.
+type entity struct {
+	id           string
+	names        map[string]int
+	nameOrder    []string
+	types        map[string]int
+	descriptions orderedSet
+	chunkIDs     orderedSet
+}
+
+// name returns the most frequent surface form, preferring the first seen.
+func (e *entity) name() string {
+	return mostFrequent(e.names, e.nameOrder)
+}
+
+func (e *entity) entityType() string {
+	order := make([]string, 0, len(e.types))
+	for t := range e.types {
+		order = append(order, t)
+	}
+	sort.Strings(order)
+	return mostFrequent(e.types, order)
+}
+
+// relation accumulates every statement of one (source, type, target) triple.
+type relation struct {
+	fromID       string
+	toID         string
+	relType      string
+	weight       int
+	descriptions orderedSet
+	chunkIDs     orderedSet
+}
+
+// builder resolves extracted entities and relations across chunks.
+type builder struct {
+	entities     map[string]*entity
+	entityOrder  []string
+	relations    map[string]*relation
+	relOrder     []string
+	chunkMention map[string]*orderedSet
+}
+
+func newBuilder() *builder {
+	return &builder{
+		entities:     make(map[string]*entity),
+		relations:    make(map[string]*relation),
+		chunkMention: make(map[string]*orderedSet),
+	}
+}
+
+func (b *builder) addExtraction(chunkID string, result *extraction) {
+	if result == nil {
+		return
+	}
+	for _, extracted := range result.Entities {
+		e := b.resolve(extracted.Name, chunkID)
+		if e == nil {
+			continue
+		}
+		if t := normalizeEntityType(extracted.Type); t != "" {
+			e.types[t]++
+		}
+		e.descriptions.add(strings.TrimSpace(extracted.Description))
+	}
+	for _, extracted := range result.Relations {
+		from := b.resolve(extracted.Source, chunkID)
+		to := b.resolve(extracted.Target, chunkID)
+		if from == nil || to == nil || from == to {
+			continue
+		}
+		relType := normalizeRelationType(extracted.Type)
+		key := from.id + "\x00" + relType + "\x00" + to.id
+		r, ok := b.relations[key]
+		if !ok {
+			r = &relation{fromID: from.id, toID: to.id, relType: relType}
+			b.relations[key] = r
+			b.relOrder = append(b.relOrder, key)
+		}
+		if r.chunkIDs.add(chunkID) {
+			r.weight++
+		}
+		r.descriptions.add(strings.TrimSpace(extracted.Description))
+	}
+}
+
+// resolve returns the entity a surface form refers to, creating it on first
+// mention, and records the chunk as provenance.
+func (b *builder) resolve(name, chunkID string) *entity {
+	name = strings.Join(strings.Fields(name), " ")
+	key := entityKey(name)
+	if key == "" {
+		return nil
+	}
+	e, ok := b.entities[key]
+	if !ok {
+		e = &entity{
+			id:    "entity:" + strings.ReplaceAll(key, " ", "_"),
+			names: make(map[string]int),
+			types: make(map[string]int),
+		}
+		b.entities[key] = e
+		b.entityOrder = append(b.entityOrder, key)
+	}
+	if _, ok := e.names[name]; !ok {
+		e.nameOrder = append(e.nameOrder, name)
+	}
+	e.names[name]++
+	e.chunkIDs.add(chunkID)
+	mentions, ok := b.chunkMention[chunkID]
+	if !ok {
+		mentions = &orderedSet{}
+		b.chunkMention[chunkID] = mentions
+	}
+	mentions.add(e.id)
+	return e
+}
+
+func (b *builder) graphData() *graph.Data {
+	data := &graph.Data{}
+	for _, key := range b.entityOrder {
+		e := b.entities[key]
+		metadata := map[string]any{
+			graph.MetaNodeKind: graph.NodeKindEntity,
+			graph.MetaAliases:  append([]string(nil), e.nameOrder...),
+			graph.MetaChunkIDs: e.chunkIDs.values(),
+		}
+		if t := e.entityType(); t != "" {
+			metadata[graph.MetaEntityType] = t
+		}
+		data.Nodes = append(data.Nodes, &graph.Node{
+			ID:       e.id,
+			Name:     e.name(),
+			Content:  strings.Join(e.descriptions.values(), "\n"),
+			Metadata: metadata,
+		})
+	}
+	for _, key := range b.relOrder {
+		r := b.relations[key]
+		metadata := map[string]any{
+			graph.MetaWeight:   r.weight,
+			graph.MetaChunkIDs: r.chunkIDs.values(),
+		}
+		if descriptions := r.descriptions.values(); len(descriptions) > 0 {
+			metadata[graph.MetaDescription] = strings.Join(descriptions, "\n")
+		}
+		data.Edges = append(data.Edges, &graph.Edge{
+			ID:       "relation:" + r.fromID + "|" + r.relType + "|" + r.toID,
+			FromID:   r.fromID,
+			ToID:     r.toID,
+			Type:     r.relType,
+			Metadata: metadata,
+		})
+	}
+	return data
+}
+
+// addChunkNodes adds a node per chunk that mentions an entity and links it to
+// the mentioned entities.
+func (b *builder) addChunkNodes(data *graph.Data, chunks []*document.Document) {
+	for _, chunk := range chunks {
+		mentions, ok := b.chunkMention[chunk.ID]
+		if !ok {
+			continue
+		}
+		metadata := make(map[string]any, len(chunk.Metadata)+1)
+		for k, v := range chunk.Metadata {
+			metadata[k] = v
+		}
+		metadata[graph.MetaNodeKind] = graph.NodeKindChunk
+		data.Nodes = append(data.Nodes, &graph.Node{
+			ID:       chunk.ID,
+			Name:     chunk.Name,
+			Content:  chunk.Content,
+			Metadata: metadata,
+		})
+		for _, entityID := range mentions.values() {
+			data.Edges = append(data.Edges, &graph.Edge{
+				ID:     "mentions:" + chunk.ID + "|" + entityID,
+				FromID: chunk.ID,
+				ToID:   entityID,
+				Type:   graph.EdgeTypeMentions,
+			})
+		}
+	}
+}
+
+// entityKey normalizes a surface form so that case, spacing, surrounding
+// punctuation and a leading article do not split one entity into several.
+func entityKey(name string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	key = strings.TrimFunc(key, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`.,;:!?"'`+"`"+`()[]{}`, r)
+	})
+	if rest := strings.TrimPrefix(key, "the "); rest != "" {
+		key = rest
+	}
+	return key
+}
+
+func normalizeEntityType(t string) string {
+	return strings.ToLower(strings.Join(strings.Fields(t), " "))
+}
+
+// normalizeRelationType converts a relation type to UPPER_SNAKE_CASE.
+func normalizeRelationType(t string) string {
+	var b strings.Builder
+	pendingSep := false
+	for _, r := range t {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingSep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSep = false
+			b.WriteRune(unicode.ToUpper(r))
+			continue
+		}
+		pendingSep = true
+	}
+	if b.Len() == 0 {
+		return defaultRelationType
+	}
+	return b.String()
+}
+
+func mostFrequent(counts map[string]int, order []string) string {
+	var best string
+	bestCount := 0
+	for _, v := range order {
+		if counts[v] > bestCount {
+			best, bestCount = v, counts[v]
+		}
+	}
+	return best
+}
+
+// orderedSet keeps unique non-empty strings in insertion order.
+type orderedSet struct {
+	items []string
+	index map[string]struct{}
+}
+
+func (s *orderedSet) add(v string) bool {
+	if v == "" {
+		return false
+	}
+	if s.index == nil {
+		s.index = make(map[string]struct{})
+	}
+	if _, ok := s.index[v]; ok {
+		return false
+	}
+	s.index[v] = struct{}{}
+	s.items = append(s.items, v)
+	return true
+}
+
+func (s *orderedSet) values() []string {
+	return append([]string(nil), s.items...)
+}
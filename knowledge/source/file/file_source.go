@@ -12,6 +12,7 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -102,6 +103,57 @@ func (s *Source) ReadDocuments(ctx context.Context) ([]*document.Document, error
 	return allDocuments, nil
 }
 
+// WatchPaths returns the files of this source, including the ones that do
+// not exist yet.
+func (s *Source) WatchPaths(ctx context.Context) ([]string, error) {
+	var paths []string
+	for _, filePath := range s.filePaths {
+		if filePath != "" {
+			paths = append(paths, filePath)
+		}
+	}
+	return paths, nil
+}
+
+// ListFiles returns the configured files that currently exist.
+func (s *Source) ListFiles(ctx context.Context) ([]string, error) {
+	var filePaths []string
+	for _, filePath := range s.filePaths {
+		if filePath == "" {
+			continue
+		}
+		if _, err := os.Stat(filePath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to stat file %s: %w", filePath, err)
+		}
+		filePaths = append(filePaths, filePath)
+	}
+	return filePaths, nil
+}
+
+// ReadFiles reads the documents of the given files. It processes every file
+// and returns the errors of all files that fail to process.
+func (s *Source) ReadFiles(ctx context.Context, filePaths []string) ([]*document.Document, error) {
+	var (
+		allDocuments []*document.Document
+		errs         []error
+	)
+	for _, filePath := range filePaths {
+		documents, err := s.processFile(ctx, filePath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to process file %s: %w", filePath, err))
+			continue
+		}
+		allDocuments = append(allDocuments, documents...)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return allDocuments, nil
+}
+
 // Name returns the name of this source.
 func (s *Source) Name() string {
 	return s.name
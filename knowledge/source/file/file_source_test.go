@@ -287,6 +287,47 @@ func TestProcessFile_Unsupported(t *testing.T) {
 	}
 }
 
+func TestListAndReadFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(existing, []byte("alpha"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "later.txt")
+
+	src := New([]string{existing, "", missing})
+	roots, err := src.WatchPaths(ctx)
+	if err != nil || len(roots) != 2 {
+		t.Fatalf("WatchPaths() = %v, %v; want both configured files", roots, err)
+	}
+	files, err := src.ListFiles(ctx)
+	if err != nil || len(files) != 1 || files[0] != existing {
+		t.Fatalf("ListFiles() = %v, %v; want only the existing file", files, err)
+	}
+
+	docs, err := src.ReadFiles(ctx, files)
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("ReadFiles() = %d docs, %v", len(docs), err)
+	}
+	if _, err := src.ReadFiles(ctx, []string{missing}); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+
+	// Every file is processed, and the errors of all failing files are
+	// reported together.
+	other := filepath.Join(dir, "other.txt")
+	docs, err = src.ReadFiles(ctx, []string{missing, existing, other})
+	if err == nil || docs != nil {
+		t.Fatalf("ReadFiles() = %d docs, %v; want an error and no docs", len(docs), err)
+	}
+	for _, path := range []string{missing, other} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("error %q does not mention %s", err, path)
+		}
+	}
+}
+
 // TestNameAndType verifies Name() and Type() methods.
 func TestNameAndType(t *testing.T) {
 	tests := []struct {
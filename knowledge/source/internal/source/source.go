@@ -22,10 +22,14 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/transform"
 
 	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/csv"
+	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/epub"
+	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/html"
 	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/json"
 	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/markdown"
+	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/pptx"
 	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/proto"
 	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/text"
+	_ "trpc.group/trpc-go/trpc-agent-go/knowledge/document/reader/xlsx"
 )
 
 var (
@@ -136,6 +140,14 @@ func GetFileType(filePath string) string {
 		return "csv"
 	case ".docx", ".doc":
 		return "docx"
+	case ".html", ".htm":
+		return "html"
+	case ".xlsx":
+		return "xlsx"
+	case ".pptx":
+		return "pptx"
+	case ".epub":
+		return "epub"
 	case ".proto":
 		return "proto"
 	case ".go":
@@ -155,8 +167,8 @@ func GetFileTypeFromContentType(contentType, fileName string) string {
 		mainType := strings.TrimSpace(parts[0])
 
 		switch {
-		case strings.Contains(mainType, "text/html"):
-			return "text"
+		case strings.Contains(mainType, "text/html"), strings.Contains(mainType, "application/xhtml+xml"):
+			return "html"
 		case strings.Contains(mainType, "text/plain"):
 			return "text"
 		case strings.Contains(mainType, "application/json"):
@@ -169,13 +181,19 @@ func GetFileTypeFromContentType(contentType, fileName string) string {
 			return getGoFileType()
 		case strings.Contains(mainType, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"):
 			return "docx"
+		case strings.Contains(mainType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"):
+			return "xlsx"
+		case strings.Contains(mainType, "application/vnd.openxmlformats-officedocument.presentationml.presentation"):
+			return "pptx"
+		case strings.Contains(mainType, "application/epub+zip"):
+			return "epub"
 		}
 	}
 
 	// Fall back to file extension.
 	ext := filepath.Ext(fileName)
 	switch ext {
-	case ".txt", ".text", ".html", ".htm":
+	case ".txt", ".text":
 		return "text"
 	case ".pdf":
 		return "pdf"
@@ -187,6 +205,14 @@ func GetFileTypeFromContentType(contentType, fileName string) string {
 		return "csv"
 	case ".docx", ".doc":
 		return "docx"
+	case ".html", ".htm":
+		return "html"
+	case ".xlsx":
+		return "xlsx"
+	case ".pptx":
+		return "pptx"
+	case ".epub":
+		return "epub"
 	case ".proto":
 		return "proto"
 	case ".go":
@@ -159,8 +159,11 @@ func TestGetFileTypeAllExtensions(t *testing.T) {
 	}{
 		{".txt", "text"},
 		{".text", "text"},
-		{".html", "text"},
-		{".htm", "text"},
+		{".html", "html"},
+		{".htm", "html"},
+		{".xlsx", "xlsx"},
+		{".pptx", "pptx"},
+		{".epub", "epub"},
 		{".pdf", "pdf"},
 		{".md", "markdown"},
 		{".markdown", "markdown"},
@@ -189,7 +192,11 @@ func TestGetFileTypeFromContentType(t *testing.T) {
 		want        string
 	}{
 		// Content type based detection
-		{"text/html; charset=utf-8", "", "text"},
+		{"text/html; charset=utf-8", "", "html"},
+		{"application/xhtml+xml", "", "html"},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "", "xlsx"},
+		{"application/vnd.openxmlformats-officedocument.presentationml.presentation", "", "pptx"},
+		{"application/epub+zip", "", "epub"},
 		{"text/plain", "", "text"},
 		{"text/plain; charset=utf-8", "", "text"},
 		{"application/json", "", "json"},
@@ -205,8 +212,11 @@ func TestGetFileTypeFromContentType(t *testing.T) {
 		{"", "file.markdown", "markdown"},
 		{"", "file.txt", "text"},
 		{"", "file.text", "text"},
-		{"", "file.html", "text"},
-		{"", "file.htm", "text"},
+		{"", "file.html", "html"},
+		{"", "file.htm", "html"},
+		{"", "file.xlsx", "xlsx"},
+		{"", "file.pptx", "pptx"},
+		{"", "file.epub", "epub"},
 		{"", "file.json", "json"},
 		{"", "file.csv", "csv"},
 		{"", "file.pdf", "pdf"},
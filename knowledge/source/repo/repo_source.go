@@ -12,6 +12,7 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"net/url"
@@ -102,7 +103,73 @@ func (s *Source) ReadDocuments(ctx context.Context) ([]*document.Document, error
 	if err != nil {
 		return nil, err
 	}
+	return s.readFiles(rootToScan, repoRoot, repoInfo, filePaths)
+}
+
+// WatchPaths returns the scanned directory of a local repository. Remote
+// repositories are cloned into a temporary directory and cannot be watched.
+func (s *Source) WatchPaths(ctx context.Context) ([]string, error) {
+	_, rootToScan, err := s.resolveLocalScanRoot()
+	if err != nil {
+		return nil, err
+	}
+	return []string{rootToScan}, nil
+}
+
+// ListFiles returns the files currently found in a local repository.
+func (s *Source) ListFiles(ctx context.Context) ([]string, error) {
+	_, rootToScan, err := s.resolveLocalScanRoot()
+	if err != nil {
+		return nil, err
+	}
+	return s.getFilePaths(rootToScan)
+}
+
+// ReadFiles reads the documents of the given files of a local repository.
+// Files handled by directory-level parsers are parsed together with the rest
+// of the scanned tree so that cross-file references still resolve, but only
+// the documents of the given files are returned.
+func (s *Source) ReadFiles(ctx context.Context, filePaths []string) ([]*document.Document, error) {
+	if len(filePaths) == 0 {
+		return nil, nil
+	}
+	_, rootToScan, err := s.resolveLocalScanRoot()
+	if err != nil {
+		return nil, err
+	}
+	repoRoot, repoInfo, _, err := s.resolveRepository(ctx, s.repository)
+	if err != nil {
+		return nil, err
+	}
+	return s.readFiles(rootToScan, repoRoot, repoInfo, filePaths)
+}
+
+// errRemoteRepositoryFiles is returned when per-file access is requested for a
+// repository that is cloned from a URL.
+var errRemoteRepositoryFiles = errors.New("per-file access requires a local repository directory")
+
+// resolveLocalScanRoot resolves the repository root and scan root of a local
+// repository without touching the network.
+func (s *Source) resolveLocalScanRoot() (string, string, error) {
+	if s.repository.URL != "" {
+		return "", "", errRemoteRepositoryFiles
+	}
+	if s.repository.Dir == "" {
+		return "", "", fmt.Errorf("repository must set either URL or Dir")
+	}
+	repoRoot, err := filepath.Abs(s.repository.Dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	rootToScan, err := resolveScanRoot(repoRoot, s.repository.Subdir)
+	if err != nil {
+		return "", "", err
+	}
+	return repoRoot, rootToScan, nil
+}
 
+// readFiles reads the given files in processing priority order.
+func (s *Source) readFiles(rootToScan, repoRoot string, repoInfo *repoInfo, filePaths []string) ([]*document.Document, error) {
 	fc, err := s.classifyFiles(repoRoot, filePaths)
 	if err != nil {
 		return nil, err
@@ -372,6 +372,62 @@ func (s *Service) Do() error { return nil }
 	assertEqual(t, methodCount, 1)
 }
 
+func TestListAndReadFilesOfLocalRepo(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeRepoFile(t, filepath.Join(repoRoot, "go.mod"), "module example.com/demo\n\ngo 1.21\n")
+	writeRepoFile(t, filepath.Join(repoRoot, "service.go"), "package demo\n\ntype Service struct{}\n")
+	writeRepoFile(t, filepath.Join(repoRoot, "method.go"), "package demo\n\nfunc (s *Service) Do() error { return nil }\n")
+	writeRepoFile(t, filepath.Join(repoRoot, "README.md"), "# Demo\n\nA demo repository.\n")
+	writeRepoFile(t, filepath.Join(repoRoot, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	src := New(WithRepository(Repository{Dir: repoRoot}))
+	ctx := context.Background()
+	roots, err := src.WatchPaths(ctx)
+	if err != nil {
+		t.Fatalf("WatchPaths() error = %v", err)
+	}
+	assertEqual(t, len(roots), 1)
+	assertEqual(t, roots[0], repoRoot)
+
+	files, err := src.ListFiles(ctx)
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	assertEqual(t, len(files), 4)
+
+	docs, err := src.ReadFiles(ctx, []string{filepath.Join(repoRoot, "method.go"), filepath.Join(repoRoot, "README.md")})
+	if err != nil {
+		t.Fatalf("ReadFiles() error = %v", err)
+	}
+	var foundMethod, foundReadme bool
+	for _, doc := range docs {
+		switch doc.Metadata["trpc_ast_file_path"] {
+		case "method.go":
+			foundMethod = foundMethod || doc.Metadata["trpc_ast_full_name"] == "example.com/demo.Service.Do"
+		case "README.md":
+			foundReadme = true
+		default:
+			t.Fatalf("unexpected document of %v", doc.Metadata["trpc_ast_file_path"])
+		}
+	}
+	if !foundMethod || !foundReadme {
+		t.Fatalf("expected method and readme docs, got method=%v readme=%v", foundMethod, foundReadme)
+	}
+
+	docs, err = src.ReadFiles(ctx, nil)
+	if err != nil || len(docs) != 0 {
+		t.Fatalf("ReadFiles(nil) = %d docs, %v", len(docs), err)
+	}
+
+	remote := New(WithRepository(Repository{URL: "https://example.com/demo.git"}))
+	if _, err := remote.WatchPaths(ctx); err == nil {
+		t.Fatal("expected an error for a remote repository")
+	}
+	if _, err := remote.ReadFiles(ctx, []string{"main.go"}); err == nil {
+		t.Fatal("expected an error for a remote repository")
+	}
+}
+
 func TestWithRepositoryLastOptionWins(t *testing.T) {
 	secondRepo := t.TempDir()
 	src := New(WithRepository(Repository{Dir: t.TempDir()}),
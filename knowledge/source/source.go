@@ -26,6 +26,8 @@ const (
 	TypeDir  = "dir"
 	TypeRepo = "repo"
 	TypeURL  = "url"
+	// TypeEntityGraph is the type of sources that extract entity graphs from documents.
+	TypeEntityGraph = "entity_graph"
 )
 
 // FileReaderType represents the type of file to be processed by a reader.
@@ -56,6 +58,18 @@ const (
 
 	// FileReaderTypeGo represents Go source files reader(.go)
 	FileReaderTypeGo FileReaderType = "go"
+
+	// FileReaderTypeHTML represents HTML pages reader(.html, .htm)
+	FileReaderTypeHTML FileReaderType = "html"
+
+	// FileReaderTypeXLSX represents Microsoft Excel workbooks reader(.xlsx)
+	FileReaderTypeXLSX FileReaderType = "xlsx"
+
+	// FileReaderTypePPTX represents Microsoft PowerPoint presentations reader(.pptx)
+	FileReaderTypePPTX FileReaderType = "pptx"
+
+	// FileReaderTypeEPUB represents EPUB e-books reader(.epub)
+	FileReaderTypeEPUB FileReaderType = "epub"
 )
 
 // MetaPrefix is the prefix for all metadata keys generated by trpc-agent-go.
@@ -85,7 +99,10 @@ const (
 	MetaMarkdownHeaderPath    = MetaPrefix + "markdown_header_path" // header path for markdown chunks
 	MetadataDenseScore        = MetaPrefix + "dense_score"
 	MetadataSparseScore       = MetaPrefix + "sparse_score"
+	MetaQueryVariants         = MetaPrefix + "query_variants" // query variants that retrieved the document
 	MetaOverlappedContentSize = MetaPrefix + "overlapped_content_size"
+	MetaChunkStartOffset      = MetaPrefix + "chunk_start_offset" // rune offset of the chunk in the document content
+	MetaChunkEndOffset        = MetaPrefix + "chunk_end_offset"   // rune offset just past the chunk in the document content
 
 	// necessary metadata
 	MetaURI        = MetaPrefix + "uri"         // URI (absolute path / URL / md5 for pure text)
@@ -95,6 +112,18 @@ const (
 	MetaRepoURL    = codeast.TrpcAstMetaPrefix + "repo_url"
 	MetaBranch     = codeast.TrpcAstMetaPrefix + "branch"
 	MetaRepoPath   = MetaPrefix + "repo_path"
+
+	// structure metadata set by document readers
+	MetaTitle        = MetaPrefix + "title"         // HTML page or EPUB book title
+	MetaSheetName    = MetaPrefix + "sheet_name"    // XLSX sheet name
+	MetaSheetIndex   = MetaPrefix + "sheet_index"   // XLSX sheet index, 1-based
+	MetaRowStart     = MetaPrefix + "row_start"     // first XLSX row in the chunk, 1-based
+	MetaRowEnd       = MetaPrefix + "row_end"       // last XLSX row in the chunk, 1-based
+	MetaSlideNumber  = MetaPrefix + "slide_number"  // PPTX slide number, 1-based
+	MetaSlideTitle   = MetaPrefix + "slide_title"   // PPTX slide title
+	MetaSpeakerNotes = MetaPrefix + "speaker_notes" // PPTX speaker notes
+	MetaChapterIndex = MetaPrefix + "chapter_index" // EPUB chapter index in reading order, 1-based
+	MetaChapterTitle = MetaPrefix + "chapter_title" // EPUB chapter title
 )
 
 // Source represents a knowledge source that can provide documents.
@@ -144,6 +173,25 @@ type GraphSource interface {
 	ReadGraph(ctx context.Context, opts ...ReadGraphOption) (*graph.Data, error)
 }
 
+// FileSource represents a knowledge source backed by local files that can
+// read a subset of its files. Watchers use it to re-index only the files that
+// changed instead of reloading the whole source.
+type FileSource interface {
+	Source
+
+	// WatchPaths returns the local files and directories the source reads from.
+	WatchPaths(ctx context.Context) ([]string, error)
+
+	// ListFiles returns the files the source would read right now.
+	ListFiles(ctx context.Context) ([]string, error)
+
+	// ReadFiles reads the documents of the given files, which are paths
+	// returned by ListFiles. The documents carry the same metadata as the ones
+	// returned by ReadDocuments. It returns an error when any of the files
+	// cannot be read.
+	ReadFiles(ctx context.Context, filePaths []string) ([]*document.Document, error)
+}
+
 // MetadataFieldPrefix is the prefix for metadata fields in filter conditions.
 // Fields with this prefix are treated as metadata fields and will be processed accordingly.
 const MetadataFieldPrefix = "metadata."
@@ -19,6 +19,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/agent"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/citation"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/searchfilter"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
@@ -103,6 +104,9 @@ type DocumentResult struct {
 	Text     string         `json:"text"`
 	Metadata map[string]any `json:"metadata,omitempty"`
 	Score    float64        `json:"score"`
+	// Citation is the marker the model cites the document with, e.g. "[3]".
+	// It is set when the tool has a citation tracker.
+	Citation string `json:"citation,omitempty"`
 }
 
 // Option is a function that configures the knowledge search tool.
@@ -132,6 +136,7 @@ type options struct {
 	excludeMetadataKeys map[string]struct{}
 	postProcessor       ResultPostProcessor
 	includeContent      bool
+	citationTracker     *citation.Tracker
 }
 
 // WithToolName sets the name of the knowledge search tool.
@@ -224,6 +229,15 @@ func WithResultPostProcessor(p ResultPostProcessor) Option {
 	}
 }
 
+// WithCitationTracker assigns citation IDs to the returned documents and asks
+// the model to cite them. Give the same tracker to citation.NewPlugin to
+// resolve the citations of the final response.
+func WithCitationTracker(t *citation.Tracker) Option {
+	return func(opts *options) {
+		opts.citationTracker = t
+	}
+}
+
 func withIncludeContentDefault(include bool) Option {
 	return func(opts *options) {
 		opts.includeContent = include
@@ -282,6 +296,7 @@ func NewKnowledgeSearchTool(kb knowledge.Knowledge, opts ...Option) tool.Tool {
 		if err != nil {
 			return nil, err
 		}
+		applyCitations(ctx, result, resp, opt.citationTracker)
 		return applyPostProcessor(ctx, resp, opt.postProcessor), nil
 	}
 
@@ -382,6 +397,7 @@ func NewAgenticFilterSearchTool(
 		if err != nil {
 			return nil, err
 		}
+		applyCitations(ctx, result, resp, opt.citationTracker)
 		return applyPostProcessor(ctx, resp, opt.postProcessor), nil
 	}
 
@@ -488,6 +504,27 @@ func applyPostProcessor(ctx context.Context, resp *KnowledgeSearchResponse, p Re
 	return p(ctx, resp)
 }
 
+// applyCitations sets the citation markers of resp, whose documents are in
+// the order of result, and tells the model to use them.
+func applyCitations(
+	ctx context.Context,
+	result *knowledge.SearchResult,
+	resp *KnowledgeSearchResponse,
+	tracker *citation.Tracker,
+) {
+	if tracker == nil {
+		return
+	}
+	for i, doc := range result.Documents {
+		if doc.Document == nil {
+			continue
+		}
+		resp.Documents[i].Citation = citation.Marker(tracker.Cite(ctx, doc.Document).ID)
+	}
+	resp.Message += ". Cite the documents you use by appending their citation marker, e.g. [1], " +
+		"to the sentences they support"
+}
+
 // convertSearchResults converts knowledge.SearchResult to KnowledgeSearchResponse.
 func convertSearchResults(
 	result *knowledge.SearchResult,
@@ -587,7 +624,8 @@ func filterMetadata(metadata map[string]any, excludeKeys map[string]struct{}) ma
 			k == source.MetaChunkIndex ||
 			k == source.MetaMarkdownHeaderPath ||
 			k == source.MetadataDenseScore ||
-			k == source.MetadataSparseScore {
+			k == source.MetadataSparseScore ||
+			k == source.MetaQueryVariants {
 			filtered[k] = v
 		}
 	}
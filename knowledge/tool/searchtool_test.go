@@ -22,6 +22,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	internaltool "trpc.group/trpc-go/trpc-agent-go/internal/tool"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/citation"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/searchfilter"
 	"trpc.group/trpc-go/trpc-agent-go/model"
@@ -266,6 +267,35 @@ func TestKnowledgeSearchTool(t *testing.T) {
 		require.Contains(t, rsp.Message, "Found 1 relevant document")
 	})
 
+	t.Run("citation markers", func(t *testing.T) {
+		kb := stubKnowledge{result: &knowledge.SearchResult{
+			Documents: []*knowledge.Result{
+				{Document: &document.Document{ID: "doc-1", Content: "foo"}, Score: 0.9},
+				{Document: &document.Document{ID: "doc-2", Content: "bar"}, Score: 0.8},
+			},
+		}}
+		tracker := citation.NewTracker()
+		searchTool := NewKnowledgeSearchTool(kb, WithCitationTracker(tracker))
+		ctx := agent.NewInvocationContext(context.Background(), agent.NewInvocation(
+			agent.WithInvocationSession(session.NewSession("app", "user", "sess"))))
+		res, err := searchTool.(ctool.CallableTool).Call(ctx, marshalArgs(t, "hello"))
+		require.NoError(t, err)
+		rsp := res.(*KnowledgeSearchResponse)
+		require.Equal(t, "[1]", rsp.Documents[0].Citation)
+		require.Equal(t, "[2]", rsp.Documents[1].Citation)
+		require.Contains(t, rsp.Message, "citation marker")
+
+		// A later search in the same session keeps the IDs.
+		kb.result.Documents = kb.result.Documents[1:]
+		searchTool = NewKnowledgeSearchTool(kb, WithCitationTracker(tracker))
+		res, err = searchTool.(ctool.CallableTool).Call(ctx, marshalArgs(t, "hello"))
+		require.NoError(t, err)
+		require.Equal(t, "[2]", res.(*KnowledgeSearchResponse).Documents[0].Citation)
+		chunk, ok := tracker.Lookup(ctx, "1")
+		require.True(t, ok)
+		require.Equal(t, "doc-1", chunk.DocumentID)
+	})
+
 	t.Run("passes invocation session context", func(t *testing.T) {
 		var captured *knowledge.SearchRequest
 		kb := stubKnowledge{
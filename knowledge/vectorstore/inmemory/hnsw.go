@@ -0,0 +1,415 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// defaultHNSWM is the default number of neighbours per node and layer.
+	defaultHNSWM = 16
+	// defaultHNSWEfConstruction is the default candidate list size when inserting.
+	defaultHNSWEfConstruction = 64
+	// defaultHNSWEfSearch is the default candidate list size when searching.
+	defaultHNSWEfSearch = 64
+	// hnswRebuildMinDeleted is the number of deleted nodes below which the
+	// graph is never rebuilt.
+	hnswRebuildMinDeleted = 64
+)
+
+// HNSWIndexParams configures the HNSW approximate nearest neighbour index.
+// Zero fields take their defaults.
+type HNSWIndexParams struct {
+	// M is the number of neighbours kept per node on the upper layers; layer
+	// zero keeps 2*M. Larger values raise recall and memory use. Default 16.
+	M int
+	// EfConstruction is the candidate list size used while inserting. Larger
+	// values build a better graph more slowly. Default 64.
+	EfConstruction int
+	// EfSearch is the candidate list size used while searching. It is raised
+	// to the query limit when smaller. Larger values raise recall and
+	// latency. Default 64.
+	EfSearch int
+	// Seed seeds the layer assignment, for reproducible graphs. Default 0
+	// means a fixed seed of 1.
+	Seed int64
+}
+
+func (p *HNSWIndexParams) withDefaults() HNSWIndexParams {
+	out := HNSWIndexParams{}
+	if p != nil {
+		out = *p
+	}
+	if out.M <= 1 {
+		out.M = defaultHNSWM
+	}
+	if out.EfConstruction <= 0 {
+		out.EfConstruction = defaultHNSWEfConstruction
+	}
+	if out.EfSearch <= 0 {
+		out.EfSearch = defaultHNSWEfSearch
+	}
+	if out.Seed == 0 {
+		out.Seed = 1
+	}
+	return out
+}
+
+// hnswNode is one vector in the graph. vec shares its backing array with
+// the store's embedding, which is never modified in place.
+type hnswNode struct {
+	id      string
+	vec     []float64
+	norm    float64
+	level   int
+	friends [][]int32
+	deleted bool
+}
+
+// hnswIndex is a Hierarchical Navigable Small World graph over cosine
+// similarity (Malkov & Yashunin, 2016). Deletions leave tombstones that are
+// still traversed but never returned; the graph is rebuilt once tombstones
+// outnumber live nodes. It is not safe for concurrent use: the store guards
+// it with its own mutex.
+type hnswIndex struct {
+	params    HNSWIndexParams
+	levelMult float64
+	rng       *rand.Rand
+
+	// dim is the dimension of indexed vectors, set by the first insert.
+	dim      int
+	nodes    []*hnswNode
+	byID     map[string]int32
+	entry    int32
+	maxLevel int
+	deleted  int
+}
+
+func newHNSWIndex(params HNSWIndexParams) *hnswIndex {
+	return &hnswIndex{
+		params:    params,
+		levelMult: 1 / math.Log(float64(params.M)),
+		rng:       rand.New(rand.NewSource(params.Seed)),
+		byID:      make(map[string]int32),
+		entry:     -1,
+	}
+}
+
+// len returns the number of live nodes.
+func (h *hnswIndex) len() int {
+	return len(h.byID)
+}
+
+// accepts reports whether the index can hold vectors of dimension dim.
+func (h *hnswIndex) accepts(dim int) bool {
+	return h.dim == 0 || h.dim == dim
+}
+
+func (h *hnswIndex) maxFriends(level int) int {
+	if level == 0 {
+		return 2 * h.params.M
+	}
+	return h.params.M
+}
+
+func (h *hnswIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+}
+
+// insert adds or replaces the vector for id. Vectors whose dimension differs
+// from the indexed one are ignored and reported as false.
+func (h *hnswIndex) insert(id string, vec []float64) bool {
+	h.remove(id)
+	if h.len() == 0 && h.dim != len(vec) {
+		h.reset()
+	}
+	if !h.accepts(len(vec)) {
+		return false
+	}
+	h.dim = len(vec)
+	h.insertNode(&hnswNode{id: id, vec: vec, norm: vectorNorm(vec), level: h.randomLevel()})
+	h.maybeRebuild()
+	return true
+}
+
+func (h *hnswIndex) insertNode(node *hnswNode) {
+	node.friends = make([][]int32, node.level+1)
+	idx := int32(len(h.nodes))
+	h.nodes = append(h.nodes, node)
+	h.byID[node.id] = idx
+	if h.entry < 0 {
+		h.entry = idx
+		h.maxLevel = node.level
+		return
+	}
+
+	ep := h.entry
+	for level := h.maxLevel; level > node.level; level-- {
+		ep = h.greedy(node.vec, node.norm, ep, level)
+	}
+	for level := min(node.level, h.maxLevel); level >= 0; level-- {
+		candidates := h.searchLayer(node.vec, node.norm, ep, h.params.EfConstruction, level, nil)
+		node.friends[level] = h.selectNeighbors(candidates, h.params.M)
+		for _, friend := range node.friends[level] {
+			h.link(friend, idx, level)
+		}
+		ep = candidates[0].idx
+	}
+	if node.level > h.maxLevel {
+		h.maxLevel = node.level
+		h.entry = idx
+	}
+}
+
+// link adds a back edge from node idx to to on level, pruning the
+// neighbour list when it grows past its limit.
+func (h *hnswIndex) link(idx, to int32, level int) {
+	node := h.nodes[idx]
+	node.friends[level] = append(node.friends[level], to)
+	if len(node.friends[level]) <= h.maxFriends(level) {
+		return
+	}
+	candidates := make([]hnswCandidate, 0, len(node.friends[level]))
+	for _, f := range node.friends[level] {
+		candidates = append(candidates, hnswCandidate{idx: f, sim: h.similarity(node.vec, node.norm, f)})
+	}
+	sortCandidates(candidates)
+	node.friends[level] = h.selectNeighbors(candidates, h.maxFriends(level))
+}
+
+// selectNeighbors applies the neighbour selection heuristic of the paper:
+// a candidate is kept only when it is closer to the base vector than to any
+// neighbour kept so far, which keeps edges pointing in diverse directions.
+// Remaining slots are filled with the closest discarded candidates.
+// candidates must be sorted by descending similarity.
+func (h *hnswIndex) selectNeighbors(candidates []hnswCandidate, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var discarded []int32
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		cn := h.nodes[c.idx]
+		keep := true
+		for _, s := range selected {
+			if h.similarity(cn.vec, cn.norm, s) > c.sim {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c.idx)
+		} else {
+			discarded = append(discarded, c.idx)
+		}
+	}
+	for _, idx := range discarded {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, idx)
+	}
+	return selected
+}
+
+// remove tombstones the node for id.
+func (h *hnswIndex) remove(id string) {
+	idx, ok := h.byID[id]
+	if !ok {
+		return
+	}
+	delete(h.byID, id)
+	h.nodes[idx].deleted = true
+	h.deleted++
+	h.maybeRebuild()
+}
+
+// maybeRebuild rebuilds the graph from live nodes once tombstones outnumber
+// them, so deleted nodes stop costing memory and traversal time.
+func (h *hnswIndex) maybeRebuild() {
+	if h.deleted < hnswRebuildMinDeleted || h.deleted <= h.len() {
+		return
+	}
+	h.rebuild()
+}
+
+// reset drops every node.
+func (h *hnswIndex) reset() {
+	h.dim = 0
+	h.nodes = nil
+	h.byID = make(map[string]int32)
+	h.entry = -1
+	h.maxLevel = 0
+	h.deleted = 0
+}
+
+func (h *hnswIndex) rebuild() {
+	old := h.nodes
+	h.nodes = make([]*hnswNode, 0, h.len())
+	h.byID = make(map[string]int32, h.len())
+	h.entry = -1
+	h.maxLevel = 0
+	h.deleted = 0
+	for _, node := range old {
+		if node.deleted {
+			continue
+		}
+		h.insertNode(&hnswNode{id: node.id, vec: node.vec, norm: node.norm, level: node.level})
+	}
+	if len(h.nodes) == 0 {
+		h.dim = 0
+	}
+}
+
+// search returns up to k live nodes most similar to vec, best first. When
+// accept is set, only nodes it accepts are returned; the others are still
+// traversed so the graph stays connected.
+func (h *hnswIndex) search(vec []float64, k, ef int, accept func(id string) bool) []hnswCandidate {
+	if h.entry < 0 || len(vec) != h.dim || k <= 0 {
+		return nil
+	}
+	norm := vectorNorm(vec)
+	ep := h.entry
+	for level := h.maxLevel; level > 0; level-- {
+		ep = h.greedy(vec, norm, ep, level)
+	}
+	results := h.searchLayer(vec, norm, ep, max(ef, k), 0, func(n *hnswNode) bool {
+		return !n.deleted && (accept == nil || accept(n.id))
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// greedy walks level towards vec from ep and returns the closest node found.
+func (h *hnswIndex) greedy(vec []float64, norm float64, ep int32, level int) int32 {
+	best := ep
+	bestSim := h.similarity(vec, norm, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, f := range h.nodes[best].friends[level] {
+			if sim := h.similarity(vec, norm, f); sim > bestSim {
+				best, bestSim, changed = f, sim, true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer runs a best-first search of level from ep and returns up to ef
+// result nodes sorted by descending similarity. A nil accept takes every
+// node, deleted ones included, which is what graph construction needs.
+func (h *hnswIndex) searchLayer(
+	vec []float64,
+	norm float64,
+	ep int32,
+	ef int,
+	level int,
+	accept func(*hnswNode) bool,
+) []hnswCandidate {
+	visited := map[int32]struct{}{ep: {}}
+	start := hnswCandidate{idx: ep, sim: h.similarity(vec, norm, ep)}
+	frontier := &maxCandidateHeap{start}
+	results := &minCandidateHeap{}
+	if accept == nil || accept(h.nodes[ep]) {
+		heap.Push(results, start)
+	}
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(hnswCandidate)
+		if results.Len() >= ef && c.sim < (*results)[0].sim {
+			break
+		}
+		for _, f := range h.nodes[c.idx].friends[level] {
+			if _, seen := visited[f]; seen {
+				continue
+			}
+			visited[f] = struct{}{}
+			sim := h.similarity(vec, norm, f)
+			if results.Len() >= ef && sim <= (*results)[0].sim {
+				continue
+			}
+			heap.Push(frontier, hnswCandidate{idx: f, sim: sim})
+			if accept != nil && !accept(h.nodes[f]) {
+				continue
+			}
+			heap.Push(results, hnswCandidate{idx: f, sim: sim})
+			if results.Len() > ef {
+				heap.Pop(results)
+			}
+		}
+	}
+	out := make([]hnswCandidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(hnswCandidate)
+	}
+	return out
+}
+
+func (h *hnswIndex) similarity(vec []float64, norm float64, idx int32) float64 {
+	node := h.nodes[idx]
+	if norm == 0 || node.norm == 0 {
+		return 0
+	}
+	var dot float64
+	for i, v := range node.vec {
+		dot += vec[i] * v
+	}
+	return dot / (norm * node.norm)
+}
+
+func vectorNorm(vec []float64) float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += v * v
+	}
+	return math.Sqrt(sum)
+}
+
+type hnswCandidate struct {
+	idx int32
+	sim float64
+}
+
+func sortCandidates(c []hnswCandidate) {
+	sort.Slice(c, func(i, j int) bool { return c[i].sim > c[j].sim })
+}
+
+// maxCandidateHeap pops the most similar candidate first.
+type maxCandidateHeap []hnswCandidate
+
+func (h maxCandidateHeap) Len() int           { return len(h) }
+func (h maxCandidateHeap) Less(i, j int) bool { return h[i].sim > h[j].sim }
+func (h maxCandidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxCandidateHeap) Push(x any)        { *h = append(*h, x.(hnswCandidate)) }
+func (h *maxCandidateHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// minCandidateHeap pops the least similar candidate first.
+type minCandidateHeap []hnswCandidate
+
+func (h minCandidateHeap) Len() int           { return len(h) }
+func (h minCandidateHeap) Less(i, j int) bool { return h[i].sim < h[j].sim }
+func (h minCandidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minCandidateHeap) Push(x any)        { *h = append(*h, x.(hnswCandidate)) }
+func (h *minCandidateHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
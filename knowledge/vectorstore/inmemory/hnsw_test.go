@@ -0,0 +1,214 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/searchfilter"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+)
+
+func randomVector(rng *rand.Rand, dim int) []float64 {
+	vec := make([]float64, dim)
+	for i := range vec {
+		vec[i] = rng.NormFloat64()
+	}
+	return vec
+}
+
+// fillStores adds the same n random documents to every store. Even
+// documents are in group "even", odd ones in group "odd".
+func fillStores(t *testing.T, n, dim int, stores ...*VectorStore) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < n; i++ {
+		group := "even"
+		if i%2 == 1 {
+			group = "odd"
+		}
+		doc := &document.Document{
+			ID:       fmt.Sprintf("doc-%d", i),
+			Content:  "content",
+			Metadata: map[string]any{"group": group, "n": i},
+		}
+		vec := randomVector(rng, dim)
+		for _, vs := range stores {
+			require.NoError(t, vs.Add(context.Background(), doc, vec))
+		}
+	}
+}
+
+func resultIDs(t *testing.T, vs *VectorStore, query *vectorstore.SearchQuery) []string {
+	t.Helper()
+	result, err := vs.Search(context.Background(), query)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(result.Results))
+	for _, r := range result.Results {
+		ids = append(ids, r.Document.ID)
+	}
+	return ids
+}
+
+// recall returns the fraction of exact results found by the index.
+func recall(t *testing.T, exact, indexed *VectorStore, queries []*vectorstore.SearchQuery) float64 {
+	t.Helper()
+	found, total := 0, 0
+	for _, q := range queries {
+		want := resultIDs(t, exact, q)
+		got := make(map[string]bool)
+		for _, id := range resultIDs(t, indexed, q) {
+			got[id] = true
+		}
+		for _, id := range want {
+			if got[id] {
+				found++
+			}
+		}
+		total += len(want)
+	}
+	require.NotZero(t, total)
+	return float64(found) / float64(total)
+}
+
+func TestHNSW_RecallMatchesExactSearch(t *testing.T) {
+	exact := New()
+	indexed := New(WithHNSWIndex(nil))
+	fillStores(t, 3000, 32, exact, indexed)
+
+	rng := rand.New(rand.NewSource(7))
+	var queries []*vectorstore.SearchQuery
+	for i := 0; i < 50; i++ {
+		queries = append(queries, &vectorstore.SearchQuery{Vector: randomVector(rng, 32), Limit: 10})
+	}
+	assert.GreaterOrEqual(t, recall(t, exact, indexed, queries), 0.9)
+
+	// Scores are exact cosine similarities, best first.
+	result, err := indexed.Search(context.Background(), queries[0])
+	require.NoError(t, err)
+	require.Len(t, result.Results, 10)
+	for i, r := range result.Results {
+		_, emb, err := indexed.Get(context.Background(), r.Document.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, cosineSimilarity(queries[0].Vector, emb), r.Score, 1e-9)
+		if i > 0 {
+			assert.LessOrEqual(t, r.Score, result.Results[i-1].Score)
+		}
+	}
+
+	// MinScore drops results below the threshold.
+	threshold := result.Results[4].Score
+	q := &vectorstore.SearchQuery{Vector: queries[0].Vector, Limit: 10, MinScore: threshold}
+	assert.Len(t, resultIDs(t, indexed, q), 5)
+}
+
+func TestHNSW_FilteredSearch(t *testing.T) {
+	exact := New()
+	indexed := New(WithHNSWIndex(&HNSWIndexParams{M: 8, EfConstruction: 40, EfSearch: 40}))
+	// 2500 matches per group is above filteredScanLimit, so the graph is
+	// walked with the filter applied.
+	fillStores(t, 5000, 16, exact, indexed)
+
+	rng := rand.New(rand.NewSource(9))
+	filters := []*vectorstore.SearchFilter{
+		{Metadata: map[string]any{"group": "odd"}},
+		{FilterCondition: &searchfilter.UniversalFilterCondition{
+			Field: "metadata.group", Operator: searchfilter.OperatorEqual, Value: "even",
+		}},
+		// Selective filters scan their matches exactly.
+		{IDs: []string{"doc-1", "doc-2", "doc-3"}},
+	}
+	for _, filter := range filters {
+		var queries []*vectorstore.SearchQuery
+		for i := 0; i < 20; i++ {
+			queries = append(queries, &vectorstore.SearchQuery{
+				Vector: randomVector(rng, 16), Limit: 10, Filter: filter,
+			})
+		}
+		assert.GreaterOrEqual(t, recall(t, exact, indexed, queries), 0.9)
+		for _, q := range queries {
+			for _, id := range resultIDs(t, indexed, q) {
+				assert.True(t, indexed.matchesFilter(id, filter), id)
+			}
+		}
+	}
+	ids := resultIDs(t, indexed, &vectorstore.SearchQuery{
+		Vector: randomVector(rng, 16), Limit: 10, Filter: filters[0],
+	})
+	assert.Len(t, ids, 10, "a filtered graph walk still fills the limit")
+}
+
+func TestHNSW_IncrementalUpdates(t *testing.T) {
+	ctx := context.Background()
+	vs := New(WithHNSWIndex(&HNSWIndexParams{M: 8}))
+	fillStores(t, 500, 8, vs)
+	target := []float64{1, 1, 1, 1, 1, 1, 1, 1}
+	query := &vectorstore.SearchQuery{Vector: target, Limit: 3}
+
+	require.NoError(t, vs.Update(ctx, &document.Document{ID: "doc-7", Content: "moved"}, target))
+	ids := resultIDs(t, vs, query)
+	require.NotEmpty(t, ids)
+	assert.Equal(t, "doc-7", ids[0])
+
+	// Re-adding an ID replaces its vector.
+	require.NoError(t, vs.Add(ctx, &document.Document{ID: "doc-8", Content: "moved"}, target))
+	assert.ElementsMatch(t, []string{"doc-7", "doc-8"}, resultIDs(t, vs, query)[:2])
+
+	require.NoError(t, vs.Delete(ctx, "doc-7"))
+	assert.NotContains(t, resultIDs(t, vs, query), "doc-7")
+	assert.Equal(t, 499, vs.index.len())
+
+	require.NoError(t, vs.DeleteByFilter(ctx, vectorstore.WithDeleteFilter(map[string]any{"group": "odd"})))
+	count, err := vs.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, count, vs.index.len())
+	assert.Zero(t, vs.index.deleted, "the graph is rebuilt once tombstones outnumber live nodes")
+	for _, id := range resultIDs(t, vs, &vectorstore.SearchQuery{Vector: target, Limit: 20}) {
+		doc, _, err := vs.Get(ctx, id)
+		require.NoError(t, err)
+		assert.NotEqual(t, "odd", doc.Metadata["group"])
+	}
+
+	require.NoError(t, vs.DeleteByFilter(ctx, vectorstore.WithDeleteAll(true)))
+	assert.Empty(t, resultIDs(t, vs, query))
+	require.NoError(t, vs.Add(ctx, &document.Document{ID: "new", Content: "c"}, []float64{1, 0}))
+	assert.Equal(t, []string{"new"}, resultIDs(t, vs, &vectorstore.SearchQuery{Vector: []float64{1, 0}}))
+}
+
+func TestHNSW_MixedDimensions(t *testing.T) {
+	ctx := context.Background()
+	vs := New(WithHNSWIndex(nil))
+	require.NoError(t, vs.Add(ctx, &document.Document{ID: "a", Content: "c"}, []float64{1, 0, 0}))
+	require.NoError(t, vs.Add(ctx, &document.Document{ID: "b", Content: "c"}, []float64{1, 0}))
+	assert.Equal(t, 1, vs.index.len())
+
+	// Queries of the other dimension fall back to exact search.
+	assert.Equal(t, []string{"a"}, resultIDs(t, vs, &vectorstore.SearchQuery{Vector: []float64{1, 0, 0}}))
+	assert.Equal(t, []string{"b"}, resultIDs(t, vs, &vectorstore.SearchQuery{Vector: []float64{1, 0}}))
+
+	// Once the index is empty it takes the next dimension.
+	require.NoError(t, vs.Delete(ctx, "a"))
+	require.NoError(t, vs.Add(ctx, &document.Document{ID: "c", Content: "c"}, []float64{0, 1}))
+	assert.Equal(t, 2, vs.index.dim)
+	assert.Equal(t, []string{"c"}, resultIDs(t, vs, &vectorstore.SearchQuery{Vector: []float64{0, 1}, Limit: 1}))
+}
+
+func TestHNSWIndexParams_Defaults(t *testing.T) {
+	var nilParams *HNSWIndexParams
+	assert.Equal(t, HNSWIndexParams{M: 16, EfConstruction: 64, EfSearch: 64, Seed: 1}, nilParams.withDefaults())
+	p := &HNSWIndexParams{M: 32, EfSearch: 200, Seed: 5}
+	assert.Equal(t, HNSWIndexParams{M: 32, EfConstruction: 64, EfSearch: 200, Seed: 5}, p.withDefaults())
+}
@@ -0,0 +1,186 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"math"
+
+	"trpc.group/trpc-go/trpc-agent-go/internal/knowledge/bm25"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+)
+
+const (
+	defaultVectorWeight   = 0.7
+	defaultTextWeight     = 0.3
+	defaultRRFK           = 60
+	defaultCandidateRatio = 3
+)
+
+// HybridFusionMode represents the fusion mode for hybrid search.
+type HybridFusionMode int
+
+const (
+	// HybridFusionWeighted uses weighted fusion (default).
+	// Formula: score = vector_score * vectorWeight + text_score * textWeight
+	HybridFusionWeighted HybridFusionMode = iota
+
+	// HybridFusionRRF uses Reciprocal Rank Fusion.
+	// Formula: score = sum(1 / (k + rank_i)) for each ranking list
+	HybridFusionRRF
+)
+
+// RRFParams contains parameters for Reciprocal Rank Fusion.
+type RRFParams struct {
+	// K is the RRF constant (default: 60).
+	K int
+
+	// CandidateRatio controls how many candidates to take from each ranking.
+	// Hybrid search takes (limit * CandidateRatio) vector candidates, and
+	// RRF also takes that many keyword candidates. Default: 3.
+	CandidateRatio int
+}
+
+// WithHybridSearchWeights sets the weights for weighted hybrid search.
+// Weights are normalized to sum to 1.0; invalid weights keep the defaults
+// of 0.7 for vector similarity and 0.3 for text relevance.
+func WithHybridSearchWeights(vectorWeight, textWeight float64) Option {
+	return func(vs *VectorStore) {
+		total := vectorWeight + textWeight
+		if vectorWeight < 0 || textWeight < 0 || total <= 0 {
+			return
+		}
+		vs.vectorWeight = vectorWeight / total
+		vs.textWeight = textWeight / total
+	}
+}
+
+// WithHybridFusionMode sets the fusion mode for hybrid search.
+// Default is HybridFusionWeighted.
+func WithHybridFusionMode(mode HybridFusionMode) Option {
+	return func(vs *VectorStore) {
+		vs.fusionMode = mode
+	}
+}
+
+// WithRRFParams sets the parameters for Reciprocal Rank Fusion.
+// Values <= 0 are ignored (defaults are kept).
+func WithRRFParams(params *RRFParams) Option {
+	return func(vs *VectorStore) {
+		if params == nil {
+			return
+		}
+		if params.K > 0 {
+			vs.rrfParams.K = params.K
+		}
+		if params.CandidateRatio > 0 {
+			vs.rrfParams.CandidateRatio = params.CandidateRatio
+		}
+	}
+}
+
+// WithTokenizer sets the tokenizer of the keyword index, e.g. a dictionary
+// based Chinese word segmenter. The default lower-cases words and splits
+// Chinese, Japanese and Korean text into characters and character bigrams.
+func WithTokenizer(tokenize func(text string) []string) Option {
+	return func(vs *VectorStore) {
+		if tokenize != nil {
+			vs.tokenize = tokenize
+		}
+	}
+}
+
+// searchByKeyword ranks documents by BM25 over their content. Scores are
+// normalized into [0, 1) so MinScore applies as for vector search.
+func (vs *VectorStore) searchByKeyword(query *vectorstore.SearchQuery) (*vectorstore.SearchResult, error) {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	var results []*vectorstore.ScoredDocument
+	for _, hit := range vs.textIndex.Search(query.Query, vs.getMaxResult(query.Limit), vs.filterFunc(query.Filter)) {
+		score := bm25.Normalize(hit.Score)
+		if score < query.MinScore {
+			break
+		}
+		results = append(results, &vectorstore.ScoredDocument{
+			Document: vs.documents[hit.ID].Clone(),
+			Score:    score,
+		})
+	}
+	return &vectorstore.SearchResult{Results: results}, nil
+}
+
+// searchByHybrid fuses vector similarity with BM25 keyword relevance. The
+// dense and sparse parts of each score are reported in the document
+// metadata.
+func (vs *VectorStore) searchByHybrid(query *vectorstore.SearchQuery) (*vectorstore.SearchResult, error) {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	limit := vs.getMaxResult(query.Limit)
+	candidates := limit * vs.rrfParams.CandidateRatio
+	accept := vs.filterFunc(query.Filter)
+
+	denseQuery := *query
+	denseQuery.MinScore = math.Inf(-1)
+	var dense []bm25.Hit
+	for _, r := range vs.vectorResults(&denseQuery, candidates) {
+		dense = append(dense, bm25.Hit{ID: r.Document.ID, Score: r.Score})
+	}
+
+	var fused []bm25.Fused
+	if vs.fusionMode == HybridFusionRRF {
+		fused = bm25.FuseRRF(dense, vs.textIndex.Search(query.Query, candidates, accept), vs.rrfParams.K)
+	} else {
+		// Every keyword match is a candidate, and its exact similarity is
+		// filled in, so weighted scores do not depend on candidate cuts.
+		sparse := vs.textIndex.Search(query.Query, 0, accept)
+		seen := make(map[string]bool, len(dense))
+		for _, h := range dense {
+			seen[h.ID] = true
+		}
+		for i, h := range sparse {
+			if !seen[h.ID] {
+				if emb := vs.embeddings[h.ID]; len(emb) == len(query.Vector) {
+					dense = append(dense, bm25.Hit{ID: h.ID, Score: cosineSimilarity(query.Vector, emb)})
+				}
+			}
+			sparse[i].Score = bm25.Normalize(h.Score)
+		}
+		fused = bm25.FuseWeighted(dense, sparse, vs.vectorWeight, vs.textWeight)
+	}
+
+	var results []*vectorstore.ScoredDocument
+	for _, f := range fused {
+		if len(results) >= limit {
+			break
+		}
+		// RRF scores are rank based and not comparable with MinScore.
+		if vs.fusionMode != HybridFusionRRF && f.Score < query.MinScore {
+			break
+		}
+		doc := vs.documents[f.ID].Clone()
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]any)
+		}
+		doc.Metadata[source.MetadataDenseScore] = f.Dense
+		doc.Metadata[source.MetadataSparseScore] = f.Sparse
+		results = append(results, &vectorstore.ScoredDocument{Document: doc, Score: f.Score})
+	}
+	return &vectorstore.SearchResult{Results: results}, nil
+}
+
+// filterFunc returns a predicate for filter, or nil when there is none.
+func (vs *VectorStore) filterFunc(filter *vectorstore.SearchFilter) func(docID string) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(docID string) bool { return vs.matchesFilter(docID, filter) }
+}
@@ -0,0 +1,156 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+)
+
+func newKeywordStore(t *testing.T, opts ...Option) *VectorStore {
+	t.Helper()
+	vs := New(opts...)
+	docs := []struct {
+		id, content, lang string
+		vec               []float64
+	}{
+		{"go", "Go is a statically typed language with goroutines", "en", []float64{1, 0, 0}},
+		{"rust", "Rust is a systems language with ownership", "en", []float64{0.9, 0.1, 0}},
+		{"vector", "向量数据库支持混合检索和关键词检索", "zh", []float64{0, 1, 0}},
+		{"db", "关系型数据库使用 SQL 查询", "zh", []float64{0, 0.9, 0.1}},
+		{"cooking", "How to cook rice", "en", []float64{0, 0, 1}},
+	}
+	for _, d := range docs {
+		require.NoError(t, vs.Add(context.Background(), &document.Document{
+			ID: d.id, Content: d.content, Metadata: map[string]any{"lang": d.lang},
+		}, d.vec))
+	}
+	return vs
+}
+
+func TestVectorStore_KeywordSearch(t *testing.T) {
+	vs := newKeywordStore(t)
+	keyword := func(q string, filter *vectorstore.SearchFilter, minScore float64) []string {
+		return resultIDs(t, vs, &vectorstore.SearchQuery{
+			Query: q, SearchMode: vectorstore.SearchModeKeyword, Filter: filter, MinScore: minScore,
+		})
+	}
+
+	assert.Equal(t, []string{"go"}, keyword("goroutines", nil, 0))
+	assert.Equal(t, []string{"vector"}, keyword("混合检索", nil, 0))
+	assert.Equal(t, []string{"db", "vector"}, keyword("SQL 数据库", nil, 0))
+	assert.Equal(t, []string{"vector"}, keyword("数据库", &vectorstore.SearchFilter{IDs: []string{"vector"}}, 0))
+	assert.ElementsMatch(t, []string{"go", "rust"}, keyword("language", &vectorstore.SearchFilter{
+		Metadata: map[string]any{"lang": "en"},
+	}, 0))
+	assert.Empty(t, keyword("language", nil, 0.99))
+
+	result, err := vs.Search(context.Background(), &vectorstore.SearchQuery{
+		Query: "rice", SearchMode: vectorstore.SearchModeKeyword,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	assert.Greater(t, result.Results[0].Score, 0.0)
+	assert.Less(t, result.Results[0].Score, 1.0)
+
+	// Without query text, keyword mode still falls back to filter search.
+	assert.Len(t, keyword("", nil, 0), 5)
+
+	// Deleted and updated documents leave the keyword index.
+	ctx := context.Background()
+	require.NoError(t, vs.Delete(ctx, "go"))
+	assert.Empty(t, keyword("goroutines", nil, 0))
+	require.NoError(t, vs.Update(ctx, &document.Document{ID: "rust", Content: "borrow checker"}, []float64{1, 0, 0}))
+	assert.Equal(t, []string{"rust"}, keyword("borrow", nil, 0))
+	assert.Empty(t, keyword("ownership", nil, 0))
+	require.NoError(t, vs.DeleteByFilter(ctx, vectorstore.WithDeleteDocumentIDs([]string{"rust"})))
+	assert.Empty(t, keyword("borrow", nil, 0))
+	require.NoError(t, vs.DeleteByFilter(ctx, vectorstore.WithDeleteAll(true)))
+	assert.Empty(t, keyword("rice", nil, 0))
+}
+
+func TestVectorStore_HybridWeighted(t *testing.T) {
+	vs := newKeywordStore(t)
+	query := &vectorstore.SearchQuery{
+		Query:  "ownership",
+		Vector: []float64{1, 0, 0},
+		Limit:  3,
+	}
+	result, err := vs.Search(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 3)
+	assert.Equal(t, "rust", result.Results[0].Document.ID, "the keyword match outranks the closer vector")
+	assert.Equal(t, "go", result.Results[1].Document.ID)
+
+	top := result.Results[0]
+	dense := top.Document.Metadata[source.MetadataDenseScore].(float64)
+	sparse := top.Document.Metadata[source.MetadataSparseScore].(float64)
+	assert.Greater(t, sparse, 0.0)
+	assert.InDelta(t, top.Score, dense+sparse, 1e-9)
+	assert.InDelta(t, 0.7*cosineSimilarity(query.Vector, []float64{0.9, 0.1, 0}), dense, 1e-9)
+
+	// MinScore applies to the fused score.
+	query.MinScore = top.Score
+	assert.Equal(t, []string{"rust"}, resultIDs(t, vs, query))
+
+	// Without query text, hybrid search is plain vector search.
+	ids := resultIDs(t, vs, &vectorstore.SearchQuery{Vector: []float64{1, 0, 0}, Limit: 2})
+	assert.Equal(t, []string{"go", "rust"}, ids)
+
+	// Weighting only text ranks by keyword relevance.
+	textOnly := newKeywordStore(t, WithHybridSearchWeights(0, 1))
+	ids = resultIDs(t, textOnly, &vectorstore.SearchQuery{Query: "数据库", Vector: []float64{0, 0, 1}, Limit: 2})
+	assert.ElementsMatch(t, []string{"vector", "db"}, ids)
+}
+
+func TestVectorStore_HybridRRF(t *testing.T) {
+	vs := newKeywordStore(t,
+		WithHybridFusionMode(HybridFusionRRF),
+		WithRRFParams(&RRFParams{K: 10, CandidateRatio: 2}),
+		WithHNSWIndex(nil),
+	)
+	result, err := vs.Search(context.Background(), &vectorstore.SearchQuery{
+		Query:    "ownership",
+		Vector:   []float64{1, 0, 0},
+		Limit:    2,
+		MinScore: 0.9,
+		Filter:   &vectorstore.SearchFilter{Metadata: map[string]any{"lang": "en"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 2, "MinScore does not apply to rank based scores")
+	assert.Equal(t, "rust", result.Results[0].Document.ID)
+	assert.InDelta(t, 1.0/12+1.0/11, result.Results[0].Score, 1e-12)
+	assert.Equal(t, "go", result.Results[1].Document.ID)
+	assert.InDelta(t, 1.0/11, result.Results[1].Score, 1e-12)
+}
+
+func TestVectorStore_CustomTokenizerAndSnapshot(t *testing.T) {
+	vs := newKeywordStore(t, WithTokenizer(strings.Fields))
+	ids := resultIDs(t, vs, &vectorstore.SearchQuery{Query: "SQL", SearchMode: vectorstore.SearchModeKeyword})
+	assert.Equal(t, []string{"db"}, ids)
+	ids = resultIDs(t, vs, &vectorstore.SearchQuery{Query: "sql", SearchMode: vectorstore.SearchModeKeyword})
+	assert.Empty(t, ids, "the custom tokenizer does not lower-case")
+
+	// The keyword index is rebuilt when a snapshot is loaded.
+	var buf bytes.Buffer
+	require.NoError(t, newKeywordStore(t).Save(&buf))
+	loaded := New()
+	require.NoError(t, loaded.Load(&buf))
+	ids = resultIDs(t, loaded, &vectorstore.SearchQuery{Query: "混合检索", SearchMode: vectorstore.SearchModeKeyword})
+	assert.Equal(t, []string{"vector"}, ids)
+}
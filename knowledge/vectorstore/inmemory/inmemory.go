@@ -8,6 +8,12 @@
 //
 
 // Package inmemory provides an in-memory vector store implementation.
+//
+// By default Search scans every embedding. WithHNSWIndex switches vector
+// search to an HNSW approximate nearest neighbour index, and
+// WithSnapshotPath persists the store to disk so a restart does not need to
+// re-embed the knowledge base. Document content is also kept in a BM25
+// keyword index for keyword and hybrid search.
 package inmemory
 
 import (
@@ -15,14 +21,17 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
 	"reflect"
 	"sort"
 	"sync"
 	"time"
 
+	"trpc.group/trpc-go/trpc-agent-go/internal/knowledge/bm25"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/searchfilter"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+	"trpc.group/trpc-go/trpc-agent-go/log"
 )
 
 var (
@@ -35,6 +44,10 @@ var (
 
 	// defaultMaxResults is the default maximum number of search results.
 	defaultMaxResults = 10
+
+	// filteredScanLimit is the number of filter matches up to which an
+	// indexed search scans the matches exactly instead of walking the graph.
+	filteredScanLimit = 2048
 )
 var _ vectorstore.VectorStore = (*VectorStore)(nil)
 
@@ -48,6 +61,21 @@ type VectorStore struct {
 	maxResults int
 
 	filterConverter searchfilter.Converter[comparisonFunc]
+
+	// hnswParams enables the HNSW index when set.
+	hnswParams *HNSWIndexParams
+	// index is the HNSW index, nil for exact search.
+	index *hnswIndex
+	// snapshotPath is loaded by New and written by Close when set.
+	snapshotPath string
+
+	// textIndex is the BM25 keyword index over document content.
+	textIndex    *bm25.Index
+	tokenize     bm25.Tokenizer
+	vectorWeight float64
+	textWeight   float64
+	fusionMode   HybridFusionMode
+	rrfParams    RRFParams
 }
 
 // Option represents a functional option for configuring VectorStore.
@@ -63,6 +91,27 @@ func WithMaxResults(maxResults int) Option {
 	}
 }
 
+// WithHNSWIndex enables the HNSW approximate nearest neighbour index for
+// vector search. A nil params uses the defaults. Documents whose embedding
+// dimension differs from the first indexed one are still stored and found by
+// exact search for queries of their dimension.
+func WithHNSWIndex(params *HNSWIndexParams) Option {
+	return func(vs *VectorStore) {
+		p := params.withDefaults()
+		vs.hnswParams = &p
+	}
+}
+
+// WithSnapshotPath sets a snapshot file. New loads it when it exists and
+// Close writes it, so documents and the index survive restarts. Call
+// SaveFile to write a snapshot at other times, e.g. after loading a
+// knowledge base.
+func WithSnapshotPath(path string) Option {
+	return func(vs *VectorStore) {
+		vs.snapshotPath = path
+	}
+}
+
 // New creates a new in-memory vector store instance with options.
 func New(opts ...Option) *VectorStore {
 	vs := &VectorStore{
@@ -70,12 +119,25 @@ func New(opts ...Option) *VectorStore {
 		embeddings:      make(map[string][]float64),
 		maxResults:      defaultMaxResults,
 		filterConverter: &inmemoryConverter{},
+		tokenize:        bm25.Tokenize,
+		vectorWeight:    defaultVectorWeight,
+		textWeight:      defaultTextWeight,
+		rrfParams:       RRFParams{K: defaultRRFK, CandidateRatio: defaultCandidateRatio},
 	}
 
 	// Apply options.
 	for _, opt := range opts {
 		opt(vs)
 	}
+	vs.textIndex = bm25.NewIndex(bm25.DefaultParams(), vs.tokenize)
+	if vs.hnswParams != nil {
+		vs.index = newHNSWIndex(*vs.hnswParams)
+	}
+	if vs.snapshotPath != "" {
+		if err := vs.LoadFile(vs.snapshotPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("inmemory: load snapshot %s: %v", vs.snapshotPath, err)
+		}
+	}
 
 	return vs
 }
@@ -105,6 +167,10 @@ func (vs *VectorStore) Add(ctx context.Context, doc *document.Document, embeddin
 	vs.documents[doc.ID] = clonedDoc
 	vs.embeddings[doc.ID] = make([]float64, len(embedding))
 	copy(vs.embeddings[doc.ID], embedding)
+	if vs.index != nil {
+		vs.index.insert(doc.ID, vs.embeddings[doc.ID])
+	}
+	vs.textIndex.Add(doc.ID, clonedDoc.Content)
 
 	return nil
 }
@@ -162,6 +228,10 @@ func (vs *VectorStore) Update(ctx context.Context, doc *document.Document, embed
 	vs.documents[doc.ID] = clonedDoc
 	vs.embeddings[doc.ID] = make([]float64, len(embedding))
 	copy(vs.embeddings[doc.ID], embedding)
+	if vs.index != nil {
+		vs.index.insert(doc.ID, vs.embeddings[doc.ID])
+	}
+	vs.textIndex.Add(doc.ID, clonedDoc.Content)
 
 	return nil
 }
@@ -181,6 +251,10 @@ func (vs *VectorStore) Delete(ctx context.Context, id string) error {
 
 	delete(vs.documents, id)
 	delete(vs.embeddings, id)
+	if vs.index != nil {
+		vs.index.remove(id)
+	}
+	vs.textIndex.Remove(id)
 
 	return nil
 }
@@ -198,16 +272,20 @@ func (vs *VectorStore) Search(ctx context.Context, query *vectorstore.SearchQuer
 	case vectorstore.SearchModeFilter:
 		return vs.searchByFilter(ctx, query)
 	case vectorstore.SearchModeHybrid:
-		// For in-memory implementation, hybrid mode falls back to vector search
-		// since we don't have full-text search capabilities
 		if len(query.Vector) == 0 {
 			return nil, fmt.Errorf("query vector cannot be empty for hybrid search")
 		}
-		return vs.searchByVector(ctx, query)
+		// Without query text, hybrid search is vector search.
+		if query.Query == "" {
+			return vs.searchByVector(ctx, query)
+		}
+		return vs.searchByHybrid(query)
 	case vectorstore.SearchModeKeyword:
-		// For in-memory implementation, keyword search is not supported
-		// Fall back to filter search
-		return vs.searchByFilter(ctx, query)
+		// Without query text, fall back to filter search.
+		if query.Query == "" {
+			return vs.searchByFilter(ctx, query)
+		}
+		return vs.searchByKeyword(query)
 	default:
 		// Default behavior: require vector for backward compatibility
 		if len(query.Vector) == 0 {
@@ -226,6 +304,36 @@ func (vs *VectorStore) searchByVector(ctx context.Context, query *vectorstore.Se
 	vs.mutex.RLock()
 	defer vs.mutex.RUnlock()
 
+	return &vectorstore.SearchResult{
+		Results: vs.vectorResults(query, vs.getMaxResult(query.Limit)),
+	}, nil
+}
+
+// vectorResults returns the limit documents most similar to the query
+// vector, best first. The caller holds the read lock.
+func (vs *VectorStore) vectorResults(query *vectorstore.SearchQuery, limit int) []*vectorstore.ScoredDocument {
+	var results []*vectorstore.ScoredDocument
+	if vs.index != nil && len(query.Vector) == vs.index.dim {
+		results = vs.searchByIndex(query, limit)
+	} else {
+		results = vs.scan(query, vs.filterFunc(query.Filter))
+	}
+
+	// Sort by score (descending)
+	sortByScore(results)
+
+	// Apply limit
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// scan scores every embedding accepted by accept against the query vector.
+func (vs *VectorStore) scan(
+	query *vectorstore.SearchQuery,
+	accept func(docID string) bool,
+) []*vectorstore.ScoredDocument {
 	var results []*vectorstore.ScoredDocument
 
 	// Calculate similarity scores for all documents
@@ -236,10 +344,8 @@ func (vs *VectorStore) searchByVector(ctx context.Context, query *vectorstore.Se
 		}
 
 		// Apply filter if specified
-		if query.Filter != nil {
-			if !vs.matchesFilter(docID, query.Filter) {
-				continue
-			}
+		if accept != nil && !accept(docID) {
+			continue
 		}
 
 		// Calculate cosine similarity
@@ -253,19 +359,45 @@ func (vs *VectorStore) searchByVector(ctx context.Context, query *vectorstore.Se
 			})
 		}
 	}
+	return results
+}
 
-	// Sort by score (descending)
-	sortByScore(results)
-
-	// Apply limit
-	limit := vs.getMaxResult(query.Limit)
-	if len(results) > limit {
-		results = results[:limit]
+// searchByIndex searches the HNSW index. A filter is applied while walking
+// the graph, so the limit is filled with matching documents; when few
+// documents match, they are scanned exactly instead.
+func (vs *VectorStore) searchByIndex(
+	query *vectorstore.SearchQuery,
+	limit int,
+) []*vectorstore.ScoredDocument {
+	var accept func(docID string) bool
+	if query.Filter != nil {
+		matched := make(map[string]struct{})
+		for docID := range vs.documents {
+			if vs.matchesFilter(docID, query.Filter) {
+				matched[docID] = struct{}{}
+			}
+		}
+		accept = func(docID string) bool {
+			_, ok := matched[docID]
+			return ok
+		}
+		if len(matched) <= filteredScanLimit {
+			return vs.scan(query, accept)
+		}
 	}
 
-	return &vectorstore.SearchResult{
-		Results: results,
-	}, nil
+	var results []*vectorstore.ScoredDocument
+	for _, c := range vs.index.search(query.Vector, limit, vs.index.params.EfSearch, accept) {
+		if c.sim < query.MinScore {
+			break
+		}
+		id := vs.index.nodes[c.idx].id
+		results = append(results, &vectorstore.ScoredDocument{
+			Document: vs.documents[id].Clone(),
+			Score:    c.sim,
+		})
+	}
+	return results
 }
 
 // searchByFilter performs filter-only search
@@ -329,6 +461,10 @@ func (vs *VectorStore) DeleteByFilter(
 	if deleteAll {
 		vs.documents = make(map[string]*document.Document)
 		vs.embeddings = make(map[string][]float64)
+		if vs.index != nil {
+			vs.index.reset()
+		}
+		vs.textIndex.Reset()
 		return nil
 	}
 
@@ -358,6 +494,10 @@ func (vs *VectorStore) DeleteByFilter(
 	for _, docID := range toDelete {
 		delete(vs.documents, docID)
 		delete(vs.embeddings, docID)
+		if vs.index != nil {
+			vs.index.remove(docID)
+		}
+		vs.textIndex.Remove(docID)
 	}
 
 	return nil
@@ -482,15 +622,23 @@ func (vs *VectorStore) GetMetadata(
 	return result, nil
 }
 
-// Close implements vectorstore.VectorStore interface.
+// Close implements vectorstore.VectorStore interface. With WithSnapshotPath
+// it writes the snapshot first.
 func (vs *VectorStore) Close() error {
 	vs.mutex.Lock()
 	defer vs.mutex.Unlock()
 
+	var err error
+	if vs.snapshotPath != "" && vs.documents != nil {
+		err = vs.saveFileLocked(vs.snapshotPath)
+	}
+
 	vs.documents = nil
 	vs.embeddings = nil
+	vs.index = nil
+	vs.textIndex.Reset()
 
-	return nil
+	return err
 }
 
 // cosineSimilarity calculates the cosine similarity between two vectors.
@@ -0,0 +1,285 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+)
+
+// snapshotVersion is the version of the snapshot format.
+const snapshotVersion = 1
+
+var errStoreClosed = errors.New("inmemory: vector store is closed")
+
+func init() {
+	// Metadata values are interfaces; gob needs the concrete types that
+	// readers and JSON decoding commonly produce.
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register(time.Time{})
+}
+
+// snapshot is the gob-encoded file format. Metadata values must be gob
+// encodable; types other than the basic ones, map[string]any, []any and
+// time.Time have to be registered with gob.Register.
+type snapshot struct {
+	Version    int
+	Documents  []*document.Document
+	Embeddings [][]float64
+	Index      *indexSnapshot
+}
+
+type indexSnapshot struct {
+	M        int
+	Dim      int
+	Entry    int32
+	MaxLevel int
+	Deleted  int
+	Nodes    []nodeSnapshot
+}
+
+type nodeSnapshot struct {
+	ID      string
+	Level   int
+	Friends [][]int32
+	Deleted bool
+	// Vector is only stored for deleted nodes, whose embedding is no longer
+	// in the store but is still needed to traverse the graph.
+	Vector []float64
+}
+
+// Save writes a snapshot of the documents, embeddings and HNSW graph to w.
+func (vs *VectorStore) Save(w io.Writer) error {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+	return vs.saveLocked(w)
+}
+
+// SaveFile writes a snapshot to path. The file is replaced atomically, so
+// a crash while saving leaves the previous snapshot intact.
+func (vs *VectorStore) SaveFile(path string) error {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+	return vs.saveFileLocked(path)
+}
+
+// Load replaces the contents of the store with the snapshot read from r.
+// The keyword index is rebuilt from the document content.
+// With WithHNSWIndex the saved graph is reused when it was built with the
+// same M, and rebuilt from the embeddings otherwise; no re-embedding is
+// needed either way.
+func (vs *VectorStore) Load(r io.Reader) error {
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(r)).Decode(&snap); err != nil {
+		return fmt.Errorf("inmemory: decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("inmemory: unsupported snapshot version %d", snap.Version)
+	}
+	if len(snap.Documents) != len(snap.Embeddings) {
+		return fmt.Errorf("inmemory: snapshot has %d documents but %d embeddings",
+			len(snap.Documents), len(snap.Embeddings))
+	}
+
+	documents := make(map[string]*document.Document, len(snap.Documents))
+	embeddings := make(map[string][]float64, len(snap.Documents))
+	for i, doc := range snap.Documents {
+		if doc == nil || doc.ID == "" || len(snap.Embeddings[i]) == 0 {
+			return fmt.Errorf("inmemory: snapshot entry %d is invalid", i)
+		}
+		documents[doc.ID] = doc
+		embeddings[doc.ID] = snap.Embeddings[i]
+	}
+
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	vs.documents = documents
+	vs.embeddings = embeddings
+	vs.textIndex.Reset()
+	for _, doc := range snap.Documents {
+		vs.textIndex.Add(doc.ID, doc.Content)
+	}
+	if vs.hnswParams == nil {
+		return nil
+	}
+	vs.index = newHNSWIndex(*vs.hnswParams)
+	if snap.Index != nil && snap.Index.M == vs.hnswParams.M && vs.index.restore(snap.Index, embeddings) {
+		return nil
+	}
+	vs.index.reset()
+	for _, doc := range snap.Documents {
+		vs.index.insert(doc.ID, embeddings[doc.ID])
+	}
+	return nil
+}
+
+// LoadFile replaces the contents of the store with the snapshot at path.
+// The returned error wraps os.ErrNotExist when there is no snapshot.
+func (vs *VectorStore) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return vs.Load(f)
+}
+
+func (vs *VectorStore) saveLocked(w io.Writer) error {
+	if vs.documents == nil {
+		return errStoreClosed
+	}
+	ids := make([]string, 0, len(vs.documents))
+	for id := range vs.documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	snap := snapshot{
+		Version:    snapshotVersion,
+		Documents:  make([]*document.Document, 0, len(ids)),
+		Embeddings: make([][]float64, 0, len(ids)),
+	}
+	for _, id := range ids {
+		snap.Documents = append(snap.Documents, vs.documents[id])
+		snap.Embeddings = append(snap.Embeddings, vs.embeddings[id])
+	}
+	if vs.index != nil {
+		snap.Index = vs.index.snapshot()
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := gob.NewEncoder(bw).Encode(&snap); err != nil {
+		return fmt.Errorf("inmemory: encode snapshot: %w", err)
+	}
+	return bw.Flush()
+}
+
+func (vs *VectorStore) saveFileLocked(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("inmemory: create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("inmemory: create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := vs.saveLocked(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("inmemory: sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("inmemory: close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("inmemory: replace snapshot: %w", err)
+	}
+	return nil
+}
+
+func (h *hnswIndex) snapshot() *indexSnapshot {
+	snap := &indexSnapshot{
+		M:        h.params.M,
+		Dim:      h.dim,
+		Entry:    h.entry,
+		MaxLevel: h.maxLevel,
+		Deleted:  h.deleted,
+		Nodes:    make([]nodeSnapshot, len(h.nodes)),
+	}
+	for i, node := range h.nodes {
+		snap.Nodes[i] = nodeSnapshot{
+			ID:      node.id,
+			Level:   node.level,
+			Friends: node.friends,
+			Deleted: node.deleted,
+		}
+		if node.deleted {
+			snap.Nodes[i].Vector = node.vec
+		}
+	}
+	return snap
+}
+
+// restore loads a saved graph, taking live vectors from embeddings. It
+// reports false when the graph does not match the embeddings, in which case
+// the index must be rebuilt.
+func (h *hnswIndex) restore(snap *indexSnapshot, embeddings map[string][]float64) bool {
+	n := int32(len(snap.Nodes))
+	if n == 0 || snap.Entry < 0 || snap.Entry >= n {
+		return false
+	}
+	nodes := make([]*hnswNode, n)
+	byID := make(map[string]int32, len(embeddings))
+	for i, ns := range snap.Nodes {
+		vec := ns.Vector
+		if !ns.Deleted {
+			vec = embeddings[ns.ID]
+			if _, dup := byID[ns.ID]; dup {
+				return false
+			}
+			byID[ns.ID] = int32(i)
+		}
+		if len(vec) != snap.Dim || len(ns.Friends) != ns.Level+1 {
+			return false
+		}
+		for _, friends := range ns.Friends {
+			for _, f := range friends {
+				if f < 0 || f >= n {
+					return false
+				}
+			}
+		}
+		nodes[i] = &hnswNode{
+			id:      ns.ID,
+			vec:     vec,
+			norm:    vectorNorm(vec),
+			level:   ns.Level,
+			friends: ns.Friends,
+			deleted: ns.Deleted,
+		}
+	}
+	if nodes[snap.Entry].level != snap.MaxLevel {
+		return false
+	}
+	for _, node := range nodes {
+		for level, friends := range node.friends {
+			for _, f := range friends {
+				if nodes[f].level < level {
+					return false
+				}
+			}
+		}
+	}
+	for id, vec := range embeddings {
+		if _, ok := byID[id]; !ok && len(vec) == snap.Dim {
+			return false
+		}
+	}
+	h.dim = snap.Dim
+	h.nodes = nodes
+	h.byID = byID
+	h.entry = snap.Entry
+	h.maxLevel = snap.MaxLevel
+	h.deleted = snap.Deleted
+	return true
+}
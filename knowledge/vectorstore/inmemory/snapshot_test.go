@@ -0,0 +1,157 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+)
+
+func TestSnapshot_RoundTripKeepsDocumentsAndGraph(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "kb", "store.snapshot")
+	vs := New(WithHNSWIndex(&HNSWIndexParams{M: 8}), WithSnapshotPath(path))
+	fillStores(t, 300, 8, vs)
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, vs.Add(ctx, &document.Document{
+		ID:        "typed",
+		Name:      "typed",
+		Content:   "metadata types survive",
+		CreatedAt: createdAt,
+		Metadata: map[string]any{
+			"int":    3,
+			"float":  1.5,
+			"tags":   []string{"a", "b"},
+			"nested": map[string]any{"k": []any{"v", 1}},
+			"when":   createdAt,
+		},
+	}, []float64{1, 2, 3, 4, 5, 6, 7, 8}))
+	require.NoError(t, vs.Delete(ctx, "doc-3"))
+
+	rng := rand.New(rand.NewSource(3))
+	var queries []*vectorstore.SearchQuery
+	for i := 0; i < 10; i++ {
+		queries = append(queries, &vectorstore.SearchQuery{Vector: randomVector(rng, 8), Limit: 5})
+	}
+	var want [][]string
+	for _, q := range queries {
+		want = append(want, resultIDs(t, vs, q))
+	}
+	nodes, deleted := len(vs.index.nodes), vs.index.deleted
+	require.NoError(t, vs.Close())
+
+	loaded := New(WithHNSWIndex(&HNSWIndexParams{M: 8}), WithSnapshotPath(path))
+	count, err := loaded.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 300, count)
+	assert.Equal(t, nodes, len(loaded.index.nodes), "the saved graph is reused")
+	assert.Equal(t, deleted, loaded.index.deleted)
+	for i, q := range queries {
+		assert.Equal(t, want[i], resultIDs(t, loaded, q))
+	}
+
+	doc, emb, err := loaded.Get(ctx, "typed")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3, 4, 5, 6, 7, 8}, emb)
+	assert.Equal(t, 3, doc.Metadata["int"])
+	assert.Equal(t, []string{"a", "b"}, doc.Metadata["tags"])
+	assert.Equal(t, map[string]any{"k": []any{"v", 1}}, doc.Metadata["nested"])
+	assert.True(t, createdAt.Equal(doc.CreatedAt))
+	result, err := loaded.Search(ctx, &vectorstore.SearchQuery{
+		SearchMode: vectorstore.SearchModeFilter,
+		Filter:     &vectorstore.SearchFilter{Metadata: map[string]any{"int": 3}},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1, "metadata keeps its Go types for filters")
+
+	// The loaded index keeps accepting updates.
+	require.NoError(t, loaded.Add(ctx, &document.Document{ID: "after", Content: "c"}, []float64{-1, -1, -1, -1, -1, -1, -1, -1}))
+	assert.Equal(t, "after", resultIDs(t, loaded, &vectorstore.SearchQuery{Vector: []float64{-1, -1, -1, -1, -1, -1, -1, -1}})[0])
+}
+
+func TestSnapshot_IndexRebuiltWhenParamsDiffer(t *testing.T) {
+	ctx := context.Background()
+	exact := New()
+	fillStores(t, 200, 8, exact)
+	var buf bytes.Buffer
+	require.NoError(t, exact.Save(&buf))
+
+	indexed := New(WithHNSWIndex(nil))
+	require.NoError(t, indexed.Load(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, 200, indexed.index.len())
+
+	buf.Reset()
+	require.NoError(t, indexed.Save(&buf))
+	other := New(WithHNSWIndex(&HNSWIndexParams{M: 4}))
+	require.NoError(t, other.Load(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, 4, other.index.params.M)
+	assert.Equal(t, 200, other.index.len())
+
+	// Without an index the graph is ignored.
+	plain := New()
+	require.NoError(t, plain.Load(bytes.NewReader(buf.Bytes())))
+	assert.Nil(t, plain.index)
+	q := &vectorstore.SearchQuery{Vector: []float64{1, 0, 0, 0, 0, 0, 0, 0}, Limit: 3}
+	assert.Equal(t, resultIDs(t, exact, q), resultIDs(t, plain, q))
+	count, err := plain.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 200, count)
+}
+
+func TestSnapshot_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing snapshot starts empty; Close creates it.
+	path := filepath.Join(dir, "missing.snapshot")
+	vs := New(WithSnapshotPath(path))
+	assert.ErrorIs(t, vs.LoadFile(path), os.ErrNotExist)
+	require.NoError(t, vs.Add(context.Background(), &document.Document{ID: "a", Content: "c"}, []float64{1}))
+	require.NoError(t, vs.Close())
+	require.NoError(t, vs.Close(), "closing twice does not overwrite the snapshot")
+	assert.Len(t, New(WithSnapshotPath(path)).documents, 1)
+	assert.ErrorIs(t, vs.Save(&bytes.Buffer{}), errStoreClosed)
+
+	// A corrupt snapshot is reported and leaves the store empty.
+	corrupt := filepath.Join(dir, "corrupt.snapshot")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not a snapshot"), 0o600))
+	assert.Empty(t, New(WithSnapshotPath(corrupt)).documents)
+	assert.Error(t, New().LoadFile(corrupt))
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&snapshot{Version: 99}))
+	assert.EqualError(t, New().Load(&buf), "inmemory: unsupported snapshot version 99")
+
+	// Unregistered metadata types cannot be encoded.
+	type custom struct{ A int }
+	vs = New()
+	require.NoError(t, vs.Add(context.Background(), &document.Document{
+		ID: "a", Content: "c", Metadata: map[string]any{"x": custom{1}},
+	}, []float64{1}))
+	err := vs.SaveFile(filepath.Join(dir, "custom.snapshot"))
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, os.ErrNotExist))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.NotContains(t, e.Name(), ".tmp-", "temporary files are removed")
+	}
+}
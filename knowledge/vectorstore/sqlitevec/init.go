@@ -39,9 +39,38 @@ func (s *Store) initDB(ctx context.Context) error {
 		}
 	}
 
+	// Create the keyword index table.
+	for _, stmt := range s.buildCreateTermTableSQL() {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create term table %s: %w", s.opts.termTableName, err)
+		}
+	}
+
 	return nil
 }
 
+// buildCreateTermTableSQL returns the SQL statements for creating the
+// keyword index table. Each document has one row per distinct term holding
+// the term frequency, plus a row with an empty term holding the document
+// length; the tokenizer never produces empty terms.
+func (s *Store) buildCreateTermTableSQL() []string {
+	termTable := s.opts.termTableName
+
+	createTable := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+  term TEXT NOT NULL,
+  doc_id TEXT NOT NULL,
+  tf INTEGER NOT NULL,
+  PRIMARY KEY (term, doc_id)
+);`, termTable)
+
+	idxDocID := fmt.Sprintf(
+		`CREATE INDEX IF NOT EXISTS %s__doc_id ON %s(doc_id);`,
+		termTable, termTable,
+	)
+
+	return []string{createTable, idxDocID}
+}
+
 // buildCreateVecTableSQL returns the CREATE VIRTUAL TABLE statement for the
 // vec0 main table.
 func (s *Store) buildCreateVecTableSQL() string {
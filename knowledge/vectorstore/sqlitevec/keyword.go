@@ -0,0 +1,441 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package sqlitevec
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/internal/knowledge/bm25"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+)
+
+// docLengthTerm is the term of the row holding a document's length.
+const docLengthTerm = ""
+
+// insertTermRows indexes the content of a document for keyword search.
+func (s *Store) insertTermRows(ctx context.Context, tx *sql.Tx, docID, content string) error {
+	tf, length := bm25.TermFrequencies(s.opts.tokenize, content)
+	insertSQL := fmt.Sprintf(`INSERT INTO %s (term, doc_id, tf) VALUES (?, ?, ?)`, s.opts.termTableName)
+	stmt, err := tx.PrepareContext(ctx, insertSQL)
+	if err != nil {
+		return fmt.Errorf("sqlitevec prepare term insert: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, docLengthTerm, docID, length); err != nil {
+		return fmt.Errorf("sqlitevec insert document length: %w", err)
+	}
+	for term, n := range tf {
+		if term == docLengthTerm {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, term, docID, n); err != nil {
+			return fmt.Errorf("sqlitevec insert term %q: %w", term, err)
+		}
+	}
+	return nil
+}
+
+// deleteTermRows removes a document from the keyword index.
+func (s *Store) deleteTermRows(ctx context.Context, tx *sql.Tx, docID string) error {
+	deleteSQL := fmt.Sprintf(`DELETE FROM %s WHERE doc_id = ?`, s.opts.termTableName)
+	if _, err := tx.ExecContext(ctx, deleteSQL, docID); err != nil {
+		return fmt.Errorf("sqlitevec delete terms: %w", err)
+	}
+	return nil
+}
+
+// RebuildKeywordIndex re-tokenizes the content of every document. It is
+// needed for documents stored before keyword search was available, or after
+// changing the tokenizer.
+func (s *Store) RebuildKeywordIndex(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, content FROM %s`, s.opts.tableName))
+	if err != nil {
+		return fmt.Errorf("sqlitevec rebuild keyword index: %w", err)
+	}
+	type contentRow struct {
+		id      string
+		content sql.NullString
+	}
+	var docs []contentRow
+	for rows.Next() {
+		var item contentRow
+		if err := rows.Scan(&item.id, &item.content); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("sqlitevec rebuild keyword index scan: %w", err)
+		}
+		docs = append(docs, item)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("sqlitevec rebuild keyword index iterate: %w", err)
+	}
+	_ = rows.Close()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlitevec rebuild keyword index: begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, s.opts.termTableName)); err != nil {
+		return fmt.Errorf("sqlitevec rebuild keyword index: clear: %w", err)
+	}
+	for _, doc := range docs {
+		if err := s.insertTermRows(ctx, tx, doc.id, doc.content.String); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// searchByKeyword ranks documents by BM25 over their content. Scores are
+// normalized into [0, 1) so MinScore applies as for vector search.
+func (s *Store) searchByKeyword(ctx context.Context, query *vectorstore.SearchQuery) (*vectorstore.SearchResult, error) {
+	if strings.TrimSpace(query.Query) == "" {
+		return nil, errors.New("sqlitevec: query text cannot be empty for keyword search")
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = s.opts.maxResults
+	}
+
+	hits, err := s.keywordHits(ctx, query.Query, query.Filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, hit := range hits {
+		if bm25.Normalize(hit.Score) < query.MinScore {
+			break
+		}
+		ids = append(ids, hit.ID)
+	}
+	docs, err := s.loadScoredDocuments(ctx, ids, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*vectorstore.ScoredDocument
+	for _, hit := range hits[:len(ids)] {
+		sd, ok := docs[hit.ID]
+		if !ok {
+			continue
+		}
+		sd.Score = bm25.Normalize(hit.Score)
+		results = append(results, sd)
+	}
+	return &vectorstore.SearchResult{Results: results}, nil
+}
+
+// searchByHybrid fuses vector similarity with BM25 keyword relevance. The
+// dense and sparse parts of each score are reported in the document
+// metadata.
+func (s *Store) searchByHybrid(ctx context.Context, query *vectorstore.SearchQuery) (*vectorstore.SearchResult, error) {
+	if len(query.Vector) == 0 {
+		return nil, errors.New("sqlitevec: query vector cannot be empty for hybrid search")
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = s.opts.maxResults
+	}
+	candidates := limit * s.opts.rrfParams.CandidateRatio
+
+	denseQuery := *query
+	denseQuery.Limit = candidates
+	denseQuery.MinScore = math.Inf(-1)
+	denseResult, err := s.searchByVector(ctx, &denseQuery)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]*vectorstore.ScoredDocument, len(denseResult.Results))
+	dense := make([]bm25.Hit, 0, len(denseResult.Results))
+	for _, r := range denseResult.Results {
+		docs[r.Document.ID] = r
+		dense = append(dense, bm25.Hit{ID: r.Document.ID, Score: r.Score})
+	}
+
+	var fused []bm25.Fused
+	if s.opts.fusionMode == HybridFusionRRF {
+		sparse, err := s.keywordHits(ctx, query.Query, query.Filter, candidates)
+		if err != nil {
+			return nil, err
+		}
+		fused = bm25.FuseRRF(dense, sparse, s.opts.rrfParams.K)
+	} else {
+		// Every keyword match is a candidate, and its exact similarity is
+		// filled in, so weighted scores do not depend on candidate cuts.
+		sparse, err := s.keywordHits(ctx, query.Query, query.Filter, 0)
+		if err != nil {
+			return nil, err
+		}
+		var missing []string
+		for i, h := range sparse {
+			if _, ok := docs[h.ID]; !ok {
+				missing = append(missing, h.ID)
+			}
+			sparse[i].Score = bm25.Normalize(h.Score)
+		}
+		blob, err := s.serializeEmbedding(query.Vector)
+		if err != nil {
+			return nil, fmt.Errorf("sqlitevec search: serialize embedding: %w", err)
+		}
+		extra, err := s.loadScoredDocuments(ctx, missing, blob)
+		if err != nil {
+			return nil, err
+		}
+		for id, sd := range extra {
+			docs[id] = sd
+			dense = append(dense, bm25.Hit{ID: id, Score: sd.Score})
+		}
+		fused = bm25.FuseWeighted(dense, sparse, s.opts.vectorWeight, s.opts.textWeight)
+	}
+
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	var missing []string
+	for _, f := range fused {
+		if _, ok := docs[f.ID]; !ok {
+			missing = append(missing, f.ID)
+		}
+	}
+	extra, err := s.loadScoredDocuments(ctx, missing, nil)
+	if err != nil {
+		return nil, err
+	}
+	for id, sd := range extra {
+		docs[id] = sd
+	}
+
+	var results []*vectorstore.ScoredDocument
+	for _, f := range fused {
+		// RRF scores are rank based and not comparable with MinScore.
+		if s.opts.fusionMode != HybridFusionRRF && f.Score < query.MinScore {
+			break
+		}
+		sd, ok := docs[f.ID]
+		if !ok {
+			continue
+		}
+		if sd.Document.Metadata == nil {
+			sd.Document.Metadata = make(map[string]any)
+		}
+		sd.Document.Metadata[source.MetadataDenseScore] = f.Dense
+		sd.Document.Metadata[source.MetadataSparseScore] = f.Sparse
+		sd.Score = f.Score
+		results = append(results, sd)
+	}
+	return &vectorstore.SearchResult{Results: results}, nil
+}
+
+// keywordHits returns the raw BM25 scores of documents matching the filter
+// and at least one query term, best first. Document frequencies count every
+// document. A limit <= 0 returns every match.
+func (s *Store) keywordHits(
+	ctx context.Context,
+	text string,
+	filter *vectorstore.SearchFilter,
+	limit int,
+) ([]bm25.Hit, error) {
+	terms := bm25.QueryTerms(s.opts.tokenize, text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var (
+		n         int
+		avgDocLen float64
+	)
+	statsSQL := fmt.Sprintf(`SELECT COUNT(*), COALESCE(AVG(tf), 0) FROM %s WHERE term = ?`, s.opts.termTableName)
+	if err := s.db.QueryRowContext(ctx, statsSQL, docLengthTerm).Scan(&n, &avgDocLen); err != nil {
+		return nil, fmt.Errorf("sqlitevec keyword search: stats: %w", err)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(terms)), ",")
+	termParams := make([]any, len(terms))
+	for i, term := range terms {
+		termParams[i] = term
+	}
+
+	dfSQL := fmt.Sprintf(`SELECT term, COUNT(*) FROM %s WHERE term IN (%s) GROUP BY term`,
+		s.opts.termTableName, placeholders)
+	rows, err := s.db.QueryContext(ctx, dfSQL, termParams...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlitevec keyword search: document frequency: %w", err)
+	}
+	idf := make(map[string]float64, len(terms))
+	for rows.Next() {
+		var (
+			term string
+			df   int
+		)
+		if err := rows.Scan(&term, &df); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("sqlitevec keyword search: scan document frequency: %w", err)
+		}
+		idf[term] = bm25.IDF(n, df)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("sqlitevec keyword search: iterate document frequency: %w", err)
+	}
+	_ = rows.Close()
+	if len(idf) == 0 {
+		return nil, nil
+	}
+
+	postingsSQL := fmt.Sprintf(`SELECT t.doc_id, t.term, t.tf, l.tf
+	FROM %s t JOIN %s l ON l.doc_id = t.doc_id AND l.term = ?
+	WHERE t.term IN (%s)`, s.opts.termTableName, s.opts.termTableName, placeholders)
+	params := append([]any{docLengthTerm}, termParams...)
+	if filter != nil {
+		filterSQL, filterParams, err := s.filterB.buildFilterClauses(
+			filter.IDs,
+			filter.Metadata,
+			filter.FilterCondition,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("sqlitevec keyword search: build filter: %w", err)
+		}
+		if filterSQL != "" {
+			postingsSQL += fmt.Sprintf(` AND t.doc_id IN (SELECT v.id FROM %s v WHERE %s)`,
+				s.opts.tableName, filterSQL)
+			params = append(params, filterParams...)
+		}
+	}
+
+	rows, err = s.db.QueryContext(ctx, postingsSQL, params...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlitevec keyword search: %w", err)
+	}
+	defer rows.Close()
+
+	bm25Params := bm25.DefaultParams()
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var (
+			docID, term string
+			tf, docLen  int
+		)
+		if err := rows.Scan(&docID, &term, &tf, &docLen); err != nil {
+			return nil, fmt.Errorf("sqlitevec keyword search: scan: %w", err)
+		}
+		scores[docID] += bm25Params.TermScore(idf[term], tf, docLen, avgDocLen)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlitevec keyword search: iterate: %w", err)
+	}
+
+	hits := make([]bm25.Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, bm25.Hit{ID: id, Score: score})
+	}
+	bm25.SortHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// loadScoredDocuments loads documents by ID. When vectorBlob is set, each
+// score is the cosine similarity with that vector as in vector search;
+// otherwise scores are 0.
+func (s *Store) loadScoredDocuments(
+	ctx context.Context,
+	ids []string,
+	vectorBlob []byte,
+) (map[string]*vectorstore.ScoredDocument, error) {
+	out := make(map[string]*vectorstore.ScoredDocument, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	distanceExpr := "0"
+	var params []any
+	if vectorBlob != nil {
+		distanceExpr = "vec_distance_cosine(v.embedding, " + sqlVectorFromBlob + ")"
+		params = append(params, vectorBlob)
+	}
+	for _, id := range ids {
+		params = append(params, id)
+	}
+	selectSQL := fmt.Sprintf(`SELECT
+	v.id, v.name, v.content, v.metadata, v.created_at, v.updated_at, %s
+	FROM %s v
+	WHERE v.id IN (%s)`, distanceExpr, s.opts.tableName,
+		strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","))
+
+	rows, err := s.db.QueryContext(ctx, selectSQL, params...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlitevec load documents: %w", err)
+	}
+	defer rows.Close()
+
+	type documentRow struct {
+		id           string
+		name         sql.NullString
+		content      sql.NullString
+		metadataJSON sql.NullString
+		createdAtNs  int64
+		updatedAtNs  int64
+		distance     float64
+	}
+
+	var scanned []documentRow
+	for rows.Next() {
+		var item documentRow
+		if err := rows.Scan(
+			&item.id,
+			&item.name,
+			&item.content,
+			&item.metadataJSON,
+			&item.createdAtNs,
+			&item.updatedAtNs,
+			&item.distance,
+		); err != nil {
+			return nil, fmt.Errorf("sqlitevec scan document row: %w", err)
+		}
+		scanned = append(scanned, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlitevec load documents iterate: %w", err)
+	}
+	_ = rows.Close()
+
+	for _, item := range scanned {
+		sd, err := s.buildScoredDocument(
+			ctx,
+			item.id,
+			item.name,
+			item.content,
+			item.metadataJSON,
+			item.createdAtNs,
+			item.updatedAtNs,
+			0,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if vectorBlob != nil {
+			sd.Score = 1.0 - item.distance/2.0
+		}
+		out[item.id] = sd
+	}
+	return out, nil
+}
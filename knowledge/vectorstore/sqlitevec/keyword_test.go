@@ -0,0 +1,211 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package sqlitevec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/internal/knowledge/bm25"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+)
+
+func newKeywordTestStore(t *testing.T, opts ...Option) *Store {
+	t.Helper()
+	store, err := New(append([]Option{
+		WithDSN(":memory:"),
+		WithIndexDimension(testDimension),
+	}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	docs := []struct {
+		id, content, lang string
+		vec               []float64
+	}{
+		{"go", "Go is a statically typed language with goroutines", "en", testEmbedding(1, 0, 0, 0)},
+		{"rust", "Rust is a systems language with ownership", "en", testEmbedding(0.9, 0.1, 0, 0)},
+		{"vector", "向量数据库支持混合检索和关键词检索", "zh", testEmbedding(0, 1, 0, 0)},
+		{"db", "关系型数据库使用 SQL 查询", "zh", testEmbedding(0, 0.9, 0.1, 0)},
+		{"cooking", "How to cook rice", "en", testEmbedding(0, 0, 1, 0)},
+	}
+	for _, d := range docs {
+		require.NoError(t, store.Add(context.Background(), &document.Document{
+			ID: d.id, Content: d.content, Metadata: map[string]any{"lang": d.lang},
+		}, d.vec))
+	}
+	return store
+}
+
+func searchIDs(t *testing.T, store *Store, query *vectorstore.SearchQuery) []string {
+	t.Helper()
+	result, err := store.Search(context.Background(), query)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(result.Results))
+	for _, r := range result.Results {
+		ids = append(ids, r.Document.ID)
+	}
+	return ids
+}
+
+func TestSearchModeKeyword(t *testing.T) {
+	store := newKeywordTestStore(t)
+	ctx := context.Background()
+	keyword := func(q string, filter *vectorstore.SearchFilter, minScore float64) []string {
+		return searchIDs(t, store, &vectorstore.SearchQuery{
+			Query: q, SearchMode: vectorstore.SearchModeKeyword, Filter: filter, MinScore: minScore,
+		})
+	}
+
+	assert.Equal(t, []string{"go"}, keyword("goroutines", nil, 0))
+	assert.Equal(t, []string{"vector"}, keyword("混合检索", nil, 0))
+	assert.Equal(t, []string{"db", "vector"}, keyword("SQL 数据库", nil, 0))
+	assert.Equal(t, []string{"vector"}, keyword("数据库", &vectorstore.SearchFilter{IDs: []string{"vector"}}, 0))
+	assert.ElementsMatch(t, []string{"go", "rust"}, keyword("language", &vectorstore.SearchFilter{
+		Metadata: map[string]any{"lang": "en"},
+	}, 0))
+	assert.Empty(t, keyword("language", nil, 0.99))
+	assert.Empty(t, keyword("missing", nil, 0))
+
+	result, err := store.Search(ctx, &vectorstore.SearchQuery{
+		Query: "rice", SearchMode: vectorstore.SearchModeKeyword,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	assert.Greater(t, result.Results[0].Score, 0.0)
+	assert.Less(t, result.Results[0].Score, 1.0)
+	assert.Equal(t, "en", result.Results[0].Document.Metadata["lang"])
+
+	// Deleted and updated documents leave the keyword index.
+	require.NoError(t, store.Delete(ctx, "go"))
+	assert.Empty(t, keyword("goroutines", nil, 0))
+	require.NoError(t, store.Update(ctx, &document.Document{ID: "rust", Content: "borrow checker"}, testEmbedding(1)))
+	assert.Equal(t, []string{"rust"}, keyword("borrow", nil, 0))
+	assert.Empty(t, keyword("ownership", nil, 0))
+	require.NoError(t, store.DeleteByFilter(ctx, vectorstore.WithDeleteDocumentIDs([]string{"rust"})))
+	assert.Empty(t, keyword("borrow", nil, 0))
+	require.NoError(t, store.DeleteByFilter(ctx, vectorstore.WithDeleteAll(true)))
+	assert.Empty(t, keyword("rice", nil, 0))
+}
+
+func TestSearchModeKeyword_MatchesInMemoryIndex(t *testing.T) {
+	store := newKeywordTestStore(t)
+	index := bm25.NewIndex(bm25.DefaultParams(), nil)
+	ids, err := store.collectFilteredIDs(context.Background(), nil, nil, nil)
+	require.NoError(t, err)
+	for _, id := range ids {
+		doc, _, err := store.Get(context.Background(), id)
+		require.NoError(t, err)
+		index.Add(id, doc.Content)
+	}
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("filler-%02d", i)
+		content := strings.Repeat("language ", i%4+1) + "filler text"
+		require.NoError(t, store.Add(context.Background(), &document.Document{
+			ID: id, Content: content,
+		}, testEmbedding(0, 0, 0, 1)))
+		index.Add(id, content)
+	}
+
+	result, err := store.Search(context.Background(), &vectorstore.SearchQuery{
+		Query: "typed language 数据库", SearchMode: vectorstore.SearchModeKeyword, Limit: 5,
+	})
+	require.NoError(t, err)
+	want := index.Search("typed language 数据库", 5, nil)
+	require.Len(t, result.Results, len(want))
+	for i, hit := range want {
+		assert.Equal(t, hit.ID, result.Results[i].Document.ID)
+		assert.InDelta(t, bm25.Normalize(hit.Score), result.Results[i].Score, 1e-9)
+	}
+}
+
+func TestSearchModeHybrid_Weighted(t *testing.T) {
+	store := newKeywordTestStore(t)
+	query := &vectorstore.SearchQuery{
+		SearchMode: vectorstore.SearchModeHybrid,
+		Query:      "ownership",
+		Vector:     testEmbedding(1, 0, 0, 0),
+		Limit:      3,
+	}
+	result, err := store.Search(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 3)
+	assert.Equal(t, "rust", result.Results[0].Document.ID, "the keyword match outranks the closer vector")
+	assert.Equal(t, "go", result.Results[1].Document.ID)
+
+	top := result.Results[0]
+	dense := top.Document.Metadata[source.MetadataDenseScore].(float64)
+	sparse := top.Document.Metadata[source.MetadataSparseScore].(float64)
+	assert.Greater(t, sparse, 0.0)
+	assert.InDelta(t, top.Score, dense+sparse, 1e-9)
+	assert.Equal(t, "en", top.Document.Metadata["lang"])
+
+	// MinScore applies to the fused score.
+	query.MinScore = top.Score
+	assert.Equal(t, []string{"rust"}, searchIDs(t, store, query))
+
+	// Keyword matches outside the vector candidates get their similarity
+	// filled in.
+	textOnly := newKeywordTestStore(t, WithHybridSearchWeights(0, 1))
+	ids := searchIDs(t, textOnly, &vectorstore.SearchQuery{
+		SearchMode: vectorstore.SearchModeHybrid,
+		Query:      "数据库",
+		Vector:     testEmbedding(0, 0, 1, 0),
+		Limit:      1,
+	})
+	assert.Equal(t, []string{"db"}, ids)
+}
+
+func TestSearchModeHybrid_RRF(t *testing.T) {
+	store := newKeywordTestStore(t,
+		WithHybridFusionMode(HybridFusionRRF),
+		WithRRFParams(&RRFParams{K: 10, CandidateRatio: 2}),
+	)
+	result, err := store.Search(context.Background(), &vectorstore.SearchQuery{
+		SearchMode: vectorstore.SearchModeHybrid,
+		Query:      "ownership",
+		Vector:     testEmbedding(1, 0, 0, 0),
+		Limit:      2,
+		MinScore:   0.9,
+		Filter:     &vectorstore.SearchFilter{Metadata: map[string]any{"lang": "en"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 2, "MinScore does not apply to rank based scores")
+	assert.Equal(t, "rust", result.Results[0].Document.ID)
+	assert.InDelta(t, 1.0/12+1.0/11, result.Results[0].Score, 1e-12)
+	assert.Equal(t, "go", result.Results[1].Document.ID)
+	assert.InDelta(t, 1.0/11, result.Results[1].Score, 1e-12)
+}
+
+func TestRebuildKeywordIndex(t *testing.T) {
+	store := newKeywordTestStore(t)
+	ctx := context.Background()
+
+	_, err := store.db.ExecContext(ctx, "DELETE FROM "+store.opts.termTableName)
+	require.NoError(t, err)
+	assert.Empty(t, searchIDs(t, store, &vectorstore.SearchQuery{
+		Query: "混合检索", SearchMode: vectorstore.SearchModeKeyword,
+	}))
+
+	store.opts.tokenize = strings.Fields
+	require.NoError(t, store.RebuildKeywordIndex(ctx))
+	assert.Equal(t, []string{"db"}, searchIDs(t, store, &vectorstore.SearchQuery{
+		Query: "SQL", SearchMode: vectorstore.SearchModeKeyword,
+	}))
+	assert.Empty(t, searchIDs(t, store, &vectorstore.SearchQuery{
+		Query: "sql", SearchMode: vectorstore.SearchModeKeyword,
+	}), "the custom tokenizer does not lower-case")
+}
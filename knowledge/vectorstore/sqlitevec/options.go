@@ -13,6 +13,7 @@ package sqlitevec
 import (
 	"fmt"
 
+	"trpc.group/trpc-go/trpc-agent-go/internal/knowledge/bm25"
 	"trpc.group/trpc-go/trpc-agent-go/internal/session/sqldb"
 )
 
@@ -21,18 +22,54 @@ const (
 	defaultDSN               = ":memory:"
 	defaultTableName         = "knowledge_documents"
 	defaultMetadataTableName = "knowledge_document_meta"
+	defaultTermTableName     = "knowledge_document_terms"
 	defaultIndexDimension    = 1536
 	defaultMaxResults        = 10
+	defaultVectorWeight      = 0.7
+	defaultTextWeight        = 0.3
+	defaultRRFK              = 60
+	defaultCandidateRatio    = 3
 )
 
+// HybridFusionMode represents the fusion mode for hybrid search.
+type HybridFusionMode int
+
+const (
+	// HybridFusionWeighted uses weighted fusion (default).
+	// Formula: score = vector_score * vectorWeight + text_score * textWeight
+	HybridFusionWeighted HybridFusionMode = iota
+
+	// HybridFusionRRF uses Reciprocal Rank Fusion.
+	// Formula: score = sum(1 / (k + rank_i)) for each ranking list
+	HybridFusionRRF
+)
+
+// RRFParams contains parameters for Reciprocal Rank Fusion.
+type RRFParams struct {
+	// K is the RRF constant (default: 60).
+	K int
+
+	// CandidateRatio controls how many candidates to take from each ranking.
+	// Hybrid search takes (limit * CandidateRatio) vector candidates, and
+	// RRF also takes that many keyword candidates. Default: 3.
+	CandidateRatio int
+}
+
 type options struct {
 	dsn               string
 	driverName        string
 	tableName         string
 	metadataTableName string
+	termTableName     string
 	indexDimension    int
 	maxResults        int
 	skipDBInit        bool
+
+	tokenize     bm25.Tokenizer
+	vectorWeight float64
+	textWeight   float64
+	fusionMode   HybridFusionMode
+	rrfParams    RRFParams
 }
 
 var defaultOptions = options{
@@ -40,8 +77,14 @@ var defaultOptions = options{
 	driverName:        defaultDriverName,
 	tableName:         defaultTableName,
 	metadataTableName: defaultMetadataTableName,
+	termTableName:     defaultTermTableName,
 	indexDimension:    defaultIndexDimension,
 	maxResults:        defaultMaxResults,
+	tokenize:          bm25.Tokenize,
+	vectorWeight:      defaultVectorWeight,
+	textWeight:        defaultTextWeight,
+	fusionMode:        HybridFusionWeighted,
+	rrfParams:         RRFParams{K: defaultRRFK, CandidateRatio: defaultCandidateRatio},
 }
 
 // Option configures the sqlitevec vector store.
@@ -89,6 +132,16 @@ func WithMetadataTableName(tableName string) Option {
 	}
 }
 
+// WithTermTableName sets the keyword index table name.
+func WithTermTableName(tableName string) Option {
+	return func(o *options) {
+		if err := sqldb.ValidateTableName(tableName); err != nil {
+			panic(fmt.Sprintf("invalid term table name: %v", err))
+		}
+		o.termTableName = tableName
+	}
+}
+
 // WithIndexDimension sets the embedding dimension.
 func WithIndexDimension(dimension int) Option {
 	return func(o *options) {
@@ -113,3 +166,53 @@ func WithSkipDBInit(skip bool) Option {
 		o.skipDBInit = skip
 	}
 }
+
+// WithTokenizer sets the tokenizer of the keyword index, e.g. a dictionary
+// based Chinese word segmenter. The default lower-cases words and splits
+// Chinese, Japanese and Korean text into characters and character bigrams.
+// Changing it requires RebuildKeywordIndex for existing documents.
+func WithTokenizer(tokenize func(text string) []string) Option {
+	return func(o *options) {
+		if tokenize != nil {
+			o.tokenize = tokenize
+		}
+	}
+}
+
+// WithHybridSearchWeights sets the weights for weighted hybrid search.
+// Weights are normalized to sum to 1.0; invalid weights keep the defaults
+// of 0.7 for vector similarity and 0.3 for text relevance.
+func WithHybridSearchWeights(vectorWeight, textWeight float64) Option {
+	return func(o *options) {
+		total := vectorWeight + textWeight
+		if vectorWeight < 0 || textWeight < 0 || total <= 0 {
+			return
+		}
+		o.vectorWeight = vectorWeight / total
+		o.textWeight = textWeight / total
+	}
+}
+
+// WithHybridFusionMode sets the fusion mode for hybrid search.
+// Default is HybridFusionWeighted.
+func WithHybridFusionMode(mode HybridFusionMode) Option {
+	return func(o *options) {
+		o.fusionMode = mode
+	}
+}
+
+// WithRRFParams sets the parameters for Reciprocal Rank Fusion.
+// Values <= 0 are ignored (defaults are kept).
+func WithRRFParams(params *RRFParams) Option {
+	return func(o *options) {
+		if params == nil {
+			return
+		}
+		if params.K > 0 {
+			o.rrfParams.K = params.K
+		}
+		if params.CandidateRatio > 0 {
+			o.rrfParams.CandidateRatio = params.CandidateRatio
+		}
+	}
+}
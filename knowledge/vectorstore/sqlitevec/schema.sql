@@ -4,10 +4,14 @@
 -- 1. The vec0 main table intentionally stays close to pgvector's document schema.
 -- 2. Extended document fields and filterable metadata are expanded into a separate
 --    metadata index table maintained by application-layer transactions.
+-- 3. Content is tokenized in Go into a term table for BM25 keyword search. Each
+--    document has one row per distinct term with its frequency, plus a row with
+--    an empty term holding the document length.
 --
 -- Replace:
 --   {{VEC_TABLE_NAME}}  with the vec0 table name
 --   {{META_TABLE_NAME}} with the metadata index table name
+--   {{TERM_TABLE_NAME}} with the keyword index table name
 --   {{DIMENSION}}       with the embedding dimension
 
 CREATE VIRTUAL TABLE IF NOT EXISTS {{VEC_TABLE_NAME}} USING vec0(
@@ -46,3 +50,13 @@ ON {{META_TABLE_NAME}}(doc_id);
 
 CREATE INDEX IF NOT EXISTS {{META_TABLE_NAME}}__doc_id__key
 ON {{META_TABLE_NAME}}(doc_id, key);
+
+CREATE TABLE IF NOT EXISTS {{TERM_TABLE_NAME}} (
+  term TEXT NOT NULL,
+  doc_id TEXT NOT NULL,
+  tf INTEGER NOT NULL,
+  PRIMARY KEY (term, doc_id)
+);
+
+CREATE INDEX IF NOT EXISTS {{TERM_TABLE_NAME}}__doc_id
+ON {{TERM_TABLE_NAME}}(doc_id);
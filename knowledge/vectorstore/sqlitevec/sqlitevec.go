@@ -150,6 +150,13 @@ func (s *Store) Add(ctx context.Context, doc *document.Document, embedding []flo
 	if err := s.insertMetadataRows(ctx, tx, doc.ID, storedMetadata); err != nil {
 		return err
 	}
+	// Index content for keyword search.
+	if err := s.deleteTermRows(ctx, tx, doc.ID); err != nil {
+		return err
+	}
+	if err := s.insertTermRows(ctx, tx, doc.ID, doc.Content); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
@@ -292,6 +299,14 @@ func (s *Store) Update(ctx context.Context, doc *document.Document, embedding []
 		return err
 	}
 
+	// Rebuild keyword index rows.
+	if err := s.deleteTermRows(ctx, tx, doc.ID); err != nil {
+		return err
+	}
+	if err := s.insertTermRows(ctx, tx, doc.ID, doc.Content); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -309,10 +324,13 @@ func (s *Store) Delete(ctx context.Context, id string) error {
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	// Delete metadata rows first.
+	// Delete metadata and keyword index rows first.
 	if err := s.deleteMetadataRows(ctx, tx, id); err != nil {
 		return err
 	}
+	if err := s.deleteTermRows(ctx, tx, id); err != nil {
+		return err
+	}
 
 	deleteSQL := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.opts.tableName)
 	res, err := tx.ExecContext(ctx, deleteSQL, id)
@@ -341,9 +359,13 @@ func (s *Store) Search(ctx context.Context, query *vectorstore.SearchQuery) (*ve
 	case vectorstore.SearchModeFilter:
 		return s.searchByFilter(ctx, query)
 	case vectorstore.SearchModeKeyword:
-		return nil, errors.New("sqlitevec: SearchModeKeyword is not supported in v1")
+		return s.searchByKeyword(ctx, query)
 	case vectorstore.SearchModeHybrid:
-		return s.searchByVector(ctx, query)
+		// Without query text there is nothing to match keywords against.
+		if strings.TrimSpace(query.Query) == "" {
+			return s.searchByVector(ctx, query)
+		}
+		return s.searchByHybrid(ctx, query)
 	default:
 		// Default to vector search for backward compatibility.
 		if len(query.Vector) > 0 {
@@ -579,6 +601,9 @@ func (s *Store) DeleteByFilter(ctx context.Context, opts ...vectorstore.DeleteOp
 		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, s.opts.metadataTableName)); err != nil {
 			return fmt.Errorf("sqlitevec delete all metadata: %w", err)
 		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, s.opts.termTableName)); err != nil {
+			return fmt.Errorf("sqlitevec delete all terms: %w", err)
+		}
 		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, s.opts.tableName)); err != nil {
 			return fmt.Errorf("sqlitevec delete all: %w", err)
 		}
@@ -599,6 +624,9 @@ func (s *Store) DeleteByFilter(ctx context.Context, opts ...vectorstore.DeleteOp
 		if err := s.deleteMetadataRows(ctx, tx, id); err != nil {
 			return err
 		}
+		if err := s.deleteTermRows(ctx, tx, id); err != nil {
+			return err
+		}
 		delSQL := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.opts.tableName)
 		if _, err := tx.ExecContext(ctx, delSQL, id); err != nil {
 			return fmt.Errorf("sqlitevec delete by filter id=%s: %w", id, err)
@@ -30,6 +30,7 @@ func TestOptionsHelpers(t *testing.T) {
 	WithDriverName("custom-driver")(&o)
 	WithTableName("custom_docs")(&o)
 	WithMetadataTableName("custom_meta")(&o)
+	WithTermTableName("custom_terms")(&o)
 	WithIndexDimension(256)(&o)
 	WithMaxResults(20)(&o)
 	WithSkipDBInit(true)(&o)
@@ -38,6 +39,7 @@ func TestOptionsHelpers(t *testing.T) {
 	assert.Equal(t, "custom-driver", o.driverName)
 	assert.Equal(t, "custom_docs", o.tableName)
 	assert.Equal(t, "custom_meta", o.metadataTableName)
+	assert.Equal(t, "custom_terms", o.termTableName)
 	assert.Equal(t, 256, o.indexDimension)
 	assert.Equal(t, 20, o.maxResults)
 	assert.True(t, o.skipDBInit)
@@ -53,6 +55,21 @@ func TestOptionsHelpers(t *testing.T) {
 
 	assert.Panics(t, func() { WithTableName("bad-name")(&o) })
 	assert.Panics(t, func() { WithMetadataTableName("bad-name")(&o) })
+	assert.Panics(t, func() { WithTermTableName("bad-name")(&o) })
+
+	WithHybridSearchWeights(3, 1)(&o)
+	assert.InDelta(t, 0.75, o.vectorWeight, 1e-9)
+	assert.InDelta(t, 0.25, o.textWeight, 1e-9)
+	WithHybridSearchWeights(-1, 1)(&o)
+	WithHybridSearchWeights(0, 0)(&o)
+	assert.InDelta(t, 0.75, o.vectorWeight, 1e-9)
+	WithHybridFusionMode(HybridFusionRRF)(&o)
+	assert.Equal(t, HybridFusionRRF, o.fusionMode)
+	WithRRFParams(&RRFParams{K: 10})(&o)
+	WithRRFParams(nil)(&o)
+	assert.Equal(t, RRFParams{K: 10, CandidateRatio: defaultCandidateRatio}, o.rrfParams)
+	WithTokenizer(nil)(&o)
+	assert.NotNil(t, o.tokenize)
 }
 
 func TestNew_InvalidDriverAndSkipInit(t *testing.T) {
@@ -526,4 +543,10 @@ func TestInitDB_ErrorPathsForInvalidTableNames(t *testing.T) {
 	err = store2.initDB(ctx)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "create metadata table")
+
+	store3 := newTestStore(t)
+	store3.opts.termTableName = "bad-terms"
+	err = store3.initDB(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create term table")
 }
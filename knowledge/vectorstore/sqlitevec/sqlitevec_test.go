@@ -420,18 +420,18 @@ func TestSearch_UsesMetadataTableValues(t *testing.T) {
 	assert.Equal(t, "updated", result.Results[0].Document.Metadata["category"])
 }
 
-// ---------- Unsupported modes ----------
+// ---------- Keyword and hybrid modes ----------
 
-func TestSearchModeKeyword_Unsupported(t *testing.T) {
+func TestSearchModeKeyword_EmptyQuery(t *testing.T) {
 	store := newTestStore(t)
 	_, err := store.Search(context.Background(), &vectorstore.SearchQuery{
 		SearchMode: vectorstore.SearchModeKeyword,
 	})
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not supported")
+	assert.Contains(t, err.Error(), "query text cannot be empty")
 }
 
-func TestSearchModeHybrid_Unsupported(t *testing.T) {
+func TestSearchModeHybrid_WithoutQueryText(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
@@ -0,0 +1,629 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+)
+
+// WatchMode is how a SourceWatcher detects file changes.
+type WatchMode string
+
+const (
+	// WatchModeNotify uses filesystem notifications.
+	WatchModeNotify WatchMode = "notify"
+	// WatchModePoll periodically scans the files of the sources.
+	WatchModePoll WatchMode = "poll"
+)
+
+// WatchStatus is a snapshot of the state of a SourceWatcher.
+type WatchStatus struct {
+	// Mode is how the watcher detects changes.
+	Mode WatchMode
+	// Sources holds the state of each watched source.
+	Sources []SourceWatchStatus
+	// Errors lists recent errors that are not tied to a source, such as
+	// filesystem notification failures, oldest first.
+	Errors []WatchError
+}
+
+// SourceWatchStatus is the state of one watched source.
+type SourceWatchStatus struct {
+	// SourceName is the name of the source.
+	SourceName string
+	// Files is the number of files tracked for the source.
+	Files int
+	// LastSync is when changes of the source were last re-indexed. It is zero
+	// until the first change is re-indexed.
+	LastSync time.Time
+	// PendingChanges is the number of changed paths that are not re-indexed
+	// yet, including the ones whose re-indexing failed and will be retried.
+	PendingChanges int
+	// Added, Modified and Removed count the files re-indexed since the
+	// watcher started.
+	Added    int
+	Modified int
+	Removed  int
+	// Errors lists the most recent errors of the source, oldest first.
+	Errors []WatchError
+}
+
+// WatchError is an error that occurred while watching or re-indexing.
+type WatchError struct {
+	Time time.Time
+	Err  error
+}
+
+// SourceWatcher watches the files of knowledge sources and re-indexes only the
+// files that are added, modified or removed. Create it with
+// BuiltinKnowledge.Watch and stop it with Close.
+type SourceWatcher struct {
+	dk       *BuiltinKnowledge
+	cfg      *watchConfig
+	mode     WatchMode
+	notifier *fsnotify.Watcher
+	// watchedDirs is only used by the goroutine that owns the notifier.
+	watchedDirs map[string]struct{}
+
+	mu      sync.Mutex
+	sources []*watchedSource
+	errors  []WatchError
+
+	syncMu    sync.Mutex // serializes re-indexing
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// watchedSource is the state of one watched source. files is written with
+// both syncMu and mu held. polled is only used by the polling goroutine.
+type watchedSource struct {
+	name    string
+	roots   []string
+	files   map[string]watchedFile
+	polled  map[string]watchedFile
+	pending map[string]struct{}
+	status  SourceWatchStatus
+}
+
+// watchedFile is the state of one file, keyed by its absolute path.
+type watchedFile struct {
+	path    string // as returned by ListFiles
+	size    int64
+	modTime time.Time
+}
+
+// Watch starts watching the files of the registered sources that implement
+// source.FileSource, such as source/dir, source/file and local source/repo
+// sources. The current files are taken as the indexed state, so the sources
+// should be loaded first. Changes are debounced and only the changed files are
+// re-indexed with ReloadSourceFiles, which reports through the load progress
+// callback set with WithWatchLoadOptions.
+//
+// Filesystem notifications are used when available, otherwise the files are
+// polled. The watcher stops when ctx is done or Close is called.
+func (dk *BuiltinKnowledge) Watch(ctx context.Context, opts ...WatchOption) (*SourceWatcher, error) {
+	cfg := &watchConfig{
+		debounce:     defaultWatchDebounce,
+		pollInterval: defaultWatchPollInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	w := &SourceWatcher{
+		dk:          dk,
+		cfg:         cfg,
+		mode:        WatchModePoll,
+		watchedDirs: make(map[string]struct{}),
+		done:        make(chan struct{}),
+	}
+	if err := w.initSources(ctx); err != nil {
+		return nil, err
+	}
+
+	if !cfg.forcePolling {
+		if err := w.startNotifier(); err != nil {
+			log.WarnfContext(ctx, "Filesystem notifications unavailable, polling sources every %s: %v",
+				cfg.pollInterval, err)
+		} else {
+			w.mode = WatchModeNotify
+		}
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	go w.run(ctx)
+	log.InfofContext(ctx, "Watching %d source(s) in %s mode", len(w.sources), w.mode)
+	return w, nil
+}
+
+// initSources selects the sources to watch and records their current files.
+func (w *SourceWatcher) initSources(ctx context.Context) error {
+	registered := w.dk.Sources()
+	var selected []source.FileSource
+	if len(w.cfg.sourceNames) == 0 {
+		for _, src := range registered {
+			if fileSrc, ok := src.(source.FileSource); ok {
+				selected = append(selected, fileSrc)
+			}
+		}
+	} else {
+		for _, name := range w.cfg.sourceNames {
+			fileSrc, err := findFileSource(registered, name)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, fileSrc)
+		}
+	}
+
+	for _, fileSrc := range selected {
+		roots, err := fileSrc.WatchPaths(ctx)
+		if err == nil {
+			roots, err = absPaths(roots)
+		}
+		if err != nil {
+			if len(w.cfg.sourceNames) == 0 {
+				log.WarnfContext(ctx, "Not watching source %s: %v", fileSrc.Name(), err)
+				continue
+			}
+			return fmt.Errorf("failed to get watch paths of source %s: %w", fileSrc.Name(), err)
+		}
+		files, err := scanFiles(ctx, fileSrc)
+		if err != nil {
+			return fmt.Errorf("failed to list files of source %s: %w", fileSrc.Name(), err)
+		}
+		w.sources = append(w.sources, &watchedSource{
+			name:    fileSrc.Name(),
+			roots:   roots,
+			files:   files,
+			polled:  files,
+			pending: make(map[string]struct{}),
+			status:  SourceWatchStatus{SourceName: fileSrc.Name()},
+		})
+	}
+	if len(w.sources) == 0 {
+		return fmt.Errorf("no watchable sources")
+	}
+	return nil
+}
+
+// startNotifier sets up filesystem notifications for all watched sources.
+func (w *SourceWatcher) startNotifier() error {
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w.notifier = notifier
+	for _, ws := range w.sources {
+		for _, root := range ws.roots {
+			if err := w.watchRoot(root); err != nil {
+				notifier.Close()
+				w.notifier = nil
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// watchRoot watches a directory tree, or the parent directory of a file so
+// that files replaced by editors and files created later are noticed.
+func (w *SourceWatcher) watchRoot(root string) error {
+	info, err := os.Stat(root)
+	if err == nil && info.IsDir() {
+		return w.watchTree(root)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return w.watchDir(filepath.Dir(root))
+}
+
+// watchTree watches dir and its subdirectories, except .git directories whose
+// churn does not affect the indexed files.
+func (w *SourceWatcher) watchTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return w.watchDir(path)
+	})
+}
+
+func (w *SourceWatcher) watchDir(dir string) error {
+	if _, ok := w.watchedDirs[dir]; ok {
+		return nil
+	}
+	if err := w.notifier.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	w.watchedDirs[dir] = struct{}{}
+	return nil
+}
+
+// run is the event loop of the watcher.
+func (w *SourceWatcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	var (
+		events       <-chan fsnotify.Event
+		notifyErrors <-chan error
+		poll         <-chan time.Time
+		debounce     <-chan time.Time
+		rescanAll    bool
+	)
+	if w.notifier != nil {
+		events, notifyErrors = w.notifier.Events, w.notifier.Errors
+	} else {
+		ticker := time.NewTicker(w.cfg.pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if w.handleEvent(ctx, ev) {
+				debounce = time.After(w.cfg.debounce)
+			}
+		case err, ok := <-notifyErrors:
+			if !ok {
+				notifyErrors = nil
+				continue
+			}
+			// Events may have been dropped, so compare all files.
+			w.recordError(ctx, nil, fmt.Errorf("filesystem notification: %w", err))
+			rescanAll = true
+			debounce = time.After(w.cfg.debounce)
+		case <-poll:
+			if w.pollChanges(ctx) {
+				debounce = time.After(w.cfg.debounce)
+			}
+		case <-debounce:
+			debounce = nil
+			if w.syncPending(ctx, rescanAll) {
+				// Retry failed re-indexing later.
+				debounce = time.After(w.cfg.pollInterval)
+			}
+			rescanAll = false
+		}
+	}
+}
+
+// handleEvent records a filesystem event and reports whether it concerns a
+// watched source.
+func (w *SourceWatcher) handleEvent(ctx context.Context, ev fsnotify.Event) bool {
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	path := filepath.Clean(ev.Name)
+
+	w.mu.Lock()
+	var matched []*watchedSource
+	for _, ws := range w.sources {
+		if ws.covers(path) {
+			ws.pending[path] = struct{}{}
+			matched = append(matched, ws)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(matched) > 0 && ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if err := w.watchTree(path); err != nil {
+				w.recordError(ctx, matched[0], err)
+			}
+		}
+	}
+	return len(matched) > 0
+}
+
+// pollChanges compares the files of all sources with their indexed state to
+// find pending changes, and reports whether any file changed since the
+// previous poll, so that the debounce restarts only while files keep changing.
+func (w *SourceWatcher) pollChanges(ctx context.Context) bool {
+	changed := false
+	for _, ws := range w.sources {
+		fileSrc, err := findFileSource(w.dk.Sources(), ws.name)
+		var current map[string]watchedFile
+		if err == nil {
+			current, err = scanFiles(ctx, fileSrc)
+		}
+		if err != nil {
+			w.recordError(ctx, ws, err)
+			continue
+		}
+		if !diffFiles(ws.polled, current).empty() {
+			changed = true
+		}
+		ws.polled = current
+		w.mu.Lock()
+		for _, path := range diffFiles(ws.files, current).paths() {
+			ws.pending[path] = struct{}{}
+		}
+		w.mu.Unlock()
+	}
+	return changed
+}
+
+// syncPending re-indexes the sources with pending changes, or all sources
+// when all is true, and reports whether any changes are still pending.
+func (w *SourceWatcher) syncPending(ctx context.Context, all bool) bool {
+	remaining := false
+	for _, ws := range w.sources {
+		w.mu.Lock()
+		hasPending := len(ws.pending) > 0
+		w.mu.Unlock()
+		if !all && !hasPending {
+			continue
+		}
+		if err := w.syncSource(ctx, ws); err != nil {
+			remaining = true
+		}
+	}
+	return remaining
+}
+
+// Sync compares the files of all watched sources with their recorded state
+// and re-indexes the changes right away, without waiting for the debounce.
+func (w *SourceWatcher) Sync(ctx context.Context) error {
+	var errs []error
+	for _, ws := range w.sources {
+		if err := w.syncSource(ctx, ws); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncSource re-indexes the files of a source that changed since the last
+// sync. Failed changes stay pending.
+func (w *SourceWatcher) syncSource(ctx context.Context, ws *watchedSource) error {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+
+	w.mu.Lock()
+	handled := make([]string, 0, len(ws.pending))
+	for path := range ws.pending {
+		handled = append(handled, path)
+	}
+	w.mu.Unlock()
+
+	fileSrc, err := findFileSource(w.dk.Sources(), ws.name)
+	var current map[string]watchedFile
+	if err == nil {
+		current, err = scanFiles(ctx, fileSrc)
+	}
+	var diff fileDiff
+	if err == nil {
+		diff = diffFiles(ws.files, current)
+		if !diff.empty() {
+			changed := make([]string, 0, len(diff.added)+len(diff.modified))
+			for _, path := range append(append([]string(nil), diff.added...), diff.modified...) {
+				changed = append(changed, current[path].path)
+			}
+			removed := make([]string, 0, len(diff.removed))
+			for _, path := range diff.removed {
+				removed = append(removed, ws.files[path].path)
+			}
+			log.InfofContext(ctx, "Source %s changed: %d added, %d modified, %d removed file(s)",
+				ws.name, len(diff.added), len(diff.modified), len(diff.removed))
+			err = w.dk.ReloadSourceFiles(ctx, ws.name, changed, removed, w.cfg.loadOpts...)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, path := range handled {
+		delete(ws.pending, path)
+	}
+	if err != nil {
+		for _, path := range diff.paths() {
+			ws.pending[path] = struct{}{}
+		}
+		ws.addError(err)
+		log.WarnfContext(ctx, "Failed to re-index source %s: %v", ws.name, err)
+		return fmt.Errorf("source %s: %w", ws.name, err)
+	}
+	ws.files = current
+	if !diff.empty() {
+		ws.status.LastSync = time.Now()
+		ws.status.Added += len(diff.added)
+		ws.status.Modified += len(diff.modified)
+		ws.status.Removed += len(diff.removed)
+	}
+	return nil
+}
+
+// Status returns a snapshot of the state of the watcher.
+func (w *SourceWatcher) Status() WatchStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := WatchStatus{
+		Mode:    w.mode,
+		Sources: make([]SourceWatchStatus, 0, len(w.sources)),
+		Errors:  slices.Clone(w.errors),
+	}
+	for _, ws := range w.sources {
+		st := ws.status
+		st.Files = len(ws.files)
+		st.PendingChanges = len(ws.pending)
+		st.Errors = slices.Clone(ws.status.Errors)
+		status.Sources = append(status.Sources, st)
+	}
+	return status
+}
+
+// Close stops the watcher. Re-indexing in progress is cancelled.
+func (w *SourceWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		w.cancel()
+		<-w.done
+		if w.notifier != nil {
+			err = w.notifier.Close()
+		}
+	})
+	return err
+}
+
+// recordError records an error of a source, or of the watcher when ws is nil.
+func (w *SourceWatcher) recordError(ctx context.Context, ws *watchedSource, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ws != nil {
+		ws.addError(err)
+		log.WarnfContext(ctx, "Watching source %s: %v", ws.name, err)
+		return
+	}
+	w.errors = appendWatchError(w.errors, err)
+	log.WarnfContext(ctx, "Watching sources: %v", err)
+}
+
+func (ws *watchedSource) addError(err error) {
+	ws.status.Errors = appendWatchError(ws.status.Errors, err)
+}
+
+// covers reports whether path is one of the roots of the source or is inside
+// one of them.
+func (ws *watchedSource) covers(path string) bool {
+	for _, root := range ws.roots {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendWatchError(errs []WatchError, err error) []WatchError {
+	errs = append(errs, WatchError{Time: time.Now(), Err: err})
+	if len(errs) > maxWatchErrors {
+		errs = errs[len(errs)-maxWatchErrors:]
+	}
+	return errs
+}
+
+// findFileSource returns the registered source with the given name.
+func findFileSource(sources []source.Source, name string) (source.FileSource, error) {
+	for _, src := range sources {
+		if src.Name() != name {
+			continue
+		}
+		fileSrc, ok := src.(source.FileSource)
+		if !ok {
+			return nil, fmt.Errorf("source %s does not support file watching", name)
+		}
+		return fileSrc, nil
+	}
+	return nil, fmt.Errorf("source with name %s not found", name)
+}
+
+// scanFiles returns the current state of the files of a source.
+func scanFiles(ctx context.Context, fileSrc source.FileSource) (map[string]watchedFile, error) {
+	filePaths, err := fileSrc.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]watchedFile, len(filePaths))
+	for _, filePath := range filePaths {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return nil, err
+		}
+		files[absPath] = watchedFile{path: filePath, size: info.Size(), modTime: info.ModTime()}
+	}
+	return files, nil
+}
+
+// fileDiff lists the absolute paths of changed files in sorted order.
+type fileDiff struct {
+	added    []string
+	modified []string
+	removed  []string
+}
+
+func diffFiles(previous, current map[string]watchedFile) fileDiff {
+	var diff fileDiff
+	for path, file := range current {
+		old, ok := previous[path]
+		switch {
+		case !ok:
+			diff.added = append(diff.added, path)
+		case old.size != file.size || !old.modTime.Equal(file.modTime):
+			diff.modified = append(diff.modified, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			diff.removed = append(diff.removed, path)
+		}
+	}
+	sort.Strings(diff.added)
+	sort.Strings(diff.modified)
+	sort.Strings(diff.removed)
+	return diff
+}
+
+func (d fileDiff) empty() bool {
+	return len(d.added) == 0 && len(d.modified) == 0 && len(d.removed) == 0
+}
+
+func (d fileDiff) paths() []string {
+	return append(append(append([]string(nil), d.added...), d.modified...), d.removed...)
+}
+
+func absPaths(paths []string) ([]string, error) {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, absPath)
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package knowledge
+
+import "time"
+
+const (
+	defaultWatchDebounce     = 500 * time.Millisecond
+	defaultWatchPollInterval = 5 * time.Second
+	// maxWatchErrors is the number of recent errors kept per source.
+	maxWatchErrors = 10
+)
+
+// WatchOption configures a SourceWatcher.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	sourceNames  []string
+	debounce     time.Duration
+	pollInterval time.Duration
+	forcePolling bool
+	loadOpts     []LoadOption
+}
+
+// WithWatchSources limits the watcher to the named sources. By default all
+// registered sources that implement source.FileSource are watched.
+func WithWatchSources(names ...string) WatchOption {
+	return func(c *watchConfig) {
+		c.sourceNames = append(c.sourceNames, names...)
+	}
+}
+
+// WithWatchDebounce sets how long the watcher waits after the last change
+// before re-indexing. Defaults to 500ms.
+func WithWatchDebounce(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		if d > 0 {
+			c.debounce = d
+		}
+	}
+}
+
+// WithWatchPollInterval sets how often the files are scanned when the
+// watcher polls. Defaults to 5s.
+func WithWatchPollInterval(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		if d > 0 {
+			c.pollInterval = d
+		}
+	}
+}
+
+// WithWatchPolling makes the watcher poll the files instead of using
+// filesystem notifications. Polling is also used when notifications are not
+// available, e.g. on network filesystems or when the watch limit is reached.
+func WithWatchPolling(enabled bool) WatchOption {
+	return func(c *watchConfig) {
+		c.forcePolling = enabled
+	}
+}
+
+// WithWatchLoadOptions sets the load options used for re-indexing, e.g.
+// WithLoadProgressCallback to receive progress and error events.
+func WithWatchLoadOptions(opts ...LoadOption) WatchOption {
+	return func(c *watchConfig) {
+		c.loadOpts = append(c.loadOpts, opts...)
+	}
+}
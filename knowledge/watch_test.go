@@ -0,0 +1,307 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package knowledge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/source"
+	dirsource "trpc.group/trpc-go/trpc-agent-go/knowledge/source/dir"
+	filesource "trpc.group/trpc-go/trpc-agent-go/knowledge/source/file"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+	vsinmemory "trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore/inmemory"
+)
+
+func writeWatchFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// indexedContents returns the sorted contents of the indexed documents.
+func indexedContents(t *testing.T, vs vectorstore.VectorStore) []string {
+	t.Helper()
+	ctx := context.Background()
+	metas, err := vs.GetMetadata(ctx)
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	var contents []string
+	for id := range metas {
+		doc, _, err := vs.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get %s: %v", id, err)
+		}
+		contents = append(contents, strings.TrimSpace(doc.Content))
+	}
+	sort.Strings(contents)
+	return contents
+}
+
+func newWatchTestKnowledge(t *testing.T, sync bool, sources ...source.Source) (*BuiltinKnowledge, vectorstore.VectorStore) {
+	t.Helper()
+	vs := vsinmemory.New()
+	kb := New(
+		WithVectorStore(vs),
+		WithEmbedder(stubEmbedder{}),
+		WithSources(sources),
+		WithEnableSourceSync(sync),
+	)
+	if err := kb.Load(context.Background(), WithShowProgress(false), WithShowStats(false)); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return kb, vs
+}
+
+func waitForWatch(t *testing.T, w *SourceWatcher, cond func(SourceWatchStatus) bool) SourceWatchStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		st := w.Status().Sources[0]
+		if cond(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("watcher did not reach the expected state: %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSourceWatcher_ReindexesChangedFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		polling bool
+		sync    bool
+	}{
+		{name: "notify", sync: true},
+		{name: "poll", polling: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeWatchFile(t, filepath.Join(dir, "a.txt"), "alpha")
+			writeWatchFile(t, filepath.Join(dir, "b.txt"), "bravo")
+			writeWatchFile(t, filepath.Join(dir, "skip.log"), "ignored")
+			src := dirsource.New([]string{dir}, dirsource.WithName("docs"),
+				dirsource.WithFileExtensions([]string{".txt"}), dirsource.WithRecursive(true))
+			kb, vs := newWatchTestKnowledge(t, tc.sync, src)
+
+			var mu sync.Mutex
+			var events []LoadProgressEvent
+			w, err := kb.Watch(context.Background(),
+				WithWatchPolling(tc.polling),
+				WithWatchPollInterval(20*time.Millisecond),
+				WithWatchDebounce(20*time.Millisecond),
+				WithWatchLoadOptions(WithShowProgress(false), WithShowStats(false),
+					WithLoadProgressCallback(func(_ context.Context, ev LoadProgressEvent) {
+						mu.Lock()
+						events = append(events, ev)
+						mu.Unlock()
+					})),
+			)
+			if err != nil {
+				t.Fatalf("Watch: %v", err)
+			}
+			defer w.Close()
+			if !tc.polling && w.Status().Mode != WatchModeNotify {
+				t.Skip("filesystem notifications are not available")
+			}
+			if got := w.Status().Sources[0].Files; got != 2 {
+				t.Fatalf("tracked files = %d, want 2", got)
+			}
+
+			writeWatchFile(t, filepath.Join(dir, "a.txt"), "alpha, edited")
+			if err := os.Remove(filepath.Join(dir, "b.txt")); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			writeWatchFile(t, filepath.Join(dir, "sub", "c.txt"), "charlie")
+			writeWatchFile(t, filepath.Join(dir, "skip.log"), "still ignored")
+
+			st := waitForWatch(t, w, func(st SourceWatchStatus) bool {
+				return st.Added == 1 && st.Modified == 1 && st.Removed == 1 && st.PendingChanges == 0
+			})
+			if st.LastSync.IsZero() || st.Files != 2 || len(st.Errors) != 0 {
+				t.Fatalf("unexpected status: %+v", st)
+			}
+			want := []string{"alpha, edited", "charlie"}
+			if got := indexedContents(t, vs); strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Fatalf("indexed contents = %q, want %q", got, want)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) == 0 || !events[len(events)-1].Done {
+				t.Fatalf("expected progress events ending with Done, got %+v", events)
+			}
+			if events[0].SourceName != "docs" || events[0].SourceTotal != 2 {
+				t.Fatalf("only the changed files should be loaded, got %+v", events[0])
+			}
+		})
+	}
+}
+
+func TestSourceWatcher_SyncAndStatus(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	writeWatchFile(t, first, "first")
+	writeWatchFile(t, second, "second")
+	src := filesource.New([]string{first, second}, filesource.WithName("notes"))
+	kb, vs := newWatchTestKnowledge(t, true, src)
+
+	w, err := kb.Watch(context.Background(), WithWatchSources("notes"),
+		WithWatchDebounce(time.Hour), WithWatchLoadOptions(WithShowProgress(false), WithShowStats(false)))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync without changes: %v", err)
+	}
+	if st := w.Status().Sources[0]; !st.LastSync.IsZero() || st.Files != 2 {
+		t.Fatalf("unexpected status before changes: %+v", st)
+	}
+
+	writeWatchFile(t, first, "first, edited")
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	st := w.Status().Sources[0]
+	if st.Modified != 1 || st.Removed != 1 || st.Files != 1 || st.LastSync.IsZero() || st.SourceName != "notes" {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	if got := indexedContents(t, vs); len(got) != 1 || got[0] != "first, edited" {
+		t.Fatalf("indexed contents = %q", got)
+	}
+
+	writeWatchFile(t, second, "second, restored")
+	if err := w.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if st := w.Status().Sources[0]; st.Added != 1 || st.Files != 2 {
+		t.Fatalf("unexpected status after restoring: %+v", st)
+	}
+	if got := indexedContents(t, vs); len(got) != 2 || got[1] != "second, restored" {
+		t.Fatalf("indexed contents = %q", got)
+	}
+
+	if err := kb.RemoveSource(context.Background(), "notes"); err != nil {
+		t.Fatalf("RemoveSource: %v", err)
+	}
+	writeWatchFile(t, second, "changed after removal")
+	if err := w.Sync(context.Background()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Sync after removal error = %v", err)
+	}
+	st = w.Status().Sources[0]
+	if len(st.Errors) != 1 || !strings.Contains(st.Errors[0].Err.Error(), "not found") {
+		t.Fatalf("expected the error in the status, got %+v", st)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestBuiltinKnowledge_ReloadKeepsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.txt")
+	second := filepath.Join(dir, "b.txt")
+	writeWatchFile(t, first, "alpha")
+	writeWatchFile(t, second, "bravo")
+	src := dirsource.New([]string{dir}, dirsource.WithName("docs"))
+	kb, vs := newWatchTestKnowledge(t, false, src)
+
+	// The first file cannot be read while it is being replaced.
+	if err := os.Rename(first, first+".swp"); err != nil {
+		t.Fatal(err)
+	}
+	writeWatchFile(t, second, "bravo, edited")
+	err := kb.ReloadSourceFiles(context.Background(), "docs", []string{first, second}, nil,
+		WithShowProgress(false), WithShowStats(false))
+	if err == nil || !strings.Contains(err.Error(), first) {
+		t.Fatalf("ReloadSourceFiles error = %v", err)
+	}
+	if got := indexedContents(t, vs); len(got) != 2 || got[0] != "alpha" || got[1] != "bravo" {
+		t.Fatalf("indexed contents after a failed reload = %q", got)
+	}
+}
+
+func TestBuiltinKnowledge_WatchErrors(t *testing.T) {
+	ctx := context.Background()
+	plain := &mockSource{name: "plain"}
+	kb := New(WithVectorStore(vsinmemory.New()), WithSources([]source.Source{plain}))
+
+	if _, err := kb.Watch(ctx); err == nil || !strings.Contains(err.Error(), "no watchable sources") {
+		t.Fatalf("Watch without file sources error = %v", err)
+	}
+	if _, err := kb.Watch(ctx, WithWatchSources("plain")); err == nil ||
+		!strings.Contains(err.Error(), "does not support file watching") {
+		t.Fatalf("Watch of a plain source error = %v", err)
+	}
+	if _, err := kb.Watch(ctx, WithWatchSources("unknown")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Watch of an unknown source error = %v", err)
+	}
+
+	if err := kb.ReloadSourceFiles(ctx, "unknown", nil, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("ReloadSourceFiles of an unknown source error = %v", err)
+	}
+	if err := kb.ReloadSourceFiles(ctx, "plain", nil, nil); err == nil ||
+		!strings.Contains(err.Error(), "does not support file reload") {
+		t.Fatalf("ReloadSourceFiles of a plain source error = %v", err)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	now := time.Now()
+	previous := map[string]watchedFile{
+		"/a": {size: 1, modTime: now},
+		"/b": {size: 1, modTime: now},
+		"/c": {size: 1, modTime: now},
+	}
+	current := map[string]watchedFile{
+		"/a": {size: 1, modTime: now},
+		"/b": {size: 1, modTime: now.Add(time.Second)},
+		"/d": {size: 1, modTime: now},
+	}
+	diff := diffFiles(previous, current)
+	if strings.Join(diff.paths(), ",") != "/d,/b,/c" || diff.empty() {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if !diffFiles(current, current).empty() {
+		t.Fatal("identical states should not differ")
+	}
+
+	ws := &watchedSource{roots: []string{"/docs", "/notes.txt"}}
+	for path, want := range map[string]bool{
+		"/docs": true, "/docs/a.txt": true, "/docs2/a.txt": false, "/notes.txt": true, "/notes.txt.swp": false,
+	} {
+		if got := ws.covers(path); got != want {
+			t.Fatalf("covers(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package lifecycle manages the lifecycle of user data across the session,
+// memory and artifact services: time and size based retention, and purging
+// all data of a user (right to be forgotten) with an audit trail.
+package lifecycle
+
+import (
+	"errors"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/artifact"
+	"trpc.group/trpc-go/trpc-agent-go/memory"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// ErrUsersRequired is returned when the users of an app must be listed but
+// the session service cannot enumerate them and none were given with
+// WithUsers.
+var ErrUsersRequired = errors.New("lifecycle: session service cannot list users, set WithUsers")
+
+// Rule is a retention rule. Zero fields disable the corresponding limit.
+type Rule struct {
+	// MaxAge deletes sessions not updated within MaxAge, together with their
+	// session-scoped artifacts.
+	MaxAge time.Duration
+	// MaxEvents keeps the newest MaxEvents events of each session. It needs
+	// a session service implementing session.EventTrimmer.
+	MaxEvents int
+	// MemoryMaxAge deletes memories not updated within MemoryMaxAge.
+	MemoryMaxAge time.Duration
+}
+
+// IsZero reports whether r retains everything.
+func (r Rule) IsZero() bool {
+	return r == Rule{}
+}
+
+// Service applies retention rules and purges users across the session,
+// memory and artifact services.
+type Service struct {
+	sessions  session.Service
+	memories  memory.Service
+	artifacts artifact.Service
+	opts      options
+	now       func() time.Time
+}
+
+type options struct {
+	rule        Rule
+	appRules    map[string]Rule
+	progressLog ProgressLog
+}
+
+// Option configures a Service.
+type Option func(*options)
+
+// WithRetention sets the default retention rule.
+func WithRetention(rule Rule) Option {
+	return func(o *options) {
+		o.rule = rule
+	}
+}
+
+// WithAppRetention sets the retention rule of an app, which replaces the
+// default rule for that app.
+func WithAppRetention(appName string, rule Rule) Option {
+	return func(o *options) {
+		o.appRules[appName] = rule
+	}
+}
+
+// WithProgressLog sets the log recording purges. Without it, purges are not
+// audited and an interrupted purge starts over.
+func WithProgressLog(l ProgressLog) Option {
+	return func(o *options) {
+		o.progressLog = l
+	}
+}
+
+// NewService creates a Service. Any of the services may be nil, in which
+// case the data it holds is left alone.
+func NewService(
+	sessions session.Service,
+	memories memory.Service,
+	artifacts artifact.Service,
+	opts ...Option,
+) *Service {
+	o := options{appRules: make(map[string]Rule)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Service{
+		sessions:  sessions,
+		memories:  memories,
+		artifacts: artifacts,
+		opts:      o,
+		now:       time.Now,
+	}
+}
+
+// RuleFor returns the retention rule of an app.
+func (s *Service) RuleFor(appName string) Rule {
+	if rule, ok := s.opts.appRules[appName]; ok {
+		return rule
+	}
+	return s.opts.rule
+}
+
+type runOptions struct {
+	userIDs []string
+	dryRun  bool
+}
+
+// RunOption configures a single retention run or purge.
+type RunOption func(*runOptions)
+
+// WithUsers restricts a retention run to the given users. It is required
+// when the session service does not implement session.UserLister.
+func WithUsers(userIDs ...string) RunOption {
+	return func(o *runOptions) {
+		o.userIDs = append(o.userIDs, userIDs...)
+	}
+}
+
+// WithDryRun reports what would be deleted without deleting anything.
+func WithDryRun(dryRun bool) RunOption {
+	return func(o *runOptions) {
+		o.dryRun = dryRun
+	}
+}
+
+func newRunOptions(opts []RunOption) runOptions {
+	var o runOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// sessionInfo returns the artifact scope of a session.
+func sessionInfo(key session.Key) artifact.SessionInfo {
+	return artifact.SessionInfo{AppName: key.AppName, UserID: key.UserID, SessionID: key.SessionID}
+}
@@ -0,0 +1,261 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/artifact"
+	artifactinmemory "trpc.group/trpc-go/trpc-agent-go/artifact/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/memory"
+	memoryinmemory "trpc.group/trpc-go/trpc-agent-go/memory/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	sessioninmemory "trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+type stores struct {
+	sessions  *sessioninmemory.SessionService
+	memories  *memoryinmemory.MemoryService
+	artifacts *artifactinmemory.Service
+}
+
+// seed gives user u1 of app two sessions of four events, each with an
+// artifact, a user-scoped artifact, a memory and user state, and user u2 one
+// session.
+func seed(t *testing.T, appName string) stores {
+	t.Helper()
+	ctx := context.Background()
+	st := stores{
+		sessions:  sessioninmemory.NewSessionService(),
+		memories:  memoryinmemory.NewMemoryService(),
+		artifacts: artifactinmemory.NewService(),
+	}
+	t.Cleanup(func() {
+		st.sessions.Close()
+		st.memories.Close()
+	})
+	for _, key := range []session.Key{
+		{AppName: appName, UserID: "u1", SessionID: "s1"},
+		{AppName: appName, UserID: "u1", SessionID: "s2"},
+		{AppName: appName, UserID: "u2", SessionID: "s1"},
+	} {
+		sess, err := st.sessions.CreateSession(ctx, key, nil)
+		require.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			msg := model.NewUserMessage(fmt.Sprintf("message %d", i))
+			e := event.NewResponseEvent("inv", "user", &model.Response{Choices: []model.Choice{{Message: msg}}})
+			require.NoError(t, st.sessions.AppendEvent(ctx, sess, e))
+		}
+		_, err = st.artifacts.SaveArtifact(ctx, sessionInfo(key), "report.txt", &artifact.Artifact{Data: []byte("x")})
+		require.NoError(t, err)
+	}
+	u1 := session.Key{AppName: appName, UserID: "u1", SessionID: "s1"}
+	_, err := st.artifacts.SaveArtifact(ctx, sessionInfo(u1), "user:avatar.png", &artifact.Artifact{Data: []byte("x")})
+	require.NoError(t, err)
+	require.NoError(t, st.memories.AddMemory(ctx, memory.UserKey{AppName: appName, UserID: "u1"}, "likes go", nil))
+	require.NoError(t, st.sessions.UpdateUserState(ctx, session.UserKey{AppName: appName, UserID: "u1"},
+		session.StateMap{"lang": []byte("go")}))
+	return st
+}
+
+func (st stores) service(opts ...Option) *Service {
+	return NewService(st.sessions, st.memories, st.artifacts, opts...)
+}
+
+func TestApplyRetention_MaxAge(t *testing.T) {
+	st := seed(t, "app")
+	ctx := context.Background()
+	svc := st.service(WithRetention(Rule{MaxAge: time.Hour, MemoryMaxAge: time.Hour}))
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	dry, err := svc.ApplyRetention(ctx, "app", WithDryRun(true))
+	require.NoError(t, err)
+	assert.Len(t, dry.ExpiredSessions, 3)
+	assert.Equal(t, 3, dry.DeletedArtifacts)
+	assert.Equal(t, 1, dry.ExpiredMemories)
+	sessions, err := st.sessions.ListSessions(ctx, session.UserKey{AppName: "app", UserID: "u1"})
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	report, err := svc.ApplyRetention(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Users)
+	assert.Equal(t, dry.ExpiredSessions, report.ExpiredSessions)
+	sessions, err = st.sessions.ListSessions(ctx, session.UserKey{AppName: "app", UserID: "u1"})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	memories, err := st.memories.ReadMemories(ctx, memory.UserKey{AppName: "app", UserID: "u1"}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, memories)
+
+	// User-scoped artifacts outlive expired sessions.
+	keys, err := st.artifacts.ListArtifactKeys(ctx, artifact.SessionInfo{AppName: "app", UserID: "u1", SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user:avatar.png"}, keys)
+}
+
+func TestApplyRetention_AppOverride(t *testing.T) {
+	st := seed(t, "app")
+	ctx := context.Background()
+	svc := st.service(
+		WithRetention(Rule{MaxAge: time.Hour}),
+		WithAppRetention("app", Rule{MaxEvents: 1}),
+	)
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	assert.Equal(t, Rule{MaxEvents: 1}, svc.RuleFor("app"))
+	assert.Equal(t, Rule{MaxAge: time.Hour}, svc.RuleFor("other"))
+
+	report, err := svc.ApplyRetention(ctx, "app", WithUsers("u1"))
+	require.NoError(t, err)
+	assert.Empty(t, report.ExpiredSessions)
+	assert.Equal(t, 2, report.TrimmedSessions)
+	assert.Equal(t, 6, report.TrimmedEvents)
+	sess, err := st.sessions.GetSession(ctx, session.Key{AppName: "app", UserID: "u1", SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Len(t, sess.Events, 1)
+	sess, err = st.sessions.GetSession(ctx, session.Key{AppName: "app", UserID: "u2", SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Len(t, sess.Events, 4)
+
+	// Without event trimming support the limit is reported as skipped.
+	svc = NewService(plainSessions{st.sessions}, nil, nil, WithRetention(Rule{MaxEvents: 1}))
+	report, err = svc.ApplyRetention(ctx, "app", WithUsers("u2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{SkippedMaxEvents}, report.Skipped)
+	_, err = svc.ApplyRetention(ctx, "app")
+	assert.ErrorIs(t, err, ErrUsersRequired)
+}
+
+func TestPurgeUser(t *testing.T) {
+	st := seed(t, "app")
+	ctx := context.Background()
+	progress := NewFileProgressLog(filepath.Join(t.TempDir(), "purge.jsonl"))
+	svc := st.service(WithProgressLog(progress))
+
+	dry, err := svc.PurgeUser(ctx, "app", "u1", WithDryRun(true))
+	require.NoError(t, err)
+	want := &PurgeReport{
+		PurgeID:       dry.PurgeID,
+		AppName:       "app",
+		UserID:        "u1",
+		DryRun:        true,
+		Sessions:      []string{"s1", "s2"},
+		Artifacts:     []string{"user:avatar.png", "s1/report.txt", "s2/report.txt"},
+		Memories:      1,
+		UserStateKeys: []string{"lang"},
+	}
+	assert.Equal(t, want, dry)
+	entries, err := progress.Entries(ctx, "app", "u1")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	report, err := svc.PurgeUser(ctx, "app", "u1")
+	require.NoError(t, err)
+	want.PurgeID, want.DryRun = report.PurgeID, false
+	assert.Equal(t, want, report)
+
+	sessions, err := st.sessions.ListSessions(ctx, session.UserKey{AppName: "app", UserID: "u1"})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	keys, err := st.artifacts.ListArtifactKeys(ctx, artifact.SessionInfo{AppName: "app", UserID: "u1", SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	state, err := st.sessions.ListUserStates(ctx, session.UserKey{AppName: "app", UserID: "u1"})
+	require.NoError(t, err)
+	assert.Empty(t, state)
+	sessions, err = st.sessions.ListSessions(ctx, session.UserKey{AppName: "app", UserID: "u2"})
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	entries, err = progress.Entries(ctx, "app", "u1")
+	require.NoError(t, err)
+	require.Len(t, entries, 9)
+	assert.Equal(t, ActionStart, entries[0].Action)
+	assert.Equal(t, ActionDone, entries[8].Action)
+}
+
+func TestPurgeUser_ResumesAfterFailure(t *testing.T) {
+	st := seed(t, "app")
+	ctx := context.Background()
+	progress := NewFileProgressLog(filepath.Join(t.TempDir(), "purge.jsonl"))
+	flaky := &flakySessions{SessionService: st.sessions, failOn: "s2"}
+	svc := NewService(flaky, st.memories, st.artifacts, WithProgressLog(progress))
+
+	first, err := svc.PurgeUser(ctx, "app", "u1")
+	require.ErrorIs(t, err, errFlaky)
+	assert.Equal(t, []string{"s1"}, first.Sessions)
+
+	// A new service, as after a restart, picks up the same purge.
+	flaky.failOn = ""
+	svc = NewService(flaky, st.memories, st.artifacts, WithProgressLog(progress))
+	report, err := svc.PurgeUser(ctx, "app", "u1")
+	require.NoError(t, err)
+	assert.True(t, report.Resumed)
+	assert.Equal(t, first.PurgeID, report.PurgeID)
+	assert.Equal(t, []string{"s1", "s2"}, report.Sessions)
+	assert.Equal(t, 1, report.Memories)
+	assert.Len(t, report.Artifacts, 3)
+
+	// The next purge is a new one.
+	again, err := svc.PurgeUser(ctx, "app", "u1")
+	require.NoError(t, err)
+	assert.False(t, again.Resumed)
+	assert.NotEqual(t, report.PurgeID, again.PurgeID)
+	assert.Empty(t, again.Sessions)
+
+	_, err = svc.PurgeUser(ctx, "app", "")
+	assert.Error(t, err)
+}
+
+func TestPurgeUser_WithoutSessions(t *testing.T) {
+	st := seed(t, "app")
+	ctx := context.Background()
+	info := artifact.SessionInfo{AppName: "app", UserID: "u3", SessionID: "gone"}
+	_, err := st.artifacts.SaveArtifact(ctx, info, "user:avatar.png", &artifact.Artifact{Data: []byte("x")})
+	require.NoError(t, err)
+
+	report, err := st.service().PurgeUser(ctx, "app", "u3")
+	require.NoError(t, err)
+	assert.Empty(t, report.Sessions)
+	assert.Equal(t, []string{"user:avatar.png"}, report.Artifacts)
+	keys, err := st.artifacts.ListArtifactKeys(ctx, info)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+// plainSessions hides the optional interfaces of a session service.
+type plainSessions struct {
+	session.Service
+}
+
+var errFlaky = errors.New("flaky delete")
+
+// flakySessions fails to delete the session failOn.
+type flakySessions struct {
+	*sessioninmemory.SessionService
+	failOn string
+}
+
+func (s *flakySessions) DeleteSession(ctx context.Context, key session.Key, opts ...session.Option) error {
+	if key.SessionID == s.failOn {
+		return errFlaky
+	}
+	return s.SessionService.DeleteSession(ctx, key, opts...)
+}
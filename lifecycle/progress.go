@@ -0,0 +1,135 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package lifecycle
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Action is the kind of a purge log entry.
+type Action string
+
+// Purge log actions.
+const (
+	// ActionStart opens a purge.
+	ActionStart Action = "start"
+	// ActionDeleteArtifact records a deleted artifact, Target is its session
+	// ID and filename joined by "/".
+	ActionDeleteArtifact Action = "delete_artifact"
+	// ActionClearMemories records cleared memories, Count is their number.
+	ActionClearMemories Action = "clear_memories"
+	// ActionDeleteSession records a deleted session, Target is its ID.
+	ActionDeleteSession Action = "delete_session"
+	// ActionDeleteUserState records a deleted user state key.
+	ActionDeleteUserState Action = "delete_user_state"
+	// ActionDone closes a purge.
+	ActionDone Action = "done"
+)
+
+// Entry is a purge log entry.
+type Entry struct {
+	PurgeID string    `json:"purge_id"`
+	AppName string    `json:"app_name"`
+	UserID  string    `json:"user_id"`
+	Action  Action    `json:"action"`
+	Target  string    `json:"target,omitempty"`
+	Count   int       `json:"count,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// ProgressLog is an append-only log of purges. It is the audit trail of
+// purges and lets an interrupted purge resume under the same purge ID.
+type ProgressLog interface {
+	// Append durably records e.
+	Append(ctx context.Context, e Entry) error
+	// Entries returns the entries of a user in the order they were
+	// appended.
+	Entries(ctx context.Context, appName, userID string) ([]Entry, error)
+}
+
+// FileProgressLog is a ProgressLog stored as a JSONL file. Every entry is
+// synced to disk before Append returns.
+type FileProgressLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ ProgressLog = (*FileProgressLog)(nil)
+
+// NewFileProgressLog returns a FileProgressLog at path. The file is created
+// on the first Append.
+func NewFileProgressLog(path string) *FileProgressLog {
+	return &FileProgressLog{path: path}
+}
+
+// Append implements ProgressLog.
+func (f *FileProgressLog) Append(_ context.Context, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("lifecycle: encode progress entry: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("lifecycle: create progress log dir: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("lifecycle: open progress log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("lifecycle: write progress log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("lifecycle: sync progress log: %w", err)
+	}
+	return file.Close()
+}
+
+// Entries implements ProgressLog. Lines that cannot be decoded, such as a
+// line truncated by a crash during Append, are skipped.
+func (f *FileProgressLog) Entries(_ context.Context, appName, userID string) ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: open progress log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.AppName == appName && e.UserID == userID {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("lifecycle: read progress log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,285 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	iartifact "trpc.group/trpc-go/trpc-agent-go/internal/artifact"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/memory"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// PurgeReport describes the data of a user deleted by PurgeUser, or that
+// would be deleted in a dry run.
+type PurgeReport struct {
+	PurgeID string
+	AppName string
+	UserID  string
+	DryRun  bool
+	// Resumed is set when the purge continued an interrupted one. The
+	// report then also covers the data deleted before the interruption.
+	Resumed bool
+	// Sessions are the IDs of the deleted sessions.
+	Sessions []string
+	// Artifacts are the deleted artifacts, as session ID and filename joined
+	// by "/", or the filename alone for user-scoped artifacts.
+	Artifacts []string
+	// Memories is the number of deleted memories.
+	Memories int
+	// UserStateKeys are the deleted user state keys.
+	UserStateKeys []string
+}
+
+// PurgeUser deletes all data of a user: their artifacts, memories, sessions
+// and user state. App state is kept.
+//
+// Every deletion is recorded in the progress log once done. When a previous
+// purge of the user did not finish, PurgeUser resumes it under the same purge
+// ID. In a dry run nothing is deleted or logged.
+func (s *Service) PurgeUser(ctx context.Context, appName, userID string, opts ...RunOption) (*PurgeReport, error) {
+	userKey := session.UserKey{AppName: appName, UserID: userID}
+	if err := userKey.CheckUserKey(); err != nil {
+		return nil, err
+	}
+	o := newRunOptions(opts)
+	report := &PurgeReport{AppName: appName, UserID: userID, DryRun: o.dryRun}
+	if err := s.startPurge(ctx, report); err != nil {
+		return report, err
+	}
+	if err := s.purgeMemories(ctx, report); err != nil {
+		return report, err
+	}
+	if err := s.purgeUserArtifacts(ctx, report); err != nil {
+		return report, err
+	}
+	if err := s.purgeSessions(ctx, report); err != nil {
+		return report, err
+	}
+	if err := s.purgeUserState(ctx, report); err != nil {
+		return report, err
+	}
+	if err := s.record(ctx, report, ActionDone, "", 0); err != nil {
+		return report, err
+	}
+	log.InfofContext(ctx, "lifecycle: purge %s of %s/%s (dry run %t): %d sessions, %d artifacts, %d memories, %d user state keys",
+		report.PurgeID, appName, userID, report.DryRun,
+		len(report.Sessions), len(report.Artifacts), report.Memories, len(report.UserStateKeys))
+	return report, nil
+}
+
+// startPurge assigns the purge ID, resuming the last unfinished purge of the
+// user found in the progress log.
+func (s *Service) startPurge(ctx context.Context, report *PurgeReport) error {
+	if s.opts.progressLog != nil && !report.DryRun {
+		entries, err := s.opts.progressLog.Entries(ctx, report.AppName, report.UserID)
+		if err != nil {
+			return err
+		}
+		if id := unfinishedPurge(entries); id != "" {
+			report.PurgeID = id
+			report.Resumed = true
+			for _, e := range entries {
+				if e.PurgeID == id {
+					report.add(e.Action, e.Target, e.Count)
+				}
+			}
+			return nil
+		}
+	}
+	report.PurgeID = uuid.NewString()
+	return s.record(ctx, report, ActionStart, "", 0)
+}
+
+// unfinishedPurge returns the ID of the last purge in entries if it has no
+// done entry.
+func unfinishedPurge(entries []Entry) string {
+	var id string
+	for _, e := range entries {
+		switch e.Action {
+		case ActionStart:
+			id = e.PurgeID
+		case ActionDone:
+			if e.PurgeID == id {
+				id = ""
+			}
+		}
+	}
+	return id
+}
+
+func (s *Service) purgeMemories(ctx context.Context, report *PurgeReport) error {
+	if s.memories == nil {
+		return nil
+	}
+	userKey := memory.UserKey{AppName: report.AppName, UserID: report.UserID}
+	entries, err := s.memories.ReadMemories(ctx, userKey, 0)
+	if err != nil {
+		return fmt.Errorf("lifecycle: read memories: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if !report.DryRun {
+		if err := s.memories.ClearMemories(ctx, userKey); err != nil {
+			return fmt.Errorf("lifecycle: clear memories: %w", err)
+		}
+	}
+	return s.record(ctx, report, ActionClearMemories, "", len(entries))
+}
+
+func (s *Service) purgeSessions(ctx context.Context, report *PurgeReport) error {
+	if s.sessions == nil {
+		return nil
+	}
+	userKey := session.UserKey{AppName: report.AppName, UserID: report.UserID}
+	sessions, err := s.sessions.ListSessions(ctx, userKey)
+	if err != nil {
+		return fmt.Errorf("lifecycle: list sessions: %w", err)
+	}
+	slices.SortFunc(sessions, func(a, b *session.Session) int { return strings.Compare(a.ID, b.ID) })
+	for _, sess := range sessions {
+		key := session.Key{AppName: report.AppName, UserID: report.UserID, SessionID: sess.ID}
+		if err := s.purgeArtifacts(ctx, report, key, false); err != nil {
+			return err
+		}
+		if !report.DryRun {
+			if err := s.sessions.DeleteSession(ctx, key); err != nil {
+				return fmt.Errorf("lifecycle: delete session %s: %w", sess.ID, err)
+			}
+		}
+		if err := s.record(ctx, report, ActionDeleteSession, sess.ID, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeUserArtifacts deletes the user-scoped artifacts of the user. Artifact
+// services only list them along with a session, so they are listed with a
+// session ID no session has, which finds them even when the user has no
+// session.
+func (s *Service) purgeUserArtifacts(ctx context.Context, report *PurgeReport) error {
+	key := session.Key{AppName: report.AppName, UserID: report.UserID, SessionID: uuid.NewString()}
+	return s.purgeArtifacts(ctx, report, key, true)
+}
+
+// purgeArtifacts deletes the artifacts of a session, and the user-scoped
+// artifacts of its user when withUser is set.
+func (s *Service) purgeArtifacts(ctx context.Context, report *PurgeReport, key session.Key, withUser bool) error {
+	filenames, err := s.listArtifacts(ctx, key, withUser)
+	if err != nil {
+		return err
+	}
+	for _, filename := range filenames {
+		target := artifactTarget(key.SessionID, filename)
+		if !report.DryRun {
+			if err := s.artifacts.DeleteArtifact(ctx, sessionInfo(key), filename); err != nil {
+				return fmt.Errorf("lifecycle: delete artifact %s: %w", target, err)
+			}
+		}
+		if err := s.record(ctx, report, ActionDeleteArtifact, target, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listArtifacts returns the sorted artifact filenames of a session, with the
+// user-scoped artifacts of its user when withUser is set.
+func (s *Service) listArtifacts(ctx context.Context, key session.Key, withUser bool) ([]string, error) {
+	if s.artifacts == nil {
+		return nil, nil
+	}
+	filenames, err := s.artifacts.ListArtifactKeys(ctx, sessionInfo(key))
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: list artifacts of session %s: %w", key.SessionID, err)
+	}
+	if !withUser {
+		filenames = slices.DeleteFunc(filenames, iartifact.FileHasUserNamespace)
+	}
+	slices.Sort(filenames)
+	return filenames, nil
+}
+
+func (s *Service) purgeUserState(ctx context.Context, report *PurgeReport) error {
+	if s.sessions == nil {
+		return nil
+	}
+	userKey := session.UserKey{AppName: report.AppName, UserID: report.UserID}
+	state, err := s.sessions.ListUserStates(ctx, userKey)
+	if err != nil {
+		return fmt.Errorf("lifecycle: list user state: %w", err)
+	}
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if !report.DryRun {
+			if err := s.sessions.DeleteUserState(ctx, userKey, k); err != nil {
+				return fmt.Errorf("lifecycle: delete user state %s: %w", k, err)
+			}
+		}
+		if err := s.record(ctx, report, ActionDeleteUserState, k, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record adds a completed step to the report and, unless in a dry run, to
+// the progress log.
+func (s *Service) record(ctx context.Context, report *PurgeReport, action Action, target string, count int) error {
+	report.add(action, target, count)
+	if s.opts.progressLog == nil || report.DryRun {
+		return nil
+	}
+	err := s.opts.progressLog.Append(ctx, Entry{
+		PurgeID: report.PurgeID,
+		AppName: report.AppName,
+		UserID:  report.UserID,
+		Action:  action,
+		Target:  target,
+		Count:   count,
+		Time:    s.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("lifecycle: record purge progress: %w", err)
+	}
+	return nil
+}
+
+func (r *PurgeReport) add(action Action, target string, count int) {
+	switch action {
+	case ActionDeleteArtifact:
+		r.Artifacts = append(r.Artifacts, target)
+	case ActionClearMemories:
+		r.Memories += count
+	case ActionDeleteSession:
+		r.Sessions = append(r.Sessions, target)
+	case ActionDeleteUserState:
+		r.UserStateKeys = append(r.UserStateKeys, target)
+	}
+}
+
+func artifactTarget(sessionID, filename string) string {
+	if iartifact.FileHasUserNamespace(filename) {
+		return filename
+	}
+	return sessionID + "/" + filename
+}
@@ -0,0 +1,186 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/memory"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// Skipped limits of RetentionReport.
+const (
+	// SkippedMaxEvents is reported when the session service does not
+	// implement session.EventTrimmer.
+	SkippedMaxEvents = "max_events"
+)
+
+// RetentionReport describes the data deleted by ApplyRetention, or that
+// would be deleted in a dry run.
+type RetentionReport struct {
+	AppName string
+	DryRun  bool
+	Rule    Rule
+	// Users is the number of users checked.
+	Users int
+	// ExpiredSessions are the sessions deleted for MaxAge.
+	ExpiredSessions []session.Key
+	// TrimmedSessions and TrimmedEvents count the sessions trimmed for
+	// MaxEvents and their deleted events. In a dry run, events beyond the
+	// ones returned by ListSessions are not counted.
+	TrimmedSessions int
+	TrimmedEvents   int
+	// DeletedArtifacts counts the artifacts of the expired sessions.
+	DeletedArtifacts int
+	// ExpiredMemories counts the memories deleted for MemoryMaxAge.
+	ExpiredMemories int
+	// Skipped lists the limits of the rule the services cannot apply.
+	Skipped []string
+}
+
+// ApplyRetention applies the retention rule of an app to its users. Users
+// are listed with session.UserLister unless given with WithUsers.
+func (s *Service) ApplyRetention(ctx context.Context, appName string, opts ...RunOption) (*RetentionReport, error) {
+	if appName == "" {
+		return nil, session.ErrAppNameRequired
+	}
+	o := newRunOptions(opts)
+	report := &RetentionReport{AppName: appName, DryRun: o.dryRun, Rule: s.RuleFor(appName)}
+	if report.Rule.IsZero() {
+		return report, nil
+	}
+	userIDs, err := s.listUsers(ctx, appName, o)
+	if err != nil {
+		return report, err
+	}
+	for _, userID := range userIDs {
+		userKey := session.UserKey{AppName: appName, UserID: userID}
+		if err := s.retainSessions(ctx, report, userKey); err != nil {
+			return report, err
+		}
+		if err := s.retainMemories(ctx, report, userKey); err != nil {
+			return report, err
+		}
+		report.Users++
+	}
+	log.InfofContext(ctx, "lifecycle: retention of %s (dry run %t): %d users, %d expired sessions, "+
+		"%d events trimmed from %d sessions, %d artifacts, %d memories",
+		appName, report.DryRun, report.Users, len(report.ExpiredSessions),
+		report.TrimmedEvents, report.TrimmedSessions, report.DeletedArtifacts, report.ExpiredMemories)
+	return report, nil
+}
+
+func (s *Service) listUsers(ctx context.Context, appName string, o runOptions) ([]string, error) {
+	if len(o.userIDs) > 0 {
+		userIDs := slices.Clone(o.userIDs)
+		slices.Sort(userIDs)
+		return slices.Compact(userIDs), nil
+	}
+	lister, ok := s.sessions.(session.UserLister)
+	if !ok {
+		return nil, ErrUsersRequired
+	}
+	userIDs, err := lister.ListUserIDs(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: list users: %w", err)
+	}
+	return userIDs, nil
+}
+
+func (s *Service) retainSessions(ctx context.Context, report *RetentionReport, userKey session.UserKey) error {
+	rule := report.Rule
+	if s.sessions == nil || (rule.MaxAge <= 0 && rule.MaxEvents <= 0) {
+		return nil
+	}
+	trimmer, canTrim := s.sessions.(session.EventTrimmer)
+	if rule.MaxEvents > 0 && !canTrim && !slices.Contains(report.Skipped, SkippedMaxEvents) {
+		report.Skipped = append(report.Skipped, SkippedMaxEvents)
+	}
+	sessions, err := s.sessions.ListSessions(ctx, userKey)
+	if err != nil {
+		return fmt.Errorf("lifecycle: list sessions of %s: %w", userKey.UserID, err)
+	}
+	slices.SortFunc(sessions, func(a, b *session.Session) int { return a.UpdatedAt.Compare(b.UpdatedAt) })
+	now := s.now()
+	for _, sess := range sessions {
+		key := session.Key{AppName: userKey.AppName, UserID: userKey.UserID, SessionID: sess.ID}
+		if rule.MaxAge > 0 && now.Sub(sess.UpdatedAt) > rule.MaxAge {
+			if err := s.expireSession(ctx, report, key); err != nil {
+				return err
+			}
+			continue
+		}
+		if rule.MaxEvents <= 0 || !canTrim {
+			continue
+		}
+		trimmed := len(sess.Events) - rule.MaxEvents
+		if !report.DryRun {
+			if trimmed, err = trimmer.TrimEvents(ctx, key, rule.MaxEvents); err != nil {
+				return fmt.Errorf("lifecycle: trim events of session %s: %w", sess.ID, err)
+			}
+		}
+		if trimmed > 0 {
+			report.TrimmedSessions++
+			report.TrimmedEvents += trimmed
+		}
+	}
+	return nil
+}
+
+// expireSession deletes a session and its session-scoped artifacts.
+// User-scoped artifacts are shared by the sessions of the user and kept.
+func (s *Service) expireSession(ctx context.Context, report *RetentionReport, key session.Key) error {
+	filenames, err := s.listArtifacts(ctx, key, false)
+	if err != nil {
+		return err
+	}
+	if !report.DryRun {
+		for _, filename := range filenames {
+			if err := s.artifacts.DeleteArtifact(ctx, sessionInfo(key), filename); err != nil {
+				return fmt.Errorf("lifecycle: delete artifact %s: %w", artifactTarget(key.SessionID, filename), err)
+			}
+		}
+		if err := s.sessions.DeleteSession(ctx, key); err != nil {
+			return fmt.Errorf("lifecycle: delete session %s: %w", key.SessionID, err)
+		}
+	}
+	report.DeletedArtifacts += len(filenames)
+	report.ExpiredSessions = append(report.ExpiredSessions, key)
+	return nil
+}
+
+func (s *Service) retainMemories(ctx context.Context, report *RetentionReport, userKey session.UserKey) error {
+	if s.memories == nil || report.Rule.MemoryMaxAge <= 0 {
+		return nil
+	}
+	memKey := memory.UserKey{AppName: userKey.AppName, UserID: userKey.UserID}
+	entries, err := s.memories.ReadMemories(ctx, memKey, 0)
+	if err != nil {
+		return fmt.Errorf("lifecycle: read memories of %s: %w", userKey.UserID, err)
+	}
+	cutoff := s.now().Add(-report.Rule.MemoryMaxAge)
+	for _, e := range entries {
+		if e == nil || !e.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if !report.DryRun {
+			key := memory.Key{AppName: userKey.AppName, UserID: userKey.UserID, MemoryID: e.ID}
+			if err := s.memories.DeleteMemory(ctx, key); err != nil {
+				return fmt.Errorf("lifecycle: delete memory %s: %w", e.ID, err)
+			}
+		}
+		report.ExpiredMemories++
+	}
+	return nil
+}
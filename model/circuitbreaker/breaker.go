@@ -0,0 +1,257 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is the state of one candidate's breaker.
+type State int
+
+const (
+	// StateClosed admits every request.
+	StateClosed State = iota
+	// StateHalfOpen admits a limited number of trial requests.
+	StateHalfOpen
+	// StateOpen rejects every request.
+	StateOpen
+)
+
+// String implements fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChangeHook is called when the breaker of the named candidate changes
+// state.
+type StateChangeHook func(candidate string, from, to State)
+
+type transition struct {
+	from, to State
+}
+
+// permit is handed out by acquire and must be returned to release.
+type permit struct {
+	// gen is the breaker generation the permit was issued in. Outcomes of
+	// permits from an earlier generation are ignored.
+	gen   uint64
+	trial bool
+	probe bool
+}
+
+// breaker is the state machine of one candidate.
+type breaker struct {
+	name             string
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenRequests int
+	probing          bool
+	now              func() time.Time
+	// notify is called outside the lock for every transition.
+	notify func(name string, t transition)
+	// startProber is called outside the lock when the breaker opens and no
+	// prober is running.
+	startProber func(b *breaker)
+
+	mu             sync.Mutex
+	state          State
+	gen            uint64
+	failures       int
+	openedAt       time.Time
+	inflight       int
+	successes      int
+	proberRunning  bool
+	pendingNotices []transition
+}
+
+// acquire asks to send a live request.
+func (b *breaker) acquire() (permit, bool) {
+	b.mu.Lock()
+	p, ok := b.acquireLocked(false)
+	notices, start := b.takeNoticesLocked()
+	b.mu.Unlock()
+	b.flush(notices, start)
+	return p, ok
+}
+
+// acquireProbe asks to send a background probe. It succeeds once an open
+// breaker has waited out its open timeout.
+func (b *breaker) acquireProbe() (permit, bool) {
+	b.mu.Lock()
+	p, ok := b.acquireLocked(true)
+	notices, start := b.takeNoticesLocked()
+	b.mu.Unlock()
+	b.flush(notices, start)
+	return p, ok
+}
+
+func (b *breaker) acquireLocked(probe bool) (permit, bool) {
+	switch b.state {
+	case StateClosed:
+		return permit{gen: b.gen}, !probe
+	case StateOpen:
+		if b.probing != probe || b.now().Before(b.openedAt.Add(b.openTimeout)) {
+			return permit{}, false
+		}
+		b.transitionLocked(StateHalfOpen)
+	}
+	// Half-open: with probing, only the prober sends trials.
+	if b.probing != probe || b.inflight >= b.halfOpenRequests {
+		return permit{}, false
+	}
+	b.inflight++
+	return permit{gen: b.gen, trial: true, probe: probe}, true
+}
+
+// release reports the outcome of a request sent with p.
+func (b *breaker) release(p permit, outcome Outcome) {
+	b.mu.Lock()
+	b.releaseLocked(p, outcome)
+	notices, start := b.takeNoticesLocked()
+	b.mu.Unlock()
+	b.flush(notices, start)
+}
+
+func (b *breaker) releaseLocked(p permit, outcome Outcome) {
+	if p.gen != b.gen {
+		return
+	}
+	if p.trial {
+		b.inflight--
+	}
+	switch outcome {
+	case OutcomeSuccess:
+		switch b.state {
+		case StateClosed:
+			b.failures = 0
+		case StateHalfOpen:
+			b.successes++
+			if p.probe || b.successes >= b.halfOpenRequests {
+				b.transitionLocked(StateClosed)
+			}
+		}
+	case OutcomeFailure:
+		switch b.state {
+		case StateClosed:
+			b.failures++
+			if b.failures >= b.failureThreshold {
+				b.transitionLocked(StateOpen)
+			}
+		case StateHalfOpen:
+			b.transitionLocked(StateOpen)
+		}
+	}
+}
+
+func (b *breaker) transitionLocked(to State) {
+	if b.state == to {
+		return
+	}
+	b.pendingNotices = append(b.pendingNotices, transition{from: b.state, to: to})
+	b.state = to
+	b.gen++
+	b.failures = 0
+	b.inflight = 0
+	b.successes = 0
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+}
+
+func (b *breaker) takeNoticesLocked() ([]transition, bool) {
+	notices := b.pendingNotices
+	b.pendingNotices = nil
+	start := false
+	if b.probing && b.state == StateOpen && !b.proberRunning {
+		b.proberRunning = true
+		start = true
+	}
+	return notices, start
+}
+
+func (b *breaker) flush(notices []transition, startProber bool) {
+	if b.notify != nil {
+		for _, t := range notices {
+			b.notify(b.name, t)
+		}
+	}
+	if startProber && b.startProber != nil {
+		b.startProber(b)
+	}
+}
+
+// currentState returns the breaker state.
+func (b *breaker) currentState() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// untilProbe returns how long the prober waits before its next probe, or
+// false when the breaker is no longer open and the prober should exit.
+func (b *breaker) untilProbe() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != StateOpen {
+		b.proberRunning = false
+		return 0, false
+	}
+	return b.openedAt.Add(b.openTimeout).Sub(b.now()), true
+}
+
+// stopProber marks the prober as exited, for shutdown.
+func (b *breaker) stopProber() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.proberRunning = false
+}
+
+// runProber probes the candidate until its breaker closes or ctx is done.
+func (b *breaker) runProber(ctx context.Context, probe func(context.Context) Outcome) {
+	for {
+		wait, ok := b.untilProbe()
+		if !ok {
+			return
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				b.stopProber()
+				return
+			case <-timer.C:
+			}
+		}
+		p, ok := b.acquireProbe()
+		if !ok {
+			continue
+		}
+		outcome := probe(ctx)
+		if outcome == OutcomeIgnored {
+			// A probe must settle the trial, or the breaker would stay
+			// half-open without a prober.
+			outcome = OutcomeFailure
+		}
+		b.release(p, outcome)
+	}
+}
@@ -0,0 +1,137 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker(halfOpen int) (*breaker, *time.Time, *[]transition) {
+	now := time.Unix(1_700_000_000, 0)
+	var seen []transition
+	b := &breaker{
+		name:             "m",
+		failureThreshold: 2,
+		openTimeout:      10 * time.Second,
+		halfOpenRequests: halfOpen,
+		now:              func() time.Time { return now },
+		notify:           func(_ string, t transition) { seen = append(seen, t) },
+	}
+	return b, &now, &seen
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _, seen := newTestBreaker(1)
+
+	p, ok := b.acquire()
+	require.True(t, ok)
+	b.release(p, OutcomeFailure)
+	p, _ = b.acquire()
+	b.release(p, OutcomeSuccess)
+	p, _ = b.acquire()
+	b.release(p, OutcomeIgnored)
+	p, _ = b.acquire()
+	b.release(p, OutcomeFailure)
+	assert.Equal(t, StateClosed, b.currentState(), "a success resets the count and ignored outcomes do not count")
+
+	p, _ = b.acquire()
+	b.release(p, OutcomeFailure)
+	assert.Equal(t, StateOpen, b.currentState())
+	assert.Equal(t, []transition{{StateClosed, StateOpen}}, *seen)
+
+	_, ok = b.acquire()
+	assert.False(t, ok)
+}
+
+func TestBreaker_HalfOpenTrials(t *testing.T) {
+	b, now, seen := newTestBreaker(2)
+	for i := 0; i < 2; i++ {
+		p, _ := b.acquire()
+		b.release(p, OutcomeFailure)
+	}
+	require.Equal(t, StateOpen, b.currentState())
+
+	*now = now.Add(10 * time.Second)
+	p1, ok := b.acquire()
+	require.True(t, ok)
+	assert.Equal(t, StateHalfOpen, b.currentState())
+	p2, ok := b.acquire()
+	require.True(t, ok)
+	_, ok = b.acquire()
+	assert.False(t, ok, "half-open admits a limited number of trials")
+
+	b.release(p1, OutcomeSuccess)
+	assert.Equal(t, StateHalfOpen, b.currentState())
+	b.release(p2, OutcomeSuccess)
+	assert.Equal(t, StateClosed, b.currentState())
+
+	assert.Equal(t, []transition{
+		{StateClosed, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateClosed},
+	}, *seen)
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b, now, _ := newTestBreaker(1)
+	stale, _ := b.acquire()
+	for i := 0; i < 2; i++ {
+		p, _ := b.acquire()
+		b.release(p, OutcomeFailure)
+	}
+	*now = now.Add(10 * time.Second)
+	p, ok := b.acquire()
+	require.True(t, ok)
+
+	// Outcomes of permits issued before the breaker opened are ignored.
+	b.release(stale, OutcomeSuccess)
+	assert.Equal(t, StateHalfOpen, b.currentState())
+
+	b.release(p, OutcomeFailure)
+	assert.Equal(t, StateOpen, b.currentState())
+	_, ok = b.acquire()
+	assert.False(t, ok, "the open timeout restarts")
+}
+
+func TestBreaker_ProbingBlocksLiveTrials(t *testing.T) {
+	b, now, _ := newTestBreaker(1)
+	b.probing = true
+	started := 0
+	b.startProber = func(*breaker) { started++ }
+
+	_, ok := b.acquireProbe()
+	assert.False(t, ok, "closed breakers are not probed")
+	for i := 0; i < 2; i++ {
+		p, _ := b.acquire()
+		b.release(p, OutcomeFailure)
+	}
+	assert.Equal(t, 1, started)
+
+	*now = now.Add(10 * time.Second)
+	_, ok = b.acquire()
+	assert.False(t, ok)
+	p, ok := b.acquireProbe()
+	require.True(t, ok)
+	_, ok = b.acquire()
+	assert.False(t, ok)
+	b.release(p, OutcomeSuccess)
+	assert.Equal(t, StateClosed, b.currentState())
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "half_open", StateHalfOpen.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "unknown", State(9).String())
+}
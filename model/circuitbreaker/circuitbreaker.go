@@ -0,0 +1,396 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package circuitbreaker provides a model.Model wrapper that stops sending
+// requests to candidates that keep failing.
+//
+// Each candidate has a breaker. A closed breaker admits every request and
+// opens after consecutive failures; an open breaker rejects requests until
+// its open timeout passes; a half-open breaker then admits trial requests
+// and closes once they succeed, or opens again on failure. With WithProbe
+// the trials are background probes instead of live traffic.
+//
+// Requests are routed to one candidate whose breaker admits them, chosen at
+// random in proportion to its weight. The wrapper does not retry: a failed
+// call surfaces its error, and when every breaker is open the wrapper fails
+// fast with an ErrorTypeCircuitOpen response. Compose it with model/failover
+// to move on to the next provider immediately instead of waiting on one
+// that is down:
+//
+//	primary, _ := circuitbreaker.New(circuitbreaker.WithCandidates(openaiModel))
+//	backup, _ := circuitbreaker.New(circuitbreaker.WithCandidates(anthropicModel))
+//	llm, _ := failover.New(failover.WithCandidates(primary, backup))
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	tmetric "trpc.group/trpc-go/trpc-agent-go/telemetry/metric"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+	semconvtrace "trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/trace"
+)
+
+type member struct {
+	model   model.Model
+	weight  int
+	breaker *breaker
+}
+
+type breakerModel struct {
+	members       []*member
+	name          string
+	contextWindow int
+	classifier    Classifier
+	probeRequest  *model.Request
+	probeTimeout  time.Duration
+	hooks         []StateChangeHook
+	meterProvider metric.MeterProvider
+	intn          func(n int) int
+
+	// ctx bounds background probers; Close cancels it.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	metricsOnce    sync.Once
+	stateChangeCnt metric.Int64Counter
+}
+
+// New creates a circuit breaker model wrapper.
+func New(opt ...Option) (model.Model, error) {
+	opts := newOptions(opt...)
+	if len(opts.candidates) == 0 {
+		return nil, errors.New("circuitbreaker: at least one candidate model is required")
+	}
+	if opts.failureThreshold <= 0 {
+		return nil, errors.New("circuitbreaker: failure threshold must be positive")
+	}
+	if opts.openTimeout <= 0 {
+		return nil, errors.New("circuitbreaker: open timeout must be positive")
+	}
+	if opts.halfOpenRequests <= 0 {
+		return nil, errors.New("circuitbreaker: half-open requests must be positive")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &breakerModel{
+		name:          opts.name,
+		classifier:    opts.classifier,
+		probeRequest:  opts.probeRequest,
+		probeTimeout:  opts.probeTimeout,
+		hooks:         opts.onStateChange,
+		meterProvider: opts.meterProvider,
+		intn:          rand.Intn,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	for i, c := range opts.candidates {
+		if c.model == nil {
+			cancel()
+			return nil, fmt.Errorf("circuitbreaker: candidate model at index %d is nil", i)
+		}
+		if c.weight <= 0 {
+			cancel()
+			return nil, fmt.Errorf("circuitbreaker: candidate model at index %d has non-positive weight %d", i, c.weight)
+		}
+		mem := &member{model: c.model, weight: c.weight}
+		mem.breaker = &breaker{
+			name:             c.model.Info().Name,
+			failureThreshold: opts.failureThreshold,
+			openTimeout:      opts.openTimeout,
+			halfOpenRequests: opts.halfOpenRequests,
+			probing:          opts.probeRequest != nil,
+			now:              time.Now,
+			notify:           m.onTransition,
+		}
+		mem.breaker.startProber = func(b *breaker) {
+			go b.runProber(m.ctx, func(ctx context.Context) Outcome {
+				return m.probe(ctx, mem.model)
+			})
+		}
+		m.members = append(m.members, mem)
+	}
+	if m.name == "" {
+		m.name = m.members[0].model.Info().Name
+	}
+	m.contextWindow = stableCandidateContextWindow(m.members)
+	return m, nil
+}
+
+// Info returns the logical model info.
+func (m *breakerModel) Info() model.Info {
+	return model.Info{
+		Name:          m.name,
+		ContextWindow: m.contextWindow,
+	}
+}
+
+// InputTokenBudget returns the smallest advertised candidate budget so a
+// request remains eligible for every candidate it may be routed to.
+func (m *breakerModel) InputTokenBudget(
+	ctx context.Context,
+	request *model.Request,
+) int {
+	type budgeter interface {
+		InputTokenBudget(context.Context, *model.Request) int
+	}
+	budget := 0
+	for _, mem := range m.members {
+		b, ok := mem.model.(budgeter)
+		if !ok {
+			return 0
+		}
+		candidateBudget := b.InputTokenBudget(ctx, request)
+		if candidateBudget <= 0 {
+			return 0
+		}
+		if budget == 0 || candidateBudget < budget {
+			budget = candidateBudget
+		}
+	}
+	return budget
+}
+
+// Close stops background probers.
+func (m *breakerModel) Close() error {
+	m.cancel()
+	return nil
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *breakerModel) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	seq, err := m.GenerateContentIter(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, 1)
+	go func() {
+		defer close(responseChan)
+		seq(func(resp *model.Response) bool {
+			if resp == nil {
+				return true
+			}
+			select {
+			case responseChan <- resp.Clone():
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return responseChan, nil
+}
+
+// GenerateContentIter implements the model.IterModel interface.
+func (m *breakerModel) GenerateContentIter(
+	ctx context.Context,
+	request *model.Request,
+) (model.Seq[*model.Response], error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	return func(yield func(*model.Response) bool) {
+		m.run(ctx, request, yield)
+	}, nil
+}
+
+func (m *breakerModel) run(
+	ctx context.Context,
+	request *model.Request,
+	yield func(*model.Response) bool,
+) {
+	mem, p, ok := m.pick()
+	if !ok {
+		names := make([]string, 0, len(m.members))
+		for _, mem := range m.members {
+			names = append(names, mem.breaker.name)
+		}
+		yield(buildOpenResponse(names))
+		return
+	}
+	outcome := OutcomeIgnored
+	defer func() { mem.breaker.release(p, outcome) }()
+
+	seq, err := sequenceForCandidate(ctx, mem.model, request)
+	if err != nil {
+		outcome = m.classifier(ctx, nil, err)
+		yield(&model.Response{
+			Error: &model.ResponseError{
+				Message: fmt.Sprintf("candidate model %q failed to start: %v", mem.breaker.name, err),
+				Type:    model.ErrorTypeAPIError,
+			},
+			Timestamp: time.Now(),
+			Done:      true,
+		})
+		return
+	}
+	settled := false
+	seq(func(resp *model.Response) bool {
+		if resp == nil {
+			return true
+		}
+		if !settled {
+			settled = true
+			outcome = m.classifier(ctx, resp, nil)
+		}
+		return yield(resp)
+	})
+	if !settled && ctx.Err() == nil {
+		outcome = m.classifier(ctx, nil, nil)
+	}
+}
+
+// pick chooses a candidate whose breaker admits the request, at random in
+// proportion to the candidate weights.
+func (m *breakerModel) pick() (*member, permit, bool) {
+	remaining := append([]*member(nil), m.members...)
+	total := 0
+	for _, mem := range remaining {
+		total += mem.weight
+	}
+	for len(remaining) > 0 {
+		n := m.intn(total)
+		i := 0
+		for ; n >= remaining[i].weight; i++ {
+			n -= remaining[i].weight
+		}
+		mem := remaining[i]
+		if p, ok := mem.breaker.acquire(); ok {
+			return mem, p, true
+		}
+		total -= mem.weight
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return nil, permit{}, false
+}
+
+// probe sends the probe request to a candidate and classifies the result.
+func (m *breakerModel) probe(ctx context.Context, candidate model.Model) Outcome {
+	probeCtx, cancel := context.WithTimeout(ctx, m.probeTimeout)
+	defer cancel()
+	request := *m.probeRequest
+	request.Messages = append([]model.Message(nil), m.probeRequest.Messages...)
+	seq, err := sequenceForCandidate(probeCtx, candidate, &request)
+	if err != nil {
+		return m.classifier(ctx, nil, err)
+	}
+	var first *model.Response
+	seq(func(resp *model.Response) bool {
+		if resp == nil {
+			return true
+		}
+		first = resp
+		return false
+	})
+	if first == nil {
+		if probeCtx.Err() != nil {
+			return OutcomeFailure
+		}
+		return m.classifier(ctx, nil, nil)
+	}
+	return m.classifier(ctx, first, nil)
+}
+
+func (m *breakerModel) onTransition(name string, t transition) {
+	if t.to == StateOpen {
+		log.Warnf("circuitbreaker: candidate model %q %s -> %s", name, t.from, t.to)
+	} else {
+		log.Infof("circuitbreaker: candidate model %q %s -> %s", name, t.from, t.to)
+	}
+	m.metricsOnce.Do(m.initMetrics)
+	if m.stateChangeCnt != nil {
+		m.stateChangeCnt.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String(semconvtrace.KeyGenAIRequestModel, name),
+			attribute.String(metrics.KeyTRPCAgentGoCircuitBreakerFromState, t.from.String()),
+			attribute.String(metrics.KeyTRPCAgentGoCircuitBreakerToState, t.to.String()),
+		))
+	}
+	for _, hook := range m.hooks {
+		hook(name, t.from, t.to)
+	}
+}
+
+func (m *breakerModel) initMetrics() {
+	mp := m.meterProvider
+	if mp == nil {
+		mp = tmetric.GetMeterProvider()
+	}
+	if mp == nil {
+		return
+	}
+	var err error
+	if m.stateChangeCnt, err = mp.Meter(metrics.MeterNameCircuitBreaker).Int64Counter(
+		metrics.MetricTRPCAgentGoModelCircuitBreakerStateChangeCnt,
+		metric.WithDescription("Number of model circuit breaker state changes"),
+		metric.WithUnit("1"),
+	); err != nil {
+		log.Warnf("circuitbreaker: create metric %s: %v",
+			metrics.MetricTRPCAgentGoModelCircuitBreakerStateChangeCnt, err)
+	}
+}
+
+func stableCandidateContextWindow(members []*member) int {
+	contextWindow := 0
+	for _, mem := range members {
+		window := mem.model.Info().ContextWindow
+		if window <= 0 {
+			return 0
+		}
+		if contextWindow == 0 {
+			contextWindow = window
+			continue
+		}
+		if contextWindow != window {
+			return 0
+		}
+	}
+	return contextWindow
+}
+
+func sequenceForCandidate(
+	ctx context.Context,
+	candidate model.Model,
+	request *model.Request,
+) (model.Seq[*model.Response], error) {
+	if iterModel, ok := candidate.(model.IterModel); ok {
+		seq, err := iterModel.GenerateContentIter(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if seq == nil {
+			return nil, fmt.Errorf("candidate model %q returned nil response sequence", candidate.Info().Name)
+		}
+		return seq, nil
+	}
+	responseChan, err := candidate.GenerateContent(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if responseChan == nil {
+		return nil, fmt.Errorf("candidate model %q returned nil response channel", candidate.Info().Name)
+	}
+	return func(yield func(*model.Response) bool) {
+		for response := range responseChan {
+			if !yield(response) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,288 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/failover"
+)
+
+// stubModel fails while down is set and answers with its name otherwise.
+type stubModel struct {
+	name     string
+	down     atomic.Bool
+	startErr error
+	calls    atomic.Int32
+}
+
+func (m *stubModel) Info() model.Info { return model.Info{Name: m.name, ContextWindow: 1000} }
+
+func (m *stubModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	m.calls.Add(1)
+	if m.startErr != nil {
+		return nil, m.startErr
+	}
+	ch := make(chan *model.Response, 1)
+	if m.down.Load() {
+		ch <- &model.Response{Done: true, Error: &model.ResponseError{
+			Type: model.ErrorTypeAPIError, Message: "503 Service Unavailable",
+		}}
+	} else {
+		ch <- &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage(m.name)}}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func call(t *testing.T, llm model.Model) *model.Response {
+	t.Helper()
+	ch, err := llm.GenerateContent(context.Background(), &model.Request{})
+	require.NoError(t, err)
+	var last *model.Response
+	for resp := range ch {
+		last = resp
+	}
+	require.NotNil(t, last)
+	return last
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New()
+	assert.EqualError(t, err, "circuitbreaker: at least one candidate model is required")
+	_, err = New(WithCandidates(nil))
+	assert.EqualError(t, err, "circuitbreaker: candidate model at index 0 is nil")
+	_, err = New(WithWeightedCandidate(&stubModel{name: "a"}, 0))
+	assert.Error(t, err)
+	_, err = New(WithCandidates(&stubModel{name: "a"}), WithFailureThreshold(0))
+	assert.Error(t, err)
+	_, err = New(WithCandidates(&stubModel{name: "a"}), WithOpenTimeout(0))
+	assert.Error(t, err)
+	_, err = New(WithCandidates(&stubModel{name: "a"}), WithHalfOpenRequests(0))
+	assert.Error(t, err)
+
+	llm, err := New(WithCandidates(&stubModel{name: "a"}, &stubModel{name: "b"}))
+	require.NoError(t, err)
+	assert.Equal(t, model.Info{Name: "a", ContextWindow: 1000}, llm.Info())
+	_, ok := llm.(model.IterModel)
+	assert.True(t, ok)
+	llm, err = New(WithCandidates(&stubModel{name: "a"}), WithName("logical"))
+	require.NoError(t, err)
+	assert.Equal(t, "logical", llm.Info().Name)
+}
+
+func TestCircuitBreaker_OpensAndFailsFast(t *testing.T) {
+	base := &stubModel{name: "a"}
+	base.down.Store(true)
+	var (
+		mu      sync.Mutex
+		changes []string
+	)
+	reader := sdkmetric.NewManualReader()
+	llm, err := New(
+		WithCandidates(base),
+		WithFailureThreshold(2),
+		WithOpenTimeout(time.Hour),
+		WithStateChangeHook(func(name string, from, to State) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, name+":"+from.String()+"->"+to.String())
+		}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		resp := call(t, llm)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, model.ErrorTypeAPIError, resp.Error.Type)
+	}
+	resp := call(t, llm)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, ErrorTypeCircuitOpen, resp.Error.Type)
+	assert.Equal(t, int32(2), base.calls.Load(), "an open breaker does not call the candidate")
+	assert.Equal(t, []string{"a:closed->open"}, changes)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	data := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.Len(t, data.DataPoints, 1)
+	assert.Equal(t, int64(1), data.DataPoints[0].Value)
+}
+
+func TestCircuitBreaker_RecoversThroughHalfOpen(t *testing.T) {
+	base := &stubModel{name: "a"}
+	base.down.Store(true)
+	llm, err := New(WithCandidates(base), WithFailureThreshold(1), WithOpenTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+
+	require.NotNil(t, call(t, llm).Error)
+	assert.Equal(t, ErrorTypeCircuitOpen, call(t, llm).Error.Type)
+
+	base.down.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	resp := call(t, llm)
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, StateClosed, llm.(*breakerModel).members[0].breaker.currentState())
+}
+
+func TestCircuitBreaker_RoutesAroundOpenCandidate(t *testing.T) {
+	a := &stubModel{name: "a"}
+	b := &stubModel{name: "b"}
+	llm, err := New(
+		WithWeightedCandidate(a, 3),
+		WithWeightedCandidate(b, 1),
+		WithFailureThreshold(1),
+		WithOpenTimeout(time.Hour),
+	)
+	require.NoError(t, err)
+	impl := llm.(*breakerModel)
+
+	// n < 3 selects a, n == 3 selects b.
+	picks := []int{0, 3, 2}
+	impl.intn = func(int) int {
+		n := picks[0]
+		picks = picks[1:]
+		return n
+	}
+	assert.Equal(t, "a", call(t, llm).Choices[0].Message.Content)
+	assert.Equal(t, "b", call(t, llm).Choices[0].Message.Content)
+
+	a.down.Store(true)
+	require.NotNil(t, call(t, llm).Error)
+
+	// a is open, so every request goes to b whatever the draw.
+	impl.intn = func(int) int { return 0 }
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "b", call(t, llm).Choices[0].Message.Content)
+	}
+	assert.Equal(t, int32(2), a.calls.Load())
+}
+
+func TestCircuitBreaker_ComposesWithFailover(t *testing.T) {
+	primary := &stubModel{name: "primary"}
+	primary.down.Store(true)
+	backup := &stubModel{name: "backup"}
+	cbPrimary, err := New(WithCandidates(primary), WithFailureThreshold(1), WithOpenTimeout(time.Hour))
+	require.NoError(t, err)
+	cbBackup, err := New(WithCandidates(backup))
+	require.NoError(t, err)
+	llm, err := failover.New(failover.WithCandidates(cbPrimary, cbBackup))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		resp := call(t, llm)
+		require.Nil(t, resp.Error)
+		assert.Equal(t, "backup", resp.Choices[0].Message.Content)
+	}
+	assert.Equal(t, int32(1), primary.calls.Load())
+}
+
+func TestCircuitBreaker_StartErrorAndClassifier(t *testing.T) {
+	base := &stubModel{name: "a", startErr: errors.New("dial tcp: connection refused")}
+	llm, err := New(WithCandidates(base), WithFailureThreshold(1), WithOpenTimeout(time.Hour))
+	require.NoError(t, err)
+	resp := call(t, llm)
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Message, "connection refused")
+	assert.Equal(t, ErrorTypeCircuitOpen, call(t, llm).Error.Type)
+
+	// A classifier that ignores everything never opens the breaker.
+	base = &stubModel{name: "a"}
+	base.down.Store(true)
+	llm, err = New(
+		WithCandidates(base),
+		WithFailureThreshold(1),
+		WithClassifier(func(context.Context, *model.Response, error) Outcome { return OutcomeIgnored }),
+	)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, model.ErrorTypeAPIError, call(t, llm).Error.Type)
+	}
+}
+
+func TestCircuitBreaker_BackgroundProbe(t *testing.T) {
+	base := &stubModel{name: "a"}
+	base.down.Store(true)
+	var closed atomic.Bool
+	llm, err := New(
+		WithCandidates(base),
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+		WithProbe(&model.Request{Messages: []model.Message{model.NewUserMessage("ping")}}),
+		WithProbeTimeout(time.Second),
+		WithStateChangeHook(func(_ string, _, to State) {
+			if to == StateClosed {
+				closed.Store(true)
+			}
+		}),
+	)
+	require.NoError(t, err)
+	defer llm.(*breakerModel).Close()
+
+	require.NotNil(t, call(t, llm).Error)
+	// Probes keep failing while the candidate is down.
+	require.Eventually(t, func() bool { return base.calls.Load() >= 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, ErrorTypeCircuitOpen, call(t, llm).Error.Type, "live traffic is not used for trials")
+
+	base.down.Store(false)
+	require.Eventually(t, closed.Load, time.Second, time.Millisecond)
+	assert.Nil(t, call(t, llm).Error)
+}
+
+func TestDefaultClassifier(t *testing.T) {
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	code := "context_length_exceeded"
+	tests := []struct {
+		name string
+		ctx  context.Context
+		resp *model.Response
+		err  error
+		want Outcome
+	}{
+		{"success", ctx, &model.Response{}, nil, OutcomeSuccess},
+		{"nothing", ctx, nil, nil, OutcomeSuccess},
+		{"start error", ctx, nil, errors.New("boom"), OutcomeFailure},
+		{"server error", ctx, &model.Response{Error: &model.ResponseError{Message: "500 Internal Server Error"}}, nil, OutcomeFailure},
+		{"rate limited", ctx, &model.Response{Error: &model.ResponseError{Message: "429 Too Many Requests"}}, nil, OutcomeFailure},
+		{"bad request", ctx, &model.Response{Error: &model.ResponseError{Message: "400 Bad Request"}}, nil, OutcomeIgnored},
+		{"invalid request type", ctx, &model.Response{Error: &model.ResponseError{Type: "invalid_request_error"}}, nil, OutcomeIgnored},
+		{"context length code", ctx, &model.Response{Error: &model.ResponseError{Message: "too long", Code: &code}}, nil, OutcomeIgnored},
+		{"cancelled", cancelled, nil, context.Canceled, OutcomeIgnored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultClassifier(tt.ctx, tt.resp, tt.err))
+		})
+	}
+}
+
+func TestInputTokenBudget(t *testing.T) {
+	llm, err := New(WithCandidates(&stubModel{name: "a"}))
+	require.NoError(t, err)
+	b := llm.(interface {
+		InputTokenBudget(context.Context, *model.Request) int
+	})
+	assert.Zero(t, b.InputTokenBudget(context.Background(), &model.Request{}))
+}
@@ -0,0 +1,101 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// ErrorTypeCircuitOpen is the response error type returned when every
+// candidate's breaker is open.
+const ErrorTypeCircuitOpen = "circuit_open"
+
+// Outcome is how a call counts towards its candidate's health.
+type Outcome int
+
+const (
+	// OutcomeSuccess counts as a success.
+	OutcomeSuccess Outcome = iota
+	// OutcomeFailure counts as a failure.
+	OutcomeFailure
+	// OutcomeIgnored does not count, for failures caused by the caller
+	// rather than the provider.
+	OutcomeIgnored
+)
+
+// Classifier classifies a call from its first meaningful result: the error
+// returned when starting the call, or else the first response. As with
+// model/failover, a call that emitted a non-error chunk succeeded even if it
+// fails later.
+type Classifier func(ctx context.Context, resp *model.Response, err error) Outcome
+
+// clientErrorMarkers identify errors caused by the request itself, which
+// say nothing about the provider's health.
+var clientErrorMarkers = []string{
+	"invalid_request_error",
+	"context_length_exceeded",
+	"400 bad request",
+	"413 request entity too large",
+	"422 unprocessable entity",
+}
+
+// DefaultClassifier treats every error as a failure except cancellations
+// by the caller and errors caused by an invalid request.
+func DefaultClassifier(ctx context.Context, resp *model.Response, err error) Outcome {
+	if err == nil && !hasResponseError(resp) {
+		return OutcomeSuccess
+	}
+	if ctx.Err() != nil {
+		return OutcomeIgnored
+	}
+	if err == nil && isClientError(resp.Error) {
+		return OutcomeIgnored
+	}
+	return OutcomeFailure
+}
+
+func isClientError(e *model.ResponseError) bool {
+	text := strings.ToLower(e.Type + " " + e.Message)
+	if e.Code != nil {
+		text += " " + strings.ToLower(*e.Code)
+	}
+	for _, marker := range clientErrorMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasResponseError(response *model.Response) bool {
+	if response == nil || response.Error == nil {
+		return false
+	}
+	return response.Error.Message != "" ||
+		response.Error.Type != "" ||
+		response.Error.Param != nil ||
+		response.Error.Code != nil
+}
+
+func buildOpenResponse(names []string) *model.Response {
+	return &model.Response{
+		Error: &model.ResponseError{
+			Message: fmt.Sprintf("circuit breaker open for candidate models %q", names),
+			Type:    ErrorTypeCircuitOpen,
+		},
+		Timestamp: time.Now(),
+		Done:      true,
+	}
+}
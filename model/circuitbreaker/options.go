@@ -0,0 +1,159 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package circuitbreaker
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenRequests = 1
+	defaultProbeTimeout     = 10 * time.Second
+	defaultWeight           = 1
+)
+
+type candidate struct {
+	model  model.Model
+	weight int
+}
+
+type options struct {
+	candidates       []candidate
+	name             string
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenRequests int
+	classifier       Classifier
+	probeRequest     *model.Request
+	probeTimeout     time.Duration
+	onStateChange    []StateChangeHook
+	meterProvider    metric.MeterProvider
+}
+
+func newOptions(opt ...Option) options {
+	opts := options{
+		failureThreshold: defaultFailureThreshold,
+		openTimeout:      defaultOpenTimeout,
+		halfOpenRequests: defaultHalfOpenRequests,
+		classifier:       DefaultClassifier,
+		probeTimeout:     defaultProbeTimeout,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return opts
+}
+
+// Option configures a circuit breaker model.
+type Option func(*options)
+
+// WithCandidates appends candidates with weight 1.
+// Multiple calls accumulate candidates instead of replacing them.
+func WithCandidates(candidates ...model.Model) Option {
+	return func(o *options) {
+		for _, c := range candidates {
+			o.candidates = append(o.candidates, candidate{model: c, weight: defaultWeight})
+		}
+	}
+}
+
+// WithWeightedCandidate appends a candidate receiving a share of traffic
+// proportional to weight among the candidates whose breaker is closed.
+func WithWeightedCandidate(c model.Model, weight int) Option {
+	return func(o *options) {
+		o.candidates = append(o.candidates, candidate{model: c, weight: weight})
+	}
+}
+
+// WithName sets a stable logical model name for the wrapper.
+// Default is the name of the first candidate.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithFailureThreshold sets the consecutive failures that open a breaker.
+// Default is 5.
+func WithFailureThreshold(n int) Option {
+	return func(o *options) {
+		o.failureThreshold = n
+	}
+}
+
+// WithOpenTimeout sets how long a breaker stays open before it lets trial
+// requests through. Default is 30s.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests sets how many trial requests a half-open breaker
+// admits concurrently; the breaker closes once all of them succeed.
+// Default is 1.
+func WithHalfOpenRequests(n int) Option {
+	return func(o *options) {
+		o.halfOpenRequests = n
+	}
+}
+
+// WithClassifier sets how responses and errors are classified.
+// Default is DefaultClassifier.
+func WithClassifier(c Classifier) Option {
+	return func(o *options) {
+		if c != nil {
+			o.classifier = c
+		}
+	}
+}
+
+// WithProbe enables background probing. An open breaker no longer admits
+// live trial requests; instead, after the open timeout, the request is sent
+// to the candidate in the background and the breaker closes once it
+// succeeds. Keep the probe cheap, for example with a small MaxTokens.
+func WithProbe(request *model.Request) Option {
+	return func(o *options) {
+		o.probeRequest = request
+	}
+}
+
+// WithProbeTimeout bounds each background probe. Default is 10s.
+func WithProbeTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.probeTimeout = d
+		}
+	}
+}
+
+// WithStateChangeHook registers a hook called on every breaker state
+// change. Hooks run synchronously and must not block.
+func WithStateChangeHook(hook StateChangeHook) Option {
+	return func(o *options) {
+		if hook != nil {
+			o.onStateChange = append(o.onStateChange, hook)
+		}
+	}
+}
+
+// WithMeterProvider sets the meter provider state changes are exported to.
+// Default is the global provider from telemetry/metric.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) {
+		o.meterProvider = mp
+	}
+}
@@ -0,0 +1,214 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package pricing converts model token usage into money.
+//
+// A Catalog maps model names to per-million-token prices. Prices are in
+// whatever currency the caller uses; the package ships no vendor prices
+// because they change too often to hard-code:
+//
+//	catalog := pricing.NewCatalog(map[string]pricing.Price{
+//		"gpt-4o":      {InputPerMillion: 2.5, CachedInputPerMillion: 1.25, OutputPerMillion: 10},
+//		"gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+//	})
+//	cost, ok := catalog.Cost(rsp.Model, rsp.Usage)
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Price is the price of one model, per million tokens.
+type Price struct {
+	// InputPerMillion is the price of uncached prompt tokens.
+	InputPerMillion float64 `json:"input_per_million"`
+	// OutputPerMillion is the price of completion tokens.
+	OutputPerMillion float64 `json:"output_per_million"`
+	// CachedInputPerMillion is the price of prompt tokens read from the
+	// provider's prompt cache. Zero means InputPerMillion.
+	CachedInputPerMillion float64 `json:"cached_input_per_million,omitempty"`
+	// CacheWritePerMillion is the price of prompt tokens written to the
+	// provider's prompt cache. Zero means InputPerMillion.
+	CacheWritePerMillion float64 `json:"cache_write_per_million,omitempty"`
+	// ReasoningPerMillion is the price of reasoning tokens, which providers
+	// report as part of the completion tokens. Zero means OutputPerMillion.
+	ReasoningPerMillion float64 `json:"reasoning_per_million,omitempty"`
+}
+
+// Cost is the cost of one model call, broken down by token kind.
+type Cost struct {
+	Input       float64 `json:"input"`
+	CachedInput float64 `json:"cached_input,omitempty"`
+	CacheWrite  float64 `json:"cache_write,omitempty"`
+	Output      float64 `json:"output"`
+	Reasoning   float64 `json:"reasoning,omitempty"`
+	Total       float64 `json:"total"`
+}
+
+// Tokens is usage normalized across providers.
+type Tokens struct {
+	// Input is the number of uncached prompt tokens.
+	Input int
+	// CachedInput is the number of prompt tokens read from cache.
+	CachedInput int
+	// CacheWrite is the number of prompt tokens written to cache.
+	CacheWrite int
+	// Output is the number of completion tokens, reasoning excluded.
+	Output int
+	// Reasoning is the number of reasoning tokens.
+	Reasoning int
+}
+
+// TokensOf normalizes usage reported by any provider.
+//
+// OpenAI-compatible providers count cached tokens inside PromptTokens,
+// while Anthropic reports cache reads and writes next to PromptTokens.
+// Usage carrying cache read or write counts is treated as the latter.
+func TokensOf(usage *model.Usage) Tokens {
+	if usage == nil {
+		return Tokens{}
+	}
+	details := usage.PromptTokensDetails
+	t := Tokens{
+		Reasoning: usage.CompletionTokensDetails.ReasoningTokens,
+	}
+	if details.CacheReadTokens > 0 || details.CacheCreationTokens > 0 {
+		t.Input = usage.PromptTokens
+		t.CachedInput = details.CacheReadTokens
+		t.CacheWrite = details.CacheCreationTokens
+	} else {
+		t.CachedInput = min(details.CachedTokens, usage.PromptTokens)
+		t.Input = usage.PromptTokens - t.CachedInput
+	}
+	t.Reasoning = min(t.Reasoning, usage.CompletionTokens)
+	t.Output = usage.CompletionTokens - t.Reasoning
+	return t
+}
+
+// Cost prices the given tokens.
+func (p Price) Cost(t Tokens) Cost {
+	cached := p.CachedInputPerMillion
+	if cached == 0 {
+		cached = p.InputPerMillion
+	}
+	write := p.CacheWritePerMillion
+	if write == 0 {
+		write = p.InputPerMillion
+	}
+	reasoning := p.ReasoningPerMillion
+	if reasoning == 0 {
+		reasoning = p.OutputPerMillion
+	}
+	c := Cost{
+		Input:       perMillion(t.Input, p.InputPerMillion),
+		CachedInput: perMillion(t.CachedInput, cached),
+		CacheWrite:  perMillion(t.CacheWrite, write),
+		Output:      perMillion(t.Output, p.OutputPerMillion),
+		Reasoning:   perMillion(t.Reasoning, reasoning),
+	}
+	c.Total = c.Input + c.CachedInput + c.CacheWrite + c.Output + c.Reasoning
+	return c
+}
+
+func perMillion(tokens int, price float64) float64 {
+	return float64(tokens) * price / 1e6
+}
+
+// Catalog maps model names to prices. It is safe for concurrent use.
+type Catalog struct {
+	mu     sync.RWMutex
+	prices map[string]Price
+}
+
+// NewCatalog creates a catalog holding prices keyed by model name.
+func NewCatalog(prices map[string]Price) *Catalog {
+	c := &Catalog{prices: make(map[string]Price, len(prices))}
+	for name, p := range prices {
+		c.prices[strings.ToLower(name)] = p
+	}
+	return c
+}
+
+// LoadCatalog reads a catalog from a JSON object keyed by model name:
+//
+//	{"gpt-4o": {"input_per_million": 2.5, "output_per_million": 10}}
+func LoadCatalog(r io.Reader) (*Catalog, error) {
+	var prices map[string]Price
+	if err := json.NewDecoder(r).Decode(&prices); err != nil {
+		return nil, fmt.Errorf("decode pricing catalog: %w", err)
+	}
+	return NewCatalog(prices), nil
+}
+
+// Set adds or replaces the price of a model.
+func (c *Catalog) Set(modelName string, p Price) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prices[strings.ToLower(modelName)] = p
+}
+
+// Lookup returns the price of a model.
+// - Exact match (case-insensitive) first
+// - Longest prefix at a model-ID boundary second, so "gpt-4o" prices
+// "gpt-4o-2024-08-06" but not "gpt-4o-mini" when the latter is listed
+func (c *Catalog) Lookup(modelName string) (Price, bool) {
+	if c == nil || modelName == "" {
+		return Price{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key := strings.ToLower(modelName)
+	if p, ok := c.prices[key]; ok {
+		return p, true
+	}
+	var (
+		best    Price
+		bestLen int
+	)
+	for k, p := range c.prices {
+		if len(k) > bestLen && isModelPrefixMatch(key, k) {
+			best, bestLen = p, len(k)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Cost prices usage reported by modelName. It returns false when the model
+// has no price.
+func (c *Catalog) Cost(modelName string, usage *model.Usage) (Cost, bool) {
+	p, ok := c.Lookup(modelName)
+	if !ok {
+		return Cost{}, false
+	}
+	return p.Cost(TokensOf(usage)), true
+}
+
+// isModelPrefixMatch reports whether prefix matches a full model name or is
+// followed by a separator used by common snapshot/provider suffixes.
+func isModelPrefixMatch(modelName, prefix string) bool {
+	if !strings.HasPrefix(modelName, prefix) {
+		return false
+	}
+	if len(modelName) == len(prefix) {
+		return true
+	}
+	switch modelName[len(prefix)] {
+	case '-', '@', ':':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,119 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package pricing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestTokensOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage *model.Usage
+		want  Tokens
+	}{
+		{"nil", nil, Tokens{}},
+		{
+			name:  "plain",
+			usage: &model.Usage{PromptTokens: 100, CompletionTokens: 20},
+			want:  Tokens{Input: 100, Output: 20},
+		},
+		{
+			name: "openai cached and reasoning",
+			usage: &model.Usage{
+				PromptTokens:            100,
+				CompletionTokens:        50,
+				PromptTokensDetails:     model.PromptTokensDetails{CachedTokens: 60},
+				CompletionTokensDetails: model.CompletionTokensDetails{ReasoningTokens: 30},
+			},
+			want: Tokens{Input: 40, CachedInput: 60, Output: 20, Reasoning: 30},
+		},
+		{
+			name: "anthropic cache read and write",
+			usage: &model.Usage{
+				PromptTokens:     10,
+				CompletionTokens: 5,
+				PromptTokensDetails: model.PromptTokensDetails{
+					CachedTokens:        200,
+					CacheReadTokens:     200,
+					CacheCreationTokens: 50,
+				},
+			},
+			want: Tokens{Input: 10, CachedInput: 200, CacheWrite: 50, Output: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TokensOf(tt.usage))
+		})
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	p := Price{InputPerMillion: 2, CachedInputPerMillion: 1, OutputPerMillion: 8}
+	c := p.Cost(Tokens{Input: 1_000_000, CachedInput: 500_000, CacheWrite: 100_000, Output: 250_000, Reasoning: 250_000})
+	assert.InDelta(t, 2.0, c.Input, 1e-9)
+	assert.InDelta(t, 0.5, c.CachedInput, 1e-9)
+	assert.InDelta(t, 0.2, c.CacheWrite, 1e-9, "cache write falls back to input price")
+	assert.InDelta(t, 2.0, c.Output, 1e-9)
+	assert.InDelta(t, 2.0, c.Reasoning, 1e-9, "reasoning falls back to output price")
+	assert.InDelta(t, 6.7, c.Total, 1e-9)
+}
+
+func TestCatalog_Lookup(t *testing.T) {
+	c := NewCatalog(map[string]Price{
+		"GPT-4o":      {InputPerMillion: 2.5},
+		"gpt-4o-mini": {InputPerMillion: 0.15},
+	})
+	c.Set("claude-sonnet-4", Price{InputPerMillion: 3})
+
+	tests := []struct {
+		model string
+		input float64
+		ok    bool
+	}{
+		{"gpt-4o", 2.5, true},
+		{"gpt-4o-2024-08-06", 2.5, true},
+		{"gpt-4o-mini", 0.15, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"claude-sonnet-4@20250514", 3, true},
+		{"gpt-4omni", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := c.Lookup(tt.model)
+		assert.Equal(t, tt.ok, ok, tt.model)
+		assert.Equal(t, tt.input, p.InputPerMillion, tt.model)
+	}
+
+	var nilCatalog *Catalog
+	_, ok := nilCatalog.Lookup("gpt-4o")
+	assert.False(t, ok)
+}
+
+func TestCatalog_CostAndLoad(t *testing.T) {
+	c, err := LoadCatalog(strings.NewReader(`{"m": {"input_per_million": 1, "output_per_million": 2}}`))
+	require.NoError(t, err)
+	cost, ok := c.Cost("m", &model.Usage{PromptTokens: 1000, CompletionTokens: 1000})
+	require.True(t, ok)
+	assert.InDelta(t, 0.003, cost.Total, 1e-12)
+
+	_, ok = c.Cost("unknown", &model.Usage{PromptTokens: 1})
+	assert.False(t, ok)
+
+	_, err = LoadCatalog(strings.NewReader(`[`))
+	assert.Error(t, err)
+}
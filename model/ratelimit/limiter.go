@@ -0,0 +1,148 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter enforces the request and token budget of one model. The local
+// limiter keeps the budget in process memory; the redis limiter in
+// model/ratelimit/redis shares it across replicas.
+type Limiter interface {
+	// Reserve consumes one request and the given number of tokens. It
+	// returns zero when the reservation succeeded, or how long to wait
+	// before trying again, in which case nothing is consumed.
+	Reserve(ctx context.Context, tokens int) (time.Duration, error)
+	// Adjust corrects the token budget once the real usage is known.
+	// Positive values consume more tokens, negative values return them.
+	Adjust(ctx context.Context, tokens int) error
+	// Block rejects all reservations until the given time, e.g. when the
+	// provider asked to retry after a delay.
+	Block(ctx context.Context, until time.Time) error
+}
+
+// Limits are the budgets enforced by a limiter. Zero disables a dimension.
+type Limits struct {
+	// RequestsPerMinute is the maximum number of requests per minute.
+	RequestsPerMinute int
+	// TokensPerMinute is the maximum number of tokens per minute.
+	TokensPerMinute int
+}
+
+// localLimiter is an in-memory token bucket limiter. Each bucket holds up
+// to one minute of budget and refills continuously.
+type localLimiter struct {
+	limits Limits
+	now    func() time.Time
+
+	mu       sync.Mutex
+	requests float64
+	tokens   float64
+	last     time.Time
+	blocked  time.Time
+}
+
+// NewLocalLimiter creates an in-memory limiter for limits. Budgets start
+// full.
+func NewLocalLimiter(limits Limits) Limiter {
+	return &localLimiter{
+		limits:   limits,
+		now:      time.Now,
+		requests: float64(limits.RequestsPerMinute),
+		tokens:   float64(limits.TokensPerMinute),
+	}
+}
+
+// Reserve implements Limiter.
+func (l *localLimiter) Reserve(_ context.Context, tokens int) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.refill()
+
+	rpm := float64(l.limits.RequestsPerMinute)
+	tpm := float64(l.limits.TokensPerMinute)
+	// A request larger than the whole budget could never run; let it
+	// through once the bucket is full instead.
+	need := math.Min(float64(tokens), tpm)
+
+	var wait time.Duration
+	if l.blocked.After(now) {
+		wait = l.blocked.Sub(now)
+	}
+	if rpm > 0 && l.requests < 1 {
+		wait = max(wait, refillTime(1-l.requests, rpm))
+	}
+	if tpm > 0 && l.tokens < need {
+		wait = max(wait, refillTime(need-l.tokens, tpm))
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+	if rpm > 0 {
+		l.requests--
+	}
+	if tpm > 0 {
+		l.tokens -= need
+	}
+	return 0, nil
+}
+
+// Adjust implements Limiter.
+func (l *localLimiter) Adjust(_ context.Context, tokens int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits.TokensPerMinute <= 0 {
+		return nil
+	}
+	l.refill()
+	l.tokens = math.Min(float64(l.limits.TokensPerMinute), l.tokens-float64(tokens))
+	return nil
+}
+
+// Block implements Limiter.
+func (l *localLimiter) Block(_ context.Context, until time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.blocked) {
+		l.blocked = until
+	}
+	return nil
+}
+
+// refill adds the budget accrued since the last call and returns now.
+func (l *localLimiter) refill() time.Time {
+	now := l.now()
+	if l.last.IsZero() {
+		l.last = now
+		return now
+	}
+	elapsed := now.Sub(l.last).Minutes()
+	if elapsed <= 0 {
+		return now
+	}
+	l.last = now
+	if rpm := float64(l.limits.RequestsPerMinute); rpm > 0 {
+		l.requests = math.Min(rpm, l.requests+elapsed*rpm)
+	}
+	if tpm := float64(l.limits.TokensPerMinute); tpm > 0 {
+		l.tokens = math.Min(tpm, l.tokens+elapsed*tpm)
+	}
+	return now
+}
+
+// refillTime returns how long a bucket refilling perMinute units per minute
+// takes to accrue missing units.
+func refillTime(missing, perMinute float64) time.Duration {
+	return time.Duration(math.Ceil(missing / perMinute * float64(time.Minute)))
+}
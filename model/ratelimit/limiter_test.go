@@ -0,0 +1,84 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(limits Limits) (*localLimiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLocalLimiter(limits).(*localLimiter)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLocalLimiter_RequestsPerMinute(t *testing.T) {
+	l, now := newTestLimiter(Limits{RequestsPerMinute: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		wait, err := l.Reserve(ctx, 0)
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+	wait, err := l.Reserve(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, wait)
+
+	*now = now.Add(30 * time.Second)
+	wait, err = l.Reserve(ctx, 0)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
+
+func TestLocalLimiter_TokensPerMinute(t *testing.T) {
+	l, now := newTestLimiter(Limits{TokensPerMinute: 600})
+	ctx := context.Background()
+
+	wait, _ := l.Reserve(ctx, 500)
+	assert.Zero(t, wait)
+	wait, _ = l.Reserve(ctx, 200)
+	assert.Equal(t, 10*time.Second, wait, "100 missing tokens at 10 tokens/s")
+
+	// Usage lower than estimated returns tokens.
+	require.NoError(t, l.Adjust(ctx, -100))
+	wait, _ = l.Reserve(ctx, 200)
+	assert.Zero(t, wait)
+
+	// Requests larger than the whole budget run once the bucket is full.
+	wait, _ = l.Reserve(ctx, 10_000)
+	assert.Equal(t, time.Minute, wait)
+	*now = now.Add(time.Minute)
+	wait, _ = l.Reserve(ctx, 10_000)
+	assert.Zero(t, wait)
+}
+
+func TestLocalLimiter_Block(t *testing.T) {
+	l, now := newTestLimiter(Limits{})
+	ctx := context.Background()
+
+	wait, _ := l.Reserve(ctx, 1)
+	assert.Zero(t, wait, "no limits admit everything")
+
+	require.NoError(t, l.Block(ctx, now.Add(5*time.Second)))
+	require.NoError(t, l.Block(ctx, now.Add(time.Second)), "earlier block does not shorten")
+	wait, _ = l.Reserve(ctx, 1)
+	assert.Equal(t, 5*time.Second, wait)
+
+	*now = now.Add(5 * time.Second)
+	wait, _ = l.Reserve(ctx, 1)
+	assert.Zero(t, wait)
+}
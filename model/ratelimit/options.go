@@ -0,0 +1,148 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const defaultCooldown = 5 * time.Second
+
+// KeyFunc returns the fairness key of a request. Queued requests are
+// dispatched in round-robin order across keys.
+type KeyFunc func(ctx context.Context, req *model.Request) string
+
+type options struct {
+	limits       Limits
+	limiter      Limiter
+	tokenCounter model.TokenCounter
+	keyFunc      KeyFunc
+	maxWait      time.Duration
+	maxRetries   int
+	retryAfter   RetryAfterFunc
+	cooldown     time.Duration
+}
+
+// Option configures a rate limited model.
+type Option func(*options)
+
+func newOptions(opt ...Option) options {
+	opts := options{
+		keyFunc:    KeyByUser,
+		retryAfter: DefaultRetryAfter,
+		cooldown:   defaultCooldown,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if opts.tokenCounter == nil {
+		opts.tokenCounter = model.NewSimpleTokenCounter()
+	}
+	return opts
+}
+
+// WithRequestsPerMinute sets the local requests-per-minute limit. Zero
+// disables it. Ignored when WithLimiter is set.
+func WithRequestsPerMinute(rpm int) Option {
+	return func(o *options) {
+		o.limits.RequestsPerMinute = rpm
+	}
+}
+
+// WithTokensPerMinute sets the local tokens-per-minute limit. Zero disables
+// it. Ignored when WithLimiter is set.
+func WithTokensPerMinute(tpm int) Option {
+	return func(o *options) {
+		o.limits.TokensPerMinute = tpm
+	}
+}
+
+// WithLimiter sets the limiter holding the budget, e.g. a redis limiter
+// shared across replicas. It overrides WithRequestsPerMinute and
+// WithTokensPerMinute.
+func WithLimiter(l Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithTokenCounter sets the counter estimating input tokens before
+// dispatch, e.g. a model/tiktoken counter. Default is
+// model.NewSimpleTokenCounter().
+func WithTokenCounter(c model.TokenCounter) Option {
+	return func(o *options) {
+		o.tokenCounter = c
+	}
+}
+
+// WithKeyFunc sets the fairness key of requests. Default is KeyByUser.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.keyFunc = fn
+		}
+	}
+}
+
+// WithMaxQueueWait bounds how long a request waits for budget. A request
+// that times out gets an error response of type ErrorTypeRateLimited.
+// Zero, the default, waits until the context is done.
+func WithMaxQueueWait(d time.Duration) Option {
+	return func(o *options) {
+		o.maxWait = d
+	}
+}
+
+// WithMaxRetries sets how many times a request rejected by the provider
+// for exceeding a rate limit is queued again before the error is returned.
+// Retries only happen before any content was emitted. Default is 0.
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.maxRetries = n
+	}
+}
+
+// WithRetryAfter sets how provider rate limit errors are recognized.
+// Default is DefaultRetryAfter.
+func WithRetryAfter(fn RetryAfterFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.retryAfter = fn
+		}
+	}
+}
+
+// WithDefaultCooldown sets how long dispatching pauses after a provider
+// rate limit error without a retry hint. Default is 5s.
+func WithDefaultCooldown(d time.Duration) Option {
+	return func(o *options) {
+		o.cooldown = d
+	}
+}
+
+// KeyByUser groups requests by the app and user of the invocation in ctx.
+func KeyByUser(ctx context.Context, _ *model.Request) string {
+	if inv, ok := agent.InvocationFromContext(ctx); ok && inv != nil && inv.Session != nil {
+		return inv.Session.AppName + "/" + inv.Session.UserID
+	}
+	return ""
+}
+
+// KeyByApp groups requests by the app of the invocation in ctx.
+func KeyByApp(ctx context.Context, _ *model.Request) string {
+	if inv, ok := agent.InvocationFromContext(ctx); ok && inv != nil && inv.Session != nil {
+		return inv.Session.AppName
+	}
+	return ""
+}
@@ -0,0 +1,279 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package ratelimit provides a model.Model wrapper that throttles requests
+// on the client side to stay within provider rate limits.
+//
+// Each request estimates its input tokens before dispatch and waits until
+// the requests-per-minute and tokens-per-minute budgets admit it. Waiting
+// requests are served in round-robin order across fairness keys (app/user
+// by default), so one busy user cannot starve the others. When the
+// provider still answers with a rate limit error, dispatching pauses for
+// the Retry-After delay it asked for:
+//
+//	counter, _ := tiktoken.New("gpt-4o")
+//	llm, err := ratelimit.New(openai.New("gpt-4o"),
+//		ratelimit.WithRequestsPerMinute(500),
+//		ratelimit.WithTokensPerMinute(200_000),
+//		ratelimit.WithTokenCounter(counter),
+//	)
+//
+// Budgets live in memory by default; use WithLimiter with the limiter from
+// model/ratelimit/redis to share them across replicas.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// ErrorTypeRateLimited is the response error type used when a request
+// could not be admitted within the configured queue wait.
+const ErrorTypeRateLimited = "rate_limited"
+
+// ErrQueueTimeout is returned when a request waits longer than the
+// configured maximum queue wait.
+var ErrQueueTimeout = errors.New("ratelimit: queue wait timeout")
+
+// limitedModel throttles calls to a wrapped model.
+type limitedModel struct {
+	base  model.Model
+	opts  options
+	sched *scheduler
+}
+
+// New creates a rate limited wrapper around base.
+func New(base model.Model, opts ...Option) (model.Model, error) {
+	if base == nil {
+		return nil, errors.New("ratelimit: base model is nil")
+	}
+	o := newOptions(opts...)
+	if o.limits.RequestsPerMinute < 0 || o.limits.TokensPerMinute < 0 {
+		return nil, fmt.Errorf("ratelimit: negative limits %+v", o.limits)
+	}
+	if o.limiter == nil {
+		o.limiter = NewLocalLimiter(o.limits)
+	}
+	return &limitedModel{base: base, opts: o, sched: newScheduler(o.limiter)}, nil
+}
+
+// Info returns the wrapped model info.
+func (m *limitedModel) Info() model.Info {
+	return m.base.Info()
+}
+
+// InputTokenBudget forwards the budget advertised by the wrapped model.
+func (m *limitedModel) InputTokenBudget(ctx context.Context, request *model.Request) int {
+	type budgeter interface {
+		InputTokenBudget(context.Context, *model.Request) int
+	}
+	if b, ok := m.base.(budgeter); ok {
+		return b.InputTokenBudget(ctx, request)
+	}
+	return 0
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *limitedModel) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	seq, err := m.GenerateContentIter(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, 1)
+	go func() {
+		defer close(responseChan)
+		seq(func(resp *model.Response) bool {
+			if resp == nil {
+				return true
+			}
+			select {
+			case responseChan <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return responseChan, nil
+}
+
+// GenerateContentIter implements the model.IterModel interface. The
+// returned sequence waits for budget before calling the wrapped model.
+func (m *limitedModel) GenerateContentIter(
+	ctx context.Context,
+	request *model.Request,
+) (model.Seq[*model.Response], error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	tokens := m.estimateTokens(ctx, request)
+	key := m.opts.keyFunc(ctx, request)
+	return func(yield func(*model.Response) bool) {
+		for attempt := 0; ; attempt++ {
+			if err := m.sched.wait(ctx, key, tokens, m.opts.maxWait); err != nil {
+				if ctx.Err() == nil {
+					yield(errorResponse(ErrorTypeRateLimited, err.Error()))
+				}
+				return
+			}
+			if !m.attempt(ctx, request, tokens, attempt < m.opts.maxRetries, yield) {
+				return
+			}
+		}
+	}, nil
+}
+
+// attempt runs one call of the wrapped model and reports whether it should
+// be retried after a provider rate limit error.
+func (m *limitedModel) attempt(
+	ctx context.Context,
+	request *model.Request,
+	tokens int,
+	canRetry bool,
+	yield func(*model.Response) bool,
+) bool {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	seq, err := sequenceFor(attemptCtx, m.base, request)
+	if err != nil {
+		// The request never reached the provider, so its reservation is
+		// given back.
+		m.adjust(ctx, -tokens)
+		yield(errorResponse(model.ErrorTypeAPIError, err.Error()))
+		return false
+	}
+	var (
+		retry    bool
+		emitted  bool
+		rejected bool
+		used     int
+	)
+	seq(func(resp *model.Response) bool {
+		if resp == nil {
+			return true
+		}
+		if resp.Error != nil {
+			if delay, limited := m.opts.retryAfter(resp); limited {
+				m.block(ctx, delay)
+				rejected = !emitted
+				if canRetry && !emitted {
+					retry = true
+					return false
+				}
+			}
+		} else {
+			emitted = true
+		}
+		if resp.Usage != nil && resp.Usage.TotalTokens > 0 {
+			used = resp.Usage.TotalTokens
+		}
+		return yield(resp)
+	})
+	var delta int
+	switch {
+	case used > 0:
+		delta = used - tokens
+	case rejected:
+		// The provider rejected the request, so it consumed no tokens and
+		// its reservation is given back.
+		delta = -tokens
+	}
+	m.adjust(ctx, delta)
+	return retry
+}
+
+// adjust corrects the tokens charged for a call by delta.
+func (m *limitedModel) adjust(ctx context.Context, delta int) {
+	if delta == 0 {
+		return
+	}
+	if err := m.opts.limiter.Adjust(ctx, delta); err != nil {
+		log.WarnfContext(ctx, "ratelimit: adjust tokens failed: %v", err)
+	}
+}
+
+// block pauses dispatching after a provider rate limit error.
+func (m *limitedModel) block(ctx context.Context, delay time.Duration) {
+	if delay <= 0 {
+		delay = m.opts.cooldown
+	}
+	if delay <= 0 {
+		return
+	}
+	if err := m.opts.limiter.Block(ctx, time.Now().Add(delay)); err != nil {
+		log.WarnfContext(ctx, "ratelimit: block failed: %v", err)
+	}
+}
+
+// estimateTokens estimates the tokens a request consumes: its input plus
+// the requested maximum output, if any.
+func (m *limitedModel) estimateTokens(ctx context.Context, request *model.Request) int {
+	tokens, err := m.opts.tokenCounter.CountTokensRange(ctx, request.Messages, 0, len(request.Messages))
+	if err != nil {
+		log.WarnfContext(ctx, "ratelimit: count tokens failed: %v", err)
+		tokens = 0
+	}
+	if request.GenerationConfig.MaxTokens != nil && *request.GenerationConfig.MaxTokens > 0 {
+		tokens += *request.GenerationConfig.MaxTokens
+	}
+	return tokens
+}
+
+func errorResponse(errType, message string) *model.Response {
+	now := time.Now()
+	return &model.Response{
+		Object:    model.ObjectTypeError,
+		Created:   now.Unix(),
+		Timestamp: now,
+		Done:      true,
+		Error: &model.ResponseError{
+			Type:    errType,
+			Message: message,
+		},
+	}
+}
+
+func sequenceFor(
+	ctx context.Context,
+	base model.Model,
+	request *model.Request,
+) (model.Seq[*model.Response], error) {
+	if iterModel, ok := base.(model.IterModel); ok {
+		seq, err := iterModel.GenerateContentIter(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if seq == nil {
+			return nil, fmt.Errorf("model %q returned nil response sequence", base.Info().Name)
+		}
+		return seq, nil
+	}
+	responseChan, err := base.GenerateContent(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if responseChan == nil {
+		return nil, fmt.Errorf("model %q returned nil response channel", base.Info().Name)
+	}
+	return func(yield func(*model.Response) bool) {
+		for response := range responseChan {
+			if !yield(response) {
+				return
+			}
+		}
+	}, nil
+}
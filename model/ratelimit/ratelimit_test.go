@@ -0,0 +1,299 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// fakeModel answers with the next scripted response, or "ok" once the
+// script is exhausted.
+type fakeModel struct {
+	mu     sync.Mutex
+	script []*model.Response
+	calls  atomic.Int32
+}
+
+func (m *fakeModel) Info() model.Info { return model.Info{Name: "fake"} }
+
+func (m *fakeModel) GenerateContent(_ context.Context, _ *model.Request) (<-chan *model.Response, error) {
+	m.calls.Add(1)
+	m.mu.Lock()
+	resp := &model.Response{
+		Done:    true,
+		Usage:   &model.Usage{TotalTokens: 50},
+		Choices: []model.Choice{{Message: model.NewAssistantMessage("ok")}},
+	}
+	if len(m.script) > 0 {
+		resp, m.script = m.script[0], m.script[1:]
+	}
+	m.mu.Unlock()
+	ch := make(chan *model.Response, 1)
+	ch <- resp
+	close(ch)
+	return ch, nil
+}
+
+// gateLimiter admits one reservation per permit and records adjustments.
+type gateLimiter struct {
+	permits  atomic.Int32
+	adjusted atomic.Int64
+	blocked  atomic.Int64
+}
+
+func (l *gateLimiter) Reserve(context.Context, int) (time.Duration, error) {
+	for {
+		p := l.permits.Load()
+		if p <= 0 {
+			return time.Millisecond, nil
+		}
+		if l.permits.CompareAndSwap(p, p-1) {
+			return 0, nil
+		}
+	}
+}
+
+func (l *gateLimiter) Adjust(_ context.Context, tokens int) error {
+	l.adjusted.Add(int64(tokens))
+	return nil
+}
+
+func (l *gateLimiter) Block(_ context.Context, until time.Time) error {
+	l.blocked.Store(int64(time.Until(until)))
+	return nil
+}
+
+func drain(t *testing.T, llm model.Model, ctx context.Context, req *model.Request) []*model.Response {
+	t.Helper()
+	ch, err := llm.GenerateContent(ctx, req)
+	require.NoError(t, err)
+	var out []*model.Response
+	for resp := range ch {
+		out = append(out, resp)
+	}
+	return out
+}
+
+func rateLimitError(msg string) *model.Response {
+	return &model.Response{Done: true, Error: &model.ResponseError{Type: model.ErrorTypeAPIError, Message: msg}}
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(nil)
+	assert.Error(t, err)
+	_, err = New(&fakeModel{}, WithRequestsPerMinute(-1))
+	assert.Error(t, err)
+	llm, err := New(&fakeModel{}, WithRequestsPerMinute(10))
+	require.NoError(t, err)
+	assert.Equal(t, "fake", llm.Info().Name)
+	_, ok := llm.(model.IterModel)
+	assert.True(t, ok)
+}
+
+func TestRateLimit_AdjustsToActualUsage(t *testing.T) {
+	lim := &gateLimiter{}
+	lim.permits.Store(1)
+	llm, err := New(&fakeModel{}, WithLimiter(lim))
+	require.NoError(t, err)
+
+	maxTokens := 100
+	req := &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hello")},
+		GenerationConfig: model.GenerationConfig{MaxTokens: &maxTokens},
+	}
+	impl := llm.(*limitedModel)
+	estimated := impl.estimateTokens(context.Background(), req)
+	assert.Greater(t, estimated, maxTokens)
+
+	out := drain(t, llm, context.Background(), req)
+	require.Len(t, out, 1)
+	assert.Equal(t, int64(50-estimated), lim.adjusted.Load())
+}
+
+func TestRateLimit_QueueTimeout(t *testing.T) {
+	base := &fakeModel{}
+	llm, err := New(base, WithLimiter(&gateLimiter{}), WithMaxQueueWait(20*time.Millisecond))
+	require.NoError(t, err)
+
+	out := drain(t, llm, context.Background(), &model.Request{})
+	require.Len(t, out, 1)
+	require.NotNil(t, out[0].Error)
+	assert.Equal(t, ErrorTypeRateLimited, out[0].Error.Type)
+	assert.Zero(t, base.calls.Load())
+	assert.Zero(t, llm.(*limitedModel).sched.queued())
+}
+
+func TestRateLimit_ContextCancel(t *testing.T) {
+	base := &fakeModel{}
+	llm, err := New(base, WithLimiter(&gateLimiter{}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	out := drain(t, llm, ctx, &model.Request{})
+	assert.Empty(t, out)
+	assert.Zero(t, base.calls.Load())
+}
+
+func TestRateLimit_FairAcrossKeys(t *testing.T) {
+	lim := &gateLimiter{}
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	llm, err := New(&fakeModel{}, WithLimiter(lim), WithKeyFunc(func(_ context.Context, req *model.Request) string {
+		return req.Messages[0].Content[:1]
+	}))
+	require.NoError(t, err)
+	sched := llm.(*limitedModel).sched
+
+	var wg sync.WaitGroup
+	for i, name := range []string{"a1", "a2", "a3", "b1"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			drain(t, llm, context.Background(), &model.Request{Messages: []model.Message{model.NewUserMessage(name)}})
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}(name)
+		require.Eventually(t, func() bool { return sched.queued() == i+1 }, time.Second, time.Millisecond)
+	}
+	for i := 1; i <= 4; i++ {
+		lim.permits.Store(1)
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(order) == i
+		}, time.Second, time.Millisecond)
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"a1", "b1", "a2", "a3"}, order)
+}
+
+func TestRateLimit_RetryAfterProviderError(t *testing.T) {
+	base := &fakeModel{script: []*model.Response{
+		rateLimitError(`POST "https://api.openai.com/v1/chat/completions": 429 Too Many Requests ` +
+			`{"message":"Rate limit reached. Please try again in 20ms."}`),
+	}}
+	lim := &gateLimiter{}
+	lim.permits.Store(2)
+	llm, err := New(base, WithLimiter(lim), WithMaxRetries(1))
+	require.NoError(t, err)
+
+	out := drain(t, llm, context.Background(), &model.Request{})
+	require.Len(t, out, 1)
+	assert.Nil(t, out[0].Error)
+	assert.Equal(t, "ok", out[0].Choices[0].Message.Content)
+	assert.Equal(t, int32(2), base.calls.Load())
+	assert.InDelta(t, float64(20*time.Millisecond), float64(lim.blocked.Load()), float64(10*time.Millisecond))
+}
+
+func TestRateLimit_RetryReturnsRejectedTokens(t *testing.T) {
+	const tpm = 100000
+	base := &fakeModel{script: []*model.Response{rateLimitError("429 Too Many Requests")}}
+	lim := NewLocalLimiter(Limits{TokensPerMinute: tpm})
+	llm, err := New(base, WithLimiter(lim), WithMaxRetries(1), WithDefaultCooldown(time.Millisecond))
+	require.NoError(t, err)
+
+	maxTokens := 1000
+	out := drain(t, llm, context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hello")},
+		GenerationConfig: model.GenerationConfig{MaxTokens: &maxTokens},
+	})
+	require.Len(t, out, 1)
+	assert.Nil(t, out[0].Error)
+	assert.Equal(t, int32(2), base.calls.Load())
+
+	// Only the usage of the successful call is charged.
+	local := lim.(*localLimiter)
+	local.mu.Lock()
+	defer local.mu.Unlock()
+	assert.InDelta(t, float64(tpm-50), local.tokens, 10)
+}
+
+// failingModel fails every call before producing a response.
+type failingModel struct{}
+
+func (failingModel) Info() model.Info { return model.Info{Name: "failing"} }
+
+func (failingModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestRateLimit_ReturnsTokensOnCallError(t *testing.T) {
+	lim := &gateLimiter{}
+	lim.permits.Store(1)
+	llm, err := New(failingModel{}, WithLimiter(lim))
+	require.NoError(t, err)
+
+	maxTokens := 1000
+	req := &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hello")},
+		GenerationConfig: model.GenerationConfig{MaxTokens: &maxTokens},
+	}
+	estimated := llm.(*limitedModel).estimateTokens(context.Background(), req)
+
+	out := drain(t, llm, context.Background(), req)
+	require.Len(t, out, 1)
+	require.NotNil(t, out[0].Error)
+	assert.Equal(t, int64(-estimated), lim.adjusted.Load())
+}
+
+func TestRateLimit_NoRetryReturnsError(t *testing.T) {
+	base := &fakeModel{script: []*model.Response{rateLimitError("429 Too Many Requests")}}
+	lim := &gateLimiter{}
+	lim.permits.Store(1)
+	llm, err := New(base, WithLimiter(lim), WithDefaultCooldown(time.Second))
+	require.NoError(t, err)
+
+	out := drain(t, llm, context.Background(), &model.Request{})
+	require.Len(t, out, 1)
+	require.NotNil(t, out[0].Error)
+	assert.Equal(t, int32(1), base.calls.Load())
+	assert.InDelta(t, float64(time.Second), float64(lim.blocked.Load()), float64(50*time.Millisecond))
+}
+
+func TestDefaultRetryAfter(t *testing.T) {
+	code := "rate_limit_exceeded"
+	tests := []struct {
+		name    string
+		resp    *model.Response
+		delay   time.Duration
+		limited bool
+	}{
+		{"nil", nil, 0, false},
+		{"no error", &model.Response{}, 0, false},
+		{"other error", rateLimitError("500 Internal Server Error"), 0, false},
+		{"status only", rateLimitError("429 Too Many Requests"), 0, true},
+		{"retry-after header", rateLimitError("429 Too Many Requests, Retry-After: 7"), 7 * time.Second, true},
+		{"try again ms", rateLimitError("Rate limit reached. Please try again in 250ms."), 250 * time.Millisecond, true},
+		{"try again s", rateLimitError("rate limit exceeded, try again in 1.5s"), 1500 * time.Millisecond, true},
+		{"code", &model.Response{Error: &model.ResponseError{Message: "slow down", Code: &code}}, 0, true},
+		{"type", &model.Response{Error: &model.ResponseError{Message: "slow down", Type: "rate_limit_error"}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, limited := DefaultRetryAfter(tt.resp)
+			assert.Equal(t, tt.limited, limited)
+			assert.Equal(t, tt.delay, delay)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package redis provides a redis-backed ratelimit.Limiter, so that model
+// rate limits hold across every replica sharing the same provider key.
+//
+// Each limiter keeps its token buckets in one hash, updated atomically by
+// Lua scripts that use the redis server clock:
+//
+//	{prefix}{name}    req, tok, ts, blocked
+//
+// Use one name per provider key and model:
+//
+//	lim, err := redis.NewLimiter("openai:gpt-4o", ratelimit.Limits{
+//		RequestsPerMinute: 500,
+//		TokensPerMinute:   200_000,
+//	}, redis.WithRedisClientURL("redis://localhost:6379"))
+//	llm, err := ratelimit.New(openai.New("gpt-4o"), ratelimit.WithLimiter(lim))
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-agent-go/model/ratelimit"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+// luaRefill loads the bucket state and refills it up to now. It is shared
+// by the scripts below.
+//
+// KEYS[1] = bucket hash
+// ARGV[1] = requests per minute (0 disables)
+// ARGV[2] = tokens per minute (0 disables)
+const luaRefill = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local rpm = tonumber(ARGV[1])
+local tpm = tonumber(ARGV[2])
+local st = redis.call('HMGET', KEYS[1], 'req', 'tok', 'ts', 'blocked')
+local req = tonumber(st[1]) or rpm
+local tok = tonumber(st[2]) or tpm
+local ts = tonumber(st[3]) or now
+local blocked = tonumber(st[4]) or 0
+local elapsed = math.max(0, now - ts)
+if rpm > 0 then req = math.min(rpm, req + elapsed * rpm / 60000) end
+if tpm > 0 then tok = math.min(tpm, tok + elapsed * tpm / 60000) end
+
+local function save()
+  redis.call('HSET', KEYS[1], 'req', req, 'tok', tok, 'ts', now, 'blocked', blocked)
+  -- An idle bucket is full again after a minute.
+  redis.call('PEXPIRE', KEYS[1], 60000 + math.max(0, blocked - now))
+end
+`
+
+// luaReserve returns 0 when the reservation succeeded, or the milliseconds
+// to wait before trying again.
+//
+// ARGV[3] = tokens
+var luaReserve = redis.NewScript(luaRefill + `
+local need = tonumber(ARGV[3])
+if tpm > 0 and need > tpm then need = tpm end
+local wait = 0
+if blocked > now then wait = blocked - now end
+if rpm > 0 and req < 1 then
+  wait = math.max(wait, math.ceil((1 - req) * 60000 / rpm))
+end
+if tpm > 0 and tok < need then
+  wait = math.max(wait, math.ceil((need - tok) * 60000 / tpm))
+end
+if wait == 0 then
+  if rpm > 0 then req = req - 1 end
+  if tpm > 0 then tok = tok - need end
+end
+save()
+return wait
+`)
+
+// luaAdjust consumes (positive) or returns (negative) tokens.
+//
+// ARGV[3] = tokens
+var luaAdjust = redis.NewScript(luaRefill + `
+if tpm > 0 then tok = math.min(tpm, tok - tonumber(ARGV[3])) end
+save()
+return 1
+`)
+
+// luaBlock rejects reservations for the given milliseconds.
+//
+// ARGV[3] = delay_ms
+var luaBlock = redis.NewScript(luaRefill + `
+blocked = math.max(blocked, now + tonumber(ARGV[3]))
+save()
+return 1
+`)
+
+var _ ratelimit.Limiter = (*Limiter)(nil)
+
+// Limiter is a ratelimit.Limiter backed by redis.
+type Limiter struct {
+	opts   Options
+	client redis.UniversalClient
+	key    string
+	limits ratelimit.Limits
+}
+
+// NewLimiter creates a redis limiter enforcing limits for the budget
+// called name. Limiters created with the same name on any replica share
+// one budget.
+func NewLimiter(name string, limits ratelimit.Limits, options ...Option) (*Limiter, error) {
+	if name == "" {
+		return nil, errors.New("limiter name is empty")
+	}
+	if limits.RequestsPerMinute < 0 || limits.TokensPerMinute < 0 {
+		return nil, fmt.Errorf("negative limits %+v", limits)
+	}
+	opts := defaultOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	builderOpts := []storage.ClientBuilderOpt{
+		storage.WithClientBuilderURL(opts.url),
+		storage.WithExtraOptions(opts.extraOptions...),
+	}
+
+	// if instance name set, and url not set, use instance name to create redis client
+	if opts.url == "" && opts.instanceName != "" {
+		var ok bool
+		if builderOpts, ok = storage.GetRedisInstance(opts.instanceName); !ok {
+			return nil, fmt.Errorf("redis instance %s not found", opts.instanceName)
+		}
+	}
+
+	redisClient, err := storage.GetClientBuilder()(builderOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create redis client from url failed: %w", err)
+	}
+	return &Limiter{
+		opts:   opts,
+		client: redisClient,
+		key:    opts.keyPrefix + "{" + name + "}",
+		limits: limits,
+	}, nil
+}
+
+// Reserve implements ratelimit.Limiter.
+func (l *Limiter) Reserve(ctx context.Context, tokens int) (time.Duration, error) {
+	ms, err := l.run(ctx, luaReserve, int64(tokens))
+	if err != nil {
+		return 0, fmt.Errorf("reserve: %w", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Adjust implements ratelimit.Limiter.
+func (l *Limiter) Adjust(ctx context.Context, tokens int) error {
+	if _, err := l.run(ctx, luaAdjust, int64(tokens)); err != nil {
+		return fmt.Errorf("adjust: %w", err)
+	}
+	return nil
+}
+
+// Block implements ratelimit.Limiter. The delay is measured against the
+// redis server clock, so replicas with skewed clocks agree on it.
+func (l *Limiter) Block(ctx context.Context, until time.Time) error {
+	delay := time.Until(until).Milliseconds()
+	if delay <= 0 {
+		return nil
+	}
+	if _, err := l.run(ctx, luaBlock, delay); err != nil {
+		return fmt.Errorf("block: %w", err)
+	}
+	return nil
+}
+
+// Close closes the redis client.
+func (l *Limiter) Close() error {
+	if l.client != nil {
+		return l.client.Close()
+	}
+	return nil
+}
+
+func (l *Limiter) run(ctx context.Context, script *redis.Script, arg int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.timeout)
+	defer cancel()
+	return script.Run(ctx, l.client, []string{l.key},
+		l.limits.RequestsPerMinute, l.limits.TokensPerMinute, arg).Int64()
+}
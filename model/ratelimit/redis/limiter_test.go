@@ -0,0 +1,152 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/ratelimit"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+func setupTestRedis(t *testing.T) (*miniredis.Miniredis, string) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	mr.SetTime(time.Unix(1_700_000_000, 0))
+	return mr, "redis://" + mr.Addr()
+}
+
+func newTestLimiter(t *testing.T, url string, limits ratelimit.Limits, opts ...Option) *Limiter {
+	t.Helper()
+	l, err := NewLimiter("gpt", limits, append([]Option{WithRedisClientURL(url)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestNewLimiter_Errors(t *testing.T) {
+	_, err := NewLimiter("", ratelimit.Limits{}, WithRedisClientURL("redis://localhost:6379"))
+	require.Error(t, err)
+	_, err = NewLimiter("gpt", ratelimit.Limits{RequestsPerMinute: -1}, WithRedisClientURL("redis://localhost:6379"))
+	require.Error(t, err)
+	_, err = NewLimiter("gpt", ratelimit.Limits{}, WithRedisClientURL(""))
+	require.Error(t, err)
+	_, err = NewLimiter("gpt", ratelimit.Limits{}, WithRedisInstance("no-instance"))
+	require.Error(t, err)
+}
+
+func TestNewLimiter_WithRedisInstance(t *testing.T) {
+	_, url := setupTestRedis(t)
+	storage.RegisterRedisInstance("ratelimit-test", storage.WithClientBuilderURL(url))
+	l, err := NewLimiter("gpt", ratelimit.Limits{RequestsPerMinute: 1}, WithRedisInstance("ratelimit-test"))
+	require.NoError(t, err)
+	defer l.Close()
+	wait, err := l.Reserve(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
+
+func TestLimiter_SharedBudget(t *testing.T) {
+	mr, url := setupTestRedis(t)
+	limits := ratelimit.Limits{RequestsPerMinute: 2, TokensPerMinute: 600}
+	a := newTestLimiter(t, url, limits)
+	b := newTestLimiter(t, url, limits)
+	ctx := context.Background()
+
+	wait, err := a.Reserve(ctx, 300)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+	wait, err = b.Reserve(ctx, 300)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	// Both replicas drew from one budget.
+	wait, err = a.Reserve(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, wait)
+
+	mr.SetTime(time.Unix(1_700_000_030, 0))
+	wait, err = b.Reserve(ctx, 100)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+	assert.Greater(t, mr.TTL("ratelimit:{gpt}"), time.Duration(0))
+}
+
+func TestLimiter_AdjustAndBlock(t *testing.T) {
+	mr, url := setupTestRedis(t)
+	l := newTestLimiter(t, url, ratelimit.Limits{TokensPerMinute: 600}, WithKeyPrefix("rl:"))
+	ctx := context.Background()
+
+	wait, _ := l.Reserve(ctx, 600)
+	assert.Zero(t, wait)
+	wait, _ = l.Reserve(ctx, 60)
+	assert.Equal(t, 6*time.Second, wait)
+	require.NoError(t, l.Adjust(ctx, -60))
+	wait, _ = l.Reserve(ctx, 60)
+	assert.Zero(t, wait)
+
+	require.NoError(t, l.Adjust(ctx, -600))
+	require.NoError(t, l.Block(ctx, time.Now().Add(10*time.Second)))
+	wait, _ = l.Reserve(ctx, 1)
+	assert.InDelta(t, float64(10*time.Second), float64(wait), float64(100*time.Millisecond))
+
+	mr.SetTime(time.Unix(1_700_000_011, 0))
+	wait, _ = l.Reserve(ctx, 1)
+	assert.Zero(t, wait)
+	assert.True(t, mr.Exists("rl:{gpt}"))
+}
+
+// TestLimiter_WithModel throttles a wrapped model across two wrappers that
+// share one redis budget.
+func TestLimiter_WithModel(t *testing.T) {
+	_, url := setupTestRedis(t)
+	limits := ratelimit.Limits{RequestsPerMinute: 1}
+	newModel := func() model.Model {
+		llm, err := ratelimit.New(&stubModel{},
+			ratelimit.WithLimiter(newTestLimiter(t, url, limits)),
+			ratelimit.WithMaxQueueWait(50*time.Millisecond))
+		require.NoError(t, err)
+		return llm
+	}
+	call := func(llm model.Model) *model.Response {
+		ch, err := llm.GenerateContent(context.Background(), &model.Request{})
+		require.NoError(t, err)
+		var last *model.Response
+		for resp := range ch {
+			last = resp
+		}
+		return last
+	}
+
+	first := call(newModel())
+	assert.Nil(t, first.Error)
+	second := call(newModel())
+	require.NotNil(t, second.Error)
+	assert.Equal(t, ratelimit.ErrorTypeRateLimited, second.Error.Type)
+}
+
+type stubModel struct{}
+
+func (stubModel) Info() model.Info { return model.Info{Name: "stub"} }
+
+func (stubModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	ch := make(chan *model.Response, 1)
+	ch <- &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage("ok")}}}
+	close(ch)
+	return ch, nil
+}
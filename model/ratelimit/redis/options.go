@@ -0,0 +1,75 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redis
+
+import "time"
+
+const (
+	defaultKeyPrefix = "ratelimit:"
+	defaultTimeout   = time.Second
+)
+
+var defaultOptions = Options{
+	keyPrefix: defaultKeyPrefix,
+	timeout:   defaultTimeout,
+}
+
+// Options is the options for the redis rate limiter.
+type Options struct {
+	url          string
+	instanceName string
+	extraOptions []any
+	keyPrefix    string
+	timeout      time.Duration
+}
+
+// Option is the option for the redis rate limiter.
+type Option func(*Options)
+
+// WithRedisClientURL creates a redis client from URL and sets it to the limiter.
+func WithRedisClientURL(url string) Option {
+	return func(opts *Options) {
+		opts.url = url
+	}
+}
+
+// WithRedisInstance uses a redis instance from storage.
+// Note: WithRedisClientURL has higher priority than WithRedisInstance.
+// If both are specified, WithRedisClientURL will be used.
+func WithRedisInstance(instanceName string) Option {
+	return func(opts *Options) {
+		opts.instanceName = instanceName
+	}
+}
+
+// WithExtraOptions sets the extra options for the redis rate limiter.
+// this option mainly used for the customized redis client builder, it will be passed to the builder.
+func WithExtraOptions(extraOptions ...any) Option {
+	return func(opts *Options) {
+		opts.extraOptions = append(opts.extraOptions, extraOptions...)
+	}
+}
+
+// WithKeyPrefix sets the prefix of the keys holding budgets.
+// Default is "ratelimit:".
+func WithKeyPrefix(prefix string) Option {
+	return func(opts *Options) {
+		opts.keyPrefix = prefix
+	}
+}
+
+// WithTimeout bounds every redis command. Default is 1s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		if timeout > 0 {
+			opts.timeout = timeout
+		}
+	}
+}
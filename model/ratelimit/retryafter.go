@@ -0,0 +1,78 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package ratelimit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+var (
+	// retryAfterPattern matches "Retry-After: 20" style hints, in seconds
+	// unless a unit follows.
+	retryAfterPattern = regexp.MustCompile(`(?i)retry[- _]after["':=\s]+(\d+(?:\.\d+)?)\s*(ms|s|sec|seconds?)?\b`)
+	// tryAgainPattern matches "Please try again in 1.5s" style hints.
+	tryAgainPattern = regexp.MustCompile(`(?i)try again in (\d+(?:\.\d+)?)\s*(ms|s|m|sec|seconds?|minutes?)\b`)
+)
+
+// RetryAfterFunc inspects an error response. It reports whether the
+// provider rejected the request for exceeding a rate limit and, when the
+// provider said so, how long to wait before the next request.
+type RetryAfterFunc func(resp *model.Response) (delay time.Duration, limited bool)
+
+// DefaultRetryAfter recognizes rate limit errors by HTTP status 429, the
+// "rate_limit" error type or code used by OpenAI and Anthropic, or a "rate
+// limit" message, and extracts "Retry-After" or "try again in" hints from
+// the message. It returns a zero delay when no hint is present.
+func DefaultRetryAfter(resp *model.Response) (time.Duration, bool) {
+	if resp == nil || resp.Error == nil {
+		return 0, false
+	}
+	e := resp.Error
+	msg := strings.ToLower(e.Message)
+	limited := strings.Contains(strings.ToLower(e.Type), "rate_limit") ||
+		strings.Contains(msg, "429") ||
+		strings.Contains(msg, "rate limit") ||
+		strings.Contains(msg, "rate_limit") ||
+		strings.Contains(msg, "too many requests")
+	if e.Code != nil {
+		code := strings.ToLower(*e.Code)
+		limited = limited || code == "429" || strings.Contains(code, "rate_limit")
+	}
+	if !limited {
+		return 0, false
+	}
+	if m := retryAfterPattern.FindStringSubmatch(e.Message); m != nil {
+		return parseDelay(m[1], m[2]), true
+	}
+	if m := tryAgainPattern.FindStringSubmatch(e.Message); m != nil {
+		return parseDelay(m[1], m[2]), true
+	}
+	return 0, true
+}
+
+func parseDelay(value, unit string) time.Duration {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0
+	}
+	scale := time.Second
+	switch u := strings.ToLower(unit); {
+	case u == "ms":
+		scale = time.Millisecond
+	case u == "m" || strings.HasPrefix(u, "minute"):
+		scale = time.Minute
+	}
+	return time.Duration(f * float64(scale))
+}
@@ -0,0 +1,192 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+)
+
+// maxPollInterval bounds how long the dispatcher sleeps between limiter
+// polls, so that cancelled waiters and budget freed by other replicas are
+// noticed promptly.
+const maxPollInterval = time.Second
+
+// scheduler queues requests per fairness key and dispatches them in
+// round-robin order across keys as the limiter admits them. Within a key
+// requests are served first come, first served.
+type scheduler struct {
+	limiter Limiter
+
+	mu      sync.Mutex
+	queues  map[string][]*waiter
+	order   []string
+	next    int
+	running bool
+}
+
+type waiter struct {
+	tokens int
+	ready  chan struct{}
+}
+
+func newScheduler(limiter Limiter) *scheduler {
+	return &scheduler{limiter: limiter, queues: make(map[string][]*waiter)}
+}
+
+// wait blocks until the limiter admits a request of tokens for key, ctx is
+// done or maxWait elapses. maxWait <= 0 means no limit.
+func (s *scheduler) wait(ctx context.Context, key string, tokens int, maxWait time.Duration) error {
+	w := &waiter{tokens: tokens, ready: make(chan struct{})}
+	s.mu.Lock()
+	if _, ok := s.queues[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.queues[key] = append(s.queues[key], w)
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+	s.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrQueueTimeout
+	}
+	s.mu.Lock()
+	removed := s.remove(key, w)
+	s.mu.Unlock()
+	if !removed {
+		// Admitted concurrently with giving up; hand the tokens back.
+		s.refund(w.tokens)
+	}
+	return err
+}
+
+// run dispatches queued waiters until the queues are empty.
+func (s *scheduler) run() {
+	for {
+		s.mu.Lock()
+		if len(s.order) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		s.next %= len(s.order)
+		key := s.order[s.next]
+		w := s.queues[key][0]
+		s.mu.Unlock()
+
+		wait, err := s.limiter.Reserve(context.Background(), w.tokens)
+		if err != nil {
+			// Fail open: a broken shared limiter must not stall every model
+			// call.
+			log.Warnf("ratelimit: reserve failed, admitting request: %v", err)
+			wait = 0
+		}
+		if wait > 0 {
+			time.Sleep(min(wait, maxPollInterval))
+			continue
+		}
+
+		s.mu.Lock()
+		admitted := false
+		if q := s.queues[key]; len(q) > 0 && q[0] == w {
+			s.queues[key] = q[1:]
+			if len(s.queues[key]) == 0 {
+				s.removeKey(key)
+			} else {
+				s.next = s.position(key) + 1
+			}
+			admitted = true
+		}
+		s.mu.Unlock()
+		if admitted {
+			close(w.ready)
+		} else {
+			s.refund(w.tokens)
+		}
+	}
+}
+
+// remove drops w from the queue of key and reports whether it was queued.
+// The caller must hold s.mu.
+func (s *scheduler) remove(key string, w *waiter) bool {
+	q := s.queues[key]
+	for i, queued := range q {
+		if queued != w {
+			continue
+		}
+		s.queues[key] = append(q[:i:i], q[i+1:]...)
+		if len(s.queues[key]) == 0 {
+			s.removeKey(key)
+		}
+		return true
+	}
+	return false
+}
+
+// removeKey drops an empty key from the rotation. The caller must hold s.mu.
+func (s *scheduler) removeKey(key string) {
+	delete(s.queues, key)
+	i := s.position(key)
+	if i >= len(s.order) || s.order[i] != key {
+		return
+	}
+	s.order = append(s.order[:i], s.order[i+1:]...)
+	if i < s.next {
+		s.next--
+	}
+}
+
+// position returns the index of key in the rotation. The caller must hold
+// s.mu.
+func (s *scheduler) position(key string) int {
+	for i, k := range s.order {
+		if k == key {
+			return i
+		}
+	}
+	return 0
+}
+
+func (s *scheduler) refund(tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	if err := s.limiter.Adjust(context.Background(), -tokens); err != nil {
+		log.Warnf("ratelimit: refund tokens failed: %v", err)
+	}
+}
+
+// queued returns the number of waiting requests.
+func (s *scheduler) queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, q := range s.queues {
+		n += len(q)
+	}
+	return n
+}
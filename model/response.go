@@ -206,6 +206,34 @@ type Response struct {
 
 	// IsPartial indicates if this is a partial response.
 	IsPartial bool `json:"is_partial"`
+
+	// Cache reports how a response cache handled this response.
+	// It is nil when no response cache was consulted.
+	Cache *CacheInfo `json:"cache,omitempty"`
+}
+
+// CacheStatus describes the outcome of a response cache lookup.
+type CacheStatus string
+
+// Response cache outcomes.
+const (
+	// CacheStatusHit means the response was replayed from the cache.
+	CacheStatusHit CacheStatus = "hit"
+	// CacheStatusMiss means no cached response matched and the model was called.
+	CacheStatusMiss CacheStatus = "miss"
+	// CacheStatusBypass means the request was not eligible for caching.
+	CacheStatusBypass CacheStatus = "bypass"
+)
+
+// CacheInfo reports response cache details.
+type CacheInfo struct {
+	// Status is the outcome of the cache lookup.
+	Status CacheStatus `json:"status"`
+	// Similarity is the similarity between the request and the matched
+	// cache entry. Only set on hits.
+	Similarity float64 `json:"similarity,omitempty"`
+	// EntryID identifies the cache entry that served a hit.
+	EntryID string `json:"entry_id,omitempty"`
 }
 
 // Clone creates a deep copy of the response.
@@ -249,6 +277,10 @@ func (rsp *Response) Clone() *Response {
 		fp := *rsp.SystemFingerprint
 		clone.SystemFingerprint = &fp
 	}
+	if rsp.Cache != nil {
+		info := *rsp.Cache
+		clone.Cache = &info
+	}
 	return &clone
 }
 
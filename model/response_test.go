@@ -953,6 +953,7 @@ func TestResponse_Clone(t *testing.T) {
 					s := "fp_abcdef"
 					return &s
 				}(),
+				Cache: &CacheInfo{Status: CacheStatusHit, Similarity: 0.97, EntryID: "entry-1"},
 			},
 		},
 	}
@@ -1012,6 +1013,15 @@ func TestResponse_Clone(t *testing.T) {
 				assert.Nil(t, clone.SystemFingerprint)
 			}
 
+			// Verify Cache is deep copied
+			if tt.response.Cache != nil {
+				require.NotNil(t, clone.Cache)
+				assert.NotSame(t, tt.response.Cache, clone.Cache)
+				assert.Equal(t, tt.response.Cache, clone.Cache)
+			} else {
+				assert.Nil(t, clone.Cache)
+			}
+
 			// Verify modifying clone doesn't affect original
 			if len(clone.Choices) > 0 {
 				clone.Choices[0].Message.Content = "Modified"
@@ -0,0 +1,124 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package semanticcache
+
+import (
+	"context"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/embedder"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const (
+	defaultThreshold   = 0.95
+	defaultTTL         = 24 * time.Hour
+	defaultSearchLimit = 3
+)
+
+type options struct {
+	embedder    embedder.Embedder
+	vectorStore vectorstore.VectorStore
+	threshold   float64
+	ttl         time.Duration
+	scopes      []Scope
+	keyText     func(*model.Request) string
+	bypass      []func(context.Context, *model.Request) bool
+	searchLimit int
+}
+
+// Option configures a semantic cache model.
+type Option func(*options)
+
+func newOptions(opt ...Option) options {
+	opts := options{
+		threshold:   defaultThreshold,
+		ttl:         defaultTTL,
+		scopes:      []Scope{ScopeApp(), ScopeSystemPrompt(), ScopeTools()},
+		keyText:     DefaultKeyText,
+		searchLimit: defaultSearchLimit,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return opts
+}
+
+// WithEmbedder sets the embedder used to embed request key texts. Required.
+func WithEmbedder(e embedder.Embedder) Option {
+	return func(o *options) {
+		o.embedder = e
+	}
+}
+
+// WithVectorStore sets the store holding cache entries. Required.
+// Use a store dedicated to the cache, not one shared with a knowledge base.
+func WithVectorStore(vs vectorstore.VectorStore) Option {
+	return func(o *options) {
+		o.vectorStore = vs
+	}
+}
+
+// WithSimilarityThreshold sets the minimum similarity score, in [0, 1], for
+// a cached response to be replayed. Default is 0.95.
+func WithSimilarityThreshold(threshold float64) Option {
+	return func(o *options) {
+		o.threshold = threshold
+	}
+}
+
+// WithTTL sets how long cached responses stay valid. Zero or negative means
+// entries never expire. Default is 24h.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithScopes replaces the scoping rules. A request only matches entries
+// written under the same scope. The model name and structured output
+// schema are always part of the scope.
+// Default is ScopeApp, ScopeSystemPrompt and ScopeTools.
+func WithScopes(scopes ...Scope) Option {
+	return func(o *options) {
+		o.scopes = scopes
+	}
+}
+
+// WithKeyText sets how a request is turned into the text that is embedded.
+// Default is DefaultKeyText.
+func WithKeyText(fn func(*model.Request) string) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.keyText = fn
+		}
+	}
+}
+
+// WithBypass adds a predicate that skips the cache for matching requests,
+// in addition to the built-in rules for tool activity and non-text input.
+func WithBypass(fn func(ctx context.Context, req *model.Request) bool) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.bypass = append(o.bypass, fn)
+		}
+	}
+}
+
+// WithSearchLimit sets how many nearest entries are inspected per lookup,
+// so that expired entries do not hide a valid one. Default is 3.
+func WithSearchLimit(limit int) Option {
+	return func(o *options) {
+		if limit > 0 {
+			o.searchLimit = limit
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package semanticcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Scope returns one component of the cache scope for a request. Requests
+// only match cache entries written with identical components.
+type Scope func(ctx context.Context, req *model.Request) string
+
+// ScopeApp scopes entries to the app name of the invocation in ctx.
+func ScopeApp() Scope {
+	return func(ctx context.Context, _ *model.Request) string {
+		if inv, ok := agent.InvocationFromContext(ctx); ok && inv != nil && inv.Session != nil {
+			return "app=" + inv.Session.AppName
+		}
+		return "app="
+	}
+}
+
+// ScopeUser scopes entries to the app and user of the invocation in ctx,
+// so users never see each other's cached responses.
+func ScopeUser() Scope {
+	return func(ctx context.Context, _ *model.Request) string {
+		if inv, ok := agent.InvocationFromContext(ctx); ok && inv != nil && inv.Session != nil {
+			return "user=" + inv.Session.AppName + "/" + inv.Session.UserID
+		}
+		return "user="
+	}
+}
+
+// ScopeSystemPrompt scopes entries to a hash of the system messages.
+func ScopeSystemPrompt() Scope {
+	return func(_ context.Context, req *model.Request) string {
+		h := sha256.New()
+		for _, msg := range req.Messages {
+			if msg.Role == model.RoleSystem {
+				h.Write([]byte(msg.Content))
+				h.Write([]byte{0})
+			}
+		}
+		return "system=" + hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// ScopeTools scopes entries to a hash of the declarations of the tools
+// offered to the model.
+func ScopeTools() Scope {
+	return func(_ context.Context, req *model.Request) string {
+		names := make([]string, 0, len(req.Tools))
+		for name := range req.Tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		h := sha256.New()
+		for _, name := range names {
+			h.Write([]byte(name))
+			h.Write([]byte{0})
+			if t := req.Tools[name]; t != nil {
+				if b, err := json.Marshal(t.Declaration()); err == nil {
+					h.Write(b)
+				}
+			}
+			h.Write([]byte{0})
+		}
+		return "tools=" + hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// ScopeStatic adds a fixed component, e.g. a prompt or deployment version.
+func ScopeStatic(value string) Scope {
+	return func(context.Context, *model.Request) string {
+		return "static=" + value
+	}
+}
+
+// scopeKey hashes the scope components of a request.
+func (m *cacheModel) scopeKey(ctx context.Context, req *model.Request) string {
+	parts := []string{"model=" + m.base.Info().Name}
+	if req.StructuredOutput != nil {
+		if b, err := json.Marshal(req.StructuredOutput); err == nil {
+			parts = append(parts, "output="+string(b))
+		}
+	}
+	for _, scope := range m.opts.scopes {
+		if scope != nil {
+			parts = append(parts, scope(ctx, req))
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// DefaultKeyText renders the non-system messages of a request as
+// normalized text: one "role: content" line per message, lowercased, with
+// whitespace collapsed.
+func DefaultKeyText(req *model.Request) string {
+	var b strings.Builder
+	for _, msg := range req.Messages {
+		if msg.Role == model.RoleSystem {
+			continue
+		}
+		text := msg.Content
+		for _, part := range msg.ContentParts {
+			if part.Type == model.ContentTypeText && part.Text != nil {
+				text += " " + *part.Text
+			}
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(string(msg.Role))
+		b.WriteString(": ")
+		b.WriteString(normalize(text))
+	}
+	return b.String()
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,455 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+// Package semanticcache provides a model.Model wrapper that replays cached
+// responses for requests that are semantically close to earlier ones.
+//
+// The wrapper embeds a normalized rendering of each request and looks it up
+// in a vector store. When an entry in the same scope scores at or above the
+// similarity threshold, its response is replayed without calling the
+// wrapped model; otherwise the request is forwarded and the final response
+// is stored. Scopes keep entries of different apps, users, system prompts
+// or tool sets apart:
+//
+//	cached, err := semanticcache.New(llm,
+//		semanticcache.WithEmbedder(emb),
+//		semanticcache.WithVectorStore(inmemory.New()),
+//		semanticcache.WithSimilarityThreshold(0.97),
+//		semanticcache.WithTTL(time.Hour),
+//	)
+//
+// Requests carrying tool calls or tool results, and requests with non-text
+// input, always bypass the cache. Every response reports the outcome in
+// model.Response.Cache.
+package semanticcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/document"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Metadata keys of cache entries in the vector store.
+const (
+	MetaKeyScope     = "semantic_cache_scope"
+	MetaKeyExpiresAt = "semantic_cache_expires_at"
+	MetaKeyResponse  = "semantic_cache_response"
+)
+
+// cacheModel wraps a model with a semantic response cache.
+type cacheModel struct {
+	base model.Model
+	opts options
+}
+
+// cachedResponse is the part of a response kept in a cache entry.
+type cachedResponse struct {
+	Model   string         `json:"model,omitempty"`
+	Choices []model.Choice `json:"choices"`
+}
+
+// New creates a semantic cache wrapper around base.
+func New(base model.Model, opts ...Option) (model.Model, error) {
+	if base == nil {
+		return nil, errors.New("semanticcache: base model is nil")
+	}
+	o := newOptions(opts...)
+	if o.embedder == nil {
+		return nil, errors.New("semanticcache: embedder is required")
+	}
+	if o.vectorStore == nil {
+		return nil, errors.New("semanticcache: vector store is required")
+	}
+	if o.threshold <= 0 || o.threshold > 1 {
+		return nil, fmt.Errorf("semanticcache: similarity threshold %v out of range (0, 1]", o.threshold)
+	}
+	return &cacheModel{base: base, opts: o}, nil
+}
+
+// Info returns the wrapped model info.
+func (m *cacheModel) Info() model.Info {
+	return m.base.Info()
+}
+
+// InputTokenBudget forwards the budget advertised by the wrapped model.
+func (m *cacheModel) InputTokenBudget(ctx context.Context, request *model.Request) int {
+	type budgeter interface {
+		InputTokenBudget(context.Context, *model.Request) int
+	}
+	if b, ok := m.base.(budgeter); ok {
+		return b.InputTokenBudget(ctx, request)
+	}
+	return 0
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *cacheModel) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	seq, err := m.GenerateContentIter(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, 1)
+	go func() {
+		defer close(responseChan)
+		seq(func(resp *model.Response) bool {
+			if resp == nil {
+				return true
+			}
+			select {
+			case responseChan <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return responseChan, nil
+}
+
+// GenerateContentIter implements the model.IterModel interface.
+func (m *cacheModel) GenerateContentIter(
+	ctx context.Context,
+	request *model.Request,
+) (model.Seq[*model.Response], error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if m.shouldBypass(ctx, request) {
+		return m.forward(ctx, request, &model.CacheInfo{Status: model.CacheStatusBypass}, nil)
+	}
+
+	keyText := m.opts.keyText(request)
+	if strings.TrimSpace(keyText) == "" {
+		return m.forward(ctx, request, &model.CacheInfo{Status: model.CacheStatusBypass}, nil)
+	}
+	vector, err := m.opts.embedder.GetEmbedding(ctx, keyText)
+	if err != nil || len(vector) == 0 {
+		log.WarnfContext(ctx, "semantic cache: embed request failed, bypassing cache: %v", err)
+		return m.forward(ctx, request, &model.CacheInfo{Status: model.CacheStatusBypass}, nil)
+	}
+	scope := m.scopeKey(ctx, request)
+
+	if hit, score := m.lookup(ctx, vector, scope); hit != nil {
+		return m.replay(request, hit, score), nil
+	}
+	return m.forward(ctx, request, &model.CacheInfo{Status: model.CacheStatusMiss}, &entry{
+		keyText: keyText,
+		vector:  vector,
+		scope:   scope,
+	})
+}
+
+// shouldBypass reports whether the request must not be served from or
+// written to the cache.
+func (m *cacheModel) shouldBypass(ctx context.Context, request *model.Request) bool {
+	for _, msg := range request.Messages {
+		if len(msg.ToolCalls) > 0 || msg.Role == model.RoleTool || msg.ToolID != "" {
+			return true
+		}
+		for _, part := range msg.ContentParts {
+			if part.Type != model.ContentTypeText {
+				return true
+			}
+		}
+	}
+	for _, fn := range m.opts.bypass {
+		if fn(ctx, request) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the best live entry in scope, if any.
+func (m *cacheModel) lookup(
+	ctx context.Context,
+	vector []float64,
+	scope string,
+) (*vectorstore.ScoredDocument, float64) {
+	result, err := m.opts.vectorStore.Search(ctx, &vectorstore.SearchQuery{
+		Vector:     vector,
+		Limit:      m.opts.searchLimit,
+		MinScore:   m.opts.threshold,
+		SearchMode: vectorstore.SearchModeVector,
+		Filter: &vectorstore.SearchFilter{
+			Metadata: map[string]any{MetaKeyScope: scope},
+		},
+	})
+	if err != nil {
+		log.WarnfContext(ctx, "semantic cache: search failed, bypassing cache: %v", err)
+		return nil, 0
+	}
+	if result == nil {
+		return nil, 0
+	}
+	now := time.Now().Unix()
+	for _, scored := range result.Results {
+		if scored == nil || scored.Document == nil || scored.Score < m.opts.threshold {
+			continue
+		}
+		meta := scored.Document.Metadata
+		if meta[MetaKeyScope] != scope {
+			continue
+		}
+		if exp := toInt64(meta[MetaKeyExpiresAt]); exp > 0 && exp <= now {
+			if err := m.opts.vectorStore.Delete(ctx, scored.Document.ID); err != nil {
+				log.WarnfContext(ctx, "semantic cache: delete expired entry %s failed: %v",
+					scored.Document.ID, err)
+			}
+			continue
+		}
+		return scored, scored.Score
+	}
+	return nil, 0
+}
+
+// replay emits a cached response. Streaming requests receive one partial
+// chunk carrying the whole content before the final response.
+func (m *cacheModel) replay(
+	request *model.Request,
+	hit *vectorstore.ScoredDocument,
+	score float64,
+) model.Seq[*model.Response] {
+	return func(yield func(*model.Response) bool) {
+		var cached cachedResponse
+		raw, _ := hit.Document.Metadata[MetaKeyResponse].(string)
+		if err := json.Unmarshal([]byte(raw), &cached); err != nil || len(cached.Choices) == 0 {
+			// A corrupt entry must not break the caller; treat it as a
+			// cache error surfaced on the response.
+			yield(&model.Response{
+				Object:    model.ObjectTypeError,
+				Created:   time.Now().Unix(),
+				Timestamp: time.Now(),
+				Done:      true,
+				Error: &model.ResponseError{
+					Type:    model.ErrorTypeAPIError,
+					Message: fmt.Sprintf("semantic cache: invalid entry %s", hit.Document.ID),
+				},
+			})
+			return
+		}
+		info := model.CacheInfo{
+			Status:     model.CacheStatusHit,
+			Similarity: score,
+			EntryID:    hit.Document.ID,
+		}
+		id := "semcache-" + uuid.NewString()
+		now := time.Now()
+		modelName := cached.Model
+		if modelName == "" {
+			modelName = m.base.Info().Name
+		}
+		if request.GenerationConfig.Stream {
+			chunk := &model.Response{
+				ID:        id,
+				Object:    model.ObjectTypeChatCompletionChunk,
+				Created:   now.Unix(),
+				Model:     modelName,
+				Timestamp: now,
+				IsPartial: true,
+				Cache:     cloneInfo(info),
+			}
+			for _, choice := range cached.Choices {
+				chunk.Choices = append(chunk.Choices, model.Choice{
+					Index: choice.Index,
+					Delta: choice.Message,
+				})
+			}
+			if !yield(chunk) {
+				return
+			}
+		}
+		final := &model.Response{
+			ID:        id,
+			Object:    model.ObjectTypeChatCompletion,
+			Created:   now.Unix(),
+			Model:     modelName,
+			Choices:   cached.Choices,
+			Timestamp: now,
+			Done:      true,
+			Cache:     cloneInfo(info),
+		}
+		yield(final)
+	}
+}
+
+// entry is a pending cache write for a missed request.
+type entry struct {
+	keyText string
+	vector  []float64
+	scope   string
+}
+
+// forward calls the wrapped model, tags every response with info and, when
+// e is set, stores the final response.
+func (m *cacheModel) forward(
+	ctx context.Context,
+	request *model.Request,
+	info *model.CacheInfo,
+	e *entry,
+) (model.Seq[*model.Response], error) {
+	seq, err := sequenceFor(ctx, m.base, request)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(*model.Response) bool) {
+		var (
+			final    *model.Response
+			streamed strings.Builder
+		)
+		seq(func(resp *model.Response) bool {
+			if resp == nil {
+				return true
+			}
+			out := resp.Clone()
+			out.Cache = cloneInfo(*info)
+			if e != nil {
+				if out.IsPartial && len(out.Choices) > 0 {
+					streamed.WriteString(out.Choices[0].Delta.Content)
+				} else if !out.IsPartial {
+					final = out
+				}
+			}
+			return yield(out)
+		})
+		if e != nil && final != nil {
+			m.store(ctx, e, final, streamed.String())
+		}
+	}, nil
+}
+
+// store writes a cacheable final response to the vector store. Failures
+// are logged and otherwise ignored.
+func (m *cacheModel) store(ctx context.Context, e *entry, final *model.Response, streamed string) {
+	if final.Error != nil || len(final.Choices) == 0 {
+		return
+	}
+	choices := make([]model.Choice, 0, len(final.Choices))
+	for _, choice := range final.Choices {
+		if len(choice.Message.ToolCalls) > 0 || len(choice.Delta.ToolCalls) > 0 {
+			return
+		}
+		msg := choice.Message
+		if msg.Content == "" && choice.Index == 0 {
+			msg.Content = streamed
+		}
+		if msg.Role == "" {
+			msg.Role = model.RoleAssistant
+		}
+		choices = append(choices, model.Choice{
+			Index:        choice.Index,
+			Message:      msg,
+			FinishReason: choice.FinishReason,
+		})
+	}
+	if choices[0].Message.Content == "" {
+		return
+	}
+	payload, err := json.Marshal(cachedResponse{Model: final.Model, Choices: choices})
+	if err != nil {
+		log.WarnfContext(ctx, "semantic cache: encode response failed: %v", err)
+		return
+	}
+	var expiresAt int64
+	if m.opts.ttl > 0 {
+		expiresAt = time.Now().Add(m.opts.ttl).Unix()
+	}
+	doc := &document.Document{
+		ID:      uuid.NewString(),
+		Name:    "semantic-cache-entry",
+		Content: e.keyText,
+		Metadata: map[string]any{
+			MetaKeyScope:     e.scope,
+			MetaKeyExpiresAt: expiresAt,
+			MetaKeyResponse:  string(payload),
+		},
+		CreatedAt: time.Now(),
+	}
+	// The response has been delivered already; finish the write even if
+	// the caller cancels right after.
+	if err := m.opts.vectorStore.Add(context.WithoutCancel(ctx), doc, e.vector); err != nil {
+		log.WarnfContext(ctx, "semantic cache: store entry failed: %v", err)
+	}
+}
+
+func sequenceFor(
+	ctx context.Context,
+	base model.Model,
+	request *model.Request,
+) (model.Seq[*model.Response], error) {
+	if iterModel, ok := base.(model.IterModel); ok {
+		seq, err := iterModel.GenerateContentIter(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if seq == nil {
+			return nil, fmt.Errorf("model %q returned nil response sequence", base.Info().Name)
+		}
+		return seq, nil
+	}
+	responseChan, err := base.GenerateContent(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if responseChan == nil {
+		return nil, fmt.Errorf("model %q returned nil response channel", base.Info().Name)
+	}
+	return func(yield func(*model.Response) bool) {
+		for response := range responseChan {
+			if !yield(response) {
+				return
+			}
+		}
+	}, nil
+}
+
+func cloneInfo(info model.CacheInfo) *model.CacheInfo {
+	return &info
+}
+
+// toInt64 reads a numeric metadata value, which vector stores may return
+// as any integer or float type, or as a string.
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint64:
+		return int64(n)
+	case float64:
+		return int64(n)
+	case float32:
+		return int64(n)
+	case json.Number:
+		i, _ := n.Int64()
+		return i
+	case string:
+		i, _ := strconv.ParseInt(n, 10, 64)
+		return i
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,338 @@
+//
+// Tencent is pleased to support the open source community by making
+// trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+
+package semanticcache
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/vectorstore/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// fakeEmbedder hashes words into a bag-of-words vector. Texts listed in
+// aliases are embedded as their alias, which models paraphrases.
+type fakeEmbedder struct {
+	aliases map[string]string
+	err     error
+	calls   atomic.Int32
+}
+
+func (e *fakeEmbedder) GetEmbedding(_ context.Context, text string) ([]float64, error) {
+	e.calls.Add(1)
+	if e.err != nil {
+		return nil, e.err
+	}
+	if alias, ok := e.aliases[text]; ok {
+		text = alias
+	}
+	vec := make([]float64, 64)
+	for _, w := range strings.Fields(text) {
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		vec[h.Sum32()%64]++
+	}
+	return vec, nil
+}
+
+func (e *fakeEmbedder) GetEmbeddingWithUsage(ctx context.Context, text string) ([]float64, map[string]any, error) {
+	v, err := e.GetEmbedding(ctx, text)
+	return v, nil, err
+}
+
+func (e *fakeEmbedder) GetDimensions() int { return 64 }
+
+// fakeModel answers every request with answer and counts calls.
+type fakeModel struct {
+	answer    string
+	toolCalls []model.ToolCall
+	calls     atomic.Int32
+}
+
+func (m *fakeModel) Info() model.Info { return model.Info{Name: "fake"} }
+
+func (m *fakeModel) GenerateContent(ctx context.Context, req *model.Request) (<-chan *model.Response, error) {
+	m.calls.Add(1)
+	ch := make(chan *model.Response, 16)
+	stop := "stop"
+	if req.GenerationConfig.Stream {
+		for _, part := range strings.SplitAfter(m.answer, " ") {
+			ch <- &model.Response{
+				Object:    model.ObjectTypeChatCompletionChunk,
+				IsPartial: true,
+				Choices:   []model.Choice{{Delta: model.Message{Role: model.RoleAssistant, Content: part}}},
+			}
+		}
+	}
+	ch <- &model.Response{
+		Object: model.ObjectTypeChatCompletion,
+		Model:  "fake",
+		Done:   true,
+		Usage:  &model.Usage{TotalTokens: 10},
+		Choices: []model.Choice{{
+			Message: model.Message{
+				Role:      model.RoleAssistant,
+				Content:   m.answer,
+				ToolCalls: m.toolCalls,
+			},
+			FinishReason: &stop,
+		}},
+	}
+	close(ch)
+	return ch, nil
+}
+
+type stubTool struct{ name string }
+
+func (t *stubTool) Declaration() *tool.Declaration { return &tool.Declaration{Name: t.name} }
+
+func newRequest(question string, stream bool) *model.Request {
+	return &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage("You are helpful."),
+			model.NewUserMessage(question),
+		},
+		GenerationConfig: model.GenerationConfig{Stream: stream},
+	}
+}
+
+func collect(t *testing.T, llm model.Model, ctx context.Context, req *model.Request) []*model.Response {
+	t.Helper()
+	ch, err := llm.GenerateContent(ctx, req)
+	require.NoError(t, err)
+	var out []*model.Response
+	for resp := range ch {
+		out = append(out, resp)
+	}
+	require.NotEmpty(t, out)
+	return out
+}
+
+func newCache(t *testing.T, base model.Model, emb *fakeEmbedder, opts ...Option) model.Model {
+	t.Helper()
+	opts = append([]Option{WithEmbedder(emb), WithVectorStore(inmemory.New())}, opts...)
+	llm, err := New(base, opts...)
+	require.NoError(t, err)
+	return llm
+}
+
+func TestNew_Validation(t *testing.T) {
+	emb := &fakeEmbedder{}
+	vs := inmemory.New()
+	_, err := New(nil, WithEmbedder(emb), WithVectorStore(vs))
+	assert.Error(t, err)
+	_, err = New(&fakeModel{}, WithVectorStore(vs))
+	assert.Error(t, err)
+	_, err = New(&fakeModel{}, WithEmbedder(emb))
+	assert.Error(t, err)
+	_, err = New(&fakeModel{}, WithEmbedder(emb), WithVectorStore(vs), WithSimilarityThreshold(1.5))
+	assert.Error(t, err)
+	llm, err := New(&fakeModel{}, WithEmbedder(emb), WithVectorStore(vs))
+	require.NoError(t, err)
+	assert.Equal(t, "fake", llm.Info().Name)
+	_, ok := llm.(model.IterModel)
+	assert.True(t, ok)
+}
+
+func TestCache_MissThenHit(t *testing.T) {
+	base := &fakeModel{answer: "Paris is the capital."}
+	emb := &fakeEmbedder{aliases: map[string]string{
+		"user: what's the capital city of france?": "user: what is the capital of france?",
+	}}
+	llm := newCache(t, base, emb)
+	ctx := context.Background()
+
+	first := collect(t, llm, ctx, newRequest("What is the capital of France?", false))
+	require.Len(t, first, 1)
+	assert.Equal(t, model.CacheStatusMiss, first[0].Cache.Status)
+	assert.NotNil(t, first[0].Usage)
+
+	// Case and whitespace differences normalize to the same key.
+	second := collect(t, llm, ctx, newRequest("  what is the   CAPITAL of france?", false))
+	require.Len(t, second, 1)
+	assert.Equal(t, model.CacheStatusHit, second[0].Cache.Status)
+	assert.InDelta(t, 1.0, second[0].Cache.Similarity, 1e-9)
+	assert.NotEmpty(t, second[0].Cache.EntryID)
+	assert.True(t, second[0].Done)
+	assert.Nil(t, second[0].Usage)
+	assert.Equal(t, "Paris is the capital.", second[0].Choices[0].Message.Content)
+	require.NotNil(t, second[0].Choices[0].FinishReason)
+	assert.Equal(t, "stop", *second[0].Choices[0].FinishReason)
+
+	// A paraphrase embeds close enough to hit.
+	third := collect(t, llm, ctx, newRequest("What's the capital city of France?", false))
+	assert.Equal(t, model.CacheStatusHit, third[0].Cache.Status)
+
+	// An unrelated question misses.
+	fourth := collect(t, llm, ctx, newRequest("How tall is Mount Everest?", false))
+	assert.Equal(t, model.CacheStatusMiss, fourth[0].Cache.Status)
+	assert.Equal(t, int32(2), base.calls.Load())
+}
+
+func TestCache_StreamingReplay(t *testing.T) {
+	base := &fakeModel{answer: "Paris is the capital."}
+	llm := newCache(t, base, &fakeEmbedder{})
+	ctx := context.Background()
+
+	miss := collect(t, llm, ctx, newRequest("capital of france", true))
+	require.Len(t, miss, 5)
+	for _, resp := range miss {
+		assert.Equal(t, model.CacheStatusMiss, resp.Cache.Status)
+	}
+
+	hit := collect(t, llm, ctx, newRequest("capital of france", true))
+	require.Len(t, hit, 2)
+	assert.True(t, hit[0].IsPartial)
+	assert.Equal(t, model.ObjectTypeChatCompletionChunk, hit[0].Object)
+	assert.Equal(t, "Paris is the capital.", hit[0].Choices[0].Delta.Content)
+	assert.False(t, hit[1].IsPartial)
+	assert.True(t, hit[1].Done)
+	assert.Equal(t, "Paris is the capital.", hit[1].Choices[0].Message.Content)
+	assert.Equal(t, hit[0].ID, hit[1].ID)
+	assert.Equal(t, model.CacheStatusHit, hit[1].Cache.Status)
+	assert.Equal(t, int32(1), base.calls.Load())
+
+	// Entries written by a streaming call serve non-streaming calls too.
+	plain := collect(t, llm, ctx, newRequest("capital of france", false))
+	require.Len(t, plain, 1)
+	assert.Equal(t, model.CacheStatusHit, plain[0].Cache.Status)
+}
+
+func TestCache_Scopes(t *testing.T) {
+	base := &fakeModel{answer: "answer"}
+	llm := newCache(t, base, &fakeEmbedder{}, WithScopes(ScopeUser(), ScopeSystemPrompt(), ScopeTools()))
+	withUser := func(user string) context.Context {
+		inv := agent.NewInvocation(agent.WithInvocationSession(session.NewSession("app", user, "s")))
+		return agent.NewInvocationContext(context.Background(), inv)
+	}
+
+	collect(t, llm, withUser("alice"), newRequest("hello there", false))
+	resp := collect(t, llm, withUser("alice"), newRequest("hello there", false))
+	assert.Equal(t, model.CacheStatusHit, resp[0].Cache.Status)
+
+	resp = collect(t, llm, withUser("bob"), newRequest("hello there", false))
+	assert.Equal(t, model.CacheStatusMiss, resp[0].Cache.Status, "users must not share entries")
+
+	req := newRequest("hello there", false)
+	req.Messages[0] = model.NewSystemMessage("You are terse.")
+	resp = collect(t, llm, withUser("alice"), req)
+	assert.Equal(t, model.CacheStatusMiss, resp[0].Cache.Status, "system prompt is part of the scope")
+
+	req = newRequest("hello there", false)
+	req.Tools = map[string]tool.Tool{"search": &stubTool{name: "search"}}
+	resp = collect(t, llm, withUser("alice"), req)
+	assert.Equal(t, model.CacheStatusMiss, resp[0].Cache.Status, "tool set is part of the scope")
+	assert.Equal(t, int32(4), base.calls.Load())
+}
+
+func TestCache_TTL(t *testing.T) {
+	base := &fakeModel{answer: "answer"}
+	vs := inmemory.New()
+	llm, err := New(base, WithEmbedder(&fakeEmbedder{}), WithVectorStore(vs), WithTTL(time.Second))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	collect(t, llm, ctx, newRequest("question", false))
+	resp := collect(t, llm, ctx, newRequest("question", false))
+	assert.Equal(t, model.CacheStatusHit, resp[0].Cache.Status)
+
+	// Age the entry past its expiry.
+	docs, err := vs.GetMetadata(ctx)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	for id := range docs {
+		doc, vec, err := vs.Get(ctx, id)
+		require.NoError(t, err)
+		doc.Metadata[MetaKeyExpiresAt] = float64(time.Now().Add(-time.Minute).Unix())
+		require.NoError(t, vs.Update(ctx, doc, vec))
+	}
+
+	resp = collect(t, llm, ctx, newRequest("question", false))
+	assert.Equal(t, model.CacheStatusMiss, resp[0].Cache.Status)
+	assert.Equal(t, int32(2), base.calls.Load())
+	count, err := vs.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expired entry is replaced by the fresh one")
+}
+
+func TestCache_Bypass(t *testing.T) {
+	base := &fakeModel{answer: "answer"}
+	emb := &fakeEmbedder{}
+	llm := newCache(t, base, emb, WithBypass(func(_ context.Context, req *model.Request) bool {
+		return req.GenerationConfig.Temperature != nil
+	}))
+	ctx := context.Background()
+
+	req := newRequest("weather?", false)
+	req.Messages = append(req.Messages,
+		model.Message{Role: model.RoleAssistant, ToolCalls: []model.ToolCall{{ID: "1"}}},
+		model.NewToolMessage("1", "weather", "sunny"),
+	)
+	for i := 0; i < 2; i++ {
+		resp := collect(t, llm, ctx, req)
+		assert.Equal(t, model.CacheStatusBypass, resp[0].Cache.Status)
+	}
+
+	temp := 0.9
+	req = newRequest("weather?", false)
+	req.GenerationConfig.Temperature = &temp
+	resp := collect(t, llm, ctx, req)
+	assert.Equal(t, model.CacheStatusBypass, resp[0].Cache.Status)
+	assert.Equal(t, int32(0), emb.calls.Load())
+	assert.Equal(t, int32(3), base.calls.Load())
+}
+
+func TestCache_DoesNotStoreToolCalls(t *testing.T) {
+	base := &fakeModel{toolCalls: []model.ToolCall{{ID: "1", Function: model.FunctionDefinitionParam{Name: "search"}}}}
+	llm := newCache(t, base, &fakeEmbedder{})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		resp := collect(t, llm, ctx, newRequest("look it up", false))
+		assert.Equal(t, model.CacheStatusMiss, resp[0].Cache.Status)
+	}
+	assert.Equal(t, int32(2), base.calls.Load())
+}
+
+func TestCache_EmbedderFailureFailsOpen(t *testing.T) {
+	base := &fakeModel{answer: "answer"}
+	llm := newCache(t, base, &fakeEmbedder{err: errors.New("boom")})
+	resp := collect(t, llm, context.Background(), newRequest("question", false))
+	assert.Equal(t, model.CacheStatusBypass, resp[0].Cache.Status)
+	assert.Equal(t, "answer", resp[0].Choices[0].Message.Content)
+}
+
+func TestDefaultKeyText(t *testing.T) {
+	text := "Look  at\tTHIS"
+	req := &model.Request{Messages: []model.Message{
+		model.NewSystemMessage("ignored"),
+		model.NewUserMessage("Hello\n World"),
+		{Role: model.RoleUser, ContentParts: []model.ContentPart{{Type: model.ContentTypeText, Text: &text}}},
+	}}
+	assert.Equal(t, "user: hello world\nuser: look at this", DefaultKeyText(req))
+}
+
+func TestToInt64(t *testing.T) {
+	assert.Equal(t, int64(5), toInt64(int64(5)))
+	assert.Equal(t, int64(5), toInt64(5))
+	assert.Equal(t, int64(5), toInt64(5.0))
+	assert.Equal(t, int64(5), toInt64("5"))
+	assert.Equal(t, int64(0), toInt64(nil))
+}
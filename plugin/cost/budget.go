@@ -0,0 +1,98 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// ErrBudgetExceeded is wrapped by the error returned when a run is rejected
+// by a budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Scope is the granularity a budget is tracked at.
+type Scope string
+
+// Budget scopes. Narrower scopes stay within their app.
+const (
+	// ScopeApp shares one budget across every user of the app.
+	ScopeApp Scope = "app"
+	// ScopeUser gives each user of the app its own budget.
+	ScopeUser Scope = "user"
+	// ScopeSession gives each session its own budget.
+	ScopeSession Scope = "session"
+)
+
+// Budget caps the cost spent within a scope.
+type Budget struct {
+	// Name identifies the budget in errors, logs and metrics.
+	Name string
+	// Scope is the granularity the budget is tracked at.
+	Scope Scope
+	// Limit is the spend, in catalog currency, at which the budget is
+	// exceeded.
+	Limit float64
+	// Window is the trailing period spend is summed over. Zero means
+	// lifetime.
+	Window time.Duration
+	// Fallback is the model runs switch to once the budget is exceeded.
+	// Nil rejects the run instead.
+	Fallback model.Model
+}
+
+func (b Budget) filter(appName, userID, sessionID string, now time.Time) Filter {
+	f := Filter{AppName: appName}
+	switch b.Scope {
+	case ScopeUser:
+		f.UserID = userID
+	case ScopeSession:
+		f.UserID = userID
+		f.SessionID = sessionID
+	}
+	if b.Window > 0 {
+		f.Since = now.Add(-b.Window)
+	}
+	return f
+}
+
+func (b Budget) validate() error {
+	switch b.Scope {
+	case ScopeApp, ScopeUser, ScopeSession:
+	default:
+		return fmt.Errorf("budget %q: unknown scope %q", b.Name, b.Scope)
+	}
+	if b.Limit <= 0 {
+		return fmt.Errorf("budget %q: limit must be positive", b.Name)
+	}
+	if b.Window < 0 {
+		return fmt.Errorf("budget %q: negative window", b.Name)
+	}
+	return nil
+}
+
+// ExceededError reports the budget that rejected a run. It wraps
+// ErrBudgetExceeded.
+type ExceededError struct {
+	Budget string
+	Spent  float64
+	Limit  float64
+}
+
+// Error implements error.
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s: %q spent %.6f of %.6f", ErrBudgetExceeded, e.Budget, e.Spent, e.Limit)
+}
+
+// Unwrap returns ErrBudgetExceeded.
+func (e *ExceededError) Unwrap() error { return ErrBudgetExceeded }
@@ -0,0 +1,201 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package gorm provides a cost.Ledger stored in any SQL database supported
+// by gorm, so budgets hold across every replica sharing the database:
+//
+//	db, _ := gorm.Open(postgres.Open(dsn))
+//	ledger, err := costgorm.NewLedger(costgorm.WithGormDB(db))
+//	p, err := cost.New(cost.WithCatalog(catalog), cost.WithLedger(ledger))
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gormio "gorm.io/gorm"
+
+	"trpc.group/trpc-go/trpc-agent-go/plugin/cost"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/gorm"
+)
+
+var _ cost.Ledger = (*Ledger)(nil)
+
+// record is the row layout of the ledger table. Times are stored as unix
+// milliseconds so range filters compare the same way on every database.
+type record struct {
+	ID              uint64  `gorm:"primaryKey;autoIncrement"`
+	CreatedAtMs     int64   `gorm:"column:created_at_ms;not null;index"`
+	AppName         string  `gorm:"size:128;not null;index:,composite:scope"`
+	UserID          string  `gorm:"size:128;not null;index:,composite:scope"`
+	SessionID       string  `gorm:"size:128;not null;index:,composite:scope"`
+	AgentName       string  `gorm:"size:128;not null"`
+	InvocationID    string  `gorm:"size:128;not null"`
+	Model           string  `gorm:"size:128;not null"`
+	InputTokens     int     `gorm:"not null"`
+	OutputTokens    int     `gorm:"not null"`
+	CachedTokens    int     `gorm:"not null"`
+	ReasoningTokens int     `gorm:"not null"`
+	Cost            float64 `gorm:"not null"`
+}
+
+// summaryRow is the result of the aggregate queries.
+type summaryRow struct {
+	Dim          string
+	Cost         float64
+	InputTokens  int64
+	OutputTokens int64
+	Requests     int64
+}
+
+const summarySelect = "COALESCE(SUM(cost), 0) AS cost, " +
+	"COALESCE(SUM(input_tokens), 0) AS input_tokens, " +
+	"COALESCE(SUM(output_tokens), 0) AS output_tokens, " +
+	"COUNT(*) AS requests"
+
+var dimensionColumns = map[cost.Dimension]string{
+	cost.DimensionApp:     "app_name",
+	cost.DimensionUser:    "user_id",
+	cost.DimensionSession: "session_id",
+	cost.DimensionAgent:   "agent_name",
+	cost.DimensionModel:   "model",
+}
+
+// Ledger is a cost.Ledger backed by gorm.
+type Ledger struct {
+	client storage.Client
+	table  string
+}
+
+// NewLedger creates a gorm ledger and, unless WithSkipDBInit is set,
+// creates its table.
+func NewLedger(opts ...Option) (*Ledger, error) {
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var builderOpts []storage.ClientBuilderOpt
+	switch {
+	case o.db != nil:
+		builderOpts = []storage.ClientBuilderOpt{storage.WithDB(o.db)}
+	case o.instanceName != "":
+		var ok bool
+		if builderOpts, ok = storage.GetGormInstance(o.instanceName); !ok {
+			return nil, fmt.Errorf("gorm instance %s not found", o.instanceName)
+		}
+	default:
+		return nil, errors.New("gorm db or instance is required")
+	}
+	client, err := storage.GetClientBuilder()(context.Background(), builderOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create gorm client failed: %w", err)
+	}
+
+	l := &Ledger{client: client, table: o.tableName}
+	if !o.skipDBInit {
+		if err := client.DB().Table(l.table).AutoMigrate(&record{}); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("migrate table %s: %w", l.table, err)
+		}
+	}
+	return l, nil
+}
+
+// Add implements cost.Ledger.
+func (l *Ledger) Add(ctx context.Context, r cost.Record) error {
+	row := &record{
+		CreatedAtMs:     r.Time.UnixMilli(),
+		AppName:         r.AppName,
+		UserID:          r.UserID,
+		SessionID:       r.SessionID,
+		AgentName:       r.AgentName,
+		InvocationID:    r.InvocationID,
+		Model:           r.Model,
+		InputTokens:     r.InputTokens,
+		OutputTokens:    r.OutputTokens,
+		CachedTokens:    r.CachedTokens,
+		ReasoningTokens: r.ReasoningTokens,
+		Cost:            r.Cost,
+	}
+	if err := l.db(ctx).Create(row).Error; err != nil {
+		return fmt.Errorf("insert cost record: %w", err)
+	}
+	return nil
+}
+
+// Sum implements cost.Ledger.
+func (l *Ledger) Sum(ctx context.Context, f cost.Filter) (cost.Summary, error) {
+	var row summaryRow
+	if err := l.where(l.db(ctx), f).Select(summarySelect).Scan(&row).Error; err != nil {
+		return cost.Summary{}, fmt.Errorf("sum cost records: %w", err)
+	}
+	return row.summary(), nil
+}
+
+// SumBy implements cost.Ledger.
+func (l *Ledger) SumBy(ctx context.Context, f cost.Filter, d cost.Dimension) (map[string]cost.Summary, error) {
+	col, ok := dimensionColumns[d]
+	if !ok {
+		return nil, fmt.Errorf("unknown dimension %q", d)
+	}
+	var rows []summaryRow
+	if err := l.where(l.db(ctx), f).
+		Select(col + " AS dim, " + summarySelect).
+		Group(col).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("sum cost records by %s: %w", d, err)
+	}
+	out := make(map[string]cost.Summary, len(rows))
+	for _, row := range rows {
+		out[row.Dim] = row.summary()
+	}
+	return out, nil
+}
+
+// Close closes the database when the ledger opened it.
+func (l *Ledger) Close() error {
+	return l.client.Close()
+}
+
+func (l *Ledger) db(ctx context.Context) *gormio.DB {
+	return l.client.DB().WithContext(ctx).Table(l.table)
+}
+
+func (l *Ledger) where(db *gormio.DB, f cost.Filter) *gormio.DB {
+	if f.AppName != "" {
+		db = db.Where("app_name = ?", f.AppName)
+	}
+	if f.UserID != "" {
+		db = db.Where("user_id = ?", f.UserID)
+	}
+	if f.SessionID != "" {
+		db = db.Where("session_id = ?", f.SessionID)
+	}
+	if f.AgentName != "" {
+		db = db.Where("agent_name = ?", f.AgentName)
+	}
+	if !f.Since.IsZero() {
+		db = db.Where("created_at_ms >= ?", f.Since.UnixMilli())
+	}
+	if !f.Until.IsZero() {
+		db = db.Where("created_at_ms < ?", f.Until.UnixMilli())
+	}
+	return db
+}
+
+func (r summaryRow) summary() cost.Summary {
+	return cost.Summary{
+		Cost:         r.Cost,
+		InputTokens:  r.InputTokens,
+		OutputTokens: r.OutputTokens,
+		Requests:     r.Requests,
+	}
+}
@@ -0,0 +1,106 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	gormio "gorm.io/gorm"
+
+	"trpc.group/trpc-go/trpc-agent-go/plugin/cost"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/gorm"
+)
+
+func openTestDB(t *testing.T) *gormio.DB {
+	t.Helper()
+	db, err := gormio.Open(sqlite.Open("file::memory:"), &gormio.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// Every connection to :memory: is a new database.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestNewLedger_Errors(t *testing.T) {
+	_, err := NewLedger()
+	assert.Error(t, err)
+	_, err = NewLedger(WithGormInstance("no-instance"))
+	assert.Error(t, err)
+}
+
+func TestNewLedger_WithGormInstance(t *testing.T) {
+	storage.RegisterGormInstance("cost-test", storage.WithDB(openTestDB(t)))
+	l, err := NewLedger(WithGormInstance("cost-test"), WithTableName("usage_costs"))
+	require.NoError(t, err)
+	require.NoError(t, l.Add(context.Background(), cost.Record{Time: time.Now(), AppName: "app", Cost: 1}))
+	assert.True(t, l.client.DB().Migrator().HasTable("usage_costs"))
+	require.NoError(t, l.Close())
+}
+
+func TestLedger_SumAndSumBy(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLedger(WithGormDB(openTestDB(t)))
+	require.NoError(t, err)
+
+	base := time.Unix(1_700_000_000, 0)
+	records := []cost.Record{
+		{Time: base, AppName: "app", UserID: "u1", SessionID: "s1", AgentName: "a", Model: "m1", InputTokens: 10, OutputTokens: 1, Cost: 1},
+		{Time: base.Add(time.Hour), AppName: "app", UserID: "u1", SessionID: "s2", AgentName: "b", Model: "m2", InputTokens: 20, OutputTokens: 2, Cost: 2},
+		{Time: base.Add(2 * time.Hour), AppName: "app", UserID: "u2", SessionID: "s3", AgentName: "a", Model: "m1", InputTokens: 30, OutputTokens: 3, Cost: 4},
+		{Time: base, AppName: "other", UserID: "u1", Cost: 8},
+	}
+	for _, r := range records {
+		require.NoError(t, l.Add(ctx, r))
+	}
+
+	sum, err := l.Sum(ctx, cost.Filter{AppName: "app"})
+	require.NoError(t, err)
+	assert.Equal(t, cost.Summary{Cost: 7, InputTokens: 60, OutputTokens: 6, Requests: 3}, sum)
+
+	sum, err = l.Sum(ctx, cost.Filter{AppName: "app", UserID: "u1", SessionID: "s2", AgentName: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, sum.Cost)
+	sum, err = l.Sum(ctx, cost.Filter{AppName: "app", Since: base.Add(time.Hour), Until: base.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, sum.Cost, "since is inclusive, until exclusive")
+	sum, err = l.Sum(ctx, cost.Filter{AppName: "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, cost.Summary{}, sum)
+
+	byModel, err := l.SumBy(ctx, cost.Filter{AppName: "app"}, cost.DimensionModel)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]cost.Summary{
+		"m1": {Cost: 5, InputTokens: 40, OutputTokens: 4, Requests: 2},
+		"m2": {Cost: 2, InputTokens: 20, OutputTokens: 2, Requests: 1},
+	}, byModel)
+	byApp, err := l.SumBy(ctx, cost.Filter{}, cost.DimensionApp)
+	require.NoError(t, err)
+	assert.Equal(t, 8.0, byApp["other"].Cost)
+
+	_, err = l.SumBy(ctx, cost.Filter{}, cost.Dimension("tenant"))
+	assert.Error(t, err)
+}
+
+func TestLedger_SkipDBInit(t *testing.T) {
+	db := openTestDB(t)
+	l, err := NewLedger(WithGormDB(db), WithSkipDBInit(true))
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable(defaultTableName))
+	assert.Error(t, l.Add(context.Background(), cost.Record{}))
+	_, err = l.Sum(context.Background(), cost.Filter{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package gorm
+
+import (
+	gormio "gorm.io/gorm"
+)
+
+const defaultTableName = "cost_records"
+
+// Option configures the gorm ledger.
+type Option func(*options)
+
+type options struct {
+	db           *gormio.DB
+	instanceName string
+	tableName    string
+	skipDBInit   bool
+}
+
+var defaultOptions = options{
+	tableName: defaultTableName,
+}
+
+// WithGormDB uses an existing gorm database. The ledger does not close it.
+func WithGormDB(db *gormio.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithGormInstance uses a gorm instance registered with
+// storage/gorm.RegisterGormInstance.
+// Note: WithGormDB has higher priority than WithGormInstance.
+func WithGormInstance(name string) Option {
+	return func(o *options) {
+		o.instanceName = name
+	}
+}
+
+// WithTableName sets the table storing cost records.
+// Default is "cost_records".
+func WithTableName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.tableName = name
+		}
+	}
+}
+
+// WithSkipDBInit skips creating the table, for deployments that manage
+// schema migrations themselves.
+func WithSkipDBInit(skip bool) Option {
+	return func(o *options) {
+		o.skipDBInit = skip
+	}
+}
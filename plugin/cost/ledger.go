@@ -0,0 +1,167 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cost
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Record is the cost of one model call.
+type Record struct {
+	Time            time.Time `json:"time"`
+	AppName         string    `json:"app_name"`
+	UserID          string    `json:"user_id"`
+	SessionID       string    `json:"session_id"`
+	AgentName       string    `json:"agent_name"`
+	InvocationID    string    `json:"invocation_id"`
+	Model           string    `json:"model"`
+	InputTokens     int       `json:"input_tokens"`
+	OutputTokens    int       `json:"output_tokens"`
+	CachedTokens    int       `json:"cached_tokens"`
+	ReasoningTokens int       `json:"reasoning_tokens"`
+	Cost            float64   `json:"cost"`
+}
+
+// Filter selects records. Empty fields match everything.
+type Filter struct {
+	AppName   string
+	UserID    string
+	SessionID string
+	AgentName string
+	// Since matches records at or after the time.
+	Since time.Time
+	// Until matches records before the time.
+	Until time.Time
+}
+
+// Match reports whether r is selected by the filter.
+func (f Filter) Match(r Record) bool {
+	switch {
+	case f.AppName != "" && f.AppName != r.AppName,
+		f.UserID != "" && f.UserID != r.UserID,
+		f.SessionID != "" && f.SessionID != r.SessionID,
+		f.AgentName != "" && f.AgentName != r.AgentName,
+		!f.Since.IsZero() && r.Time.Before(f.Since),
+		!f.Until.IsZero() && !r.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Summary aggregates records.
+type Summary struct {
+	Cost         float64 `json:"cost"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Requests     int64   `json:"requests"`
+}
+
+// Add adds a record to the summary.
+func (s *Summary) Add(r Record) {
+	s.Cost += r.Cost
+	s.InputTokens += int64(r.InputTokens)
+	s.OutputTokens += int64(r.OutputTokens)
+	s.Requests++
+}
+
+// Dimension is a record field to group summaries by.
+type Dimension string
+
+// Dimensions supported by Ledger.SumBy.
+const (
+	DimensionApp     Dimension = "app"
+	DimensionUser    Dimension = "user"
+	DimensionSession Dimension = "session"
+	DimensionAgent   Dimension = "agent"
+	DimensionModel   Dimension = "model"
+)
+
+// Value returns the field of r selected by the dimension.
+func (d Dimension) Value(r Record) string {
+	switch d {
+	case DimensionApp:
+		return r.AppName
+	case DimensionUser:
+		return r.UserID
+	case DimensionSession:
+		return r.SessionID
+	case DimensionAgent:
+		return r.AgentName
+	case DimensionModel:
+		return r.Model
+	default:
+		return ""
+	}
+}
+
+// Ledger stores cost records and aggregates them.
+type Ledger interface {
+	// Add stores a record.
+	Add(ctx context.Context, r Record) error
+	// Sum aggregates the records selected by the filter.
+	Sum(ctx context.Context, f Filter) (Summary, error)
+	// SumBy aggregates the records selected by the filter, grouped by a
+	// dimension.
+	SumBy(ctx context.Context, f Filter, d Dimension) (map[string]Summary, error)
+}
+
+// MemoryLedger keeps records in memory. It suits tests and single-process
+// deployments; records are lost on restart and never expire.
+type MemoryLedger struct {
+	mu      sync.RWMutex
+	records []Record
+}
+
+var _ Ledger = (*MemoryLedger)(nil)
+
+// NewMemoryLedger creates an empty in-memory ledger.
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{}
+}
+
+// Add implements Ledger.
+func (l *MemoryLedger) Add(_ context.Context, r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, r)
+	return nil
+}
+
+// Sum implements Ledger.
+func (l *MemoryLedger) Sum(_ context.Context, f Filter) (Summary, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var s Summary
+	for _, r := range l.records {
+		if f.Match(r) {
+			s.Add(r)
+		}
+	}
+	return s, nil
+}
+
+// SumBy implements Ledger.
+func (l *MemoryLedger) SumBy(_ context.Context, f Filter, d Dimension) (map[string]Summary, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make(map[string]Summary)
+	for _, r := range l.records {
+		if !f.Match(r) {
+			continue
+		}
+		key := d.Value(r)
+		s := out[key]
+		s.Add(r)
+		out[key] = s
+	}
+	return out, nil
+}
@@ -0,0 +1,74 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLedger_SumAndSumBy(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryLedger()
+	base := time.Unix(1_700_000_000, 0)
+	records := []Record{
+		{Time: base, AppName: "app", UserID: "u1", SessionID: "s1", AgentName: "a", Model: "m1", InputTokens: 10, OutputTokens: 1, Cost: 1},
+		{Time: base.Add(time.Hour), AppName: "app", UserID: "u1", SessionID: "s2", AgentName: "b", Model: "m2", InputTokens: 20, OutputTokens: 2, Cost: 2},
+		{Time: base.Add(2 * time.Hour), AppName: "app", UserID: "u2", SessionID: "s3", AgentName: "a", Model: "m1", InputTokens: 30, OutputTokens: 3, Cost: 4},
+		{Time: base, AppName: "other", UserID: "u1", Cost: 8},
+	}
+	for _, r := range records {
+		require.NoError(t, l.Add(ctx, r))
+	}
+
+	sum, err := l.Sum(ctx, Filter{AppName: "app"})
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Cost: 7, InputTokens: 60, OutputTokens: 6, Requests: 3}, sum)
+
+	sum, _ = l.Sum(ctx, Filter{AppName: "app", UserID: "u1"})
+	assert.Equal(t, 3.0, sum.Cost)
+	sum, _ = l.Sum(ctx, Filter{SessionID: "s2"})
+	assert.Equal(t, 2.0, sum.Cost)
+	sum, _ = l.Sum(ctx, Filter{AgentName: "a"})
+	assert.Equal(t, 5.0, sum.Cost)
+	sum, _ = l.Sum(ctx, Filter{AppName: "app", Since: base.Add(time.Hour), Until: base.Add(2 * time.Hour)})
+	assert.Equal(t, 2.0, sum.Cost, "since is inclusive, until exclusive")
+
+	byModel, err := l.SumBy(ctx, Filter{AppName: "app"}, DimensionModel)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Summary{
+		"m1": {Cost: 5, InputTokens: 40, OutputTokens: 4, Requests: 2},
+		"m2": {Cost: 2, InputTokens: 20, OutputTokens: 2, Requests: 1},
+	}, byModel)
+
+	byApp, _ := l.SumBy(ctx, Filter{}, DimensionApp)
+	assert.Equal(t, 7.0, byApp["app"].Cost)
+	assert.Equal(t, 8.0, byApp["other"].Cost)
+	byUser, _ := l.SumBy(ctx, Filter{AppName: "app"}, DimensionUser)
+	assert.Len(t, byUser, 2)
+	bySession, _ := l.SumBy(ctx, Filter{AppName: "app"}, DimensionSession)
+	assert.Len(t, bySession, 3)
+	byAgent, _ := l.SumBy(ctx, Filter{AppName: "app"}, DimensionAgent)
+	assert.Equal(t, 5.0, byAgent["a"].Cost)
+}
+
+func TestBudget_Validate(t *testing.T) {
+	assert.NoError(t, Budget{Name: "b", Scope: ScopeUser, Limit: 1}.validate())
+	assert.Error(t, Budget{Name: "b", Scope: "tenant", Limit: 1}.validate())
+	assert.Error(t, Budget{Name: "b", Scope: ScopeApp}.validate())
+	assert.Error(t, Budget{Name: "b", Scope: ScopeApp, Limit: 1, Window: -time.Second}.validate())
+
+	_, err := New(WithBudgets(Budget{Name: "bad", Scope: ScopeApp}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cost
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/model/pricing"
+)
+
+const defaultPluginName = "cost"
+
+// Option configures the cost plugin.
+type Option func(*options)
+
+type options struct {
+	name          string
+	catalog       *pricing.Catalog
+	ledger        Ledger
+	budgets       []Budget
+	meterProvider metric.MeterProvider
+	now           func() time.Time
+}
+
+func newOptions(opts ...Option) *options {
+	options := &options{
+		name:   defaultPluginName,
+		ledger: NewMemoryLedger(),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(options)
+		}
+	}
+	return options
+}
+
+// WithName sets the plugin name.
+func WithName(name string) Option {
+	return func(opts *options) {
+		opts.name = name
+	}
+}
+
+// WithCatalog sets the prices used to cost model calls. Calls to models
+// missing from the catalog are recorded with zero cost.
+func WithCatalog(catalog *pricing.Catalog) Option {
+	return func(opts *options) {
+		opts.catalog = catalog
+	}
+}
+
+// WithLedger sets where cost records are stored. Default is an in-memory
+// ledger; use a shared ledger such as plugin/cost/gorm when budgets must
+// hold across replicas.
+func WithLedger(ledger Ledger) Option {
+	return func(opts *options) {
+		if ledger != nil {
+			opts.ledger = ledger
+		}
+	}
+}
+
+// WithBudgets adds budgets enforced before every agent run and model call.
+func WithBudgets(budgets ...Budget) Option {
+	return func(opts *options) {
+		opts.budgets = append(opts.budgets, budgets...)
+	}
+}
+
+// WithMeterProvider sets the meter provider cost metrics are exported to.
+// Default is the global provider from telemetry/metric.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(opts *options) {
+		opts.meterProvider = mp
+	}
+}
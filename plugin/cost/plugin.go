@@ -0,0 +1,278 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package cost provides a Runner plugin that prices model calls, records
+// them in a ledger and enforces spending budgets.
+//
+// Every final model response carrying usage is priced with a
+// pricing.Catalog, stored in a Ledger aggregated by app, user, session,
+// agent and model, exported as the trpc_agent_go.client.cost metric and
+// attached to the event under ExtensionKey.
+//
+// Budgets are checked before every agent run and model call. An exceeded
+// budget either rejects the run with an error wrapping ErrBudgetExceeded,
+// or switches the rest of the run to a cheaper fallback model:
+//
+//	p, err := cost.New(
+//		cost.WithCatalog(catalog),
+//		cost.WithBudgets(
+//			cost.Budget{Name: "daily-user", Scope: cost.ScopeUser, Limit: 1, Window: 24 * time.Hour,
+//				Fallback: openai.New("gpt-4o-mini")},
+//			cost.Budget{Name: "monthly-app", Scope: cost.ScopeApp, Limit: 500, Window: 30 * 24 * time.Hour},
+//		),
+//	)
+//	r := runner.NewRunner("app", agent, runner.WithPlugins(p))
+package cost
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/pricing"
+	"trpc.group/trpc-go/trpc-agent-go/plugin"
+	tmetric "trpc.group/trpc-go/trpc-agent-go/telemetry/metric"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+	semconvtrace "trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/trace"
+)
+
+// ExtensionKey is the event extension holding the Info of a priced model
+// response.
+const ExtensionKey = "trpc_agent.cost"
+
+// Budget actions reported in metrics.
+const (
+	actionReject  = "reject"
+	actionDegrade = "degrade"
+)
+
+// Info is the cost attached to a model response event.
+type Info struct {
+	Model     string       `json:"model"`
+	Priced    bool         `json:"priced"`
+	Breakdown pricing.Cost `json:"breakdown"`
+	Total     float64      `json:"total"`
+}
+
+// Plugin prices model calls and enforces budgets.
+type Plugin struct {
+	opts *options
+
+	metricsOnce sync.Once
+	costCounter metric.Float64Counter
+	actionCnt   metric.Int64Counter
+}
+
+var _ plugin.Plugin = (*Plugin)(nil)
+
+// New creates a cost plugin.
+func New(options ...Option) (*Plugin, error) {
+	opts := newOptions(options...)
+	for _, b := range opts.budgets {
+		if err := b.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Plugin{opts: opts}, nil
+}
+
+// Name implements plugin.Plugin.
+func (p *Plugin) Name() string {
+	return p.opts.name
+}
+
+// Ledger returns the ledger the plugin records to, for reporting.
+func (p *Plugin) Ledger() Ledger {
+	return p.opts.ledger
+}
+
+// Register implements plugin.Plugin.
+func (p *Plugin) Register(r *plugin.Registry) {
+	if p == nil || r == nil {
+		return
+	}
+	if len(p.opts.budgets) > 0 {
+		r.BeforeAgent(p.beforeAgent)
+		r.BeforeModel(p.beforeModel)
+	}
+	r.OnEvent(p.onEvent)
+}
+
+func (p *Plugin) beforeAgent(
+	ctx context.Context,
+	args *agent.BeforeAgentArgs,
+) (*agent.BeforeAgentResult, error) {
+	if args == nil || args.Invocation == nil {
+		return nil, nil
+	}
+	return nil, p.enforce(ctx, args.Invocation)
+}
+
+// beforeModel re-checks budgets between model calls of a long run. A
+// fallback chosen here applies from the next model call on.
+func (p *Plugin) beforeModel(
+	ctx context.Context,
+	_ *model.BeforeModelArgs,
+) (*model.BeforeModelResult, error) {
+	inv, ok := agent.InvocationFromContext(ctx)
+	if !ok || inv == nil {
+		return nil, nil
+	}
+	return nil, p.enforce(ctx, inv)
+}
+
+// enforce rejects the invocation when a budget without fallback is
+// exceeded, or else switches it to the fallback of the first exceeded
+// budget.
+func (p *Plugin) enforce(ctx context.Context, inv *agent.Invocation) error {
+	appName, userID, sessionID := sessionKeys(inv)
+	now := p.opts.now()
+	var degrade *Budget
+	for i := range p.opts.budgets {
+		b := &p.opts.budgets[i]
+		if degrade != nil && b.Fallback != nil {
+			continue
+		}
+		sum, err := p.opts.ledger.Sum(ctx, b.filter(appName, userID, sessionID, now))
+		if err != nil {
+			// Fail open: an unavailable ledger must not take the app down.
+			log.WarnfContext(ctx, "cost: sum budget %q: %v", b.Name, err)
+			continue
+		}
+		if sum.Cost < b.Limit {
+			continue
+		}
+		if b.Fallback == nil {
+			p.countAction(ctx, appName, b.Name, actionReject)
+			return &ExceededError{Budget: b.Name, Spent: sum.Cost, Limit: b.Limit}
+		}
+		degrade = b
+	}
+	if degrade != nil && inv.RunOptions.Model != degrade.Fallback {
+		inv.RunOptions.Model = degrade.Fallback
+		p.countAction(ctx, appName, degrade.Name, actionDegrade)
+		log.InfofContext(ctx, "cost: budget %q exceeded, switching to model %s",
+			degrade.Name, degrade.Fallback.Info().Name)
+	}
+	return nil
+}
+
+func (p *Plugin) onEvent(
+	ctx context.Context,
+	inv *agent.Invocation,
+	e *event.Event,
+) (*event.Event, error) {
+	if e == nil || e.Response == nil || e.Response.IsPartial || e.Response.Usage == nil ||
+		e.Response.Object == model.ObjectTypeRunnerCompletion {
+		return nil, nil
+	}
+	rsp := e.Response
+	modelName := rsp.Model
+	if modelName == "" && inv != nil && inv.Model != nil {
+		modelName = inv.Model.Info().Name
+	}
+	c, priced := p.opts.catalog.Cost(modelName, rsp.Usage)
+	tokens := pricing.TokensOf(rsp.Usage)
+
+	appName, userID, sessionID := sessionKeys(inv)
+	rec := Record{
+		Time:            p.opts.now(),
+		AppName:         appName,
+		UserID:          userID,
+		SessionID:       sessionID,
+		AgentName:       e.Author,
+		InvocationID:    e.InvocationID,
+		Model:           modelName,
+		InputTokens:     rsp.Usage.PromptTokens,
+		OutputTokens:    rsp.Usage.CompletionTokens,
+		CachedTokens:    tokens.CachedInput,
+		ReasoningTokens: tokens.Reasoning,
+		Cost:            c.Total,
+	}
+	if err := p.opts.ledger.Add(ctx, rec); err != nil {
+		log.WarnfContext(ctx, "cost: record model call: %v", err)
+	}
+	if priced {
+		p.recordCost(ctx, rec)
+	}
+	if err := event.SetExtension(e, ExtensionKey, Info{
+		Model:     modelName,
+		Priced:    priced,
+		Breakdown: c,
+		Total:     c.Total,
+	}); err != nil {
+		return nil, fmt.Errorf("%s: attach cost: %w", p.opts.name, err)
+	}
+	return e, nil
+}
+
+func sessionKeys(inv *agent.Invocation) (appName, userID, sessionID string) {
+	if inv == nil || inv.Session == nil {
+		return "", "", ""
+	}
+	return inv.Session.AppName, inv.Session.UserID, inv.Session.ID
+}
+
+func (p *Plugin) initMetrics() {
+	p.metricsOnce.Do(func() {
+		mp := p.opts.meterProvider
+		if mp == nil {
+			mp = tmetric.GetMeterProvider()
+		}
+		if mp == nil {
+			return
+		}
+		meter := mp.Meter(metrics.MeterNameCost)
+		var err error
+		if p.costCounter, err = meter.Float64Counter(
+			metrics.MetricTRPCAgentGoClientCost,
+			metric.WithDescription("Accumulated cost of model calls"),
+		); err != nil {
+			log.Warnf("cost: create metric %s: %v", metrics.MetricTRPCAgentGoClientCost, err)
+		}
+		if p.actionCnt, err = meter.Int64Counter(
+			metrics.MetricTRPCAgentGoBudgetActionCnt,
+			metric.WithDescription("Number of runs rejected or degraded by a budget"),
+			metric.WithUnit("1"),
+		); err != nil {
+			log.Warnf("cost: create metric %s: %v", metrics.MetricTRPCAgentGoBudgetActionCnt, err)
+		}
+	})
+}
+
+func (p *Plugin) recordCost(ctx context.Context, rec Record) {
+	p.initMetrics()
+	if p.costCounter == nil {
+		return
+	}
+	p.costCounter.Add(ctx, rec.Cost, metric.WithAttributes(
+		attribute.String(semconvtrace.KeyTRPCAgentGoAppName, rec.AppName),
+		attribute.String(semconvtrace.KeyGenAIAgentName, rec.AgentName),
+		attribute.String(semconvtrace.KeyGenAIRequestModel, rec.Model),
+	))
+}
+
+func (p *Plugin) countAction(ctx context.Context, appName, budget, action string) {
+	p.initMetrics()
+	if p.actionCnt == nil {
+		return
+	}
+	p.actionCnt.Add(ctx, 1, metric.WithAttributes(
+		attribute.String(semconvtrace.KeyTRPCAgentGoAppName, appName),
+		attribute.String(metrics.KeyTRPCAgentGoBudgetName, budget),
+		attribute.String(metrics.KeyTRPCAgentGoBudgetAction, action),
+	))
+}
@@ -0,0 +1,237 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cost
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/pricing"
+	"trpc.group/trpc-go/trpc-agent-go/plugin"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/semconv/metrics"
+)
+
+// usageModel answers every request with "ok" and fixed usage.
+type usageModel struct {
+	name  string
+	calls atomic.Int32
+}
+
+func (m *usageModel) Info() model.Info { return model.Info{Name: m.name} }
+
+func (m *usageModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	m.calls.Add(1)
+	ch := make(chan *model.Response, 1)
+	ch <- &model.Response{
+		Model:   m.name,
+		Done:    true,
+		Choices: []model.Choice{{Message: model.NewAssistantMessage("ok")}},
+		Usage:   &model.Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000, TotalTokens: 1_100_000},
+	}
+	close(ch)
+	return ch, nil
+}
+
+var testCatalog = pricing.NewCatalog(map[string]pricing.Price{
+	"expensive": {InputPerMillion: 1, OutputPerMillion: 10},
+	"cheap":     {InputPerMillion: 0.1, OutputPerMillion: 1},
+})
+
+// run sends one message and returns the runner events and the run error,
+// whether surfaced by Run or as an error event.
+func run(t *testing.T, r runner.Runner, userID string) ([]*event.Event, error) {
+	t.Helper()
+	ch, err := r.Run(context.Background(), userID, "session-"+userID, model.NewUserMessage("hi"))
+	if err != nil {
+		return nil, err
+	}
+	var events []*event.Event
+	for e := range ch {
+		events = append(events, e)
+		if e.Response != nil && e.Response.Error != nil {
+			err = errors.New(e.Response.Error.Message)
+		}
+	}
+	return events, err
+}
+
+func newRunner(t *testing.T, m model.Model, p *Plugin) runner.Runner {
+	t.Helper()
+	r := runner.NewRunner("app", llmagent.New("assistant", llmagent.WithModel(m)), runner.WithPlugins(p))
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestPlugin_RecordsCostAndAttachesExtension(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	p, err := New(
+		WithCatalog(testCatalog),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "cost", p.Name())
+
+	events, err := run(t, newRunner(t, &usageModel{name: "expensive"}, p), "u1")
+	require.NoError(t, err)
+
+	var info *Info
+	for _, e := range events {
+		if got, ok, _ := event.GetExtension[Info](e, ExtensionKey); ok {
+			info = &got
+		}
+	}
+	require.NotNil(t, info)
+	assert.True(t, info.Priced)
+	assert.Equal(t, "expensive", info.Model)
+	assert.InDelta(t, 2.0, info.Total, 1e-9)
+
+	sum, err := p.Ledger().Sum(context.Background(), Filter{AppName: "app", UserID: "u1", SessionID: "session-u1"})
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, sum.Cost, 1e-9)
+	assert.Equal(t, int64(1), sum.Requests)
+	byAgent, _ := p.Ledger().SumBy(context.Background(), Filter{}, DimensionAgent)
+	assert.Contains(t, byAgent, "assistant")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, metrics.MeterNameCost, rm.ScopeMetrics[0].Scope.Name)
+	data := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[float64])
+	assert.InDelta(t, 2.0, data.DataPoints[0].Value, 1e-9)
+}
+
+func TestPlugin_UnpricedModelRecordedWithZeroCost(t *testing.T) {
+	p, err := New()
+	require.NoError(t, err)
+	_, err = run(t, newRunner(t, &usageModel{name: "unknown"}, p), "u1")
+	require.NoError(t, err)
+	sum, _ := p.Ledger().Sum(context.Background(), Filter{})
+	assert.Equal(t, int64(1), sum.Requests)
+	assert.Zero(t, sum.Cost)
+}
+
+func TestPlugin_RejectsOverBudget(t *testing.T) {
+	p, err := New(
+		WithCatalog(testCatalog),
+		WithBudgets(Budget{Name: "per-user", Scope: ScopeUser, Limit: 3, Window: time.Hour}),
+	)
+	require.NoError(t, err)
+	m := &usageModel{name: "expensive"}
+	r := newRunner(t, m, p)
+
+	for i := 0; i < 2; i++ {
+		_, err = run(t, r, "u1")
+		require.NoError(t, err)
+	}
+	_, err = run(t, r, "u1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrBudgetExceeded.Error())
+	assert.Equal(t, int32(2), m.calls.Load())
+
+	// Other users have their own budget.
+	_, err = run(t, r, "u2")
+	require.NoError(t, err)
+
+	// Spend older than the window no longer counts.
+	p.opts.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = run(t, r, "u1")
+	require.NoError(t, err)
+}
+
+func TestPlugin_DegradesToFallback(t *testing.T) {
+	expensive := &usageModel{name: "expensive"}
+	cheap := &usageModel{name: "cheap"}
+	p, err := New(
+		WithCatalog(testCatalog),
+		WithBudgets(
+			Budget{Name: "app", Scope: ScopeApp, Limit: 1, Fallback: cheap},
+			Budget{Name: "hard", Scope: ScopeApp, Limit: 100},
+		),
+	)
+	require.NoError(t, err)
+	r := newRunner(t, expensive, p)
+
+	for i := 0; i < 3; i++ {
+		_, err = run(t, r, "u1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), expensive.calls.Load())
+	assert.Equal(t, int32(2), cheap.calls.Load())
+
+	byModel, _ := p.Ledger().SumBy(context.Background(), Filter{AppName: "app"}, DimensionModel)
+	assert.InDelta(t, 2.0, byModel["expensive"].Cost, 1e-9)
+	assert.InDelta(t, 0.4, byModel["cheap"].Cost, 1e-9)
+}
+
+func TestPlugin_BeforeModelSwitchesLaterCalls(t *testing.T) {
+	cheap := &usageModel{name: "cheap"}
+	p, err := New(
+		WithCatalog(testCatalog),
+		WithBudgets(Budget{Name: "session", Scope: ScopeSession, Limit: 1, Fallback: cheap}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, p.Ledger().Add(context.Background(), Record{
+		Time: time.Now(), AppName: "app", UserID: "u", SessionID: "s", Cost: 5,
+	}))
+
+	inv := &agent.Invocation{Session: &session.Session{AppName: "app", UserID: "u", ID: "s"}}
+	ctx := agent.NewInvocationContext(context.Background(), inv)
+	_, err = p.beforeModel(ctx, &model.BeforeModelArgs{})
+	require.NoError(t, err)
+	assert.Same(t, cheap, inv.RunOptions.Model)
+
+	_, err = p.beforeModel(context.Background(), &model.BeforeModelArgs{})
+	assert.NoError(t, err, "no invocation in context")
+}
+
+// failingLedger fails every call.
+type failingLedger struct{}
+
+func (failingLedger) Add(context.Context, Record) error { return errors.New("down") }
+func (failingLedger) Sum(context.Context, Filter) (Summary, error) {
+	return Summary{}, errors.New("down")
+}
+func (failingLedger) SumBy(context.Context, Filter, Dimension) (map[string]Summary, error) {
+	return nil, errors.New("down")
+}
+
+func TestPlugin_LedgerErrorsFailOpen(t *testing.T) {
+	p, err := New(
+		WithCatalog(testCatalog),
+		WithLedger(failingLedger{}),
+		WithBudgets(Budget{Name: "b", Scope: ScopeApp, Limit: 1}),
+	)
+	require.NoError(t, err)
+	_, err = run(t, newRunner(t, &usageModel{name: "expensive"}, p), "u1")
+	require.NoError(t, err)
+}
+
+func TestPlugin_Register(t *testing.T) {
+	p, err := New()
+	require.NoError(t, err)
+	m, err := plugin.NewManager(p)
+	require.NoError(t, err)
+	assert.NotNil(t, m)
+	p.Register(nil)
+}
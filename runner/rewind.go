@@ -0,0 +1,120 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/fork"
+)
+
+var (
+	// ErrRewindUnsupported indicates that the runner cannot rewind
+	// conversations.
+	ErrRewindUnsupported = errors.New("runner: rewind unsupported")
+	// ErrInvalidRewindMessage indicates that the replacement message of a
+	// rewind is not a non-empty user message.
+	ErrInvalidRewindMessage = errors.New(
+		"runner: rewind message must be a non-empty user message",
+	)
+)
+
+// RewindRequest describes where to rewind a conversation to.
+type RewindRequest struct {
+	// EventID is the user message to rewind to. When it is a later event,
+	// such as an answer to regenerate, the last user message before it is
+	// used.
+	EventID string
+	// Message replaces the user message at the rewind point (edit and
+	// regenerate). When nil, the original message is sent again
+	// (regenerate).
+	Message *model.Message
+	// BranchSessionID is the ID of the new branch session. A UUID is used
+	// when empty.
+	BranchSessionID string
+}
+
+// Rewinder extends Runner with rewinding conversations.
+//
+// Rewinding never destroys history: the conversation is forked into a new
+// branch session, see package session/fork, and the source session is left
+// as it was. Branches can be listed with fork.List.
+type Rewinder interface {
+	// Rewind forks sessionID before req.EventID into a new branch session
+	// and runs the agent on the branch with the user message at that point,
+	// or req.Message. It returns the branch session ID and the events of the
+	// run.
+	Rewind(
+		ctx context.Context,
+		userID string,
+		sessionID string,
+		req RewindRequest,
+		runOpts ...agent.RunOption,
+	) (string, <-chan *event.Event, error)
+}
+
+var _ Rewinder = (*runner)(nil)
+
+// Rewind rewinds a conversation on runners that support it.
+func Rewind(
+	ctx context.Context,
+	r Runner,
+	userID string,
+	sessionID string,
+	req RewindRequest,
+	runOpts ...agent.RunOption,
+) (string, <-chan *event.Event, error) {
+	rewinder, ok := r.(Rewinder)
+	if !ok {
+		return "", nil, ErrRewindUnsupported
+	}
+	return rewinder.Rewind(ctx, userID, sessionID, req, runOpts...)
+}
+
+// Rewind implements Rewinder.
+func (r *runner) Rewind(
+	ctx context.Context,
+	userID string,
+	sessionID string,
+	req RewindRequest,
+	runOpts ...agent.RunOption,
+) (string, <-chan *event.Event, error) {
+	var replacement model.Message
+	if req.Message != nil {
+		replacement = *req.Message
+		if replacement.Role == "" && model.HasPayload(replacement) {
+			replacement.Role = model.RoleUser
+		}
+		if replacement.Role != model.RoleUser || !model.HasPayload(replacement) {
+			return "", nil, ErrInvalidRewindMessage
+		}
+	}
+	key := session.Key{AppName: r.appName, UserID: userID, SessionID: sessionID}
+	forked, err := fork.At(ctx, r.sessionService, key, req.EventID, fork.WithSessionID(req.BranchSessionID))
+	if err != nil {
+		return "", nil, fmt.Errorf("runner: rewind session %s: %w", sessionID, err)
+	}
+	message := forked.Message
+	if req.Message != nil {
+		message = replacement
+	}
+	branchID := forked.Session.ID
+	events, err := r.Run(ctx, userID, branchID, message, runOpts...)
+	if err != nil {
+		return branchID, nil, err
+	}
+	return branchID, events, nil
+}
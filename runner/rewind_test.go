@@ -0,0 +1,105 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/fork"
+	sessioninmemory "trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+func TestRunnerRewind(t *testing.T) {
+	ctx := context.Background()
+	svc := sessioninmemory.NewSessionService()
+	defer svc.Close()
+	r := NewRunner("app", &mockAgent{name: "assistant"}, WithSessionService(svc))
+	defer r.Close()
+
+	for _, text := range []string{"first", "second"} {
+		events, err := r.Run(ctx, "user", "s1", model.NewUserMessage(text))
+		require.NoError(t, err)
+		for range events {
+		}
+	}
+	key := session.Key{AppName: "app", UserID: "user", SessionID: "s1"}
+	sess, err := svc.GetSession(ctx, key)
+	require.NoError(t, err)
+	require.Len(t, sess.Events, 4)
+	second := sess.Events[2]
+	require.True(t, second.IsUserMessage())
+
+	edited := model.NewUserMessage("second, edited")
+	branchID, events, err := Rewind(ctx, r, "user", "s1", RewindRequest{
+		EventID:         second.ID,
+		Message:         &edited,
+		BranchSessionID: "s1-edit",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s1-edit", branchID)
+	for range events {
+	}
+
+	branch, err := svc.GetSession(ctx, session.Key{AppName: "app", UserID: "user", SessionID: branchID})
+	require.NoError(t, err)
+	require.Len(t, branch.Events, 4)
+	assert.Equal(t, "first", branch.Events[0].Response.Choices[0].Message.Content)
+	assert.Equal(t, "second, edited", branch.Events[2].Response.Choices[0].Message.Content)
+	assert.Contains(t, branch.Events[3].Response.Choices[0].Message.Content, "second, edited")
+
+	// Regenerating from the answer resends the original message.
+	answer := sess.Events[1]
+	require.False(t, answer.IsUserMessage())
+	branchID, events, err = Rewind(ctx, r, "user", "s1", RewindRequest{EventID: answer.ID})
+	require.NoError(t, err)
+	for range events {
+	}
+	branch, err = svc.GetSession(ctx, session.Key{AppName: "app", UserID: "user", SessionID: branchID})
+	require.NoError(t, err)
+	require.Len(t, branch.Events, 2)
+	assert.Equal(t, "first", branch.Events[0].Response.Choices[0].Message.Content)
+
+	// The source session is untouched and the branches are listed.
+	sess, err = svc.GetSession(ctx, key)
+	require.NoError(t, err)
+	assert.Len(t, sess.Events, 4)
+	branches, err := fork.List(ctx, svc, session.UserKey{AppName: "app", UserID: "user"}, "s1-edit")
+	require.NoError(t, err)
+	require.Len(t, branches, 3)
+	assert.Equal(t, "s1", branches[0].SessionID)
+	assert.Nil(t, branches[0].Info)
+}
+
+func TestRunnerRewind_Errors(t *testing.T) {
+	ctx := context.Background()
+	r := NewRunner("app", &mockAgent{name: "assistant"})
+	defer r.Close()
+
+	invalid := model.NewAssistantMessage("not a user message")
+	_, _, err := Rewind(ctx, r, "user", "s1", RewindRequest{EventID: "e1", Message: &invalid})
+	assert.ErrorIs(t, err, ErrInvalidRewindMessage)
+
+	_, _, err = Rewind(ctx, r, "user", "missing", RewindRequest{EventID: "e1"})
+	assert.ErrorIs(t, err, fork.ErrSessionNotFound)
+
+	_, _, err = Rewind(ctx, plainRunner{r}, "user", "s1", RewindRequest{EventID: "e1"})
+	assert.ErrorIs(t, err, ErrRewindUnsupported)
+}
+
+// plainRunner hides the optional capabilities of a runner.
+type plainRunner struct {
+	Runner
+}
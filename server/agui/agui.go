@@ -88,6 +88,25 @@ func newService(runner runner.Runner, opts *options) (service.Service, error) {
 			service.WithCancelPath(cancelPath),
 		)
 	}
+	if opts.rewindEnabled {
+		if opts.sessionService == nil {
+			return nil, errors.New("agui: session service is required when rewind is enabled")
+		}
+		rewindPath, err := joinURLPath(opts.basePath, opts.rewindPath)
+		if err != nil {
+			return nil, fmt.Errorf("agui: url join rewind path: %w", err)
+		}
+		branchesPath, err := joinURLPath(opts.basePath, opts.branchesPath)
+		if err != nil {
+			return nil, fmt.Errorf("agui: url join branches path: %w", err)
+		}
+		serviceOpts = append(
+			serviceOpts,
+			service.WithRewindEnabled(true),
+			service.WithRewindPath(rewindPath),
+			service.WithBranchesPath(branchesPath),
+		)
+	}
 	if opts.messagesSnapshotEnabled {
 		if opts.appName == "" {
 			return nil, errors.New("agui: app name is required when messages snapshot is enabled")
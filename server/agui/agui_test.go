@@ -22,6 +22,7 @@ import (
 	aguievents "github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
 	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"trpc.group/trpc-go/trpc-agent-go/agent"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	"trpc.group/trpc-go/trpc-agent-go/model"
@@ -290,6 +291,39 @@ func TestNewMessagesSnapshotRequiresSessionService(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestNewRewindRequiresSessionService(t *testing.T) {
+	agent := &mockAgent{info: agent.Info{Name: "demo"}}
+	r := runner.NewRunner(agent.Info().Name, agent)
+	srv, err := New(r, WithRewindEnabled(true))
+	assert.Nil(t, srv)
+	assert.EqualError(t, err, "new service: agui: session service is required when rewind is enabled")
+}
+
+func TestNewRewindRegistersRoutes(t *testing.T) {
+	agent := &mockAgent{info: agent.Info{Name: "demo"}}
+	r := runner.NewRunner(agent.Info().Name, agent)
+	srv, err := New(r,
+		WithBasePath("/agui"),
+		WithPath("/chat"),
+		WithAppName("demo"),
+		WithSessionService(inmemory.NewSessionService()),
+		WithRewindEnabled(true),
+		WithBranchesPath("/threads"),
+	)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/agui/threads", strings.NewReader(`{"threadId":"thread"}`))
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"threadId":"thread","branches":[]}`, rr.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/agui/rewind", nil)
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
 func TestNewDistributedCancelRequiresSessionService(t *testing.T) {
 	agent := &mockAgent{info: agent.Info{Name: "demo"}}
 	r := runner.NewRunner(agent.Info().Name, agent)
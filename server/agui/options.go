@@ -25,6 +25,8 @@ var (
 	defaultServiceFactory        = sse.New
 	defaultMessagesSnapshotPath  = "/history"
 	defaultCancelPath            = "/cancel"
+	defaultRewindPath            = "/rewind"
+	defaultBranchesPath          = "/branches"
 	defaultMessagesSnapshotState = false
 	defaultCancelState           = false
 )
@@ -41,6 +43,9 @@ type options struct {
 	cancelPath               string
 	cancelEnabled            bool
 	distributedCancelEnabled bool
+	rewindPath               string
+	branchesPath             string
+	rewindEnabled            bool
 	heartbeatInterval        time.Duration
 	appName                  string
 	sessionService           session.Service
@@ -57,6 +62,8 @@ func newOptions(opt ...Option) *options {
 		messagesSnapshotEnabled: defaultMessagesSnapshotState,
 		cancelPath:              defaultCancelPath,
 		cancelEnabled:           defaultCancelState,
+		rewindPath:              defaultRewindPath,
+		branchesPath:            defaultBranchesPath,
 	}
 	for _, o := range opt {
 		o(opts)
@@ -268,6 +275,29 @@ func WithConcurrentMessageStreamsEnabled(enabled bool) Option {
 	}
 }
 
+// WithRewindPath sets the rewind endpoint path for AG-UI service, "/rewind" in default.
+func WithRewindPath(path string) Option {
+	return func(o *options) {
+		o.rewindPath = path
+	}
+}
+
+// WithBranchesPath sets the branches endpoint path for AG-UI service, "/branches" in default.
+func WithBranchesPath(path string) Option {
+	return func(o *options) {
+		o.branchesPath = path
+	}
+}
+
+// WithRewindEnabled enables the rewind and branches handlers. Rewinding reruns a thread from one of its user
+// messages, edited or not, on a new branch thread instead of changing the thread, and the branches handler lists
+// the branch threads of a thread. It requires a session service.
+func WithRewindEnabled(e bool) Option {
+	return func(o *options) {
+		o.rewindEnabled = e
+	}
+}
+
 // WithMessagesSnapshotPath sets the HTTP path for the messages snapshot handler, "/history" in default.
 func WithMessagesSnapshotPath(p string) Option {
 	return func(o *options) {
@@ -0,0 +1,185 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	aguievents "github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/types"
+	"trpc.group/trpc-go/trpc-agent-go/server/agui/adapter"
+	"trpc.group/trpc-go/trpc-agent-go/server/agui/internal/multimodal"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/fork"
+)
+
+// Rewinder reruns a thread from one of its user messages on a new branch thread.
+type Rewinder interface {
+	// Rewind forks the thread before the user message whose ID is the ID of the last input message and runs the
+	// last input message, edited or not, on the new branch thread. RUN_STARTED carries the branch thread ID.
+	Rewind(ctx context.Context, input *adapter.RunAgentInput) (<-chan aguievents.Event, error)
+}
+
+// BranchLister lists the branch threads created by rewinding a thread.
+type BranchLister interface {
+	// Branches returns the thread of the input, the thread it was forked from and all threads forked from the
+	// same root, oldest first.
+	Branches(ctx context.Context, input *adapter.RunAgentInput) ([]fork.Branch, error)
+}
+
+// Rewind forks the thread of the input before the rewound user message and runs the agent on the branch thread.
+func (r *runner) Rewind(ctx context.Context, runAgentInput *adapter.RunAgentInput) (<-chan aguievents.Event, error) {
+	if r.runner == nil {
+		return nil, errors.New("runner is nil")
+	}
+	if runAgentInput == nil {
+		return nil, errors.New("run input cannot be nil")
+	}
+	if r.sessionService == nil {
+		return nil, errors.New("session service is nil")
+	}
+	hooked, err := r.applyRunAgentInputHook(ctx, runAgentInput)
+	if err != nil {
+		return nil, fmt.Errorf("run input hook: %w", err)
+	}
+	messages, err := inputMessagesFromRunAgentInput(hooked)
+	if err != nil {
+		return nil, fmt.Errorf("build input message: %w", err)
+	}
+	if messages.userMessage == nil || messages.inputID == "" {
+		return nil, errors.New("last message must be a user message with an id")
+	}
+	key, err := r.sessionKey(ctx, hooked)
+	if err != nil {
+		return nil, err
+	}
+	eventID, trackCutoff, err := r.rewindPoint(ctx, key, messages.inputID)
+	if err != nil {
+		return nil, err
+	}
+	forked, err := fork.At(ctx, r.sessionService, key, eventID, fork.WithTrackCutoff(trackCutoff))
+	if err != nil {
+		return nil, fmt.Errorf("fork thread: %w", err)
+	}
+	branch := *runAgentInput
+	branch.ThreadID = forked.Session.ID
+	return r.Run(ctx, &branch)
+}
+
+// Branches lists the branch threads of the thread of the input.
+func (r *runner) Branches(ctx context.Context, runAgentInput *adapter.RunAgentInput) ([]fork.Branch, error) {
+	if runAgentInput == nil {
+		return nil, errors.New("run input cannot be nil")
+	}
+	if r.sessionService == nil {
+		return nil, errors.New("session service is nil")
+	}
+	hooked, err := r.applyRunAgentInputHook(ctx, runAgentInput)
+	if err != nil {
+		return nil, fmt.Errorf("run input hook: %w", err)
+	}
+	key, err := r.sessionKey(ctx, hooked)
+	if err != nil {
+		return nil, err
+	}
+	return fork.List(ctx, r.sessionService, session.UserKey{AppName: key.AppName, UserID: key.UserID}, key.SessionID)
+}
+
+// sessionKey resolves the session key of the thread of the input.
+func (r *runner) sessionKey(ctx context.Context, input *adapter.RunAgentInput) (session.Key, error) {
+	appName, err := r.resolveAppName(ctx, input)
+	if err != nil {
+		return session.Key{}, fmt.Errorf("resolve app name: %w", err)
+	}
+	userID, err := r.userIDResolver(ctx, input)
+	if err != nil {
+		return session.Key{}, fmt.Errorf("resolve user ID: %w", err)
+	}
+	return session.Key{AppName: appName, UserID: userID, SessionID: input.ThreadID}, nil
+}
+
+// rewindPoint resolves the AG-UI message ID of a user message to its session event and to the time its track
+// events start. The n-th user message of the AG-UI track is the n-th user message event of the session. Message
+// IDs missing from the track are taken as session event IDs.
+func (r *runner) rewindPoint(ctx context.Context, key session.Key, messageID string) (string, time.Time, error) {
+	if r.tracker == nil {
+		return messageID, time.Time{}, nil
+	}
+	sess, err := r.sessionService.GetSession(ctx, key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("get session: %w", err)
+	}
+	if sess == nil {
+		return "", time.Time{}, fork.ErrSessionNotFound
+	}
+	trackEvents, err := r.tracker.GetEvents(ctx, key)
+	if err != nil || trackEvents == nil {
+		return messageID, time.Time{}, nil
+	}
+	ordinal := 0
+	for _, trackEvent := range trackEvents.Events {
+		id, ok := userMessageTrackEventID(trackEvent)
+		if !ok {
+			continue
+		}
+		if id != messageID {
+			ordinal++
+			continue
+		}
+		for _, evt := range sess.GetEvents() {
+			if !evt.IsUserMessage() {
+				continue
+			}
+			if ordinal == 0 {
+				return evt.ID, trackEvent.Timestamp, nil
+			}
+			ordinal--
+		}
+		break
+	}
+	return messageID, time.Time{}, nil
+}
+
+// userMessageTrackEventID returns the message ID of a user message track event.
+func userMessageTrackEventID(trackEvent session.TrackEvent) (string, bool) {
+	if len(trackEvent.Payload) == 0 {
+		return "", false
+	}
+	evt, err := aguievents.EventFromJSON(trackEvent.Payload)
+	if err != nil {
+		return "", false
+	}
+	switch e := evt.(type) {
+	case *aguievents.CustomEvent:
+		if e.Name != multimodal.CustomEventNameUserMessage {
+			return "", false
+		}
+		data, err := json.Marshal(e.Value)
+		if err != nil {
+			return "", false
+		}
+		var message types.Message
+		if err := json.Unmarshal(data, &message); err != nil {
+			return "", false
+		}
+		return message.ID, true
+	case *aguievents.TextMessageStartEvent:
+		if e.Role == nil || *e.Role != string(types.RoleUser) {
+			return "", false
+		}
+		return e.MessageID, true
+	default:
+		return "", false
+	}
+}
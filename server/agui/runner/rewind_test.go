@@ -0,0 +1,143 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	aguievents "github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	agentevent "trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/server/agui/adapter"
+	"trpc.group/trpc-go/trpc-agent-go/server/agui/internal/multimodal"
+	aguitrack "trpc.group/trpc-go/trpc-agent-go/server/agui/internal/track"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+// seedRewindThread stores a thread of two turns whose user messages m1 and m2 are tracked before their events.
+func seedRewindThread(t *testing.T, svc *inmemory.SessionService, key session.Key) {
+	t.Helper()
+	ctx := context.Background()
+	sess, err := svc.CreateSession(ctx, key, nil)
+	require.NoError(t, err)
+	base := time.Now().Add(-time.Hour)
+	track := func(at time.Time, evt aguievents.Event) {
+		payload, err := evt.ToJSON()
+		require.NoError(t, err)
+		require.NoError(t, svc.AppendTrackEvent(ctx, sess, &session.TrackEvent{
+			Track: aguitrack.TrackAGUI, Payload: payload, Timestamp: at,
+		}))
+	}
+	for i, text := range []string{"first", "second"} {
+		turn := base.Add(time.Duration(i) * time.Minute)
+		track(turn, aguievents.NewCustomEvent(multimodal.CustomEventNameUserMessage, aguievents.WithValue(
+			types.Message{ID: []string{"m1", "m2"}[i], Role: types.RoleUser, Content: text})))
+		for j, msg := range []model.Message{model.NewUserMessage(text), model.NewAssistantMessage("answer")} {
+			evt := agentevent.NewResponseEvent("inv", string(msg.Role), &model.Response{
+				Done:    true,
+				Choices: []model.Choice{{Message: msg}},
+			})
+			evt.Timestamp = turn.Add(time.Duration(j+1) * time.Second)
+			require.NoError(t, svc.AppendEvent(ctx, sess, evt))
+		}
+		track(turn.Add(3*time.Second), aguievents.NewTextMessageStartEvent("a"+text,
+			aguievents.WithRole(string(types.RoleAssistant))))
+	}
+}
+
+func TestRewind(t *testing.T) {
+	ctx := context.Background()
+	svc := inmemory.NewSessionService()
+	defer svc.Close()
+	key := session.Key{AppName: "app", UserID: "user", SessionID: "thread"}
+	seedRewindThread(t, svc, key)
+
+	var gotSessionID string
+	var gotMessage model.Message
+	underlying := &fakeRunner{run: func(ctx context.Context, userID, sessionID string, message model.Message,
+		opts ...agent.RunOption) (<-chan *agentevent.Event, error) {
+		gotSessionID, gotMessage = sessionID, message
+		ch := make(chan *agentevent.Event)
+		close(ch)
+		return ch, nil
+	}}
+	r := New(underlying, WithAppName("app"), WithSessionService(svc),
+		WithUserIDResolver(func(context.Context, *adapter.RunAgentInput) (string, error) {
+			return "user", nil
+		})).(*runner)
+
+	events, err := r.Rewind(ctx, &adapter.RunAgentInput{
+		ThreadID: "thread",
+		RunID:    "run",
+		Messages: []types.Message{{ID: "m2", Role: types.RoleUser, Content: "second, edited"}},
+	})
+	require.NoError(t, err)
+	var started *aguievents.RunStartedEvent
+	for evt := range events {
+		if e, ok := evt.(*aguievents.RunStartedEvent); ok {
+			started = e
+		}
+	}
+	require.NotNil(t, started)
+	assert.NotEqual(t, "thread", started.ThreadID())
+	assert.Equal(t, started.ThreadID(), gotSessionID)
+	assert.Equal(t, "second, edited", gotMessage.Content)
+
+	branch, err := svc.GetSession(ctx, session.Key{AppName: "app", UserID: "user", SessionID: gotSessionID})
+	require.NoError(t, err)
+	require.Len(t, branch.Events, 2)
+	trackEvents, err := branch.GetTrackEvents(aguitrack.TrackAGUI)
+	require.NoError(t, err)
+	var ids []string
+	for _, te := range trackEvents.Events {
+		if id, ok := userMessageTrackEventID(te); ok {
+			ids = append(ids, id)
+		}
+	}
+	// The original m2 is left out and the edited m2 is recorded by the branch run.
+	assert.Equal(t, []string{"m1", "m2"}, ids)
+
+	branches, err := r.Branches(ctx, &adapter.RunAgentInput{ThreadID: gotSessionID})
+	require.NoError(t, err)
+	require.Len(t, branches, 2)
+	assert.Equal(t, "thread", branches[0].SessionID)
+	assert.Equal(t, gotSessionID, branches[1].SessionID)
+}
+
+func TestRewindErrors(t *testing.T) {
+	ctx := context.Background()
+	input := &adapter.RunAgentInput{
+		ThreadID: "thread",
+		RunID:    "run",
+		Messages: []types.Message{{ID: "m1", Role: types.RoleUser, Content: "hi"}},
+	}
+	_, err := New(&fakeRunner{}).(*runner).Rewind(ctx, input)
+	assert.EqualError(t, err, "session service is nil")
+	_, err = New(&fakeRunner{}).(*runner).Branches(ctx, input)
+	assert.EqualError(t, err, "session service is nil")
+
+	svc := inmemory.NewSessionService()
+	defer svc.Close()
+	r := New(&fakeRunner{}, WithAppName("app"), WithSessionService(svc)).(*runner)
+	_, err = r.Rewind(ctx, &adapter.RunAgentInput{
+		ThreadID: "thread",
+		Messages: []types.Message{{Role: types.RoleUser, Content: "hi"}},
+	})
+	assert.EqualError(t, err, "last message must be a user message with an id")
+	_, err = r.Rewind(ctx, input)
+	assert.Error(t, err)
+}
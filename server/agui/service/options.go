@@ -18,6 +18,10 @@ const (
 	defaultMessagesSnapshotPath = "/history"
 	// defaultCancelPath is the default path for the cancel handler.
 	defaultCancelPath = "/cancel"
+	// defaultRewindPath is the default path for the rewind handler.
+	defaultRewindPath = "/rewind"
+	// defaultBranchesPath is the default path for the branches handler.
+	defaultBranchesPath = "/branches"
 )
 
 // Options holds the options for an AG-UI transport implementation.
@@ -28,6 +32,9 @@ type Options struct {
 	MessagesSnapshotPath    string        // MessagesSnapshotPath is the HTTP path for the messages snapshot handler.
 	CancelEnabled           bool          // CancelEnabled enables the cancel handler.
 	CancelPath              string        // CancelPath is the HTTP path for the cancel handler.
+	RewindEnabled           bool          // RewindEnabled enables the rewind and branches handlers.
+	RewindPath              string        // RewindPath is the HTTP path for the rewind handler.
+	BranchesPath            string        // BranchesPath is the HTTP path for the branches handler.
 	HeartbeatInterval       time.Duration // HeartbeatInterval controls how often heartbeat frames are sent.
 }
 
@@ -46,6 +53,12 @@ func NewOptions(opt ...Option) *Options {
 	if opts.CancelEnabled && opts.CancelPath == "" {
 		opts.CancelPath = defaultCancelPath
 	}
+	if opts.RewindEnabled && opts.RewindPath == "" {
+		opts.RewindPath = defaultRewindPath
+	}
+	if opts.RewindEnabled && opts.BranchesPath == "" {
+		opts.BranchesPath = defaultBranchesPath
+	}
 	return opts
 }
 
@@ -87,6 +100,27 @@ func WithCancelPath(p string) Option {
 	}
 }
 
+// WithRewindEnabled enables the rewind and branches handlers.
+func WithRewindEnabled(e bool) Option {
+	return func(s *Options) {
+		s.RewindEnabled = e
+	}
+}
+
+// WithRewindPath sets the HTTP path for the rewind handler.
+func WithRewindPath(p string) Option {
+	return func(s *Options) {
+		s.RewindPath = p
+	}
+}
+
+// WithBranchesPath sets the HTTP path for the branches handler.
+func WithBranchesPath(p string) Option {
+	return func(s *Options) {
+		s.BranchesPath = p
+	}
+}
+
 // WithHeartbeatInterval sets how often the transport sends heartbeat frames.
 func WithHeartbeatInterval(d time.Duration) Option {
 	return func(s *Options) {
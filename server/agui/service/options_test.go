@@ -54,6 +54,17 @@ func TestWithCancelEnabled(t *testing.T) {
 	assert.Equal(t, "/cancel", opts.CancelPath)
 }
 
+func TestWithRewindEnabled(t *testing.T) {
+	opts := NewOptions(WithRewindEnabled(true))
+	assert.True(t, opts.RewindEnabled)
+	assert.Equal(t, "/rewind", opts.RewindPath)
+	assert.Equal(t, "/branches", opts.BranchesPath)
+
+	opts = NewOptions(WithRewindEnabled(true), WithRewindPath("/edit"), WithBranchesPath("/threads"))
+	assert.Equal(t, "/edit", opts.RewindPath)
+	assert.Equal(t, "/threads", opts.BranchesPath)
+}
+
 func TestWithHeartbeatInterval(t *testing.T) {
 	opts := NewOptions()
 	assert.Zero(t, opts.HeartbeatInterval)
@@ -25,6 +25,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/server/agui/internal/eventstream"
 	aguirunner "trpc.group/trpc-go/trpc-agent-go/server/agui/runner"
 	"trpc.group/trpc-go/trpc-agent-go/server/agui/service"
+	"trpc.group/trpc-go/trpc-agent-go/session/fork"
 )
 
 // sse is a SSE service implementation.
@@ -32,11 +33,14 @@ type sse struct {
 	path                    string
 	messagesSnapshotPath    string
 	cancelPath              string
+	rewindPath              string
+	branchesPath            string
 	writer                  *aguisse.SSEWriter
 	runner                  aguirunner.Runner
 	handler                 http.Handler
 	messagesSnapshotEnabled bool
 	cancelEnabled           bool
+	rewindEnabled           bool
 	heartbeatInterval       time.Duration
 }
 
@@ -47,10 +51,13 @@ func New(runner aguirunner.Runner, opt ...service.Option) service.Service {
 		path:                    opts.Path,
 		messagesSnapshotPath:    opts.MessagesSnapshotPath,
 		cancelPath:              opts.CancelPath,
+		rewindPath:              opts.RewindPath,
+		branchesPath:            opts.BranchesPath,
 		runner:                  runner,
 		writer:                  aguisse.NewSSEWriter(),
 		messagesSnapshotEnabled: opts.MessagesSnapshotEnabled,
 		cancelEnabled:           opts.CancelEnabled,
+		rewindEnabled:           opts.RewindEnabled,
 		heartbeatInterval:       opts.HeartbeatInterval,
 	}
 	h := http.NewServeMux()
@@ -61,6 +68,10 @@ func New(runner aguirunner.Runner, opt ...service.Option) service.Service {
 	if s.cancelEnabled {
 		h.HandleFunc(s.cancelPath, s.handleCancel)
 	}
+	if s.rewindEnabled {
+		h.HandleFunc(s.rewindPath, s.handleRewind)
+		h.HandleFunc(s.branchesPath, s.handleBranches)
+	}
 	s.handler = h
 	return s
 }
@@ -117,22 +128,30 @@ func (s *sse) handle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	s.streamRun(ctx, w, "agui handle", runAgentInput, s.runner.Run)
+}
+
+// streamRun starts a run and streams its events to the client.
+func (s *sse) streamRun(
+	ctx context.Context,
+	w http.ResponseWriter,
+	logPrefix string,
+	runAgentInput *adapter.RunAgentInput,
+	run func(context.Context, *adapter.RunAgentInput) (<-chan aguievents.Event, error),
+) {
 	runCtx, finishConsuming := eventstream.WithConsumer(ctx)
-	eventsCh, err := s.runner.Run(runCtx, runAgentInput)
+	eventsCh, err := run(runCtx, runAgentInput)
 	if err != nil {
 		finishConsuming()
 		log.ErrorfContext(
 			ctx,
-			"agui handle: threadID: %s, runID: %s, run agent: %v",
+			"%s: threadID: %s, runID: %s, run agent: %v",
+			logPrefix,
 			runAgentInput.ThreadID,
 			runAgentInput.RunID,
 			err,
 		)
-		status := http.StatusInternalServerError
-		if errors.Is(err, aguirunner.ErrRunAlreadyExists) {
-			status = http.StatusConflict
-		}
-		http.Error(w, err.Error(), status)
+		http.Error(w, err.Error(), runErrorStatus(err))
 		return
 	}
 	if eventsCh == nil {
@@ -140,7 +159,8 @@ func (s *sse) handle(w http.ResponseWriter, r *http.Request) {
 		const message = "runner returned nil event channel"
 		log.ErrorfContext(
 			ctx,
-			"agui handle: threadID: %s, runID: %s, run agent: %s",
+			"%s: threadID: %s, runID: %s, run agent: %s",
+			logPrefix,
 			runAgentInput.ThreadID,
 			runAgentInput.RunID,
 			message,
@@ -155,7 +175,8 @@ func (s *sse) handle(w http.ResponseWriter, r *http.Request) {
 	if err := s.handleEvents(ctx, w, eventsCh, true, finishConsuming); err != nil {
 		log.ErrorfContext(
 			ctx,
-			"agui handle: threadID: %s, runID: %s, write event: %v",
+			"%s: threadID: %s, runID: %s, write event: %v",
+			logPrefix,
 			runAgentInput.ThreadID,
 			runAgentInput.RunID,
 			err,
@@ -164,6 +185,18 @@ func (s *sse) handle(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// runErrorStatus maps a run error to its HTTP status.
+func runErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, aguirunner.ErrRunAlreadyExists), errors.Is(err, fork.ErrSessionExists):
+		return http.StatusConflict
+	case errors.Is(err, fork.ErrSessionNotFound), errors.Is(err, fork.ErrEventNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // handleMessagesSnapshot streams a synthetic snapshot run to the client.
 func (s *sse) handleMessagesSnapshot(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -379,6 +412,154 @@ func (s *sse) handleCancel(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// handleRewind reruns a thread from one of its user messages on a new branch thread.
+func (s *sse) handleRewind(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log.DebugfContext(
+		ctx,
+		"agui handle rewind: path: %s, method: %s",
+		s.rewindPath,
+		r.Method,
+	)
+	if r.Method == http.MethodOptions {
+		log.DebugContext(ctx, "agui handle rewind: options request")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+		if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+			w.Header().Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		log.DebugfContext(
+			ctx,
+			"agui handle rewind: method not allowed, method: %s",
+			r.Method,
+		)
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rewinder, ok := s.runner.(aguirunner.Rewinder)
+	if !ok {
+		log.ErrorfContext(
+			ctx,
+			"agui handle rewind: runner does not support rewind",
+		)
+		http.Error(w, "runner does not support rewind", http.StatusNotImplemented)
+		return
+	}
+	runAgentInput, err := runAgentInputFromReader(r.Body)
+	if err != nil {
+		log.WarnfContext(
+			ctx,
+			"agui handle rewind: parse run agent input: %v",
+			err,
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.streamRun(ctx, w, "agui handle rewind", runAgentInput, rewinder.Rewind)
+}
+
+// branchesResponse lists the branch threads of a thread.
+type branchesResponse struct {
+	ThreadID string   `json:"threadId"`
+	Branches []branch `json:"branches"`
+}
+
+// branch describes one branch thread. The fork fields are empty for the root thread.
+type branch struct {
+	ThreadID       string    `json:"threadId"`
+	ParentThreadID string    `json:"parentThreadId,omitempty"`
+	RootThreadID   string    `json:"rootThreadId,omitempty"`
+	EventID        string    `json:"eventId,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// handleBranches lists the branch threads of the thread identified by the request payload.
+func (s *sse) handleBranches(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log.DebugfContext(
+		ctx,
+		"agui handle branches: path: %s, method: %s",
+		s.branchesPath,
+		r.Method,
+	)
+	if r.Method == http.MethodOptions {
+		log.DebugContext(ctx, "agui handle branches: options request")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+		if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+			w.Header().Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		log.DebugfContext(
+			ctx,
+			"agui handle branches: method not allowed, method: %s",
+			r.Method,
+		)
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lister, ok := s.runner.(aguirunner.BranchLister)
+	if !ok {
+		log.ErrorfContext(
+			ctx,
+			"agui handle branches: runner does not support branches",
+		)
+		http.Error(w, "runner does not support branches", http.StatusNotImplemented)
+		return
+	}
+	runAgentInput, err := runAgentInputFromReader(r.Body)
+	if err != nil {
+		log.WarnfContext(
+			ctx,
+			"agui handle branches: parse run agent input: %v",
+			err,
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	branches, err := lister.Branches(ctx, runAgentInput)
+	if err != nil {
+		log.ErrorfContext(
+			ctx,
+			"agui handle branches: threadID: %s, list branches: %v",
+			runAgentInput.ThreadID,
+			err,
+		)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := branchesResponse{ThreadID: runAgentInput.ThreadID, Branches: make([]branch, 0, len(branches))}
+	for _, b := range branches {
+		item := branch{ThreadID: b.SessionID, CreatedAt: b.CreatedAt, UpdatedAt: b.UpdatedAt}
+		if b.Info != nil {
+			item.ParentThreadID = b.Info.ParentSessionID
+			item.RootThreadID = b.Info.RootSessionID
+			item.EventID = b.Info.EventID
+		}
+		resp.Branches = append(resp.Branches, item)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.ErrorfContext(
+			ctx,
+			"agui handle branches: threadID: %s, write response: %v",
+			runAgentInput.ThreadID,
+			err,
+		)
+	}
+}
+
 // runAgentInputFromReader parses an AG-UI run request payload from a reader.
 func runAgentInputFromReader(r io.Reader) (*adapter.RunAgentInput, error) {
 	var input adapter.RunAgentInput
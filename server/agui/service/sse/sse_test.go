@@ -24,6 +24,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/server/agui/adapter"
 	aguirunner "trpc.group/trpc-go/trpc-agent-go/server/agui/runner"
 	"trpc.group/trpc-go/trpc-agent-go/server/agui/service"
+	"trpc.group/trpc-go/trpc-agent-go/session/fork"
 )
 
 func TestHandleRunnerNotConfigured(t *testing.T) {
@@ -772,6 +773,118 @@ func TestHandleCancelSuccess(t *testing.T) {
 	assert.Equal(t, 1, runner.cancelCalls)
 }
 
+func TestHandleRewindNotSupported(t *testing.T) {
+	srv := &sse{rewindPath: "/rewind", runner: &stubRunner{}, writer: aguisse.NewSSEWriter()}
+	payload := `{"threadId":"thread","runId":"run","messages":[{"id":"m1","role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/rewind", strings.NewReader(payload))
+	rr := httptest.NewRecorder()
+
+	srv.handleRewind(rr, req)
+
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
+
+func TestHandleRewindErrors(t *testing.T) {
+	runner := &rewindRunner{}
+	srv := &sse{rewindPath: "/rewind", runner: runner, writer: aguisse.NewSSEWriter()}
+	rr := httptest.NewRecorder()
+	srv.handleRewind(rr, httptest.NewRequest(http.MethodGet, "/rewind", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.handleRewind(rr, httptest.NewRequest(http.MethodPost, "/rewind", strings.NewReader("{invalid")))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	runner.rewindFn = func(context.Context, *adapter.RunAgentInput) (<-chan aguievents.Event, error) {
+		return nil, fork.ErrEventNotFound
+	}
+	payload := `{"threadId":"thread","runId":"run","messages":[{"id":"m1","role":"user","content":"hi"}]}`
+	rr = httptest.NewRecorder()
+	srv.handleRewind(rr, httptest.NewRequest(http.MethodPost, "/rewind", strings.NewReader(payload)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandleRewindSuccess(t *testing.T) {
+	eventsCh := make(chan aguievents.Event)
+	go func() {
+		defer close(eventsCh)
+		eventsCh <- aguievents.NewRunStartedEvent("branch", "run")
+	}()
+	runner := &rewindRunner{
+		rewindFn: func(ctx context.Context, input *adapter.RunAgentInput) (<-chan aguievents.Event, error) {
+			assert.Equal(t, "thread", input.ThreadID)
+			return eventsCh, nil
+		},
+	}
+	srv := &sse{rewindPath: "/rewind", runner: runner, writer: aguisse.NewSSEWriter()}
+	payload := `{"threadId":"thread","runId":"run","messages":[{"id":"m1","role":"user","content":"edited"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/rewind", strings.NewReader(payload))
+	rr := httptest.NewRecorder()
+
+	srv.handleRewind(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `"threadId":"branch"`)
+	assert.Equal(t, 1, runner.rewindCalls)
+	assert.Equal(t, 0, runner.calls)
+}
+
+func TestHandleBranches(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	runner := &rewindRunner{
+		branchesFn: func(ctx context.Context, input *adapter.RunAgentInput) ([]fork.Branch, error) {
+			assert.Equal(t, "branch", input.ThreadID)
+			return []fork.Branch{
+				{SessionID: "thread", CreatedAt: created, UpdatedAt: created},
+				{
+					SessionID: "branch",
+					Info:      &fork.Info{ParentSessionID: "thread", RootSessionID: "thread", EventID: "e1"},
+					CreatedAt: created,
+					UpdatedAt: created,
+				},
+			}, nil
+		},
+	}
+	srv := &sse{branchesPath: "/branches", runner: runner, writer: aguisse.NewSSEWriter()}
+	req := httptest.NewRequest(http.MethodPost, "/branches", strings.NewReader(`{"threadId":"branch"}`))
+	rr := httptest.NewRecorder()
+
+	srv.handleBranches(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"threadId":"branch","branches":[`+
+		`{"threadId":"thread","createdAt":"2025-01-01T00:00:00Z","updatedAt":"2025-01-01T00:00:00Z"},`+
+		`{"threadId":"branch","parentThreadId":"thread","rootThreadId":"thread","eventId":"e1",`+
+		`"createdAt":"2025-01-01T00:00:00Z","updatedAt":"2025-01-01T00:00:00Z"}]}`, rr.Body.String())
+
+	runner.branchesFn = func(context.Context, *adapter.RunAgentInput) ([]fork.Branch, error) {
+		return nil, errors.New("list failed")
+	}
+	rr = httptest.NewRecorder()
+	srv.handleBranches(rr, httptest.NewRequest(http.MethodPost, "/branches", strings.NewReader(`{"threadId":"t"}`)))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	srv.runner = &stubRunner{}
+	rr = httptest.NewRecorder()
+	srv.handleBranches(rr, httptest.NewRequest(http.MethodPost, "/branches", strings.NewReader(`{"threadId":"t"}`)))
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
+
+func TestNewRegistersRewindHandlers(t *testing.T) {
+	runner := &rewindRunner{}
+	srv := New(runner, service.WithPath("/agui"), service.WithRewindEnabled(true))
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/branches", strings.NewReader(`{"threadId":"t"}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	srv = New(runner, service.WithPath("/agui"))
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/branches", strings.NewReader(`{"threadId":"t"}`)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
 type stubRunner struct {
 	runFn     func(ctx context.Context, input *adapter.RunAgentInput) (<-chan aguievents.Event, error)
 	calls     int
@@ -818,6 +931,28 @@ func (s *cancelRunner) Cancel(ctx context.Context, input *adapter.RunAgentInput)
 	return nil
 }
 
+type rewindRunner struct {
+	stubRunner
+	rewindFn    func(context.Context, *adapter.RunAgentInput) (<-chan aguievents.Event, error)
+	branchesFn  func(context.Context, *adapter.RunAgentInput) ([]fork.Branch, error)
+	rewindCalls int
+}
+
+func (s *rewindRunner) Rewind(ctx context.Context, input *adapter.RunAgentInput) (<-chan aguievents.Event, error) {
+	s.rewindCalls++
+	if s.rewindFn != nil {
+		return s.rewindFn(ctx, input)
+	}
+	return nil, nil
+}
+
+func (s *rewindRunner) Branches(ctx context.Context, input *adapter.RunAgentInput) ([]fork.Branch, error) {
+	if s.branchesFn != nil {
+		return s.branchesFn(ctx, input)
+	}
+	return nil, nil
+}
+
 type errorResponseWriter struct {
 	*httptest.ResponseRecorder
 	failCount int
@@ -0,0 +1,138 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mcp
+
+import (
+	mcp "trpc.group/trpc-go/trpc-mcp-go"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Option configures the MCP server.
+type Option func(*options)
+
+// options holds the configuration for the MCP server.
+type options struct {
+	name           string // name is the MCP server name reported on initialize.
+	version        string // version is the MCP server version reported on initialize.
+	path           string // path is the streamable HTTP endpoint path.
+	agent          agent.Agent
+	runner         runner.Runner
+	sessionService session.Service
+	appName        string
+	userID         string
+	agentToolName  string
+	agentToolDesc  string
+	tools          []tool.Tool
+	toolSets       []tool.ToolSet
+	serverOptions  []mcp.ServerOption
+}
+
+// WithName sets the server name reported to MCP clients.
+// Default is "trpc-agent-go".
+func WithName(name string) Option {
+	return func(opts *options) {
+		opts.name = name
+	}
+}
+
+// WithVersion sets the server version reported to MCP clients.
+// Default is "1.0.0".
+func WithVersion(version string) Option {
+	return func(opts *options) {
+		opts.version = version
+	}
+}
+
+// WithPath sets the streamable HTTP endpoint path.
+// Default is "/mcp".
+func WithPath(path string) Option {
+	return func(opts *options) {
+		opts.path = path
+	}
+}
+
+// WithAgent exposes the agent as an MCP tool. A runner is created for it
+// using the session service configured by WithSessionService.
+func WithAgent(ag agent.Agent) Option {
+	return func(opts *options) {
+		opts.agent = ag
+	}
+}
+
+// WithRunner exposes the runner as an MCP tool.
+// It takes precedence over WithAgent for running, while the agent (if any)
+// is still used for the tool name and description.
+func WithRunner(r runner.Runner) Option {
+	return func(opts *options) {
+		opts.runner = r
+	}
+}
+
+// WithSessionService sets the session service used by the runner created
+// for WithAgent. If not provided, an in-memory session service will be used.
+func WithSessionService(svc session.Service) Option {
+	return func(opts *options) {
+		opts.sessionService = svc
+	}
+}
+
+// WithAppName sets the app name for the runner created for WithAgent.
+// Default is "mcp-server".
+func WithAppName(name string) Option {
+	return func(opts *options) {
+		opts.appName = name
+	}
+}
+
+// WithUserID sets the user ID used for agent runs.
+// Default is "default".
+func WithUserID(userID string) Option {
+	return func(opts *options) {
+		opts.userID = userID
+	}
+}
+
+// WithAgentTool sets the name and description of the MCP tool that runs the
+// agent. By default the agent name and description are used, or
+// "run_agent" when only a runner is provided.
+func WithAgentTool(name, description string) Option {
+	return func(opts *options) {
+		opts.agentToolName = name
+		opts.agentToolDesc = description
+	}
+}
+
+// WithTools exposes the given tools. Tools must implement
+// tool.CallableTool or tool.StreamableTool.
+func WithTools(tools ...tool.Tool) Option {
+	return func(opts *options) {
+		opts.tools = append(opts.tools, tools...)
+	}
+}
+
+// WithToolSets exposes the tools of the given tool sets. Tool names are
+// prefixed with the tool set name, the same way agents see them.
+func WithToolSets(toolSets ...tool.ToolSet) Option {
+	return func(opts *options) {
+		opts.toolSets = append(opts.toolSets, toolSets...)
+	}
+}
+
+// WithServerOptions passes extra options to the underlying streamable HTTP
+// MCP server, for example mcp.WithHTTPContextFunc or mcp.WithStatelessMode.
+func WithServerOptions(serverOptions ...mcp.ServerOption) Option {
+	return func(opts *options) {
+		opts.serverOptions = append(opts.serverOptions, serverOptions...)
+	}
+}
@@ -0,0 +1,230 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package mcp publishes agents and tools as a Model Context Protocol (MCP)
+// server over stdio or streamable HTTP.
+//
+// Each tool.Tool becomes an MCP tool with the same name, description and
+// input schema. A runner (or agent) becomes one MCP tool taking a "message"
+// argument; every MCP client session is mapped onto its own session.Service
+// session so conversations keep their history across calls. Streaming
+// results are forwarded as MCP progress notifications when the transport
+// supports them.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	mcp "trpc.group/trpc-go/trpc-mcp-go"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	itool "trpc.group/trpc-go/trpc-agent-go/internal/tool"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+const (
+	defaultName          = "trpc-agent-go"
+	defaultVersion       = "1.0.0"
+	defaultPath          = "/mcp"
+	defaultAppName       = "mcp-server"
+	defaultUserID        = "default"
+	defaultAgentToolName = "run_agent"
+)
+
+// registeredTool is a tool exposed by the server together with its MCP
+// declaration and handler.
+type registeredTool struct {
+	tool    *mcp.Tool
+	handler func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error)
+}
+
+// Server exposes agents and tools over MCP.
+type Server struct {
+	opts        *options
+	runner      runner.Runner
+	ownedRunner bool // Indicates if runner was created by this server.
+	tools       []registeredTool
+	httpServer  *mcp.Server
+	// stdioSessionID is the session used when the transport has no client
+	// session, e.g. a stdio process serving a single client.
+	stdioSessionID string
+	closeOnce      sync.Once
+}
+
+// New creates a new MCP server. At least one of WithAgent, WithRunner,
+// WithTools or WithToolSets must be provided.
+func New(opts ...Option) (*Server, error) {
+	options := &options{
+		name:    defaultName,
+		version: defaultVersion,
+		path:    defaultPath,
+		appName: defaultAppName,
+		userID:  defaultUserID,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.agent == nil && options.runner == nil &&
+		len(options.tools) == 0 && len(options.toolSets) == 0 {
+		return nil, errors.New("mcp: agent, runner, tools or tool sets must be provided")
+	}
+
+	s := &Server{
+		opts:           options,
+		stdioSessionID: uuid.New().String(),
+	}
+	if options.runner != nil {
+		s.runner = options.runner
+	} else if options.agent != nil {
+		sessionService := options.sessionService
+		if sessionService == nil {
+			sessionService = inmemory.NewSessionService()
+		}
+		s.runner = runner.NewRunner(options.appName, options.agent,
+			runner.WithSessionService(sessionService))
+		s.ownedRunner = true
+	}
+
+	tools, err := s.buildTools(context.Background())
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	s.tools = tools
+
+	serverOpts := append([]mcp.ServerOption{mcp.WithServerPath(options.path)}, options.serverOptions...)
+	s.httpServer = mcp.NewServer(options.name, options.version, serverOpts...)
+	for _, t := range s.tools {
+		s.httpServer.RegisterTool(t.tool, t.handler)
+	}
+	return s, nil
+}
+
+// buildTools converts the configured runner and tools into MCP tools.
+func (s *Server) buildTools(ctx context.Context) ([]registeredTool, error) {
+	var out []registeredTool
+	seen := make(map[string]bool)
+	add := func(t registeredTool) error {
+		if seen[t.tool.Name] {
+			return fmt.Errorf("mcp: duplicate tool name %q", t.tool.Name)
+		}
+		seen[t.tool.Name] = true
+		out = append(out, t)
+		return nil
+	}
+
+	if s.runner != nil {
+		if err := add(s.agentTool()); err != nil {
+			return nil, err
+		}
+	}
+	tools := append([]tool.Tool(nil), s.opts.tools...)
+	for _, ts := range s.opts.toolSets {
+		tools = append(tools, itool.NewNamedToolSet(ts).Tools(ctx)...)
+	}
+	for _, t := range tools {
+		rt, err := newRegisteredTool(t)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(rt); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// agentTool builds the MCP tool that runs the agent.
+func (s *Server) agentTool() registeredTool {
+	name, desc := s.opts.agentToolName, s.opts.agentToolDesc
+	var info agent.Info
+	if s.opts.agent != nil {
+		info = s.opts.agent.Info()
+	}
+	if name == "" {
+		name = info.Name
+	}
+	if name == "" {
+		name = defaultAgentToolName
+	}
+	if desc == "" {
+		desc = info.Description
+	}
+	return registeredTool{
+		tool: mcp.NewTool(name,
+			mcp.WithDescription(desc),
+			mcp.WithString(agentMessageArg,
+				mcp.Description("The user message to send to the agent."),
+				mcp.Required()),
+		),
+		handler: s.handleAgentCall,
+	}
+}
+
+// Handler returns the streamable HTTP handler serving MCP requests on Path.
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.HTTPHandler()
+}
+
+// Path returns the streamable HTTP endpoint path.
+func (s *Server) Path() string {
+	return s.opts.path
+}
+
+// Tools returns the MCP declarations of all exposed tools.
+func (s *Server) Tools() []*mcp.Tool {
+	out := make([]*mcp.Tool, 0, len(s.tools))
+	for _, t := range s.tools {
+		out = append(out, t.tool)
+	}
+	return out
+}
+
+// ServeStdio serves MCP over the process stdin and stdout until ctx is done
+// or stdin is closed.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	stdio := mcp.NewStdioServer(s.opts.name, s.opts.version)
+	for _, t := range s.tools {
+		stdio.RegisterTool(t.tool, t.handler)
+	}
+	return stdio.StartWithContext(ctx)
+}
+
+// Close closes the server and releases owned resources.
+// It's safe to call Close multiple times.
+// Only resources created by this server (not provided by user) will be closed.
+func (s *Server) Close() error {
+	var closeErr error
+	s.closeOnce.Do(func() {
+		if s.ownedRunner && s.runner != nil {
+			if err := s.runner.Close(); err != nil {
+				closeErr = err
+				log.Errorf("mcp: failed to close runner: %v", err)
+			}
+		}
+	})
+	return closeErr
+}
+
+// sessionID returns the session.Service session used for the MCP client
+// session in ctx.
+func (s *Server) sessionID(ctx context.Context) string {
+	if sess := mcp.ClientSessionFromContext(ctx); sess != nil && sess.GetID() != "" {
+		return sess.GetID()
+	}
+	return s.stdioSessionID
+}
@@ -0,0 +1,294 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	mcp "trpc.group/trpc-go/trpc-mcp-go"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+type addInput struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type addOutput struct {
+	Sum int `json:"sum"`
+}
+
+func newAddTool() tool.Tool {
+	return function.NewFunctionTool(
+		func(_ context.Context, in addInput) (addOutput, error) {
+			return addOutput{Sum: in.A + in.B}, nil
+		},
+		function.WithName("add"),
+		function.WithDescription("Add two integers."),
+	)
+}
+
+func newFailTool() tool.Tool {
+	return function.NewFunctionTool(
+		func(_ context.Context, _ struct{}) (string, error) {
+			return "", errors.New("boom")
+		},
+		function.WithName("fail"),
+	)
+}
+
+func newCountTool() tool.Tool {
+	return function.NewStreamableFunctionTool[struct{}, string](
+		func(_ context.Context, _ struct{}) (*tool.StreamReader, error) {
+			s := tool.NewStream(3)
+			go func() {
+				defer s.Writer.Close()
+				for _, c := range []string{"one ", "two ", "three"} {
+					s.Writer.Send(tool.StreamChunk{Content: c}, nil)
+				}
+			}()
+			return s.Reader, nil
+		},
+		function.WithName("count"),
+		function.WithDescription("Stream three words."),
+	)
+}
+
+type staticToolSet struct {
+	name  string
+	tools []tool.Tool
+}
+
+func (s *staticToolSet) Tools(context.Context) []tool.Tool { return s.tools }
+func (s *staticToolSet) Close() error                      { return nil }
+func (s *staticToolSet) Name() string                      { return s.name }
+
+// fakeRunner streams a partial delta then a final answer echoing the input.
+type fakeRunner struct {
+	mu       sync.Mutex
+	sessions []string
+	userIDs  []string
+	err      error
+}
+
+func (r *fakeRunner) Run(
+	_ context.Context,
+	userID string,
+	sessionID string,
+	message model.Message,
+	_ ...agent.RunOption,
+) (<-chan *event.Event, error) {
+	r.mu.Lock()
+	r.sessions = append(r.sessions, sessionID)
+	r.userIDs = append(r.userIDs, userID)
+	r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	ch := make(chan *event.Event, 2)
+	ch <- &event.Event{Response: &model.Response{
+		IsPartial: true,
+		Choices:   []model.Choice{{Delta: model.Message{Role: model.RoleAssistant, Content: "echo"}}},
+	}}
+	ch <- &event.Event{Response: &model.Response{
+		Done: true,
+		Choices: []model.Choice{{Message: model.Message{
+			Role:    model.RoleAssistant,
+			Content: "echo: " + message.Content,
+		}}},
+	}}
+	close(ch)
+	return ch, nil
+}
+
+func (r *fakeRunner) Close() error { return nil }
+
+func newTestClient(t *testing.T, s *Server) *mcp.Client {
+	t.Helper()
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	client, err := mcp.NewClient(ts.URL+s.Path(), mcp.Implementation{Name: "test", Version: "1.0.0"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	_, err = client.Initialize(context.Background(), &mcp.InitializeRequest{})
+	require.NoError(t, err)
+	return client
+}
+
+func callMCPTool(t *testing.T, client *mcp.Client, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	req := &mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := client.CallTool(context.Background(), req)
+	require.NoError(t, err)
+	return res
+}
+
+func resultText(t *testing.T, res *mcp.CallToolResult) string {
+	t.Helper()
+	require.Len(t, res.Content, 1)
+	text, ok := res.Content[0].(mcp.TextContent)
+	require.True(t, ok, "unexpected content %T", res.Content[0])
+	return text.Text
+}
+
+func TestNew_RequiresSomethingToServe(t *testing.T) {
+	_, err := New()
+	require.Error(t, err)
+}
+
+func TestNew_DuplicateToolName(t *testing.T) {
+	_, err := New(WithTools(newAddTool(), newAddTool()))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate tool name "add"`)
+}
+
+func TestServer_ListTools(t *testing.T) {
+	s, err := New(
+		WithRunner(&fakeRunner{}),
+		WithAgentTool("assistant", "Chat with the assistant."),
+		WithTools(newAddTool()),
+		WithToolSets(&staticToolSet{name: "math", tools: []tool.Tool{newCountTool()}}),
+	)
+	require.NoError(t, err)
+	defer s.Close()
+
+	client := newTestClient(t, s)
+	res, err := client.ListTools(context.Background(), &mcp.ListToolsRequest{})
+	require.NoError(t, err)
+
+	byName := make(map[string]mcp.Tool)
+	for _, tl := range res.Tools {
+		byName[tl.Name] = tl
+	}
+	require.Len(t, byName, 3)
+	assert.Equal(t, "Chat with the assistant.", byName["assistant"].Description)
+	assert.Contains(t, byName["assistant"].InputSchema.Properties, agentMessageArg)
+
+	add := byName["add"]
+	assert.Equal(t, "Add two integers.", add.Description)
+	require.NotNil(t, add.InputSchema)
+	assert.Contains(t, add.InputSchema.Properties, "a")
+	assert.Contains(t, add.InputSchema.Properties, "b")
+
+	_, ok := byName["math_count"]
+	assert.True(t, ok, "tool set tools are prefixed with the set name")
+}
+
+func TestServer_CallTool(t *testing.T) {
+	s, err := New(WithTools(newAddTool(), newFailTool()))
+	require.NoError(t, err)
+	defer s.Close()
+	client := newTestClient(t, s)
+
+	res := callMCPTool(t, client, "add", map[string]any{"a": 2, "b": 3})
+	assert.False(t, res.IsError)
+	assert.JSONEq(t, `{"sum":5}`, resultText(t, res))
+
+	res = callMCPTool(t, client, "fail", nil)
+	assert.True(t, res.IsError)
+	assert.Equal(t, "boom", resultText(t, res))
+}
+
+func TestServer_StreamableToolProgress(t *testing.T) {
+	s, err := New(WithTools(newCountTool()))
+	require.NoError(t, err)
+	defer s.Close()
+	client := newTestClient(t, s)
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	client.RegisterNotificationHandler(mcp.NotificationMethodProgress, func(n *mcp.JSONRPCNotification) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if msg, ok := n.Params.AdditionalFields["message"].(string); ok {
+			messages = append(messages, msg)
+		}
+		return nil
+	})
+
+	res := callMCPTool(t, client, "count", nil)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "one two three", resultText(t, res))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"one ", "two ", "three"}, messages)
+}
+
+func TestServer_AgentToolMapsSessions(t *testing.T) {
+	r := &fakeRunner{}
+	s, err := New(WithRunner(r), WithUserID("ide"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	first := newTestClient(t, s)
+	res := callMCPTool(t, first, defaultAgentToolName, map[string]any{agentMessageArg: "hi"})
+	assert.False(t, res.IsError)
+	assert.Equal(t, "echo: hi", resultText(t, res))
+	callMCPTool(t, first, defaultAgentToolName, map[string]any{agentMessageArg: "again"})
+
+	second := newTestClient(t, s)
+	callMCPTool(t, second, defaultAgentToolName, map[string]any{agentMessageArg: "hello"})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	require.Len(t, r.sessions, 3)
+	assert.Equal(t, r.sessions[0], r.sessions[1], "one MCP session maps to one agent session")
+	assert.NotEqual(t, r.sessions[0], r.sessions[2])
+	assert.Equal(t, []string{"ide", "ide", "ide"}, r.userIDs)
+
+	res = callMCPTool(t, first, defaultAgentToolName, map[string]any{})
+	assert.True(t, res.IsError)
+}
+
+func TestServer_AgentToolRunError(t *testing.T) {
+	s, err := New(WithRunner(&fakeRunner{err: errors.New("no model")}))
+	require.NoError(t, err)
+	defer s.Close()
+	client := newTestClient(t, s)
+
+	res := callMCPTool(t, client, defaultAgentToolName, map[string]any{agentMessageArg: "hi"})
+	assert.True(t, res.IsError)
+	assert.Equal(t, "no model", resultText(t, res))
+}
+
+func TestToCallToolResult(t *testing.T) {
+	res, err := toCallToolResult(nil)
+	require.NoError(t, err)
+	assert.Empty(t, res.Content)
+
+	res, err = toCallToolResult([]byte("raw"))
+	require.NoError(t, err)
+	assert.Equal(t, "raw", resultText(t, res))
+
+	res, err = toCallToolResult([]int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, "[1,2]", resultText(t, res))
+	assert.Nil(t, res.StructuredContent)
+
+	res, err = toCallToolResult(map[string]int{"n": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"n": float64(1)}, res.StructuredContent)
+}
@@ -0,0 +1,261 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	mcp "trpc.group/trpc-go/trpc-mcp-go"
+
+	itool "trpc.group/trpc-go/trpc-agent-go/internal/tool"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// agentMessageArg is the argument of the agent tool carrying the user message.
+const agentMessageArg = "message"
+
+// newRegisteredTool maps a tool.Tool onto an MCP tool.
+func newRegisteredTool(t tool.Tool) (registeredTool, error) {
+	decl := t.Declaration()
+	if decl == nil || decl.Name == "" {
+		return registeredTool{}, errors.New("mcp: tool declaration must have a name")
+	}
+	if _, ok := callTarget(t).(tool.CallableTool); !ok && !isStreamable(t) {
+		return registeredTool{}, fmt.Errorf("mcp: tool %q is neither callable nor streamable", decl.Name)
+	}
+	inputSchema, err := convertSchema(decl.InputSchema)
+	if err != nil {
+		return registeredTool{}, fmt.Errorf("mcp: convert input schema of %q: %w", decl.Name, err)
+	}
+	if inputSchema == nil {
+		inputSchema = &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeObject}}
+	}
+	outputSchema, err := convertSchema(decl.OutputSchema)
+	if err != nil {
+		return registeredTool{}, fmt.Errorf("mcp: convert output schema of %q: %w", decl.Name, err)
+	}
+	return registeredTool{
+		tool: &mcp.Tool{
+			Name:         decl.Name,
+			Description:  decl.Description,
+			InputSchema:  inputSchema,
+			OutputSchema: outputSchema,
+		},
+		handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return callTool(ctx, t, req)
+		},
+	}, nil
+}
+
+// convertSchema converts a tool.Schema into the OpenAPI schema used by MCP
+// declarations. Both are JSON Schema documents, so a JSON round trip keeps
+// every supported keyword.
+func convertSchema(s *tool.Schema) (*openapi3.Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	out := &openapi3.Schema{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// callTarget unwraps tool set wrappers so capability checks see the
+// original tool.
+func callTarget(t tool.Tool) tool.Tool {
+	if named, ok := t.(*itool.NamedTool); ok {
+		return named.Original()
+	}
+	return t
+}
+
+func isStreamable(t tool.Tool) bool {
+	_, ok := callTarget(t).(tool.StreamableTool)
+	return ok
+}
+
+// callTool runs a tool for an MCP tools/call request. Tool failures are
+// reported as MCP error results so that clients can show them to the model.
+func callTool(ctx context.Context, t tool.Tool, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.Params.Arguments
+	if args == nil {
+		args = map[string]any{}
+	}
+	jsonArgs, err := json.Marshal(args)
+	if err != nil {
+		return mcp.NewErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+	}
+
+	var result any
+	if isStreamable(t) {
+		result, err = callStreamable(ctx, t.(tool.StreamableTool), jsonArgs)
+	} else {
+		result, err = t.(tool.CallableTool).Call(ctx, jsonArgs)
+	}
+	if err != nil {
+		log.WarnfContext(ctx, "mcp: tool %s failed: %v", req.Params.Name, err)
+		return mcp.NewErrorResult(err.Error()), nil
+	}
+	return toCallToolResult(result)
+}
+
+// callStreamable consumes a streaming tool call, forwarding each chunk as a
+// progress notification, and returns the merged result.
+func callStreamable(ctx context.Context, t tool.StreamableTool, jsonArgs []byte) (any, error) {
+	reader, err := t.StreamableCall(ctx, jsonArgs)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	progress := newProgressReporter(ctx)
+	var contents []any
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch c := chunk.Content.(type) {
+		case tool.FinalResultChunk:
+			return c.Result, nil
+		case *tool.FinalResultChunk:
+			return c.Result, nil
+		}
+		contents = append(contents, chunk.Content)
+		progress.report(contentText(chunk.Content))
+	}
+	return tool.Merge(contents), nil
+}
+
+// toCallToolResult converts a tool result into MCP content. Strings are sent
+// as text; any other value is sent as its JSON encoding and, when it encodes
+// to an object, also as structured content.
+func toCallToolResult(result any) (*mcp.CallToolResult, error) {
+	switch v := result.(type) {
+	case nil:
+		return &mcp.CallToolResult{Content: []mcp.Content{}}, nil
+	case string:
+		return mcp.NewTextResult(v), nil
+	case []byte:
+		return mcp.NewTextResult(string(v)), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewErrorResult(fmt.Sprintf("marshal tool result: %v", err)), nil
+	}
+	out := mcp.NewTextResult(string(data))
+	var structured map[string]any
+	if json.Unmarshal(data, &structured) == nil {
+		out.StructuredContent = structured
+	}
+	return out, nil
+}
+
+// contentText renders a stream chunk as a progress message.
+func contentText(content any) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Sprint(content)
+	}
+	return string(data)
+}
+
+// handleAgentCall runs the agent with the message argument. Each MCP client
+// session maps onto one session.Service session, so history is kept across
+// calls. Partial model output is forwarded as progress notifications.
+func (s *Server) handleAgentCall(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	message, _ := req.Params.Arguments[agentMessageArg].(string)
+	if strings.TrimSpace(message) == "" {
+		return mcp.NewErrorResult(fmt.Sprintf("argument %q is required", agentMessageArg)), nil
+	}
+	eventCh, err := s.runner.Run(ctx, s.opts.userID, s.sessionID(ctx), model.NewUserMessage(message))
+	if err != nil {
+		log.ErrorfContext(ctx, "mcp: failed to run agent: %v", err)
+		return mcp.NewErrorResult(err.Error()), nil
+	}
+
+	progress := newProgressReporter(ctx)
+	var final string
+	var runErr *model.ResponseError
+	for evt := range eventCh {
+		if evt == nil || evt.Response == nil {
+			continue
+		}
+		if evt.Error != nil {
+			runErr = evt.Error
+			continue
+		}
+		if len(evt.Choices) == 0 {
+			continue
+		}
+		choice := evt.Choices[0]
+		if evt.IsPartial {
+			progress.report(choice.Delta.Content)
+			continue
+		}
+		if evt.IsToolCallResponse() || evt.IsToolResultResponse() {
+			continue
+		}
+		if choice.Message.Role == model.RoleAssistant && choice.Message.Content != "" {
+			final = choice.Message.Content
+		}
+	}
+	if runErr != nil && final == "" {
+		return mcp.NewErrorResult(runErr.Message), nil
+	}
+	return mcp.NewTextResult(final), nil
+}
+
+// progressReporter forwards incremental output as MCP progress
+// notifications. It is a no-op on transports without a notification sender.
+type progressReporter struct {
+	ctx   context.Context
+	steps float64
+}
+
+func newProgressReporter(ctx context.Context) *progressReporter {
+	return &progressReporter{ctx: ctx}
+}
+
+func (p *progressReporter) report(message string) {
+	if message == "" {
+		return
+	}
+	sender, ok := mcp.GetNotificationSender(p.ctx)
+	if !ok {
+		return
+	}
+	p.steps++
+	if err := sender.SendProgress(p.steps, message); err != nil {
+		log.DebugfContext(p.ctx, "mcp: send progress notification: %v", err)
+	}
+}
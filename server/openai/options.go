@@ -22,6 +22,7 @@ type Option func(*options)
 type options struct {
 	basePath       string // basePath is the base path for the service.
 	path           string // path is the chat completions endpoint path.
+	branches       string // branches is the branches endpoint path.
 	sessionService session.Service
 	agent          agent.Agent
 	runner         runner.Runner
@@ -45,6 +46,15 @@ func WithPath(path string) Option {
 	}
 }
 
+// WithBranchesPath sets the branches endpoint path, which lists the
+// branches created by rewinding a session.
+// Default is "/branches".
+func WithBranchesPath(path string) Option {
+	return func(opts *options) {
+		opts.branches = path
+	}
+}
+
 // WithSessionService sets the session service.
 // If not provided, an in-memory session service will be used.
 func WithSessionService(svc session.Service) Option {
@@ -0,0 +1,147 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/fork"
+)
+
+const (
+	// headerRewindEventID carries the event to rewind the session to. The
+	// last request message, which must be a user message, replaces the user
+	// message at that point, and the run continues on a new branch session
+	// returned in the X-Session-ID response header.
+	headerRewindEventID = "X-Rewind-Event-ID"
+	// headerBranchSessionID optionally names the branch session of a rewind.
+	headerBranchSessionID = "X-Branch-Session-ID"
+
+	queryUserID    = "user"
+	querySessionID = "session_id"
+
+	objectList = "list"
+)
+
+// branchesResponse lists the branches of a conversation.
+type branchesResponse struct {
+	Object string          `json:"object"`
+	Data   []branchSummary `json:"data"`
+}
+
+// branchSummary describes one branch session. The fork fields are empty for
+// the root session.
+type branchSummary struct {
+	SessionID       string `json:"session_id"`
+	ParentSessionID string `json:"parent_session_id,omitempty"`
+	RootSessionID   string `json:"root_session_id,omitempty"`
+	EventID         string `json:"event_id,omitempty"`
+	CreatedAt       int64  `json:"created_at"`
+	UpdatedAt       int64  `json:"updated_at"`
+}
+
+// startRun runs the agent on sessionID, or on a new branch of it when the
+// request rewinds the session. It returns the session the run uses.
+func (s *Server) startRun(
+	ctx context.Context,
+	r *http.Request,
+	userID string,
+	sessionID string,
+	input *runInputMessages,
+	runOpts []agent.RunOption,
+) (<-chan *event.Event, string, error) {
+	eventID := r.Header.Get(headerRewindEventID)
+	if eventID == "" {
+		eventCh, err := s.runner.Run(ctx, userID, sessionID, input.inputMessage, runOpts...)
+		return eventCh, sessionID, err
+	}
+	if input.inputMessage.Role != model.RoleUser {
+		return nil, "", runner.ErrInvalidRewindMessage
+	}
+	message := input.inputMessage
+	branchID, eventCh, err := runner.Rewind(ctx, s.runner, userID, sessionID, runner.RewindRequest{
+		EventID:         eventID,
+		Message:         &message,
+		BranchSessionID: r.Header.Get(headerBranchSessionID),
+	}, runOpts...)
+	return eventCh, branchID, err
+}
+
+// runErrorStatus maps a run error to its HTTP status and error type.
+func runErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, runner.ErrInvalidRewindMessage),
+		errors.Is(err, fork.ErrNoUserMessage):
+		return http.StatusBadRequest, errorTypeInvalidRequest
+	case errors.Is(err, fork.ErrSessionNotFound),
+		errors.Is(err, fork.ErrEventNotFound):
+		return http.StatusNotFound, errorTypeInvalidRequest
+	case errors.Is(err, fork.ErrSessionExists):
+		return http.StatusConflict, errorTypeInvalidRequest
+	case errors.Is(err, runner.ErrRewindUnsupported):
+		return http.StatusNotImplemented, errorTypeInternal
+	default:
+		return http.StatusInternalServerError, errorTypeInternal
+	}
+}
+
+// handleBranches handles the branches endpoint, which lists the session
+// given by the session_id query parameter, or the X-Session-ID header, with
+// all sessions forked from the same root.
+func (s *Server) handleBranches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set(headerAllow, http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	query := r.URL.Query()
+	sessionID := query.Get(querySessionID)
+	if sessionID == "" {
+		sessionID = r.Header.Get(headerSessionID)
+	}
+	if sessionID == "" {
+		s.writeError(w, errors.New("session_id is required"), errorTypeInvalidRequest, http.StatusBadRequest)
+		return
+	}
+	userID := query.Get(queryUserID)
+	if userID == "" {
+		userID = defaultUserID
+	}
+	branches, err := fork.List(ctx, s.sessionService, session.UserKey{AppName: s.appName, UserID: userID}, sessionID)
+	if err != nil {
+		log.ErrorfContext(ctx, "openai: failed to list branches: %v", err)
+		s.writeError(w, err, errorTypeInternal, http.StatusInternalServerError)
+		return
+	}
+	resp := branchesResponse{Object: objectList, Data: make([]branchSummary, 0, len(branches))}
+	for _, b := range branches {
+		summary := branchSummary{
+			SessionID: b.SessionID,
+			CreatedAt: b.CreatedAt.Unix(),
+			UpdatedAt: b.UpdatedAt.Unix(),
+		}
+		if b.Info != nil {
+			summary.ParentSessionID = b.Info.ParentSessionID
+			summary.RootSessionID = b.Info.RootSessionID
+			summary.EventID = b.Info.EventID
+		}
+		resp.Data = append(resp.Data, summary)
+	}
+	s.writeJSON(w, resp)
+}
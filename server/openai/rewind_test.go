@@ -0,0 +1,133 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+func chatRequest(t *testing.T, content string, headers map[string]string) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(openAIRequest{
+		Model:    "gpt-3.5-turbo",
+		Messages: []openAIMessage{{Role: "user", Content: content}},
+		User:     "u1",
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestServer_Rewind(t *testing.T) {
+	s, err := New(WithAgent(&mockAgent{name: "test-agent", response: "Hello"}))
+	require.NoError(t, err)
+	defer s.Close()
+
+	for _, content := range []string{"first", "second"} {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, chatRequest(t, content, map[string]string{headerSessionID: "s1"}))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "s1", w.Header().Get(headerSessionID))
+	}
+	sess, err := s.sessionService.GetSession(context.Background(),
+		session.Key{AppName: defaultAppName, UserID: "u1", SessionID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, sess.Events, 4)
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, chatRequest(t, "second, edited", map[string]string{
+		headerSessionID:       "s1",
+		headerRewindEventID:   sess.Events[2].ID,
+		headerBranchSessionID: "s1-edit",
+	}))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "s1-edit", w.Header().Get(headerSessionID))
+	branch, err := s.sessionService.GetSession(context.Background(),
+		session.Key{AppName: defaultAppName, UserID: "u1", SessionID: "s1-edit"})
+	require.NoError(t, err)
+	require.Len(t, branch.Events, 4)
+	assert.Equal(t, "second, edited", branch.Events[2].Response.Choices[0].Message.Content)
+
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/branches?user=u1&session_id=s1-edit", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp branchesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, objectList, resp.Object)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "s1", resp.Data[0].SessionID)
+	assert.Empty(t, resp.Data[0].ParentSessionID)
+	assert.Equal(t, branchSummary{
+		SessionID:       "s1-edit",
+		ParentSessionID: "s1",
+		RootSessionID:   "s1",
+		EventID:         sess.Events[2].ID,
+		CreatedAt:       resp.Data[1].CreatedAt,
+		UpdatedAt:       resp.Data[1].UpdatedAt,
+	}, resp.Data[1])
+}
+
+func TestServer_Rewind_Errors(t *testing.T) {
+	s, err := New(WithAgent(&mockAgent{name: "test-agent", response: "Hello"}))
+	require.NoError(t, err)
+	defer s.Close()
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, chatRequest(t, "first", map[string]string{headerSessionID: "s1"}))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, chatRequest(t, "edited", map[string]string{
+		headerSessionID:     "s1",
+		headerRewindEventID: "missing",
+	}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, chatRequest(t, "edited", map[string]string{
+		headerSessionID:     "missing",
+		headerRewindEventID: "e1",
+	}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	body, err := json.Marshal(openAIRequest{Messages: []openAIMessage{{Role: "assistant", Content: "hi"}}})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	req.Header.Set(headerRewindEventID, "e1")
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/branches", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/branches?session_id=s1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	// Runners without rewind support reject rewinds.
+	plain, err := New(WithRunner(&mockRunner{events: make(chan *event.Event)}))
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	plain.Handler().ServeHTTP(w, chatRequest(t, "edited", map[string]string{headerRewindEventID: "e1"}))
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
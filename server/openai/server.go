@@ -32,6 +32,7 @@ import (
 const (
 	defaultBasePath  = "/v1"
 	defaultPath      = "/chat/completions"
+	defaultBranches  = "/branches"
 	defaultModelName = "gpt-3.5-turbo"
 	defaultAppName   = "openai-server"
 
@@ -60,6 +61,8 @@ const (
 type Server struct {
 	basePath       string
 	path           string // path is the chat completions endpoint path.
+	branchesPath   string // branchesPath is the branches endpoint path.
+	appName        string
 	handler        http.Handler
 	sessionService session.Service
 	runner         runner.Runner
@@ -75,6 +78,7 @@ func New(opts ...Option) (*Server, error) {
 	options := &options{
 		basePath:  defaultBasePath,
 		path:      defaultPath,
+		branches:  defaultBranches,
 		modelName: defaultModelName,
 		appName:   defaultAppName,
 	}
@@ -91,6 +95,10 @@ func New(opts ...Option) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("openai: url join chat path: %w", err)
 	}
+	branchesPath, err := joinURLPath(options.basePath, options.branches)
+	if err != nil {
+		return nil, fmt.Errorf("openai: url join branches path: %w", err)
+	}
 	var r runner.Runner
 	var ownedRunner bool
 	if options.runner != nil {
@@ -105,6 +113,8 @@ func New(opts ...Option) (*Server, error) {
 	s := &Server{
 		basePath:       options.basePath,
 		path:           chatPath,
+		branchesPath:   branchesPath,
+		appName:        options.appName,
 		sessionService: options.sessionService,
 		runner:         r,
 		agent:          options.agent,
@@ -155,6 +165,7 @@ func (s *Server) setupHandler() {
 	mux := http.NewServeMux()
 	mux.HandleFunc(s.path, s.handleChatCompletions)
 	mux.HandleFunc(s.path+"/", s.handleChatCompletions)
+	mux.HandleFunc(s.branchesPath, s.handleBranches)
 	s.handler = mux
 }
 
@@ -254,22 +265,24 @@ func (s *Server) handleNonStreaming(w http.ResponseWriter, r *http.Request, req
 		s.writeError(w, err, errorTypeInvalidRequest, http.StatusBadRequest)
 		return
 	}
-	// Run the agent.
-	eventCh, err := s.runner.Run(ctx, userID, sessionID, runInput.inputMessage, runOpts...)
+	// Run the agent, on a new branch when rewinding.
+	eventCh, sessionID, err := s.startRun(ctx, r, userID, sessionID, runInput, runOpts)
 	if err != nil {
 		log.ErrorfContext(
 			ctx,
 			"openai: failed to run agent: %v",
 			err,
 		)
+		statusCode, errorType := runErrorStatus(err)
 		s.writeError(
 			w,
 			err,
-			errorTypeInternal,
-			http.StatusInternalServerError,
+			errorType,
+			statusCode,
 		)
 		return
 	}
+	w.Header().Set(headerSessionID, sessionID)
 	// Collect all events.
 	var events []*event.Event
 	for evt := range eventCh {
@@ -354,22 +367,24 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, req *op
 		s.writeError(w, err, errorTypeInvalidRequest, http.StatusBadRequest)
 		return
 	}
-	// Run the agent.
-	eventCh, err := s.runner.Run(ctx, userID, sessionID, runInput.inputMessage, runOpts...)
+	// Run the agent, on a new branch when rewinding.
+	eventCh, sessionID, err := s.startRun(ctx, r, userID, sessionID, runInput, runOpts)
 	if err != nil {
 		log.ErrorfContext(
 			ctx,
 			"openai: failed to run agent: %v",
 			err,
 		)
+		statusCode, errorType := runErrorStatus(err)
 		s.writeError(
 			w,
 			err,
-			errorTypeInternal,
-			http.StatusInternalServerError,
+			errorType,
+			statusCode,
 		)
 		return
 	}
+	w.Header().Set(headerSessionID, sessionID)
 	// Set up SSE headers.
 	w.Header().Set(headerContentType, contentTypeEventStream)
 	w.Header().Set(headerCacheControl, cacheControlNoCache)
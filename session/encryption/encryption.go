@@ -0,0 +1,647 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package encryption provides a session.Service wrapper that encrypts
+// session content at rest with per-user data keys.
+//
+// Message payloads of events (content, content parts, reasoning, tool call
+// arguments), state values, event state deltas and extensions, summaries and
+// track event payloads are sealed with AES-256-GCM before they reach the
+// wrapped service, and opened on reads. Identifiers, roles, authors,
+// timestamps, tool call IDs and names, filter keys and state keys stay in
+// clear, since backends filter, page and index on them.
+//
+// Data of a user is sealed with the data key of the user and app state with
+// the data key of the app, both from a KeyProvider. Rotating a key makes new
+// writes use the new version; state values and summaries sealed with an
+// older version, or written before encryption was enabled, are sealed again
+// when a session is read. Events are not: session services have no way to
+// rewrite stored events, so an event keeps the key version it was sealed
+// with, and events written before encryption was enabled stay in clear. Old
+// key versions must therefore be kept for as long as events sealed with
+// them are stored, which for sessions that are never deleted is forever,
+// and rotation does not retire a leaked key for existing events.
+// Service.ShredUser destroys the keys of a user, crypto-shredding their
+// data: reading it fails with ErrKeyDestroyed.
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	isummary "trpc.group/trpc-go/trpc-agent-go/session/internal/summary"
+	"trpc.group/trpc-go/trpc-agent-go/session/summary"
+)
+
+const (
+	defaultAsyncSummaryNum   = 3
+	defaultSummaryQueueSize  = 100
+	defaultSummaryJobTimeout = 60 * time.Second
+)
+
+// ErrUnsupported is returned by the optional interfaces of Service when the
+// wrapped service does not implement them.
+var ErrUnsupported = errors.New("encryption: not supported by the wrapped session service")
+
+type options struct {
+	plainStateKeys    map[string]bool
+	reencrypt         bool
+	summarizer        summary.SessionSummarizer
+	asyncSummaryNum   int
+	summaryQueueSize  int
+	summaryJobTimeout time.Duration
+}
+
+// Option configures a Service.
+type Option func(*options)
+
+// WithPlainStateKeys keeps the values of the given state keys in clear, for
+// state that is read from storage by other systems. The track index is
+// always kept in clear.
+func WithPlainStateKeys(keys ...string) Option {
+	return func(o *options) {
+		for _, k := range keys {
+			o.plainStateKeys[k] = true
+		}
+	}
+}
+
+// WithLazyReencryption sets whether GetSession seals again the state values
+// and summaries it read that were sealed with an older key version or not
+// sealed at all. Events are never sealed again. Defaults to true. A write
+// racing with the re-encryption of the same key may be overwritten by the
+// value that was read.
+func WithLazyReencryption(enabled bool) Option {
+	return func(o *options) {
+		o.reencrypt = enabled
+	}
+}
+
+// WithSummarizer sets the summarizer for session summaries. Configure it here
+// and not on the wrapped service: the wrapped service would persist the
+// summaries in clear.
+func WithSummarizer(s summary.SessionSummarizer) Option {
+	return func(o *options) {
+		o.summarizer = s
+	}
+}
+
+// WithAsyncSummaryNum sets the number of workers for async summary processing.
+func WithAsyncSummaryNum(num int) Option {
+	return func(o *options) {
+		if num < 1 {
+			num = defaultAsyncSummaryNum
+		}
+		o.asyncSummaryNum = num
+	}
+}
+
+// WithSummaryQueueSize sets the size of the summary job queue.
+func WithSummaryQueueSize(size int) Option {
+	return func(o *options) {
+		if size < 1 {
+			size = defaultSummaryQueueSize
+		}
+		o.summaryQueueSize = size
+	}
+}
+
+// WithSummaryJobTimeout sets the timeout for processing a single summary job.
+func WithSummaryJobTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.summaryJobTimeout = timeout
+		}
+	}
+}
+
+// Service is a session.Service encrypting the content stored by the service
+// it wraps.
+//
+// Service also implements session.TrackService, session.SummaryWriter and
+// session.UserLister; these fail with ErrUnsupported when the wrapped service
+// does not. Event search and event windows are not exposed, since the wrapped
+// service only sees sealed content.
+type Service struct {
+	session.Service
+	cipher      *cipherer
+	opts        options
+	asyncWorker *isummary.AsyncSummaryWorker
+	once        sync.Once
+}
+
+var (
+	_ session.Service       = (*Service)(nil)
+	_ session.TrackService  = (*Service)(nil)
+	_ session.SummaryWriter = (*Service)(nil)
+	_ session.UserLister    = (*Service)(nil)
+)
+
+// NewService wraps inner so that the content it stores is encrypted with the
+// keys of keys.
+func NewService(inner session.Service, keys KeyProvider, opts ...Option) (*Service, error) {
+	if inner == nil || keys == nil {
+		return nil, errors.New("encryption: session service and key provider are required")
+	}
+	o := options{
+		plainStateKeys:    map[string]bool{tracksStateKey: true},
+		reencrypt:         true,
+		asyncSummaryNum:   defaultAsyncSummaryNum,
+		summaryQueueSize:  defaultSummaryQueueSize,
+		summaryJobTimeout: defaultSummaryJobTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &Service{Service: inner, cipher: &cipherer{keys: keys}, opts: o}
+	if isummary.HasSummarizer(o.summarizer) {
+		s.asyncWorker = isummary.NewAsyncSummaryWorker(isummary.AsyncSummaryConfig{
+			Summarizer:            o.summarizer,
+			AsyncSummaryNum:       o.asyncSummaryNum,
+			SummaryQueueSize:      o.summaryQueueSize,
+			SummaryJobTimeout:     o.summaryJobTimeout,
+			SummaryDispatchPolicy: isummary.NewSummaryDispatchPolicy(nil, true),
+			CreateSummaryFunc:     s.CreateSessionSummary,
+		})
+		s.asyncWorker.Start()
+	}
+	return s, nil
+}
+
+// CreateSession implements session.Service.
+func (s *Service) CreateSession(
+	ctx context.Context,
+	key session.Key,
+	state session.StateMap,
+	options ...session.Option,
+) (*session.Session, error) {
+	userKey := session.UserKey{AppName: key.AppName, UserID: key.UserID}
+	sealed, err := s.cipher.sealState(ctx, state, s.userScope(userKey), s.opts.plainStateKeys)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.Service.CreateSession(ctx, key, sealed, options...)
+	if err != nil || sess == nil {
+		return sess, err
+	}
+	if _, err := s.openSession(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// GetSession implements session.Service.
+func (s *Service) GetSession(
+	ctx context.Context,
+	key session.Key,
+	options ...session.Option,
+) (*session.Session, error) {
+	sess, err := s.Service.GetSession(ctx, key, options...)
+	if err != nil || sess == nil {
+		return sess, err
+	}
+	stale, err := s.openSession(ctx, sess)
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.reencrypt {
+		s.reseal(ctx, key, sess, stale)
+	}
+	return sess, nil
+}
+
+// ListSessions implements session.Service.
+func (s *Service) ListSessions(
+	ctx context.Context,
+	userKey session.UserKey,
+	options ...session.Option,
+) ([]*session.Session, error) {
+	sessions, err := s.Service.ListSessions(ctx, userKey, options...)
+	if err != nil {
+		return nil, err
+	}
+	for _, sess := range sessions {
+		if sess == nil {
+			continue
+		}
+		if _, err := s.openSession(ctx, sess); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// UpdateAppState implements session.Service.
+func (s *Service) UpdateAppState(ctx context.Context, appName string, state session.StateMap) error {
+	sealed, err := s.cipher.sealState(ctx, state, appScope(appName), nil)
+	if err != nil {
+		return err
+	}
+	return s.Service.UpdateAppState(ctx, appName, sealed)
+}
+
+// ListAppStates implements session.Service.
+func (s *Service) ListAppStates(ctx context.Context, appName string) (session.StateMap, error) {
+	state, err := s.Service.ListAppStates(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	opened, _, err := s.cipher.openState(ctx, state, appScope(appName), nil)
+	return opened, err
+}
+
+// UpdateUserState implements session.Service.
+func (s *Service) UpdateUserState(ctx context.Context, userKey session.UserKey, state session.StateMap) error {
+	sealed, err := s.cipher.sealState(ctx, state, fixedScope(userKey), nil)
+	if err != nil {
+		return err
+	}
+	return s.Service.UpdateUserState(ctx, userKey, sealed)
+}
+
+// ListUserStates implements session.Service.
+func (s *Service) ListUserStates(ctx context.Context, userKey session.UserKey) (session.StateMap, error) {
+	state, err := s.Service.ListUserStates(ctx, userKey)
+	if err != nil {
+		return nil, err
+	}
+	opened, _, err := s.cipher.openState(ctx, state, fixedScope(userKey), nil)
+	return opened, err
+}
+
+// UpdateSessionState implements session.Service.
+func (s *Service) UpdateSessionState(ctx context.Context, key session.Key, state session.StateMap) error {
+	userKey := session.UserKey{AppName: key.AppName, UserID: key.UserID}
+	sealed, err := s.cipher.sealState(ctx, state, fixedScope(userKey), s.opts.plainStateKeys)
+	if err != nil {
+		return err
+	}
+	return s.Service.UpdateSessionState(ctx, key, sealed)
+}
+
+// AppendEvent implements session.Service. The wrapped service stores a
+// sealed copy of the event, while sess receives the event in clear.
+func (s *Service) AppendEvent(
+	ctx context.Context,
+	sess *session.Session,
+	evt *event.Event,
+	options ...session.Option,
+) error {
+	if sess == nil {
+		return session.ErrNilSession
+	}
+	if evt == nil {
+		return s.Service.AppendEvent(ctx, sess, evt, options...)
+	}
+	userKey := session.UserKey{AppName: sess.AppName, UserID: sess.UserID}
+	sealed, err := s.cipher.sealEvent(ctx, userKey, evt, s.opts.plainStateKeys)
+	if err != nil {
+		return err
+	}
+	// The wrapped service applies the event to the session it is given, which
+	// must not mix sealed and clear content.
+	if err := s.Service.AppendEvent(ctx, sess.Clone(), sealed, options...); err != nil {
+		return err
+	}
+	sess.UpdateUserSession(evt, options...)
+	return nil
+}
+
+// AppendTrackEvent implements session.TrackService.
+func (s *Service) AppendTrackEvent(
+	ctx context.Context,
+	sess *session.Session,
+	trackEvent *session.TrackEvent,
+	opts ...session.Option,
+) error {
+	tracker, ok := s.Service.(session.TrackService)
+	if !ok {
+		return fmt.Errorf("append track event: %w", ErrUnsupported)
+	}
+	if sess == nil {
+		return session.ErrNilSession
+	}
+	if trackEvent == nil {
+		return tracker.AppendTrackEvent(ctx, sess, trackEvent, opts...)
+	}
+	sealed := *trackEvent
+	payload, err := s.cipher.sealRaw(ctx, Scope{AppName: sess.AppName, UserID: sess.UserID}, trackEvent.Payload)
+	if err != nil {
+		return fmt.Errorf("seal track event: %w", err)
+	}
+	sealed.Payload = payload
+	if err := tracker.AppendTrackEvent(ctx, sess.Clone(), &sealed, opts...); err != nil {
+		return err
+	}
+	return sess.AppendTrackEvent(trackEvent, opts...)
+}
+
+// PutSessionSummary implements session.SummaryWriter.
+func (s *Service) PutSessionSummary(
+	ctx context.Context,
+	key session.Key,
+	filterKey string,
+	sum *session.Summary,
+) error {
+	writer, ok := s.Service.(session.SummaryWriter)
+	if !ok {
+		return fmt.Errorf("put session summary: %w", ErrUnsupported)
+	}
+	if sum == nil {
+		return writer.PutSessionSummary(ctx, key, filterKey, sum)
+	}
+	scope := Scope{AppName: key.AppName, UserID: key.UserID}
+	sealed := sum.Clone()
+	var err error
+	if sealed.Summary, err = s.cipher.sealString(ctx, scope, sum.Summary); err != nil {
+		return fmt.Errorf("seal summary: %w", err)
+	}
+	for i, topic := range sum.Topics {
+		if sealed.Topics[i], err = s.cipher.sealString(ctx, scope, topic); err != nil {
+			return fmt.Errorf("seal summary: %w", err)
+		}
+	}
+	return writer.PutSessionSummary(ctx, key, filterKey, sealed)
+}
+
+// ListUserIDs implements session.UserLister.
+func (s *Service) ListUserIDs(ctx context.Context, appName string) ([]string, error) {
+	lister, ok := s.Service.(session.UserLister)
+	if !ok {
+		return nil, fmt.Errorf("list user ids: %w", ErrUnsupported)
+	}
+	return lister.ListUserIDs(ctx, appName)
+}
+
+// ShredUser crypto-shreds the data of a user: it destroys their data keys,
+// so their stored sessions, state and summaries, and any copy of them in
+// backups, can no longer be read. Their user state is deleted as well, since
+// it is merged into every new session of the user. Data written for the user
+// afterwards is sealed with a new key.
+func (s *Service) ShredUser(ctx context.Context, userKey session.UserKey) error {
+	if err := userKey.CheckUserKey(); err != nil {
+		return err
+	}
+	if err := s.cipher.keys.DestroyKeys(ctx, Scope{AppName: userKey.AppName, UserID: userKey.UserID}); err != nil {
+		return err
+	}
+	// State keys are stored in clear, so the wrapped service can list them.
+	state, err := s.Service.ListUserStates(ctx, userKey)
+	if err != nil {
+		return fmt.Errorf("list user state: %w", err)
+	}
+	for k := range state {
+		if err := s.Service.DeleteUserState(ctx, userKey, k); err != nil {
+			return fmt.Errorf("delete user state %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// CreateSessionSummary implements session.Service. It summarizes sess with
+// the summarizer of WithSummarizer and stores the summary sealed.
+func (s *Service) CreateSessionSummary(ctx context.Context, sess *session.Session, filterKey string, force bool) error {
+	if !isummary.HasSummarizer(s.opts.summarizer) {
+		return nil
+	}
+	if sess == nil {
+		return session.ErrNilSession
+	}
+	key := session.Key{AppName: sess.AppName, UserID: sess.UserID, SessionID: sess.ID}
+	if err := key.CheckSessionKey(); err != nil {
+		return fmt.Errorf("check session key failed: %w", err)
+	}
+	updated, err := isummary.SummarizeSession(ctx, s.opts.summarizer, sess, filterKey, force)
+	if err != nil || !updated {
+		return err
+	}
+	sess.SummariesMu.RLock()
+	sum := sess.Summaries[filterKey].Clone()
+	sess.SummariesMu.RUnlock()
+	if sum == nil {
+		return nil
+	}
+	return s.PutSessionSummary(ctx, key, filterKey, sum)
+}
+
+// EnqueueSummaryJob implements session.Service.
+func (s *Service) EnqueueSummaryJob(ctx context.Context, sess *session.Session, filterKey string, force bool) error {
+	if !isummary.HasSummarizer(s.opts.summarizer) {
+		return nil
+	}
+	if sess == nil {
+		return session.ErrNilSession
+	}
+	return s.asyncWorker.EnqueueJob(ctx, sess, filterKey, force)
+}
+
+// GetSessionSummaryText implements session.Service.
+func (s *Service) GetSessionSummaryText(
+	ctx context.Context,
+	sess *session.Session,
+	opts ...session.SummaryOption,
+) (string, bool) {
+	text, ok := s.Service.GetSessionSummaryText(ctx, sess, opts...)
+	if !ok || sess == nil {
+		return text, ok
+	}
+	text, _, err := s.cipher.openString(ctx, Scope{AppName: sess.AppName, UserID: sess.UserID}, text)
+	if err != nil {
+		log.WarnfContext(ctx, "encryption: open summary of session %s: %v", sess.ID, err)
+		return "", false
+	}
+	return text, true
+}
+
+// Close stops the summary workers and closes the wrapped service.
+func (s *Service) Close() error {
+	var err error
+	s.once.Do(func() {
+		if s.asyncWorker != nil {
+			s.asyncWorker.Stop()
+		}
+		err = s.Service.Close()
+	})
+	return err
+}
+
+// staleParts lists the parts of a session sealed with an old key version.
+type staleParts struct {
+	sessionState []string
+	userState    []string
+	appState     []string
+	summaries    []string
+}
+
+// openSession opens the content of sess in place. sess must not share its
+// maps and slices with the wrapped service, which holds for the sessions
+// session services return.
+func (s *Service) openSession(ctx context.Context, sess *session.Session) (staleParts, error) {
+	var stale staleParts
+	userKey := session.UserKey{AppName: sess.AppName, UserID: sess.UserID}
+	scope := Scope{AppName: sess.AppName, UserID: sess.UserID}
+
+	state, staleKeys, err := s.cipher.openState(ctx, sess.SnapshotState(), s.userScope(userKey), s.opts.plainStateKeys)
+	if err != nil {
+		return stale, fmt.Errorf("session %s: %w", sess.ID, err)
+	}
+	for _, k := range staleKeys {
+		switch {
+		case strings.HasPrefix(k, session.StateAppPrefix):
+			stale.appState = append(stale.appState, k)
+		case strings.HasPrefix(k, session.StateUserPrefix):
+			stale.userState = append(stale.userState, k)
+		default:
+			stale.sessionState = append(stale.sessionState, k)
+		}
+	}
+
+	sess.EventMu.Lock()
+	events := make([]event.Event, len(sess.Events))
+	for i := range sess.Events {
+		opened, err := s.cipher.openEvent(ctx, userKey, &sess.Events[i], s.opts.plainStateKeys)
+		if err != nil {
+			sess.EventMu.Unlock()
+			return stale, fmt.Errorf("session %s: %w", sess.ID, err)
+		}
+		events[i] = *opened
+	}
+	sess.Events = events
+	sess.EventMu.Unlock()
+
+	sess.SummariesMu.Lock()
+	summaries := make(map[string]*session.Summary, len(sess.Summaries))
+	for filterKey, sum := range sess.Summaries {
+		opened, version, err := s.openSummary(ctx, scope, sum)
+		if err != nil {
+			sess.SummariesMu.Unlock()
+			return stale, fmt.Errorf("session %s summary %q: %w", sess.ID, filterKey, err)
+		}
+		summaries[filterKey] = opened
+		if opened != nil && s.cipher.stale(ctx, scope, version) {
+			stale.summaries = append(stale.summaries, filterKey)
+		}
+	}
+	if sess.Summaries != nil {
+		sess.Summaries = summaries
+	}
+	sess.SummariesMu.Unlock()
+
+	sess.TracksMu.Lock()
+	tracks := make(map[session.Track]*session.TrackEvents, len(sess.Tracks))
+	for track, trackEvents := range sess.Tracks {
+		if trackEvents == nil {
+			tracks[track] = nil
+			continue
+		}
+		opened := &session.TrackEvents{Track: trackEvents.Track, Events: make([]session.TrackEvent, len(trackEvents.Events))}
+		for i, te := range trackEvents.Events {
+			if te.Payload, err = s.cipher.openRaw(ctx, scope, te.Payload); err != nil {
+				sess.TracksMu.Unlock()
+				return stale, fmt.Errorf("session %s track %s: %w", sess.ID, track, err)
+			}
+			opened.Events[i] = te
+		}
+		tracks[track] = opened
+	}
+	if sess.Tracks != nil {
+		sess.Tracks = tracks
+	}
+	sess.TracksMu.Unlock()
+
+	// Replace the state last, once everything else could be opened.
+	session.WithSessionState(state)(sess)
+	return stale, nil
+}
+
+func (s *Service) openSummary(ctx context.Context, scope Scope, sum *session.Summary) (*session.Summary, uint32, error) {
+	if sum == nil {
+		return nil, 0, nil
+	}
+	opened := sum.Clone()
+	text, version, err := s.cipher.openString(ctx, scope, sum.Summary)
+	if err != nil {
+		return nil, 0, err
+	}
+	opened.Summary = text
+	for i, topic := range sum.Topics {
+		if opened.Topics[i], _, err = s.cipher.openString(ctx, scope, topic); err != nil {
+			return nil, 0, err
+		}
+	}
+	return opened, version, nil
+}
+
+// reseal seals again the stale parts of sess with the current keys. Events
+// are out of scope, since the wrapped service cannot rewrite them. Failures
+// are logged: the session was read successfully and the next read retries.
+func (s *Service) reseal(ctx context.Context, key session.Key, sess *session.Session, stale staleParts) {
+	userKey := session.UserKey{AppName: key.AppName, UserID: key.UserID}
+	pick := func(keys []string, prefix string) session.StateMap {
+		if len(keys) == 0 {
+			return nil
+		}
+		state := make(session.StateMap, len(keys))
+		for _, k := range keys {
+			if v, ok := sess.GetState(k); ok {
+				state[strings.TrimPrefix(k, prefix)] = v
+			}
+		}
+		return state
+	}
+	if state := pick(stale.sessionState, ""); len(state) > 0 {
+		if err := s.UpdateSessionState(ctx, key, state); err != nil {
+			log.WarnfContext(ctx, "encryption: re-encrypt state of session %s: %v", key.SessionID, err)
+		}
+	}
+	if state := pick(stale.userState, session.StateUserPrefix); len(state) > 0 {
+		if err := s.UpdateUserState(ctx, userKey, state); err != nil {
+			log.WarnfContext(ctx, "encryption: re-encrypt state of user %s: %v", key.UserID, err)
+		}
+	}
+	if state := pick(stale.appState, session.StateAppPrefix); len(state) > 0 {
+		if err := s.UpdateAppState(ctx, key.AppName, state); err != nil {
+			log.WarnfContext(ctx, "encryption: re-encrypt state of app %s: %v", key.AppName, err)
+		}
+	}
+	if _, ok := s.Service.(session.SummaryWriter); !ok {
+		return
+	}
+	for _, filterKey := range stale.summaries {
+		sess.SummariesMu.RLock()
+		sum := sess.Summaries[filterKey].Clone()
+		sess.SummariesMu.RUnlock()
+		if err := s.PutSessionSummary(ctx, key, filterKey, sum); err != nil {
+			log.WarnfContext(ctx, "encryption: re-encrypt summary %q of session %s: %v", filterKey, key.SessionID, err)
+		}
+	}
+}
+
+// userScope returns the scope of the keys of a session state, which merges
+// app, user and session state.
+func (s *Service) userScope(userKey session.UserKey) func(string) Scope {
+	return func(k string) Scope { return stateScope(userKey, k) }
+}
+
+func fixedScope(userKey session.UserKey) func(string) Scope {
+	scope := Scope{AppName: userKey.AppName, UserID: userKey.UserID}
+	return func(string) Scope { return scope }
+}
+
+func appScope(appName string) func(string) Scope {
+	scope := Scope{AppName: appName}
+	return func(string) Scope { return scope }
+}
@@ -0,0 +1,396 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+var (
+	testKey     = session.Key{AppName: "app", UserID: "u1", SessionID: "s1"}
+	testUserKey = session.UserKey{AppName: "app", UserID: "u1"}
+	masterKey   = bytes.Repeat([]byte{7}, keySize)
+)
+
+func newTestService(t *testing.T, opts ...Option) (*Service, *inmemory.SessionService, *Keyring) {
+	t.Helper()
+	inner := inmemory.NewSessionService()
+	keys, err := NewFileKeyring(filepath.Join(t.TempDir(), "keys.json"), masterKey)
+	require.NoError(t, err)
+	svc, err := NewService(inner, keys, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { svc.Close() })
+	return svc, inner, keys
+}
+
+func newEvent(msg model.Message) *event.Event {
+	e := event.NewResponseEvent("inv", string(msg.Role), &model.Response{
+		Done:    true,
+		Choices: []model.Choice{{Message: msg}},
+	})
+	e.StateDelta = session.StateMap{"step": []byte("secret step")}
+	return e
+}
+
+func toolCallMessage() model.Message {
+	msg := model.NewAssistantMessage("")
+	msg.ToolCalls = []model.ToolCall{{
+		Type:     "function",
+		ID:       "call-1",
+		Function: model.FunctionDefinitionParam{Name: "lookup", Arguments: []byte(`{"ssn":"123"}`)},
+	}}
+	return msg
+}
+
+// writeSession stores app, user and session state, two events and a summary.
+func writeSession(t *testing.T, svc *Service) *session.Session {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, svc.UpdateAppState(ctx, "app", session.StateMap{"theme": []byte("dark")}))
+	require.NoError(t, svc.UpdateUserState(ctx, testUserKey, session.StateMap{"email": []byte("a@b.c")}))
+	sess, err := svc.CreateSession(ctx, testKey, session.StateMap{"plan": []byte("secret plan")})
+	require.NoError(t, err)
+	require.NoError(t, svc.AppendEvent(ctx, sess, newEvent(model.NewUserMessage("my password is hunter2"))))
+	require.NoError(t, svc.AppendEvent(ctx, sess, newEvent(toolCallMessage())))
+	require.NoError(t, svc.PutSessionSummary(ctx, testKey, "", &session.Summary{
+		Summary: "user shared a password", Topics: []string{"passwords"}, UpdatedAt: time.Now(),
+	}))
+	return sess
+}
+
+func TestService_RoundTrip(t *testing.T) {
+	svc, inner, _ := newTestService(t)
+	ctx := context.Background()
+	runtime := writeSession(t, svc)
+	require.Len(t, runtime.Events, 2)
+	assert.Equal(t, "my password is hunter2", runtime.Events[0].Response.Choices[0].Message.Content)
+
+	stored, err := inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	require.Len(t, stored.Events, 2)
+	for k, v := range stored.SnapshotState() {
+		assert.True(t, isSealed(v), "state %s", k)
+	}
+	user := stored.Events[0].Response.Choices[0].Message
+	assert.True(t, isSealedString(user.Content))
+	assert.Equal(t, model.RoleUser, user.Role)
+	call := stored.Events[1].Response.Choices[0].Message.ToolCalls[0]
+	assert.Equal(t, "call-1", call.ID)
+	assert.Equal(t, "lookup", call.Function.Name)
+	assert.Empty(t, call.Function.Arguments)
+	assert.True(t, isSealed(stored.Events[0].StateDelta["step"]))
+	assert.True(t, isSealedString(stored.Summaries[""].Summary))
+	assert.True(t, isSealedString(stored.Summaries[""].Topics[0]))
+
+	sess, err := svc.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	require.Len(t, sess.Events, 2)
+	assert.Equal(t, "my password is hunter2", sess.Events[0].Response.Choices[0].Message.Content)
+	assert.Equal(t, toolCallMessage(), sess.Events[1].Response.Choices[0].Message)
+	assert.Equal(t, []byte("secret step"), sess.Events[0].StateDelta["step"])
+	plan, _ := sess.GetState("plan")
+	assert.Equal(t, []byte("secret plan"), plan)
+	email, _ := sess.GetState(session.StateUserPrefix + "email")
+	assert.Equal(t, []byte("a@b.c"), email)
+	theme, _ := sess.GetState(session.StateAppPrefix + "theme")
+	assert.Equal(t, []byte("dark"), theme)
+	assert.Equal(t, []string{"passwords"}, sess.Summaries[""].Topics)
+	text, ok := svc.GetSessionSummaryText(ctx, sess)
+	require.True(t, ok)
+	assert.Equal(t, "user shared a password", text)
+
+	sessions, err := svc.ListSessions(ctx, testUserKey)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "my password is hunter2", sessions[0].Events[0].Response.Choices[0].Message.Content)
+	appState, err := svc.ListAppStates(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("dark"), appState["theme"])
+	userState, err := svc.ListUserStates(ctx, testUserKey)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a@b.c"), userState["email"])
+}
+
+func TestService_TrackEvents(t *testing.T) {
+	svc, inner, _ := newTestService(t)
+	ctx := context.Background()
+	sess, err := svc.CreateSession(ctx, testKey, nil)
+	require.NoError(t, err)
+	require.NoError(t, svc.AppendTrackEvent(ctx, sess, &session.TrackEvent{
+		Track: "audit", Payload: json.RawMessage(`{"card":"4111"}`), Timestamp: time.Now(),
+	}))
+	assert.JSONEq(t, `{"card":"4111"}`, string(sess.Tracks["audit"].Events[0].Payload))
+
+	stored, err := inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored.Tracks["audit"].Events[0].Payload), "4111")
+
+	sess, err = svc.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"card":"4111"}`, string(sess.Tracks["audit"].Events[0].Payload))
+}
+
+func TestService_RotationReencryptsLazily(t *testing.T) {
+	svc, inner, keys := newTestService(t)
+	ctx := context.Background()
+	writeSession(t, svc)
+	scope := Scope{AppName: "app", UserID: "u1"}
+	rotated, err := keys.RotateKey(ctx, scope)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, rotated.Version)
+
+	version := func(v []byte) uint32 {
+		_, version, err := svc.cipher.open(ctx, scope, v)
+		require.NoError(t, err)
+		return version
+	}
+	stored, err := inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	plan, _ := stored.GetState("plan")
+	assert.EqualValues(t, 1, version(plan))
+
+	_, err = svc.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	stored, err = inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	plan, _ = stored.GetState("plan")
+	assert.EqualValues(t, 2, version(plan))
+	email, _ := stored.GetState(session.StateUserPrefix + "email")
+	assert.EqualValues(t, 2, version(email))
+	_, summaryVersion, err := svc.cipher.openString(ctx, scope, stored.Summaries[""].Summary)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, summaryVersion)
+
+	// Events keep the key they were sealed with and stay readable.
+	sess, err := svc.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	assert.Equal(t, "my password is hunter2", sess.Events[0].Response.Choices[0].Message.Content)
+}
+
+func TestService_PlaintextDataIsSealedOnRead(t *testing.T) {
+	svc, inner, _ := newTestService(t)
+	ctx := context.Background()
+	sess, err := inner.CreateSession(ctx, testKey, session.StateMap{"plan": []byte("legacy")})
+	require.NoError(t, err)
+	require.NoError(t, inner.AppendEvent(ctx, sess, newEvent(model.NewUserMessage("legacy message"))))
+
+	sess, err = svc.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	plan, _ := sess.GetState("plan")
+	assert.Equal(t, []byte("legacy"), plan)
+	assert.Equal(t, "legacy message", sess.Events[0].Response.Choices[0].Message.Content)
+
+	stored, err := inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	plan, _ = stored.GetState("plan")
+	assert.True(t, isSealed(plan))
+}
+
+func TestService_CryptoShredding(t *testing.T) {
+	svc, _, keys := newTestService(t)
+	ctx := context.Background()
+	writeSession(t, svc)
+	require.NoError(t, svc.ShredUser(ctx, testUserKey))
+
+	_, err := svc.GetSession(ctx, testKey)
+	assert.ErrorIs(t, err, ErrKeyDestroyed)
+	userState, err := svc.ListUserStates(ctx, testUserKey)
+	require.NoError(t, err)
+	assert.Empty(t, userState)
+	appState, err := svc.ListAppStates(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("dark"), appState["theme"])
+
+	// New data of the user gets a new key.
+	sess, err := svc.CreateSession(ctx, session.Key{AppName: "app", UserID: "u1", SessionID: "s2"},
+		session.StateMap{"plan": []byte("fresh")})
+	require.NoError(t, err)
+	plan, _ := sess.GetState("plan")
+	assert.Equal(t, []byte("fresh"), plan)
+	current, err := keys.CurrentKey(ctx, Scope{AppName: "app", UserID: "u1"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, current.Version)
+}
+
+func TestService_PlainStateKeys(t *testing.T) {
+	svc, inner, _ := newTestService(t, WithPlainStateKeys("region"))
+	ctx := context.Background()
+	_, err := svc.CreateSession(ctx, testKey, session.StateMap{"region": []byte("eu"), "plan": []byte("x")})
+	require.NoError(t, err)
+	stored, err := inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	region, _ := stored.GetState("region")
+	assert.Equal(t, []byte("eu"), region)
+	plan, _ := stored.GetState("plan")
+	assert.True(t, isSealed(plan))
+}
+
+func TestService_CreateSessionSummary(t *testing.T) {
+	svc, inner, _ := newTestService(t, WithSummarizer(&fakeSummarizer{out: "a secret summary"}))
+	ctx := context.Background()
+	sess := writeSession(t, svc)
+	require.NoError(t, svc.CreateSessionSummary(ctx, sess, "", true))
+
+	stored, err := inner.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	assert.True(t, isSealedString(stored.Summaries[""].Summary))
+	text, ok := svc.GetSessionSummaryText(ctx, sess)
+	require.True(t, ok)
+	assert.Equal(t, "a secret summary", text)
+}
+
+func TestService_Unsupported(t *testing.T) {
+	keys, err := NewFileKeyring(filepath.Join(t.TempDir(), "keys.json"), masterKey)
+	require.NoError(t, err)
+	svc, err := NewService(plainService{inmemory.NewSessionService()}, keys)
+	require.NoError(t, err)
+	defer svc.Close()
+	ctx := context.Background()
+	assert.ErrorIs(t, svc.PutSessionSummary(ctx, testKey, "", &session.Summary{}), ErrUnsupported)
+	_, err = svc.ListUserIDs(ctx, "app")
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.ErrorIs(t, svc.AppendTrackEvent(ctx, &session.Session{}, &session.TrackEvent{}), ErrUnsupported)
+
+	_, err = NewService(nil, keys)
+	assert.Error(t, err)
+}
+
+func TestFileKeyring_Persists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	scope := Scope{AppName: "app", UserID: "u/1"}
+	keys, err := NewFileKeyring(path, masterKey)
+	require.NoError(t, err)
+	c := &cipherer{keys: keys}
+	sealed, err := c.seal(ctx, scope, []byte("hello"))
+	require.NoError(t, err)
+
+	reopened, err := NewFileKeyring(path, masterKey)
+	require.NoError(t, err)
+	c = &cipherer{keys: reopened}
+	plain, version, err := c.open(ctx, scope, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), plain)
+	assert.EqualValues(t, 1, version)
+
+	// The scope is authenticated: data cannot be opened as another user's.
+	_, _, err = c.open(ctx, Scope{AppName: "app", UserID: "u2"}, sealed)
+	assert.Error(t, err)
+
+	wrongMaster, err := NewFileKeyring(path, bytes.Repeat([]byte{8}, keySize))
+	require.NoError(t, err)
+	_, _, err = (&cipherer{keys: wrongMaster}).open(ctx, scope, sealed)
+	assert.Error(t, err)
+
+	_, err = NewFileKeyring(path, []byte("short"))
+	assert.Error(t, err)
+}
+
+// barrierStore makes the first two loads wait for each other, so that two
+// Keyrings both see the key set before either changes it.
+type barrierStore struct {
+	KeyStore
+	loads   atomic.Int32
+	barrier sync.WaitGroup
+}
+
+func newBarrierStore(store KeyStore) *barrierStore {
+	b := &barrierStore{KeyStore: store}
+	b.barrier.Add(2)
+	return b
+}
+
+func (b *barrierStore) Load(ctx context.Context, scope Scope) (*KeySet, error) {
+	set, err := b.KeyStore.Load(ctx, scope)
+	if b.loads.Add(1) <= 2 {
+		b.barrier.Done()
+	}
+	b.barrier.Wait()
+	return set, err
+}
+
+func TestKeyring_ConcurrentKeyrings(t *testing.T) {
+	ctx := context.Background()
+	scope := Scope{AppName: "app", UserID: "u1"}
+	kms, err := NewLocalKMS(masterKey)
+	require.NoError(t, err)
+	fileStore := NewFileKeyStore(filepath.Join(t.TempDir(), "keys.json"))
+
+	// race runs fn on two Keyrings sharing one store at the same time.
+	race := func(fn func(*Keyring) (*DataKey, error)) [2]*DataKey {
+		store := newBarrierStore(fileStore)
+		var (
+			keys [2]*DataKey
+			errs [2]error
+			wg   sync.WaitGroup
+		)
+		for i := range keys {
+			keyring, err := NewKeyring(kms, store)
+			require.NoError(t, err)
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				keys[i], errs[i] = fn(keyring)
+			}(i)
+		}
+		wg.Wait()
+		require.NoError(t, errs[0])
+		require.NoError(t, errs[1])
+		return keys
+	}
+
+	// Both Keyrings use the same first key.
+	created := race(func(k *Keyring) (*DataKey, error) { return k.CurrentKey(ctx, scope) })
+	assert.EqualValues(t, 1, created[0].Version)
+	assert.Equal(t, created[0], created[1])
+
+	// Concurrent rotations each add a version, and neither is lost.
+	rotated := race(func(k *Keyring) (*DataKey, error) { return k.RotateKey(ctx, scope) })
+	assert.ElementsMatch(t, []uint32{2, 3}, []uint32{rotated[0].Version, rotated[1].Version})
+	reader, err := NewKeyring(kms, fileStore)
+	require.NoError(t, err)
+	for _, key := range append(created[:1], rotated[:]...) {
+		got, err := reader.Key(ctx, scope, key.Version)
+		require.NoError(t, err)
+		assert.Equal(t, key.Key, got.Key)
+	}
+}
+
+type fakeSummarizer struct {
+	out string
+}
+
+func (f *fakeSummarizer) ShouldSummarize(*session.Session) bool { return true }
+func (f *fakeSummarizer) Summarize(context.Context, *session.Session) (string, error) {
+	return f.out, nil
+}
+func (f *fakeSummarizer) SetPrompt(string)         {}
+func (f *fakeSummarizer) SetModel(model.Model)     {}
+func (f *fakeSummarizer) Metadata() map[string]any { return map[string]any{} }
+
+// plainService hides the optional interfaces of a service.
+type plainService struct {
+	session.Service
+}
@@ -0,0 +1,128 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sealed values start with sealMagic, followed by the big-endian key
+// version, the nonce and the AES-256-GCM ciphertext. The scope is the
+// additional authenticated data, so a value cannot be moved to another user.
+// Sealed strings are textPrefix followed by the base64 of a sealed value.
+var sealMagic = []byte{0x00, 'S', 'E', 'C', 0x01}
+
+const textPrefix = "enc:v1:"
+
+var errMalformed = errors.New("encryption: malformed sealed value")
+
+// cipherer seals and opens values with the keys of a KeyProvider.
+type cipherer struct {
+	keys KeyProvider
+}
+
+func (c *cipherer) seal(ctx context.Context, scope Scope, plaintext []byte) ([]byte, error) {
+	key, err := c.keys.CurrentKey(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key.Key)
+	if err != nil {
+		return nil, err
+	}
+	n := len(sealMagic) + 4
+	out := make([]byte, n+aead.NonceSize(), n+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, sealMagic)
+	binary.BigEndian.PutUint32(out[len(sealMagic):], key.Version)
+	nonce := out[n:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("encryption: generate nonce: %w", err)
+	}
+	return aead.Seal(out, nonce, plaintext, []byte(scope.String())), nil
+}
+
+// open decrypts a sealed value and returns the key version it was sealed
+// with. Values that are not sealed are returned as is with version 0, so
+// data written before encryption was enabled stays readable.
+func (c *cipherer) open(ctx context.Context, scope Scope, value []byte) ([]byte, uint32, error) {
+	if !isSealed(value) {
+		return value, 0, nil
+	}
+	n := len(sealMagic) + 4
+	if len(value) < n {
+		return nil, 0, errMalformed
+	}
+	version := binary.BigEndian.Uint32(value[len(sealMagic):])
+	key, err := c.keys.Key(ctx, scope, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	aead, err := newAEAD(key.Key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(value) < n+aead.NonceSize() {
+		return nil, 0, errMalformed
+	}
+	plaintext, err := aead.Open(nil, value[n:n+aead.NonceSize()], value[n+aead.NonceSize():], []byte(scope.String()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("encryption: decrypt %s data: %w", scope, err)
+	}
+	return plaintext, version, nil
+}
+
+func (c *cipherer) sealString(ctx context.Context, scope Scope, s string) (string, error) {
+	sealed, err := c.seal(ctx, scope, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return textPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *cipherer) openString(ctx context.Context, scope Scope, s string) (string, uint32, error) {
+	if !isSealedString(s) {
+		return s, 0, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(s[len(textPrefix):])
+	if err != nil || !isSealed(sealed) {
+		return "", 0, errMalformed
+	}
+	plaintext, version, err := c.open(ctx, scope, sealed)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(plaintext), version, nil
+}
+
+// stale reports whether a value opened with version should be sealed again:
+// it was sealed with an older key than the current key of scope, or it was
+// not sealed at all (version 0).
+func (c *cipherer) stale(ctx context.Context, scope Scope, version uint32) bool {
+	if version == 0 {
+		return true
+	}
+	key, err := c.keys.CurrentKey(ctx, scope)
+	return err == nil && key.Version > version
+}
+
+func isSealed(value []byte) bool {
+	return bytes.HasPrefix(value, sealMagic)
+}
+
+func isSealedString(s string) bool {
+	return strings.HasPrefix(s, textPrefix)
+}
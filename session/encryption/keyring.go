@@ -0,0 +1,469 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// keySize is the size of data keys and of the LocalKMS master key: AES-256.
+const keySize = 32
+
+const defaultKeyCacheTTL = 5 * time.Minute
+
+// maxSaveAttempts bounds how often a Keyring reloads and retries a key set
+// change that conflicts with changes made through other Keyrings.
+const maxSaveAttempts = 5
+
+var (
+	// ErrKeyNotFound is returned when data was encrypted with a key version
+	// the provider does not know.
+	ErrKeyNotFound = errors.New("encryption: data key not found")
+	// ErrKeyDestroyed is returned when data was encrypted with a key that
+	// was destroyed, i.e. the data was crypto-shredded.
+	ErrKeyDestroyed = errors.New("encryption: data key destroyed")
+	// ErrKeySetConflict is returned by KeyStore.Save when the key set of
+	// the scope was changed since it was loaded.
+	ErrKeySetConflict = errors.New("encryption: key set changed concurrently")
+)
+
+// Scope is the owner of a data key. Session data is encrypted with the key
+// of its user, and app state with the key of the app, whose UserID is empty.
+type Scope struct {
+	AppName string
+	UserID  string
+}
+
+// String returns a stable identifier of the scope, used as additional
+// authenticated data and as key store key.
+func (s Scope) String() string {
+	if s.UserID == "" {
+		return url.PathEscape(s.AppName)
+	}
+	return url.PathEscape(s.AppName) + "/" + url.PathEscape(s.UserID)
+}
+
+// DataKey is a version of the data key of a scope.
+type DataKey struct {
+	Version uint32
+	Key     []byte
+}
+
+// KeyProvider provides the data keys of scopes.
+type KeyProvider interface {
+	// CurrentKey returns the key new data of scope is encrypted with. The
+	// first key of a scope is created on first use.
+	CurrentKey(ctx context.Context, scope Scope) (*DataKey, error)
+	// Key returns a version of the data key of scope. It fails with
+	// ErrKeyDestroyed for destroyed versions and ErrKeyNotFound for unknown
+	// ones.
+	Key(ctx context.Context, scope Scope, version uint32) (*DataKey, error)
+	// RotateKey makes a new key version current. Older versions stay
+	// available to decrypt existing data.
+	RotateKey(ctx context.Context, scope Scope) (*DataKey, error)
+	// DestroyKeys destroys every key version of scope, so data encrypted
+	// with them can no longer be decrypted. New data gets a new key.
+	DestroyKeys(ctx context.Context, scope Scope) error
+}
+
+// KMS wraps data keys with a master key it holds, typically a cloud key
+// management service.
+type KMS interface {
+	// WrapKey encrypts the data key of scope.
+	WrapKey(ctx context.Context, scope Scope, key []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped by WrapKey.
+	UnwrapKey(ctx context.Context, scope Scope, wrapped []byte) ([]byte, error)
+}
+
+// KeySet is the stored form of the data keys of a scope.
+type KeySet struct {
+	// Current is the version new data is encrypted with.
+	Current uint32 `json:"current"`
+	// Destroyed is the highest version destroyed by DestroyKeys.
+	Destroyed uint32       `json:"destroyed,omitempty"`
+	Keys      []WrappedKey `json:"keys,omitempty"`
+}
+
+// WrappedKey is a data key version wrapped by the KMS.
+type WrappedKey struct {
+	Version   uint32    `json:"version"`
+	Wrapped   []byte    `json:"wrapped"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeyStore persists wrapped data keys.
+type KeyStore interface {
+	// Load returns the key set of scope, or nil if it has none.
+	Load(ctx context.Context, scope Scope) (*KeySet, error)
+	// Save replaces the key set of scope if it is still prev, the set the
+	// new one was derived from, or creates it if prev is nil and scope has
+	// no key set. Sets are compared by their Current and Destroyed
+	// versions. Otherwise Save fails with ErrKeySetConflict, and stores
+	// shared between processes must check and write atomically.
+	Save(ctx context.Context, scope Scope, prev, set *KeySet) error
+}
+
+// Keyring is a KeyProvider doing envelope encryption: data keys are
+// generated locally, wrapped by a KMS and persisted in a KeyStore. Unwrapped
+// keys are cached in memory.
+//
+// Key creation, rotation and destruction are conditional writes to the
+// store, so Keyrings sharing a KeyStore agree on the keys of a scope: when
+// one loses a race, it reloads the key set and retries. Other Keyrings see
+// a rotation when their cache expires.
+type Keyring struct {
+	kms      KMS
+	store    KeyStore
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[Scope]*cachedKeys
+}
+
+var _ KeyProvider = (*Keyring)(nil)
+
+type cachedKeys struct {
+	set *KeySet
+	// stored is the set as it is in the store, nil if there is none.
+	stored   *KeySet
+	keys     map[uint32][]byte
+	loadedAt time.Time
+}
+
+// KeyringOption configures a Keyring.
+type KeyringOption func(*Keyring)
+
+// WithKeyCacheTTL sets how long key sets are cached before they are loaded
+// again from the store. Defaults to 5 minutes. Zero disables caching.
+func WithKeyCacheTTL(ttl time.Duration) KeyringOption {
+	return func(k *Keyring) {
+		if ttl >= 0 {
+			k.cacheTTL = ttl
+		}
+	}
+}
+
+// NewKeyring creates a Keyring wrapping data keys with kms and storing them
+// in store.
+func NewKeyring(kms KMS, store KeyStore, opts ...KeyringOption) (*Keyring, error) {
+	if kms == nil || store == nil {
+		return nil, errors.New("encryption: kms and key store are required")
+	}
+	k := &Keyring{
+		kms:      kms,
+		store:    store,
+		cacheTTL: defaultKeyCacheTTL,
+		cache:    make(map[Scope]*cachedKeys),
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k, nil
+}
+
+// NewFileKeyring creates a Keyring storing its keys in a local file, wrapped
+// with a 32-byte master key. It is meant for tests and single-process
+// deployments; use NewKeyring with a KMS in production.
+func NewFileKeyring(path string, masterKey []byte, opts ...KeyringOption) (*Keyring, error) {
+	kms, err := NewLocalKMS(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(kms, NewFileKeyStore(path), opts...)
+}
+
+// CurrentKey implements KeyProvider.
+func (k *Keyring) CurrentKey(ctx context.Context, scope Scope) (*DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	c, err := k.load(ctx, scope, false)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		if c.set.Current > c.set.Destroyed {
+			return k.unwrap(ctx, scope, c, c.set.Current)
+		}
+		key, err := k.addVersion(ctx, scope, c)
+		if !errors.Is(err, ErrKeySetConflict) || attempt == maxSaveAttempts {
+			return key, err
+		}
+		// Another Keyring created the key first: use it.
+		if c, err = k.load(ctx, scope, true); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Key implements KeyProvider.
+func (k *Keyring) Key(ctx context.Context, scope Scope, version uint32) (*DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	c, err := k.load(ctx, scope, false)
+	if err != nil {
+		return nil, err
+	}
+	if version > c.set.Current {
+		// Rotated by another process since the set was cached.
+		if c, err = k.load(ctx, scope, true); err != nil {
+			return nil, err
+		}
+	}
+	return k.unwrap(ctx, scope, c, version)
+}
+
+// RotateKey implements KeyProvider.
+func (k *Keyring) RotateKey(ctx context.Context, scope Scope) (*DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		c, err := k.load(ctx, scope, true)
+		if err != nil {
+			return nil, err
+		}
+		key, err := k.addVersion(ctx, scope, c)
+		if !errors.Is(err, ErrKeySetConflict) || attempt == maxSaveAttempts {
+			return key, err
+		}
+	}
+}
+
+// DestroyKeys implements KeyProvider.
+func (k *Keyring) DestroyKeys(ctx context.Context, scope Scope) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		c, err := k.load(ctx, scope, true)
+		if err != nil {
+			return err
+		}
+		set := &KeySet{Current: c.set.Current, Destroyed: max(c.set.Current, c.set.Destroyed)}
+		err = k.store.Save(ctx, scope, c.stored, set)
+		if err == nil {
+			delete(k.cache, scope)
+			return nil
+		}
+		if !errors.Is(err, ErrKeySetConflict) || attempt == maxSaveAttempts {
+			return fmt.Errorf("encryption: save keys of %s: %w", scope, err)
+		}
+	}
+}
+
+// load returns the keys of scope from the cache, or from the store when
+// they are not cached, stale, or reload is set. k.mu must be held.
+func (k *Keyring) load(ctx context.Context, scope Scope, reload bool) (*cachedKeys, error) {
+	if c, ok := k.cache[scope]; ok && !reload && k.cacheTTL > 0 && time.Since(c.loadedAt) < k.cacheTTL {
+		return c, nil
+	}
+	set, err := k.store.Load(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: load keys of %s: %w", scope, err)
+	}
+	c := &cachedKeys{set: set, stored: set, keys: make(map[uint32][]byte), loadedAt: time.Now()}
+	if set == nil {
+		c.set = &KeySet{}
+	}
+	if old, ok := k.cache[scope]; ok {
+		// Keep unwrapped keys that are still in the set.
+		for _, w := range c.set.Keys {
+			if key, ok := old.keys[w.Version]; ok {
+				c.keys[w.Version] = key
+			}
+		}
+	}
+	k.cache[scope] = c
+	return c, nil
+}
+
+// addVersion creates a new current key version. It fails with
+// ErrKeySetConflict when the stored keys changed since c was loaded. k.mu
+// must be held.
+func (k *Keyring) addVersion(ctx context.Context, scope Scope, c *cachedKeys) (*DataKey, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("encryption: generate data key: %w", err)
+	}
+	wrapped, err := k.kms.WrapKey(ctx, scope, key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: wrap data key of %s: %w", scope, err)
+	}
+	version := max(c.set.Current, c.set.Destroyed) + 1
+	set := &KeySet{
+		Current:   version,
+		Destroyed: c.set.Destroyed,
+		Keys:      append(append([]WrappedKey(nil), c.set.Keys...), WrappedKey{Version: version, Wrapped: wrapped, CreatedAt: time.Now().UTC()}),
+	}
+	if err := k.store.Save(ctx, scope, c.stored, set); err != nil {
+		return nil, fmt.Errorf("encryption: save keys of %s: %w", scope, err)
+	}
+	c.set, c.stored = set, set
+	c.keys[version] = key
+	return &DataKey{Version: version, Key: key}, nil
+}
+
+// unwrap returns a key version of c. k.mu must be held.
+func (k *Keyring) unwrap(ctx context.Context, scope Scope, c *cachedKeys, version uint32) (*DataKey, error) {
+	if version <= c.set.Destroyed {
+		return nil, fmt.Errorf("%w: %s version %d", ErrKeyDestroyed, scope, version)
+	}
+	if key, ok := c.keys[version]; ok {
+		return &DataKey{Version: version, Key: key}, nil
+	}
+	for _, w := range c.set.Keys {
+		if w.Version != version {
+			continue
+		}
+		key, err := k.kms.UnwrapKey(ctx, scope, w.Wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: unwrap data key of %s: %w", scope, err)
+		}
+		c.keys[version] = key
+		return &DataKey{Version: version, Key: key}, nil
+	}
+	return nil, fmt.Errorf("%w: %s version %d", ErrKeyNotFound, scope, version)
+}
+
+// LocalKMS is a KMS wrapping data keys with a master key held in memory,
+// with AES-256-GCM. It is meant for tests and local development.
+type LocalKMS struct {
+	aead cipher.AEAD
+}
+
+var _ KMS = (*LocalKMS)(nil)
+
+// NewLocalKMS creates a LocalKMS from a 32-byte master key.
+func NewLocalKMS(masterKey []byte) (*LocalKMS, error) {
+	if len(masterKey) != keySize {
+		return nil, fmt.Errorf("encryption: master key must be %d bytes, got %d", keySize, len(masterKey))
+	}
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKMS{aead: aead}, nil
+}
+
+// WrapKey implements KMS.
+func (l *LocalKMS) WrapKey(_ context.Context, scope Scope, key []byte) ([]byte, error) {
+	nonce := make([]byte, l.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return l.aead.Seal(nonce, nonce, key, []byte(scope.String())), nil
+}
+
+// UnwrapKey implements KMS.
+func (l *LocalKMS) UnwrapKey(_ context.Context, scope Scope, wrapped []byte) ([]byte, error) {
+	n := l.aead.NonceSize()
+	if len(wrapped) < n {
+		return nil, errors.New("wrapped key too short")
+	}
+	return l.aead.Open(nil, wrapped[:n], wrapped[n:], []byte(scope.String()))
+}
+
+// FileKeyStore is a KeyStore keeping all key sets in one JSON file. Writes
+// replace the file atomically. Its conditional writes only hold within the
+// process: it does not coordinate between processes.
+type FileKeyStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ KeyStore = (*FileKeyStore)(nil)
+
+// NewFileKeyStore creates a key store at path. The file is created on the
+// first write.
+func NewFileKeyStore(path string) *FileKeyStore {
+	return &FileKeyStore{path: path}
+}
+
+// Load implements KeyStore.
+func (f *FileKeyStore) Load(_ context.Context, scope Scope) (*KeySet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sets, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	return sets[scope.String()], nil
+}
+
+// Save implements KeyStore.
+func (f *FileKeyStore) Save(_ context.Context, scope Scope, prev, set *KeySet) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sets, err := f.read()
+	if err != nil {
+		return err
+	}
+	if !sameKeySet(sets[scope.String()], prev) {
+		return ErrKeySetConflict
+	}
+	sets[scope.String()] = set
+	data, err := json.MarshalIndent(sets, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *FileKeyStore) read() (map[string]*KeySet, error) {
+	sets := make(map[string]*KeySet)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, fmt.Errorf("decode key file %s: %w", f.path, err)
+	}
+	return sets, nil
+}
+
+// sameKeySet reports whether a and b are the same version of a key set.
+func sameKeySet(a, b *KeySet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Current == b.Current && a.Destroyed == b.Destroyed
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
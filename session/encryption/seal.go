@@ -0,0 +1,291 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// tracksStateKey is the track index backends read from stored session state,
+// see session.TracksFromState. It holds track names only.
+const tracksStateKey = "tracks"
+
+// sealedChoice is the plaintext sealed into the content of a stored choice.
+type sealedChoice struct {
+	Message  model.Message   `json:"message"`
+	Delta    model.Message   `json:"delta,omitempty"`
+	Logprobs *model.Logprobs `json:"logprobs,omitempty"`
+}
+
+// stateScope returns the scope of a session state key: app state is sealed
+// with the app key, everything else with the user key.
+func stateScope(userKey session.UserKey, key string) Scope {
+	if strings.HasPrefix(key, session.StateAppPrefix) {
+		return Scope{AppName: userKey.AppName}
+	}
+	return Scope{AppName: userKey.AppName, UserID: userKey.UserID}
+}
+
+// sealState seals the values of state, with the scope of each key given by
+// scopeOf. Keys in plain are kept as is.
+func (c *cipherer) sealState(
+	ctx context.Context,
+	state session.StateMap,
+	scopeOf func(string) Scope,
+	plain map[string]bool,
+) (session.StateMap, error) {
+	if state == nil {
+		return nil, nil
+	}
+	sealed := make(session.StateMap, len(state))
+	for k, v := range state {
+		if v == nil || plain[k] {
+			sealed[k] = v
+			continue
+		}
+		s, err := c.seal(ctx, scopeOf(k), v)
+		if err != nil {
+			return nil, fmt.Errorf("seal state %s: %w", k, err)
+		}
+		sealed[k] = s
+	}
+	return sealed, nil
+}
+
+// openState opens the values of state and returns the keys whose values
+// should be sealed again.
+func (c *cipherer) openState(
+	ctx context.Context,
+	state session.StateMap,
+	scopeOf func(string) Scope,
+	plain map[string]bool,
+) (session.StateMap, []string, error) {
+	if state == nil {
+		return nil, nil, nil
+	}
+	opened := make(session.StateMap, len(state))
+	var stale []string
+	for k, v := range state {
+		if v == nil || plain[k] {
+			opened[k] = v
+			continue
+		}
+		p, version, err := c.open(ctx, scopeOf(k), v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open state %s: %w", k, err)
+		}
+		opened[k] = p
+		if c.stale(ctx, scopeOf(k), version) {
+			stale = append(stale, k)
+		}
+	}
+	return opened, stale, nil
+}
+
+// sealEvent returns a copy of evt with its message payloads, state delta and
+// extensions sealed. Identifiers, roles, tool call IDs and names, timestamps
+// and filter keys stay readable, since backends filter and page on them.
+func (c *cipherer) sealEvent(
+	ctx context.Context,
+	userKey session.UserKey,
+	evt *event.Event,
+	plain map[string]bool,
+) (*event.Event, error) {
+	scope := Scope{AppName: userKey.AppName, UserID: userKey.UserID}
+	sealed := *evt
+	if evt.Response != nil {
+		rsp := *evt.Response
+		rsp.Choices = make([]model.Choice, len(evt.Response.Choices))
+		for i, choice := range evt.Response.Choices {
+			sc, err := c.sealChoice(ctx, scope, choice)
+			if err != nil {
+				return nil, fmt.Errorf("seal event %s: %w", evt.ID, err)
+			}
+			rsp.Choices[i] = sc
+		}
+		sealed.Response = &rsp
+	}
+	delta, err := c.sealState(ctx, evt.StateDelta, func(k string) Scope { return stateScope(userKey, k) }, plain)
+	if err != nil {
+		return nil, fmt.Errorf("seal event %s: %w", evt.ID, err)
+	}
+	sealed.StateDelta = delta
+	if sealed.Extensions, err = c.sealExtensions(ctx, scope, evt.Extensions); err != nil {
+		return nil, fmt.Errorf("seal event %s: %w", evt.ID, err)
+	}
+	return &sealed, nil
+}
+
+// openEvent returns a copy of evt with its sealed parts opened. Events are
+// append-only, so they are not sealed again after a key rotation.
+func (c *cipherer) openEvent(
+	ctx context.Context,
+	userKey session.UserKey,
+	evt *event.Event,
+	plain map[string]bool,
+) (*event.Event, error) {
+	scope := Scope{AppName: userKey.AppName, UserID: userKey.UserID}
+	opened := *evt
+	if evt.Response != nil {
+		rsp := *evt.Response
+		rsp.Choices = make([]model.Choice, len(evt.Response.Choices))
+		for i, choice := range evt.Response.Choices {
+			oc, err := c.openChoice(ctx, scope, choice)
+			if err != nil {
+				return nil, fmt.Errorf("open event %s: %w", evt.ID, err)
+			}
+			rsp.Choices[i] = oc
+		}
+		opened.Response = &rsp
+	}
+	delta, _, err := c.openState(ctx, evt.StateDelta, func(k string) Scope { return stateScope(userKey, k) }, plain)
+	if err != nil {
+		return nil, fmt.Errorf("open event %s: %w", evt.ID, err)
+	}
+	opened.StateDelta = delta
+	if opened.Extensions, err = c.openExtensions(ctx, scope, evt.Extensions); err != nil {
+		return nil, fmt.Errorf("open event %s: %w", evt.ID, err)
+	}
+	return &opened, nil
+}
+
+func (c *cipherer) sealChoice(ctx context.Context, scope Scope, choice model.Choice) (model.Choice, error) {
+	if !hasSecret(choice.Message) && !hasSecret(choice.Delta) && choice.Logprobs == nil {
+		return choice, nil
+	}
+	payload, err := json.Marshal(sealedChoice{Message: choice.Message, Delta: choice.Delta, Logprobs: choice.Logprobs})
+	if err != nil {
+		return choice, err
+	}
+	content, err := c.sealString(ctx, scope, string(payload))
+	if err != nil {
+		return choice, err
+	}
+	choice.Message = skeleton(choice.Message)
+	choice.Message.Content = content
+	choice.Delta = skeleton(choice.Delta)
+	choice.Logprobs = nil
+	return choice, nil
+}
+
+func (c *cipherer) openChoice(ctx context.Context, scope Scope, choice model.Choice) (model.Choice, error) {
+	if !isSealedString(choice.Message.Content) {
+		return choice, nil
+	}
+	payload, _, err := c.openString(ctx, scope, choice.Message.Content)
+	if err != nil {
+		return choice, err
+	}
+	var sc sealedChoice
+	if err := json.Unmarshal([]byte(payload), &sc); err != nil {
+		return choice, fmt.Errorf("decode sealed choice: %w", err)
+	}
+	choice.Message, choice.Delta, choice.Logprobs = sc.Message, sc.Delta, sc.Logprobs
+	return choice, nil
+}
+
+// hasSecret reports whether msg carries more than its structure.
+func hasSecret(msg model.Message) bool {
+	if model.HasPayload(msg) || msg.ReasoningSignature != "" {
+		return true
+	}
+	for _, tc := range msg.ToolCalls {
+		if len(tc.Function.Arguments) > 0 || tc.Function.Description != "" || len(tc.ExtraFields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// skeleton returns the structure of msg that stays readable when it is
+// sealed.
+func skeleton(msg model.Message) model.Message {
+	out := model.Message{Role: msg.Role, ToolID: msg.ToolID, ToolName: msg.ToolName}
+	for _, tc := range msg.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, model.ToolCall{
+			Type:     tc.Type,
+			ID:       tc.ID,
+			Index:    tc.Index,
+			Function: model.FunctionDefinitionParam{Name: tc.Function.Name},
+		})
+	}
+	return out
+}
+
+// sealExtensions seals each extension into a JSON string.
+func (c *cipherer) sealExtensions(
+	ctx context.Context,
+	scope Scope,
+	extensions map[string]json.RawMessage,
+) (map[string]json.RawMessage, error) {
+	if extensions == nil {
+		return nil, nil
+	}
+	sealed := make(map[string]json.RawMessage, len(extensions))
+	for k, v := range extensions {
+		s, err := c.sealRaw(ctx, scope, v)
+		if err != nil {
+			return nil, fmt.Errorf("seal extension %s: %w", k, err)
+		}
+		sealed[k] = s
+	}
+	return sealed, nil
+}
+
+func (c *cipherer) openExtensions(
+	ctx context.Context,
+	scope Scope,
+	extensions map[string]json.RawMessage,
+) (map[string]json.RawMessage, error) {
+	if extensions == nil {
+		return nil, nil
+	}
+	opened := make(map[string]json.RawMessage, len(extensions))
+	for k, v := range extensions {
+		o, err := c.openRaw(ctx, scope, v)
+		if err != nil {
+			return nil, fmt.Errorf("open extension %s: %w", k, err)
+		}
+		opened[k] = o
+	}
+	return opened, nil
+}
+
+// sealRaw seals a JSON value into a JSON string, so that it stays valid JSON
+// for backends that store it in JSON columns.
+func (c *cipherer) sealRaw(ctx context.Context, scope Scope, raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+	s, err := c.sealString(ctx, scope, string(raw))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+func (c *cipherer) openRaw(ctx context.Context, scope Scope, raw json.RawMessage) (json.RawMessage, error) {
+	var s string
+	if len(raw) == 0 || raw[0] != '"' || json.Unmarshal(raw, &s) != nil || !isSealedString(s) {
+		return raw, nil
+	}
+	p, _, err := c.openString(ctx, scope, s)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(p), nil
+}
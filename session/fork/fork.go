@@ -0,0 +1,417 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package fork forks a session at a user message into a new session, so a
+// conversation can be rewound or edited without losing its history.
+//
+// A fork is a new session holding the events before the fork point, the
+// session state as it was at that point, and the summaries and track events
+// covering only those events. The source session is left untouched. Forks
+// record their parent and root sessions in their state, so the sessions
+// forked from one conversation can be listed as a tree of branches.
+package fork
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// StateKey is the session state key holding the Info of a fork.
+const StateKey = "fork:info"
+
+// tracksStateKey is the track index, rebuilt by the track events copied
+// into the fork.
+const tracksStateKey = "tracks"
+
+var (
+	// ErrSessionNotFound is returned when the session to fork does not exist.
+	ErrSessionNotFound = errors.New("fork: session not found")
+	// ErrEventNotFound is returned when the fork event is not in the session.
+	ErrEventNotFound = errors.New("fork: event not found")
+	// ErrNoUserMessage is returned when no user message precedes the fork
+	// event.
+	ErrNoUserMessage = errors.New("fork: no user message at or before the event")
+	// ErrSessionExists is returned when the fork session ID is taken.
+	ErrSessionExists = errors.New("fork: session already exists")
+)
+
+// Info links a fork to the session it was forked from.
+type Info struct {
+	// ParentSessionID is the session the fork was created from.
+	ParentSessionID string `json:"parent_session_id"`
+	// RootSessionID is the first session of the conversation, shared by all
+	// its forks.
+	RootSessionID string `json:"root_session_id"`
+	// EventID is the user message event of the parent the fork was created
+	// at. The fork holds the events before it.
+	EventID string `json:"event_id"`
+	// CreatedAt is when the fork was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Result is a created fork.
+type Result struct {
+	// Session is the fork.
+	Session *session.Session
+	// Info is the fork Info, also stored in its state.
+	Info Info
+	// Message is the user message at the fork point, which the fork does not
+	// hold. Send it again, or an edited version of it, to continue the
+	// conversation.
+	Message model.Message
+	// DroppedSummaries lists the filter keys of the summaries not carried
+	// over, because they covered events after the fork point or the session
+	// service cannot store summaries.
+	DroppedSummaries []string
+}
+
+type options struct {
+	sessionID   string
+	trackCutoff time.Time
+}
+
+// Option configures At.
+type Option func(*options)
+
+// WithSessionID sets the ID of the fork session. A UUID is used by default.
+func WithSessionID(id string) Option {
+	return func(o *options) {
+		o.sessionID = id
+	}
+}
+
+// WithTrackCutoff carries over only the track events before t, when t is
+// before the fork point. Callers that record track events for a turn before
+// its user message is appended use it to leave that turn out of the fork.
+func WithTrackCutoff(t time.Time) Option {
+	return func(o *options) {
+		o.trackCutoff = t
+	}
+}
+
+// At forks the session key at eventID.
+//
+// The fork point is the user message eventID, or the last user message before
+// it when eventID is another event, such as the answer to regenerate. The
+// fork holds the events before the fork point. Its state is the state of the
+// source session, with the keys changed by later events reverted to the last
+// value set by an earlier event, or removed when no earlier event set them.
+// Summaries and track events are carried over when they cover only events
+// before the fork point and the session service supports writing them
+// (session.SummaryWriter, session.TrackService).
+func At(
+	ctx context.Context,
+	svc session.Service,
+	key session.Key,
+	eventID string,
+	opts ...Option,
+) (*Result, error) {
+	if err := key.CheckSessionKey(); err != nil {
+		return nil, err
+	}
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.sessionID == "" {
+		o.sessionID = uuid.NewString()
+	}
+	src, err := svc.GetSession(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("fork: get session: %w", err)
+	}
+	if src == nil {
+		return nil, ErrSessionNotFound
+	}
+	events := src.GetEvents()
+	point, err := forkPoint(events, eventID)
+	if err != nil {
+		return nil, err
+	}
+	target := events[point]
+	forkKey := session.Key{AppName: key.AppName, UserID: key.UserID, SessionID: o.sessionID}
+	existing, err := svc.GetSession(ctx, forkKey)
+	if err != nil {
+		return nil, fmt.Errorf("fork: get session: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSessionExists, o.sessionID)
+	}
+
+	info := Info{
+		ParentSessionID: key.SessionID,
+		RootSessionID:   key.SessionID,
+		EventID:         target.ID,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if parent, ok := InfoOf(src); ok && parent.RootSessionID != "" {
+		info.RootSessionID = parent.RootSessionID
+	}
+	state, err := stateAt(src.SnapshotState(), events[:point], events[point:], info)
+	if err != nil {
+		return nil, err
+	}
+	forked, err := svc.CreateSession(ctx, forkKey, state)
+	if err != nil {
+		return nil, fmt.Errorf("fork: create session: %w", err)
+	}
+	for i := range events[:point] {
+		evt := events[i]
+		evt.StateDelta = sessionScoped(evt.StateDelta)
+		if err := svc.AppendEvent(ctx, forked, &evt); err != nil {
+			return nil, fmt.Errorf("fork: append event %s: %w", evt.ID, err)
+		}
+	}
+	if point > 0 {
+		// Replayed state deltas can leave state the session changed later.
+		if err := svc.UpdateSessionState(ctx, forkKey, state); err != nil {
+			return nil, fmt.Errorf("fork: update session state: %w", err)
+		}
+	}
+	cutoff := target.Timestamp
+	dropped, err := copySummaries(ctx, svc, forkKey, src, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	trackCutoff := cutoff
+	if !o.trackCutoff.IsZero() && o.trackCutoff.Before(cutoff) {
+		trackCutoff = o.trackCutoff
+	}
+	if err := copyTracks(ctx, svc, forked, src, trackCutoff); err != nil {
+		return nil, err
+	}
+	if forked, err = svc.GetSession(ctx, forkKey); err != nil {
+		return nil, fmt.Errorf("fork: get session: %w", err)
+	}
+	return &Result{
+		Session:          forked,
+		Info:             info,
+		Message:          userMessage(target),
+		DroppedSummaries: dropped,
+	}, nil
+}
+
+// forkPoint returns the index of the user message event at or before
+// eventID.
+func forkPoint(events []event.Event, eventID string) (int, error) {
+	idx := slices.IndexFunc(events, func(e event.Event) bool { return e.ID == eventID })
+	if idx < 0 {
+		return 0, fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+	}
+	for ; idx >= 0; idx-- {
+		if events[idx].IsUserMessage() {
+			return idx, nil
+		}
+	}
+	return 0, ErrNoUserMessage
+}
+
+func userMessage(e event.Event) model.Message {
+	for _, choice := range e.Response.Choices {
+		if choice.Message.Role == model.RoleUser {
+			return choice.Message
+		}
+	}
+	return e.Response.Choices[0].Delta
+}
+
+// stateAt returns the session-scoped state of a fork: state with the keys
+// changed by dropped events reverted to their value after kept.
+func stateAt(state session.StateMap, kept, dropped []event.Event, info Info) (session.StateMap, error) {
+	out := sessionScoped(state)
+	if out == nil {
+		out = make(session.StateMap, 1)
+	}
+	reverted := make(map[string]bool)
+	for _, e := range dropped {
+		for k := range e.StateDelta {
+			reverted[k] = true
+			delete(out, k)
+		}
+	}
+	for _, e := range kept {
+		for k, v := range e.StateDelta {
+			if reverted[k] {
+				out[k] = v
+			}
+		}
+	}
+	delete(out, tracksStateKey)
+	raw, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("fork: encode info: %w", err)
+	}
+	out[StateKey] = raw
+	return out, nil
+}
+
+// sessionScoped returns the session-scoped keys of state. App and user state
+// are shared by all sessions, so forks neither copy nor replay them.
+func sessionScoped(state session.StateMap) session.StateMap {
+	if state == nil {
+		return nil
+	}
+	out := make(session.StateMap, len(state))
+	for k, v := range state {
+		if strings.HasPrefix(k, session.StateAppPrefix) || strings.HasPrefix(k, session.StateUserPrefix) {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// copySummaries carries over the summaries of src whose cutoff is before
+// the fork point and returns the filter keys of the others.
+func copySummaries(
+	ctx context.Context,
+	svc session.Service,
+	key session.Key,
+	src *session.Session,
+	cutoff time.Time,
+) ([]string, error) {
+	src.SummariesMu.RLock()
+	summaries := make(map[string]*session.Summary, len(src.Summaries))
+	for filterKey, sum := range src.Summaries {
+		summaries[filterKey] = sum.Clone()
+	}
+	src.SummariesMu.RUnlock()
+
+	filterKeys := make([]string, 0, len(summaries))
+	for filterKey := range summaries {
+		filterKeys = append(filterKeys, filterKey)
+	}
+	slices.Sort(filterKeys)
+	writer, canWrite := svc.(session.SummaryWriter)
+	var dropped []string
+	for _, filterKey := range filterKeys {
+		sum := summaries[filterKey]
+		at := sum.CutoffTime()
+		if !canWrite || at.IsZero() || !at.Before(cutoff) {
+			dropped = append(dropped, filterKey)
+			continue
+		}
+		// Pin the cutoff: summaries updated before the fork was created
+		// would be ignored as stale.
+		sum.Boundary = sum.CutoffBoundary()
+		sum.UpdatedAt = time.Now().UTC()
+		if err := writer.PutSessionSummary(ctx, key, filterKey, sum); err != nil {
+			return nil, fmt.Errorf("fork: put summary %q: %w", filterKey, err)
+		}
+	}
+	return dropped, nil
+}
+
+// copyTracks carries over the track events of src before the fork point.
+func copyTracks(
+	ctx context.Context,
+	svc session.Service,
+	forked *session.Session,
+	src *session.Session,
+	cutoff time.Time,
+) error {
+	tracker, ok := svc.(session.TrackService)
+	if !ok {
+		return nil
+	}
+	src.TracksMu.RLock()
+	var trackEvents []session.TrackEvent
+	for _, te := range src.Tracks {
+		if te == nil {
+			continue
+		}
+		for _, e := range te.Events {
+			if e.Timestamp.Before(cutoff) {
+				trackEvents = append(trackEvents, e)
+			}
+		}
+	}
+	src.TracksMu.RUnlock()
+	slices.SortStableFunc(trackEvents, func(a, b session.TrackEvent) int { return a.Timestamp.Compare(b.Timestamp) })
+	for i := range trackEvents {
+		if err := tracker.AppendTrackEvent(ctx, forked, &trackEvents[i]); err != nil {
+			return fmt.Errorf("fork: append track event: %w", err)
+		}
+	}
+	return nil
+}
+
+// InfoOf returns the fork Info of sess, and false when sess is not a fork.
+func InfoOf(sess *session.Session) (Info, bool) {
+	if sess == nil {
+		return Info{}, false
+	}
+	raw, ok := sess.GetState(StateKey)
+	if !ok || len(raw) == 0 {
+		return Info{}, false
+	}
+	var info Info
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return Info{}, false
+	}
+	return info, true
+}
+
+// Branch is a session of a conversation tree.
+type Branch struct {
+	SessionID string
+	// Info is the fork Info, nil for the root session.
+	Info      *Info
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// List returns the branches of the conversation of sessionID: its root
+// session and every session forked from it, directly or not, ordered by
+// creation time.
+func List(ctx context.Context, svc session.Service, userKey session.UserKey, sessionID string) ([]Branch, error) {
+	sessions, err := svc.ListSessions(ctx, userKey)
+	if err != nil {
+		return nil, fmt.Errorf("fork: list sessions: %w", err)
+	}
+	root := sessionID
+	for _, sess := range sessions {
+		if sess.ID != sessionID {
+			continue
+		}
+		if info, ok := InfoOf(sess); ok && info.RootSessionID != "" {
+			root = info.RootSessionID
+		}
+	}
+	var branches []Branch
+	for _, sess := range sessions {
+		info, ok := InfoOf(sess)
+		switch {
+		case sess.ID == root:
+			branches = append(branches, Branch{SessionID: sess.ID, CreatedAt: sess.CreatedAt, UpdatedAt: sess.UpdatedAt})
+		case ok && info.RootSessionID == root:
+			branches = append(branches, Branch{
+				SessionID: sess.ID, Info: &info, CreatedAt: sess.CreatedAt, UpdatedAt: sess.UpdatedAt,
+			})
+		}
+	}
+	slices.SortFunc(branches, func(a, b Branch) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.SessionID, b.SessionID)
+	})
+	return branches, nil
+}
@@ -0,0 +1,188 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package fork
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+var (
+	testKey     = session.Key{AppName: "app", UserID: "u1", SessionID: "s1"}
+	testUserKey = session.UserKey{AppName: "app", UserID: "u1"}
+)
+
+func newEvent(msg model.Message, delta session.StateMap) *event.Event {
+	e := event.NewResponseEvent("inv", string(msg.Role), &model.Response{
+		Done:    true,
+		Choices: []model.Choice{{Message: msg}},
+	})
+	e.StateDelta = delta
+	return e
+}
+
+// seed stores a session of two turns with state deltas, summaries and track
+// events on both sides of the second user message.
+func seed(t *testing.T, svc *inmemory.SessionService) []event.Event {
+	t.Helper()
+	ctx := context.Background()
+	sess, err := svc.CreateSession(ctx, testKey, session.StateMap{"mode": []byte("a")})
+	require.NoError(t, err)
+	for _, e := range []*event.Event{
+		newEvent(model.NewUserMessage("first"), session.StateMap{"step": []byte("1")}),
+		newEvent(model.NewAssistantMessage("answer 1"), session.StateMap{"step": []byte("2")}),
+		newEvent(model.NewUserMessage("second"), session.StateMap{"step": []byte("3"), "extra": []byte("x")}),
+		newEvent(model.NewAssistantMessage("answer 2"), nil),
+	} {
+		require.NoError(t, svc.AppendEvent(ctx, sess, e))
+	}
+	events := sess.GetEvents()
+	require.Len(t, events, 4)
+	require.NoError(t, svc.PutSessionSummary(ctx, testKey, "", &session.Summary{
+		Summary:  "first turn",
+		Boundary: session.NewSummaryBoundary("", events[1].Timestamp),
+	}))
+	require.NoError(t, svc.PutSessionSummary(ctx, testKey, "late", &session.Summary{
+		Summary:  "both turns",
+		Boundary: session.NewSummaryBoundary("late", events[3].Timestamp),
+	}))
+	for _, e := range []event.Event{events[1], events[3]} {
+		require.NoError(t, svc.AppendTrackEvent(ctx, sess, &session.TrackEvent{
+			Track: "audit", Payload: json.RawMessage(`"` + e.ID + `"`), Timestamp: e.Timestamp,
+		}))
+	}
+	require.NoError(t, svc.UpdateUserState(ctx, testUserKey, session.StateMap{"lang": []byte("go")}))
+	return events
+}
+
+func TestAt(t *testing.T) {
+	svc := inmemory.NewSessionService()
+	defer svc.Close()
+	ctx := context.Background()
+	events := seed(t, svc)
+
+	res, err := At(ctx, svc, testKey, events[2].ID, WithSessionID("s2"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", res.Message.Content)
+	assert.Equal(t, Info{
+		ParentSessionID: "s1", RootSessionID: "s1", EventID: events[2].ID, CreatedAt: res.Info.CreatedAt,
+	}, res.Info)
+	assert.Equal(t, []string{"late"}, res.DroppedSummaries)
+
+	forked := res.Session
+	require.Equal(t, "s2", forked.ID)
+	require.Len(t, forked.Events, 2)
+	assert.Equal(t, events[0].ID, forked.Events[0].ID)
+	assert.Equal(t, events[1].ID, forked.Events[1].ID)
+	step, _ := forked.GetState("step")
+	assert.Equal(t, []byte("2"), step)
+	_, ok := forked.GetState("extra")
+	assert.False(t, ok)
+	mode, _ := forked.GetState("mode")
+	assert.Equal(t, []byte("a"), mode)
+	lang, _ := forked.GetState(session.StateUserPrefix + "lang")
+	assert.Equal(t, []byte("go"), lang)
+	info, ok := InfoOf(forked)
+	require.True(t, ok)
+	assert.Equal(t, res.Info, info)
+
+	require.Contains(t, forked.Summaries, "")
+	assert.NotContains(t, forked.Summaries, "late")
+	text, ok := svc.GetSessionSummaryText(ctx, forked)
+	require.True(t, ok)
+	assert.Equal(t, "first turn", text)
+	require.Contains(t, forked.Tracks, session.Track("audit"))
+	assert.Len(t, forked.Tracks["audit"].Events, 1)
+
+	// The source session is left untouched.
+	src, err := svc.GetSession(ctx, testKey)
+	require.NoError(t, err)
+	assert.Len(t, src.Events, 4)
+	step, _ = src.GetState("step")
+	assert.Equal(t, []byte("3"), step)
+}
+
+func TestAt_KeepsStateChangedAfterKeptEvents(t *testing.T) {
+	svc := inmemory.NewSessionService()
+	defer svc.Close()
+	ctx := context.Background()
+	sess, err := svc.CreateSession(ctx, testKey, nil)
+	require.NoError(t, err)
+	require.NoError(t, svc.AppendEvent(ctx, sess,
+		newEvent(model.NewUserMessage("first"), session.StateMap{"plan": []byte("draft")})))
+	require.NoError(t, svc.AppendEvent(ctx, sess, newEvent(model.NewAssistantMessage("answer 1"), nil)))
+	require.NoError(t, svc.UpdateSessionState(ctx, testKey, session.StateMap{"plan": []byte("final")}))
+	require.NoError(t, svc.AppendEvent(ctx, sess, newEvent(model.NewUserMessage("second"), nil)))
+
+	res, err := At(ctx, svc, testKey, sess.GetEvents()[2].ID)
+	require.NoError(t, err)
+	require.Len(t, res.Session.Events, 2)
+	plan, _ := res.Session.GetState("plan")
+	assert.Equal(t, []byte("final"), plan)
+}
+
+func TestAt_RegenerateAndNested(t *testing.T) {
+	svc := inmemory.NewSessionService()
+	defer svc.Close()
+	ctx := context.Background()
+	events := seed(t, svc)
+
+	// Forking at an answer forks at the user message before it.
+	res, err := At(ctx, svc, testKey, events[3].ID, WithSessionID("s2"),
+		WithTrackCutoff(events[1].Timestamp))
+	require.NoError(t, err)
+	assert.Equal(t, events[2].ID, res.Info.EventID)
+	assert.NotContains(t, res.Session.Tracks, session.Track("audit"))
+
+	nested, err := At(ctx, svc, session.Key{AppName: "app", UserID: "u1", SessionID: "s2"},
+		events[0].ID, WithSessionID("s3"))
+	require.NoError(t, err)
+	assert.Equal(t, "s2", nested.Info.ParentSessionID)
+	assert.Equal(t, "s1", nested.Info.RootSessionID)
+	assert.Empty(t, nested.Session.Events)
+	_, ok := nested.Session.GetState("step")
+	assert.False(t, ok)
+
+	_, err = svc.CreateSession(ctx, session.Key{AppName: "app", UserID: "u1", SessionID: "other"}, nil)
+	require.NoError(t, err)
+	branches, err := List(ctx, svc, testUserKey, "s3")
+	require.NoError(t, err)
+	require.Len(t, branches, 3)
+	assert.Equal(t, "s1", branches[0].SessionID)
+	assert.Nil(t, branches[0].Info)
+	assert.Equal(t, "s2", branches[1].SessionID)
+	assert.Equal(t, "s3", branches[2].SessionID)
+	assert.Equal(t, "s2", branches[2].Info.ParentSessionID)
+}
+
+func TestAt_Errors(t *testing.T) {
+	svc := inmemory.NewSessionService()
+	defer svc.Close()
+	ctx := context.Background()
+	events := seed(t, svc)
+
+	_, err := At(ctx, svc, session.Key{AppName: "app", UserID: "u1", SessionID: "missing"}, events[0].ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = At(ctx, svc, testKey, "missing")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	_, err = At(ctx, svc, testKey, events[0].ID, WithSessionID("s1"))
+	assert.ErrorIs(t, err, ErrSessionExists)
+	_, err = At(ctx, svc, session.Key{}, events[0].ID)
+	assert.Error(t, err)
+}
@@ -14,6 +14,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,7 @@ var (
 	_ session.Service       = (*SessionService)(nil)
 	_ session.TrackService  = (*SessionService)(nil)
 	_ session.WindowService = (*SessionService)(nil)
+	_ session.EventTrimmer  = (*SessionService)(nil)
 
 	_ session.StateInitializationService = (*SessionService)(nil)
 )
@@ -499,6 +501,32 @@ func (s *SessionService) ListSessions(
 	return sessionopt.ApplyListPage(sessList, opt), nil
 }
 
+// ListUserIDs returns the users with at least one unexpired session in
+// appName, in ascending order.
+func (s *SessionService) ListUserIDs(ctx context.Context, appName string) ([]string, error) {
+	if appName == "" {
+		return nil, session.ErrAppNameRequired
+	}
+	app, ok := s.getAppSessions(appName)
+	if !ok {
+		return []string{}, nil
+	}
+
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	userIDs := make([]string, 0, len(app.sessions))
+	for userID, sessions := range app.sessions {
+		for _, sWithTTL := range sessions {
+			if getValidSession(sWithTTL) != nil {
+				userIDs = append(userIDs, userID)
+				break
+			}
+		}
+	}
+	slices.Sort(userIDs)
+	return userIDs, nil
+}
+
 // DeleteSession removes a session from storage.
 func (s *SessionService) DeleteSession(
 	ctx context.Context,
@@ -535,6 +563,35 @@ func (s *SessionService) DeleteSession(
 	return nil
 }
 
+// TrimEvents deletes all but the newest keep events of a session.
+func (s *SessionService) TrimEvents(ctx context.Context, key session.Key, keep int) (int, error) {
+	if err := key.CheckSessionKey(); err != nil {
+		return 0, err
+	}
+	if keep < 0 {
+		return 0, fmt.Errorf("keep must not be negative: %d", keep)
+	}
+	app, ok := s.getAppSessions(key.AppName)
+	if !ok {
+		return 0, nil
+	}
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	sess := getValidSession(app.sessions[key.UserID][key.SessionID])
+	if sess == nil {
+		return 0, nil
+	}
+	sess.EventMu.Lock()
+	defer sess.EventMu.Unlock()
+	trimmed := len(sess.Events) - keep
+	if trimmed <= 0 {
+		return 0, nil
+	}
+	sess.Events = append([]event.Event(nil), sess.Events[trimmed:]...)
+	return trimmed, nil
+}
+
 // UpdateAppState updates the app state.
 func (s *SessionService) UpdateAppState(ctx context.Context, appName string, state session.StateMap) error {
 	if appName == "" {
@@ -2000,3 +2000,62 @@ func TestSessionService_EventPageValidation(t *testing.T) {
 	assert.ErrorIs(t, err, session.ErrEventPageOnlyForGetSession)
 	assert.Nil(t, sessions)
 }
+
+func TestListUserIDs(t *testing.T) {
+	s := NewSessionService()
+	defer s.Close()
+
+	ctx := context.Background()
+	for _, key := range []session.Key{
+		{AppName: "app", UserID: "bob", SessionID: "s1"},
+		{AppName: "app", UserID: "alice", SessionID: "s1"},
+		{AppName: "app", UserID: "alice", SessionID: "s2"},
+		{AppName: "other", UserID: "carol", SessionID: "s1"},
+	} {
+		_, err := s.CreateSession(ctx, key, nil)
+		require.NoError(t, err)
+	}
+
+	userIDs, err := s.ListUserIDs(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, userIDs)
+
+	userIDs, err = s.ListUserIDs(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+
+	_, err = s.ListUserIDs(ctx, "")
+	assert.ErrorIs(t, err, session.ErrAppNameRequired)
+}
+
+func TestTrimEvents(t *testing.T) {
+	s := NewSessionService()
+	defer s.Close()
+
+	ctx := context.Background()
+	key := session.Key{AppName: "app", UserID: "user", SessionID: "s1"}
+	sess, err := s.CreateSession(ctx, key, nil)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		msg := model.NewUserMessage(fmt.Sprintf("message %d", i))
+		e := event.NewResponseEvent("inv", "user", &model.Response{Choices: []model.Choice{{Message: msg}}})
+		require.NoError(t, s.AppendEvent(ctx, sess, e))
+	}
+
+	trimmed, err := s.TrimEvents(ctx, key, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, trimmed)
+	got, err := s.GetSession(ctx, key)
+	require.NoError(t, err)
+	require.Len(t, got.Events, 2)
+	assert.Equal(t, "message 3", got.Events[0].Response.Choices[0].Message.Content)
+
+	trimmed, err = s.TrimEvents(ctx, key, 2)
+	require.NoError(t, err)
+	assert.Zero(t, trimmed)
+	trimmed, err = s.TrimEvents(ctx, session.Key{AppName: "app", UserID: "user", SessionID: "missing"}, 0)
+	require.NoError(t, err)
+	assert.Zero(t, trimmed)
+	_, err = s.TrimEvents(ctx, key, -1)
+	assert.Error(t, err)
+}
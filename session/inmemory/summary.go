@@ -62,6 +62,26 @@ func (s *SessionService) CreateSessionSummary(ctx context.Context, sess *session
 	return s.writeSummaryUnderLock(app, key, filterKey, sum)
 }
 
+// PutSessionSummary stores a summary as is for an existing session.
+func (s *SessionService) PutSessionSummary(
+	ctx context.Context,
+	key session.Key,
+	filterKey string,
+	sum *session.Summary,
+) error {
+	if err := key.CheckSessionKey(); err != nil {
+		return fmt.Errorf("check session key failed: %w", err)
+	}
+	if sum == nil {
+		return fmt.Errorf("summary is nil")
+	}
+	app, ok := s.getAppSessions(key.AppName)
+	if !ok {
+		return fmt.Errorf("session not found: %s", key.SessionID)
+	}
+	return s.writeSummaryUnderLock(app, key, filterKey, sum)
+}
+
 // writeSummaryUnderLock writes a summary for a filterKey under app lock and refreshes TTL.
 // When filterKey is "", it represents the full-session summary.
 func (s *SessionService) writeSummaryUnderLock(app *appSessions, key session.Key, filterKey string, sum *session.Summary) error {
@@ -1088,3 +1088,23 @@ func TestMemoryService_EnqueueSummaryJob_DynamicSummarizerUsesContext(t *testing
 		return ok && text == "dynamic-trace-dynamic-async"
 	}, 2*time.Second, 50*time.Millisecond)
 }
+
+func TestMemoryService_PutSessionSummary(t *testing.T) {
+	s := NewSessionService()
+	defer s.Close()
+
+	ctx := context.Background()
+	key := session.Key{AppName: "app", UserID: "u", SessionID: "sid"}
+	err := s.PutSessionSummary(ctx, key, "", &session.Summary{Summary: "imported"})
+	require.Error(t, err)
+
+	_, err = s.CreateSession(ctx, key, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.PutSessionSummary(ctx, key, "branch", &session.Summary{Summary: "imported", UpdatedAt: time.Now()}))
+
+	sess, err := s.GetSession(ctx, key)
+	require.NoError(t, err)
+	text, ok := s.GetSessionSummaryText(ctx, sess, session.WithSummaryFilterKey("branch"))
+	require.True(t, ok)
+	require.Equal(t, "imported", text)
+}
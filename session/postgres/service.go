@@ -32,6 +32,9 @@ import (
 
 var _ session.Service = (*Service)(nil)
 var _ session.TrackService = (*Service)(nil)
+var _ session.SummaryWriter = (*Service)(nil)
+var _ session.UserLister = (*Service)(nil)
+var _ session.EventTrimmer = (*Service)(nil)
 
 var errSessionNotFound = errors.New("session not found")
 
@@ -383,6 +386,70 @@ func (s *Service) ListSessions(
 	return sessList, nil
 }
 
+// ListUserIDs returns the users with at least one unexpired session in
+// appName, in ascending order.
+func (s *Service) ListUserIDs(ctx context.Context, appName string) ([]string, error) {
+	if appName == "" {
+		return nil, session.ErrAppNameRequired
+	}
+	userIDs := []string{}
+	err := s.pgClient.Query(ctx, func(rows *sql.Rows) error {
+		for rows.Next() {
+			var userID string
+			if err := rows.Scan(&userID); err != nil {
+				return err
+			}
+			userIDs = append(userIDs, userID)
+		}
+		return nil
+	}, fmt.Sprintf(`SELECT DISTINCT user_id FROM %s
+		WHERE app_name = $1
+		AND (expires_at IS NULL OR expires_at > $2)
+		AND deleted_at IS NULL
+		ORDER BY user_id`, s.tableSessionStates),
+		appName, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("list user ids failed: %w", err)
+	}
+	return userIDs, nil
+}
+
+// TrimEvents deletes all but the newest keep events of a session. Events are
+// soft deleted when soft delete is enabled.
+func (s *Service) TrimEvents(ctx context.Context, key session.Key, keep int) (int, error) {
+	if err := key.CheckSessionKey(); err != nil {
+		return 0, err
+	}
+	if keep < 0 {
+		return 0, fmt.Errorf("keep must not be negative: %d", keep)
+	}
+	older := fmt.Sprintf(`SELECT id FROM %s
+		WHERE app_name = $1 AND user_id = $2 AND session_id = $3 AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		OFFSET $4`, s.tableSessionEvents)
+	var (
+		res sql.Result
+		err error
+	)
+	if s.opts.softDelete {
+		res, err = s.pgClient.ExecContext(ctx,
+			fmt.Sprintf(`UPDATE %s SET deleted_at = $5 WHERE id IN (%s)`, s.tableSessionEvents, older),
+			key.AppName, key.UserID, key.SessionID, keep, time.Now())
+	} else {
+		res, err = s.pgClient.ExecContext(ctx,
+			fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, s.tableSessionEvents, older),
+			key.AppName, key.UserID, key.SessionID, keep)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("trim events failed: %w", err)
+	}
+	trimmed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("trim events failed: %w", err)
+	}
+	return int(trimmed), nil
+}
+
 // DeleteSession deletes a session.
 func (s *Service) DeleteSession(
 	ctx context.Context,
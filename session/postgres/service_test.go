@@ -802,6 +802,50 @@ func TestGetSession_InvalidKey(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestListUserIDs(t *testing.T) {
+	s, mock, db := setupMockService(t, nil)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT DISTINCT user_id FROM session_states").
+		WithArgs("test-app", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("alice").AddRow("bob"))
+
+	userIDs, err := s.ListUserIDs(context.Background(), "test-app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, userIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.ListUserIDs(context.Background(), "")
+	assert.ErrorIs(t, err, session.ErrAppNameRequired)
+}
+
+func TestTrimEvents(t *testing.T) {
+	key := session.Key{AppName: "test-app", UserID: "alice", SessionID: "s1"}
+
+	s, mock, db := setupMockService(t, nil)
+	defer db.Close()
+	mock.ExpectExec("UPDATE session_events SET deleted_at = .* WHERE id IN \\(SELECT id FROM session_events").
+		WithArgs("test-app", "alice", "s1", 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	trimmed, err := s.TrimEvents(context.Background(), key, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, trimmed)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	s, mock, db = setupMockService(t, &TestServiceOpts{softDelete: boolPtr(false)})
+	defer db.Close()
+	mock.ExpectExec("DELETE FROM session_events WHERE id IN \\(SELECT id FROM session_events").
+		WithArgs("test-app", "alice", "s1", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	trimmed, err = s.TrimEvents(context.Background(), key, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, trimmed)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.TrimEvents(context.Background(), key, -1)
+	assert.Error(t, err)
+}
+
 func TestListSessions_InvalidKey(t *testing.T) {
 	s, _, db := setupMockService(t, nil)
 	defer db.Close()
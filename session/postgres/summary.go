@@ -60,6 +60,27 @@ func (s *Service) CreateSessionSummary(
 		return nil
 	}
 
+	return s.upsertSummary(ctx, key, filterKey, sum)
+}
+
+// PutSessionSummary stores a summary as is, replacing the summary stored
+// under the same filter key.
+func (s *Service) PutSessionSummary(
+	ctx context.Context,
+	key session.Key,
+	filterKey string,
+	sum *session.Summary,
+) error {
+	if err := key.CheckSessionKey(); err != nil {
+		return fmt.Errorf("check session key failed: %w", err)
+	}
+	if sum == nil {
+		return fmt.Errorf("summary is nil")
+	}
+	return s.upsertSummary(ctx, key, filterKey, sum)
+}
+
+func (s *Service) upsertSummary(ctx context.Context, key session.Key, filterKey string, sum *session.Summary) error {
 	summaryBytes, err := json.Marshal(sum)
 	if err != nil {
 		return fmt.Errorf("marshal summary failed: %w", err)
@@ -78,7 +99,7 @@ func (s *Service) CreateSessionSummary(
 		   summary = EXCLUDED.summary,
 		   updated_at = EXCLUDED.updated_at,
 		   expires_at = EXCLUDED.expires_at`, s.tableSessionSummaries),
-		sess.AppName, sess.UserID, sess.ID, filterKey, summaryBytes, sum.UpdatedAt, nil)
+		key.AppName, key.UserID, key.SessionID, filterKey, summaryBytes, sum.UpdatedAt, nil)
 
 	if err != nil {
 		return fmt.Errorf("upsert summary failed: %w", err)
@@ -1023,3 +1023,21 @@ func TestEnqueueSummaryJob_AsyncProcessing(t *testing.T) {
 	// Wait for async processing
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestPutSessionSummary(t *testing.T) {
+	s, mock, db := setupMockService(t, nil)
+	defer db.Close()
+
+	key := session.Key{AppName: "test-app", UserID: "test-user", SessionID: "test-session"}
+	mock.ExpectExec("INSERT INTO session_summaries").
+		WithArgs("test-app", "test-user", "test-session", "branch", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := s.PutSessionSummary(context.Background(), key, "branch",
+		&session.Summary{Summary: "imported", UpdatedAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Error(t, s.PutSessionSummary(context.Background(), session.Key{}, "", &session.Summary{}))
+	require.Error(t, s.PutSessionSummary(context.Background(), key, "", nil))
+}
@@ -20,6 +20,8 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/session/redis/internal/util"
 )
 
+var _ session.SummaryWriter = (*Service)(nil)
+
 // CreateSessionSummary generates a summary for the session (async-ready).
 // It performs per-filterKey delta summarization; when filterKey=="", it means full-session summary.
 // Strategy: Summary storage version follows session storage version.
@@ -89,6 +91,36 @@ func (s *Service) CreateSessionSummary(ctx context.Context, sess *session.Sessio
 	return nil
 }
 
+// PutSessionSummary stores a summary as is for an existing session. A newer
+// summary already stored under the same filter key is kept.
+func (s *Service) PutSessionSummary(
+	ctx context.Context,
+	key session.Key,
+	filterKey string,
+	sum *session.Summary,
+) error {
+	if err := key.CheckSessionKey(); err != nil {
+		return fmt.Errorf("check session key failed: %w", err)
+	}
+	if sum == nil {
+		return fmt.Errorf("summary is nil")
+	}
+	zsetExists, hashidxExists, err := s.checkSessionExists(ctx, key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case s.compatEnabled() && zsetExists:
+		s.recordStorageRoute(ctx, opCreateSessionSummary, util.StorageTypeZset)
+		return s.zsetClient.CreateSummary(ctx, key, filterKey, sum, s.opts.sessionTTL)
+	case hashidxExists:
+		s.recordStorageRoute(ctx, opCreateSessionSummary, util.StorageTypeHashIdx)
+		return s.hashidxClient.CreateSummary(ctx, key, filterKey, sum, s.opts.sessionTTL)
+	default:
+		return fmt.Errorf("session not found: %s/%s/%s", key.AppName, key.UserID, key.SessionID)
+	}
+}
+
 // GetSessionSummaryText returns the latest summary text from the session state if present.
 // When no options are provided, returns the full-session summary (SummaryFilterKeyAllContents).
 // Use session.WithSummaryFilterKey to specify a different filter key.
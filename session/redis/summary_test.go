@@ -1103,3 +1103,28 @@ func TestRedisService_GetSessionSummaryText_ZSetError(t *testing.T) {
 	_, ok := s.GetSessionSummaryText(context.Background(), sess)
 	require.False(t, ok, "should return false when zset GetSummary fails")
 }
+
+func TestRedisService_PutSessionSummary(t *testing.T) {
+	redisURL, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	s, err := NewService(WithRedisClientURL(redisURL))
+	require.NoError(t, err)
+	defer s.Close()
+
+	ctx := context.Background()
+	key := session.Key{AppName: "app", UserID: "u", SessionID: "sid"}
+	err = s.PutSessionSummary(ctx, key, "", &session.Summary{Summary: "imported"})
+	require.ErrorContains(t, err, "session not found")
+
+	_, err = s.CreateSession(ctx, key, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.PutSessionSummary(ctx, key, "branch",
+		&session.Summary{Summary: "imported", UpdatedAt: time.Now()}))
+
+	sess, err := s.GetSession(ctx, key)
+	require.NoError(t, err)
+	text, ok := s.GetSessionSummaryText(ctx, sess, session.WithSummaryFilterKey("branch"))
+	require.True(t, ok)
+	require.Equal(t, "imported", text)
+}
@@ -1043,6 +1043,33 @@ type WindowService interface {
 	) (*EventWindow, error)
 }
 
+// SummaryWriter extends session.Service with storing a summary as is,
+// without running the summarizer. Session import uses it to carry summaries
+// over from another backend.
+type SummaryWriter interface {
+	// PutSessionSummary stores summary under filterKey for an existing
+	// session, replacing any summary stored under the same key.
+	PutSessionSummary(ctx context.Context, key Key, filterKey string, summary *Summary) error
+}
+
+// UserLister extends session.Service with listing the users that own
+// sessions in an app. Backends that cannot enumerate users efficiently do
+// not implement it.
+type UserLister interface {
+	// ListUserIDs returns the IDs of the users with at least one session in
+	// appName, in ascending order.
+	ListUserIDs(ctx context.Context, appName string) ([]string, error)
+}
+
+// EventTrimmer extends session.Service with dropping the oldest events of a
+// session, for event retention.
+type EventTrimmer interface {
+	// TrimEvents deletes all but the newest keep events of a session and
+	// returns the number of deleted events. It is a no-op for missing
+	// sessions.
+	TrimEvents(ctx context.Context, key Key, keep int) (int, error)
+}
+
 // StateInitializationProjection derives one related session-state value that
 // must be committed atomically with a newly initialized primary value.
 // Projections are evaluated before the commit only for a valid initialize
@@ -0,0 +1,281 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// Export writes the app state, user states and sessions of appName in svc
+// to w, and returns what it wrote.
+//
+// Sessions are read one at a time, so memory use is bounded by the largest
+// session. Events are read in pages with session.WithGetSessionEventPage on
+// backends that support it. Other backends return the events their
+// GetSession returns, so configure them without an event limit for exports.
+func Export(ctx context.Context, svc session.Service, w io.Writer, appName string, opts ...Option) (Stats, error) {
+	if svc == nil {
+		return Stats{}, errors.New("transfer: session service is nil")
+	}
+	if appName == "" {
+		return Stats{}, session.ErrAppNameRequired
+	}
+	o := newOptions(opts...)
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	emit := func(rec *Record) error {
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("transfer: write %s record: %w", rec.Type, err)
+		}
+		return nil
+	}
+
+	exportedAt := o.now().UTC()
+	if err := emit(&Record{Type: RecordHeader, Version: Version, AppName: appName, ExportedAt: &exportedAt}); err != nil {
+		return Stats{}, err
+	}
+	appState, err := svc.ListAppStates(ctx, appName)
+	if err != nil {
+		return Stats{}, fmt.Errorf("transfer: list app states: %w", err)
+	}
+	if len(appState) > 0 {
+		if err := emit(&Record{Type: RecordAppState, AppName: appName, State: appState}); err != nil {
+			return Stats{}, err
+		}
+	}
+
+	userIDs, err := listUserIDs(ctx, svc, appName, o)
+	if err != nil {
+		return Stats{}, err
+	}
+	var total Stats
+	for _, userID := range userIDs {
+		userKey := session.UserKey{AppName: appName, UserID: userID}
+		if err := exportUserState(ctx, svc, userKey, emit); err != nil {
+			return total, err
+		}
+		total.Users++
+		sessionIDs, err := listSessionIDs(ctx, svc, userKey, o.sessionPageSize)
+		if err != nil {
+			return total, err
+		}
+		for _, sessionID := range sessionIDs {
+			key := session.Key{AppName: appName, UserID: userID, SessionID: sessionID}
+			sess, err := loadSession(ctx, svc, key, o.eventPageSize)
+			if err != nil {
+				return total, err
+			}
+			if sess == nil {
+				// Deleted or expired since it was listed.
+				continue
+			}
+			stats, err := sessionRecords(sess, emit)
+			if err != nil {
+				return total, err
+			}
+			total.add(stats)
+		}
+	}
+
+	if err := emit(&Record{Type: RecordFooter, Stats: &total}); err != nil {
+		return total, err
+	}
+	if err := bw.Flush(); err != nil {
+		return total, fmt.Errorf("transfer: flush export: %w", err)
+	}
+	return total, nil
+}
+
+func exportUserState(ctx context.Context, svc session.Service, userKey session.UserKey, emit func(*Record) error) error {
+	state, err := svc.ListUserStates(ctx, userKey)
+	if err != nil {
+		return fmt.Errorf("transfer: list user states of %s: %w", userKey.UserID, err)
+	}
+	return emit(&Record{Type: RecordUserState, AppName: userKey.AppName, UserID: userKey.UserID, State: state})
+}
+
+// listUserIDs returns the users to transfer in ascending order.
+func listUserIDs(ctx context.Context, svc session.Service, appName string, o *options) ([]string, error) {
+	var userIDs []string
+	if len(o.userIDs) > 0 {
+		userIDs = slices.Clone(o.userIDs)
+	} else {
+		lister, ok := svc.(session.UserLister)
+		if !ok {
+			return nil, ErrUsersRequired
+		}
+		var err error
+		if userIDs, err = lister.ListUserIDs(ctx, appName); err != nil {
+			return nil, fmt.Errorf("transfer: list users: %w", err)
+		}
+	}
+	slices.Sort(userIDs)
+	return slices.Compact(userIDs), nil
+}
+
+// listSessionIDs returns the session IDs of a user in ascending order. All
+// IDs are listed before any session is read, so that sessions updated while
+// they are transferred do not shift the pages.
+func listSessionIDs(ctx context.Context, svc session.Service, userKey session.UserKey, pageSize int) ([]string, error) {
+	var ids []string
+	for offset := 0; ; offset += pageSize {
+		page, err := svc.ListSessions(ctx, userKey,
+			session.WithListSessionOnlyMeta(), session.WithListSessionPage(offset, pageSize))
+		if err != nil {
+			return nil, fmt.Errorf("transfer: list sessions of %s: %w", userKey.UserID, err)
+		}
+		for _, sess := range page {
+			if sess != nil {
+				ids = append(ids, sess.ID)
+			}
+		}
+		// A backend that ignores paging returns everything at once.
+		if len(page) != pageSize {
+			break
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// loadSession reads a session with all its events, or nil if it does not
+// exist.
+func loadSession(ctx context.Context, svc session.Service, key session.Key, pageSize int) (*session.Session, error) {
+	sess, err := svc.GetSession(ctx, key, session.WithGetSessionEventPage(0, pageSize))
+	if errors.Is(err, session.ErrEventPageUnsupported) {
+		sess, err = svc.GetSession(ctx, key)
+	} else if err == nil && sess != nil {
+		// Pages count back from the most recent event.
+		for page := sess.Events; len(page) == pageSize; {
+			older, err := svc.GetSession(ctx, key, session.WithGetSessionEventPage(len(sess.Events), pageSize))
+			if err != nil {
+				return nil, fmt.Errorf("transfer: get session %s events: %w", key.SessionID, err)
+			}
+			if older == nil {
+				return nil, nil
+			}
+			page = older.Events
+			sess.Events = append(slices.Clip(page), sess.Events...)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transfer: get session %s: %w", key.SessionID, err)
+	}
+	return sess, nil
+}
+
+// sessionRecords emits the records of sess, from the session record to the
+// session_end record, and returns the counts of the session.
+func sessionRecords(sess *session.Session, emit func(*Record) error) (Stats, error) {
+	stats := Stats{Sessions: 1}
+	base := Record{AppName: sess.AppName, UserID: sess.UserID, SessionID: sess.ID}
+	record := func(typ RecordType) *Record {
+		rec := base
+		rec.Type = typ
+		return &rec
+	}
+
+	rec := record(RecordSession)
+	rec.State = sessionState(sess)
+	createdAt, updatedAt := sess.CreatedAt, sess.UpdatedAt
+	rec.CreatedAt, rec.UpdatedAt = &createdAt, &updatedAt
+	if err := emit(rec); err != nil {
+		return stats, err
+	}
+
+	sess.EventMu.RLock()
+	events := slices.Clone(sess.Events)
+	sess.EventMu.RUnlock()
+	for i := range events {
+		rec := record(RecordEvent)
+		rec.Event = &events[i]
+		if err := emit(rec); err != nil {
+			return stats, err
+		}
+		stats.Events++
+	}
+
+	sess.SummariesMu.RLock()
+	summaries := make(map[string]*session.Summary, len(sess.Summaries))
+	for filterKey, sum := range sess.Summaries {
+		if sum != nil {
+			summaries[filterKey] = sum.Clone()
+		}
+	}
+	sess.SummariesMu.RUnlock()
+	for _, filterKey := range sortedKeys(summaries) {
+		rec := record(RecordSummary)
+		rec.FilterKey = filterKey
+		rec.Summary = summaries[filterKey]
+		if err := emit(rec); err != nil {
+			return stats, err
+		}
+		stats.Summaries++
+	}
+
+	sess.TracksMu.RLock()
+	tracks := make(map[session.Track][]session.TrackEvent, len(sess.Tracks))
+	for track, events := range sess.Tracks {
+		if events != nil {
+			tracks[track] = slices.Clone(events.Events)
+		}
+	}
+	sess.TracksMu.RUnlock()
+	for _, track := range sortedKeys(tracks) {
+		for i := range tracks[track] {
+			rec := record(RecordTrackEvent)
+			rec.TrackEvent = &tracks[track][i]
+			if rec.TrackEvent.Track == "" {
+				rec.TrackEvent.Track = track
+			}
+			if err := emit(rec); err != nil {
+				return stats, err
+			}
+			stats.TrackEvents++
+		}
+	}
+
+	end := record(RecordSessionEnd)
+	sessionStats := stats
+	end.Stats = &sessionStats
+	return stats, emit(end)
+}
+
+// sessionState returns the session-scoped state of sess. GetSession merges
+// app and user state into it under prefixed keys, those are exported with
+// their own records.
+func sessionState(sess *session.Session) session.StateMap {
+	state := sess.SnapshotState()
+	for k := range state {
+		if strings.HasPrefix(k, session.StateAppPrefix) || strings.HasPrefix(k, session.StateUserPrefix) {
+			delete(state, k)
+		}
+	}
+	return state
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,267 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// Import reads an export written by Export from r into svc, and returns what
+// it imported.
+//
+// Sessions are created with the state they had when exported and their
+// events are appended in order, so their creation and update times are those
+// of the import. Summaries are imported when svc implements
+// session.SummaryWriter and track events when it implements
+// session.TrackService, otherwise they are counted as skipped.
+//
+// Import fails with ErrCorrupted when the export is truncated or its counts
+// do not match its records. Sessions imported before the failure are kept;
+// importing again with ConflictReplace completes the import.
+func Import(ctx context.Context, svc session.Service, r io.Reader, opts ...Option) (Stats, error) {
+	if svc == nil {
+		return Stats{}, errors.New("transfer: session service is nil")
+	}
+	imp := newImporter(svc, newOptions(opts...))
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return imp.stats, fmt.Errorf("%w: record %d: %v", ErrCorrupted, line, err)
+		}
+		if line == 1 {
+			if rec.Type != RecordHeader {
+				return imp.stats, fmt.Errorf("%w: missing header", ErrCorrupted)
+			}
+			if rec.Version < 1 || rec.Version > Version {
+				return imp.stats, fmt.Errorf("%w: %d", ErrUnsupportedVersion, rec.Version)
+			}
+			continue
+		}
+		if err := imp.apply(ctx, &rec); err != nil {
+			return imp.stats, err
+		}
+		if rec.Type == RecordFooter {
+			if err := imp.checkFooter(rec.Stats); err != nil {
+				return imp.stats, err
+			}
+			return imp.stats, nil
+		}
+	}
+	return imp.stats, fmt.Errorf("%w: missing footer", ErrCorrupted)
+}
+
+// importer writes records into a session service. It is shared by Import,
+// which reads the records from an export, and the Migrator, which reads them
+// from the source service.
+type importer struct {
+	svc  session.Service
+	opts *options
+
+	// stats counts what was imported, read counts the records seen.
+	stats Stats
+	read  Stats
+
+	// cur is the session being imported, nil between sessions.
+	cur      *session.Session
+	curState session.StateMap
+	skip     bool
+	// curStats counts what was imported of cur and curRead its records.
+	curStats Stats
+	curRead  Stats
+}
+
+func newImporter(svc session.Service, opts *options) *importer {
+	return &importer{svc: svc, opts: opts}
+}
+
+func (imp *importer) apply(ctx context.Context, rec *Record) error {
+	switch rec.Type {
+	case RecordAppState:
+		if imp.cur != nil {
+			return imp.outOfOrder(rec)
+		}
+		if len(rec.State) == 0 {
+			return nil
+		}
+		if err := imp.svc.UpdateAppState(ctx, rec.AppName, rec.State); err != nil {
+			return fmt.Errorf("transfer: update app state: %w", err)
+		}
+	case RecordUserState:
+		if imp.cur != nil {
+			return imp.outOfOrder(rec)
+		}
+		imp.read.Users++
+		imp.stats.Users++
+		if len(rec.State) == 0 {
+			return nil
+		}
+		userKey := session.UserKey{AppName: rec.AppName, UserID: rec.UserID}
+		if err := imp.svc.UpdateUserState(ctx, userKey, rec.State); err != nil {
+			return fmt.Errorf("transfer: update user state of %s: %w", rec.UserID, err)
+		}
+	case RecordSession:
+		if imp.cur != nil {
+			return imp.outOfOrder(rec)
+		}
+		return imp.beginSession(ctx, rec)
+	case RecordEvent, RecordSummary, RecordTrackEvent, RecordSessionEnd:
+		if imp.cur == nil || imp.cur.AppName != rec.AppName || imp.cur.UserID != rec.UserID ||
+			imp.cur.ID != rec.SessionID {
+			return imp.outOfOrder(rec)
+		}
+		return imp.applySessionRecord(ctx, rec)
+	case RecordFooter:
+		if imp.cur != nil {
+			return imp.outOfOrder(rec)
+		}
+	default:
+		return fmt.Errorf("%w: unexpected %q record", ErrCorrupted, rec.Type)
+	}
+	return nil
+}
+
+func (imp *importer) outOfOrder(rec *Record) error {
+	if imp.cur != nil {
+		return fmt.Errorf("%w: %s record inside session %s", ErrCorrupted, rec.Type, imp.cur.ID)
+	}
+	return fmt.Errorf("%w: %s record outside a session", ErrCorrupted, rec.Type)
+}
+
+func (imp *importer) beginSession(ctx context.Context, rec *Record) error {
+	key := session.Key{AppName: rec.AppName, UserID: rec.UserID, SessionID: rec.SessionID}
+	if err := key.CheckSessionKey(); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	imp.curState = rec.State
+	imp.curStats, imp.curRead = Stats{}, Stats{}
+	imp.skip = false
+
+	existing, err := imp.svc.GetSession(ctx, key, session.WithEventNum(1))
+	if err != nil {
+		return fmt.Errorf("transfer: get session %s: %w", key.SessionID, err)
+	}
+	if existing != nil {
+		switch imp.opts.conflict {
+		case ConflictSkip:
+			imp.skip = true
+			imp.cur = existing
+			return nil
+		case ConflictReplace:
+			if err := imp.svc.DeleteSession(ctx, key); err != nil {
+				return fmt.Errorf("transfer: delete session %s: %w", key.SessionID, err)
+			}
+		default:
+			return fmt.Errorf("%w: %s/%s/%s", ErrSessionExists, key.AppName, key.UserID, key.SessionID)
+		}
+	}
+	sess, err := imp.svc.CreateSession(ctx, key, rec.State)
+	if err != nil {
+		return fmt.Errorf("transfer: create session %s: %w", key.SessionID, err)
+	}
+	imp.cur = sess
+	return nil
+}
+
+func (imp *importer) applySessionRecord(ctx context.Context, rec *Record) error {
+	key := session.Key{AppName: rec.AppName, UserID: rec.UserID, SessionID: rec.SessionID}
+	switch rec.Type {
+	case RecordEvent:
+		imp.curRead.Events++
+		if imp.skip {
+			return nil
+		}
+		if rec.Event == nil {
+			return fmt.Errorf("%w: event record without event in session %s", ErrCorrupted, key.SessionID)
+		}
+		if err := imp.svc.AppendEvent(ctx, imp.cur, rec.Event); err != nil {
+			return fmt.Errorf("transfer: append event %s to session %s: %w", rec.Event.ID, key.SessionID, err)
+		}
+		imp.curStats.Events++
+	case RecordSummary:
+		imp.curRead.Summaries++
+		if imp.skip || rec.Summary == nil {
+			return nil
+		}
+		writer, ok := imp.svc.(session.SummaryWriter)
+		if !ok {
+			imp.curStats.SkippedSummaries++
+			return nil
+		}
+		sum := rec.Summary.Clone()
+		// Pin the cutoff before touching UpdatedAt: summaries older than the
+		// session are ignored, and the session was created just now.
+		sum.Boundary = sum.CutoffBoundary()
+		sum.UpdatedAt = imp.opts.now().UTC()
+		if err := writer.PutSessionSummary(ctx, key, rec.FilterKey, sum); err != nil {
+			return fmt.Errorf("transfer: put summary %q of session %s: %w", rec.FilterKey, key.SessionID, err)
+		}
+		imp.curStats.Summaries++
+	case RecordTrackEvent:
+		imp.curRead.TrackEvents++
+		if imp.skip || rec.TrackEvent == nil {
+			return nil
+		}
+		tracker, ok := imp.svc.(session.TrackService)
+		if !ok {
+			imp.curStats.SkippedTrackEvents++
+			return nil
+		}
+		if err := tracker.AppendTrackEvent(ctx, imp.cur, rec.TrackEvent); err != nil {
+			return fmt.Errorf("transfer: append %s track event to session %s: %w",
+				rec.TrackEvent.Track, key.SessionID, err)
+		}
+		imp.curStats.TrackEvents++
+	case RecordSessionEnd:
+		return imp.endSession(ctx, key, rec.Stats)
+	}
+	return nil
+}
+
+func (imp *importer) endSession(ctx context.Context, key session.Key, declared *Stats) error {
+	if declared == nil || declared.Events != imp.curRead.Events || declared.Summaries != imp.curRead.Summaries ||
+		declared.TrackEvents != imp.curRead.TrackEvents {
+		return fmt.Errorf("%w: session %s does not match its counts", ErrCorrupted, key.SessionID)
+	}
+	if !imp.skip && imp.curRead.Events > 0 && len(imp.curState) > 0 {
+		// Replayed state deltas can leave state the session changed later.
+		if err := imp.svc.UpdateSessionState(ctx, key, imp.curState); err != nil {
+			return fmt.Errorf("transfer: update session state of %s: %w", key.SessionID, err)
+		}
+	}
+	imp.read.Sessions++
+	imp.read.add(Stats{Events: imp.curRead.Events, Summaries: imp.curRead.Summaries,
+		TrackEvents: imp.curRead.TrackEvents})
+	if imp.skip {
+		imp.stats.SkippedSessions++
+	} else {
+		imp.curStats.Sessions = 1
+		imp.stats.add(imp.curStats)
+	}
+	imp.cur, imp.curState, imp.skip = nil, nil, false
+	return nil
+}
+
+func (imp *importer) checkFooter(declared *Stats) error {
+	if declared == nil || declared.Users != imp.read.Users || declared.Sessions != imp.read.Sessions ||
+		declared.Events != imp.read.Events || declared.Summaries != imp.read.Summaries ||
+		declared.TrackEvents != imp.read.TrackEvents {
+		return fmt.Errorf("%w: export does not match its footer", ErrCorrupted)
+	}
+	return nil
+}
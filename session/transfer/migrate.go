@@ -0,0 +1,331 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// Progress is the state of a migration saved in a Checkpoint. Users are
+// migrated in ascending order of ID and the sessions of a user in ascending
+// order of ID, so two cursors are enough to resume.
+type Progress struct {
+	AppName      string `json:"app_name"`
+	AppStateDone bool   `json:"app_state_done,omitempty"`
+	// LastUserID is the last user whose sessions were all copied.
+	LastUserID string `json:"last_user_id,omitempty"`
+	// UserID is the user being copied, and LastSessionID its last copied
+	// session.
+	UserID        string `json:"user_id,omitempty"`
+	LastSessionID string `json:"last_session_id,omitempty"`
+	Done          bool   `json:"done,omitempty"`
+	Report        Report `json:"report"`
+}
+
+// Checkpoint stores the progress of a Migrator.
+type Checkpoint interface {
+	// Load returns the saved progress, or nil if there is none.
+	Load(ctx context.Context) (*Progress, error)
+	// Save replaces the saved progress.
+	Save(ctx context.Context, p *Progress) error
+}
+
+// FileCheckpoint is a Checkpoint stored in a JSON file.
+type FileCheckpoint struct {
+	path string
+}
+
+var _ Checkpoint = (*FileCheckpoint)(nil)
+
+// NewFileCheckpoint creates a checkpoint stored at path.
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+// Load implements Checkpoint.
+func (c *FileCheckpoint) Load(context.Context) (*Progress, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transfer: read checkpoint: %w", err)
+	}
+	var p Progress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("transfer: decode checkpoint %s: %w", c.path, err)
+	}
+	return &p, nil
+}
+
+// Save implements Checkpoint. The file is replaced atomically, so a crash
+// while saving leaves the previous progress.
+func (c *FileCheckpoint) Save(_ context.Context, p *Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("transfer: encode checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("transfer: write checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("transfer: write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("transfer: write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("transfer: write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Report is the outcome of a migration, accumulated over resumed runs.
+type Report struct {
+	Stats Stats `json:"stats"`
+	// Mismatches lists the copied sessions whose counts in the destination
+	// differ from the source. Empty unless verification is enabled.
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+// Mismatch is a count that differs between the source and the destination
+// after a session was copied.
+type Mismatch struct {
+	Key session.Key `json:"key"`
+	// Kind is "events", "summaries" or "track_events".
+	Kind string `json:"kind"`
+	// Source is the count copied from the source.
+	Source      int `json:"source"`
+	Destination int `json:"destination"`
+}
+
+// Migrator copies the sessions of an app from one session.Service to
+// another, one session at a time.
+//
+// Sessions that already exist in the destination are replaced unless
+// WithConflict says otherwise, so that a session copied partly before a
+// failure is copied again when the migration resumes. With WithCheckpoint
+// the progress is saved after every session and Run resumes from it.
+//
+// Writes to the source during a migration may be missed; stop writers or
+// run the migration again for the users they touched.
+type Migrator struct {
+	src, dst session.Service
+	appName  string
+	opts     *options
+}
+
+// NewMigrator creates a Migrator copying the sessions of appName from src
+// to dst.
+func NewMigrator(src, dst session.Service, appName string, opts ...Option) (*Migrator, error) {
+	if src == nil || dst == nil {
+		return nil, errors.New("transfer: source and destination are required")
+	}
+	if appName == "" {
+		return nil, session.ErrAppNameRequired
+	}
+	opts = append([]Option{WithConflict(ConflictReplace)}, opts...)
+	return &Migrator{src: src, dst: dst, appName: appName, opts: newOptions(opts...)}, nil
+}
+
+// Run migrates the sessions and returns the report. After a failure, Run
+// can be called again with the same checkpoint to resume. A finished
+// checkpoint makes Run return its report without copying anything.
+func (m *Migrator) Run(ctx context.Context) (Report, error) {
+	progress, err := m.loadProgress(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	if progress.Done {
+		return progress.Report, nil
+	}
+	imp := newImporter(m.dst, m.opts)
+
+	if !progress.AppStateDone {
+		appState, err := m.src.ListAppStates(ctx, m.appName)
+		if err != nil {
+			return progress.Report, fmt.Errorf("transfer: list app states: %w", err)
+		}
+		if err := imp.apply(ctx, &Record{Type: RecordAppState, AppName: m.appName, State: appState}); err != nil {
+			return progress.Report, err
+		}
+		progress.AppStateDone = true
+		if err := m.save(ctx, progress); err != nil {
+			return progress.Report, err
+		}
+	}
+
+	userIDs, err := listUserIDs(ctx, m.src, m.appName, m.opts)
+	if err != nil {
+		return progress.Report, err
+	}
+	for _, userID := range userIDs {
+		if progress.LastUserID != "" && userID <= progress.LastUserID {
+			continue
+		}
+		if err := m.migrateUser(ctx, imp, progress, userID); err != nil {
+			return progress.Report, err
+		}
+	}
+	progress.Done = true
+	if err := m.save(ctx, progress); err != nil {
+		return progress.Report, err
+	}
+	return progress.Report, nil
+}
+
+func (m *Migrator) loadProgress(ctx context.Context) (*Progress, error) {
+	if m.opts.checkpoint == nil {
+		return &Progress{AppName: m.appName}, nil
+	}
+	progress, err := m.opts.checkpoint.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return &Progress{AppName: m.appName}, nil
+	}
+	if progress.AppName != m.appName {
+		return nil, fmt.Errorf("transfer: checkpoint is for app %q, not %q", progress.AppName, m.appName)
+	}
+	return progress, nil
+}
+
+func (m *Migrator) save(ctx context.Context, progress *Progress) error {
+	if m.opts.checkpoint == nil {
+		return nil
+	}
+	if err := m.opts.checkpoint.Save(ctx, progress); err != nil {
+		return fmt.Errorf("transfer: save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) migrateUser(ctx context.Context, imp *importer, progress *Progress, userID string) error {
+	userKey := session.UserKey{AppName: m.appName, UserID: userID}
+	var lastSessionID string
+	if progress.UserID == userID {
+		lastSessionID = progress.LastSessionID
+	} else {
+		userState, err := m.src.ListUserStates(ctx, userKey)
+		if err != nil {
+			return fmt.Errorf("transfer: list user states of %s: %w", userID, err)
+		}
+		before := imp.stats
+		if err := imp.apply(ctx, &Record{Type: RecordUserState, AppName: m.appName, UserID: userID,
+			State: userState}); err != nil {
+			return err
+		}
+		progress.Report.Stats.add(statsDiff(imp.stats, before))
+		progress.UserID, progress.LastSessionID = userID, ""
+		if err := m.save(ctx, progress); err != nil {
+			return err
+		}
+	}
+
+	sessionIDs, err := listSessionIDs(ctx, m.src, userKey, m.opts.sessionPageSize)
+	if err != nil {
+		return err
+	}
+	for _, sessionID := range sessionIDs {
+		if lastSessionID != "" && sessionID <= lastSessionID {
+			continue
+		}
+		if err := m.migrateSession(ctx, imp, progress, session.Key{
+			AppName: m.appName, UserID: userID, SessionID: sessionID,
+		}); err != nil {
+			return err
+		}
+	}
+	progress.LastUserID, progress.UserID, progress.LastSessionID = userID, "", ""
+	return m.save(ctx, progress)
+}
+
+func (m *Migrator) migrateSession(ctx context.Context, imp *importer, progress *Progress, key session.Key) error {
+	sess, err := loadSession(ctx, m.src, key, m.opts.eventPageSize)
+	if err != nil {
+		return err
+	}
+	if sess != nil {
+		before := imp.stats
+		if _, err := sessionRecords(sess, func(rec *Record) error { return imp.apply(ctx, rec) }); err != nil {
+			// Drop the partly copied session so the importer can start over.
+			imp.cur, imp.curState, imp.skip = nil, nil, false
+			return err
+		}
+		copied := statsDiff(imp.stats, before)
+		progress.Report.Stats.add(copied)
+		if m.opts.verify && copied.Sessions > 0 {
+			mismatches, err := m.verify(ctx, key, copied)
+			if err != nil {
+				return err
+			}
+			progress.Report.Mismatches = append(progress.Report.Mismatches, mismatches...)
+		}
+	}
+	progress.LastSessionID = key.SessionID
+	return m.save(ctx, progress)
+}
+
+// verify reads a copied session back from the destination and compares its
+// counts with what was copied.
+func (m *Migrator) verify(ctx context.Context, key session.Key, copied Stats) ([]Mismatch, error) {
+	sess, err := loadSession(ctx, m.dst, key, m.opts.eventPageSize)
+	if err != nil {
+		return nil, err
+	}
+	var got Stats
+	if sess != nil {
+		got.Events = len(sess.Events)
+		sess.SummariesMu.RLock()
+		got.Summaries = len(sess.Summaries)
+		sess.SummariesMu.RUnlock()
+		sess.TracksMu.RLock()
+		for _, events := range sess.Tracks {
+			if events != nil {
+				got.TrackEvents += len(events.Events)
+			}
+		}
+		sess.TracksMu.RUnlock()
+	}
+	var mismatches []Mismatch
+	check := func(kind string, want, have int) {
+		if want != have {
+			mismatches = append(mismatches, Mismatch{Key: key, Kind: kind, Source: want, Destination: have})
+		}
+	}
+	check("events", copied.Events, got.Events)
+	check("summaries", copied.Summaries, got.Summaries)
+	check("track_events", copied.TrackEvents, got.TrackEvents)
+	return mismatches, nil
+}
+
+func statsDiff(after, before Stats) Stats {
+	return Stats{
+		Users:              after.Users - before.Users,
+		Sessions:           after.Sessions - before.Sessions,
+		Events:             after.Events - before.Events,
+		Summaries:          after.Summaries - before.Summaries,
+		TrackEvents:        after.TrackEvents - before.TrackEvents,
+		SkippedSessions:    after.SkippedSessions - before.SkippedSessions,
+		SkippedSummaries:   after.SkippedSummaries - before.SkippedSummaries,
+		SkippedTrackEvents: after.SkippedTrackEvents - before.SkippedTrackEvents,
+	}
+}
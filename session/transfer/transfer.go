@@ -0,0 +1,231 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package transfer exports sessions to a portable JSONL format, imports them
+// into any session.Service and migrates the sessions of an app between two
+// services.
+//
+// An export is a stream of Records, one JSON object per line: a header, the
+// app state, then per user the user state followed by each session, and a
+// footer with the totals. A session is a session record, its events in
+// chronological order, its summaries and track events, and a session_end
+// record with its counts:
+//
+//	{"type":"header","version":1,"app_name":"app","exported_at":"..."}
+//	{"type":"app_state","app_name":"app","state":{...}}
+//	{"type":"user_state","app_name":"app","user_id":"u1","state":{...}}
+//	{"type":"session","app_name":"app","user_id":"u1","session_id":"s1","state":{...}}
+//	{"type":"event","app_name":"app","user_id":"u1","session_id":"s1","event":{...}}
+//	{"type":"summary","app_name":"app","user_id":"u1","session_id":"s1","filter_key":"","summary":{...}}
+//	{"type":"track_event","app_name":"app","user_id":"u1","session_id":"s1","track_event":{...}}
+//	{"type":"session_end","app_name":"app","user_id":"u1","session_id":"s1","stats":{...}}
+//	{"type":"footer","stats":{...}}
+//
+// State values are byte slices and are base64 encoded, as encoding/json
+// does.
+package transfer
+
+import (
+	"errors"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// Version is the export format version written by Export. Import accepts
+// exports up to this version.
+const Version = 1
+
+// RecordType is the type of an export record.
+type RecordType string
+
+// Record types of the export format.
+const (
+	RecordHeader     RecordType = "header"
+	RecordAppState   RecordType = "app_state"
+	RecordUserState  RecordType = "user_state"
+	RecordSession    RecordType = "session"
+	RecordEvent      RecordType = "event"
+	RecordSummary    RecordType = "summary"
+	RecordTrackEvent RecordType = "track_event"
+	RecordSessionEnd RecordType = "session_end"
+	RecordFooter     RecordType = "footer"
+)
+
+var (
+	// ErrUnsupportedVersion is returned when importing an export written by a
+	// newer format version.
+	ErrUnsupportedVersion = errors.New("transfer: unsupported export version")
+	// ErrCorrupted is returned when an export is truncated, out of order, or
+	// its records do not match the counts it declares.
+	ErrCorrupted = errors.New("transfer: corrupted export")
+	// ErrSessionExists is returned by Import with ConflictFail when a session
+	// already exists in the destination.
+	ErrSessionExists = errors.New("transfer: session already exists")
+	// ErrUsersRequired is returned when the users of an app cannot be listed
+	// because the service does not implement session.UserLister and no users
+	// were given with WithUserIDs.
+	ErrUsersRequired = errors.New("transfer: service cannot list users, use WithUserIDs")
+)
+
+// Record is one line of an export. Which fields are set depends on Type.
+type Record struct {
+	Type RecordType `json:"type"`
+	// Version and ExportedAt are set on the header.
+	Version    int        `json:"version,omitempty"`
+	ExportedAt *time.Time `json:"exported_at,omitempty"`
+
+	AppName   string `json:"app_name,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+
+	// State is set on app_state, user_state and session records. Keys are
+	// stored without the app: and user: prefixes.
+	State session.StateMap `json:"state,omitempty"`
+	// CreatedAt and UpdatedAt are set on session records.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
+	Event      *event.Event        `json:"event,omitempty"`
+	FilterKey  string              `json:"filter_key,omitempty"`
+	Summary    *session.Summary    `json:"summary,omitempty"`
+	TrackEvent *session.TrackEvent `json:"track_event,omitempty"`
+
+	// Stats is set on session_end and footer records.
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats counts transferred data.
+type Stats struct {
+	Users       int `json:"users,omitempty"`
+	Sessions    int `json:"sessions,omitempty"`
+	Events      int `json:"events,omitempty"`
+	Summaries   int `json:"summaries,omitempty"`
+	TrackEvents int `json:"track_events,omitempty"`
+	// SkippedSessions counts sessions that already existed in the
+	// destination and were kept, with ConflictSkip.
+	SkippedSessions int `json:"skipped_sessions,omitempty"`
+	// SkippedSummaries counts summaries the destination cannot store
+	// because it does not implement session.SummaryWriter.
+	SkippedSummaries int `json:"skipped_summaries,omitempty"`
+	// SkippedTrackEvents counts track events the destination cannot store
+	// because it does not implement session.TrackService.
+	SkippedTrackEvents int `json:"skipped_track_events,omitempty"`
+}
+
+func (s *Stats) add(o Stats) {
+	s.Users += o.Users
+	s.Sessions += o.Sessions
+	s.Events += o.Events
+	s.Summaries += o.Summaries
+	s.TrackEvents += o.TrackEvents
+	s.SkippedSessions += o.SkippedSessions
+	s.SkippedSummaries += o.SkippedSummaries
+	s.SkippedTrackEvents += o.SkippedTrackEvents
+}
+
+// Conflict is what Import does with a session that already exists in the
+// destination.
+type Conflict string
+
+const (
+	// ConflictFail stops the import with ErrSessionExists.
+	ConflictFail Conflict = "fail"
+	// ConflictSkip keeps the existing session and skips the imported one.
+	ConflictSkip Conflict = "skip"
+	// ConflictReplace deletes the existing session and imports the new one.
+	ConflictReplace Conflict = "replace"
+)
+
+const (
+	defaultSessionPageSize = 100
+	defaultEventPageSize   = 1000
+)
+
+type options struct {
+	userIDs         []string
+	sessionPageSize int
+	eventPageSize   int
+	conflict        Conflict
+	checkpoint      Checkpoint
+	verify          bool
+	now             func() time.Time
+}
+
+// Option configures Export, Import and Migrator. Options that do not apply
+// to an operation are ignored.
+type Option func(*options)
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		sessionPageSize: defaultSessionPageSize,
+		eventPageSize:   defaultEventPageSize,
+		conflict:        ConflictFail,
+		verify:          true,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithUserIDs sets the users whose sessions are exported or migrated. It is
+// required when the source does not implement session.UserLister, e.g. for
+// redis, and otherwise restricts the transfer to the given users.
+func WithUserIDs(userIDs ...string) Option {
+	return func(o *options) {
+		o.userIDs = append(o.userIDs, userIDs...)
+	}
+}
+
+// WithSessionPageSize sets how many sessions are listed per
+// ListSessions call. Defaults to 100.
+func WithSessionPageSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.sessionPageSize = n
+		}
+	}
+}
+
+// WithEventPageSize sets how many events are read per GetSession call on
+// backends that support event paging. Defaults to 1000.
+func WithEventPageSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.eventPageSize = n
+		}
+	}
+}
+
+// WithConflict sets what Import does with sessions that already exist.
+// Defaults to ConflictFail for Import and ConflictReplace for the Migrator.
+func WithConflict(c Conflict) Option {
+	return func(o *options) {
+		o.conflict = c
+	}
+}
+
+// WithCheckpoint makes the Migrator record its progress in c and resume
+// from it.
+func WithCheckpoint(c Checkpoint) Option {
+	return func(o *options) {
+		o.checkpoint = c
+	}
+}
+
+// WithVerify sets whether the Migrator reads every copied session back from
+// the destination and compares its counts with the source. Defaults to true.
+func WithVerify(verify bool) Option {
+	return func(o *options) {
+		o.verify = verify
+	}
+}
@@ -0,0 +1,310 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+const testApp = "app"
+
+func newEvent(msg model.Message, delta session.StateMap) *event.Event {
+	e := event.NewResponseEvent("inv", string(msg.Role), &model.Response{
+		Done:    true,
+		Choices: []model.Choice{{Message: msg}},
+	})
+	e.StateDelta = delta
+	return e
+}
+
+// seed fills svc with two users: u1 with a session of five events, a summary
+// and a track event, and u2 with an empty session.
+func seed(t *testing.T, svc *inmemory.SessionService) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, svc.UpdateAppState(ctx, testApp, session.StateMap{"theme": []byte("dark")}))
+	require.NoError(t, svc.UpdateUserState(ctx, session.UserKey{AppName: testApp, UserID: "u1"},
+		session.StateMap{"lang": []byte("go")}))
+
+	key := session.Key{AppName: testApp, UserID: "u1", SessionID: "s1"}
+	sess, err := svc.CreateSession(ctx, key, session.StateMap{"step": []byte("0")})
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		delta := session.StateMap{"step": []byte(fmt.Sprint(i))}
+		msg := model.NewUserMessage(fmt.Sprintf("message %d", i))
+		if i%2 == 0 {
+			msg = model.NewAssistantMessage(fmt.Sprintf("message %d", i))
+		}
+		require.NoError(t, svc.AppendEvent(ctx, sess, newEvent(msg, delta)))
+	}
+	require.NoError(t, svc.UpdateSessionState(ctx, key, session.StateMap{"step": []byte("final")}))
+	require.NoError(t, svc.PutSessionSummary(ctx, key, "", &session.Summary{Summary: "five messages", UpdatedAt: time.Now()}))
+	require.NoError(t, svc.AppendTrackEvent(ctx, sess, &session.TrackEvent{
+		Track: "metrics", Payload: json.RawMessage(`{"n":1}`), Timestamp: time.Now(),
+	}))
+
+	_, err = svc.CreateSession(ctx, session.Key{AppName: testApp, UserID: "u2", SessionID: "s2"}, nil)
+	require.NoError(t, err)
+}
+
+func assertSeeded(t *testing.T, svc *inmemory.SessionService) {
+	t.Helper()
+	ctx := context.Background()
+	appState, err := svc.ListAppStates(ctx, testApp)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("dark"), appState["theme"])
+
+	sess, err := svc.GetSession(ctx, session.Key{AppName: testApp, UserID: "u1", SessionID: "s1"})
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+	require.Len(t, sess.Events, 5)
+	assert.Equal(t, "message 1", sess.Events[0].Response.Choices[0].Message.Content)
+	assert.Equal(t, "message 5", sess.Events[4].Response.Choices[0].Message.Content)
+	step, _ := sess.GetState("step")
+	assert.Equal(t, []byte("final"), step)
+	lang, _ := sess.GetState(session.StateUserPrefix + "lang")
+	assert.Equal(t, []byte("go"), lang)
+	text, ok := svc.GetSessionSummaryText(ctx, sess)
+	require.True(t, ok)
+	assert.Equal(t, "five messages", text)
+	require.Contains(t, sess.Tracks, session.Track("metrics"))
+	assert.Len(t, sess.Tracks["metrics"].Events, 1)
+
+	sess, err = svc.GetSession(ctx, session.Key{AppName: testApp, UserID: "u2", SessionID: "s2"})
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	src := inmemory.NewSessionService()
+	defer src.Close()
+	seed(t, src)
+
+	var buf bytes.Buffer
+	stats, err := Export(context.Background(), pagedService{src}, &buf, testApp,
+		WithEventPageSize(2), WithSessionPageSize(1))
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Users: 2, Sessions: 2, Events: 5, Summaries: 1, TrackEvents: 1}, stats)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var header, footer Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &footer))
+	assert.Equal(t, RecordHeader, header.Type)
+	assert.Equal(t, Version, header.Version)
+	assert.Equal(t, RecordFooter, footer.Type)
+
+	dst := inmemory.NewSessionService()
+	defer dst.Close()
+	imported, err := Import(context.Background(), dst, bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, stats, imported)
+	assertSeeded(t, dst)
+}
+
+func TestImport_Conflict(t *testing.T) {
+	src := inmemory.NewSessionService()
+	defer src.Close()
+	seed(t, src)
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), src, &buf, testApp)
+	require.NoError(t, err)
+
+	dst := inmemory.NewSessionService()
+	defer dst.Close()
+	_, err = Import(context.Background(), dst, bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	_, err = Import(context.Background(), dst, bytes.NewReader(buf.Bytes()))
+	assert.ErrorIs(t, err, ErrSessionExists)
+
+	stats, err := Import(context.Background(), dst, bytes.NewReader(buf.Bytes()), WithConflict(ConflictSkip))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.SkippedSessions)
+	assert.Zero(t, stats.Events)
+
+	stats, err = Import(context.Background(), dst, bytes.NewReader(buf.Bytes()), WithConflict(ConflictReplace))
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Events)
+	assertSeeded(t, dst)
+}
+
+func TestImport_Corrupted(t *testing.T) {
+	src := inmemory.NewSessionService()
+	defer src.Close()
+	seed(t, src)
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), src, &buf, testApp)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(strings.TrimSpace(buf.String()), "\n")
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"truncated", strings.Join(lines[:len(lines)-1], ""), ErrCorrupted},
+		{"missing event", strings.Join(append(lines[:4:4], lines[5:]...), ""), ErrCorrupted},
+		{"no header", strings.Join(lines[1:], ""), ErrCorrupted},
+		{"newer version", `{"type":"header","version":2}` + "\n", ErrUnsupportedVersion},
+		{"garbage", lines[0] + "{not json", ErrCorrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := inmemory.NewSessionService()
+			defer dst.Close()
+			_, err := Import(context.Background(), dst, strings.NewReader(tt.input))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestExport_UsersRequired(t *testing.T) {
+	src := inmemory.NewSessionService()
+	defer src.Close()
+	seed(t, src)
+
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), pagedService{src}, &buf, testApp)
+	require.NoError(t, err)
+	_, err = Export(context.Background(), plainService{src}, &buf, testApp)
+	assert.ErrorIs(t, err, ErrUsersRequired)
+
+	buf.Reset()
+	stats, err := Export(context.Background(), plainService{src}, &buf, testApp, WithUserIDs("u2"))
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Users: 1, Sessions: 1}, stats)
+}
+
+func TestMigrator_ResumesAfterFailure(t *testing.T) {
+	src := inmemory.NewSessionService()
+	defer src.Close()
+	seed(t, src)
+	dst := inmemory.NewSessionService()
+	defer dst.Close()
+
+	checkpoint := NewFileCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+	flaky := &flakyService{SessionService: dst, failAt: 3}
+	m, err := NewMigrator(src, flaky, testApp, WithCheckpoint(checkpoint))
+	require.NoError(t, err)
+	_, err = m.Run(context.Background())
+	require.ErrorIs(t, err, errFlaky)
+
+	progress, err := checkpoint.Load(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, progress)
+	assert.True(t, progress.AppStateDone)
+	assert.Equal(t, "u1", progress.UserID)
+	assert.False(t, progress.Done)
+
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+	assert.Equal(t, Stats{Users: 2, Sessions: 2, Events: 5, Summaries: 1, TrackEvents: 1}, report.Stats)
+	assertSeeded(t, dst)
+
+	// A finished migration is not run again.
+	flaky.failAt, flaky.appends = 1, 0
+	again, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+	assert.Zero(t, flaky.appends)
+}
+
+func TestMigrator_ReportsMismatches(t *testing.T) {
+	src := inmemory.NewSessionService()
+	defer src.Close()
+	seed(t, src)
+	dst := inmemory.NewSessionService()
+	defer dst.Close()
+
+	m, err := NewMigrator(src, &droppingService{SessionService: dst}, testApp)
+	require.NoError(t, err)
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, Mismatch{
+		Key:  session.Key{AppName: testApp, UserID: "u1", SessionID: "s1"},
+		Kind: "events", Source: 5, Destination: 0,
+	}, report.Mismatches[0])
+
+	_, err = NewMigrator(src, nil, testApp)
+	assert.Error(t, err)
+}
+
+// pagedService adds event paging to a service that lacks it.
+type pagedService struct {
+	*inmemory.SessionService
+}
+
+func (s pagedService) GetSession(ctx context.Context, key session.Key, opts ...session.Option) (*session.Session, error) {
+	o := &session.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.EventPage == nil {
+		return s.SessionService.GetSession(ctx, key, opts...)
+	}
+	sess, err := s.SessionService.GetSession(ctx, key)
+	if err != nil || sess == nil {
+		return sess, err
+	}
+	end := max(len(sess.Events)-o.EventPage.Offset, 0)
+	start := max(end-o.EventPage.Limit, 0)
+	sess.Events = sess.Events[start:end]
+	return sess, nil
+}
+
+// plainService hides the optional interfaces of a service.
+type plainService struct {
+	session.Service
+}
+
+var errFlaky = errors.New("flaky append")
+
+// flakyService fails the failAt-th AppendEvent once.
+type flakyService struct {
+	*inmemory.SessionService
+	failAt  int
+	appends int
+}
+
+func (s *flakyService) AppendEvent(ctx context.Context, sess *session.Session, e *event.Event, opts ...session.Option) error {
+	s.appends++
+	if s.appends == s.failAt {
+		return errFlaky
+	}
+	return s.SessionService.AppendEvent(ctx, sess, e, opts...)
+}
+
+// droppingService loses every event.
+type droppingService struct {
+	*inmemory.SessionService
+}
+
+func (s *droppingService) AppendEvent(context.Context, *session.Session, *event.Event, ...session.Option) error {
+	return nil
+}
@@ -59,6 +59,34 @@ const (
 
 	// MetricTRPCAgentGoClientRequestCnt represents the request count for client.
 	MetricTRPCAgentGoClientRequestCnt = "trpc_agent_go.client.request_cnt"
+	// MetricTRPCAgentGoClientCost represents the accumulated cost of model calls.
+	MetricTRPCAgentGoClientCost = "trpc_agent_go.client.cost"
+	// MetricTRPCAgentGoBudgetActionCnt represents the number of runs rejected or degraded by a budget.
+	MetricTRPCAgentGoBudgetActionCnt = "trpc_agent_go.budget.action_cnt"
+	// KeyTRPCAgentGoBudgetName represents the name of the budget that was exceeded.
+	KeyTRPCAgentGoBudgetName = "trpc_agent_go.budget.name"
+	// KeyTRPCAgentGoBudgetAction represents the action taken once a budget is exceeded.
+	KeyTRPCAgentGoBudgetAction = "trpc_agent_go.budget.action"
+	// MetricTRPCAgentGoModelCircuitBreakerStateChangeCnt represents the number of circuit breaker state changes.
+	MetricTRPCAgentGoModelCircuitBreakerStateChangeCnt = "trpc_agent_go.model.circuit_breaker.state_change_cnt"
+	// KeyTRPCAgentGoCircuitBreakerFromState represents the state a circuit breaker left.
+	KeyTRPCAgentGoCircuitBreakerFromState = "trpc_agent_go.circuit_breaker.from_state"
+	// KeyTRPCAgentGoCircuitBreakerToState represents the state a circuit breaker entered.
+	KeyTRPCAgentGoCircuitBreakerToState = "trpc_agent_go.circuit_breaker.to_state"
+	// MetricTRPCAgentGoGraphCacheHitCnt represents the number of graph node cache hits.
+	MetricTRPCAgentGoGraphCacheHitCnt = "trpc_agent_go.graph.cache.hit_cnt"
+	// MetricTRPCAgentGoGraphCacheMissCnt represents the number of graph node cache misses.
+	MetricTRPCAgentGoGraphCacheMissCnt = "trpc_agent_go.graph.cache.miss_cnt"
+	// MetricTRPCAgentGoGraphCacheSetCnt represents the number of graph node cache entries stored.
+	MetricTRPCAgentGoGraphCacheSetCnt = "trpc_agent_go.graph.cache.set_cnt"
+	// MetricTRPCAgentGoGraphCacheErrorCnt represents the number of failed graph node cache operations.
+	MetricTRPCAgentGoGraphCacheErrorCnt = "trpc_agent_go.graph.cache.error_cnt"
+	// KeyTRPCAgentGoGraphCacheBackend represents the backend of a graph node cache.
+	KeyTRPCAgentGoGraphCacheBackend = "trpc_agent_go.graph.cache.backend"
+	// KeyTRPCAgentGoGraphCacheNamespace represents the namespace of a graph node cache access.
+	KeyTRPCAgentGoGraphCacheNamespace = "trpc_agent_go.graph.cache.namespace"
+	// KeyTRPCAgentGoGraphCacheOperation represents the graph node cache operation that failed.
+	KeyTRPCAgentGoGraphCacheOperation = "trpc_agent_go.graph.cache.operation"
 
 	////////////////////////// server ////////////////////////
 
@@ -79,4 +107,10 @@ const (
 	MeterNameWorkflow = "trpc_agent_go.internal.workflow"
 	// MeterNameInvokeAgent is the meter name for invoke agent operations.
 	MeterNameInvokeAgent = "trpc_agent_go.internal.invoke_agent"
+	// MeterNameCost is the meter name for cost accounting.
+	MeterNameCost = "trpc_agent_go.internal.cost"
+	// MeterNameCircuitBreaker is the meter name for model circuit breakers.
+	MeterNameCircuitBreaker = "trpc_agent_go.internal.circuit_breaker"
+	// MeterNameGraphCache is the meter name for graph node caches.
+	MeterNameGraphCache = "trpc_agent_go.internal.graph_cache"
 )